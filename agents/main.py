@@ -4,12 +4,13 @@ import logging
 from datetime import datetime, timedelta
 from fastapi import FastAPI, HTTPException
 from pydantic import BaseModel
-from typing import Optional
+from typing import Optional, Dict, Any, List
 import uvicorn
 
 from src.agents.orchestrator import JobApplicationOrchestratorAgent
 from src.services.gmail_service import GmailService
 from src.services.claude_service import ClaudeService
+from src.rpc.server import create_server
 
 # Configure logging
 logging.basicConfig(level=logging.INFO)
@@ -24,6 +25,11 @@ class ProcessJobApplicationsRequest(BaseModel):
     output_file_path: str
     end_date: Optional[str] = None
     append_mode: bool = False
+    analytics: Optional[Dict[str, Any]] = None  # Snapshot computed by the backend analytics service
+    timezone: Optional[str] = None  # User's IANA timezone, e.g. America/Los_Angeles
+    locale: Optional[str] = None  # User's locale for labels and date formatting, e.g. en-GB
+    resumes: Optional[List[Dict[str, str]]] = None  # Resume used per application: {company, position, resume}
+    companies: Optional[List[Dict[str, str]]] = None  # Company dossiers: {company, interviewProcess, culture, contacts, notes}
 
 class ProcessingResponse(BaseModel):
     success: bool
@@ -38,11 +44,12 @@ class ProcessingResponse(BaseModel):
 gmail_service = None
 claude_service = None
 orchestrator = None
+grpc_server = None
 
 @app.on_event("startup")
 async def startup_event():
     """Initialize services on startup."""
-    global gmail_service, claude_service, orchestrator
+    global gmail_service, claude_service, orchestrator, grpc_server
     
     try:
         # Initialize Claude service
@@ -53,6 +60,12 @@ async def startup_event():
         claude_service = ClaudeService(claude_api_key)
         logger.info("Claude service initialized")
         
+        # gRPC server used by the backend (see shared/proto/agents.proto)
+        grpc_port = int(os.getenv("AGENTS_GRPC_PORT", "50051"))
+        grpc_server = create_server(claude_service, grpc_port)
+        grpc_server.start()
+        logger.info(f"gRPC server listening on port {grpc_port}")
+        
         # Gmail service will be initialized when first used
         # (since it requires MCP tools to be available)
         
@@ -62,6 +75,12 @@ async def startup_event():
         logger.error(f"Error during startup: {e}")
         raise
 
+@app.on_event("shutdown")
+async def shutdown_event():
+    """Stop the gRPC server, letting in-flight calls finish."""
+    if grpc_server is not None:
+        grpc_server.stop(grace=10).wait()
+
 @app.get("/health")
 async def health_check():
     """Health check endpoint."""
@@ -70,7 +89,8 @@ async def health_check():
         "timestamp": datetime.now().isoformat(),
         "services": {
             "claude": claude_service is not None,
-            "gmail": gmail_service is not None
+            "gmail": gmail_service is not None,
+            "grpc": grpc_server is not None
         }
     }
 
@@ -101,7 +121,12 @@ async def process_job_applications(request: ProcessJobApplicationsRequest):
             start_date=start_date,
             output_file_path=request.output_file_path,
             end_date=end_date,
-            append_mode=request.append_mode
+            append_mode=request.append_mode,
+            analytics=request.analytics,
+            timezone=request.timezone,
+            locale=request.locale,
+            resumes=request.resumes,
+            companies=request.companies
         )
         
         # Generate summary
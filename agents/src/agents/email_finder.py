@@ -5,6 +5,8 @@ from typing import List, Dict, Any, Optional
 from dataclasses import dataclass
 import logging
 
+from .language_detector import LanguageDetectorAgent, job_keywords
+
 @dataclass
 class EmailSearchResult:
     message_id: str
@@ -14,12 +16,14 @@ class EmailSearchResult:
     date: datetime
     snippet: str
     body: str
+    language: str = 'en'  # ISO 639-1 code detected from subject and body
 
 class EmailFinderAgent:
     """Agent responsible for finding job application related emails from Gmail."""
     
     def __init__(self, gmail_service):
         self.gmail_service = gmail_service
+        self.language_detector = LanguageDetectorAgent()
         self.logger = logging.getLogger(__name__)
         
         # Keywords that indicate job application emails
@@ -85,6 +89,8 @@ class EmailFinderAgent:
                         snippet=message.get('snippet', ''),
                         body=self._extract_body(thread_content)
                     )
+                    email_result.language = self.language_detector.detect(
+                        f"{email_result.subject}\n{email_result.body}")
                     
                     if self._is_job_related(email_result):
                         job_emails.append(email_result)
@@ -167,8 +173,9 @@ class EmailFinderAgent:
         """Determine if email is actually job application related."""
         text_to_check = f"{email.subject} {email.body} {email.sender}".lower()
         
-        # Check for job-related keywords
-        keyword_matches = sum(1 for keyword in self.job_keywords if keyword in text_to_check)
+        # Check for job-related keywords, including those of the email's language
+        keywords = self.job_keywords + job_keywords(email.language)
+        keyword_matches = sum(1 for keyword in keywords if keyword in text_to_check)
         
         # Check for job domains
         domain_matches = sum(1 for domain in self.job_domains if domain in email.sender.lower())
@@ -1,10 +1,13 @@
 # agents/src/agents/email_parser.py
 import re
 from datetime import datetime
+from zoneinfo import ZoneInfo, ZoneInfoNotFoundError
 from typing import Dict, Any, Optional, List
 from dataclasses import dataclass
 import logging
 
+from .language_detector import LanguageDetectorAgent, status_keywords
+
 @dataclass
 class JobApplicationData:
     company: str
@@ -16,12 +19,14 @@ class JobApplicationData:
     job_id: str
     status_link: str
     email_content: str  # For summarization
+    language: str = 'en'  # ISO 639-1 code of the email
 
 class EmailParserAgent:
     """Agent responsible for parsing job application data from emails."""
     
     def __init__(self):
         self.logger = logging.getLogger(__name__)
+        self.language_detector = LanguageDetectorAgent()
         
         # Regex patterns for extracting information
         self.company_patterns = [
@@ -65,26 +70,28 @@ class EmailParserAgent:
             'smartrecruiters.com': 'SmartRecruiters'
         }
 
-    def parse_email(self, email_result) -> JobApplicationData:
-        """Parse job application data from email."""
+    def parse_email(self, email_result, timezone: Optional[str] = None) -> JobApplicationData:
+        """Parse job application data from email, dating it in the user's timezone."""
         try:
             full_text = f"{email_result.subject}\n{email_result.body}"
+            language = getattr(email_result, 'language', None) or self.language_detector.detect(full_text)
             
             return JobApplicationData(
                 company=self._extract_company(email_result.sender, full_text),
                 position=self._extract_position(email_result.subject, full_text),
-                applied_date=self._format_date(email_result.date),
-                status=self._extract_status(full_text),
+                applied_date=self._format_date(email_result.date, timezone),
+                status=self._extract_status(full_text, language),
                 source=self._extract_source(email_result.sender, full_text),
                 location=self._extract_location(full_text),
                 job_id=self._extract_job_id(full_text),
                 status_link=self._extract_links(full_text),
-                email_content=full_text[:1000]  # First 1000 chars for summarization
+                email_content=full_text[:1000],  # First 1000 chars for summarization
+                language=language
             )
             
         except Exception as e:
             self.logger.error(f"Error parsing email: {e}")
-            return self._create_fallback_data(email_result)
+            return self._create_fallback_data(email_result, timezone)
 
     def _extract_company(self, sender: str, text: str) -> str:
         """Extract company name from sender or email content."""
@@ -153,10 +160,16 @@ class EmailParserAgent:
         
         return "Software Engineer Intern"  # Default fallback
 
-    def _extract_status(self, text: str) -> str:
+    def _extract_status(self, text: str, language: str = 'en') -> str:
         """Extract application status from email content."""
         text_lower = text.lower()
         
+        # Check indicators in the email's own language first
+        if language != 'en':
+            for status, keywords in status_keywords(language).items():
+                if any(keyword in text_lower for keyword in keywords):
+                    return status
+        
         # Check for specific status indicators
         if any(word in text_lower for word in ['unfortunately', 'regret', 'sorry to inform', 'not selected', 'declined']):
             return 'Rejected'
@@ -251,16 +264,21 @@ class EmailParserAgent:
         
         return ''
 
-    def _format_date(self, date: datetime) -> str:
-        """Format date to YYYY-MM-DD."""
+    def _format_date(self, date: datetime, timezone: Optional[str] = None) -> str:
+        """Format date to YYYY-MM-DD, converting timezone-aware dates to the given IANA timezone."""
+        if timezone and date.tzinfo is not None:
+            try:
+                date = date.astimezone(ZoneInfo(timezone))
+            except ZoneInfoNotFoundError:
+                self.logger.warning(f"Unknown timezone {timezone}, using the email's own offset")
         return date.strftime("%Y-%m-%d")
 
-    def _create_fallback_data(self, email_result) -> JobApplicationData:
+    def _create_fallback_data(self, email_result, timezone: Optional[str] = None) -> JobApplicationData:
         """Create fallback data structure when parsing fails."""
         return JobApplicationData(
             company="Unknown Company",
             position="Software Engineer Intern",
-            applied_date=self._format_date(email_result.date),
+            applied_date=self._format_date(email_result.date, timezone),
             status="Applied",
             source="Email",
             location="",
@@ -4,6 +4,46 @@ from datetime import datetime
 from typing import List, Dict, Any, Optional
 import os
 import logging
+from zoneinfo import ZoneInfo, ZoneInfoNotFoundError
+
+from .translations import translate, translate_status
+
+# Excel date formats by locale; a full tag (en-GB) wins over its language (en)
+DATE_FORMATS = {
+    'en-US': 'MM/DD/YYYY',
+    'en-GB': 'DD/MM/YYYY',
+    'en-AU': 'DD/MM/YYYY',
+    'en-IN': 'DD/MM/YYYY',
+    'en-CA': 'YYYY-MM-DD',
+    'en': 'MM/DD/YYYY',
+    'de': 'DD.MM.YYYY',
+    'fr': 'DD/MM/YYYY',
+    'es': 'DD/MM/YYYY',
+    'it': 'DD/MM/YYYY',
+    'pt': 'DD/MM/YYYY',
+    'nl': 'DD-MM-YYYY',
+    'pl': 'DD.MM.YYYY',
+    'ru': 'DD.MM.YYYY',
+    'sv': 'YYYY-MM-DD',
+    'ja': 'YYYY/MM/DD',
+    'zh': 'YYYY/MM/DD',
+    'ko': 'YYYY.MM.DD',
+}
+DEFAULT_DATE_FORMAT = 'YYYY-MM-DD'
+
+# Label keys of the main sheet's columns
+COLUMN_LABELS = {
+    'Company': 'column.company',
+    'Position': 'column.position',
+    'Applied Date': 'column.applied_date',
+    'Status': 'column.status',
+    'Source': 'column.source',
+    'Location': 'column.location',
+    'Job ID': 'column.job_id',
+    'Status Link': 'column.status_link',
+    'Notes': 'column.notes',
+    'Resume': 'column.resume',
+}
 
 class ExcelWriterAgent:
     """Agent responsible for writing job application data to Excel files."""
@@ -21,14 +61,19 @@ class ExcelWriterAgent:
             'Location',
             'Job ID',
             'Status Link',
-            'Notes'
+            'Notes',
+            'Resume'
         ]
 
     def write_to_excel(self, 
                       job_applications: List[Dict[str, Any]], 
                       file_path: str, 
-                      overwrite: bool = False) -> bool:
-        """Write job applications to Excel file."""
+                      overwrite: bool = False,
+                      analytics: Optional[Dict[str, Any]] = None,
+                      locale: Optional[str] = None,
+                      timezone: Optional[str] = None,
+                      companies: Optional[List[Dict[str, str]]] = None) -> bool:
+        """Write job applications to Excel file, with optional analytics and company dossier sheets."""
         try:
             # Check if file exists and handle overwrite logic
             if os.path.exists(file_path) and not overwrite:
@@ -44,7 +89,7 @@ class ExcelWriterAgent:
             df = self._create_dataframe(job_applications)
             
             # Write to Excel with proper formatting
-            self._write_excel_file(df, file_path)
+            self._write_excel_file(df, file_path, analytics, locale, timezone, companies)
             
             self.logger.info(f"Successfully wrote {len(job_applications)} job applications to {file_path}")
             return True
@@ -55,14 +100,18 @@ class ExcelWriterAgent:
 
     def append_to_excel(self, 
                        job_applications: List[Dict[str, Any]], 
-                       file_path: str) -> bool:
+                       file_path: str,
+                       analytics: Optional[Dict[str, Any]] = None,
+                       locale: Optional[str] = None,
+                       timezone: Optional[str] = None,
+                       companies: Optional[List[Dict[str, str]]] = None) -> bool:
         """Append new job applications to existing Excel file."""
         try:
             existing_data = []
             
             # Read existing data if file exists
             if os.path.exists(file_path):
-                existing_df = pd.read_excel(file_path)
+                existing_df = pd.read_excel(file_path, sheet_name=0)
                 existing_data = existing_df.to_dict('records')
             
             # Combine existing and new data
@@ -72,7 +121,8 @@ class ExcelWriterAgent:
             all_applications = self._remove_duplicates(all_applications)
             
             # Write combined data
-            return self.write_to_excel(all_applications, file_path, overwrite=True)
+            return self.write_to_excel(all_applications, file_path, overwrite=True, analytics=analytics,
+                                       locale=locale, timezone=timezone, companies=companies)
             
         except Exception as e:
             self.logger.error(f"Error appending to Excel: {e}")
@@ -105,6 +155,8 @@ class ExcelWriterAgent:
                     row[col] = app.get('status_link', '')
                 elif col == 'Notes':
                     row[col] = app.get('summary', '')  # AI-generated summary
+                elif col == 'Resume':
+                    row[col] = app.get('resume', '')  # Resume version recorded in the backend
                 else:
                     row[col] = ''
             
@@ -120,7 +172,13 @@ class ExcelWriterAgent:
         
         return df
 
-    def _write_excel_file(self, df: pd.DataFrame, file_path: str):
+    def _write_excel_file(self,
+                          df: pd.DataFrame,
+                          file_path: str,
+                          analytics: Optional[Dict[str, Any]] = None,
+                          locale: Optional[str] = None,
+                          timezone: Optional[str] = None,
+                          companies: Optional[List[Dict[str, str]]] = None):
         """Write DataFrame to Excel with formatting."""
         with pd.ExcelWriter(file_path, engine='openpyxl') as writer:
             # Write the main data
@@ -149,10 +207,126 @@ class ExcelWriterAgent:
             header_font = Font(bold=True)
             header_fill = PatternFill(start_color="CCCCCC", end_color="CCCCCC", fill_type="solid")
             
-            for col in range(1, len(self.columns) + 1):
+            for col, name in enumerate(self.columns, start=1):
                 cell = worksheet.cell(row=1, column=col)
+                cell.value = translate(COLUMN_LABELS[name], locale)
                 cell.font = header_font
                 cell.fill = header_fill
+            
+            status_col = self.columns.index('Status') + 1
+            for row in range(2, worksheet.max_row + 1):
+                cell = worksheet.cell(row=row, column=status_col)
+                if isinstance(cell.value, str):
+                    cell.value = translate_status(cell.value, locale)
+            
+            # Store applied dates as real dates shown in the user's locale format
+            date_format = self._date_format(locale)
+            date_col = self.columns.index('Applied Date') + 1
+            for row in range(2, worksheet.max_row + 1):
+                cell = worksheet.cell(row=row, column=date_col)
+                if isinstance(cell.value, str) and cell.value:
+                    try:
+                        cell.value = datetime.strptime(cell.value, '%Y-%m-%d').date()
+                        cell.number_format = date_format
+                    except ValueError:
+                        pass
+            
+            if analytics:
+                self._write_analytics_sheet(writer, analytics, timezone, locale)
+            
+            if companies:
+                self._write_companies_sheet(writer, companies, locale)
+
+    def _date_format(self, locale: Optional[str]) -> str:
+        """Excel number format for dates in the given locale."""
+        if not locale:
+            return DEFAULT_DATE_FORMAT
+        locale = locale.replace('_', '-')
+        return DATE_FORMATS.get(locale) or DATE_FORMATS.get(locale.split('-')[0], DEFAULT_DATE_FORMAT)
+
+    def _strftime(self, locale: Optional[str]) -> str:
+        """strftime format for dates in the given locale."""
+        return self._date_format(locale).replace('YYYY', '%Y').replace('MM', '%m').replace('DD', '%d')
+
+    def _write_analytics_sheet(self, writer: pd.ExcelWriter, analytics: Dict[str, Any], timezone: Optional[str] = None,
+                               locale: Optional[str] = None):
+        """Write the backend's analytics snapshot as stacked tables on an 'Analytics' sheet."""
+        from openpyxl.styles import Font
+        
+        sections = [
+            ('section.funnel', analytics.get('funnel') or [], {
+                'stage': 'header.stage', 'count': 'header.applications', 'rate': 'header.rate'}),
+            ('section.weekly_trend', analytics.get('weeklyTrend') or [], {
+                'weekStart': 'header.week_of', 'applications': 'header.applications', 'responses': 'header.responses'}),
+            ('section.time_in_stage', analytics.get('timeInStage') or [], {
+                'stage': 'header.stage', 'count': 'header.transitions', 'medianHours': 'header.median_hours',
+                'p90Hours': 'header.p90_hours'}),
+            ('section.sources', analytics.get('sources') or [], {
+                'channel': 'header.channel', 'applications': 'header.applications', 'responseRate': 'header.response_rate',
+                'interviewRate': 'header.interview_rate', 'offerRate': 'header.offer_rate'}),
+        ]
+        offers = analytics.get('offers') or {}
+        if offers.get('offers'):
+            sections.append(('section.offers', offers['offers'], {
+                'company': 'column.company', 'position': 'column.position', 'baseSalary': 'header.base',
+                'annualTotal': 'header.annual_total', 'signingBonus': 'header.signing_bonus',
+                'originalCurrency': 'header.offered_in', 'vsMedian': 'header.vs_median'}))
+        
+        sheet_name = translate('sheet.analytics', locale)
+        row = 0
+        for title_key, records, columns in sections:
+            title = translate(title_key, locale, currency=offers.get('currency', ''))
+            labels = {field: translate(key, locale) for field, key in columns.items()}
+            section_df = pd.DataFrame(records, columns=list(columns.keys())).rename(columns=labels)
+            if 'stage' in columns:
+                section_df[labels['stage']] = section_df[labels['stage']].map(lambda s: translate_status(s, locale))
+            section_df.to_excel(writer, sheet_name=sheet_name, index=False, startrow=row + 1)
+            
+            worksheet = writer.sheets[sheet_name]
+            title_cell = worksheet.cell(row=row + 1, column=1, value=title)
+            title_cell.font = Font(bold=True, size=12)
+            for col in range(1, len(columns) + 1):
+                worksheet.cell(row=row + 2, column=col).font = Font(bold=True)
+            
+            # Leave a blank row between sections
+            row += len(section_df) + 3
+        
+        worksheet = writer.sheets[sheet_name]
+        generated_at = analytics.get('generatedAt') or datetime.now().astimezone().isoformat()
+        if timezone:
+            try:
+                generated = datetime.fromisoformat(generated_at.replace('Z', '+00:00'))
+                if generated.tzinfo is not None:
+                    generated_at = generated.astimezone(ZoneInfo(timezone)).strftime(self._strftime(locale) + ' %H:%M %Z')
+            except (ValueError, ZoneInfoNotFoundError):
+                pass
+        worksheet.cell(row=row + 1, column=1, value=translate('generated', locale, when=generated_at))
+        for column in worksheet.columns:
+            worksheet.column_dimensions[column[0].column_letter].width = 18
+
+    def _write_companies_sheet(self, writer: pd.ExcelWriter, companies: List[Dict[str, str]],
+                               locale: Optional[str] = None):
+        """Write the user's company dossiers, one company per row, on a 'Companies' sheet."""
+        from openpyxl.styles import Alignment, Font
+        
+        columns = {
+            field: translate(key, locale) for field, key in {
+                'company': 'column.company', 'interviewProcess': 'header.interview_process',
+                'culture': 'header.culture', 'contacts': 'header.contacts', 'notes': 'column.notes'}.items()}
+        sheet_name = translate('sheet.companies', locale)
+        df = pd.DataFrame(companies, columns=list(columns.keys())).rename(columns=columns)
+        df.to_excel(writer, sheet_name=sheet_name, index=False)
+        
+        worksheet = writer.sheets[sheet_name]
+        for col in range(1, len(columns) + 1):
+            worksheet.cell(row=1, column=col).font = Font(bold=True)
+        # Dossiers are free text; wrap it instead of stretching the columns
+        for row in worksheet.iter_rows(min_row=2):
+            for cell in row:
+                cell.alignment = Alignment(wrap_text=True, vertical='top')
+        for column in worksheet.columns:
+            letter = column[0].column_letter
+            worksheet.column_dimensions[letter].width = 24 if letter == 'A' else 50
 
     def _remove_duplicates(self, applications: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
         """Remove duplicate applications based on key fields."""
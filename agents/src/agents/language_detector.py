@@ -0,0 +1,150 @@
+# agents/src/agents/language_detector.py
+import re
+from typing import Dict, List, Optional, Tuple
+import logging
+
+# Scripts that identify a language on their own (checked before stopwords)
+SCRIPT_PATTERNS = [
+    ('ko', re.compile(r'[가-힯ᄀ-ᇿ]')),  # Hangul
+    ('ja', re.compile(r'[぀-ヿ]')),  # Hiragana / Katakana
+    ('zh', re.compile(r'[一-鿿]')),  # Han without kana
+    ('ru', re.compile(r'[Ѐ-ӿ]')),  # Cyrillic
+    ('ar', re.compile(r'[؀-ۿ]')),  # Arabic
+    ('he', re.compile(r'[֐-׿]')),  # Hebrew
+]
+
+# Frequent function words for Latin-script languages
+STOPWORDS = {
+    'en': {'the', 'and', 'you', 'your', 'for', 'with', 'thank', 'have', 'this', 'that', 'we', 'our', 'to', 'of'},
+    'de': {'und', 'der', 'die', 'das', 'sie', 'ihre', 'ihr', 'wir', 'nicht', 'mit', 'für', 'vielen', 'dank', 'ist', 'bei'},
+    'fr': {'et', 'le', 'la', 'les', 'vous', 'votre', 'nous', 'pour', 'avec', 'des', 'une', 'est', 'merci', 'candidature'},
+    'es': {'y', 'el', 'la', 'los', 'las', 'usted', 'su', 'para', 'con', 'una', 'gracias', 'por', 'nos', 'que'},
+    'it': {'e', 'il', 'la', 'di', 'che', 'per', 'con', 'una', 'grazie', 'vostra', 'sua', 'siamo', 'non'},
+    'pt': {'e', 'o', 'a', 'os', 'as', 'você', 'sua', 'para', 'com', 'uma', 'obrigado', 'obrigada', 'não', 'que'},
+    'nl': {'en', 'de', 'het', 'een', 'je', 'jij', 'u', 'uw', 'wij', 'voor', 'met', 'bedankt', 'niet', 'van'},
+}
+
+# Job-related keywords per language, used alongside the English keyword lists
+JOB_KEYWORDS = {
+    'de': ['bewerbung', 'vorstellungsgespräch', 'stelle', 'position', 'karriere', 'absage', 'zusage', 'angebot'],
+    'fr': ['candidature', 'entretien', 'poste', 'offre', 'recrutement', 'carrière'],
+    'es': ['candidatura', 'solicitud', 'entrevista', 'puesto', 'oferta', 'vacante'],
+    'it': ['candidatura', 'colloquio', 'posizione', 'offerta', 'selezione'],
+    'pt': ['candidatura', 'entrevista', 'vaga', 'oferta', 'processo seletivo'],
+    'nl': ['sollicitatie', 'gesprek', 'vacature', 'functie', 'aanbod'],
+    'ko': ['지원', '면접', '채용', '합격', '불합격', '서류', '입사'],
+    'ja': ['応募', '面接', '選考', '採用', '内定', '書類'],
+    'zh': ['申请', '面试', '职位', '招聘', '录用', '简历'],
+    'ru': ['вакансия', 'собеседование', 'резюме', 'отклик', 'предложение'],
+}
+
+# Status indicators per language, checked in the same order as the English ones
+STATUS_KEYWORDS = {
+    'Rejected': {
+        'de': ['leider', 'absage', 'nicht berücksichtigen', 'anderen kandidaten'],
+        'fr': ['malheureusement', 'regret', 'pas retenue', 'pas été retenue', 'autre candidat'],
+        'es': ['lamentablemente', 'desafortunadamente', 'no ha sido seleccionad'],
+        'it': ['purtroppo', 'non è stata selezionata', 'altri candidati'],
+        'pt': ['infelizmente', 'não foi selecionad'],
+        'nl': ['helaas', 'afwijzing', 'andere kandidaat'],
+        'ko': ['불합격', '아쉽게도', '유감스럽게도', '함께하지 못'],
+        'ja': ['残念ながら', '不採用', 'お見送り'],
+        'zh': ['很遗憾', '未能通过', '不合适'],
+        'ru': ['к сожалению', 'отказ'],
+    },
+    'Offer': {
+        'de': ['herzlichen glückwunsch', 'zusage', 'vertragsangebot', 'angebot'],
+        'fr': ['félicitations', 'offre d\'emploi', 'proposition'],
+        'es': ['felicidades', 'enhorabuena', 'oferta de trabajo'],
+        'it': ['congratulazioni', 'offerta di lavoro'],
+        'pt': ['parabéns', 'proposta'],
+        'nl': ['gefeliciteerd', 'aanbod'],
+        'ko': ['최종 합격', '합격', '축하드립니다', '처우'],
+        'ja': ['内定', 'おめでとう'],
+        'zh': ['恭喜', '录用通知', 'offer'],
+        'ru': ['поздравляем', 'предложение о работе'],
+    },
+    'Interview': {
+        'de': ['vorstellungsgespräch', 'interview', 'kennenlernen', 'termin'],
+        'fr': ['entretien', 'rendez-vous'],
+        'es': ['entrevista'],
+        'it': ['colloquio'],
+        'pt': ['entrevista'],
+        'nl': ['gesprek', 'sollicitatiegesprek'],
+        'ko': ['면접', '인터뷰'],
+        'ja': ['面接', '面談'],
+        'zh': ['面试'],
+        'ru': ['собеседование', 'интервью'],
+    },
+    'Applied': {
+        'de': ['eingegangen', 'erhalten', 'vielen dank für ihre bewerbung'],
+        'fr': ['bien reçu', 'avons reçu', 'merci pour votre candidature'],
+        'es': ['hemos recibido', 'gracias por tu solicitud', 'gracias por su candidatura'],
+        'it': ['abbiamo ricevuto', 'grazie per la candidatura'],
+        'pt': ['recebemos', 'obrigado pela candidatura'],
+        'nl': ['ontvangen', 'bedankt voor je sollicitatie'],
+        'ko': ['접수', '지원해 주셔서 감사'],
+        'ja': ['受け付け', 'ご応募ありがとう'],
+        'zh': ['已收到', '感谢您的申请'],
+        'ru': ['получили', 'спасибо за отклик'],
+    },
+}
+
+LANGUAGE_NAMES = {
+    'en': 'English', 'de': 'German', 'fr': 'French', 'es': 'Spanish', 'it': 'Italian',
+    'pt': 'Portuguese', 'nl': 'Dutch', 'ko': 'Korean', 'ja': 'Japanese', 'zh': 'Chinese',
+    'ru': 'Russian', 'ar': 'Arabic', 'he': 'Hebrew',
+}
+
+class LanguageDetectorAgent:
+    """Agent responsible for detecting the language of an email."""
+    
+    def __init__(self, default_language: str = 'en'):
+        self.default_language = default_language
+        self.logger = logging.getLogger(__name__)
+
+    def detect(self, text: str) -> str:
+        """Detect the ISO 639-1 language code of the text."""
+        language, _ = self.detect_with_confidence(text)
+        return language
+
+    def detect_with_confidence(self, text: str) -> Tuple[str, float]:
+        """Detect the language and a 0-1 confidence score."""
+        if not text or not text.strip():
+            return self.default_language, 0.0
+        
+        sample = text[:5000]
+        
+        # Script detection: count characters of each distinctive script
+        letters = sum(1 for c in sample if c.isalpha()) or 1
+        script_counts = [(lang, len(pattern.findall(sample))) for lang, pattern in SCRIPT_PATTERNS]
+        # Japanese text mixes kana with Han characters; treat any kana as Japanese
+        kana = dict(script_counts)['ja']
+        for lang, count in script_counts:
+            if lang == 'zh' and kana:
+                continue
+            if count / letters >= 0.2:
+                return lang, min(1.0, count / letters + 0.3)
+        
+        # Stopword scoring for Latin-script text
+        words = re.findall(r"[a-zà-öø-ÿß]+", sample.lower())
+        if not words:
+            return self.default_language, 0.0
+        scores = {lang: sum(1 for w in words if w in stopwords) for lang, stopwords in STOPWORDS.items()}
+        best = max(scores, key=scores.get)
+        total = sum(scores.values())
+        if scores[best] == 0:
+            return self.default_language, 0.0
+        return best, round(scores[best] / total, 2)
+
+    def language_name(self, code: str) -> str:
+        """English name of a language code, for prompts."""
+        return LANGUAGE_NAMES.get(code, code)
+
+def job_keywords(language: Optional[str]) -> List[str]:
+    """Job-related keywords for a non-English language (empty for English)."""
+    return JOB_KEYWORDS.get(language or '', [])
+
+def status_keywords(language: Optional[str]) -> Dict[str, List[str]]:
+    """Status indicators for a non-English language, keyed by status."""
+    return {status: by_language.get(language or '', []) for status, by_language in STATUS_KEYWORDS.items()}
@@ -27,7 +27,12 @@ class JobApplicationOrchestratorAgent:
                                start_date: datetime,
                                output_file_path: str,
                                end_date: Optional[datetime] = None,
-                               append_mode: bool = False) -> Dict[str, Any]:
+                               append_mode: bool = False,
+                               analytics: Optional[Dict[str, Any]] = None,
+                               timezone: Optional[str] = None,
+                               locale: Optional[str] = None,
+                               resumes: Optional[List[Dict[str, str]]] = None,
+                               companies: Optional[List[Dict[str, str]]] = None) -> Dict[str, Any]:
         """
         Main workflow to process job applications from Gmail to Excel.
         
@@ -36,6 +41,11 @@ class JobApplicationOrchestratorAgent:
             output_file_path: Path where Excel file will be created
             end_date: End date for email search (defaults to now)
             append_mode: Whether to append to existing file or overwrite
+            analytics: Optional analytics snapshot to embed as a separate sheet
+            timezone: User's IANA timezone, used to date emails and timestamps
+            locale: User's locale (e.g. en-GB), used for labels and date formatting in the sheet
+            resumes: Resume versions sent per application, from the backend, for the Resume column
+            companies: The user's company dossiers, from the backend, written to a Companies sheet
         
         Returns:
             Dict with processing results and statistics
@@ -73,7 +83,7 @@ class JobApplicationOrchestratorAgent:
                     self.logger.info(f"Processing email {i+1}/{len(emails)}: {email.subject[:50]}...")
                     
                     # Parse email content
-                    job_data = self.email_parser.parse_email(email)
+                    job_data = self.email_parser.parse_email(email, timezone)
                     
                     # Generate summary
                     summary = self.summarizer.summarize_email(job_data)
@@ -96,16 +106,30 @@ class JobApplicationOrchestratorAgent:
                 results['errors'].append("Failed to process any job applications")
                 return results
             
+            if resumes:
+                self._attach_resumes(job_applications, resumes)
+            
             # Step 3: Write to Excel
             self.logger.info(f"Step 3: Writing {len(job_applications)} applications to Excel...")
             
             if append_mode:
-                write_success = self.excel_writer.append_to_excel(job_applications, output_file_path)
+                write_success = self.excel_writer.append_to_excel(
+                    job_applications,
+                    output_file_path,
+                    analytics=analytics,
+                    locale=locale,
+                    timezone=timezone,
+                    companies=companies
+                )
             else:
                 write_success = self.excel_writer.write_to_excel(
                     job_applications, 
                     output_file_path, 
-                    overwrite=True
+                    overwrite=True,
+                    analytics=analytics,
+                    locale=locale,
+                    timezone=timezone,
+                    companies=companies
                 )
             
             if write_success:
@@ -123,6 +147,23 @@ class JobApplicationOrchestratorAgent:
         
         return results
 
+    def _attach_resumes(self, job_applications: List[Dict[str, Any]], resumes: List[Dict[str, str]]):
+        """Set 'resume' on each application from the backend's records, matching by company and position."""
+        by_company: Dict[str, List[Dict[str, str]]] = {}
+        for row in resumes:
+            by_company.setdefault(row.get('company', '').strip().lower(), []).append(row)
+        
+        for app in job_applications:
+            candidates = by_company.get(app.get('company', '').strip().lower(), [])
+            if not candidates:
+                continue
+            position = app.get('position', '').strip().lower()
+            match = next((c for c in candidates if c.get('position', '').strip().lower() == position), None)
+            if match is None and len(candidates) == 1:
+                match = candidates[0]
+            if match:
+                app['resume'] = match.get('resume', '')
+
     def get_processing_summary(self, results: Dict[str, Any]) -> str:
         """Generate a human-readable summary of processing results."""
         if results['success']:
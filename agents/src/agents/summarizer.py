@@ -3,6 +3,8 @@ import re
 from typing import List
 import logging
 
+from .language_detector import LANGUAGE_NAMES
+
 class SummarizerAgent:
     """Agent responsible for creating concise summaries of job application emails using Claude."""
     
@@ -44,9 +46,14 @@ class SummarizerAgent:
 
     def _create_summary_prompt(self, content: str, job_data) -> str:
         """Create prompt for Claude API."""
+        language = getattr(job_data, 'language', 'en')
+        language_note = ""
+        if language != 'en':
+            name = LANGUAGE_NAMES.get(language, language)
+            language_note = f"\nThe email is written in {name}. Read it in {name} and write the summary in English.\n"
         return f"""
 Please create a very concise summary (maximum 30 words) of this job application email.
-
+{language_note}
 Company: {job_data.company}
 Position: {job_data.position}
 Status: {job_data.status}
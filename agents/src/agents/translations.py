@@ -0,0 +1,200 @@
+# agents/src/agents/translations.py
+from typing import Optional
+
+# Export labels by language; English is the fallback for missing labels.
+# Keep in sync with the backend's catalogs in backend/internal/i18n/catalogs.
+MESSAGES = {
+    'en': {
+        'column.company': 'Company',
+        'column.position': 'Position',
+        'column.applied_date': 'Applied Date',
+        'column.status': 'Status',
+        'column.source': 'Source',
+        'column.location': 'Location',
+        'column.job_id': 'Job ID',
+        'column.status_link': 'Status Link',
+        'column.notes': 'Notes',
+        'column.resume': 'Resume',
+        'sheet.analytics': 'Analytics',
+        'sheet.companies': 'Companies',
+        'section.funnel': 'Funnel',
+        'section.weekly_trend': 'Weekly Trend',
+        'section.time_in_stage': 'Time in Stage',
+        'section.sources': 'Sources',
+        'section.offers': 'Offers ({currency})',
+        'header.stage': 'Stage',
+        'header.applications': 'Applications',
+        'header.rate': 'Rate',
+        'header.week_of': 'Week Of',
+        'header.responses': 'Responses',
+        'header.transitions': 'Transitions',
+        'header.median_hours': 'Median (hours)',
+        'header.p90_hours': 'P90 (hours)',
+        'header.channel': 'Channel',
+        'header.response_rate': 'Response Rate',
+        'header.interview_rate': 'Interview Rate',
+        'header.offer_rate': 'Offer Rate',
+        'header.base': 'Base',
+        'header.annual_total': 'Annual Total',
+        'header.signing_bonus': 'Signing Bonus',
+        'header.offered_in': 'Offered In',
+        'header.vs_median': 'Vs. Median',
+        'header.interview_process': 'Interview Process',
+        'header.culture': 'Culture',
+        'header.contacts': 'Contacts',
+        'generated': 'Generated {when}',
+    },
+    'de': {
+        'column.company': 'Unternehmen',
+        'column.position': 'Position',
+        'column.applied_date': 'Beworben am',
+        'column.status': 'Status',
+        'column.source': 'Quelle',
+        'column.location': 'Ort',
+        'column.job_id': 'Stellen-ID',
+        'column.status_link': 'Status-Link',
+        'column.notes': 'Notizen',
+        'column.resume': 'Lebenslauf',
+        'sheet.analytics': 'Auswertung',
+        'sheet.companies': 'Unternehmen',
+        'section.funnel': 'Trichter',
+        'section.weekly_trend': 'Wochenverlauf',
+        'section.time_in_stage': 'Zeit pro Phase',
+        'section.sources': 'Quellen',
+        'section.offers': 'Angebote ({currency})',
+        'header.stage': 'Phase',
+        'header.applications': 'Bewerbungen',
+        'header.rate': 'Quote',
+        'header.week_of': 'Woche ab',
+        'header.responses': 'Antworten',
+        'header.transitions': 'Übergänge',
+        'header.median_hours': 'Median (Stunden)',
+        'header.p90_hours': 'P90 (Stunden)',
+        'header.channel': 'Kanal',
+        'header.response_rate': 'Antwortquote',
+        'header.interview_rate': 'Interviewquote',
+        'header.offer_rate': 'Angebotsquote',
+        'header.base': 'Grundgehalt',
+        'header.annual_total': 'Jahresgesamt',
+        'header.signing_bonus': 'Antrittsprämie',
+        'header.offered_in': 'Währung',
+        'header.vs_median': 'Ggü. Median',
+        'header.interview_process': 'Interviewprozess',
+        'header.culture': 'Kultur',
+        'header.contacts': 'Kontakte',
+        'generated': 'Erstellt {when}',
+    },
+    'fr': {
+        'column.company': 'Entreprise',
+        'column.position': 'Poste',
+        'column.applied_date': 'Date de candidature',
+        'column.status': 'Statut',
+        'column.source': 'Source',
+        'column.location': 'Lieu',
+        'column.job_id': 'Réf. du poste',
+        'column.status_link': 'Lien de suivi',
+        'column.notes': 'Notes',
+        'column.resume': 'CV',
+        'sheet.analytics': 'Statistiques',
+        'sheet.companies': 'Entreprises',
+        'section.funnel': 'Entonnoir',
+        'section.weekly_trend': 'Tendance hebdomadaire',
+        'section.time_in_stage': 'Temps par étape',
+        'section.sources': 'Sources',
+        'section.offers': 'Offres ({currency})',
+        'header.stage': 'Étape',
+        'header.applications': 'Candidatures',
+        'header.rate': 'Taux',
+        'header.week_of': 'Semaine du',
+        'header.responses': 'Réponses',
+        'header.transitions': 'Transitions',
+        'header.median_hours': 'Médiane (heures)',
+        'header.p90_hours': 'P90 (heures)',
+        'header.channel': 'Canal',
+        'header.response_rate': 'Taux de réponse',
+        'header.interview_rate': "Taux d'entretien",
+        'header.offer_rate': "Taux d'offre",
+        'header.base': 'Fixe',
+        'header.annual_total': 'Total annuel',
+        'header.signing_bonus': "Prime d'embauche",
+        'header.offered_in': 'Devise',
+        'header.vs_median': 'Vs médiane',
+        'header.interview_process': "Processus d'entretien",
+        'header.culture': 'Culture',
+        'header.contacts': 'Contacts',
+        'generated': 'Généré le {when}',
+    },
+    'es': {
+        'column.company': 'Empresa',
+        'column.position': 'Puesto',
+        'column.applied_date': 'Fecha de candidatura',
+        'column.status': 'Estado',
+        'column.source': 'Fuente',
+        'column.location': 'Ubicación',
+        'column.job_id': 'ID del puesto',
+        'column.status_link': 'Enlace de estado',
+        'column.notes': 'Notas',
+        'column.resume': 'CV',
+        'sheet.analytics': 'Análisis',
+        'sheet.companies': 'Empresas',
+        'section.funnel': 'Embudo',
+        'section.weekly_trend': 'Tendencia semanal',
+        'section.time_in_stage': 'Tiempo por etapa',
+        'section.sources': 'Fuentes',
+        'section.offers': 'Ofertas ({currency})',
+        'header.stage': 'Etapa',
+        'header.applications': 'Candidaturas',
+        'header.rate': 'Tasa',
+        'header.week_of': 'Semana del',
+        'header.responses': 'Respuestas',
+        'header.transitions': 'Transiciones',
+        'header.median_hours': 'Mediana (horas)',
+        'header.p90_hours': 'P90 (horas)',
+        'header.channel': 'Canal',
+        'header.response_rate': 'Tasa de respuesta',
+        'header.interview_rate': 'Tasa de entrevistas',
+        'header.offer_rate': 'Tasa de ofertas',
+        'header.base': 'Base',
+        'header.annual_total': 'Total anual',
+        'header.signing_bonus': 'Bono de firma',
+        'header.offered_in': 'Moneda',
+        'header.vs_median': 'Vs. mediana',
+        'header.interview_process': 'Proceso de entrevistas',
+        'header.culture': 'Cultura',
+        'header.contacts': 'Contactos',
+        'generated': 'Generado el {when}',
+    },
+}
+
+# Application statuses by language, keyed by the English ApplicationStatus
+STATUSES = {
+    'de': {
+        'Applied': 'Beworben', 'Under Review': 'In Prüfung', 'Interview Scheduled': 'Interview geplant',
+        'Interview Complete': 'Interview abgeschlossen', 'Offer': 'Angebot', 'Rejected': 'Abgelehnt',
+        'Withdrawn': 'Zurückgezogen', 'Accepted': 'Angenommen', 'Saved': 'Gemerkt',
+    },
+    'fr': {
+        'Applied': 'Candidature envoyée', 'Under Review': "En cours d'examen", 'Interview Scheduled': 'Entretien prévu',
+        'Interview Complete': 'Entretien terminé', 'Offer': 'Offre', 'Rejected': 'Refusée',
+        'Withdrawn': 'Retirée', 'Accepted': 'Acceptée', 'Saved': 'Enregistrée',
+    },
+    'es': {
+        'Applied': 'Enviada', 'Under Review': 'En revisión', 'Interview Scheduled': 'Entrevista programada',
+        'Interview Complete': 'Entrevista realizada', 'Offer': 'Oferta', 'Rejected': 'Rechazada',
+        'Withdrawn': 'Retirada', 'Accepted': 'Aceptada', 'Saved': 'Guardada',
+    },
+}
+
+def language(locale: Optional[str]) -> str:
+    """The catalog language for a locale such as en-GB or de_AT."""
+    lang = (locale or 'en').replace('_', '-').split('-')[0].lower()
+    return lang if lang in MESSAGES else 'en'
+
+def translate(key: str, locale: Optional[str] = None, **kwargs) -> str:
+    """Translate an export label, formatting any {placeholders}."""
+    text = MESSAGES[language(locale)].get(key) or MESSAGES['en'].get(key, key)
+    return text.format(**kwargs) if kwargs else text
+
+def translate_status(status: str, locale: Optional[str] = None) -> str:
+    """Translate an application status, leaving unknown ones as they are."""
+    return STATUSES.get(language(locale), {}).get(status, status)
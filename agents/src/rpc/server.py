@@ -0,0 +1,362 @@
+# agents/src/rpc/server.py
+import json
+import logging
+import re
+from concurrent import futures
+from datetime import datetime
+from typing import Optional
+
+import grpc
+
+from src.agents.email_finder import EmailFinderAgent, EmailSearchResult
+from src.agents.email_parser import EmailParserAgent
+from src.agents.language_detector import LanguageDetectorAgent
+from src.rpc import agents_pb2, agents_pb2_grpc
+
+# Email statuses the parser returns that are not ApplicationStatus values
+STATUS_ALIASES = {
+    'Interview': 'Interview Scheduled',
+}
+
+DRAFT_PROMPTS = {
+    'follow_up': "a polite follow-up asking about the status of the application",
+    'thank_you': "a thank-you note sent after an interview",
+    'withdrawal': "a courteous note withdrawing from the hiring process",
+    'acceptance': "a note accepting the job offer",
+    'decline': "a gracious note declining the job offer",
+}
+
+# Classification prompts a ClassifyEmailRequest variant can name, and the
+# model "llm" uses when the variant does not name one
+CLASSIFY_PROMPTS = ('keywords', 'llm')
+DEFAULT_CLASSIFY_MODEL = "claude-3-haiku-20240307"
+
+# Fields of ExtractedOffer the offer prompt asks for, by kind
+OFFER_AMOUNT_FIELDS = ('base_salary', 'bonus', 'equity', 'signing_bonus')
+OFFER_TEXT_FIELDS = ('equity_schedule', 'start_date', 'deadline')
+
+class AgentsServicer(agents_pb2_grpc.AgentsServiceServicer):
+    """gRPC implementation of the agents contract in shared/proto/agents.proto."""
+    
+    def __init__(self, claude_service):
+        self.claude_service = claude_service
+        self.email_finder = EmailFinderAgent(None)
+        self.email_parser = EmailParserAgent()
+        self.language_detector = LanguageDetectorAgent()
+        self.logger = logging.getLogger(__name__)
+
+    def ClassifyEmail(self, request, context):
+        email = self._to_search_result(request.email)
+        prompt, _, model = (request.variant or 'keywords').partition('@')
+        if prompt not in CLASSIFY_PROMPTS:
+            context.abort(grpc.StatusCode.INVALID_ARGUMENT, f"unknown classification variant {request.variant!r}")
+        if prompt == 'llm':
+            return self._classify_with_model(email, model or DEFAULT_CLASSIFY_MODEL, context)
+        
+        if not self.email_finder._is_job_related(email):
+            return agents_pb2.ClassifyEmailResponse(
+                job_related=False,
+                confidence=0.6,
+                reasoning="No job application keywords or job board senders found",
+                language=email.language
+            )
+        
+        status = self.email_parser._extract_status(f"{email.subject}\n{email.body}", email.language)
+        return agents_pb2.ClassifyEmailResponse(
+            job_related=True,
+            status=STATUS_ALIASES.get(status, status),
+            confidence=0.7,
+            reasoning=f"Matched job application keywords; status indicators suggest {status}",
+            language=email.language
+        )
+
+    def _classify_with_model(self, email, model: str, context):
+        """Classify an email by asking the model, for the "llm" variant."""
+        reply = self.claude_service.create_message(
+            self._create_classify_prompt(email), model=model, max_tokens=300, temperature=0
+        )
+        if reply is None:
+            context.abort(grpc.StatusCode.UNAVAILABLE, "classification failed")
+        data = self._parse_json(reply) or {}
+        job_related = bool(data.get('job_related'))
+        status = str(data.get('status') or '') if job_related else ''
+        confidence = data.get('confidence')
+        if not isinstance(confidence, (int, float)):
+            confidence = 0.5
+        return agents_pb2.ClassifyEmailResponse(
+            job_related=job_related,
+            status=STATUS_ALIASES.get(status, status),
+            confidence=min(max(float(confidence), 0.0), 1.0),
+            reasoning=str(data.get('reasoning') or '')[:500],
+            language=email.language
+        )
+
+    def _create_classify_prompt(self, email) -> str:
+        """Create the prompt for classifying an email."""
+        return f"""
+Decide whether this email is about one of the recipient's job applications and, if so,
+the application status it implies. Reply with a single JSON object with these keys:
+- job_related: true if the email is about a job application the recipient made
+- status: one of "Applied", "Under Review", "Interview Scheduled", "Interview Complete",
+  "Offer", "Rejected", "Withdrawn", "Accepted", or null when job_related is false
+- confidence: how sure you are, from 0 to 1
+- reasoning: one short sentence explaining the decision
+The email may be in any language.
+
+Subject: {email.subject}
+From: {email.sender}
+Date: {email.date.isoformat()}
+
+{email.body[:6000] or email.snippet}
+"""
+
+    def ExtractApplication(self, request, context):
+        email = self._to_search_result(request.email)
+        data = self.email_parser.parse_email(email)
+        
+        application = agents_pb2.ExtractedApplication(
+            company=data.company,
+            position=data.position,
+            applied_date=data.applied_date,
+            status=STATUS_ALIASES.get(data.status, data.status),
+            source=data.source,
+        )
+        extracted = ['company', 'position', 'applied_date', 'status', 'source']
+        for field in ('location', 'job_id', 'status_link'):
+            value = getattr(data, field)
+            if value:
+                setattr(application, field, value)
+                extracted.append(field)
+        
+        return agents_pb2.ExtractApplicationResponse(
+            application=application,
+            confidence=len(extracted) / 8,
+            extracted_fields=extracted,
+            language=data.language
+        )
+
+    def DraftEmail(self, request, context):
+        purpose = DRAFT_PROMPTS.get(request.kind)
+        if purpose is None:
+            context.abort(grpc.StatusCode.INVALID_ARGUMENT, f"unknown draft kind: {request.kind}")
+        
+        prompt = self._create_draft_prompt(request, purpose)
+        max_tokens = request.max_tokens or 600
+        
+        text = ""
+        try:
+            for delta in self.claude_service.stream_message(prompt, max_tokens=max_tokens):
+                if not context.is_active():
+                    return
+                text += delta
+                yield agents_pb2.DraftEmailChunk(text=delta)
+        except Exception as e:
+            self.logger.error(f"Error drafting email: {e}")
+            context.abort(grpc.StatusCode.UNAVAILABLE, "draft generation failed")
+        
+        yield agents_pb2.DraftEmailChunk(done=True, subject=self._default_subject(request))
+
+    def SummarizeApplication(self, request, context):
+        prompt = self._create_summary_prompt(request)
+        summary = self.claude_service.create_message(prompt, max_tokens=200, temperature=0.2)
+        if summary is None:
+            context.abort(grpc.StatusCode.UNAVAILABLE, "summary generation failed")
+        return agents_pb2.SummarizeApplicationResponse(summary=summary.strip())
+
+    def SummarizeInterview(self, request, context):
+        reply = self.claude_service.create_message(self._create_interview_prompt(request), max_tokens=800, temperature=0.2)
+        if reply is None:
+            context.abort(grpc.StatusCode.UNAVAILABLE, "interview summary generation failed")
+        data = self._parse_json(reply) or {}
+        return agents_pb2.SummarizeInterviewResponse(
+            takeaways=self._string_list(data.get('takeaways')),
+            action_items=self._string_list(data.get('action_items'))
+        )
+
+    def ExtractOffer(self, request, context):
+        email = self._to_search_result(request.email)
+        reply = self.claude_service.create_message(self._create_offer_prompt(request, email), max_tokens=400, temperature=0)
+        if reply is None:
+            context.abort(grpc.StatusCode.UNAVAILABLE, "offer extraction failed")
+        data = self._parse_json(reply)
+        if not data or not data.get('is_offer'):
+            return agents_pb2.ExtractOfferResponse(found=False)
+        
+        offer = agents_pb2.ExtractedOffer(currency=str(data.get('currency') or request.default_currency).upper()[:3])
+        extracted = []
+        for field in OFFER_AMOUNT_FIELDS:
+            value = data.get(field)
+            if isinstance(value, (int, float)) and value >= 0:
+                setattr(offer, field, int(value))
+                extracted.append(field)
+        for field in OFFER_TEXT_FIELDS:
+            value = data.get(field)
+            if isinstance(value, str) and value.strip():
+                setattr(offer, field, value.strip())
+                extracted.append(field)
+        
+        return agents_pb2.ExtractOfferResponse(
+            found='base_salary' in extracted,
+            offer=offer,
+            confidence=min(1.0, (len(extracted) + 1) / (len(OFFER_AMOUNT_FIELDS) + len(OFFER_TEXT_FIELDS))),
+            extracted_fields=extracted
+        )
+
+    def _create_offer_prompt(self, request, email) -> str:
+        """Create the prompt for extracting compensation from an offer letter."""
+        return f"""
+Extract the compensation from this job offer letter. Reply with a single JSON object with these keys:
+- is_offer: true if the email is (or encloses) a job offer with compensation details
+- currency: ISO 4217 code, "{request.default_currency or 'USD'}" if none is named
+- base_salary: yearly base salary as a whole number (convert hourly or monthly pay to yearly)
+- bonus: yearly target bonus as a whole number (convert a percentage using the base salary)
+- equity: yearly value of the equity grant as a whole number (total grant value divided by the vesting years)
+- signing_bonus: one-off signing bonus as a whole number
+- equity_schedule: the vesting schedule in a few words, e.g. "4 years, 1 year cliff, monthly after"
+- start_date: proposed start date as YYYY-MM-DD
+- deadline: when the company needs an answer, as an RFC 3339 timestamp
+Use null for anything the letter does not state. Do not guess.
+
+Subject: {email.subject}
+From: {email.sender}
+Date: {email.date.isoformat()}
+
+{email.body[:8000] or email.snippet}
+"""
+
+    def _parse_json(self, reply: str) -> Optional[dict]:
+        """Parse the first JSON object in a model reply."""
+        match = re.search(r'\{.*\}', reply, re.DOTALL)
+        if not match:
+            return None
+        try:
+            data = json.loads(match.group(0))
+        except json.JSONDecodeError:
+            self.logger.warning("Model returned invalid JSON")
+            return None
+        return data if isinstance(data, dict) else None
+
+    def _string_list(self, value) -> list:
+        """Keep the non-empty strings of a JSON list from a model reply."""
+        if not isinstance(value, list):
+            return []
+        return [item.strip() for item in value if isinstance(item, str) and item.strip()][:10]
+
+    def _create_interview_prompt(self, request) -> str:
+        """Create the prompt for summarizing interview notes or a transcript."""
+        language = self.language_detector.language_name(request.language or 'en')
+        return f"""
+These are a job applicant's notes or a transcript of an interview. Reply with a single JSON
+object with these keys, writing in {language}:
+- takeaways: the key things learned, as a list of short sentences (at most 10), e.g. what the
+  team works on, concerns the interviewer raised, how the applicant came across, next steps
+  the company mentioned
+- action_items: what the applicant should do next, as a list of short imperative sentences
+  (at most 10), e.g. "Send the hiring manager the article on rate limiting"
+Use an empty list when there is nothing to report. Do not invent anything the notes do not say.
+
+Company: {request.company}
+Position: {request.position}
+Interview: {request.title}
+Date: {request.occurred_at[:10]}
+
+{request.transcript}
+"""
+
+    def _create_summary_prompt(self, request) -> str:
+        """Create the prompt for summarizing an application's history."""
+        language = self.language_detector.language_name(request.language or 'en')
+        events = "\n".join(
+            f"- {event.occurred_at[:10]} {event.type}: {event.description}" for event in request.events
+        ) or "None"
+        emails = "\n".join(
+            f"- {email.date[:10]} from {getattr(email, 'from')}: {email.subject} - {email.body[:500] or email.snippet}"
+            for email in request.emails
+        ) or "None"
+        interviews = "\n".join(
+            f"- {interview.occurred_at[:10]} {interview.title}: "
+            + "; ".join(list(interview.takeaways) + [f"to do: {item}" for item in interview.action_items])
+            for interview in request.interviews
+        ) or "None"
+        return f"""
+Summarize the history of this job application in one or two short sentences, in {language}.
+List the key milestones with their dates, separated by semicolons, and end with what the
+applicant is waiting on, for example:
+"Applied Mar 3 via referral; recruiter screen Mar 12; awaiting onsite scheduling"
+
+Company: {request.company}
+Position: {request.position}
+Applied: {request.applied_date}
+Source: {request.source or 'Unknown'}
+Current status: {request.status}
+
+Events:
+{events}
+
+Emails:
+{emails}
+
+Interview takeaways:
+{interviews}
+
+Write only the summary.
+"""
+
+    def _create_draft_prompt(self, request, purpose: str) -> str:
+        """Create the prompt for drafting an email."""
+        tone = request.tone or "professional"
+        language = self.language_detector.language_name(request.language or 'en')
+        return f"""
+Write {purpose} for a job applicant, in {language}.
+
+Company: {request.company}
+Position: {request.position}
+Recipient: {request.recipient_name or 'the hiring team'}
+Tone: {tone}
+Context: {request.context or 'None'}
+
+Write only the email body, without a subject line. Keep it under 150 words.
+"""
+
+    def _default_subject(self, request) -> str:
+        """Subject line for a drafted email."""
+        # The templates below are English; other languages just use the position
+        if request.language and request.language != 'en':
+            return request.position
+        subjects = {
+            'follow_up': f"Following up on my application for {request.position}",
+            'thank_you': f"Thank you - {request.position} interview",
+            'withdrawal': f"Withdrawing my application for {request.position}",
+            'acceptance': f"Accepting the {request.position} offer",
+            'decline': f"{request.position} offer",
+        }
+        return subjects.get(request.kind, request.position)
+
+    def _to_search_result(self, email) -> EmailSearchResult:
+        """Convert a protobuf Email into the finder/parser representation."""
+        return EmailSearchResult(
+            message_id=email.id,
+            thread_id=email.thread_id,
+            subject=email.subject,
+            sender=getattr(email, 'from'),
+            date=self._parse_date(email.date) or datetime.now(),
+            snippet=email.snippet,
+            body=email.body,
+            language=email.language or self.language_detector.detect(f"{email.subject}\n{email.body}")
+        )
+
+    def _parse_date(self, value: str) -> Optional[datetime]:
+        """Parse an RFC 3339 date."""
+        if not value:
+            return None
+        try:
+            return datetime.fromisoformat(value.replace('Z', '+00:00'))
+        except ValueError:
+            return None
+
+def create_server(claude_service, port: int, max_workers: int = 10) -> grpc.Server:
+    """Create (but do not start) the agents gRPC server."""
+    server = grpc.server(futures.ThreadPoolExecutor(max_workers=max_workers))
+    agents_pb2_grpc.add_AgentsServiceServicer_to_server(AgentsServicer(claude_service), server)
+    server.add_insecure_port(f"[::]:{port}")
+    return server
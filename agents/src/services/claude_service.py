@@ -1,7 +1,7 @@
 # agents/src/services/claude_service.py
 import anthropic
 import logging
-from typing import Optional
+from typing import Iterator, Optional
 
 class ClaudeService:
     """Service to interact with Claude API."""
@@ -32,3 +32,21 @@ class ClaudeService:
         except Exception as e:
             self.logger.error(f"Error calling Claude API: {e}")
             return None
+
+    def stream_message(self,
+                       prompt: str,
+                       model: str = "claude-3-sonnet-20240229",
+                       max_tokens: int = 1000,
+                       temperature: float = 0.3) -> Iterator[str]:
+        """Stream a message from Claude API, yielding text as it is generated."""
+        with self.client.messages.stream(
+            model=model,
+            max_tokens=max_tokens,
+            temperature=temperature,
+            messages=[{
+                "role": "user",
+                "content": prompt
+            }]
+        ) as stream:
+            for text in stream.text_stream:
+                yield text
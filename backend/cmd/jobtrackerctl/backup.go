@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newBackupCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Create, list and restore encrypted backups",
+	}
+
+	var user string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Back up the whole instance, or one user with --user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := backupUser(cmd, a, user)
+			if err != nil {
+				return err
+			}
+			b, err := a.backup.Create(cmd.Context(), userID)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Uploaded %s (%d rows, %d bytes)\n", b.Key, b.Rows, b.Size)
+			return nil
+		},
+	}
+	create.Flags().StringVar(&user, "user", "", "back up only this user, by ID or email")
+
+	var listUser string
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List stored backups, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := backupUser(cmd, a, listUser)
+			if err != nil {
+				return err
+			}
+			backups, err := a.backup.List(cmd.Context(), userID)
+			if err != nil {
+				return err
+			}
+			for _, b := range backups {
+				scope := "instance"
+				if b.UserID != nil {
+					scope = "user " + *b.UserID
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\t%d bytes\n",
+					b.Key, scope, b.CreatedAt.Format("2006-01-02 15:04"), b.Size)
+			}
+			return nil
+		},
+	}
+	list.Flags().StringVar(&listUser, "user", "", "list only this user's backups, by ID or email")
+
+	restore := &cobra.Command{
+		Use:   "restore <key>",
+		Short: "Restore rows missing from the database from a backup",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := a.backup.Restore(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Restored %d rows from %s\n", n, args[0])
+			return nil
+		},
+	}
+
+	cmd.AddCommand(create, list, restore)
+	return cmd
+}
+
+// backupUser resolves the --user flag, returning nil when it is unset.
+func backupUser(cmd *cobra.Command, a *app, user string) (*string, error) {
+	if user == "" {
+		return nil, nil
+	}
+	id, err := a.admin.ResolveUser(cmd.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jobtracker/backend/internal/config"
+)
+
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration the server would run with",
+		// Reads the environment and compiled-in profiles; no database needed.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+
+	var profile string
+	var redact bool
+	show := &cobra.Command{
+		Use:   "print [--profile <name>] [--redact]",
+		Short: "Print every setting with its effective value and where it came from",
+		Long: "Prints each configuration variable as KEY=value, with whether the value\n" +
+			"came from the environment, the profile or the built-in default.\n" +
+			"Use --redact before sharing the output: it masks secrets and URL passwords.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if profile == "" {
+				profile = os.Getenv("CONFIG_PROFILE")
+			}
+			cfg, err := config.Load(profile)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "# profile %s\n", cfg.Profile)
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			for _, s := range cfg.Settings() {
+				value := s.Value
+				if redact {
+					value = s.Redacted()
+				}
+				fmt.Fprintf(w, "%s=%s\t# %s\n", s.Key, value, s.Source)
+			}
+			return w.Flush()
+		},
+	}
+	show.Flags().StringVar(&profile, "profile", "", "profile to load (default $CONFIG_PROFILE, else "+config.DefaultProfile+")")
+	show.Flags().BoolVar(&redact, "redact", false, "mask secrets and URL passwords")
+
+	profiles := &cobra.Command{
+		Use:   "profiles",
+		Short: "List the compiled-in profiles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range config.Profiles() {
+				fmt.Fprintln(cmd.OutOrStdout(), name)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(show, profiles)
+	return cmd
+}
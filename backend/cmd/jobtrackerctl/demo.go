@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jobtracker/backend/internal/apikeys"
+	"github.com/jobtracker/backend/internal/demo"
+)
+
+func newDemoCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "demo",
+		Short: "Manage the demo account",
+	}
+
+	var (
+		email  string
+		apiKey bool
+	)
+	seed := &cobra.Command{
+		Use:   "seed",
+		Short: "Create or reset a demo account with realistic applications, emails and interviews",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			res, err := demo.Seed(cmd.Context(), a.db, email)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Seeded %s (%s): %d applications, %d emails, %d interviews\n",
+				email, res.UserID, res.Applications, res.Emails, res.Interviews)
+
+			if apiKey {
+				key, err := apikeys.NewService(a.db).Create(cmd.Context(), res.UserID, "demo")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "API key: %s\n", key.Key)
+			}
+			return nil
+		},
+	}
+	seed.Flags().StringVar(&email, "email", demo.DefaultEmail, "email of the demo account")
+	seed.Flags().BoolVar(&apiKey, "api-key", false, "also create an API key for the demo account")
+
+	cmd.AddCommand(seed)
+	return cmd
+}
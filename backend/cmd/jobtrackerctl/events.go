@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jobtracker/backend/internal/eventlog"
+)
+
+func newEventsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Inspect and replay an application's event stream",
+	}
+
+	show := &cobra.Command{
+		Use:   "show <application-id>",
+		Short: "Print every event that shaped an application",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := a.events.Owner(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			events, err := a.events.Events(cmd.Context(), userID, args[0])
+			if err != nil {
+				return err
+			}
+			for _, e := range events {
+				printEvent(cmd.OutOrStdout(), e)
+			}
+			return nil
+		},
+	}
+
+	var apply bool
+	rebuild := &cobra.Command{
+		Use:   "rebuild <application-id>",
+		Short: "Reconstruct an application from its events and report drift",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := a.events.Owner(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			r, err := a.events.Rebuild(cmd.Context(), userID, args[0], apply)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Replayed %d events\n", len(r.Events))
+			columns := make([]string, 0, len(r.State))
+			for c := range r.State {
+				columns = append(columns, c)
+			}
+			sort.Strings(columns)
+			for _, c := range columns {
+				fmt.Fprintf(out, "  %s = %s\n", c, r.State[c])
+			}
+			if len(r.Drift) == 0 {
+				fmt.Fprintln(out, "Stored row matches the event stream")
+				return nil
+			}
+			for _, d := range r.Drift {
+				fmt.Fprintf(out, "Drift in %s: stored %s, replayed %s\n", d.Column, d.Stored, d.Replayed)
+			}
+			if apply {
+				fmt.Fprintf(out, "Restored %d columns from the event stream\n", len(r.Drift))
+			} else {
+				fmt.Fprintln(out, "Run again with --apply to restore the replayed values")
+			}
+			return nil
+		},
+	}
+	rebuild.Flags().BoolVar(&apply, "apply", false, "overwrite drifted columns with the replayed values")
+
+	cmd.AddCommand(show, rebuild)
+	return cmd
+}
+
+func printEvent(w io.Writer, e *eventlog.Event) {
+	line := fmt.Sprintf("#%d\t%s\t%s by %s", e.Seq, e.OccurredAt.Format("2006-01-02 15:04:05"), e.Type, e.Actor)
+	if e.Ref != nil {
+		line += " (" + *e.Ref + ")"
+	}
+	var parts []string
+	for c, v := range e.Changes {
+		parts = append(parts, c+"="+string(v))
+	}
+	for k, v := range e.Details {
+		parts = append(parts, k+": "+string(v))
+	}
+	sort.Strings(parts)
+	if len(parts) > 0 {
+		line += "\t" + strings.Join(parts, ", ")
+	}
+	fmt.Fprintln(w, line)
+}
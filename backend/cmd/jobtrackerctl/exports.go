@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jobtracker/backend/internal/retention"
+)
+
+func newExportsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exports",
+		Short: "Manage exported spreadsheets",
+	}
+
+	var dryRun bool
+	purge := &cobra.Command{
+		Use:   "purge",
+		Short: "Delete exports older than the exports retention rule allows",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRetention(cmd, a, retention.RuleExports, dryRun)
+		},
+	}
+	purge.Flags().BoolVar(&dryRun, "dry-run", false, "report expired exports without deleting them")
+
+	cmd.AddCommand(purge)
+	return cmd
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jobtracker/backend/internal/database"
+	"github.com/jobtracker/backend/internal/locks"
+)
+
+func newJobsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Inspect, retry and review processing jobs and background job locks",
+	}
+
+	var limit int
+	deadLetters := &cobra.Command{
+		Use:   "dead-letters",
+		Short: "List failed processing jobs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobs, err := a.admin.DeadLetters(cmd.Context(), limit)
+			if err != nil {
+				return err
+			}
+			for _, j := range jobs {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\t%s\t%s\n",
+					j.ID, j.UserID, j.StartDate.Format("2006-01-02"), j.UpdatedAt.Format("2006-01-02 15:04"), strings.Join(j.Errors, "; "))
+			}
+			return nil
+		},
+	}
+	deadLetters.Flags().IntVar(&limit, "limit", 50, "maximum number of jobs to list")
+
+	var all bool
+	requeue := &cobra.Command{
+		Use:   "requeue [job-id...]",
+		Short: "Reset failed processing jobs to pending",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !all {
+				return fmt.Errorf("pass job IDs or --all")
+			}
+			n, err := a.admin.Requeue(cmd.Context(), args...)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Requeued %d jobs\n", n)
+			return nil
+		},
+	}
+	requeue.Flags().BoolVar(&all, "all", false, "requeue every failed job")
+
+	// Reviews are recorded against an administrator, who runs them by ID
+	// or email.
+	var adminUser string
+	reviewer := func(cmd *cobra.Command) (string, error) {
+		if adminUser == "" {
+			return "", fmt.Errorf("pass --admin with your user ID or email")
+		}
+		return a.admin.ResolveUser(cmd.Context(), adminUser)
+	}
+
+	flagged := &cobra.Command{
+		Use:   "flagged",
+		Short: "List syncs whose results were held as anomalous",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			adminID, err := reviewer(cmd)
+			if err != nil {
+				return err
+			}
+			jobs, err := a.syncguard.Flagged(cmd.Context(), adminID)
+			if err != nil {
+				return err
+			}
+			for _, j := range jobs {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\t%d found\t%s\n",
+					j.ID, j.UserID, j.StartDate.Format("2006-01-02"), j.Found, strings.Join(j.Reasons, "; "))
+			}
+			return nil
+		},
+	}
+
+	approve := &cobra.Command{
+		Use:   "approve <job-id>",
+		Short: "Rerun a held sync and apply its results without anomaly checks",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			adminID, err := reviewer(cmd)
+			if err != nil {
+				return err
+			}
+			if err := a.syncguard.Approve(cmd.Context(), adminID, args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Approved %s; a sync will run again shortly\n", args[0])
+			return nil
+		},
+	}
+
+	reject := &cobra.Command{
+		Use:   "reject <job-id>",
+		Short: "Discard a held sync's results",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			adminID, err := reviewer(cmd)
+			if err != nil {
+				return err
+			}
+			if err := a.syncguard.Reject(cmd.Context(), adminID, args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Rejected %s\n", args[0])
+			return nil
+		},
+	}
+
+	lockStats := &cobra.Command{
+		Use:   "locks",
+		Short: "Show which instance holds each background job lock",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rdb, err := database.OpenRedis(a.cfg)
+			if err != nil {
+				return fmt.Errorf("connect to Redis: %w", err)
+			}
+			defer rdb.Close()
+
+			stats, err := locks.NewService(a.cfg, rdb).Stats(cmd.Context())
+			if err != nil {
+				return err
+			}
+			for _, st := range stats {
+				holder := "free"
+				if st.Holder != "" {
+					holder = fmt.Sprintf("%s (expires in %s)", st.Holder, st.ExpiresIn.Round(time.Second))
+				}
+				last := "never"
+				if !st.LastAt.IsZero() {
+					last = st.LastAt.Format("2006-01-02 15:04") + " by " + st.LastHolder
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\tlast %s\tacquired=%d skipped=%d takeovers=%d lost=%d errors=%d\n",
+					st.Name, holder, last, st.Acquired, st.Skipped, st.Takeovers, st.Lost, st.Errors)
+			}
+			return nil
+		},
+	}
+
+	for _, c := range []*cobra.Command{flagged, approve, reject} {
+		c.Flags().StringVar(&adminUser, "admin", "", "administrator reviewing, by ID or email")
+	}
+
+	cmd.AddCommand(deadLetters, requeue, flagged, approve, reject, lockStats)
+	return cmd
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newMailboxCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mailbox",
+		Short: "Manage synced Gmail mailboxes",
+	}
+
+	var since string
+	resync := &cobra.Command{
+		Use:   "resync <user>",
+		Short: "Reprocess a user's emails since a date, by user ID or email",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, err := time.Parse("2006-01-02", since)
+			if err != nil {
+				return fmt.Errorf("--since must be YYYY-MM-DD: %w", err)
+			}
+			res, err := a.admin.ResyncMailbox(cmd.Context(), args[0], from)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Cleared %d cached emails for %s; queued processing job %s\n",
+				res.ClearedEmails, res.UserID, res.JobID)
+			return nil
+		},
+	}
+	resync.Flags().StringVar(&since, "since", time.Now().AddDate(0, 0, -30).Format("2006-01-02"), "first day to reprocess (YYYY-MM-DD)")
+
+	cmd.AddCommand(resync)
+	return cmd
+}
@@ -0,0 +1,108 @@
+// Command jobtrackerctl runs maintenance operations against a Job Tracker
+// instance. It reads the same environment as the server.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/backup"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/database"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/quotas"
+	"github.com/jobtracker/backend/internal/retention"
+	"github.com/jobtracker/backend/internal/storage"
+	"github.com/jobtracker/backend/internal/syncguard"
+)
+
+// app holds what subcommands share once the root command has connected.
+type app struct {
+	cfg       *config.Config
+	db        *sql.DB
+	admin     *admin.Service
+	backup    *backup.Service
+	events    *eventlog.Service
+	quotas    *quotas.Service
+	retention *retention.Service
+	syncguard *syncguard.Service
+}
+
+func main() {
+	log.SetFlags(0)
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to load .env: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	a := &app{}
+	root := &cobra.Command{
+		Use:          "jobtrackerctl",
+		Short:        "Maintenance tool for a Job Tracker instance",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			a.cfg = config.New()
+			db, err := database.Open(a.cfg)
+			if err != nil {
+				return fmt.Errorf("connect to database: %w", err)
+			}
+			a.db = db
+			files, err := storage.FromConfig(a.cfg)
+			if err != nil {
+				return fmt.Errorf("file storage: %w", err)
+			}
+			a.retention = retention.NewService(a.cfg, db, files.Exports)
+			a.backup = backup.NewService(a.cfg, db)
+			a.events = eventlog.NewService(db)
+			plans, err := quotas.PlansFromConfig(a.cfg)
+			if err != nil {
+				return fmt.Errorf("load quota plans: %w", err)
+			}
+			a.quotas = quotas.NewService(a.cfg, db, plans, nil, nil)
+			a.admin = admin.NewService(a.cfg, db, a.quotas)
+			a.syncguard = syncguard.NewService(a.cfg, db, notifications.NewService(db))
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if a.db != nil {
+				return a.db.Close()
+			}
+			return nil
+		},
+	}
+	root.CompletionOptions.DisableDefaultCmd = true
+
+	root.AddCommand(
+		newUsersCommand(a),
+		newMailboxCommand(a),
+		newJobsCommand(a),
+		newMigrateCommand(a),
+		newExportsCommand(a),
+		newRetentionCommand(a),
+		newDemoCommand(a),
+		newBackupCommand(a),
+		newQuotaCommand(a),
+		newEventsCommand(a),
+		newSchemaCommand(),
+		newConfigCommand(),
+	)
+	return root
+}
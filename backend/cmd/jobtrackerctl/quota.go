@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+func newQuotaCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quota",
+		Short: "Inspect usage and assign quota plans",
+	}
+
+	show := &cobra.Command{
+		Use:   "show <user>",
+		Short: "Show a user's plan and usage, by ID or email",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := a.admin.ResolveUser(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			usage, err := a.quotas.Usage(cmd.Context(), userID)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Plan: %s\n", usage.Plan)
+			for _, q := range usage.Quotas {
+				limit := "unlimited"
+				if q.Limit != nil {
+					limit = fmt.Sprint(*q.Limit)
+				}
+				flag := ""
+				if q.Exceeded {
+					flag = "\tEXCEEDED"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%g / %s per %s%s\n", q.Metric, q.Used, limit, q.Period, flag)
+			}
+			return nil
+		},
+	}
+
+	setPlan := &cobra.Command{
+		Use:   "set-plan <user> <plan>",
+		Short: "Move a user to another plan",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := a.admin.ResolveUser(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if err := a.quotas.SetPlan(cmd.Context(), userID, args[1]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Moved %s to plan %s\n", args[0], args[1])
+			return nil
+		},
+	}
+
+	plans := &cobra.Command{
+		Use:   "plans",
+		Short: "List configured plans and their limits (0 is unlimited)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configured := a.quotas.Plans()
+			names := make([]string, 0, len(configured))
+			for name := range configured {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				l := configured[name]
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\temails=%d\tattachments=%dMB\tllm=%d¢/month\texports=%d/month\n",
+					name, l.StoredEmails, l.AttachmentMB, l.LLMSpendCents, l.ExportsPerMonth)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(show, setPlan, plans)
+	return cmd
+}
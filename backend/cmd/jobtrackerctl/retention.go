@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jobtracker/backend/internal/retention"
+)
+
+func newRetentionCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retention",
+		Short: "Preview and apply data retention rules",
+	}
+
+	report := &cobra.Command{
+		Use:   "report",
+		Short: "Show what the next retention run would delete",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRetention(cmd, a, "", true)
+		},
+	}
+
+	var rule string
+	var dryRun bool
+	run := &cobra.Command{
+		Use:   "run",
+		Short: "Apply retention rules now",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRetention(cmd, a, rule, dryRun)
+		},
+	}
+	run.Flags().StringVar(&rule, "rule", "", "apply only this rule (email_bodies, rejected_applications, exports)")
+	run.Flags().BoolVar(&dryRun, "dry-run", false, "report without deleting")
+
+	cmd.AddCommand(report, run)
+	return cmd
+}
+
+// runRetention applies or previews one rule, or all rules when rule is
+// empty, and prints a line per rule.
+func runRetention(cmd *cobra.Command, a *app, rule string, dryRun bool) error {
+	var (
+		reports []*retention.Report
+		err     error
+	)
+	if dryRun {
+		reports, err = a.retention.DryRun(cmd.Context())
+	} else {
+		reports, err = a.retention.Apply(cmd.Context(), rule)
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	matched := false
+	for _, rep := range reports {
+		if rule != "" && rep.Rule != rule {
+			continue
+		}
+		matched = true
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t(default %d days)\t%s %d rows for %d users\n",
+			rep.Rule, rep.DefaultDays, verb, rep.Rows, rep.Users)
+	}
+	if err == nil && rule != "" && !matched {
+		return retention.ErrUnknownRule
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jobtracker/backend/graph"
+	"github.com/jobtracker/backend/internal/graphschema"
+)
+
+func newSchemaCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Check GraphQL schema changes and deprecations",
+		// Works on the schema compiled into this binary; no database needed.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+
+	changelog := &cobra.Command{
+		Use:   "changelog",
+		Short: "List deprecated fields and registered persisted-query manifests",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, err := graphschema.New(graph.Schema, graph.Manifests())
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			c := registry.Changelog()
+			fmt.Fprintf(out, "Schema version %s\n", c.Version)
+			for _, d := range c.Deprecations {
+				removal := ""
+				if d.RemoveAfter != nil {
+					removal = " (removal after " + *d.RemoveAfter + ")"
+				}
+				fmt.Fprintf(out, "  %s\t%s%s\n", d.Coordinate, d.Reason, removal)
+			}
+			for _, m := range c.Manifests {
+				fmt.Fprintf(out, "Manifest %s %s: %d operations\n", m.Client, m.Version, m.Operations)
+			}
+			return nil
+		},
+	}
+
+	var base string
+	check := &cobra.Command{
+		Use:   "check --base <schema.graphqls>",
+		Short: "Fail on breaking changes since a base schema or broken persisted queries",
+		Long: "Compares the current schema with a base version, e.g.\n" +
+			"  git show origin/main:backend/graph/schema.graphqls > /tmp/base.graphqls\n" +
+			"and validates every registered persisted-query manifest against it.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			current, err := graphschema.Load("schema.graphqls", graph.Schema)
+			if err != nil {
+				return fmt.Errorf("current schema: %w", err)
+			}
+			manifests, err := graphschema.LoadManifests(graph.Manifests())
+			if err != nil {
+				return err
+			}
+			problems := graphschema.CheckManifests(current, manifests)
+			for _, p := range problems {
+				fmt.Fprintf(out, "BROKEN %v\n", p)
+			}
+
+			src, err := os.ReadFile(base)
+			if err != nil {
+				return err
+			}
+			prev, err := graphschema.Load(base, string(src))
+			if err != nil {
+				return fmt.Errorf("base schema: %w", err)
+			}
+			changes := graphschema.Compare(prev, current, time.Now().Format("2006-01-02"))
+			for _, c := range changes {
+				fmt.Fprintln(out, c)
+			}
+			if len(changes) == 0 {
+				fmt.Fprintln(out, "No schema changes")
+			}
+			if graphschema.Breaking(changes) || len(problems) > 0 {
+				return fmt.Errorf("schema check failed: deprecate fields before removing them and keep registered operations valid")
+			}
+			return nil
+		},
+	}
+	check.Flags().StringVar(&base, "base", "", "schema file to compare against")
+	check.MarkFlagRequired("base")
+
+	cmd.AddCommand(changelog, check)
+	return cmd
+}
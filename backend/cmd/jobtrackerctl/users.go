@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newUsersCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Manage instance administrators",
+	}
+
+	var name string
+	createAdmin := &cobra.Command{
+		Use:   "create-admin <email>",
+		Short: "Grant admin rights, creating the user if they have not signed in yet",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			user, created, err := a.admin.CreateAdmin(cmd.Context(), args[0], name)
+			if err != nil {
+				return err
+			}
+			if created {
+				fmt.Fprintf(cmd.OutOrStdout(), "Created admin %s (%s)\n", user.Email, user.ID)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Granted admin to %s (%s)\n", user.Email, user.ID)
+			}
+			return nil
+		},
+	}
+	createAdmin.Flags().StringVar(&name, "name", "", "display name for a new user")
+
+	revokeAdmin := &cobra.Command{
+		Use:   "revoke-admin <user>",
+		Short: "Remove admin rights from a user, by ID or email",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := a.admin.RevokeAdmin(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Revoked admin from %s\n", args[0])
+			return nil
+		},
+	}
+
+	listAdmins := &cobra.Command{
+		Use:   "admins",
+		Short: "List administrators",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			users, err := a.admin.Admins(cmd.Context())
+			if err != nil {
+				return err
+			}
+			for _, u := range users {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\n", u.ID, u.Email, u.Name)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(createAdmin, revokeAdmin, listAdmins)
+	return cmd
+}
@@ -11,9 +11,69 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/jobtracker/backend/graph"
+	"github.com/jobtracker/backend/internal/actions"
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/analytics"
+	"github.com/jobtracker/backend/internal/apikeys"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/automation"
+	"github.com/jobtracker/backend/internal/avscan"
+	"github.com/jobtracker/backend/internal/backup"
+	"github.com/jobtracker/backend/internal/board"
+	"github.com/jobtracker/backend/internal/calendar"
+	"github.com/jobtracker/backend/internal/clientauth"
+	"github.com/jobtracker/backend/internal/companies"
+	"github.com/jobtracker/backend/internal/consent"
 	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/currency"
+	"github.com/jobtracker/backend/internal/database"
+	"github.com/jobtracker/backend/internal/deadlines"
+	"github.com/jobtracker/backend/internal/editlocks"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/experiments"
+	"github.com/jobtracker/backend/internal/exports"
+	"github.com/jobtracker/backend/internal/extension"
+	"github.com/jobtracker/backend/internal/goals"
+	"github.com/jobtracker/backend/internal/googleauth"
+	"github.com/jobtracker/backend/internal/graphschema"
 	"github.com/jobtracker/backend/internal/handlers"
+	"github.com/jobtracker/backend/internal/health"
+	"github.com/jobtracker/backend/internal/imports"
+	"github.com/jobtracker/backend/internal/integrity"
+	"github.com/jobtracker/backend/internal/interviews"
+	"github.com/jobtracker/backend/internal/locks"
+	"github.com/jobtracker/backend/internal/logos"
+	"github.com/jobtracker/backend/internal/mailbox"
+	"github.com/jobtracker/backend/internal/masking"
+	"github.com/jobtracker/backend/internal/mobile"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/outreach"
+	"github.com/jobtracker/backend/internal/postings"
+	"github.com/jobtracker/backend/internal/privacy"
+	"github.com/jobtracker/backend/internal/profile"
+	"github.com/jobtracker/backend/internal/querylog"
+	"github.com/jobtracker/backend/internal/quotas"
+	"github.com/jobtracker/backend/internal/ratelimit"
+	"github.com/jobtracker/backend/internal/realtime"
+	"github.com/jobtracker/backend/internal/referrals"
+	"github.com/jobtracker/backend/internal/replay"
+	"github.com/jobtracker/backend/internal/resthooks"
+	"github.com/jobtracker/backend/internal/resumes"
+	"github.com/jobtracker/backend/internal/retention"
+	"github.com/jobtracker/backend/internal/salary"
+	"github.com/jobtracker/backend/internal/scheduler"
+	"github.com/jobtracker/backend/internal/server"
 	"github.com/jobtracker/backend/internal/services"
+	"github.com/jobtracker/backend/internal/sharing"
+	"github.com/jobtracker/backend/internal/storage"
+	"github.com/jobtracker/backend/internal/suggest"
+	"github.com/jobtracker/backend/internal/syncguard"
+	"github.com/jobtracker/backend/internal/tasks"
+	"github.com/jobtracker/backend/internal/triage"
+	"github.com/jobtracker/backend/internal/watchers"
+	"github.com/jobtracker/backend/internal/workspaces"
 )
 
 func main() {
@@ -30,8 +90,185 @@ func main() {
 	agentService := services.NewAgentService(cfg)
 	dbService := services.NewDatabaseService(cfg)
 
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	rdb, err := database.OpenRedis(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	defer rdb.Close()
+
+	quotaPlans, err := quotas.PlansFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load quota plans: %v", err)
+	}
+	realtimeService := realtime.NewService(rdb)
+	notificationService := notifications.NewService(db)
+	quotaService := quotas.NewService(cfg, db, quotaPlans, notificationService, realtimeService)
+
+	experimentService := experiments.NewService(db)
+	agentsClient, err := agents.NewClient(cfg, quotaService, experimentService)
+	if err != nil {
+		log.Fatalf("Failed to create agents client: %v", err)
+	}
+	defer agentsClient.Close()
+
+	tokenStore := googleauth.NewTokenStore(cfg, db)
+	applicationService := applications.NewService(db, agentsClient, tokenStore)
+	apiKeyService := apikeys.NewService(db)
+	postingService := postings.NewService(postings.NewFetcher(15 * time.Second))
+	restHookService := resthooks.NewService(db)
+	profileService := profile.NewService(db)
+	boardService := board.NewService(db, profileService)
+	goalService := goals.NewService(db, notificationService, profileService, boardService)
+	deadlineService := deadlines.NewService(db, notificationService)
+	referralService := referrals.NewService(db, notificationService)
+	outreachService := outreach.NewService(db)
+	interviewService := interviews.NewService(db, notificationService, agentsClient, tokenStore)
+	calendarSyncer := calendar.NewSyncer(db, tokenStore, interviewService)
+	actionService := actions.NewService(db, interviewService, agentsClient)
+	automationService := automation.NewService(db, actionService)
+	salaryProviders, err := salary.ProvidersFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load salary providers: %v", err)
+	}
+	rates := currency.FromConfig(cfg, rdb)
+	salaryService := salary.NewService(db, applicationService, profileService, rates, agentsClient, salaryProviders...)
+	watcherService := watchers.NewService(db, postingService, notificationService)
+	clientAuthService := clientauth.NewService(cfg, db, rdb, apiKeyService, tokenStore)
+	consentService := consent.NewService(cfg, db, rdb, tokenStore)
+	scanner, err := avscan.FromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid attachment scanner configuration: %v", err)
+	}
+	files, err := storage.FromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid file storage configuration: %v", err)
+	}
+	resumeService := resumes.NewService(cfg, db, quotaService, scanner, files.Resumes)
+	retentionService := retention.NewService(cfg, db, files.Exports)
+	analyticsService := analytics.NewService(cfg, db, rdb, salaryService)
+	companyService := companies.NewService(db, applicationService)
+	exportService := exports.NewService(cfg, db, realtimeService, files.Exports, analyticsService, resumeService, companyService, quotaService,
+		profileService)
+	healthService := health.NewService(cfg, db, rdb)
+	mailboxService := mailbox.NewService(cfg, db, tokenStore, notificationService)
+	triageService := triage.NewService(db, actionService)
+	syncGuard := syncguard.NewService(cfg, db, notificationService)
+	importService := imports.NewService(db, tokenStore, exportService, quotaService, agentsClient, applicationService,
+		triageService, syncGuard)
+	rateLimiter := ratelimit.NewService(cfg, db, rdb)
+	workspaceService := workspaces.NewService(db, analyticsService)
+	integrityService := integrity.NewService(cfg, db)
+	shareLinkService := sharing.NewService(db)
+	logoService := logos.NewService(cfg, db, files.Logos)
+
+	// Schema changelog and persisted queries registered by client releases
+	schemaRegistry, err := graphschema.New(graph.Schema, graph.Manifests())
+	if err != nil {
+		log.Fatalf("Invalid GraphQL schema or query manifests: %v", err)
+	}
+
+	// GraphQL resolver dependencies
+	resolver := &graph.Resolver{
+		Actions:       actionService,
+		Admin:         admin.NewService(cfg, db, quotaService),
+		Agents:        agentsClient,
+		Analytics:     analyticsService,
+		Automation:    automationService,
+		APIKeys:       apiKeyService,
+		Applications:  applicationService,
+		Board:         boardService,
+		Goals:         goalService,
+		Health:        healthService,
+		Integrity:     integrityService,
+		Imports:       importService,
+		Interviews:    interviewService,
+		Logos:         logoService,
+		Calendar:      calendarSyncer,
+		Mailbox:       mailboxService,
+		ClientAuth:    clientAuthService,
+		Consent:       consentService,
+		Events:        eventlog.NewService(db),
+		Deadlines:     deadlineService,
+		EditLocks:     editlocks.NewService(db, rdb, realtimeService),
+		Experiments:   experimentService,
+		Exports:       exportService,
+		Realtime:      realtimeService,
+		Triage:        triageService,
+		Companies:     companyService,
+		Referrals:     referralService,
+		Replay:        replay.NewService(db, agentsClient, applicationService, automationService, mailboxService),
+		Notifications: notificationService,
+		FieldMasking:  masking.New(),
+		OperationLog:  querylog.New(cfg),
+		Outreach:      outreachService,
+		Postings:      postingService,
+		Profiles:      profileService,
+		Quotas:        quotaService,
+		RateLimits:    rateLimiter,
+		Resumes:       resumeService,
+		Retention:     retentionService,
+		Salary:        salaryService,
+		Schema:        schemaRegistry,
+		Suggest:       suggest.NewService(db, rdb),
+		Sharing:       shareLinkService,
+		SyncGuard:     syncGuard,
+		Tasks:         tasks.NewService(db, exportService),
+		Watchers:      watcherService,
+		Workspaces:    workspaceService,
+	}
+
+	// Background jobs
+	jobs := scheduler.New(locks.NewService(cfg, rdb))
+	jobs.RegisterSingleton("board-snapshots", scheduler.Hourly(), boardService.Snapshot)
+	jobs.RegisterSingleton("goal-weekly-summary", scheduler.Hourly(), goalService.SendWeeklySummaries)
+	jobs.RegisterSingleton("offer-deadline-reminders", scheduler.Every(15*time.Minute), deadlineService.Escalate)
+	jobs.RegisterSingleton("posting-closing-reminders", scheduler.Hourly(), deadlineService.RemindClosings)
+	jobs.RegisterSingleton("outreach-replies", scheduler.Every(15*time.Minute), outreachService.Reconcile)
+	jobs.RegisterSingleton("referral-thanks", scheduler.Every(15*time.Minute), referralService.RemindThanks)
+	jobs.RegisterSingleton("scheduling-links", scheduler.Every(5*time.Minute), actionService.AddEmailSchedulingLinks)
+	jobs.RegisterSingleton("thank-you-prompts", scheduler.Every(15*time.Minute), actionService.AddThankYous)
+	jobs.RegisterSingleton("interview-feedback-prompts", scheduler.Every(15*time.Minute), interviewService.PromptSelfAssessments)
+	jobs.RegisterSingleton("snooze-resurface", scheduler.Every(time.Minute), applicationService.ResurfaceJob(realtimeService))
+	jobs.RegisterSingleton("ats-detection", scheduler.Every(5*time.Minute), applicationService.DetectATS)
+	jobs.RegisterSingleton("alias-detection", scheduler.Every(15*time.Minute), applicationService.DetectAliases)
+	jobs.RegisterSingleton("rest-hook-dispatch", scheduler.Every(30*time.Second), restHookService.Dispatch)
+	jobs.RegisterSingleton("automation-rules", scheduler.Every(time.Minute), automationService.Evaluate)
+	jobs.RegisterSingleton("mailbox-maintenance", scheduler.Every(10*time.Minute), mailboxService.Maintain)
+	jobs.RegisterSingleton("mailbox-reconcile", scheduler.Hourly(), mailboxService.Reconcile)
+	jobs.RegisterSingleton("email-imports", scheduler.Every(time.Minute), importService.Import)
+	jobs.RegisterSingleton("imported-email-classification", scheduler.Every(time.Minute), importService.Classify)
+	jobs.RegisterSingleton("rejection-email-rules", scheduler.Every(5*time.Minute), mailboxService.ApplyRejectionRules)
+	jobs.RegisterSingleton("calendar-invites", scheduler.Every(5*time.Minute), interviewService.ImportInvites)
+	jobs.RegisterSingleton("calendar-reconcile", scheduler.Every(15*time.Minute), calendarSyncer.Reconcile)
+	jobs.RegisterSingleton("salary-enrichment", scheduler.Every(time.Hour), salaryService.EnrichPending)
+	jobs.RegisterSingleton("offer-extraction", scheduler.Every(15*time.Minute), salaryService.ExtractOffers)
+	jobs.RegisterSingleton("company-watch-check", scheduler.Every(time.Hour), watcherService.CheckDue)
+	// Each replica probes dependencies for its own capabilities cache
+	jobs.Register("dependency-health", scheduler.Every(30*time.Second), healthService.Probe)
+	jobs.RegisterSingleton("email-minimization", scheduler.Every(time.Hour), privacy.NewService(db).Minimize)
+	jobs.RegisterSingleton("data-retention", scheduler.Every(24*time.Hour), retentionService.Run)
+	jobs.RegisterSingleton("storage-lifecycle", scheduler.Every(24*time.Hour), files.Expire)
+	jobs.RegisterSingleton("integrity-check", scheduler.Every(24*time.Hour), integrityService.Check)
+	if cfg.BackupSchedule != "" {
+		backupSchedule, err := scheduler.Parse(cfg.BackupSchedule)
+		if err != nil {
+			log.Fatalf("Invalid BACKUP_SCHEDULE: %v", err)
+		}
+		jobs.RegisterSingleton("backup", backupSchedule, backup.NewService(cfg, db).Run)
+	}
+	// Scheduled jobs call the agents service in the batch lane, behind
+	// requests users are waiting on.
+	jobsCtx, stopJobs := context.WithCancel(agents.WithPriority(context.Background(), agents.PriorityBatch))
+	jobs.Start(jobsCtx)
+
 	// Initialize handlers
-	handler := handlers.New(cfg, gmailService, agentService, dbService)
+	handler := handlers.New(cfg, gmailService, agentService, dbService, resolver)
 
 	// Setup Gin router
 	if cfg.Environment == "production" {
@@ -39,12 +276,17 @@ func main() {
 	}
 	
 	router := gin.Default()
+	if err := server.TrustProxies(router, cfg); err != nil {
+		log.Fatalf("Invalid trusted proxy configuration: %v", err)
+	}
+	router.Use(server.HSTS(cfg))
+	router.Use(server.ClientIP())
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, X-API-Key, X-Share-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -67,12 +309,16 @@ func main() {
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
-		// GraphQL endpoint
-		v1.POST("/graphql", handler.GraphQL())
+		// GraphQL endpoint; API key requests are authenticated before the
+		// limiter so they are counted against their user and key
+		v1.POST("/graphql", apiKeyService.OptionalMiddleware(), rateLimiter.Middleware("graphql"), handler.GraphQL())
 		v1.GET("/graphql", handler.GraphQLPlayground())
 		
-		// WebSocket endpoint for real-time updates
-		v1.GET("/ws", handler.WebSocket())
+		// Read-only GraphQL for share links, masked to the link's level
+		v1.POST("/shared/graphql", shareLinkService.Middleware(), rateLimiter.Middleware("shared"), handler.GraphQL())
+		
+		// WebSocket endpoint for real-time updates; refused while draining
+		v1.GET("/ws", realtimeService.DrainGuard(), handler.WebSocket())
 		
 		// OAuth endpoints
 		auth := v1.Group("/auth")
@@ -80,18 +326,55 @@ func main() {
 			auth.GET("/gmail", handler.InitiateGmailAuth())
 			auth.GET("/gmail/callback", handler.HandleGmailCallback())
 			auth.POST("/logout", handler.Logout())
+
+			// PKCE and device-code flows for the CLI and browser extension
+			clientAuthService.Register(auth)
+
+			// Incremental consent for optional Google scopes
+			consentService.Register(auth)
 		}
+		
+		// Browser extension endpoints (API key authenticated)
+		ext := v1.Group("/extension", apiKeyService.Middleware(), rateLimiter.Middleware("extension"))
+		extension.New(applicationService, postingService).Register(ext)
+		
+		// Compact payloads for the mobile app (API key authenticated)
+		mobileGroup := v1.Group("/mobile", apiKeyService.Middleware(), rateLimiter.Middleware("mobile"))
+		mobile.New(db).Register(mobileGroup)
+		
+		// Resume file downloads (API key authenticated)
+		resumeGroup := v1.Group("/resumes", apiKeyService.Middleware(), rateLimiter.Middleware("resumes"))
+		resumeService.Register(resumeGroup)
+		
+		// Export spreadsheet downloads (API key authenticated)
+		exportGroup := v1.Group("/exports", apiKeyService.Middleware(), rateLimiter.Middleware("exports"))
+		exportService.Register(exportGroup)
+		
+		// Calendar feed of offer and posting deadlines (feed token in the URL)
+		feedGroup := v1.Group("/deadlines", deadlineService.FeedMiddleware(), rateLimiter.Middleware("deadlines"))
+		deadlineService.Register(feedGroup)
+		
+		// Workspace report downloads for coaches (API key authenticated)
+		workspaceGroup := v1.Group("/workspaces", apiKeyService.Middleware(), rateLimiter.Middleware("workspaces"))
+		workspaceService.Register(workspaceGroup)
+		
+		// Company logos, cached by the logo proxy (signed URLs)
+		logoGroup := v1.Group("/logos", rateLimiter.Middleware("logos"))
+		logoService.Register(logoGroup)
+		
+		// Zapier-compatible REST hooks (API key authenticated)
+		hooks := v1.Group("/hooks", apiKeyService.Middleware(), rateLimiter.Middleware("hooks"))
+		restHookService.Register(hooks)
 	}
 
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+	// Create HTTP server, terminating TLS itself when configured
+	srv, err := server.New(cfg, router)
+	if err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server starting on port %s", cfg.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -103,6 +386,16 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
+	// Hand WebSocket clients over to other replicas; Shutdown does not wait
+	// for hijacked connections
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 10*time.Second)
+	realtimeService.Drain(drainCtx, time.Duration(cfg.RealtimeReconnectAfterSeconds)*time.Second)
+	cancelDrain()
+
+	// Stop background jobs before draining requests
+	stopJobs()
+	jobs.Wait()
+
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"github.com/jobtracker/backend/internal/actions"
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/analytics"
+	"github.com/jobtracker/backend/internal/apikeys"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/automation"
+	"github.com/jobtracker/backend/internal/board"
+	"github.com/jobtracker/backend/internal/calendar"
+	"github.com/jobtracker/backend/internal/clientauth"
+	"github.com/jobtracker/backend/internal/companies"
+	"github.com/jobtracker/backend/internal/consent"
+	"github.com/jobtracker/backend/internal/deadlines"
+	"github.com/jobtracker/backend/internal/editlocks"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/experiments"
+	"github.com/jobtracker/backend/internal/exports"
+	"github.com/jobtracker/backend/internal/goals"
+	"github.com/jobtracker/backend/internal/graphschema"
+	"github.com/jobtracker/backend/internal/health"
+	"github.com/jobtracker/backend/internal/imports"
+	"github.com/jobtracker/backend/internal/integrity"
+	"github.com/jobtracker/backend/internal/interviews"
+	"github.com/jobtracker/backend/internal/logos"
+	"github.com/jobtracker/backend/internal/mailbox"
+	"github.com/jobtracker/backend/internal/masking"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/outreach"
+	"github.com/jobtracker/backend/internal/postings"
+	"github.com/jobtracker/backend/internal/profile"
+	"github.com/jobtracker/backend/internal/querylog"
+	"github.com/jobtracker/backend/internal/quotas"
+	"github.com/jobtracker/backend/internal/ratelimit"
+	"github.com/jobtracker/backend/internal/realtime"
+	"github.com/jobtracker/backend/internal/referrals"
+	"github.com/jobtracker/backend/internal/replay"
+	"github.com/jobtracker/backend/internal/resumes"
+	"github.com/jobtracker/backend/internal/retention"
+	"github.com/jobtracker/backend/internal/salary"
+	"github.com/jobtracker/backend/internal/sharing"
+	"github.com/jobtracker/backend/internal/suggest"
+	"github.com/jobtracker/backend/internal/syncguard"
+	"github.com/jobtracker/backend/internal/tasks"
+	"github.com/jobtracker/backend/internal/triage"
+	"github.com/jobtracker/backend/internal/watchers"
+	"github.com/jobtracker/backend/internal/workspaces"
+)
+
+// This file will not be regenerated automatically.
+//
+// It serves as dependency injection for your app, add any dependencies you require here.
+
+type Resolver struct {
+	Actions       *actions.Service
+	Admin         *admin.Service
+	Agents        *agents.Client
+	Analytics     *analytics.Service
+	Automation    *automation.Service
+	APIKeys       *apikeys.Service
+	Applications  *applications.Service
+	Board         *board.Service
+	Goals         *goals.Service
+	Health        *health.Service
+	Imports       *imports.Service
+	Integrity     *integrity.Service
+	Interviews    *interviews.Service
+	Logos         *logos.Service
+	Calendar      *calendar.Syncer
+	Mailbox       *mailbox.Service
+	ClientAuth    *clientauth.Service
+	Companies     *companies.Service
+	Consent       *consent.Service
+	Events        *eventlog.Service
+	Deadlines     *deadlines.Service
+	EditLocks     *editlocks.Service
+	Experiments   *experiments.Service
+	Exports       *exports.Service
+	Notifications *notifications.Service
+	FieldMasking  *masking.Masker  // added to the GraphQL server with Use
+	OperationLog  *querylog.Logger // added to the GraphQL server with Use
+	Outreach      *outreach.Service
+	Postings      *postings.Service
+	Profiles      *profile.Service
+	Quotas        *quotas.Service
+	RateLimits    *ratelimit.Service
+	Realtime      *realtime.Service
+	Referrals     *referrals.Service
+	Replay        *replay.Service
+	Resumes       *resumes.Service
+	Retention     *retention.Service
+	Salary        *salary.Service
+	Schema        *graphschema.Registry
+	Suggest       *suggest.Service
+	Sharing       *sharing.Service
+	SyncGuard     *syncguard.Service
+	Tasks         *tasks.Service
+	Triage        *triage.Service
+	Watchers      *watchers.Service
+	Workspaces    *workspaces.Service
+}
@@ -0,0 +1,21 @@
+package graph
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Schema is the GraphQL schema served at /api/v1/graphql.
+//
+//go:embed schema.graphqls
+var Schema string
+
+//go:embed manifests
+var manifests embed.FS
+
+// Manifests returns the persisted-query manifests registered by client
+// releases; see manifests/README.md.
+func Manifests() fs.FS {
+	sub, _ := fs.Sub(manifests, "manifests") // only fails for invalid paths
+	return sub
+}
@@ -0,0 +1,98 @@
+package actions
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// linkBatch caps the emails read per run of AddEmailSchedulingLinks.
+const linkBatch = 500
+
+// SchedulingLink is a self-service booking link found in an email.
+type SchedulingLink struct {
+	URL      string
+	Provider string
+}
+
+var schedulingPatterns = []struct {
+	provider string
+	re       *regexp.Regexp
+}{
+	{"Calendly", regexp.MustCompile(`https://(?:www\.)?calendly\.com/[^\s"'<>)]+`)},
+	{"GoodTime", regexp.MustCompile(`https://[a-z0-9.-]*goodtime\.io/[^\s"'<>)]+`)},
+	{"Google Calendar", regexp.MustCompile(`https://calendar\.(?:app\.)?google(?:\.com)?/(?:calendar/)?appointments/[^\s"'<>)]+`)},
+	{"Cal.com", regexp.MustCompile(`https://(?:app\.)?cal\.com/[^\s"'<>)]+`)},
+	{"Greenhouse", regexp.MustCompile(`https://app\.greenhouse\.io/(?:scheduling|candidate_availability)[^\s"'<>)]*`)},
+	{"HubSpot", regexp.MustCompile(`https://meetings\.hubspot\.com/[^\s"'<>)]+`)},
+	{"YouCanBookMe", regexp.MustCompile(`https://[a-z0-9-]+\.youcanbook\.me[^\s"'<>)]*`)},
+	{"Microsoft Bookings", regexp.MustCompile(`https://outlook\.office365\.com/(?:owa/calendar|book)/[^\s"'<>)]+`)},
+}
+
+// ExtractSchedulingLinks finds booking links in an email body. It is the
+// only place they are recognised; the agents service leaves them to the
+// backend.
+func ExtractSchedulingLinks(body string) []SchedulingLink {
+	seen := make(map[string]bool)
+	var out []SchedulingLink
+	for _, p := range schedulingPatterns {
+		for _, m := range p.re.FindAllString(body, -1) {
+			m = strings.TrimRight(m, ".,;")
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			out = append(out, SchedulingLink{URL: m, Provider: p.provider})
+		}
+	}
+	return out
+}
+
+type linkEmail struct {
+	emailID, applicationID, userID, body string
+}
+
+// AddEmailSchedulingLinks records the booking links in emails linked to an
+// application that have not been read for them yet as pending scheduling
+// actions, oldest first. It is intended to run from the scheduler.
+func (s *Service) AddEmailSchedulingLinks(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, a.id, a.user_id, COALESCE(e.subject, '') || E'\n' || COALESCE(e.body_text, '')
+		FROM email_cache e
+		JOIN applications a ON a.user_id = e.user_id AND (a.id = e.application_id OR a.email_id = e.id)
+		WHERE e.scheduling_checked_at IS NULL AND e.redacted_at IS NULL
+		ORDER BY e.date NULLS LAST
+		LIMIT $1`, linkBatch)
+	if err != nil {
+		return err
+	}
+	var pending []linkEmail
+	for rows.Next() {
+		var e linkEmail
+		if err := rows.Scan(&e.emailID, &e.applicationID, &e.userID, &e.body); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.AddSchedulingLinks(ctx, e.userID, e.applicationID, ExtractSchedulingLinks(e.body)); err != nil {
+			log.Printf("Failed to record scheduling links from email %s: %v", e.emailID, err)
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE email_cache SET scheduling_checked_at = CURRENT_TIMESTAMP WHERE id = $1`, e.emailID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractSchedulingLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []SchedulingLink
+	}{
+		{
+			name: "calendly with trailing punctuation",
+			body: "Pick a time: https://calendly.com/acme-recruiting/30min.",
+			want: []SchedulingLink{{URL: "https://calendly.com/acme-recruiting/30min", Provider: "Calendly"}},
+		},
+		{
+			name: "several providers, duplicates once",
+			body: "https://meetings.hubspot.com/jane or https://cal.com/jane/intro; again https://meetings.hubspot.com/jane",
+			want: []SchedulingLink{
+				{URL: "https://cal.com/jane/intro", Provider: "Cal.com"},
+				{URL: "https://meetings.hubspot.com/jane", Provider: "HubSpot"},
+			},
+		},
+		{
+			name: "google appointments",
+			body: `<a href="https://calendar.app.google/appointments/abc123">book</a>`,
+			want: []SchedulingLink{{URL: "https://calendar.app.google/appointments/abc123", Provider: "Google Calendar"}},
+		},
+		{
+			name: "greenhouse availability, not its job board",
+			body: "https://app.greenhouse.io/candidate_availability/42 and https://boards.greenhouse.io/acme/jobs/1",
+			want: []SchedulingLink{{URL: "https://app.greenhouse.io/candidate_availability/42", Provider: "Greenhouse"}},
+		},
+		{
+			name: "no booking links",
+			body: "We will be in touch at https://example.com/careers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractSchedulingLinks(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractSchedulingLinks = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Package actions tracks pending to-dos attached to applications, such as
+// "schedule your interview" when a recruiter sends a booking link or "send a
+// thank-you note" once an interview is over.
+package actions
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/interviews"
+)
+
+// Action kinds.
+const (
+	KindScheduleInterview = "schedule_interview"
+	KindReplyEmail        = "reply_email"
+	KindSendThankYou      = "send_thank_you"
+	KindTask              = "task" // free-form to-do, e.g. created by an automation rule
+)
+
+// Action statuses.
+const (
+	StatusPending   = "pending"
+	StatusDone      = "done"
+	StatusDismissed = "dismissed"
+)
+
+// Action is a pending to-do on an application.
+type Action struct {
+	ID            string  `json:"id"`
+	ApplicationID string  `json:"applicationId"`
+	Kind          string  `json:"kind"`
+	URL           *string `json:"url"`
+	Provider      *string `json:"provider"`
+	Title         *string `json:"title"` // what to do, set on tasks
+	// InterviewID, RecipientName and RecipientEmail are set on thank-you
+	// actions: the interview to follow up on and who to thank.
+	InterviewID    *string       `json:"interviewId"`
+	RecipientName  *string       `json:"recipientName"`
+	RecipientEmail *string       `json:"recipientEmail"`
+	Draft          *agents.Draft `json:"draft"` // pre-drafted note, if any
+	Status         string        `json:"status"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	ResolvedAt     *time.Time    `json:"resolvedAt"`
+}
+
+// Service manages application actions.
+type Service struct {
+	db     *sql.DB
+	agents *agents.Client
+}
+
+// NewService creates an action service and registers it to clear scheduling
+// actions when an interview gets scheduled. The agents client drafts
+// thank-you notes.
+func NewService(db *sql.DB, interviewService *interviews.Service, agentsClient *agents.Client) *Service {
+	s := &Service{db: db, agents: agentsClient}
+	interviewService.AddHook(s)
+	return s
+}
+
+const columns = `id, application_id, kind, url, provider, title, interview_id, recipient_name, recipient_email,
+	draft_subject, draft_body, status, created_at, resolved_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*Action, error) {
+	a := &Action{}
+	var subject, body sql.NullString
+	if err := row.Scan(&a.ID, &a.ApplicationID, &a.Kind, &a.URL, &a.Provider, &a.Title, &a.InterviewID, &a.RecipientName,
+		&a.RecipientEmail, &subject, &body, &a.Status, &a.CreatedAt, &a.ResolvedAt); err != nil {
+		return nil, err
+	}
+	if body.Valid {
+		a.Draft = &agents.Draft{Subject: subject.String, Body: body.String}
+	}
+	return a, nil
+}
+
+// List returns the user's actions, optionally only pending ones for one
+// application. Listed across applications, pending actions of snoozed
+// applications are left out.
+func (s *Service) List(ctx context.Context, userID string, applicationID *string, pendingOnly bool) ([]*Action, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM application_actions
+		WHERE user_id = $1
+		  AND ($2::uuid IS NULL OR application_id = $2::uuid)
+		  AND (NOT $3 OR status = 'pending')
+		  AND ($2::uuid IS NOT NULL OR NOT $3 OR application_id NOT IN (
+			SELECT id FROM applications WHERE user_id = $1 AND snoozed_until IS NOT NULL))
+		ORDER BY created_at DESC`,
+		userID, applicationID, pendingOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Action
+	for rows.Next() {
+		a, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// AddSchedulingLinks records a pending "schedule your interview" action for
+// each scheduling link found in an email about the application. Links that
+// already have a pending action are skipped.
+func (s *Service) AddSchedulingLinks(ctx context.Context, userID, applicationID string, links []SchedulingLink) error {
+	for _, l := range links {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO application_actions (application_id, user_id, kind, url, provider)
+			SELECT $1, $2, $3, $4, $5
+			WHERE NOT EXISTS (
+				SELECT 1 FROM application_actions
+				WHERE application_id = $1 AND kind = $3 AND url = $4 AND status = 'pending'
+			)`,
+			applicationID, userID, KindScheduleInterview, l.URL, l.Provider)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add records a pending action of the given kind on one of the user's
+// applications, unless one is already pending.
+func (s *Service) Add(ctx context.Context, userID, applicationID, kind string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO application_actions (application_id, user_id, kind)
+		SELECT a.id, a.user_id, $3 FROM applications a
+		WHERE a.id = $1 AND a.user_id = $2 AND NOT EXISTS (
+			SELECT 1 FROM application_actions
+			WHERE application_id = $1 AND kind = $3 AND status = 'pending'
+		)`,
+		applicationID, userID, kind)
+	return err
+}
+
+// AddTask records a pending task with the given title on one of the user's
+// applications, unless the same task is already pending.
+func (s *Service) AddTask(ctx context.Context, userID, applicationID, title string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO application_actions (application_id, user_id, kind, title)
+		SELECT a.id, a.user_id, $3, $4 FROM applications a
+		WHERE a.id = $1 AND a.user_id = $2 AND NOT EXISTS (
+			SELECT 1 FROM application_actions
+			WHERE application_id = $1 AND kind = $3 AND title = $4 AND status = 'pending'
+		)`,
+		applicationID, userID, KindTask, title)
+	return err
+}
+
+// Resolve marks an action done or dismissed. It reports false if no pending
+// action matched.
+func (s *Service) Resolve(ctx context.Context, userID, id, status string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE application_actions SET status = $3, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND status = 'pending'`,
+		id, userID, status)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// InterviewSaved clears pending scheduling actions once a confirmed interview
+// exists for the application, and dismisses the thank-you action of an
+// interview that was cancelled.
+func (s *Service) InterviewSaved(ctx context.Context, iv *interviews.Interview) error {
+	switch iv.Status {
+	case interviews.StatusScheduled:
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE application_actions SET status = 'done', resolved_at = CURRENT_TIMESTAMP
+			WHERE application_id = $1 AND kind = $2 AND status = 'pending'`,
+			iv.ApplicationID, KindScheduleInterview)
+		return err
+	case interviews.StatusCancelled:
+		return s.dismissThankYou(ctx, iv.ID)
+	}
+	return nil
+}
+
+// InterviewDeleted is a no-op; deleting an interview does not reopen actions,
+// and its thank-you action is deleted with it.
+func (s *Service) InterviewDeleted(context.Context, *interviews.Interview) error {
+	return nil
+}
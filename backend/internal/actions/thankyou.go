@@ -0,0 +1,142 @@
+package actions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+)
+
+// thankYouWindow is how long after an interview ends a thank-you note is
+// still worth prompting for. Older interviews are never prompted, so turning
+// the feature on does not flood users with stale to-dos.
+const thankYouWindow = 72 * time.Hour
+
+// ErrThankYouNotFound is returned when drafting an action that is not one
+// of the user's pending thank-you notes.
+var ErrThankYouNotFound = apperr.New(apperr.NotFound, "thank-you note not found")
+
+type thankYou struct {
+	id, userID string
+	autoDraft  bool
+}
+
+// AddThankYous records a pending "send a thank-you note" action, addressed
+// to the interviewer, for every interview that ended within the last
+// thankYouWindow. Each interview gets one action, so a dismissed or sent
+// note is never prompted again. Users who turned on thank-you drafts get
+// the note pre-drafted; drafting failures are logged and leave the action
+// undrafted. It is intended to run from the scheduler.
+func (s *Service) AddThankYous(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH added AS (
+			INSERT INTO application_actions (application_id, user_id, kind, interview_id, recipient_name, recipient_email)
+			SELECT i.application_id, i.user_id, $1, i.id, i.interviewer_name, i.interviewer_email
+			FROM interviews i
+			WHERE i.status <> 'cancelled' AND i.ends_at <= CURRENT_TIMESTAMP AND i.ends_at > $2
+				AND NOT EXISTS (SELECT 1 FROM application_actions x WHERE x.interview_id = i.id AND x.kind = $1)
+			RETURNING id, user_id
+		)
+		SELECT added.id, added.user_id, COALESCE(u.thank_you_drafts, FALSE)
+		FROM added LEFT JOIN users u ON u.id = added.user_id`,
+		KindSendThankYou, time.Now().Add(-thankYouWindow))
+	if err != nil {
+		return err
+	}
+	var added []thankYou
+	for rows.Next() {
+		var t thankYou
+		if err := rows.Scan(&t.id, &t.userID, &t.autoDraft); err != nil {
+			rows.Close()
+			return err
+		}
+		added = append(added, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range added {
+		if !t.autoDraft || s.agents == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.DraftThankYou(auth.WithUserID(ctx, t.userID), t.userID, t.id); err != nil {
+			log.Printf("Failed to draft thank-you note %s: %v", t.id, err)
+		}
+	}
+	return nil
+}
+
+// DraftThankYou has the agents service draft the note for one of the user's
+// pending thank-you actions, replacing any earlier draft.
+func (s *Service) DraftThankYou(ctx context.Context, userID, id string) (*Action, error) {
+	var company, position, title string
+	var recipient sql.NullString
+	var startsAt time.Time
+	var language *string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT a.company, a.position, x.recipient_name, i.title, i.starts_at, LEFT(u.locale, 2)
+		FROM application_actions x
+		JOIN applications a ON a.id = x.application_id
+		JOIN interviews i ON i.id = x.interview_id
+		LEFT JOIN users u ON u.id = x.user_id
+		WHERE x.id = $1 AND x.user_id = $2 AND x.kind = $3 AND x.status = 'pending'`,
+		id, userID, KindSendThankYou).Scan(&company, &position, &recipient, &title, &startsAt, &language)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrThankYouNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req := &agentspb.DraftEmailRequest{
+		Kind:          "thank_you",
+		Company:       company,
+		Position:      position,
+		RecipientName: recipient.String,
+		Context:       fmt.Sprintf("%s on %s", title, startsAt.Format("January 2")),
+	}
+	if language != nil {
+		req.Language = *language
+	}
+	draft, err := s.agents.DraftEmail(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	a, err := scan(s.db.QueryRowContext(ctx, `
+		UPDATE application_actions SET draft_subject = $3, draft_body = $4
+		WHERE id = $1 AND user_id = $2 AND status = 'pending'
+		RETURNING `+columns,
+		id, userID, draft.Subject, draft.Body))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Sent or dismissed while the draft was being written.
+		return nil, ErrThankYouNotFound
+	}
+	return a, err
+}
+
+// SetThankYouDrafts turns pre-drafting of thank-you notes on or off for the
+// user. Drafts count against the user's LLM spend quota.
+func (s *Service) SetThankYouDrafts(ctx context.Context, userID string, enabled bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET thank_you_drafts = $2 WHERE id = $1`, userID, enabled)
+	return err
+}
+
+// dismissThankYou dismisses the pending thank-you action of an interview.
+func (s *Service) dismissThankYou(ctx context.Context, interviewID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE application_actions SET status = 'dismissed', resolved_at = CURRENT_TIMESTAMP
+		WHERE interview_id = $1 AND kind = $2 AND status = 'pending'`,
+		interviewID, KindSendThankYou)
+	return err
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// FailedJob is a processing job that ended in the failed state and will not
+// be retried until it is requeued.
+type FailedJob struct {
+	ID        string
+	UserID    string
+	StartDate time.Time
+	Errors    []string
+	UpdatedAt time.Time
+}
+
+// DeadLetters lists failed processing jobs, most recent first.
+func (s *Service) DeadLetters(ctx context.Context, limit int) ([]*FailedJob, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, start_date, COALESCE(errors, '{}'), updated_at
+		FROM processing_jobs
+		WHERE status = 'failed'
+		ORDER BY updated_at DESC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*FailedJob
+	for rows.Next() {
+		j := &FailedJob{}
+		if err := rows.Scan(&j.ID, &j.UserID, &j.StartDate, pq.Array(&j.Errors), &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// Requeue resets failed processing jobs to pending so they run again. With
+// no IDs every failed job is requeued. It returns the number of jobs reset.
+func (s *Service) Requeue(ctx context.Context, ids ...string) (int64, error) {
+	query := `
+		UPDATE processing_jobs
+		SET status = 'pending', progress = 0, current_stage = NULL, errors = NULL, completed_at = NULL
+		WHERE status = 'failed'`
+	args := []interface{}{}
+	if len(ids) > 0 {
+		query += ` AND id::text = ANY($1)`
+		args = append(args, pq.Array(ids))
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
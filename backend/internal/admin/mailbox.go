@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jobtracker/backend/internal/quotas"
+)
+
+// Resync is the outcome of a mailbox resync.
+type Resync struct {
+	UserID        string
+	ClearedEmails int64
+	JobID         string
+}
+
+// ResyncMailbox forgets the cached emails a user received since the given
+// time and queues a processing job over the same range, so the agents read
+// the messages again instead of skipping them as already processed.
+func (s *Service) ResyncMailbox(ctx context.Context, user string, since time.Time) (*Resync, error) {
+	userID, err := s.ResolveUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quotas.Check(ctx, userID, quotas.MetricExports, 1); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM email_cache WHERE user_id = $1 AND (date IS NULL OR date >= $2)`,
+		userID, since)
+	if err != nil {
+		return nil, err
+	}
+	cleared, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	output := filepath.Join(s.cfg.ExcelOutputDir, fmt.Sprintf("resync-%s-%s.xlsx", userID, time.Now().UTC().Format("20060102T150405")))
+	var jobID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO processing_jobs (user_id, start_date, output_path, kind)
+		VALUES ($1, $2, $3, 'backfill')
+		RETURNING id`,
+		userID, since, output).Scan(&jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &Resync{UserID: userID, ClearedEmails: cleared, JobID: jobID}, nil
+}
@@ -0,0 +1,215 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// ErrNotAdmin is returned when someone other than an administrator asks for
+// something only administrators may see or change.
+var ErrNotAdmin = apperr.New(apperr.Forbidden, "only administrators can do this")
+
+// Require returns ErrNotAdmin unless the user is an administrator. Every
+// administrator-only operation, in this package and others, checks with it.
+func Require(ctx context.Context, db *sql.DB, userID string) error {
+	var admin bool
+	err := db.QueryRowContext(ctx, `SELECT is_admin FROM users WHERE id = $1`, userID).Scan(&admin)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && !admin {
+		return ErrNotAdmin
+	}
+	return err
+}
+
+// metricsStorageUsers caps the users listed by storage.
+const metricsStorageUsers = 20
+
+// InstanceMetrics is an operator's view of instance health.
+type InstanceMetrics struct {
+	From  string      `json:"from"` // YYYY-MM-DD, UTC
+	To    string      `json:"to"`
+	Users *UserCounts `json:"users"`
+	// Days has one entry per UTC day from From to To.
+	Days []*DailyMetrics `json:"days"`
+	// SyncErrorRate is the share of syncs in the period that failed.
+	SyncErrorRate float64           `json:"syncErrorRate"`
+	LLMSpendCents float64           `json:"llmSpendCents"`
+	LLMOperations []*OperationSpend `json:"llmOperations"` // most expensive first
+	Storage       []*UserStorage    `json:"storage"`       // largest first
+}
+
+// UserCounts counts users by activity. A user is active on a day they
+// changed an application themselves.
+type UserCounts struct {
+	Total               int `json:"total"`
+	Active1d            int `json:"active1d"`
+	Active7d            int `json:"active7d"`
+	Active30d           int `json:"active30d"`
+	MailboxConnected    int `json:"mailboxConnected"`
+	MailboxDisconnected int `json:"mailboxDisconnected"` // Google revoked access
+}
+
+// DailyMetrics is one day of instance activity.
+type DailyMetrics struct {
+	Date        string `json:"date"`
+	ActiveUsers int    `json:"activeUsers"`
+	Syncs       int    `json:"syncs"` // processing jobs started
+	SyncsFailed int    `json:"syncsFailed"`
+	// EmailsProcessed counts emails cached by syncs, EmailsClassified
+	// those the agents service matched to an application status.
+	EmailsProcessed  int     `json:"emailsProcessed"`
+	EmailsClassified int     `json:"emailsClassified"`
+	LLMRequests      int     `json:"llmRequests"`
+	LLMSpendCents    float64 `json:"llmSpendCents"`
+}
+
+// OperationSpend is the LLM spend of one agents operation.
+type OperationSpend struct {
+	Operation  string  `json:"operation"`
+	Requests   int     `json:"requests"`
+	SpendCents float64 `json:"spendCents"`
+}
+
+// UserStorage is what one user stores on the instance.
+type UserStorage struct {
+	UserID          string `json:"userId"`
+	Email           string `json:"email"`
+	Emails          int    `json:"emails"`
+	EmailBytes      int64  `json:"emailBytes"` // cached bodies and snippets
+	AttachmentBytes int64  `json:"attachmentBytes"`
+}
+
+// Metrics returns instance-wide aggregates over the last days (default 30,
+// at most 365), for administrators only.
+func (s *Service) Metrics(ctx context.Context, adminID string, days int) (*InstanceMetrics, error) {
+	if err := Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	if days <= 0 || days > 365 {
+		days = 30
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, 1-days)
+
+	m := &InstanceMetrics{
+		From: from.Format("2006-01-02"), To: today.Format("2006-01-02"),
+		Users: &UserCounts{}, LLMOperations: []*OperationSpend{}, Storage: []*UserStorage{},
+	}
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM users),
+			COUNT(DISTINCT user_id) FILTER (WHERE occurred_at >= CURRENT_TIMESTAMP - INTERVAL '1 day'),
+			COUNT(DISTINCT user_id) FILTER (WHERE occurred_at >= CURRENT_TIMESTAMP - INTERVAL '7 days'),
+			COUNT(DISTINCT user_id),
+			(SELECT COUNT(*) FROM users WHERE COALESCE(refresh_token, '') <> '' AND mailbox_disconnected_at IS NULL),
+			(SELECT COUNT(*) FROM users WHERE mailbox_disconnected_at IS NOT NULL)
+		FROM application_event_stream
+		WHERE actor = 'user' AND occurred_at >= CURRENT_TIMESTAMP - INTERVAL '30 days'`).Scan(
+		&m.Users.Total, &m.Users.Active1d, &m.Users.Active7d, &m.Users.Active30d,
+		&m.Users.MailboxConnected, &m.Users.MailboxDisconnected); err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]*DailyMetrics)
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		d := &DailyMetrics{Date: day.Format("2006-01-02")}
+		byDate[d.Date] = d
+		m.Days = append(m.Days, d)
+	}
+	// Each query yields (UTC day, value, value) rows merged into byDate.
+	daily := []struct {
+		query string
+		add   func(d *DailyMetrics, a, b float64)
+	}{
+		{`SELECT (occurred_at AT TIME ZONE 'UTC')::date, COUNT(DISTINCT user_id), 0
+			FROM application_event_stream WHERE actor = 'user' AND occurred_at >= $1 GROUP BY 1`,
+			func(d *DailyMetrics, a, _ float64) { d.ActiveUsers = int(a) }},
+		{`SELECT (created_at AT TIME ZONE 'UTC')::date, COUNT(*), COUNT(*) FILTER (WHERE status = 'failed')
+			FROM processing_jobs WHERE created_at >= $1 GROUP BY 1`,
+			func(d *DailyMetrics, a, b float64) { d.Syncs, d.SyncsFailed = int(a), int(b) }},
+		{`SELECT (processed_at AT TIME ZONE 'UTC')::date, COUNT(*), COUNT(classified_status)
+			FROM email_cache WHERE processed_at >= $1 GROUP BY 1`,
+			func(d *DailyMetrics, a, b float64) { d.EmailsProcessed, d.EmailsClassified = int(a), int(b) }},
+		{`SELECT (created_at AT TIME ZONE 'UTC')::date, COUNT(*), COALESCE(SUM(cost_cents), 0)
+			FROM llm_usage WHERE created_at >= $1 GROUP BY 1`,
+			func(d *DailyMetrics, a, b float64) { d.LLMRequests, d.LLMSpendCents = int(a), b }},
+	}
+	for _, q := range daily {
+		if err := s.mergeDaily(ctx, q.query, from, byDate, q.add); err != nil {
+			return nil, err
+		}
+	}
+	var syncs, failed int
+	for _, d := range m.Days {
+		syncs += d.Syncs
+		failed += d.SyncsFailed
+		m.LLMSpendCents += d.LLMSpendCents
+	}
+	if syncs > 0 {
+		m.SyncErrorRate = float64(failed) / float64(syncs)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT operation, COUNT(*), COALESCE(SUM(cost_cents), 0)
+		FROM llm_usage WHERE created_at >= $1
+		GROUP BY operation ORDER BY 3 DESC`, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		o := &OperationSpend{}
+		if err := rows.Scan(&o.Operation, &o.Requests, &o.SpendCents); err != nil {
+			return nil, err
+		}
+		m.LLMOperations = append(m.LLMOperations, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT u.id, u.email, COALESCE(e.emails, 0), COALESCE(e.bytes, 0), COALESCE(r.bytes, 0)
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, COUNT(*) AS emails,
+				SUM(COALESCE(octet_length(body_text), 0) + COALESCE(octet_length(snippet), 0)) AS bytes
+			FROM email_cache GROUP BY user_id
+		) e ON e.user_id = u.id
+		LEFT JOIN (SELECT user_id, SUM(size_bytes) AS bytes FROM resumes GROUP BY user_id) r ON r.user_id = u.id
+		ORDER BY COALESCE(e.bytes, 0) + COALESCE(r.bytes, 0) DESC, u.email
+		LIMIT $1`, metricsStorageUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		u := &UserStorage{}
+		if err := rows.Scan(&u.UserID, &u.Email, &u.Emails, &u.EmailBytes, &u.AttachmentBytes); err != nil {
+			return nil, err
+		}
+		m.Storage = append(m.Storage, u)
+	}
+	return m, rows.Err()
+}
+
+func (s *Service) mergeDaily(ctx context.Context, query string, from time.Time, byDate map[string]*DailyMetrics, add func(d *DailyMetrics, a, b float64)) error {
+	rows, err := s.db.QueryContext(ctx, query, from)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day time.Time
+		var a, b float64
+		if err := rows.Scan(&day, &a, &b); err != nil {
+			return err
+		}
+		if d := byDate[day.Format("2006-01-02")]; d != nil {
+			add(d, a, b)
+		}
+	}
+	return rows.Err()
+}
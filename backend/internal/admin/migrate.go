@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"context"
+	"os"
+)
+
+// Migrate applies the schema file, normally database/init.sql. The schema is
+// written to be idempotent, so running it against an existing database adds
+// new tables, columns and indexes without touching existing data.
+func (s *Service) Migrate(ctx context.Context, path string) error {
+	schema, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Without arguments lib/pq sends the file as a simple query, which may
+	// contain multiple statements.
+	if _, err := tx.ExecContext(ctx, string(schema)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
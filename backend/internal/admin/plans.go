@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/jobtracker/backend/internal/quotas"
+)
+
+// SetUserPlan moves a user, by ID or email, to another quota plan and
+// returns their usage against it. Only administrators may change plans.
+func (s *Service) SetUserPlan(ctx context.Context, adminID, user, plan string) (*quotas.Usage, error) {
+	if err := Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	userID, err := s.ResolveUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quotas.SetPlan(ctx, userID, plan); err != nil {
+		return nil, err
+	}
+	return s.quotas.Usage(ctx, userID)
+}
@@ -0,0 +1,45 @@
+// Package admin implements instance maintenance operations for
+// jobtrackerctl: managing administrators, resyncing mailboxes, requeueing
+// failed processing jobs and applying the schema. Expired data is purged by
+// the retention package. It also serves instance-wide metrics to
+// administrators.
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/quotas"
+)
+
+// ErrUserNotFound is returned when no user matches an ID or email.
+var ErrUserNotFound = apperr.New(apperr.NotFound, "user not found")
+
+// Service runs maintenance operations directly against the database.
+type Service struct {
+	cfg    *config.Config
+	db     *sql.DB
+	quotas *quotas.Service
+}
+
+// NewService creates an admin service. Mailbox resyncs queue processing
+// jobs, so they are checked against the user's exports quota.
+func NewService(cfg *config.Config, db *sql.DB, quotaService *quotas.Service) *Service {
+	return &Service{cfg: cfg, db: db, quotas: quotaService}
+}
+
+// ResolveUser returns the ID of the user with the given ID or email.
+func (s *Service) ResolveUser(ctx context.Context, user string) (string, error) {
+	var id string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id FROM users WHERE id = $1 OR LOWER(email) = LOWER($1)`,
+		strings.TrimSpace(user)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	return id, err
+}
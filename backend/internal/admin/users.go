@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// ErrInvalidEmail is returned when an administrator's email is malformed.
+var ErrInvalidEmail = apperr.New(apperr.Validation, "invalid email address")
+
+// User is an instance user as shown by jobtrackerctl.
+type User struct {
+	ID      string
+	Email   string
+	Name    string
+	IsAdmin bool
+}
+
+// CreateAdmin grants administrator rights to the user with the given email.
+// If nobody has signed in with that address yet, a placeholder user is
+// created and adopted on their first Google sign-in. created reports whether
+// a new user row was inserted.
+func (s *Service) CreateAdmin(ctx context.Context, email, name string) (user *User, created bool, err error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return nil, false, ErrInvalidEmail
+	}
+	email = strings.ToLower(addr.Address)
+
+	user = &User{IsAdmin: true}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO users (id, email, name, is_admin)
+		VALUES (uuid_generate_v4()::text, $1, NULLIF($2, ''), TRUE)
+		ON CONFLICT (email) DO UPDATE
+		SET is_admin = TRUE, name = COALESCE(users.name, EXCLUDED.name)
+		RETURNING id, email, COALESCE(name, ''), (xmax = 0)`,
+		email, strings.TrimSpace(name)).Scan(&user.ID, &user.Email, &user.Name, &created)
+	if err != nil {
+		return nil, false, err
+	}
+	return user, created, nil
+}
+
+// RevokeAdmin removes administrator rights from a user.
+func (s *Service) RevokeAdmin(ctx context.Context, user string) error {
+	id, err := s.ResolveUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `UPDATE users SET is_admin = FALSE WHERE id = $1`, id)
+	return err
+}
+
+// Admins lists the instance administrators.
+func (s *Service) Admins(ctx context.Context) ([]*User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, email, COALESCE(name, ''), is_admin
+		FROM users WHERE is_admin
+		ORDER BY email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		u := &User{}
+		if err := rows.Scan(&u.ID, &u.Email, &u.Name, &u.IsAdmin); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
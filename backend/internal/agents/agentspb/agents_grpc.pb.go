@@ -0,0 +1,348 @@
+// Contract between the Go backend and the Python agents service.
+//
+// Regenerate the Go and Python stubs with `make proto` after editing.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.1
+// source: agents.proto
+
+package agentspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AgentsService_ClassifyEmail_FullMethodName        = "/jobtracker.agents.v1.AgentsService/ClassifyEmail"
+	AgentsService_ExtractApplication_FullMethodName   = "/jobtracker.agents.v1.AgentsService/ExtractApplication"
+	AgentsService_DraftEmail_FullMethodName           = "/jobtracker.agents.v1.AgentsService/DraftEmail"
+	AgentsService_SummarizeApplication_FullMethodName = "/jobtracker.agents.v1.AgentsService/SummarizeApplication"
+	AgentsService_SummarizeInterview_FullMethodName   = "/jobtracker.agents.v1.AgentsService/SummarizeInterview"
+	AgentsService_ExtractOffer_FullMethodName         = "/jobtracker.agents.v1.AgentsService/ExtractOffer"
+)
+
+// AgentsServiceClient is the client API for AgentsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AgentsServiceClient interface {
+	// Decide whether an email is about a job application and, if so, what
+	// it says about the application's status.
+	ClassifyEmail(ctx context.Context, in *ClassifyEmailRequest, opts ...grpc.CallOption) (*ClassifyEmailResponse, error)
+	// Extract application fields (company, position, ...) from an email.
+	ExtractApplication(ctx context.Context, in *ExtractApplicationRequest, opts ...grpc.CallOption) (*ExtractApplicationResponse, error)
+	// Draft an email such as a follow-up or thank-you note. The text is
+	// streamed as it is generated; the final chunk has done set.
+	DraftEmail(ctx context.Context, in *DraftEmailRequest, opts ...grpc.CallOption) (AgentsService_DraftEmailClient, error)
+	// Summarize an application's history in a sentence or two, e.g.
+	// "Applied Mar 3 via referral; recruiter screen Mar 12; awaiting onsite
+	// scheduling".
+	SummarizeApplication(ctx context.Context, in *SummarizeApplicationRequest, opts ...grpc.CallOption) (*SummarizeApplicationResponse, error)
+	// Pull the key takeaways and follow-up action items out of the user's
+	// notes or transcript of an interview.
+	SummarizeInterview(ctx context.Context, in *SummarizeInterviewRequest, opts ...grpc.CallOption) (*SummarizeInterviewResponse, error)
+	// Extract the compensation from an offer letter (email or attachment).
+	ExtractOffer(ctx context.Context, in *ExtractOfferRequest, opts ...grpc.CallOption) (*ExtractOfferResponse, error)
+}
+
+type agentsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAgentsServiceClient(cc grpc.ClientConnInterface) AgentsServiceClient {
+	return &agentsServiceClient{cc}
+}
+
+func (c *agentsServiceClient) ClassifyEmail(ctx context.Context, in *ClassifyEmailRequest, opts ...grpc.CallOption) (*ClassifyEmailResponse, error) {
+	out := new(ClassifyEmailResponse)
+	err := c.cc.Invoke(ctx, AgentsService_ClassifyEmail_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentsServiceClient) ExtractApplication(ctx context.Context, in *ExtractApplicationRequest, opts ...grpc.CallOption) (*ExtractApplicationResponse, error) {
+	out := new(ExtractApplicationResponse)
+	err := c.cc.Invoke(ctx, AgentsService_ExtractApplication_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentsServiceClient) DraftEmail(ctx context.Context, in *DraftEmailRequest, opts ...grpc.CallOption) (AgentsService_DraftEmailClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AgentsService_ServiceDesc.Streams[0], AgentsService_DraftEmail_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentsServiceDraftEmailClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AgentsService_DraftEmailClient interface {
+	Recv() (*DraftEmailChunk, error)
+	grpc.ClientStream
+}
+
+type agentsServiceDraftEmailClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentsServiceDraftEmailClient) Recv() (*DraftEmailChunk, error) {
+	m := new(DraftEmailChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentsServiceClient) SummarizeApplication(ctx context.Context, in *SummarizeApplicationRequest, opts ...grpc.CallOption) (*SummarizeApplicationResponse, error) {
+	out := new(SummarizeApplicationResponse)
+	err := c.cc.Invoke(ctx, AgentsService_SummarizeApplication_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentsServiceClient) SummarizeInterview(ctx context.Context, in *SummarizeInterviewRequest, opts ...grpc.CallOption) (*SummarizeInterviewResponse, error) {
+	out := new(SummarizeInterviewResponse)
+	err := c.cc.Invoke(ctx, AgentsService_SummarizeInterview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentsServiceClient) ExtractOffer(ctx context.Context, in *ExtractOfferRequest, opts ...grpc.CallOption) (*ExtractOfferResponse, error) {
+	out := new(ExtractOfferResponse)
+	err := c.cc.Invoke(ctx, AgentsService_ExtractOffer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AgentsServiceServer is the server API for AgentsService service.
+// All implementations must embed UnimplementedAgentsServiceServer
+// for forward compatibility
+type AgentsServiceServer interface {
+	// Decide whether an email is about a job application and, if so, what
+	// it says about the application's status.
+	ClassifyEmail(context.Context, *ClassifyEmailRequest) (*ClassifyEmailResponse, error)
+	// Extract application fields (company, position, ...) from an email.
+	ExtractApplication(context.Context, *ExtractApplicationRequest) (*ExtractApplicationResponse, error)
+	// Draft an email such as a follow-up or thank-you note. The text is
+	// streamed as it is generated; the final chunk has done set.
+	DraftEmail(*DraftEmailRequest, AgentsService_DraftEmailServer) error
+	// Summarize an application's history in a sentence or two, e.g.
+	// "Applied Mar 3 via referral; recruiter screen Mar 12; awaiting onsite
+	// scheduling".
+	SummarizeApplication(context.Context, *SummarizeApplicationRequest) (*SummarizeApplicationResponse, error)
+	// Pull the key takeaways and follow-up action items out of the user's
+	// notes or transcript of an interview.
+	SummarizeInterview(context.Context, *SummarizeInterviewRequest) (*SummarizeInterviewResponse, error)
+	// Extract the compensation from an offer letter (email or attachment).
+	ExtractOffer(context.Context, *ExtractOfferRequest) (*ExtractOfferResponse, error)
+	mustEmbedUnimplementedAgentsServiceServer()
+}
+
+// UnimplementedAgentsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAgentsServiceServer struct {
+}
+
+func (UnimplementedAgentsServiceServer) ClassifyEmail(context.Context, *ClassifyEmailRequest) (*ClassifyEmailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClassifyEmail not implemented")
+}
+func (UnimplementedAgentsServiceServer) ExtractApplication(context.Context, *ExtractApplicationRequest) (*ExtractApplicationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExtractApplication not implemented")
+}
+func (UnimplementedAgentsServiceServer) DraftEmail(*DraftEmailRequest, AgentsService_DraftEmailServer) error {
+	return status.Errorf(codes.Unimplemented, "method DraftEmail not implemented")
+}
+func (UnimplementedAgentsServiceServer) SummarizeApplication(context.Context, *SummarizeApplicationRequest) (*SummarizeApplicationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SummarizeApplication not implemented")
+}
+func (UnimplementedAgentsServiceServer) SummarizeInterview(context.Context, *SummarizeInterviewRequest) (*SummarizeInterviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SummarizeInterview not implemented")
+}
+func (UnimplementedAgentsServiceServer) ExtractOffer(context.Context, *ExtractOfferRequest) (*ExtractOfferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExtractOffer not implemented")
+}
+func (UnimplementedAgentsServiceServer) mustEmbedUnimplementedAgentsServiceServer() {}
+
+// UnsafeAgentsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AgentsServiceServer will
+// result in compilation errors.
+type UnsafeAgentsServiceServer interface {
+	mustEmbedUnimplementedAgentsServiceServer()
+}
+
+func RegisterAgentsServiceServer(s grpc.ServiceRegistrar, srv AgentsServiceServer) {
+	s.RegisterService(&AgentsService_ServiceDesc, srv)
+}
+
+func _AgentsService_ClassifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClassifyEmailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentsServiceServer).ClassifyEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentsService_ClassifyEmail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentsServiceServer).ClassifyEmail(ctx, req.(*ClassifyEmailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentsService_ExtractApplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtractApplicationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentsServiceServer).ExtractApplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentsService_ExtractApplication_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentsServiceServer).ExtractApplication(ctx, req.(*ExtractApplicationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentsService_DraftEmail_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DraftEmailRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentsServiceServer).DraftEmail(m, &agentsServiceDraftEmailServer{stream})
+}
+
+type AgentsService_DraftEmailServer interface {
+	Send(*DraftEmailChunk) error
+	grpc.ServerStream
+}
+
+type agentsServiceDraftEmailServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentsServiceDraftEmailServer) Send(m *DraftEmailChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AgentsService_SummarizeApplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SummarizeApplicationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentsServiceServer).SummarizeApplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentsService_SummarizeApplication_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentsServiceServer).SummarizeApplication(ctx, req.(*SummarizeApplicationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentsService_SummarizeInterview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SummarizeInterviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentsServiceServer).SummarizeInterview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentsService_SummarizeInterview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentsServiceServer).SummarizeInterview(ctx, req.(*SummarizeInterviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentsService_ExtractOffer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtractOfferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentsServiceServer).ExtractOffer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentsService_ExtractOffer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentsServiceServer).ExtractOffer(ctx, req.(*ExtractOfferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AgentsService_ServiceDesc is the grpc.ServiceDesc for AgentsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AgentsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jobtracker.agents.v1.AgentsService",
+	HandlerType: (*AgentsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ClassifyEmail",
+			Handler:    _AgentsService_ClassifyEmail_Handler,
+		},
+		{
+			MethodName: "ExtractApplication",
+			Handler:    _AgentsService_ExtractApplication_Handler,
+		},
+		{
+			MethodName: "SummarizeApplication",
+			Handler:    _AgentsService_SummarizeApplication_Handler,
+		},
+		{
+			MethodName: "SummarizeInterview",
+			Handler:    _AgentsService_SummarizeInterview_Handler,
+		},
+		{
+			MethodName: "ExtractOffer",
+			Handler:    _AgentsService_ExtractOffer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DraftEmail",
+			Handler:       _AgentsService_DraftEmail_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "agents.proto",
+}
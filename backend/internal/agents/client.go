@@ -0,0 +1,316 @@
+// Package agents is the gRPC client for the Python agents service. The
+// contract lives in shared/proto/agents.proto; agentspb holds the generated
+// Go stubs.
+package agents
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/quotas"
+)
+
+// Client calls the agents service with per-call deadlines.
+type Client struct {
+	conn         *grpc.ClientConn
+	rpc          agentspb.AgentsServiceClient
+	timeout      time.Duration
+	draftTimeout time.Duration
+	quotas       *quotas.Service
+	experiment   Experiment
+	lanes        *lanes
+}
+
+// Experiment chooses the variant, a prompt and model, that emails are
+// classified with, and records the results of emails sampled into an A/B
+// experiment.
+type Experiment interface {
+	// Variants returns the variant to classify the email with and, when
+	// the email is sampled into a running experiment, a challenger variant
+	// to classify it with as well ("" for none).
+	Variants(ctx context.Context, email *agentspb.Email) (variant, challenger string)
+	// Record stores a sampled email's results; challenger is nil if the
+	// challenger variant failed.
+	Record(ctx context.Context, email *agentspb.Email, result, challenger *agentspb.ClassifyEmailResponse)
+}
+
+// NewClient connects to the agents service at AGENTS_GRPC_ADDR. The
+// connection is established lazily, so the backend can start before the
+// agents service is up. Calls made on behalf of a user (a context carrying
+// auth.UserID) are metered against their LLM spend quota, and calls wait for
+// a slot in their priority lane (see WithPriority). experiment may be
+// nil to always classify with the agents service's default variant.
+func NewClient(cfg *config.Config, quotaService *quotas.Service, experiment Experiment) (*Client, error) {
+	conn, err := grpc.Dial(cfg.AgentsGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		conn:         conn,
+		rpc:          agentspb.NewAgentsServiceClient(conn),
+		timeout:      time.Duration(cfg.AgentsRPCTimeoutSeconds) * time.Second,
+		draftTimeout: time.Duration(cfg.AgentsDraftTimeoutSeconds) * time.Second,
+		quotas:       quotaService,
+		experiment:   experiment,
+		lanes:        newLanes(cfg.AgentsInteractiveConcurrency, cfg.AgentsBatchConcurrency),
+	}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ClassifyEmail reports whether an email is about a job application and
+// the status it implies. Emails sampled into a classification experiment
+// are classified with the challenger variant too, in parallel; only the
+// production result is returned, and the challenger is not metered against
+// the user's quota.
+func (c *Client) ClassifyEmail(ctx context.Context, email *agentspb.Email) (*agentspb.ClassifyEmailResponse, error) {
+	release, err := c.admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	var variant, challenger string
+	if c.experiment != nil {
+		variant, challenger = c.experiment.Variants(ctx, email)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var challengerResp *agentspb.ClassifyEmailResponse
+	var releaseChallenger func()
+	if challenger != "" {
+		// The challenger is extra work that must not slow production
+		// classification down, so the email is left out of the experiment
+		// rather than waiting when the batch lane is full.
+		if releaseChallenger = c.lanes.tryBatch(); releaseChallenger == nil {
+			challenger = ""
+		}
+	}
+	if challenger != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer releaseChallenger()
+			r, err := c.rpc.ClassifyEmail(ctx, &agentspb.ClassifyEmailRequest{Email: email, Variant: challenger})
+			if err != nil {
+				log.Printf("Challenger classification %s of email %s failed: %v", challenger, email.Id, err)
+				return
+			}
+			challengerResp = r
+		}()
+	}
+	resp, err := c.rpc.ClassifyEmail(ctx, &agentspb.ClassifyEmailRequest{Email: email, Variant: variant})
+	wg.Wait()
+	if err != nil {
+		return nil, upstream(err)
+	}
+	c.record(ctx, "classify_email", quotas.EstimateTokens(email.Subject, email.Body), quotas.EstimateTokens(resp.Reasoning))
+	if challenger != "" {
+		c.experiment.Record(context.WithoutCancel(ctx), email, resp, challengerResp)
+	}
+	return resp, nil
+}
+
+// ReplayClassifyEmail classifies an email the way ClassifyEmail would have,
+// or with the given variant, and returns the result and the variant used
+// ("" for the agents service's default). The experiment's challenger is
+// never run and no trial is recorded, so replays leave experiments alone.
+func (c *Client) ReplayClassifyEmail(ctx context.Context, email *agentspb.Email, variant string) (*agentspb.ClassifyEmailResponse, string, error) {
+	release, err := c.admit(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer release()
+	if variant == "" && c.experiment != nil {
+		variant, _ = c.experiment.Variants(ctx, email)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.rpc.ClassifyEmail(ctx, &agentspb.ClassifyEmailRequest{Email: email, Variant: variant})
+	if err != nil {
+		return nil, variant, upstream(err)
+	}
+	c.record(ctx, "classify_email", quotas.EstimateTokens(email.Subject, email.Body), quotas.EstimateTokens(resp.Reasoning))
+	return resp, variant, nil
+}
+
+// ExtractApplication extracts application fields from an email.
+func (c *Client) ExtractApplication(ctx context.Context, email *agentspb.Email) (*agentspb.ExtractApplicationResponse, error) {
+	release, err := c.admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.rpc.ExtractApplication(ctx, &agentspb.ExtractApplicationRequest{Email: email})
+	if err != nil {
+		return nil, upstream(err)
+	}
+	c.record(ctx, "extract_application", quotas.EstimateTokens(email.Subject, email.Body), quotas.EstimateTokens(resp.Application.String()))
+	return resp, nil
+}
+
+// Draft is a generated email.
+type Draft struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// DraftEmail generates an email, calling onChunk with each piece of text as
+// it arrives (onChunk may be nil). Returning an error from onChunk cancels
+// the generation.
+func (c *Client) DraftEmail(ctx context.Context, req *agentspb.DraftEmailRequest, onChunk func(text string) error) (*Draft, error) {
+	release, err := c.admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(ctx, c.draftTimeout)
+	defer cancel()
+
+	stream, err := c.rpc.DraftEmail(ctx, req)
+	if err != nil {
+		return nil, upstream(err)
+	}
+
+	var body strings.Builder
+	draft := &Draft{}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, upstream(err)
+		}
+		if chunk.Text != "" {
+			body.WriteString(chunk.Text)
+			if onChunk != nil {
+				if err := onChunk(chunk.Text); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if chunk.Done {
+			draft.Subject = chunk.Subject
+			break
+		}
+	}
+	draft.Body = strings.TrimSpace(body.String())
+	c.record(ctx, "draft_email", quotas.EstimateTokens(req.Company, req.Position, req.RecipientName, req.Context),
+		quotas.EstimateTokens(draft.Subject, draft.Body))
+	return draft, nil
+}
+
+// SummarizeApplication summarizes an application's history.
+func (c *Client) SummarizeApplication(ctx context.Context, req *agentspb.SummarizeApplicationRequest) (string, error) {
+	release, err := c.admit(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.rpc.SummarizeApplication(ctx, req)
+	if err != nil {
+		return "", upstream(err)
+	}
+	input := []string{req.Company, req.Position}
+	for _, e := range req.Events {
+		input = append(input, e.Description)
+	}
+	for _, e := range req.Emails {
+		input = append(input, e.Subject, e.Body, e.Snippet)
+	}
+	c.record(ctx, "summarize_application", quotas.EstimateTokens(input...), quotas.EstimateTokens(resp.Summary))
+	return resp.Summary, nil
+}
+
+// SummarizeInterview pulls the key takeaways and follow-up action items out
+// of interview notes or a transcript.
+func (c *Client) SummarizeInterview(ctx context.Context, req *agentspb.SummarizeInterviewRequest) (*agentspb.SummarizeInterviewResponse, error) {
+	release, err := c.admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.rpc.SummarizeInterview(ctx, req)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	output := append(append([]string{}, resp.Takeaways...), resp.ActionItems...)
+	c.record(ctx, "summarize_interview", quotas.EstimateTokens(req.Title, req.Transcript), quotas.EstimateTokens(output...))
+	return resp, nil
+}
+
+// ExtractOffer extracts the compensation from an offer letter, assuming
+// defaultCurrency when the letter names none.
+func (c *Client) ExtractOffer(ctx context.Context, email *agentspb.Email, defaultCurrency string) (*agentspb.ExtractOfferResponse, error) {
+	release, err := c.admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.rpc.ExtractOffer(ctx, &agentspb.ExtractOfferRequest{Email: email, DefaultCurrency: defaultCurrency})
+	if err != nil {
+		return nil, upstream(err)
+	}
+	c.record(ctx, "extract_offer", quotas.EstimateTokens(email.Subject, email.Body), quotas.EstimateTokens(resp.Offer.String()))
+	return resp, nil
+}
+
+// admit refuses calls for users who have used up their LLM spend quota,
+// then waits for a slot in the call's priority lane. The returned function
+// frees the slot.
+func (c *Client) admit(ctx context.Context) (func(), error) {
+	if userID, ok := auth.UserIDFromContext(ctx); ok && c.quotas != nil {
+		if err := c.quotas.Check(ctx, userID, quotas.MetricLLMSpend, 0); err != nil {
+			return nil, err
+		}
+	}
+	return c.lanes.acquire(ctx)
+}
+
+// record meters a completed call. Token counts are estimated from the text
+// exchanged since the agents service does not report them.
+func (c *Client) record(ctx context.Context, operation string, inputTokens, outputTokens int) {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok || c.quotas == nil {
+		return
+	}
+	if err := c.quotas.RecordLLM(context.WithoutCancel(ctx), userID, operation, inputTokens, outputTokens); err != nil {
+		log.Printf("Failed to record LLM usage for user %s: %v", userID, err)
+	}
+}
+
+// upstream classifies an error from the agents service. Invalid requests
+// are the caller's fault; everything else is an upstream failure.
+func upstream(err error) error {
+	if status.Code(err) == codes.InvalidArgument {
+		return apperr.Wrap(apperr.Validation, err)
+	}
+	return apperr.Wrap(apperr.UpstreamLLM, err)
+}
@@ -0,0 +1,103 @@
+package agents
+
+import (
+	"context"
+)
+
+// Priority is the lane an agents call waits in for a free slot. Interactive
+// calls, made while a user waits on the result, have their own concurrency
+// budget so they do not queue behind a large backfill; batch calls are
+// limited to theirs.
+type Priority int
+
+// Priorities. Calls are interactive unless their context says otherwise.
+const (
+	PriorityInteractive Priority = iota
+	PriorityBatch
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose agents calls wait in p's lane.
+// Background work such as backfills, imports and scheduled jobs should use
+// PriorityBatch.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the lane calls made with ctx wait in.
+func PriorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// lanes bounds the calls in flight per priority. A nil channel is an
+// unlimited lane.
+type lanes struct {
+	interactive chan struct{}
+	batch       chan struct{}
+}
+
+func newLanes(interactive, batch int) *lanes {
+	l := &lanes{}
+	if interactive > 0 {
+		l.interactive = make(chan struct{}, interactive)
+	}
+	if batch > 0 {
+		l.batch = make(chan struct{}, batch)
+	}
+	return l
+}
+
+// acquire waits for a slot in the context's lane and returns the function
+// that frees it. Interactive calls may also take an idle batch slot, so a
+// quiet night's budget is not wasted; batch calls never take interactive
+// ones.
+func (l *lanes) acquire(ctx context.Context) (func(), error) {
+	if PriorityFromContext(ctx) == PriorityBatch {
+		return take(ctx, l.batch)
+	}
+	if l.interactive == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.interactive <- struct{}{}:
+		return func() { <-l.interactive }, nil
+	default:
+	}
+	// The batch case is never ready on an unlimited (nil) batch lane.
+	select {
+	case l.interactive <- struct{}{}:
+		return func() { <-l.interactive }, nil
+	case l.batch <- struct{}{}:
+		return func() { <-l.batch }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func take(ctx context.Context, lane chan struct{}) (func(), error) {
+	if lane == nil {
+		return func() {}, nil
+	}
+	select {
+	case lane <- struct{}{}:
+		return func() { <-lane }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tryBatch takes a batch slot if one is free, returning the function that
+// frees it, or nil if the lane is full.
+func (l *lanes) tryBatch() func() {
+	if l.batch == nil {
+		return func() {}
+	}
+	select {
+	case l.batch <- struct{}{}:
+		return func() { <-l.batch }
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,142 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// ErrBenchmarkOptInRequired is returned when a user who has not opted in to
+// benchmarks asks to see them.
+var ErrBenchmarkOptInRequired = apperr.New(apperr.Forbidden, "benchmark statistics require opting in")
+
+// Benchmark compares one of the user's rates with the instance distribution.
+type Benchmark struct {
+	Metric string   `json:"metric"`
+	Yours  float64  `json:"yours"`
+	Median *float64 `json:"median"`
+	P25    *float64 `json:"p25"`
+	P75    *float64 `json:"p75"`
+}
+
+// Benchmarks is the result of the benchmarks query. Instance-wide values are
+// nil unless at least BenchmarkMinUsers opted-in users qualify, so that no
+// individual's numbers can be inferred.
+type Benchmarks struct {
+	Participants int          `json:"participants"`
+	Available    bool         `json:"available"`
+	Metrics      []*Benchmark `json:"metrics"`
+}
+
+type userRates struct {
+	applications int
+	responses    int
+	interviews   int
+	offers       int
+}
+
+func (u *userRates) values() map[string]float64 {
+	return map[string]float64{
+		"responseRate":  rate(u.responses, u.applications),
+		"interviewRate": rate(u.interviews, u.applications),
+		"offerRate":     rate(u.offers, u.applications),
+	}
+}
+
+var benchmarkMetrics = []string{"responseRate", "interviewRate", "offerRate"}
+
+// SetBenchmarkOptIn records whether the user's anonymized rates may be
+// included in instance benchmarks.
+func (s *Service) SetBenchmarkOptIn(ctx context.Context, userID string, optIn bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET benchmark_opt_in = $2 WHERE id = $1`, userID, optIn)
+	return err
+}
+
+// Benchmarks compares the user's conversion rates with the distribution over
+// every opted-in user on this instance. Only per-user rates leave the
+// database query and no user identifiers are returned. Withdrawn
+// applications are left out, as in the user's own analytics by default.
+func (s *Service) Benchmarks(ctx context.Context, userID string) (*Benchmarks, error) {
+	var optedIn bool
+	if err := s.db.QueryRowContext(ctx, `SELECT benchmark_opt_in FROM users WHERE id = $1`, userID).Scan(&optedIn); err != nil {
+		return nil, err
+	}
+	if !optedIn {
+		return nil, ErrBenchmarkOptInRequired
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.user_id, a.status, COUNT(*)
+		FROM applications a
+		JOIN users u ON u.id = a.user_id
+		WHERE u.benchmark_opt_in AND a.status <> $1 AND a.status <> $2
+		GROUP BY a.user_id, a.status`,
+		models.StatusWithdrawn, models.StatusSaved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]*userRates)
+	for rows.Next() {
+		var id, status string
+		var n int
+		if err := rows.Scan(&id, &status, &n); err != nil {
+			return nil, err
+		}
+		u := users[id]
+		if u == nil {
+			u = &userRates{}
+			users[id] = u
+		}
+		u.applications += n
+		if models.HasResponse(status) {
+			u.responses += n
+		}
+		if models.ReachedInterview(status) {
+			u.interviews += n
+		}
+		if models.ReachedOffer(status) {
+			u.offers += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	own := users[userID]
+	if own == nil {
+		own = &userRates{}
+	}
+	yours := own.values()
+
+	dist := make(map[string][]float64)
+	for _, u := range users {
+		if u.applications < s.cfg.BenchmarkMinApplications {
+			continue
+		}
+		for metric, v := range u.values() {
+			dist[metric] = append(dist[metric], v)
+		}
+	}
+
+	out := &Benchmarks{Participants: len(dist[benchmarkMetrics[0]])}
+	out.Available = out.Participants >= s.cfg.BenchmarkMinUsers
+	for _, metric := range benchmarkMetrics {
+		b := &Benchmark{Metric: metric, Yours: yours[metric]}
+		if out.Available {
+			values := dist[metric]
+			sort.Float64s(values)
+			median, p25, p75 := percentile(values, 0.5), percentile(values, 0.25), percentile(values, 0.75)
+			b.Median, b.P25, b.P75 = &median, &p25, &p75
+		}
+		out.Metrics = append(out.Metrics, b)
+	}
+	if !out.Available {
+		// Don't reveal how close the instance is to the threshold.
+		out.Participants = 0
+	}
+	return out, nil
+}
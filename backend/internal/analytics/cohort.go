@@ -0,0 +1,121 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// MemberTotals are one user's headline counts within a cohort.
+type MemberTotals struct {
+	UserID        string  `json:"-"`
+	Applications  int     `json:"applications"`
+	Responses     int     `json:"responses"`
+	Interviews    int     `json:"interviews"`
+	Offers        int     `json:"offers"`
+	ResponseRate  float64 `json:"responseRate"`
+	InterviewRate float64 `json:"interviewRate"`
+	OfferRate     float64 `json:"offerRate"`
+}
+
+// Cohort is the part of a Snapshot that can be combined across users, plus
+// each user's own totals. It has no offers, time in stage or anything else
+// that would expose a single user's applications.
+type Cohort struct {
+	GeneratedAt time.Time              `json:"generatedAt"`
+	Funnel      []*FunnelStage         `json:"funnel"`
+	WeeklyTrend []*WeeklyTrend         `json:"weeklyTrend"`
+	Sources     []*SourceEffectiveness `json:"sources"`
+	// Members has an entry for every requested user, in the order given,
+	// including those with no applications in the range.
+	Members []*MemberTotals `json:"members"`
+}
+
+// Cohort computes the funnel, weekly trend and channel breakdown over the
+// applications of all the given users together, as Snapshot does for one.
+func (s *Service) Cohort(ctx context.Context, userIDs []string, r DateRange) (*Cohort, error) {
+	out := &Cohort{GeneratedAt: time.Now()}
+
+	funnel, trend, err := s.funnelAndTrend(ctx, userIDs, r)
+	if err != nil {
+		return nil, err
+	}
+	out.Funnel, out.WeeklyTrend = funnel, trend
+
+	sources, err := s.sourceAnalytics(ctx, userIDs, r)
+	if err != nil {
+		return nil, err
+	}
+	out.Sources = sources.ByChannel
+
+	if out.Members, err = s.memberTotals(ctx, userIDs, r); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Service) memberTotals(ctx context.Context, userIDs []string, r DateRange) ([]*MemberTotals, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, status, COUNT(*)
+		FROM applications
+		WHERE user_id = ANY($1)
+		  AND ($2::date IS NULL OR applied_date >= $2::date)
+		  AND ($3::date IS NULL OR applied_date <= $3::date)
+		  AND ($4 OR status <> $5) AND status <> $6
+		GROUP BY 1, 2`,
+		pq.Array(userIDs), r.StartDate, r.EndDate, r.IncludeWithdrawn, models.StatusWithdrawn, models.StatusSaved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]*userRates)
+	for rows.Next() {
+		var id, status string
+		var n int
+		if err := rows.Scan(&id, &status, &n); err != nil {
+			return nil, err
+		}
+		u := users[id]
+		if u == nil {
+			u = &userRates{}
+			users[id] = u
+		}
+		u.applications += n
+		if models.HasResponse(status) {
+			u.responses += n
+		}
+		if models.ReachedInterview(status) {
+			u.interviews += n
+		}
+		if models.ReachedOffer(status) {
+			u.offers += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]*MemberTotals, 0, len(userIDs))
+	for _, id := range userIDs {
+		u := users[id]
+		if u == nil {
+			u = &userRates{}
+		}
+		v := u.values()
+		out = append(out, &MemberTotals{
+			UserID:        id,
+			Applications:  u.applications,
+			Responses:     u.responses,
+			Interviews:    u.interviews,
+			Offers:        u.offers,
+			ResponseRate:  v["responseRate"],
+			InterviewRate: v["interviewRate"],
+			OfferRate:     v["offerRate"],
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,145 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// heatmapTTL is how long a computed heatmap is served from Redis. The
+// widget shows a year at day granularity, so a few minutes of lag is fine.
+const heatmapTTL = 10 * time.Minute
+
+// ActivityDay counts what happened on one day in the user's timezone.
+type ActivityDay struct {
+	Date         string `json:"date"`         // YYYY-MM-DD
+	Emails       int    `json:"emails"`       // job-related emails received
+	Applications int    `json:"applications"` // applications sent
+	Interviews   int    `json:"interviews"`
+	Total        int    `json:"total"`
+}
+
+// ActivityHeatmap is a GitHub-style daily activity series.
+type ActivityHeatmap struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Days has one entry per day from From to To, including idle days.
+	Days []*ActivityDay `json:"days"`
+	// Max is the busiest day's total, for scaling the colors.
+	Max          int `json:"max"`
+	Emails       int `json:"emails"`
+	Applications int `json:"applications"`
+	Interviews   int `json:"interviews"`
+	// ActiveDays counts days with any activity.
+	ActiveDays int `json:"activeDays"`
+}
+
+// Heatmap returns the user's daily activity over the past year, ending
+// today in their timezone. With an application ID only that application's
+// emails, sending and interviews are counted. Results are cached briefly.
+func (s *Service) Heatmap(ctx context.Context, userID string, applicationID *string) (*ActivityHeatmap, error) {
+	var tz string
+	if err := s.db.QueryRowContext(ctx, `SELECT timezone FROM users WHERE id = $1`, userID).Scan(&tz); err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from := to.AddDate(-1, 0, 1)
+
+	key := "analytics:heatmap:" + userID + ":" + to.Format("2006-01-02")
+	if applicationID != nil {
+		key += ":" + *applicationID
+	}
+	if raw, err := s.rdb.Get(ctx, key).Bytes(); err == nil {
+		var h ActivityHeatmap
+		if err := json.Unmarshal(raw, &h); err == nil {
+			return &h, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		log.Printf("Failed to read cached heatmap of user %s: %v", userID, err)
+	}
+
+	h, err := s.heatmap(ctx, userID, applicationID, loc, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err := json.Marshal(h); err == nil {
+		if err := s.rdb.Set(ctx, key, raw, heatmapTTL).Err(); err != nil {
+			log.Printf("Failed to cache heatmap of user %s: %v", userID, err)
+		}
+	}
+	return h, nil
+}
+
+func (s *Service) heatmap(ctx context.Context, userID string, applicationID *string, loc *time.Location, from, to time.Time) (*ActivityHeatmap, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT day, SUM(emails), SUM(applications), SUM(interviews) FROM (
+			SELECT (date AT TIME ZONE $2)::date AS day, COUNT(*) AS emails, 0 AS applications, 0 AS interviews
+			FROM email_cache
+			WHERE user_id = $1 AND is_job_related AND date >= $3
+				AND ($5::text IS NULL OR application_id::text = $5)
+			GROUP BY 1
+			UNION ALL
+			SELECT applied_date, 0, COUNT(*), 0
+			FROM applications
+			WHERE user_id = $1 AND applied_date >= $3::date AND applied_date <= $4::date AND status <> 'Saved'
+				AND ($5::text IS NULL OR id::text = $5)
+			GROUP BY 1
+			UNION ALL
+			SELECT (starts_at AT TIME ZONE $2)::date, 0, 0, COUNT(*)
+			FROM interviews
+			WHERE user_id = $1 AND status <> 'cancelled' AND starts_at >= $3
+				AND ($5::text IS NULL OR application_id::text = $5)
+			GROUP BY 1
+		) counts
+		WHERE day BETWEEN $3::date AND $4::date
+		GROUP BY day`,
+		userID, loc.String(), from.Format("2006-01-02"), to.Format("2006-01-02"), applicationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]*ActivityDay)
+	for rows.Next() {
+		var day time.Time
+		d := &ActivityDay{}
+		if err := rows.Scan(&day, &d.Emails, &d.Applications, &d.Interviews); err != nil {
+			return nil, err
+		}
+		d.Date = day.Format("2006-01-02")
+		counts[d.Date] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	h := &ActivityHeatmap{From: from.Format("2006-01-02"), To: to.Format("2006-01-02")}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		d := counts[date]
+		if d == nil {
+			d = &ActivityDay{Date: date}
+		}
+		d.Total = d.Emails + d.Applications + d.Interviews
+		h.Days = append(h.Days, d)
+		h.Emails += d.Emails
+		h.Applications += d.Applications
+		h.Interviews += d.Interviews
+		if d.Total > 0 {
+			h.ActiveDays++
+		}
+		if d.Total > h.Max {
+			h.Max = d.Total
+		}
+	}
+	return h, nil
+}
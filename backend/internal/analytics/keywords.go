@@ -0,0 +1,150 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/resumes"
+)
+
+const (
+	// defaultKeywordLimit is how many keywords KeywordFrequency returns by
+	// default.
+	defaultKeywordLimit = 50
+	// minFilteredRejections is how many rejected applications must ask for
+	// a skill before it is reported as one the user is filtered on.
+	minFilteredRejections = 2
+	// maxFilteredOn caps the skills reported as filtered on.
+	maxFilteredOn = 10
+)
+
+// KeywordCount is how often a skill appears in the job descriptions of the
+// user's applications, and how those applications went.
+type KeywordCount struct {
+	Keyword       string  `json:"keyword"`
+	Applications  int     `json:"applications"`
+	Share         float64 `json:"share"` // of applications with a description
+	Interviews    int     `json:"interviews"`
+	Rejections    int     `json:"rejections"`
+	InterviewRate float64 `json:"interviewRate"`
+	RejectionRate float64 `json:"rejectionRate"`
+	// OnResume reports whether any of the user's resumes lists the skill.
+	OnResume bool `json:"onResume"`
+}
+
+// KeywordFrequency is the result of the keywordFrequency query.
+type KeywordFrequency struct {
+	// Analyzed is the number of applications with a job description.
+	Analyzed int             `json:"analyzed"`
+	Keywords []*KeywordCount `json:"keywords"`
+	// FilteredOn are skills missing from the user's resumes that keep
+	// showing up in roles they were rejected from, most often rejected
+	// first.
+	FilteredOn []*KeywordCount `json:"filteredOn"`
+}
+
+// KeywordFrequency counts the skills asked for in the job descriptions of
+// the user's applications, most frequent first, for a tag cloud. Only
+// applications with a stored description are analyzed.
+func (s *Service) KeywordFrequency(ctx context.Context, userID string, r DateRange, limit int) (*KeywordFrequency, error) {
+	if limit <= 0 {
+		limit = defaultKeywordLimit
+	}
+	onResume, err := s.resumeSkills(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT job_description, status FROM applications
+		WHERE user_id = $1 AND NULLIF(TRIM(job_description), '') IS NOT NULL
+		  AND ($2::date IS NULL OR applied_date >= $2::date)
+		  AND ($3::date IS NULL OR applied_date <= $3::date)`,
+		userID, r.StartDate, r.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := &KeywordFrequency{Keywords: []*KeywordCount{}, FilteredOn: []*KeywordCount{}}
+	counts := make(map[string]*KeywordCount)
+	for rows.Next() {
+		var description, status string
+		if err := rows.Scan(&description, &status); err != nil {
+			return nil, err
+		}
+		out.Analyzed++
+		for _, skill := range resumes.MatchSkills(description) {
+			k := counts[skill]
+			if k == nil {
+				k = &KeywordCount{Keyword: skill, OnResume: onResume[strings.ToLower(skill)]}
+				counts[skill] = k
+			}
+			k.Applications++
+			if models.ReachedInterview(status) {
+				k.Interviews++
+			}
+			if status == models.StatusRejected {
+				k.Rejections++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, k := range counts {
+		k.Share = rate(k.Applications, out.Analyzed)
+		k.InterviewRate = rate(k.Interviews, k.Applications)
+		k.RejectionRate = rate(k.Rejections, k.Applications)
+		out.Keywords = append(out.Keywords, k)
+		if !k.OnResume && k.Rejections >= minFilteredRejections {
+			out.FilteredOn = append(out.FilteredOn, k)
+		}
+	}
+	sort.Slice(out.Keywords, func(i, j int) bool {
+		if out.Keywords[i].Applications != out.Keywords[j].Applications {
+			return out.Keywords[i].Applications > out.Keywords[j].Applications
+		}
+		return out.Keywords[i].Keyword < out.Keywords[j].Keyword
+	})
+	if len(out.Keywords) > limit {
+		out.Keywords = out.Keywords[:limit]
+	}
+	sort.Slice(out.FilteredOn, func(i, j int) bool {
+		if out.FilteredOn[i].Rejections != out.FilteredOn[j].Rejections {
+			return out.FilteredOn[i].Rejections > out.FilteredOn[j].Rejections
+		}
+		return out.FilteredOn[i].Keyword < out.FilteredOn[j].Keyword
+	})
+	if len(out.FilteredOn) > maxFilteredOn {
+		out.FilteredOn = out.FilteredOn[:maxFilteredOn]
+	}
+	return out, nil
+}
+
+// resumeSkills returns the lowercased skills listed on any of the user's
+// resumes.
+func (s *Service) resumeSkills(ctx context.Context, userID string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT skills FROM resumes WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]bool)
+	for rows.Next() {
+		var skills []string
+		if err := rows.Scan(pq.Array(&skills)); err != nil {
+			return nil, err
+		}
+		for _, skill := range skills {
+			out[strings.ToLower(skill)] = true
+		}
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,105 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jobtracker/backend/internal/interviews"
+)
+
+// RoundOutcomes is how loops fared at one round position.
+type RoundOutcomes struct {
+	Position int     `json:"position"`
+	Reached  int     `json:"reached"` // loops that got this far
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	PassRate float64 `json:"passRate"` // of rounds decided
+}
+
+// LoopOutcomes is the result of the loopOutcomes query.
+type LoopOutcomes struct {
+	Loops      int `json:"loops"`
+	InProgress int `json:"inProgress"`
+	Passed     int `json:"passed"`
+	Failed     int `json:"failed"`
+	// PassRate is passed loops over finished ones.
+	PassRate float64 `json:"passRate"`
+	// ByRound shows where loops end, by round position.
+	ByRound []*RoundOutcomes `json:"byRound"`
+}
+
+// LoopOutcomes summarizes how the user's interview loops ended and at which
+// round failed loops stopped. Skipped rounds count as passed.
+func (s *Service) LoopOutcomes(ctx context.Context, userID string, r DateRange) (*LoopOutcomes, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT l.id, r.position, r.status
+		FROM interview_loops l
+		JOIN applications a ON a.id = l.application_id
+		JOIN interview_rounds r ON r.loop_id = l.id
+		WHERE l.user_id = $1
+		  AND ($2::date IS NULL OR a.applied_date >= $2::date)
+		  AND ($3::date IS NULL OR a.applied_date <= $3::date)
+		ORDER BY l.id, r.position`,
+		userID, r.StartDate, r.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loops := make(map[string]*interviews.Loop)
+	var order []string
+	for rows.Next() {
+		var loopID string
+		round := &interviews.Round{}
+		if err := rows.Scan(&loopID, &round.Position, &round.Status); err != nil {
+			return nil, err
+		}
+		if loops[loopID] == nil {
+			loops[loopID] = &interviews.Loop{ID: loopID}
+			order = append(order, loopID)
+		}
+		loops[loopID].Rounds = append(loops[loopID].Rounds, round)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := &LoopOutcomes{ByRound: []*RoundOutcomes{}}
+	byPosition := make(map[int]*RoundOutcomes)
+	for _, id := range order {
+		l := loops[id]
+		out.Loops++
+		switch l.Outcome() {
+		case interviews.LoopPassed:
+			out.Passed++
+		case interviews.LoopFailed:
+			out.Failed++
+		default:
+			out.InProgress++
+		}
+		// A loop reaches each round up to the first one failed or still
+		// undecided.
+		for _, round := range l.Rounds {
+			ro := byPosition[round.Position]
+			if ro == nil {
+				ro = &RoundOutcomes{Position: round.Position}
+				byPosition[round.Position] = ro
+				out.ByRound = append(out.ByRound, ro)
+			}
+			ro.Reached++
+			if round.Status == interviews.RoundFailed {
+				ro.Failed++
+			}
+			if round.Status != interviews.RoundPassed && round.Status != interviews.RoundSkipped {
+				break
+			}
+			ro.Passed++
+		}
+	}
+	out.PassRate = rate(out.Passed, out.Passed+out.Failed)
+	for _, ro := range out.ByRound {
+		ro.PassRate = rate(ro.Passed, ro.Passed+ro.Failed)
+	}
+	sort.Slice(out.ByRound, func(i, j int) bool { return out.ByRound[i].Position < out.ByRound[j].Position })
+	return out, nil
+}
@@ -0,0 +1,43 @@
+package analytics
+
+import (
+	"database/sql"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/salary"
+)
+
+// Service computes aggregate statistics over a user's applications.
+type Service struct {
+	cfg    *config.Config
+	db     *sql.DB
+	rdb    *redis.Client
+	salary *salary.Service
+}
+
+// NewService creates an analytics service backed by the given database,
+// caching expensive series in Redis. Offers in snapshots are compared
+// through the salary service.
+func NewService(cfg *config.Config, db *sql.DB, rdb *redis.Client, salaryService *salary.Service) *Service {
+	return &Service{cfg: cfg, db: db, rdb: rdb, salary: salaryService}
+}
+
+// DateRange optionally bounds analytics queries by applied date (YYYY-MM-DD).
+type DateRange struct {
+	StartDate *string
+	EndDate   *string
+	// IncludeWithdrawn counts withdrawn applications in response rates and
+	// the funnel. They are left out by default since the company never had
+	// the chance to respond.
+	IncludeWithdrawn bool
+}
+
+// rate returns part/total, or zero when there is nothing to divide by.
+func rate(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
@@ -0,0 +1,142 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/salary"
+)
+
+// FunnelStage is the number of applications that reached a stage.
+type FunnelStage struct {
+	Stage string  `json:"stage"`
+	Count int     `json:"count"`
+	Rate  float64 `json:"rate"`
+}
+
+// WeeklyTrend counts applications submitted in a week and how many of them
+// have received a response so far.
+type WeeklyTrend struct {
+	WeekStart    string `json:"weekStart"`
+	Applications int    `json:"applications"`
+	Responses    int    `json:"responses"`
+}
+
+// Snapshot bundles the headline analytics so that exports can embed a
+// complete standalone report. exports.Service.Request sends it to the
+// agents service, which writes it to the Analytics sheet.
+type Snapshot struct {
+	GeneratedAt time.Time              `json:"generatedAt"`
+	Funnel      []*FunnelStage         `json:"funnel"`
+	WeeklyTrend []*WeeklyTrend         `json:"weeklyTrend"`
+	TimeInStage []*StageDuration       `json:"timeInStage"`
+	Sources     []*SourceEffectiveness `json:"sources"`
+	// Offers are converted to the user's preferred currency.
+	Offers *salary.OfferComparison `json:"offers"`
+}
+
+// Snapshot computes the funnel, weekly trend, time-in-stage, source
+// breakdown and offer comparison for the user over the given range.
+func (s *Service) Snapshot(ctx context.Context, userID string, r DateRange) (*Snapshot, error) {
+	out := &Snapshot{GeneratedAt: time.Now()}
+
+	funnel, trend, err := s.funnelAndTrend(ctx, []string{userID}, r)
+	if err != nil {
+		return nil, err
+	}
+	out.Funnel, out.WeeklyTrend = funnel, trend
+
+	stages, err := s.TimeInStage(ctx, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	out.TimeInStage = stages.Stages
+
+	sources, err := s.SourceAnalytics(ctx, userID, r)
+	if err != nil {
+		return nil, err
+	}
+	out.Sources = sources.ByChannel
+
+	// Offers are optional in the report; missing exchange rates should not
+	// fail the whole snapshot.
+	if offers, err := s.salary.CompareOffers(ctx, userID, ""); err != nil {
+		log.Printf("Analytics: offer comparison unavailable for %s: %v", userID, err)
+	} else {
+		out.Offers = offers
+	}
+
+	return out, nil
+}
+
+// funnelAndTrend computes the funnel and weekly trend over the applications
+// of all the given users together. Saved roles have not been applied to and
+// are left out.
+func (s *Service) funnelAndTrend(ctx context.Context, userIDs []string, r DateRange) ([]*FunnelStage, []*WeeklyTrend, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date_trunc('week', applied_date)::date, status, COUNT(*)
+		FROM applications
+		WHERE user_id = ANY($1)
+		  AND ($2::date IS NULL OR applied_date >= $2::date)
+		  AND ($3::date IS NULL OR applied_date <= $3::date)
+		  AND ($4 OR status <> $5) AND status <> $6
+		GROUP BY 1, 2
+		ORDER BY 1`,
+		pq.Array(userIDs), r.StartDate, r.EndDate, r.IncludeWithdrawn, models.StatusWithdrawn, models.StatusSaved)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var total, responses, interviews, offers, accepted int
+	var trend []*WeeklyTrend
+	for rows.Next() {
+		var week time.Time
+		var status string
+		var n int
+		if err := rows.Scan(&week, &status, &n); err != nil {
+			return nil, nil, err
+		}
+
+		label := week.Format("2006-01-02")
+		if len(trend) == 0 || trend[len(trend)-1].WeekStart != label {
+			trend = append(trend, &WeeklyTrend{WeekStart: label})
+		}
+		w := trend[len(trend)-1]
+		w.Applications += n
+
+		total += n
+		if models.HasResponse(status) {
+			responses += n
+			w.Responses += n
+		}
+		if models.ReachedInterview(status) {
+			interviews += n
+		}
+		if models.ReachedOffer(status) {
+			offers += n
+		}
+		if status == models.StatusAccepted {
+			accepted += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	funnel := []*FunnelStage{
+		{Stage: models.StatusApplied, Count: total},
+		{Stage: "Responded", Count: responses},
+		{Stage: "Interviewed", Count: interviews},
+		{Stage: models.StatusOffer, Count: offers},
+		{Stage: models.StatusAccepted, Count: accepted},
+	}
+	for _, f := range funnel {
+		f.Rate = rate(f.Count, total)
+	}
+	return funnel, trend, nil
+}
@@ -0,0 +1,174 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// Application channels used to group free-form sources.
+const (
+	ChannelReferral          = "referral"
+	ChannelRecruiterOutreach = "recruiter_outreach"
+	ChannelColdApply         = "cold_apply"
+)
+
+// Channel keywords match whole words, so "preferred" and "reference" are
+// not referrals.
+var (
+	referralPattern  = regexp.MustCompile(`\b(refer|referr(al|als|ed|er))\b`)
+	recruiterPattern = regexp.MustCompile(`\b(recruiters?|outreach|inbound|headhunters?|sourced)\b`)
+)
+
+// ClassifyChannel maps an application's free-form source (e.g. "LinkedIn",
+// "Referral - Jane", "Recruiter outreach") onto one of the known channels.
+// Anything that is not clearly a referral or recruiter contact counts as a
+// cold application.
+func ClassifyChannel(source string) string {
+	s := strings.ToLower(source)
+	switch {
+	case referralPattern.MatchString(s):
+		return ChannelReferral
+	case recruiterPattern.MatchString(s):
+		return ChannelRecruiterOutreach
+	}
+	return ChannelColdApply
+}
+
+// SourceEffectiveness holds outcome counts and conversion rates for a
+// channel or a specific source within it.
+type SourceEffectiveness struct {
+	Channel       string  `json:"channel"`
+	Source        *string `json:"source"`
+	Applications  int     `json:"applications"`
+	Responses     int     `json:"responses"`
+	Interviews    int     `json:"interviews"`
+	Offers        int     `json:"offers"`
+	Rejections    int     `json:"rejections"`
+	ResponseRate  float64 `json:"responseRate"`
+	InterviewRate float64 `json:"interviewRate"`
+	OfferRate     float64 `json:"offerRate"`
+}
+
+func (e *SourceEffectiveness) add(status string, count int) {
+	e.Applications += count
+	if models.HasResponse(status) {
+		e.Responses += count
+	}
+	if models.ReachedInterview(status) {
+		e.Interviews += count
+	}
+	if models.ReachedOffer(status) {
+		e.Offers += count
+	}
+	if status == models.StatusRejected {
+		e.Rejections += count
+	}
+}
+
+func (e *SourceEffectiveness) computeRates() {
+	e.ResponseRate = rate(e.Responses, e.Applications)
+	e.InterviewRate = rate(e.Interviews, e.Applications)
+	e.OfferRate = rate(e.Offers, e.Applications)
+}
+
+// SourceAnalytics is the result of the sourceAnalytics query.
+type SourceAnalytics struct {
+	ByChannel []*SourceEffectiveness `json:"byChannel"`
+	BySource  []*SourceEffectiveness `json:"bySource"`
+	// ByReferralState breaks the referral channel down by how far each
+	// tracked referral got; Source holds the state.
+	ByReferralState []*SourceEffectiveness `json:"byReferralState"`
+}
+
+// SourceAnalytics breaks down application outcomes by channel and by raw
+// source so users can see which channels actually produce interviews.
+// Applications with a tracked referral count as referrals whatever their
+// source says; withdrawn ones are left out unless r.IncludeWithdrawn.
+func (s *Service) SourceAnalytics(ctx context.Context, userID string, r DateRange) (*SourceAnalytics, error) {
+	return s.sourceAnalytics(ctx, []string{userID}, r)
+}
+
+// sourceAnalytics computes the breakdown over the applications of all the
+// given users together.
+func (s *Service) sourceAnalytics(ctx context.Context, userIDs []string, r DateRange) (*SourceAnalytics, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COALESCE(NULLIF(TRIM(a.source), ''), 'Unknown'), a.status, ref.state, COUNT(*)
+		FROM applications a
+		LEFT JOIN application_referrals ref ON ref.application_id = a.id
+		WHERE a.user_id = ANY($1)
+		  AND ($2::date IS NULL OR a.applied_date >= $2::date)
+		  AND ($3::date IS NULL OR a.applied_date <= $3::date)
+		  AND ($4 OR a.status <> $5) AND a.status <> $6
+		GROUP BY 1, 2, 3`,
+		pq.Array(userIDs), r.StartDate, r.EndDate, r.IncludeWithdrawn, models.StatusWithdrawn, models.StatusSaved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := make(map[string]*SourceEffectiveness)
+	sources := make(map[string]*SourceEffectiveness)
+	states := make(map[string]*SourceEffectiveness)
+	for rows.Next() {
+		var source, status string
+		var state sql.NullString
+		var count int
+		if err := rows.Scan(&source, &status, &state, &count); err != nil {
+			return nil, err
+		}
+
+		channel := ClassifyChannel(source)
+		if state.Valid {
+			channel = ChannelReferral
+			if states[state.String] == nil {
+				st := state.String
+				states[st] = &SourceEffectiveness{Channel: ChannelReferral, Source: &st}
+			}
+			states[state.String].add(status, count)
+		}
+		if channels[channel] == nil {
+			channels[channel] = &SourceEffectiveness{Channel: channel}
+		}
+		channels[channel].add(status, count)
+
+		if sources[source] == nil {
+			src := source
+			sources[source] = &SourceEffectiveness{Channel: channel, Source: &src}
+		}
+		sources[source].add(status, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &SourceAnalytics{
+		ByChannel:       sortedByVolume(channels),
+		BySource:        sortedByVolume(sources),
+		ByReferralState: sortedByVolume(states),
+	}, nil
+}
+
+func sortedByVolume(m map[string]*SourceEffectiveness) []*SourceEffectiveness {
+	out := make([]*SourceEffectiveness, 0, len(m))
+	for _, e := range m {
+		e.computeRates()
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Applications != out[j].Applications {
+			return out[i].Applications > out[j].Applications
+		}
+		if out[i].Channel != out[j].Channel {
+			return out[i].Channel < out[j].Channel
+		}
+		return out[i].Source != nil && out[j].Source != nil && *out[i].Source < *out[j].Source
+	})
+	return out
+}
@@ -0,0 +1,29 @@
+package analytics
+
+import "testing"
+
+func TestClassifyChannel(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"Referral - Jane", ChannelReferral},
+		{"referred by a friend", ChannelReferral},
+		{"Employee refer", ChannelReferral},
+		{"Referrer: Sam", ChannelReferral},
+		{"Recruiter outreach", ChannelRecruiterOutreach},
+		{"LinkedIn inbound", ChannelRecruiterOutreach},
+		{"Sourced on GitHub", ChannelRecruiterOutreach},
+		{"LinkedIn", ChannelColdApply},
+		{"", ChannelColdApply},
+		// Words that merely contain a keyword.
+		{"Preferred partner job board", ChannelColdApply},
+		{"Reference check portal", ChannelColdApply},
+		{"Outsourced agency", ChannelColdApply},
+	}
+	for _, tt := range tests {
+		if got := ClassifyChannel(tt.source); got != tt.want {
+			t.Errorf("ClassifyChannel(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
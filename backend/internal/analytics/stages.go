@@ -0,0 +1,198 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// StageDuration summarises how long applications stay in a stage.
+type StageDuration struct {
+	Stage       string  `json:"stage"`
+	Count       int     `json:"count"`
+	MedianHours float64 `json:"medianHours"`
+	P90Hours    float64 `json:"p90Hours"`
+}
+
+// StageOutlier is an application that has been sitting in its current
+// stage for longer than the p90 for that stage.
+type StageOutlier struct {
+	ApplicationID string    `json:"applicationId"`
+	Company       string    `json:"company"`
+	Position      string    `json:"position"`
+	Stage         string    `json:"stage"`
+	EnteredAt     time.Time `json:"enteredAt"`
+	Hours         float64   `json:"hours"`
+	P90Hours      float64   `json:"p90Hours"`
+}
+
+// CompanyTimeInStage is the stage distribution for a single company.
+type CompanyTimeInStage struct {
+	Company string           `json:"company"`
+	Stages  []*StageDuration `json:"stages"`
+}
+
+// TimeInStage is the result of the timeInStage query.
+type TimeInStage struct {
+	Stages    []*StageDuration      `json:"stages"`
+	Outliers  []*StageOutlier       `json:"outliers"`
+	ByCompany []*CompanyTimeInStage `json:"byCompany"`
+}
+
+// stageOrder lists the non-terminal stages in pipeline order.
+var stageOrder = []string{
+	models.StatusApplied,
+	models.StatusUnderReview,
+	models.StatusInterviewScheduled,
+	models.StatusInterviewComplete,
+	models.StatusOffer,
+}
+
+// isTerminal reports whether applications never leave the status, in which
+// case time spent in it is not meaningful.
+func isTerminal(status string) bool {
+	switch status {
+	case models.StatusRejected, models.StatusWithdrawn, models.StatusAccepted:
+		return true
+	}
+	return false
+}
+
+type stay struct {
+	applicationID string
+	company       string
+	position      string
+	stage         string
+	enteredAt     time.Time
+	leftAt        *time.Time
+}
+
+func (s stay) hours(now time.Time) float64 {
+	end := now
+	if s.leftAt != nil {
+		end = *s.leftAt
+	}
+	return end.Sub(s.enteredAt).Hours()
+}
+
+// TimeInStage computes the median and p90 time applications spend in each
+// stage from the status history, for the whole account (or a single company
+// when company is set) and per company. Applications currently sitting in a
+// stage longer than its p90 are reported as outliers.
+func (s *Service) TimeInStage(ctx context.Context, userID string, company *string) (*TimeInStage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT h.application_id, a.company, a.position, h.status, h.changed_at,
+		       LEAD(h.changed_at) OVER (PARTITION BY h.application_id ORDER BY h.changed_at)
+		FROM application_status_history h
+		JOIN applications a ON a.id = h.application_id
+		WHERE h.user_id = $1 AND ($2::text IS NULL OR LOWER(a.company) = LOWER($2))`,
+		userID, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stays []stay
+	for rows.Next() {
+		var st stay
+		if err := rows.Scan(&st.applicationID, &st.company, &st.position, &st.stage, &st.enteredAt, &st.leftAt); err != nil {
+			return nil, err
+		}
+		if isTerminal(st.stage) {
+			continue
+		}
+		stays = append(stays, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	out := &TimeInStage{
+		Stages:    stageDistribution(stays, now),
+		Outliers:  []*StageOutlier{},
+		ByCompany: []*CompanyTimeInStage{},
+	}
+
+	p90 := make(map[string]float64, len(out.Stages))
+	for _, d := range out.Stages {
+		p90[d.Stage] = d.P90Hours
+	}
+	for _, st := range stays {
+		if st.leftAt != nil {
+			continue
+		}
+		if h := st.hours(now); h > p90[st.stage] && p90[st.stage] > 0 {
+			out.Outliers = append(out.Outliers, &StageOutlier{
+				ApplicationID: st.applicationID,
+				Company:       st.company,
+				Position:      st.position,
+				Stage:         st.stage,
+				EnteredAt:     st.enteredAt,
+				Hours:         h,
+				P90Hours:      p90[st.stage],
+			})
+		}
+	}
+	sort.Slice(out.Outliers, func(i, j int) bool {
+		return out.Outliers[i].Hours/out.Outliers[i].P90Hours > out.Outliers[j].Hours/out.Outliers[j].P90Hours
+	})
+
+	byCompany := make(map[string][]stay)
+	for _, st := range stays {
+		byCompany[st.company] = append(byCompany[st.company], st)
+	}
+	for name, cs := range byCompany {
+		out.ByCompany = append(out.ByCompany, &CompanyTimeInStage{Company: name, Stages: stageDistribution(cs, now)})
+	}
+	sort.Slice(out.ByCompany, func(i, j int) bool { return out.ByCompany[i].Company < out.ByCompany[j].Company })
+
+	return out, nil
+}
+
+// stageDistribution computes per-stage percentiles over completed stays.
+// Stays that are still open are excluded so that recently-entered stages do
+// not drag the distribution down.
+func stageDistribution(stays []stay, now time.Time) []*StageDuration {
+	hours := make(map[string][]float64)
+	for _, st := range stays {
+		if st.leftAt == nil {
+			continue
+		}
+		hours[st.stage] = append(hours[st.stage], st.hours(now))
+	}
+
+	var out []*StageDuration
+	for _, stage := range stageOrder {
+		h := hours[stage]
+		if len(h) == 0 {
+			continue
+		}
+		sort.Float64s(h)
+		out = append(out, &StageDuration{
+			Stage:       stage,
+			Count:       len(h),
+			MedianHours: percentile(h, 0.5),
+			P90Hours:    percentile(h, 0.9),
+		})
+	}
+	return out
+}
+
+// percentile returns the p-th percentile of sorted values using linear
+// interpolation between closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	if lo == hi {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// WishlistConversion reports how many saved roles the user went on to apply
+// to, and how quickly.
+type WishlistConversion struct {
+	Saved int `json:"saved"`
+	// Applied counts saved roles since applied to, whatever happened next.
+	Applied int `json:"applied"`
+	// Waiting are still saved with their posting open or with no deadline.
+	Waiting int `json:"waiting"`
+	// Missed are still saved after their posting closed.
+	Missed int `json:"missed"`
+	// Dropped were withdrawn or otherwise closed without applying.
+	Dropped        int     `json:"dropped"`
+	ConversionRate float64 `json:"conversionRate"`
+	// MedianDaysToApply is nil until a saved role has been applied to.
+	MedianDaysToApply *float64 `json:"medianDaysToApply"`
+}
+
+// WishlistConversion follows every role the user saved in the range (by
+// the day it was saved) through its status history to whether it was
+// applied to.
+func (s *Service) WishlistConversion(ctx context.Context, userID string, r DateRange) (*WishlistConversion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.status, a.posting_deadline < CURRENT_TIMESTAMP,
+			MIN(h.changed_at) FILTER (WHERE h.status = $2),
+			MIN(h.changed_at) FILTER (WHERE h.status NOT IN ($2, $3, $4))
+		FROM application_status_history h
+		JOIN applications a ON a.id = h.application_id
+		WHERE h.user_id = $1
+		GROUP BY a.id, a.status, a.posting_deadline
+		HAVING MIN(h.changed_at) FILTER (WHERE h.status = $2) IS NOT NULL
+		  AND ($5::date IS NULL OR MIN(h.changed_at) FILTER (WHERE h.status = $2) >= $5::date)
+		  AND ($6::date IS NULL OR MIN(h.changed_at) FILTER (WHERE h.status = $2) < $6::date + 1)`,
+		userID, models.StatusSaved, models.StatusWithdrawn, models.StatusRejected, r.StartDate, r.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := &WishlistConversion{}
+	var days []float64
+	for rows.Next() {
+		var status string
+		var closed *bool
+		var savedAt time.Time
+		var appliedAt *time.Time
+		if err := rows.Scan(&status, &closed, &savedAt, &appliedAt); err != nil {
+			return nil, err
+		}
+		out.Saved++
+		switch {
+		case appliedAt != nil:
+			out.Applied++
+			days = append(days, appliedAt.Sub(savedAt).Hours()/24)
+		case status != models.StatusSaved:
+			out.Dropped++
+		case closed != nil && *closed:
+			out.Missed++
+		default:
+			out.Waiting++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out.ConversionRate = rate(out.Applied, out.Saved)
+	if len(days) > 0 {
+		sort.Float64s(days)
+		median := percentile(days, 0.5)
+		out.MedianDaysToApply = &median
+	}
+	return out, nil
+}
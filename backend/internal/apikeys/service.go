@@ -0,0 +1,166 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+)
+
+// keyPrefix marks plaintext keys so they are easy to recognise in leaks.
+const keyPrefix = "jt_"
+
+// ErrInvalidKey is returned for unknown or revoked keys.
+var ErrInvalidKey = apperr.New(apperr.Unauthenticated, "invalid API key")
+
+// APIKey is a long-lived credential for non-browser clients such as the
+// browser extension. Only a hash of the key is stored.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedKey is returned once when a key is created; Key is never shown again.
+type CreatedKey struct {
+	APIKey *APIKey `json:"apiKey"`
+	Key    string  `json:"key"`
+}
+
+// Service issues and verifies API keys.
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates an API key service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create issues a new key for the user.
+func (s *Service) Create(ctx context.Context, userID, name string) (*CreatedKey, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	k := &APIKey{Name: name, Prefix: key[:len(keyPrefix)+6]}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		userID, name, k.Prefix, hash(key)).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &CreatedKey{APIKey: k, Key: key}, nil
+}
+
+// List returns the user's active keys.
+func (s *Service) List(ctx context.Context, userID string) ([]*APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, prefix, last_used_at, created_at FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*APIKey
+	for rows.Next() {
+		k := &APIKey{}
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &k.LastUsedAt, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// Revoke disables a key. It reports false if no active key matched.
+func (s *Service) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Authenticate resolves a plaintext key to its user and records its use.
+func (s *Service) Authenticate(ctx context.Context, key string) (string, error) {
+	_, userID, err := s.authenticate(ctx, key)
+	return userID, err
+}
+
+// authenticate returns the ID and user of a plaintext key.
+func (s *Service) authenticate(ctx context.Context, key string) (keyID, userID string, err error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return "", "", ErrInvalidKey
+	}
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING id, user_id`, hash(key)).Scan(&keyID, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrInvalidKey
+	}
+	return keyID, userID, err
+}
+
+// Middleware authenticates requests carrying an API key in the X-API-Key
+// header or as a bearer token, and rejects everything else.
+func (s *Service) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		keyID, userID, err := s.authenticate(c.Request.Context(), key)
+		if err != nil {
+			apperr.Respond(c, "API key authentication", err)
+			return
+		}
+
+		auth.SetUserID(c, userID)
+		auth.SetAPIKeyID(c, keyID)
+		c.Next()
+	}
+}
+
+// OptionalMiddleware is Middleware for endpoints that also serve browser
+// sessions: requests with an API key are authenticated (and rejected if the
+// key is invalid), requests without one are passed on for the handler to
+// authenticate.
+func (s *Service) OptionalMiddleware() gin.HandlerFunc {
+	required := s.Middleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("X-API-Key") == "" &&
+			!strings.HasPrefix(c.GetHeader("Authorization"), "Bearer "+keyPrefix) {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
@@ -0,0 +1,138 @@
+// Package apperr is the error taxonomy shared by the services, the GraphQL
+// API and the REST handlers. Services classify their errors with a Code;
+// the API layers map codes to GraphQL error extensions and HTTP statuses
+// so clients can branch on the code instead of the message.
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Code classifies an error for API clients.
+type Code string
+
+const (
+	NotFound        Code = "NOT_FOUND"
+	Conflict        Code = "CONFLICT"        // the resource's state does not allow the operation
+	RateLimited     Code = "RATE_LIMITED"    // rate limits and usage quotas
+	UpstreamGmail   Code = "UPSTREAM_GMAIL"  // Gmail and other Google APIs failed
+	UpstreamLLM     Code = "UPSTREAM_LLM"    // the agents service or its LLM failed
+	Validation      Code = "VALIDATION"      // the input is invalid
+	Unauthenticated Code = "UNAUTHENTICATED" // missing or invalid credentials
+	Forbidden       Code = "FORBIDDEN"
+	Unavailable     Code = "UNAVAILABLE" // a feature is disabled or a dependency timed out
+	Internal        Code = "INTERNAL"
+)
+
+// statuses maps codes to REST status codes.
+var statuses = map[Code]int{
+	NotFound:        http.StatusNotFound,
+	Conflict:        http.StatusConflict,
+	RateLimited:     http.StatusTooManyRequests,
+	UpstreamGmail:   http.StatusBadGateway,
+	UpstreamLLM:     http.StatusBadGateway,
+	Validation:      http.StatusBadRequest,
+	Unauthenticated: http.StatusUnauthorized,
+	Forbidden:       http.StatusForbidden,
+	Unavailable:     http.StatusServiceUnavailable,
+	Internal:        http.StatusInternalServerError,
+}
+
+// Error is a classified error. Sentinel errors are declared with New and
+// compared with errors.Is as before.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError // per-field details of Validation errors
+}
+
+// FieldError explains why one input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"` // JSON name, dotted for nested fields
+	Message string `json:"message"`
+}
+
+// New creates a classified error.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// wrapped classifies an error from elsewhere, such as an upstream client,
+// without hiding it from errors.Is and errors.As.
+type wrapped struct {
+	code Code
+	err  error
+}
+
+func (w *wrapped) Error() string { return w.err.Error() }
+func (w *wrapped) Unwrap() error { return w.err }
+
+// Wrap classifies err with code; a nil err stays nil.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &wrapped{code: code, err: err}
+}
+
+// FieldsOf returns the field details of a Validation error in err's chain.
+func FieldsOf(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+	return nil
+}
+
+// CodeOf returns the code of the outermost classified error in err's
+// chain. Unclassified database errors are mapped where the meaning is
+// unambiguous; everything else is Internal.
+func CodeOf(err error) Code {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		switch e := e.(type) {
+		case *Error:
+			return e.Code
+		case *wrapped:
+			return e.code
+		}
+	}
+
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound
+	case errors.As(err, &pqErr) && pqErr.Code == "23505": // unique_violation
+		return Conflict
+	case errors.As(err, &pqErr) && pqErr.Code == "22P02": // invalid_text_representation, e.g. a malformed UUID
+		return Validation
+	case errors.Is(err, context.DeadlineExceeded):
+		return Unavailable
+	}
+	return Internal
+}
+
+// HTTPStatus returns the REST status code for a code.
+func HTTPStatus(code Code) int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message returns the message to show clients for err. Internal errors are
+// replaced with a generic message so details never leak.
+func Message(err error) string {
+	if CodeOf(err) == Internal {
+		return "internal error"
+	}
+	return err.Error()
+}
@@ -0,0 +1,34 @@
+package apperr
+
+import (
+	"context"
+	"log"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Presenter is the GraphQL server's error presenter. It adds the error's
+// code as extensions.code and hides the details of internal errors.
+func Presenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	code := CodeOf(err)
+	if code == Internal {
+		// Query validation and parse errors come from gqlgen itself and
+		// already carry a useful message.
+		if gqlErr.Err == nil {
+			code = Validation
+		} else {
+			log.Printf("GraphQL %s failed: %v", graphql.GetPath(ctx), err)
+			gqlErr.Message = Message(err)
+		}
+	}
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]interface{}{}
+	}
+	gqlErr.Extensions["code"] = code
+	if fields := FieldsOf(err); len(fields) > 0 {
+		gqlErr.Extensions["fields"] = fields
+	}
+	return gqlErr
+}
@@ -0,0 +1,23 @@
+package apperr
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Respond writes err as a JSON error body, {"error": message, "code": code}
+// plus "fields" for validation errors, with the status for its code.
+// Internal errors are logged with what (the operation that failed) and
+// reported generically.
+func Respond(c *gin.Context, what string, err error) {
+	code := CodeOf(err)
+	if code == Internal {
+		log.Printf("%s failed: %v", what, err)
+	}
+	body := gin.H{"error": Message(err), "code": code}
+	if fields := FieldsOf(err); len(fields) > 0 {
+		body["fields"] = fields
+	}
+	c.AbortWithStatusJSON(HTTPStatus(code), body)
+}
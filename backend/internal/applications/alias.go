@@ -0,0 +1,215 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/mail"
+	"sort"
+	"strings"
+
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// aliasBatch caps the emails checked per run of DetectAliases.
+const aliasBatch = 500
+
+// DetectAlias returns which of the user's addresses an email was sent to:
+// a plus address such as me+google@gmail.com, the primary address itself,
+// or, when the email has a single recipient that is neither, that
+// recipient (an alias forwarding into the mailbox). It returns "" when the
+// recipients say nothing about the user. Gmail ignores dots and case in
+// the local part and treats googlemail.com as gmail.com, and so does this.
+func DetectAlias(userEmail, recipients string) string {
+	primary, ok := splitAddress(userEmail)
+	if !ok {
+		return ""
+	}
+	addrs, err := mail.ParseAddressList(recipients)
+	if err != nil {
+		addrs = nil
+		for _, part := range strings.Split(recipients, ",") {
+			if a, err := mail.ParseAddress(strings.TrimSpace(part)); err == nil {
+				addrs = append(addrs, a)
+			}
+		}
+	}
+	for _, a := range addrs {
+		if addr, ok := splitAddress(a.Address); ok && addr.base == primary.base && addr.domain == primary.domain {
+			return strings.ToLower(a.Address)
+		}
+	}
+	if len(addrs) == 1 {
+		return strings.ToLower(addrs[0].Address)
+	}
+	return ""
+}
+
+// AliasTag returns the tag of a plus address ("google" for
+// me+google@gmail.com), or "" if it has none.
+func AliasTag(alias string) string {
+	local, _, _ := strings.Cut(alias, "@")
+	_, tag, _ := strings.Cut(local, "+")
+	return tag
+}
+
+type address struct {
+	base   string // local part without the plus tag, normalized
+	domain string
+}
+
+func splitAddress(addr string) (address, bool) {
+	local, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(addr)), "@")
+	if !ok || local == "" || domain == "" {
+		return address{}, false
+	}
+	base, _, _ := strings.Cut(local, "+")
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		base = strings.ReplaceAll(base, ".", "")
+	}
+	return address{base: base, domain: domain}, true
+}
+
+// RecordAlias stores the address the user applied with, detected from the
+// recipients of an email about the application. An alias already recorded
+// is kept, since the first email (usually the application confirmation)
+// shows the address the user applied with.
+func (s *Service) RecordAlias(ctx context.Context, userID, id, alias string) error {
+	if alias == "" {
+		return nil
+	}
+	src := eventlog.Source{Type: eventlog.EventClassificationApplied, Actor: eventlog.ActorSync}
+	return eventlog.Within(ctx, s.db, src, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE applications SET alias = $3 WHERE id = $1 AND user_id = $2 AND alias IS NULL`,
+			id, userID, alias)
+		return err
+	})
+}
+
+type aliasEmail struct {
+	emailID, applicationID, userID, userEmail string
+	recipient                                 sql.NullString
+}
+
+// DetectAliases records the alias of applications that have none from the
+// emails linked to them that have not been checked yet, oldest first. It is
+// intended to run from the scheduler.
+func (s *Service) DetectAliases(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, a.id, a.user_id, u.email, e.recipient
+		FROM email_cache e
+		JOIN applications a ON a.user_id = e.user_id AND (a.id = e.application_id OR a.email_id = e.id)
+		JOIN users u ON u.id = a.user_id
+		WHERE e.alias_checked_at IS NULL
+		ORDER BY e.date NULLS LAST
+		LIMIT $1`, aliasBatch)
+	if err != nil {
+		return err
+	}
+	var pending []aliasEmail
+	for rows.Next() {
+		var e aliasEmail
+		if err := rows.Scan(&e.emailID, &e.applicationID, &e.userID, &e.userEmail, &e.recipient); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.RecordAlias(ctx, e.userID, e.applicationID, DetectAlias(e.userEmail, e.recipient.String)); err != nil {
+			log.Printf("Failed to record alias of application %s: %v", e.applicationID, err)
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE email_cache SET alias_checked_at = CURRENT_TIMESTAMP WHERE id = $1`, e.emailID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AliasStats is how applications made with one alias fared.
+type AliasStats struct {
+	Alias        string  `json:"alias"`
+	Tag          *string `json:"tag"` // plus tag, e.g. "google"
+	Applications int     `json:"applications"`
+	Responded    int     `json:"responded"`   // heard back beyond the acknowledgement
+	Interviewed  int     `json:"interviewed"` // reached an interview
+	ResponseRate float64 `json:"responseRate"`
+}
+
+// Aliases attributes the user's applications to the aliases they were made
+// with, most used first.
+func (s *Service) Aliases(ctx context.Context, userID string) ([]*AliasStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT alias, status FROM applications
+		WHERE user_id = $1 AND alias IS NOT NULL`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byAlias := make(map[string]*AliasStats)
+	var out []*AliasStats
+	for rows.Next() {
+		var alias, status string
+		if err := rows.Scan(&alias, &status); err != nil {
+			return nil, err
+		}
+		st := byAlias[alias]
+		if st == nil {
+			st = &AliasStats{Alias: alias}
+			if tag := AliasTag(alias); tag != "" {
+				st.Tag = &tag
+			}
+			byAlias[alias] = st
+			out = append(out, st)
+		}
+		st.Applications++
+		if models.HasResponse(status) {
+			st.Responded++
+		}
+		if models.ReachedInterview(status) {
+			st.Interviewed++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, st := range out {
+		st.ResponseRate = float64(st.Responded) / float64(st.Applications)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Applications != out[j].Applications {
+			return out[i].Applications > out[j].Applications
+		}
+		return out[i].Alias < out[j].Alias
+	})
+	return out, nil
+}
+
+// ByAlias returns the user's applications made with an alias, newest first.
+func (s *Service) ByAlias(ctx context.Context, userID, alias string) ([]*models.Application, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM applications
+		WHERE user_id = $1 AND alias = LOWER($2)
+		ORDER BY applied_date DESC`,
+		userID, strings.TrimSpace(alias))
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows)
+}
@@ -0,0 +1,86 @@
+package applications
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/ats"
+)
+
+// atsBatch caps the emails read per run of DetectATS.
+const atsBatch = 100
+
+type atsEmail struct {
+	emailID, applicationID, userID string
+}
+
+// DetectATS records the applicant tracking system and candidate portal of
+// applications from the emails linked to them that have not been checked
+// yet, newest first. Gmail is asked for each message, since the headers that
+// name the ATS are not cached. Each email is checked once, including when
+// reading it fails, so one unreadable message does not hold up the rest.
+// Mailboxes that are paused or disconnected wait. It is intended to run from
+// the scheduler.
+func (s *Service) DetectATS(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, a.id, a.user_id
+		FROM email_cache e
+		JOIN applications a ON a.user_id = e.user_id AND (a.id = e.application_id OR a.email_id = e.id)
+		JOIN users u ON u.id = e.user_id
+		WHERE e.ats_checked_at IS NULL AND e.gmail_deleted_at IS NULL
+			AND u.mailbox_disconnected_at IS NULL AND u.mailbox_paused_at IS NULL
+		ORDER BY a.user_id, e.date DESC NULLS LAST
+		LIMIT $1`, atsBatch)
+	if err != nil {
+		return err
+	}
+	var pending []atsEmail
+	for rows.Next() {
+		var e atsEmail
+		if err := rows.Scan(&e.emailID, &e.applicationID, &e.userID); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var svc *gmail.Service
+	var svcUser string
+	for _, e := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if e.userID != svcUser {
+			svc, svcUser = nil, e.userID
+			ts, err := s.tokens.TokenSource(ctx, e.userID)
+			if err == nil {
+				svc, err = gmail.NewService(ctx, option.WithTokenSource(ts))
+			}
+			if err != nil {
+				log.Printf("Failed to detect the ATS of user %s's applications: %v", e.userID, err)
+			}
+		}
+		if svc == nil {
+			continue
+		}
+		msg, err := svc.Users.Messages.Get("me", e.emailID).Format("full").Context(ctx).Do()
+		if err == nil {
+			err = s.RecordATS(ctx, e.userID, e.applicationID, ats.FromMessage(msg))
+		}
+		if err != nil {
+			log.Printf("Failed to detect the ATS of application %s from email %s: %v", e.applicationID, e.emailID, err)
+		}
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE email_cache SET ats_checked_at = CURRENT_TIMESTAMP WHERE id = $1`, e.emailID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
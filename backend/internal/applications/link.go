@@ -0,0 +1,76 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// LinkEmail files a classified email under the application it is about:
+// the user's most recent application to the extracted company (and
+// position, when one was extracted) or, with none, a new application
+// created from the extraction. A matched application takes the email's
+// status unless it changed after the email was received, so old mail does
+// not undo newer progress. It returns nil when no company was extracted.
+func (s *Service) LinkEmail(ctx context.Context, userID, emailID string, received *time.Time,
+	a *agentspb.ExtractedApplication, status string) (*models.Application, error) {
+	if a == nil || a.Company == "" {
+		return nil, nil
+	}
+	var position *string
+	if a.Position != "" {
+		position = &a.Position
+	}
+	matches, err := s.FindByCompany(ctx, userID, a.Company, position)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = a.Status
+	}
+
+	src := eventlog.Source{Type: eventlog.EventEmailIngested, Actor: eventlog.ActorSync, Ref: emailID}
+	if len(matches) > 0 {
+		app := matches[0]
+		if status == "" || status == app.Status {
+			return app, nil
+		}
+		err := eventlog.Within(ctx, s.db, src, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE applications SET status = $3
+				WHERE id = $1 AND user_id = $2 AND ($4::timestamptz IS NULL OR updated_at <= $4)`,
+				app.ID, userID, status, received)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.Get(ctx, userID, app.ID)
+	}
+
+	if status == "" {
+		status = models.StatusApplied
+	}
+	var applied *time.Time
+	if d, err := time.Parse("2006-01-02", a.AppliedDate); err == nil {
+		applied = &d
+	} else if received != nil {
+		applied = received
+	}
+	var app *models.Application
+	err = eventlog.Within(ctx, s.db, src, func(tx *sql.Tx) error {
+		var err error
+		app, err = scan(tx.QueryRowContext(ctx, `
+			INSERT INTO applications (user_id, company, position, applied_date, status, source, location, job_id,
+				status_link, email_id)
+			VALUES ($1, $2, $3, COALESCE($4::date, CURRENT_DATE), $5, $6, $7, $8, $9, $10)
+			RETURNING `+columns,
+			userID, a.Company, a.Position, applied, status, a.Source, a.Location, a.JobId, a.StatusLink, emailID))
+		return err
+	})
+	return app, err
+}
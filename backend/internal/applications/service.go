@@ -0,0 +1,243 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/ats"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/googleauth"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// ErrNotFound is returned when an application does not exist or belongs to
+// another user.
+var ErrNotFound = apperr.New(apperr.NotFound, "application not found")
+
+// Input holds the editable fields of an application (ApplicationInput).
+type Input struct {
+	Company         string                `json:"company" validate:"required,max=255"`
+	Position        string                `json:"position" validate:"required,max=1000"`
+	AppliedDate     string                `json:"appliedDate" validate:"omitempty,datetime=2006-01-02"`
+	Status          string                `json:"status" validate:"max=50"`
+	Source          string                `json:"source" validate:"max=255"`
+	Location        *string               `json:"location" validate:"omitempty,max=255"`
+	JobID           *string               `json:"jobId" validate:"omitempty,max=255"`
+	StatusLink      *string               `json:"statusLink" validate:"omitempty,url,max=2048"`
+	Notes           *string               `json:"notes" validate:"omitempty,max=10000"`
+	JobDescription  *string               `json:"jobDescription" validate:"omitempty,max=100000"`
+	PostingDeadline *time.Time            `json:"postingDeadline"`
+	Tags            []string              `json:"tags" validate:"max=20,dive,required,max=50"`
+	CustomFields    []*models.CustomField `json:"customFields" validate:"max=50,dive"`
+}
+
+// Service reads and writes applications.
+type Service struct {
+	db     *sql.DB
+	agents *agents.Client
+	tokens *googleauth.TokenStore
+	events *eventlog.Service
+}
+
+// NewService creates an application service. The agents client drafts
+// withdrawal emails and summarizes applications, and emails are read from
+// Gmail with the user's token to detect their ATS.
+func NewService(db *sql.DB, agentsClient *agents.Client, tokens *googleauth.TokenStore) *Service {
+	return &Service{db: db, agents: agentsClient, tokens: tokens, events: eventlog.NewService(db)}
+}
+
+const columns = `id, user_id, company, position, applied_date::text, status, COALESCE(source, ''),
+	location, job_id, status_link, notes, notes_private, email_id, ats, portal_url, snoozed_until, job_description, posting_deadline,
+	withdrawal_reason, tags, custom_fields, alias, archived_at, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*models.Application, error) {
+	a := &models.Application{}
+	var customFields []byte
+	err := row.Scan(&a.ID, &a.UserID, &a.Company, &a.Position, &a.AppliedDate, &a.Status, &a.Source,
+		&a.Location, &a.JobID, &a.StatusLink, &a.Notes, &a.NotesPrivate, &a.EmailID, &a.ATS, &a.PortalURL,
+		&a.SnoozedUntil, &a.JobDescription, &a.PostingDeadline, &a.WithdrawalReason, pq.Array(&a.Tags), &customFields,
+		&a.Alias, &a.ArchivedAt, &a.CreatedAt, &a.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(customFields, &a.CustomFields); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func scanAll(rows *sql.Rows) ([]*models.Application, error) {
+	defer rows.Close()
+	var out []*models.Application
+	for rows.Next() {
+		a, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// Get returns one of the user's applications.
+func (s *Service) Get(ctx context.Context, userID, id string) (*models.Application, error) {
+	return scan(s.db.QueryRowContext(ctx,
+		`SELECT `+columns+` FROM applications WHERE id = $1 AND user_id = $2`, id, userID))
+}
+
+// SetNotesPrivate marks the notes of one of the user's applications
+// private, hiding them from shared views, or shares them again.
+func (s *Service) SetNotesPrivate(ctx context.Context, userID, id string, private bool) (*models.Application, error) {
+	var app *models.Application
+	err := eventlog.Within(ctx, s.db, eventlog.Source{Type: eventlog.EventEdited, Actor: eventlog.ActorUser}, func(tx *sql.Tx) error {
+		var err error
+		app, err = scan(tx.QueryRowContext(ctx, `
+			UPDATE applications SET notes_private = $3
+			WHERE id = $1 AND user_id = $2
+			RETURNING `+columns,
+			id, userID, private))
+		return err
+	})
+	return app, err
+}
+
+// SetArchived archives one of the user's applications, hiding it from
+// default views for good, or brings it back.
+func (s *Service) SetArchived(ctx context.Context, userID, id string, archived bool) (*models.Application, error) {
+	var app *models.Application
+	err := eventlog.Within(ctx, s.db, eventlog.Source{Type: eventlog.EventEdited, Actor: eventlog.ActorUser}, func(tx *sql.Tx) error {
+		var err error
+		app, err = scan(tx.QueryRowContext(ctx, `
+			UPDATE applications SET archived_at = CASE WHEN $3 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END
+			WHERE id = $1 AND user_id = $2
+			RETURNING `+columns,
+			id, userID, archived))
+		return err
+	})
+	return app, err
+}
+
+// Create inserts a new application entered by the user.
+func (s *Service) Create(ctx context.Context, userID string, in Input) (*models.Application, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	return s.insert(ctx, userID, in, nil)
+}
+
+func (s *Service) insert(ctx context.Context, userID string, in Input, resumeID *string) (*models.Application, error) {
+	if in.Status == "" {
+		in.Status = models.StatusApplied
+	}
+	in.Tags = NormalizeTags(in.Tags)
+	if in.CustomFields == nil {
+		in.CustomFields = []*models.CustomField{}
+	}
+	customFields, err := json.Marshal(in.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	var app *models.Application
+	err = eventlog.Within(ctx, s.db, eventlog.Source{Type: eventlog.EventCreated, Actor: eventlog.ActorUser}, func(tx *sql.Tx) error {
+		var err error
+		app, err = scan(tx.QueryRowContext(ctx, `
+			INSERT INTO applications (user_id, company, position, applied_date, status, source, location, job_id, status_link, notes, job_description,
+				posting_deadline, tags, custom_fields, resume_id)
+			VALUES ($1, $2, $3, COALESCE(NULLIF($4, '')::date, CURRENT_DATE), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			RETURNING `+columns,
+			userID, in.Company, in.Position, in.AppliedDate, in.Status, in.Source,
+			in.Location, in.JobID, in.StatusLink, in.Notes, in.JobDescription,
+			in.PostingDeadline, pq.Array(in.Tags), customFields, resumeID))
+		return err
+	})
+	return app, err
+}
+
+// NormalizeTags trims and lowercases tags and drops blanks and duplicates,
+// keeping the first occurrence's order.
+func NormalizeTags(tags []string) []string {
+	out := []string{}
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// FindByCompany returns the user's applications to a company (case
+// insensitive), optionally narrowed to positions containing the given text,
+// matched literally and case insensitively.
+func (s *Service) FindByCompany(ctx context.Context, userID, company string, position *string) ([]*models.Application, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM applications
+		WHERE user_id = $1 AND LOWER(company) = LOWER($2)
+		  AND ($3::text IS NULL OR strpos(LOWER(position), LOWER($3)) > 0)
+		ORDER BY applied_date DESC`,
+		userID, company, position)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows)
+}
+
+// RecordATS stores the applicant tracking system and candidate-portal link
+// detected in an email about the application. Existing values are only
+// replaced when the new detection has them.
+func (s *Service) RecordATS(ctx context.Context, userID, id string, d ats.Detection) error {
+	if !d.Found() {
+		return nil
+	}
+	src := eventlog.Source{Type: eventlog.EventClassificationApplied, Actor: eventlog.ActorSync}
+	return eventlog.Within(ctx, s.db, src, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE applications
+			SET ats = COALESCE(NULLIF($3, ''), ats), portal_url = COALESCE(NULLIF($4, ''), portal_url)
+			WHERE id = $1 AND user_id = $2`,
+			id, userID, d.ATS, d.PortalURL)
+		return err
+	})
+}
+
+// QuickLink is a labelled shortcut shown next to an application.
+type QuickLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// QuickLinks returns the shortcuts for an application: its candidate portal
+// and its status link, without duplicates.
+func QuickLinks(a *models.Application) []*QuickLink {
+	var links []*QuickLink
+	if a.PortalURL != nil && *a.PortalURL != "" {
+		label := "Candidate portal"
+		if a.ATS != nil {
+			label = *a.ATS + " portal"
+		}
+		links = append(links, &QuickLink{Label: label, URL: *a.PortalURL})
+	}
+	if a.StatusLink != nil && *a.StatusLink != "" && (a.PortalURL == nil || *a.StatusLink != *a.PortalURL) {
+		links = append(links, &QuickLink{Label: "Status page", URL: *a.StatusLink})
+	}
+	return links
+}
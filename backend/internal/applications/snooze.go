@@ -0,0 +1,81 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/realtime"
+)
+
+// ErrSnoozeInPast is returned when snoozing until a time that has passed.
+var ErrSnoozeInPast = apperr.New(apperr.Validation, "snooze time must be in the future")
+
+// Snooze hides one of the user's applications from default views and
+// suppresses its reminders until the given time, when Resurface brings it
+// back.
+func (s *Service) Snooze(ctx context.Context, userID, id string, until time.Time) (*models.Application, error) {
+	if !until.After(time.Now()) {
+		return nil, ErrSnoozeInPast
+	}
+	return s.setSnooze(ctx, userID, id, &until)
+}
+
+// Unsnooze brings a snoozed application back immediately.
+func (s *Service) Unsnooze(ctx context.Context, userID, id string) (*models.Application, error) {
+	return s.setSnooze(ctx, userID, id, nil)
+}
+
+func (s *Service) setSnooze(ctx context.Context, userID, id string, until *time.Time) (*models.Application, error) {
+	var app *models.Application
+	err := eventlog.Within(ctx, s.db, eventlog.Source{Type: eventlog.EventEdited, Actor: eventlog.ActorUser}, func(tx *sql.Tx) error {
+		var err error
+		app, err = scan(tx.QueryRowContext(ctx, `
+			UPDATE applications SET snoozed_until = $3
+			WHERE id = $1 AND user_id = $2
+			RETURNING `+columns,
+			id, userID, until))
+		return err
+	})
+	return app, err
+}
+
+// Resurface unsnoozes every application whose snooze has run out and
+// returns them.
+func (s *Service) Resurface(ctx context.Context) ([]*models.Application, error) {
+	var apps []*models.Application
+	err := eventlog.Within(ctx, s.db, eventlog.Source{Type: eventlog.EventEdited, Actor: eventlog.ActorSystem}, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE applications SET snoozed_until = NULL
+			WHERE snoozed_until <= CURRENT_TIMESTAMP
+			RETURNING `+columns)
+		if err != nil {
+			return err
+		}
+		apps, err = scanAll(rows)
+		return err
+	})
+	return apps, err
+}
+
+// ResurfaceJob returns the scheduled job that unsnoozes due applications and
+// tells the user's open clients, which put them back in view.
+func (s *Service) ResurfaceJob(bus *realtime.Service) func(context.Context) error {
+	return func(ctx context.Context) error {
+		apps, err := s.Resurface(ctx)
+		if err != nil {
+			return err
+		}
+		for _, app := range apps {
+			ev := realtime.Event{Type: realtime.EventApplicationResurfaced, Payload: app}
+			if err := bus.Publish(ctx, app.UserID, ev); err != nil {
+				log.Printf("Failed to publish resurfaced application %s: %v", app.ID, err)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,191 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// summaryEmails caps the emails sent to the agents service for a summary;
+// the latest are kept.
+const summaryEmails = 20
+
+// summaryColumns are left out of event descriptions: they are long and say
+// little about where the application stands.
+var summaryColumns = map[string]bool{
+	"job_description": true, "custom_fields": true, "email_id": true, "resume_id": true,
+	"user_id": true, "id": true, "created_at": true, "updated_at": true,
+}
+
+// Summary returns a short LLM-written history of the application, such as
+// "Applied Mar 3 via referral; recruiter screen Mar 12; awaiting onsite
+// scheduling". Summaries are cached and only regenerated once the
+// application has new events, emails or interview takeaways. When regeneration fails the stale
+// summary is returned if there is one.
+func (s *Service) Summary(ctx context.Context, app *models.Application) (*string, error) {
+	var cached sql.NullString
+	var seq, cachedSeq int
+	var lastEmail, cachedLastEmail, lastInterview, cachedLastInterview sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT (SELECT COALESCE(MAX(seq), 0) FROM application_event_stream WHERE application_id = $1),
+			(SELECT MAX(date) FROM email_cache WHERE application_id = $1 OR id = $2),
+			(SELECT MAX(transcript_summarized_at) FROM interviews WHERE application_id = $1),
+			s.summary, COALESCE(s.event_seq, -1), s.last_email_at, s.last_interview_summary_at
+		FROM (SELECT 1) one LEFT JOIN application_summaries s ON s.application_id = $1`,
+		app.ID, app.EmailID).Scan(&seq, &lastEmail, &lastInterview, &cached, &cachedSeq, &cachedLastEmail, &cachedLastInterview)
+	if err != nil {
+		return nil, err
+	}
+	if cached.Valid && cachedSeq == seq && sameTime(lastEmail, cachedLastEmail) && sameTime(lastInterview, cachedLastInterview) {
+		return &cached.String, nil
+	}
+
+	summary, err := s.summarize(ctx, app)
+	if err != nil {
+		if cached.Valid {
+			log.Printf("Serving stale summary of application %s: %v", app.ID, err)
+			return &cached.String, nil
+		}
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO application_summaries (application_id, summary, event_seq, last_email_at, last_interview_summary_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (application_id) DO UPDATE SET summary = EXCLUDED.summary, event_seq = EXCLUDED.event_seq,
+			last_email_at = EXCLUDED.last_email_at, last_interview_summary_at = EXCLUDED.last_interview_summary_at,
+			generated_at = CURRENT_TIMESTAMP`,
+		app.ID, summary, seq, lastEmail, lastInterview); err != nil {
+		log.Printf("Failed to cache summary of application %s: %v", app.ID, err)
+	}
+	return &summary, nil
+}
+
+func sameTime(a, b sql.NullTime) bool {
+	return a.Valid == b.Valid && a.Time.Equal(b.Time)
+}
+
+// summarize asks the agents service for a summary of the application's
+// event stream, emails and interview takeaways.
+func (s *Service) summarize(ctx context.Context, app *models.Application) (string, error) {
+	req := &agentspb.SummarizeApplicationRequest{
+		Company:     app.Company,
+		Position:    app.Position,
+		AppliedDate: app.AppliedDate,
+		Status:      app.Status,
+		Source:      app.Source,
+	}
+	events, err := s.events.Events(ctx, app.UserID, app.ID)
+	if err != nil && !errors.Is(err, eventlog.ErrNoEvents) {
+		return "", err
+	}
+	for _, e := range events {
+		req.Events = append(req.Events, &agentspb.TimelineEvent{
+			OccurredAt:  e.OccurredAt.Format(time.RFC3339),
+			Type:        e.Type,
+			Description: describe(e),
+		})
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT * FROM (
+			SELECT id, COALESCE(subject, ''), COALESCE(sender, ''), date, COALESCE(snippet, ''),
+				COALESCE(body_text, ''), COALESCE(language, '')
+			FROM email_cache
+			WHERE user_id = $1 AND (application_id = $2 OR id = $3)
+			ORDER BY date DESC LIMIT $4
+		) latest ORDER BY date`,
+		app.UserID, app.ID, app.EmailID, summaryEmails)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e := &agentspb.Email{}
+		var date sql.NullTime
+		if err := rows.Scan(&e.Id, &e.Subject, &e.From, &date, &e.Snippet, &e.Body, &e.Language); err != nil {
+			return "", err
+		}
+		if date.Valid {
+			e.Date = date.Time.Format(time.RFC3339)
+		}
+		// The summary is written in the language the company writes in.
+		if e.Language != "" {
+			req.Language = e.Language
+		}
+		req.Emails = append(req.Emails, e)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if req.Interviews, err = s.interviewTakeaways(ctx, app); err != nil {
+		return "", err
+	}
+	return s.agents.SummarizeApplication(ctx, req)
+}
+
+// interviewTakeaways returns what came out of the application's interviews
+// whose transcripts have been summarized, oldest first.
+func (s *Service) interviewTakeaways(ctx context.Context, app *models.Application) ([]*agentspb.InterviewTakeaways, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT title, starts_at, transcript_takeaways, transcript_action_items
+		FROM interviews
+		WHERE user_id = $1 AND application_id = $2 AND transcript_summarized_at IS NOT NULL
+		ORDER BY starts_at`,
+		app.UserID, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*agentspb.InterviewTakeaways
+	for rows.Next() {
+		t := &agentspb.InterviewTakeaways{}
+		var startsAt time.Time
+		if err := rows.Scan(&t.Title, &startsAt, pq.Array(&t.Takeaways), pq.Array(&t.ActionItems)); err != nil {
+			return nil, err
+		}
+		t.OccurredAt = startsAt.Format(time.RFC3339)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// describe renders the columns an event changed, e.g. "status: Interview
+// Scheduled", or its details for events that change nothing.
+func describe(e *eventlog.Event) string {
+	fields := e.Changes
+	if len(fields) == 0 {
+		fields = e.Details
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if !summaryColumns[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		var value any
+		if err := json.Unmarshal(fields[name], &value); err != nil || value == nil {
+			continue
+		}
+		text := fmt.Sprint(value)
+		if r := []rune(text); len(r) > 200 {
+			text = string(r[:200]) + "..."
+		}
+		parts = append(parts, name+": "+text)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,222 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+var (
+	// ErrTemplateNotFound is returned when a template does not exist or
+	// belongs to another user.
+	ErrTemplateNotFound = apperr.New(apperr.NotFound, "application template not found")
+	// ErrTemplateExists is returned when the user already has a template
+	// with the same name (ignoring case).
+	ErrTemplateExists = apperr.New(apperr.Conflict, "a template with this name already exists")
+)
+
+// Template holds the fields shared by applications to a role the user
+// applies for over and over, such as "SWE New Grad".
+type Template struct {
+	ID           string                `json:"id"`
+	Name         string                `json:"name"`
+	Position     string                `json:"position"`
+	Source       *string               `json:"source"`
+	Location     *string               `json:"location"`
+	Tags         []string              `json:"tags"`
+	CustomFields []*models.CustomField `json:"customFields"`
+	ResumeID     *string               `json:"resumeId"` // resume version attached to new applications
+	CreatedAt    time.Time             `json:"createdAt"`
+	UpdatedAt    time.Time             `json:"updatedAt"`
+}
+
+// TemplateInput creates or replaces a template.
+type TemplateInput struct {
+	Name         string                `json:"name" validate:"required,max=255"`
+	Position     string                `json:"position" validate:"required,max=1000"`
+	Source       *string               `json:"source" validate:"omitempty,max=255"`
+	Location     *string               `json:"location" validate:"omitempty,max=255"`
+	Tags         []string              `json:"tags" validate:"max=20,dive,required,max=50"`
+	CustomFields []*models.CustomField `json:"customFields" validate:"max=50,dive"`
+	ResumeID     *string               `json:"resumeId" validate:"omitempty,uuid"`
+}
+
+// FromTemplateInput holds what differs between applications made from the
+// same template. Tags are added to the template's; custom fields override
+// the template's values of the same name.
+type FromTemplateInput struct {
+	Company        string                `json:"company" validate:"required,max=255"`
+	AppliedDate    string                `json:"appliedDate" validate:"omitempty,datetime=2006-01-02"`
+	Location       *string               `json:"location" validate:"omitempty,max=255"`
+	JobID          *string               `json:"jobId" validate:"omitempty,max=255"`
+	StatusLink     *string               `json:"statusLink" validate:"omitempty,url,max=2048"`
+	Notes          *string               `json:"notes" validate:"omitempty,max=10000"`
+	JobDescription *string               `json:"jobDescription" validate:"omitempty,max=100000"`
+	Tags           []string              `json:"tags" validate:"max=20,dive,required,max=50"`
+	CustomFields   []*models.CustomField `json:"customFields" validate:"max=50,dive"`
+}
+
+const templateColumns = `id, name, position, source, location, tags, custom_fields, resume_id, created_at, updated_at`
+
+func scanTemplate(row scanner) (*Template, error) {
+	t := &Template{}
+	var customFields []byte
+	err := row.Scan(&t.ID, &t.Name, &t.Position, &t.Source, &t.Location, pq.Array(&t.Tags), &customFields,
+		&t.ResumeID, &t.CreatedAt, &t.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(customFields, &t.CustomFields); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Templates returns the user's templates by name.
+func (s *Service) Templates(ctx context.Context, userID string) ([]*Template, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+templateColumns+` FROM application_templates
+		WHERE user_id = $1 ORDER BY LOWER(name)`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Template returns one of the user's templates.
+func (s *Service) Template(ctx context.Context, userID, id string) (*Template, error) {
+	return scanTemplate(s.db.QueryRowContext(ctx,
+		`SELECT `+templateColumns+` FROM application_templates WHERE id = $1 AND user_id = $2`, id, userID))
+}
+
+// SaveTemplate creates a template, or replaces one of the user's templates
+// when id is given. The resume must be one of the user's.
+func (s *Service) SaveTemplate(ctx context.Context, userID string, id *string, in TemplateInput) (*Template, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	if in.ResumeID != nil {
+		var owned bool
+		if err := s.db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM resumes WHERE id = $1 AND user_id = $2)`, *in.ResumeID, userID).Scan(&owned); err != nil {
+			return nil, err
+		}
+		if !owned {
+			return nil, validation.Field("resumeId", "must be one of your resumes")
+		}
+	}
+	if in.CustomFields == nil {
+		in.CustomFields = []*models.CustomField{}
+	}
+	customFields, err := json.Marshal(in.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{userID, strings.TrimSpace(in.Name), in.Position, in.Source, in.Location,
+		pq.Array(NormalizeTags(in.Tags)), customFields, in.ResumeID}
+	var t *Template
+	if id == nil {
+		t, err = scanTemplate(s.db.QueryRowContext(ctx, `
+			INSERT INTO application_templates (user_id, name, position, source, location, tags, custom_fields, resume_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING `+templateColumns, args...))
+	} else {
+		t, err = scanTemplate(s.db.QueryRowContext(ctx, `
+			UPDATE application_templates SET name = $2, position = $3, source = $4, location = $5, tags = $6,
+				custom_fields = $7, resume_id = $8, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $9 AND user_id = $1
+			RETURNING `+templateColumns, append(args, *id)...))
+	}
+	if err != nil && apperr.CodeOf(err) == apperr.Conflict {
+		return nil, ErrTemplateExists
+	}
+	return t, err
+}
+
+// DeleteTemplate removes a template. Applications made from it are kept.
+func (s *Service) DeleteTemplate(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM application_templates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// CreateFromTemplate adds an application from one of the user's templates:
+// the role title, source, location, tags, custom fields and resume version
+// come from the template and only the company is required.
+func (s *Service) CreateFromTemplate(ctx context.Context, userID, templateID string, in FromTemplateInput) (*models.Application, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	t, err := s.Template(ctx, userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	app := Input{
+		Company:        in.Company,
+		Position:       t.Position,
+		AppliedDate:    in.AppliedDate,
+		Location:       t.Location,
+		JobID:          in.JobID,
+		StatusLink:     in.StatusLink,
+		Notes:          in.Notes,
+		JobDescription: in.JobDescription,
+		Tags:           append(append([]string{}, t.Tags...), in.Tags...),
+		CustomFields:   mergeCustomFields(t.CustomFields, in.CustomFields),
+	}
+	if t.Source != nil {
+		app.Source = *t.Source
+	}
+	if in.Location != nil {
+		app.Location = in.Location
+	}
+	return s.insert(ctx, userID, app, t.ResumeID)
+}
+
+// mergeCustomFields returns base with each field in overrides replacing the
+// one of the same name (ignoring case) or appended after it.
+func mergeCustomFields(base, overrides []*models.CustomField) []*models.CustomField {
+	out := append([]*models.CustomField{}, base...)
+	for _, o := range overrides {
+		replaced := false
+		for i, f := range out {
+			if strings.EqualFold(f.Name, o.Name) {
+				out[i] = o
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, o)
+		}
+	}
+	return out
+}
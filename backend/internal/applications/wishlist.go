@@ -0,0 +1,71 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/postings"
+)
+
+// ErrNotSaved is returned when marking an application applied that is not
+// on the wishlist.
+var ErrNotSaved = apperr.New(apperr.Conflict, "application has already been applied to")
+
+// SavePosting adds a captured job posting to the user's wishlist: an
+// application in the Saved status, keeping the posting's link, description
+// and deadline so closing reminders can be sent. Saving a posting already
+// tracked for the same link returns the existing application.
+func (s *Service) SavePosting(ctx context.Context, userID string, p *postings.Posting) (*models.Application, error) {
+	existing, err := scan(s.db.QueryRowContext(ctx,
+		`SELECT `+columns+` FROM applications WHERE user_id = $1 AND status_link = $2 ORDER BY created_at DESC LIMIT 1`,
+		userID, p.URL))
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	in := Input{
+		Company:         p.Company,
+		Position:        p.Title,
+		Status:          models.StatusSaved,
+		Source:          p.Source,
+		Location:        p.Location,
+		StatusLink:      &p.URL,
+		PostingDeadline: p.Deadline,
+	}
+	if p.Description != "" {
+		in.JobDescription = &p.Description
+	}
+	if in.Company == "" {
+		in.Company = p.Source
+	}
+	return s.Create(ctx, userID, in)
+}
+
+// MarkApplied moves a saved application to Applied, dated today since the
+// date it was saved on is not when it was applied to.
+func (s *Service) MarkApplied(ctx context.Context, userID, id string) (*models.Application, error) {
+	var app *models.Application
+	err := eventlog.Within(ctx, s.db, eventlog.Source{Type: eventlog.EventEdited, Actor: eventlog.ActorUser}, func(tx *sql.Tx) error {
+		var err error
+		app, err = scan(tx.QueryRowContext(ctx, `
+			UPDATE applications SET status = $3, applied_date = CURRENT_DATE
+			WHERE id = $1 AND user_id = $2 AND status = $4
+			RETURNING `+columns,
+			id, userID, models.StatusApplied, models.StatusSaved))
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		if _, err := s.Get(ctx, userID, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotSaved
+	}
+	return app, err
+}
@@ -0,0 +1,117 @@
+package applications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/mail"
+	"net/url"
+
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// ErrNotWithdrawable is returned when withdrawing an application that was
+// already rejected or withdrawn.
+var ErrNotWithdrawable = apperr.New(apperr.Conflict, "application is already closed")
+
+// WithdrawalInput withdraws an application.
+type WithdrawalInput struct {
+	Reason string `json:"reason" validate:"required,max=2000"`
+	// DraftEmail asks for a polite withdrawal email to send from Gmail.
+	DraftEmail    bool    `json:"draftEmail"`
+	RecipientName *string `json:"recipientName" validate:"omitempty,max=255"`
+}
+
+// Withdrawal is a withdrawn application and, when asked for, the email
+// telling the company. The email is not sent.
+type Withdrawal struct {
+	Application *models.Application `json:"application"`
+	Draft       *agents.Draft       `json:"draft"`
+	// Recipient is the sender of the latest email about the application.
+	Recipient *string `json:"recipient"`
+	// ComposeURL opens the draft in Gmail, ready to send.
+	ComposeURL *string `json:"composeUrl"`
+}
+
+// Withdraw marks one of the user's open applications withdrawn and records
+// why. With DraftEmail set it also drafts the withdrawal email, addressed to
+// whoever last wrote about the application, with the reason as context. A
+// failed draft is logged and does not undo the withdrawal.
+func (s *Service) Withdraw(ctx context.Context, userID, id string, in WithdrawalInput) (*Withdrawal, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	var app *models.Application
+	err := eventlog.Within(ctx, s.db, eventlog.Source{Type: eventlog.EventEdited, Actor: eventlog.ActorUser}, func(tx *sql.Tx) error {
+		var err error
+		app, err = scan(tx.QueryRowContext(ctx, `
+			UPDATE applications SET status = $3, withdrawal_reason = $4
+			WHERE id = $1 AND user_id = $2 AND status NOT IN ($3, $5)
+			RETURNING `+columns,
+			id, userID, models.StatusWithdrawn, in.Reason, models.StatusRejected))
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		// Tell a closed application apart from a missing one.
+		if _, err := s.Get(ctx, userID, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotWithdrawable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Withdrawal{Application: app}
+	if !in.DraftEmail {
+		return out, nil
+	}
+	var sender sql.NullString
+	err = s.db.QueryRowContext(ctx, `
+		SELECT sender FROM email_cache
+		WHERE user_id = $1 AND (application_id = $2 OR id = $3) AND sender IS NOT NULL
+		ORDER BY date DESC LIMIT 1`,
+		userID, app.ID, app.EmailID).Scan(&sender)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	req := &agentspb.DraftEmailRequest{
+		Kind:     "withdrawal",
+		Company:  app.Company,
+		Position: app.Position,
+		Context:  in.Reason,
+	}
+	if in.RecipientName != nil {
+		req.RecipientName = *in.RecipientName
+	}
+	if addr, err := mail.ParseAddress(sender.String); err == nil {
+		out.Recipient = &addr.Address
+		if req.RecipientName == "" {
+			req.RecipientName = addr.Name
+		}
+	}
+	draft, err := s.agents.DraftEmail(ctx, req, nil)
+	if err != nil {
+		log.Printf("Failed to draft withdrawal email for application %s: %v", app.ID, err)
+		return out, nil
+	}
+	out.Draft = draft
+	compose := composeURL(out.Recipient, draft)
+	out.ComposeURL = &compose
+	return out, nil
+}
+
+// composeURL links to Gmail's compose window prefilled with the draft.
+func composeURL(to *string, d *agents.Draft) string {
+	q := url.Values{"view": {"cm"}, "fs": {"1"}, "su": {d.Subject}, "body": {d.Body}}
+	if to != nil {
+		q.Set("to", *to)
+	}
+	return "https://mail.google.com/mail/?" + q.Encode()
+}
@@ -0,0 +1,177 @@
+// Package ats recognises the applicant tracking system behind recruiting
+// emails and finds candidate-portal links in them.
+package ats
+
+import (
+	"encoding/base64"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// Known applicant tracking systems.
+const (
+	Greenhouse      = "Greenhouse"
+	Lever           = "Lever"
+	Workday         = "Workday"
+	Ashby           = "Ashby"
+	SmartRecruiters = "SmartRecruiters"
+	ICIMS           = "iCIMS"
+	Jobvite         = "Jobvite"
+)
+
+type system struct {
+	name string
+	// domains appear in sender, reply-to, return-path or message-id headers.
+	domains []string
+	// portal matches candidate-portal URLs in the message body.
+	portal *regexp.Regexp
+}
+
+var systems = []system{
+	{
+		name:    Greenhouse,
+		domains: []string{"greenhouse.io", "greenhouse-mail.io"},
+		portal:  regexp.MustCompile(`https://(?:[a-z0-9-]+\.)?greenhouse\.io/[^\s"'<>)]+`),
+	},
+	{
+		name:    Lever,
+		domains: []string{"lever.co", "hire.lever.co"},
+		portal:  regexp.MustCompile(`https://(?:jobs|hire)\.lever\.co/[^\s"'<>)]+`),
+	},
+	{
+		name:    Workday,
+		domains: []string{"myworkday.com", "myworkdayjobs.com", "workday.com"},
+		portal:  regexp.MustCompile(`https://[a-z0-9.-]+\.myworkday(?:jobs)?\.com/[^\s"'<>)]+`),
+	},
+	{
+		name:    Ashby,
+		domains: []string{"ashbyhq.com"},
+		portal:  regexp.MustCompile(`https://(?:jobs\.|app\.)?ashbyhq\.com/[^\s"'<>)]+`),
+	},
+	{
+		name:    SmartRecruiters,
+		domains: []string{"smartrecruiters.com"},
+		portal:  regexp.MustCompile(`https://(?:jobs\.|my\.)?smartrecruiters\.com/[^\s"'<>)]+`),
+	},
+	{
+		name:    ICIMS,
+		domains: []string{"icims.com"},
+		portal:  regexp.MustCompile(`https://[a-z0-9.-]+\.icims\.com/[^\s"'<>)]+`),
+	},
+	{
+		name:    Jobvite,
+		domains: []string{"jobvite.com"},
+		portal:  regexp.MustCompile(`https://[a-z0-9.-]*jobvite\.com/[^\s"'<>)]+`),
+	},
+}
+
+// Detection is the ATS and candidate-portal link found in an email.
+type Detection struct {
+	ATS       string
+	PortalURL string
+}
+
+// Found reports whether anything was detected.
+func (d Detection) Found() bool {
+	return d.ATS != "" || d.PortalURL != ""
+}
+
+// headersToCheck are the headers that carry the ATS's sending domain.
+var headersToCheck = []string{"From", "Reply-To", "Return-Path", "Sender", "Message-ID", "List-Unsubscribe", "X-Mailer"}
+
+// Detect inspects message headers and body text. Header names are matched
+// case insensitively. Header evidence wins over links in the body, since
+// job-board emails often link to several systems.
+func Detect(headers map[string]string, body string) Detection {
+	canonical := make(map[string]string, len(headers))
+	for k, v := range headers {
+		canonical[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+	var d Detection
+	for _, h := range headersToCheck {
+		if name := matchDomain(canonical[textproto.CanonicalMIMEHeaderKey(h)]); name != "" {
+			d.ATS = name
+			break
+		}
+	}
+
+	for _, sys := range systems {
+		if d.ATS != "" && sys.name != d.ATS {
+			continue
+		}
+		if link := sys.portal.FindString(body); link != "" {
+			d.PortalURL = strings.TrimRight(link, ".,;")
+			if d.ATS == "" {
+				d.ATS = sys.name
+			}
+			break
+		}
+	}
+	return d
+}
+
+func matchDomain(value string) string {
+	value = strings.ToLower(value)
+	if value == "" {
+		return ""
+	}
+	if addr, err := mail.ParseAddress(value); err == nil {
+		value = addr.Address
+	}
+	for _, sys := range systems {
+		for _, domain := range sys.domains {
+			if strings.Contains(value, "@"+domain) || strings.Contains(value, "."+domain) || strings.Contains(value, "/"+domain) {
+				return sys.name
+			}
+		}
+	}
+	return ""
+}
+
+// OwnsDomain reports whether a mail or web domain belongs to a known
+// applicant tracking system, so it says nothing about the employer.
+func OwnsDomain(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, sys := range systems {
+		for _, d := range sys.domains {
+			if domain == d || strings.HasSuffix(domain, "."+d) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FromMessage runs Detect over a Gmail message's headers and text parts.
+func FromMessage(msg *gmail.Message) Detection {
+	headers := make(map[string]string)
+	var body strings.Builder
+	if msg.Payload != nil {
+		for _, h := range msg.Payload.Headers {
+			headers[h.Name] = h.Value
+		}
+		collectText(msg.Payload, &body)
+	}
+	return Detect(headers, body.String())
+}
+
+func collectText(part *gmail.MessagePart, out *strings.Builder) {
+	if strings.HasPrefix(strings.ToLower(part.MimeType), "text/") && part.Body != nil && part.Body.Data != "" {
+		raw, err := base64.URLEncoding.DecodeString(part.Body.Data)
+		if err != nil {
+			// Gmail sometimes omits padding.
+			raw, err = base64.RawURLEncoding.DecodeString(part.Body.Data)
+		}
+		if err == nil {
+			out.Write(raw)
+			out.WriteByte('\n')
+		}
+	}
+	for _, child := range part.Parts {
+		collectText(child, out)
+	}
+}
@@ -0,0 +1,94 @@
+package ats
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		want    Detection
+	}{
+		{
+			name:    "sender domain",
+			headers: map[string]string{"From": "Acme Recruiting <no-reply@greenhouse.io>"},
+			want:    Detection{ATS: Greenhouse},
+		},
+		{
+			name:    "header names in any case",
+			headers: map[string]string{"reply-to": "jobs@hire.lever.co", "MESSAGE-ID": "<x@example.com>"},
+			want:    Detection{ATS: Lever},
+		},
+		{
+			name:    "portal link of the header's system",
+			headers: map[string]string{"Return-Path": "<bounce@acme.myworkday.com>"},
+			body:    "Check https://jobs.lever.co/acme or https://acme.wd5.myworkday.com/acme/d/task/1.htmld.",
+			want:    Detection{ATS: Workday, PortalURL: "https://acme.wd5.myworkday.com/acme/d/task/1.htmld"},
+		},
+		{
+			name: "body link alone",
+			body: "Track your application at https://jobs.ashbyhq.com/acme/123, thanks",
+			want: Detection{ATS: Ashby, PortalURL: "https://jobs.ashbyhq.com/acme/123"},
+		},
+		{
+			name:    "nothing known",
+			headers: map[string]string{"From": "someone@example.com"},
+			body:    "https://example.com/careers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.headers, tt.body); got != tt.want {
+				t.Errorf("Detect = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnsDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   bool
+	}{
+		{"greenhouse.io", true},
+		{"mail.Greenhouse.io.", true},
+		{"notgreenhouse.io", false},
+		{"acme.com", false},
+	}
+	for _, tt := range tests {
+		if got := OwnsDomain(tt.domain); got != tt.want {
+			t.Errorf("OwnsDomain(%q) = %v, want %v", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestFromMessage(t *testing.T) {
+	body := "Your portal: https://acme.icims.com/jobs/1/candidate"
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"padded", base64.URLEncoding.EncodeToString([]byte(body))},
+		{"unpadded", base64.RawURLEncoding.EncodeToString([]byte(body))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &gmail.Message{Payload: &gmail.MessagePart{
+				MimeType: "multipart/alternative",
+				Headers:  []*gmail.MessagePartHeader{{Name: "from", Value: "Acme <careers@example.com>"}},
+				Parts: []*gmail.MessagePart{
+					{MimeType: "Text/Plain", Body: &gmail.MessagePartBody{Data: tt.data}},
+				},
+			}}
+			want := Detection{ATS: ICIMS, PortalURL: "https://acme.icims.com/jobs/1/candidate"}
+			if got := FromMessage(msg); got != want {
+				t.Errorf("FromMessage = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
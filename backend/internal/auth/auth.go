@@ -0,0 +1,51 @@
+// Package auth carries the authenticated user through gin and request contexts.
+package auth
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin context keys the authentication middleware sets.
+const (
+	ginUserIDKey   = "userID"
+	ginAPIKeyIDKey = "apiKeyID"
+)
+
+type contextKey struct{}
+
+// SetUserID records the authenticated user on the gin context and on the
+// request context seen by downstream services and resolvers.
+func SetUserID(c *gin.Context, userID string) {
+	c.Set(ginUserIDKey, userID)
+	c.Request = c.Request.WithContext(WithUserID(c.Request.Context(), userID))
+}
+
+// UserID returns the authenticated user from the gin context, or "" if the
+// request is anonymous.
+func UserID(c *gin.Context) string {
+	return c.GetString(ginUserIDKey)
+}
+
+// SetAPIKeyID records the API key a request authenticated with.
+func SetAPIKeyID(c *gin.Context, keyID string) {
+	c.Set(ginAPIKeyIDKey, keyID)
+}
+
+// APIKeyID returns the ID of the API key the request authenticated with, or
+// "" if it did not use one.
+func APIKeyID(c *gin.Context) string {
+	return c.GetString(ginAPIKeyIDKey)
+}
+
+// WithUserID returns a context carrying the user ID.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserIDFromContext returns the user ID carried by ctx.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
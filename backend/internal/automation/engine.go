@@ -0,0 +1,511 @@
+package automation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/eventlog"
+)
+
+// Run statuses.
+const (
+	RunScheduled = "scheduled"
+	RunDone      = "done"
+	RunSkipped   = "skipped" // the rule was disabled or the application moved on
+	RunFailed    = "failed"
+	RunDryRun    = "dry_run" // recorded by a dry-run rule and never run
+)
+
+const (
+	// emailWindow is how recent an email must be for rules to fire on it;
+	// older ones are left alone, e.g. after a backfill.
+	emailWindow = 7 * 24 * time.Hour
+	// evaluateBatch bounds the emails and the status changes one
+	// evaluation reads; the next run picks up the rest.
+	evaluateBatch = 500
+	// runBatch bounds the due actions one evaluation runs.
+	runBatch = 200
+	// maxMatches caps what Test reports.
+	maxMatches = 100
+	// refPrefix tags application changes made by a rule, whose status
+	// changes do not fire rules again, so rules cannot loop.
+	refPrefix = "rule:"
+)
+
+// Run is an action a rule scheduled, ran or, in dry-run mode, would have run.
+type Run struct {
+	ID            string     `json:"id"`
+	RuleID        string     `json:"ruleId"`
+	ApplicationID string     `json:"applicationId"`
+	Action        *Action    `json:"action"`
+	Trigger       string     `json:"trigger"` // what the rule fired on
+	Status        string     `json:"status"`
+	Detail        *string    `json:"detail"` // why it was skipped or failed
+	DueAt         time.Time  `json:"dueAt"`
+	RanAt         *time.Time `json:"ranAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// PlannedAction is an action Test found a rule would run.
+type PlannedAction struct {
+	Action      *Action   `json:"action"`
+	Description string    `json:"description"` // e.g. "Archive after 7 days"
+	DueAt       time.Time `json:"dueAt"`
+}
+
+// Match is an email or status change a rule would have fired on.
+type Match struct {
+	ApplicationID string           `json:"applicationId"`
+	Company       string           `json:"company"`
+	Position      string           `json:"position"`
+	Trigger       string           `json:"trigger"`
+	OccurredAt    time.Time        `json:"occurredAt"`
+	Actions       []*PlannedAction `json:"actions"`
+}
+
+// event is something a rule can fire on.
+type event struct {
+	ref           string // email:<id> or event:<id>, unique per occurrence
+	kind          string // one of the triggers
+	userID        string
+	applicationID string
+	status        string
+	description   string
+	occurredAt    time.Time
+	// company and position are only read for Test.
+	company, position string
+}
+
+// matches reports whether the rule fires on e.
+func (r *Rule) matches(e *event) bool {
+	return r.Trigger == e.kind && (r.Status == nil || strings.EqualFold(*r.Status, e.status))
+}
+
+func emailDescription(subject, status string) string {
+	if subject == "" {
+		subject = "(no subject)"
+	}
+	return fmt.Sprintf("Email %q classified as %s", subject, status)
+}
+
+func statusDescription(status string) string {
+	return "Status changed to " + status
+}
+
+// describe renders an action for people, e.g. `Create task "Prepare for
+// the onsite"` or "Archive after 7 days".
+func describe(a *Action) string {
+	var text string
+	switch a.Type {
+	case ActionSetStatus:
+		text = "Set status to " + *a.Status
+	case ActionAddTag:
+		text = "Add tag " + *a.Tag
+	case ActionArchive:
+		text = "Archive"
+	case ActionCreateTask:
+		text = fmt.Sprintf("Create task %q", *a.Title)
+	}
+	switch a.DelayDays {
+	case 0:
+		return text
+	case 1:
+		return text + " after 1 day"
+	}
+	return fmt.Sprintf("%s after %d days", text, a.DelayDays)
+}
+
+func delay(a *Action) time.Duration {
+	return time.Duration(a.DelayDays) * 24 * time.Hour
+}
+
+// Evaluate fires rules on emails classified and status changes made since
+// the last run, then runs the actions that have come due. Each email and
+// status change fires a rule at most once. It is intended to run from the
+// scheduler every minute or so.
+func (s *Service) Evaluate(ctx context.Context) error {
+	emails, err := s.classifiedEmails(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.schedule(ctx, emails); err != nil {
+		return err
+	}
+
+	var cursor time.Time
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO automation_cursor (id, last_occurred_at) VALUES (1, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET id = 1
+		RETURNING last_occurred_at`).Scan(&cursor); err != nil {
+		return err
+	}
+	changes, err := s.statusChanges(ctx, cursor)
+	if err != nil {
+		return err
+	}
+	if err := s.schedule(ctx, changes); err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		// Nothing a rule fires on happened; move the cursor to now so the
+		// next scan does not revisit the stream of users without rules.
+		_, err = s.db.ExecContext(ctx, `UPDATE automation_cursor SET last_occurred_at = GREATEST(last_occurred_at, CURRENT_TIMESTAMP - INTERVAL '1 second') WHERE id = 1`)
+	} else {
+		_, err = s.db.ExecContext(ctx, `UPDATE automation_cursor SET last_occurred_at = $1 WHERE id = 1`,
+			changes[len(changes)-1].occurredAt)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.runDue(ctx)
+}
+
+// classifiedEmails claims the recent classified emails not yet looked at,
+// whether or not their user has rules, so a new rule does not fire on mail
+// that arrived before it.
+func (s *Service) classifiedEmails(ctx context.Context) ([]*event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE email_cache SET automation_checked_at = CURRENT_TIMESTAMP
+		WHERE id IN (
+			SELECT id FROM email_cache
+			WHERE automation_checked_at IS NULL AND classified_status IS NOT NULL
+				AND application_id IS NOT NULL AND date > $1
+			ORDER BY date
+			LIMIT $2
+		)
+		RETURNING id, user_id, application_id, classified_status, COALESCE(subject, ''), date`,
+		time.Now().Add(-emailWindow), evaluateBatch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*event
+	for rows.Next() {
+		e := &event{kind: TriggerEmailClassified}
+		var id, subject string
+		if err := rows.Scan(&id, &e.userID, &e.applicationID, &e.status, &subject, &e.occurredAt); err != nil {
+			return nil, err
+		}
+		e.ref = "email:" + id
+		e.description = emailDescription(subject, e.status)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// statusChanges returns the status changes recorded after the cursor for
+// users with status rules, oldest first. Changes made by rules are left
+// out.
+func (s *Service) statusChanges(ctx context.Context, cursor time.Time) ([]*event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ev.id, ev.user_id, ev.application_id, ev.changes->>'status', ev.occurred_at
+		FROM application_event_stream ev
+		WHERE ev.occurred_at > $1 AND ev.changes->>'status' IS NOT NULL
+			AND COALESCE(ev.ref, '') NOT LIKE $3 || '%'
+			AND EXISTS (
+				SELECT 1 FROM automation_rules r
+				WHERE r.user_id = ev.user_id AND r.enabled AND r.trigger = $4)
+		ORDER BY ev.occurred_at
+		LIMIT $2`,
+		cursor, evaluateBatch, refPrefix, TriggerStatusChanged)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*event
+	for rows.Next() {
+		e := &event{kind: TriggerStatusChanged}
+		var id string
+		if err := rows.Scan(&id, &e.userID, &e.applicationID, &e.status, &e.occurredAt); err != nil {
+			return nil, err
+		}
+		e.ref = "event:" + id
+		e.description = statusDescription(e.status)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// schedule records a run for every action of every enabled rule that fires
+// on the events. Runs of dry-run rules are recorded as such and never run.
+func (s *Service) schedule(ctx context.Context, events []*event) error {
+	rules := make(map[string][]*Rule)
+	for _, e := range events {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		userRules, ok := rules[e.userID]
+		if !ok {
+			all, err := s.List(ctx, e.userID)
+			if err != nil {
+				return err
+			}
+			for _, r := range all {
+				if r.Enabled {
+					userRules = append(userRules, r)
+				}
+			}
+			rules[e.userID] = userRules
+		}
+		for _, r := range userRules {
+			if !r.matches(e) {
+				continue
+			}
+			status := RunScheduled
+			if r.DryRun {
+				status = RunDryRun
+			}
+			for i, a := range r.Actions {
+				step, err := json.Marshal(a)
+				if err != nil {
+					return err
+				}
+				// The application's status is kept so a delayed action can
+				// tell whether it has moved on.
+				if _, err := s.db.ExecContext(ctx, `
+					INSERT INTO automation_runs (rule_id, user_id, application_id, action_index, action,
+						trigger_ref, trigger, expect_status, status, due_at)
+					SELECT $1, a.user_id, a.id, $4, $5, $6, $7, a.status, $8, $9
+					FROM applications a WHERE a.id = $3 AND a.user_id = $2
+					ON CONFLICT (rule_id, trigger_ref, action_index) DO NOTHING`,
+					r.ID, e.userID, e.applicationID, i, step, e.ref, e.description, status,
+					e.occurredAt.Add(delay(a))); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type dueRun struct {
+	id, ruleID, userID, applicationID string
+	action                            *Action
+	expectStatus, status              string
+	enabled                           bool
+}
+
+// runDue runs the scheduled actions that have come due, oldest first.
+func (s *Service) runDue(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT x.id, x.rule_id, x.user_id, x.application_id, x.action, x.expect_status, a.status, r.enabled
+		FROM automation_runs x
+		JOIN automation_rules r ON r.id = x.rule_id
+		JOIN applications a ON a.id = x.application_id
+		WHERE x.status = $1 AND x.due_at <= CURRENT_TIMESTAMP
+		ORDER BY x.due_at
+		LIMIT $2`,
+		RunScheduled, runBatch)
+	if err != nil {
+		return err
+	}
+	var due []*dueRun
+	for rows.Next() {
+		d := &dueRun{}
+		var step []byte
+		if err := rows.Scan(&d.id, &d.ruleID, &d.userID, &d.applicationID, &step, &d.expectStatus, &d.status,
+			&d.enabled); err != nil {
+			rows.Close()
+			return err
+		}
+		if err := json.Unmarshal(step, &d.action); err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		status, detail := RunDone, ""
+		switch {
+		case !d.enabled:
+			status, detail = RunSkipped, "rule disabled"
+		case d.action.DelayDays > 0 && d.status != d.expectStatus:
+			status, detail = RunSkipped, "status changed to "+d.status
+		default:
+			if err := s.perform(ctx, d); err != nil {
+				log.Printf("Automation rule %s failed on application %s: %v", d.ruleID, d.applicationID, err)
+				status, detail = RunFailed, err.Error()
+			}
+		}
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE automation_runs SET status = $2, detail = NULLIF($3, ''), ran_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+			d.id, status, detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// perform runs one action. Application changes are tagged with the rule so
+// the event stream says which rule made them.
+func (s *Service) perform(ctx context.Context, d *dueRun) error {
+	a := d.action
+	var query string
+	args := []any{d.applicationID, d.userID}
+	switch a.Type {
+	case ActionCreateTask:
+		return s.actions.AddTask(ctx, d.userID, d.applicationID, *a.Title)
+	case ActionSetStatus:
+		query = `UPDATE applications SET status = $3 WHERE id = $1 AND user_id = $2 AND status <> $3`
+		args = append(args, *a.Status)
+	case ActionAddTag:
+		query = `UPDATE applications SET tags = array_append(tags, $3) WHERE id = $1 AND user_id = $2 AND NOT ($3 = ANY(tags))`
+		args = append(args, *a.Tag)
+	case ActionArchive:
+		query = `UPDATE applications SET archived_at = CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND archived_at IS NULL`
+	default:
+		return fmt.Errorf("unknown action %q", a.Type)
+	}
+	src := eventlog.Source{Type: eventlog.EventEdited, Actor: eventlog.ActorSystem, Ref: refPrefix + d.ruleID}
+	return eventlog.Within(ctx, s.db, src, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+// Runs returns the actions the user's rules scheduled, ran or would have
+// run, newest first, optionally for one rule.
+func (s *Service) Runs(ctx context.Context, userID string, ruleID *string, limit int) ([]*Run, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, rule_id, application_id, action, trigger, status, detail, due_at, ran_at, created_at
+		FROM automation_runs
+		WHERE user_id = $1 AND ($2::uuid IS NULL OR rule_id = $2::uuid)
+		ORDER BY created_at DESC, action_index
+		LIMIT $3`,
+		userID, ruleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []*Run{}
+	for rows.Next() {
+		r := &Run{}
+		var step []byte
+		if err := rows.Scan(&r.ID, &r.RuleID, &r.ApplicationID, &step, &r.Trigger, &r.Status, &r.Detail,
+			&r.DueAt, &r.RanAt, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(step, &r.Action); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Firing is a rule that would fire and the actions it would schedule.
+type Firing struct {
+	RuleID   string           `json:"ruleId"`
+	RuleName string           `json:"ruleName"`
+	DryRun   bool             `json:"dryRun"`
+	Actions  []*PlannedAction `json:"actions"`
+}
+
+// Preview returns the user's enabled rules that would fire on an email
+// classified as status at the given time, and what they would schedule.
+// Nothing is recorded.
+func (s *Service) Preview(ctx context.Context, userID, status string, at time.Time) ([]*Firing, error) {
+	rules, err := s.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	e := &event{kind: TriggerEmailClassified, userID: userID, status: status, occurredAt: at}
+	out := []*Firing{}
+	for _, r := range rules {
+		if !r.Enabled || !r.matches(e) {
+			continue
+		}
+		f := &Firing{RuleID: r.ID, RuleName: r.Name, DryRun: r.DryRun}
+		for _, a := range r.Actions {
+			f.Actions = append(f.Actions, &PlannedAction{Action: a, Description: describe(a), DueAt: at.Add(delay(a))})
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// Test tries a rule against the user's emails and status changes of the
+// last days days (30 by default, at most 90) and returns what it would
+// have done, newest first. Nothing is changed or recorded. Status changes
+// made by rules are left out, as they would not fire it.
+func (s *Service) Test(ctx context.Context, userID string, in RuleInput, days *int) ([]*Match, error) {
+	if err := validate(&in); err != nil {
+		return nil, err
+	}
+	n := 30
+	if days != nil {
+		n = min(max(*days, 1), 90)
+	}
+	rule := &Rule{Trigger: in.Trigger, Status: in.Status, Actions: in.Actions}
+	since := time.Now().AddDate(0, 0, -n)
+
+	var rows *sql.Rows
+	var err error
+	if in.Trigger == TriggerEmailClassified {
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT 'email:' || e.id, e.application_id, e.classified_status, COALESCE(e.subject, ''), e.date,
+				a.company, a.position
+			FROM email_cache e JOIN applications a ON a.id = e.application_id AND a.user_id = e.user_id
+			WHERE e.user_id = $1 AND e.classified_status IS NOT NULL AND e.date >= $2
+			ORDER BY e.date DESC`,
+			userID, since)
+	} else {
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT 'event:' || ev.id, ev.application_id, ev.changes->>'status', '', ev.occurred_at,
+				a.company, a.position
+			FROM application_event_stream ev JOIN applications a ON a.id = ev.application_id
+			WHERE ev.user_id = $1 AND ev.occurred_at >= $2 AND ev.changes->>'status' IS NOT NULL
+				AND COALESCE(ev.ref, '') NOT LIKE $3 || '%'
+			ORDER BY ev.occurred_at DESC`,
+			userID, since, refPrefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*Match{}
+	for rows.Next() && len(out) < maxMatches {
+		e := &event{kind: in.Trigger, userID: userID}
+		var subject string
+		if err := rows.Scan(&e.ref, &e.applicationID, &e.status, &subject, &e.occurredAt, &e.company, &e.position); err != nil {
+			return nil, err
+		}
+		if !rule.matches(e) {
+			continue
+		}
+		m := &Match{
+			ApplicationID: e.applicationID,
+			Company:       e.company,
+			Position:      e.position,
+			Trigger:       statusDescription(e.status),
+			OccurredAt:    e.occurredAt,
+		}
+		if e.kind == TriggerEmailClassified {
+			m.Trigger = emailDescription(subject, e.status)
+		}
+		for _, a := range rule.Actions {
+			m.Actions = append(m.Actions, &PlannedAction{Action: a, Description: describe(a), DueAt: e.occurredAt.Add(delay(a))})
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,234 @@
+// Package automation runs the user's application stage rules, such as "when
+// an email classified as Interview Scheduled arrives, create a prep task" or
+// "after a rejection, archive the application in 7 days". Rules fire on
+// classified emails and status changes and their actions run now or after a
+// delay; a rule can be tried against recent history, or left in dry-run
+// mode, to see what it would do without doing it.
+package automation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/actions"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Triggers a rule fires on.
+const (
+	// TriggerEmailClassified fires when an email about an application is
+	// classified with a status.
+	TriggerEmailClassified = "email_classified"
+	// TriggerStatusChanged fires when an application enters a status,
+	// including when it is created in it.
+	TriggerStatusChanged = "status_changed"
+)
+
+// Action types.
+const (
+	ActionSetStatus  = "set_status"
+	ActionAddTag     = "add_tag"
+	ActionArchive    = "archive"
+	ActionCreateTask = "create_task"
+)
+
+// maxRules caps the rules per user.
+const maxRules = 50
+
+var (
+	// ErrNotFound is returned when a rule does not exist or belongs to
+	// another user.
+	ErrNotFound = apperr.New(apperr.NotFound, "automation rule not found")
+	// ErrTooManyRules is returned when the user already has maxRules rules.
+	ErrTooManyRules = apperr.New(apperr.Conflict, "too many automation rules; delete one first")
+)
+
+// Action is one step of a rule.
+type Action struct {
+	Type string `json:"type" validate:"required,oneof=set_status add_tag archive create_task"`
+	// Status is set for set_status, Tag for add_tag and Title for
+	// create_task.
+	Status *string `json:"status,omitempty" validate:"omitempty,max=50"`
+	Tag    *string `json:"tag,omitempty" validate:"omitempty,max=50"`
+	Title  *string `json:"title,omitempty" validate:"omitempty,max=255"`
+	// DelayDays postpones the action; a delayed action is skipped if the
+	// application's status has changed by the time it is due.
+	DelayDays int `json:"delayDays" validate:"min=0,max=365"`
+}
+
+// Rule is one of the user's automation rules.
+type Rule struct {
+	ID      string `json:"id"`
+	UserID  string `json:"-"`
+	Name    string `json:"name"`
+	Trigger string `json:"trigger"`
+	// Status is the classified or new status the rule fires on; nil fires
+	// on any.
+	Status  *string   `json:"status"`
+	Actions []*Action `json:"actions"`
+	Enabled bool      `json:"enabled"`
+	// DryRun rules record what they would have done without doing it.
+	DryRun    bool      `json:"dryRun"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// RuleInput creates or replaces a rule.
+type RuleInput struct {
+	Name    string    `json:"name" validate:"required,max=100"`
+	Trigger string    `json:"trigger" validate:"required,oneof=email_classified status_changed"`
+	Status  *string   `json:"status" validate:"omitempty,max=50"`
+	Actions []*Action `json:"actions" validate:"required,min=1,max=5,dive,required"`
+	// Enabled defaults to true.
+	Enabled *bool `json:"enabled"`
+	DryRun  bool  `json:"dryRun"`
+}
+
+// Service manages and runs automation rules.
+type Service struct {
+	db      *sql.DB
+	actions *actions.Service
+}
+
+// NewService creates an automation service. Tasks created by rules are
+// recorded through the action service.
+func NewService(db *sql.DB, actionService *actions.Service) *Service {
+	return &Service{db: db, actions: actionService}
+}
+
+const ruleColumns = `id, user_id, name, trigger, status, actions, enabled, dry_run, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRule(row scanner) (*Rule, error) {
+	r := &Rule{}
+	var steps []byte
+	err := row.Scan(&r.ID, &r.UserID, &r.Name, &r.Trigger, &r.Status, &steps, &r.Enabled, &r.DryRun,
+		&r.CreatedAt, &r.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(steps, &r.Actions); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// validate checks the input and normalizes it in place.
+func validate(in *RuleInput) error {
+	if err := validation.Struct(in); err != nil {
+		return err
+	}
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Status != nil {
+		if status := strings.TrimSpace(*in.Status); status == "" {
+			in.Status = nil
+		} else {
+			in.Status = &status
+		}
+	}
+	for _, a := range in.Actions {
+		param, field := a.Status, "status"
+		switch a.Type {
+		case ActionAddTag:
+			param, field = a.Tag, "tag"
+		case ActionCreateTask:
+			param, field = a.Title, "title"
+		case ActionArchive:
+			continue
+		}
+		if param == nil || strings.TrimSpace(*param) == "" {
+			return validation.Field(field, "is required for "+a.Type+" actions")
+		}
+		*param = strings.TrimSpace(*param)
+	}
+	return nil
+}
+
+// List returns the user's rules, oldest first.
+func (s *Service) List(ctx context.Context, userID string) ([]*Rule, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+ruleColumns+` FROM automation_rules WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []*Rule{}
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Get returns one of the user's rules.
+func (s *Service) Get(ctx context.Context, userID, id string) (*Rule, error) {
+	return scanRule(s.db.QueryRowContext(ctx,
+		`SELECT `+ruleColumns+` FROM automation_rules WHERE id = $1 AND user_id = $2`, id, userID))
+}
+
+// Create adds a rule. It only fires on emails and status changes from
+// then on; Test shows what it would have done before.
+func (s *Service) Create(ctx context.Context, userID string, in RuleInput) (*Rule, error) {
+	if err := validate(&in); err != nil {
+		return nil, err
+	}
+	steps, err := json.Marshal(in.Actions)
+	if err != nil {
+		return nil, err
+	}
+	enabled := in.Enabled == nil || *in.Enabled
+	r, err := scanRule(s.db.QueryRowContext(ctx, `
+		INSERT INTO automation_rules (user_id, name, trigger, status, actions, enabled, dry_run)
+		SELECT $1, $2, $3, $4, $5, $6, $7
+		WHERE (SELECT COUNT(*) FROM automation_rules WHERE user_id = $1) < $8
+		RETURNING `+ruleColumns,
+		userID, in.Name, in.Trigger, in.Status, steps, enabled, in.DryRun, maxRules))
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrTooManyRules
+	}
+	return r, err
+}
+
+// Update replaces a rule. Actions it already scheduled still run, unless
+// the rule is disabled before they are due.
+func (s *Service) Update(ctx context.Context, userID, id string, in RuleInput) (*Rule, error) {
+	if err := validate(&in); err != nil {
+		return nil, err
+	}
+	steps, err := json.Marshal(in.Actions)
+	if err != nil {
+		return nil, err
+	}
+	return scanRule(s.db.QueryRowContext(ctx, `
+		UPDATE automation_rules SET name = $3, trigger = $4, status = $5, actions = $6,
+			enabled = COALESCE($7, enabled), dry_run = $8
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+ruleColumns,
+		id, userID, in.Name, in.Trigger, in.Status, steps, in.Enabled, in.DryRun))
+}
+
+// Delete removes a rule and cancels the actions it scheduled.
+func (s *Service) Delete(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM automation_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package avscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// API scans files by posting them to a configurable HTTP endpoint. The
+// endpoint receives the file as the request body, with its name in the
+// X-Filename header, and answers with JSON of the form
+//
+//	{"infected": true, "signature": "Eicar-Test-Signature"}
+type API struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+// NewAPI creates an API scanner for the given endpoint. The key, when set,
+// is sent as a bearer token.
+func NewAPI(endpoint, apiKey string, timeout time.Duration) *API {
+	return &API{endpoint: endpoint, apiKey: apiKey, client: &http.Client{Timeout: timeout}}
+}
+
+func (a *API) Name() string { return "api" }
+
+type apiResponse struct {
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature"`
+}
+
+func (a *API) Scan(ctx context.Context, filename string, data []byte) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Filename", filename)
+	if a.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scan API returned status %d", resp.StatusCode)
+	}
+
+	var body apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode scan API response: %w", err)
+	}
+	return &Result{Infected: body.Infected, Signature: body.Signature}, nil
+}
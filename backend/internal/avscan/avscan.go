@@ -0,0 +1,49 @@
+// Package avscan checks files for malware before they are stored or served,
+// through a pluggable Scanner: a ClamAV daemon or an external HTTP API.
+package avscan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/config"
+)
+
+// Scan statuses of a stored file.
+const (
+	StatusClean     = "clean"
+	StatusInfected  = "infected"  // the file is quarantined and not served
+	StatusUnscanned = "unscanned" // no scanner configured, or it failed with ATTACHMENT_SCAN_FAIL_OPEN
+)
+
+// Result is the verdict on one file.
+type Result struct {
+	Infected  bool
+	Signature string // what was found, e.g. "Eicar-Test-Signature"
+}
+
+// Scanner checks a file for malware. An error means no verdict was reached.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, filename string, data []byte) (*Result, error)
+}
+
+// FromConfig returns the scanner selected by ATTACHMENT_SCANNER ("clamav"
+// or "api"), or nil when scanning is off.
+func FromConfig(cfg *config.Config) (Scanner, error) {
+	timeout := time.Duration(cfg.AttachmentScanTimeoutSeconds) * time.Second
+	switch strings.ToLower(cfg.AttachmentScanner) {
+	case "", "off":
+		return nil, nil
+	case "clamav":
+		return NewClamAV(cfg.ClamAVAddress, timeout), nil
+	case "api":
+		if cfg.AttachmentScanURL == "" {
+			return nil, fmt.Errorf("ATTACHMENT_SCAN_URL is required with ATTACHMENT_SCANNER=api")
+		}
+		return NewAPI(cfg.AttachmentScanURL, cfg.AttachmentScanAPIKey, timeout), nil
+	}
+	return nil, fmt.Errorf("unknown ATTACHMENT_SCANNER %q, want clamav or api", cfg.AttachmentScanner)
+}
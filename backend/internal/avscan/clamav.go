@@ -0,0 +1,78 @@
+package avscan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of the INSTREAM chunks sent to clamd.
+const chunkSize = 64 << 10
+
+// ClamAV scans files with a clamd daemon over its INSTREAM command. Files
+// larger than clamd's StreamMaxLength (25 MB by default) fail to scan.
+type ClamAV struct {
+	address string // host:port, or the path of a Unix socket
+	timeout time.Duration
+}
+
+// NewClamAV creates a scanner for the clamd daemon at address.
+func NewClamAV(address string, timeout time.Duration) *ClamAV {
+	return &ClamAV{address: address, timeout: timeout}
+}
+
+func (c *ClamAV) Name() string { return "clamav" }
+
+func (c *ClamAV) Scan(ctx context.Context, filename string, data []byte) (*Result, error) {
+	network := "tcp"
+	if strings.HasPrefix(c.address, "/") {
+		network = "unix"
+	}
+	d := net.Dialer{Timeout: c.timeout}
+	conn, err := d.DialContext(ctx, network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	w := bufio.NewWriter(conn)
+	w.WriteString("zINSTREAM\x00")
+	size := make([]byte, 4)
+	for len(data) > 0 {
+		n := min(len(data), chunkSize)
+		binary.BigEndian.PutUint32(size, uint32(n))
+		w.Write(size)
+		w.Write(data[:n])
+		data = data[n:]
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	w.Write(size)
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("send %s to clamd: %w", filename, err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && len(reply) == 0 {
+		return nil, fmt.Errorf("read clamd reply: %w", err)
+	}
+	// Replies are "stream: OK", "stream: <signature> FOUND" or "<reason> ERROR".
+	line := strings.TrimSpace(string(bytes.TrimRight(reply, "\x00")))
+	line = strings.TrimPrefix(line, "stream: ")
+	switch {
+	case line == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(line, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(line, " FOUND")}, nil
+	}
+	return nil, fmt.Errorf("clamd could not scan %s: %s", filename, line)
+}
@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// Backups are encrypted with AES-256-GCM in independently authenticated
+// chunks so they can be streamed. The file starts with a magic string, a
+// format version and a random nonce prefix; each chunk is a big-endian
+// length followed by the ciphertext. A chunk's nonce is the prefix, the
+// chunk counter and a flag marking the final chunk, so truncated or
+// reordered files fail to decrypt.
+const (
+	magic       = "JTBK"
+	formatV1    = 1
+	chunkSize   = 64 << 10
+	prefixSize  = 7
+	headerSize  = len(magic) + 1 + prefixSize
+	maxCipherSz = chunkSize + 16
+)
+
+var (
+	// ErrInvalidKey is returned when BACKUP_ENCRYPTION_KEY is not 32 bytes
+	// of base64.
+	ErrInvalidKey = apperr.New(apperr.Validation, "backup encryption key must be 32 bytes, base64 encoded")
+	// ErrCorrupt is returned when a backup fails authentication.
+	ErrCorrupt = apperr.New(apperr.Validation, "backup is corrupt, truncated or encrypted with another key")
+)
+
+// ParseKey decodes a base64 AES-256 key.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[prefixSize:], counter)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+type encrypter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+}
+
+// NewEncrypter returns a writer that encrypts to w. Close must be called to
+// write the final chunk; it does not close w.
+func NewEncrypter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	header := append([]byte(magic), formatV1)
+	if _, err := w.Write(append(header, prefix...)); err != nil {
+		return nil, err
+	}
+	return &encrypter{w: w, aead: aead, prefix: prefix, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (e *encrypter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		take := chunkSize - len(e.buf)
+		if take > len(p) {
+			take = len(p)
+		}
+		e.buf = append(e.buf, p[:take]...)
+		p, n = p[take:], n+take
+		// Keep a full buffer until more data arrives so the last chunk is
+		// always written by Close with the final flag.
+		if len(e.buf) == chunkSize && len(p) > 0 {
+			if err := e.flush(false); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (e *encrypter) flush(final bool) error {
+	sealed := e.aead.Seal(nil, chunkNonce(e.prefix, e.counter, final), e.buf, nil)
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(sealed)))
+	if _, err := e.w.Write(length[:]); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+func (e *encrypter) Close() error {
+	return e.flush(true)
+}
+
+type decrypter struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	plain   []byte
+	done    bool
+}
+
+// NewDecrypter returns a reader that decrypts a backup read from r.
+func NewDecrypter(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrCorrupt
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, fmt.Errorf("%w: not a backup file", ErrCorrupt)
+	}
+	if header[len(magic)] != formatV1 {
+		return nil, fmt.Errorf("unsupported backup format version %d", header[len(magic)])
+	}
+	return &decrypter{r: r, aead: aead, prefix: header[len(magic)+1:]}, nil
+}
+
+func (d *decrypter) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decrypter) next() error {
+	var length [4]byte
+	if _, err := io.ReadFull(d.r, length[:]); err != nil {
+		return ErrCorrupt
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > maxCipherSz {
+		return ErrCorrupt
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return ErrCorrupt
+	}
+
+	// Try the chunk as a middle chunk first, then as the final one.
+	plain, err := d.aead.Open(nil, chunkNonce(d.prefix, d.counter, false), sealed, nil)
+	if err != nil {
+		plain, err = d.aead.Open(nil, chunkNonce(d.prefix, d.counter, true), sealed, nil)
+		if err != nil {
+			return ErrCorrupt
+		}
+		d.done = true
+	}
+	d.counter++
+	d.plain = plain
+	return nil
+}
@@ -0,0 +1,209 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// table is a table included in backups. Tables are listed parents first so
+// a restore satisfies foreign keys in order.
+type table struct {
+	name string
+	// userFilter selects one user's rows with $1 as the user ID; empty
+	// means the table is only included in instance backups.
+	userFilter string
+}
+
+var tables = []table{
+	{"users", "id = $1"},
+	{"api_keys", "user_id = $1"},
+	{"share_links", "user_id = $1"},
+	{"resumes", "user_id = $1"},
+	{"application_templates", "user_id = $1"},
+	{"applications", "user_id = $1"},
+	{"processing_jobs", "user_id = $1"},
+	{"email_cache", "user_id = $1"},
+	{"interviews", "user_id = $1"},
+	{"interview_loops", "user_id = $1"},
+	{"interview_rounds", "loop_id IN (SELECT id FROM interview_loops WHERE user_id = $1)"},
+	{"interview_questions", "user_id = $1"},
+	{"application_actions", "user_id = $1"},
+	{"application_events", "user_id = $1"},
+	{"application_status_history", "user_id = $1"},
+	{"application_event_stream", "user_id = $1"},
+	{"application_salary_estimates", "application_id IN (SELECT id FROM applications WHERE user_id = $1)"},
+	{"application_offers", "application_id IN (SELECT id FROM applications WHERE user_id = $1)"},
+	{"application_referrals", "application_id IN (SELECT id FROM applications WHERE user_id = $1)"},
+	{"application_summaries", "application_id IN (SELECT id FROM applications WHERE user_id = $1)"},
+	{"rest_hook_subscriptions", "user_id = $1"},
+	{"rest_hook_cursor", ""},
+	{"automation_rules", "user_id = $1"},
+	{"automation_runs", "user_id = $1"},
+	{"automation_cursor", ""},
+	{"goals", "user_id = $1"},
+	{"notifications", "user_id = $1"},
+	{"company_watches", "user_id = $1"},
+	{"company_watch_roles", "watch_id IN (SELECT id FROM company_watches WHERE user_id = $1)"},
+	{"company_notes", "user_id = $1"},
+	{"retention_overrides", "user_id = $1"},
+	{"llm_usage", "user_id = $1"},
+	{"quota_warnings", "user_id = $1"},
+	{"board_snapshots", "user_id = $1"},
+	{"outreach", "user_id = $1"},
+	{"workspaces", ""},
+	{"workspace_members", ""},
+	{"classification_experiments", ""},
+	{"classification_trials", ""},
+}
+
+// triggerTables are filled by triggers on applications.
+var triggerTables = map[string]bool{
+	"application_status_history": true,
+	"application_event_stream":   true,
+}
+
+// header is the first line of a dump.
+type header struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	UserID    *string   `json:"userId,omitempty"` // nil for instance backups
+}
+
+// record is one row of a dump.
+type record struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// dump writes the instance, or one user's data, as JSON lines: a header
+// followed by one record per row.
+func dump(ctx context.Context, db *sql.DB, w io.Writer, userID *string) (rows int, err error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(header{Version: 1, CreatedAt: time.Now().UTC(), UserID: userID}); err != nil {
+		return 0, err
+	}
+
+	for _, t := range tables {
+		query := `SELECT row_to_json(t) FROM ` + t.name + ` t`
+		var args []interface{}
+		if userID != nil {
+			if t.userFilter == "" {
+				continue
+			}
+			query += ` WHERE ` + t.userFilter
+			args = append(args, *userID)
+		}
+
+		result, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return rows, fmt.Errorf("dump %s: %w", t.name, err)
+		}
+		for result.Next() {
+			var row json.RawMessage
+			if err := result.Scan(&row); err != nil {
+				result.Close()
+				return rows, err
+			}
+			if err := enc.Encode(record{Table: t.name, Row: row}); err != nil {
+				result.Close()
+				return rows, err
+			}
+			rows++
+		}
+		result.Close()
+		if err := result.Err(); err != nil {
+			return rows, err
+		}
+	}
+	return rows, nil
+}
+
+// restore inserts the rows of a dump that are missing from the database.
+// Existing rows are left untouched, so restoring is safe to repeat and never
+// overwrites newer data.
+func restore(ctx context.Context, db *sql.DB, r io.Reader) (restored int, err error) {
+	known := map[string]bool{}
+	for _, t := range tables {
+		known[t.name] = true
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	if !scanner.Scan() {
+		return 0, fmt.Errorf("%w: empty dump", ErrCorrupt)
+	}
+	var h header
+	if err := json.Unmarshal(scanner.Bytes(), &h); err != nil || h.Version != 1 {
+		return 0, fmt.Errorf("%w: unrecognized dump header", ErrCorrupt)
+	}
+
+	// The status and event triggers record rows for every restored
+	// application; those are replaced by the rows in the dump.
+	var newApplications []string
+	cleared := map[string]bool{}
+
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return restored, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if !known[rec.Table] {
+			return restored, fmt.Errorf("%w: unknown table %q", ErrCorrupt, rec.Table)
+		}
+
+		if triggerTables[rec.Table] && !cleared[rec.Table] {
+			if _, err := tx.ExecContext(ctx,
+				`DELETE FROM `+rec.Table+` WHERE application_id::text = ANY($1)`,
+				pq.Array(newApplications)); err != nil {
+				return restored, err
+			}
+			cleared[rec.Table] = true
+		}
+
+		insert := `INSERT INTO ` + rec.Table + ` SELECT * FROM json_populate_record(NULL::` + rec.Table + `, $1)
+			ON CONFLICT DO NOTHING`
+		if rec.Table == "applications" {
+			var id string
+			err := tx.QueryRowContext(ctx, insert+` RETURNING id::text`, []byte(rec.Row)).Scan(&id)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return restored, fmt.Errorf("restore %s: %w", rec.Table, err)
+			}
+			newApplications = append(newApplications, id)
+			restored++
+			continue
+		}
+
+		res, err := tx.ExecContext(ctx, insert, []byte(rec.Row))
+		if err != nil {
+			return restored, fmt.Errorf("restore %s: %w", rec.Table, err)
+		}
+		n, _ := res.RowsAffected()
+		restored += int(n)
+	}
+	if err := scanner.Err(); err != nil {
+		return restored, err
+	}
+	return restored, tx.Commit()
+}
@@ -0,0 +1,296 @@
+// Package backup takes encrypted snapshots of the database, either of the
+// whole instance or of each user separately, and stores them in
+// S3-compatible object storage. Uploaded resume files live outside the
+// database and are not included.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/s3"
+)
+
+var (
+	// ErrNotConfigured is returned when no bucket or encryption key is set.
+	ErrNotConfigured = apperr.New(apperr.Unavailable, "backups need BACKUP_S3_BUCKET and BACKUP_ENCRYPTION_KEY")
+	// ErrInvalidScope is returned for a BACKUP_SCOPE other than instance or users.
+	ErrInvalidScope = apperr.New(apperr.Validation, `backup scope must be "instance" or "users"`)
+)
+
+// timeLayout names backup objects so they sort chronologically.
+const timeLayout = "20060102T150405Z"
+
+const suffix = ".jsonl.gz.enc"
+
+// Service creates, lists, prunes and restores backups.
+type Service struct {
+	cfg   *config.Config
+	db    *sql.DB
+	store *s3.Client
+	key   []byte
+	err   error // why backups are unavailable, if they are
+}
+
+// NewService creates a backup service from the BACKUP_* settings. An
+// incomplete configuration is reported when a backup is attempted.
+func NewService(cfg *config.Config, db *sql.DB) *Service {
+	s := &Service{cfg: cfg, db: db}
+	if cfg.BackupS3Bucket == "" || cfg.BackupEncryptionKey == "" {
+		s.err = ErrNotConfigured
+		return s
+	}
+	if s.key, s.err = ParseKey(cfg.BackupEncryptionKey); s.err != nil {
+		return s
+	}
+	s.store, s.err = s3.NewClient(s3.Config{
+		Endpoint:        cfg.BackupS3Endpoint,
+		Region:          cfg.BackupS3Region,
+		Bucket:          cfg.BackupS3Bucket,
+		AccessKeyID:     cfg.BackupS3AccessKeyID,
+		SecretAccessKey: cfg.BackupS3SecretAccessKey,
+	}, 10*time.Minute)
+	return s
+}
+
+// Backup is a stored backup object.
+type Backup struct {
+	Key       string    `json:"key"`
+	UserID    *string   `json:"userId,omitempty"` // nil for instance backups
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+	Rows      int       `json:"rows,omitempty"` // only known right after Create
+}
+
+// root is the key prefix of all backups.
+func (s *Service) root() string {
+	if p := strings.Trim(s.cfg.BackupS3Prefix, "/"); p != "" {
+		return p + "/"
+	}
+	return ""
+}
+
+// dir is the key prefix for the instance backups, or one user's.
+func (s *Service) dir(userID *string) string {
+	if userID == nil {
+		return s.root() + "instance/"
+	}
+	return s.root() + path.Join("users", *userID) + "/"
+}
+
+// Create dumps the instance, or one user's data when userID is set,
+// compresses and encrypts it, and uploads it.
+func (s *Service) Create(ctx context.Context, userID *string) (*Backup, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	tmp, err := os.CreateTemp("", "jobtracker-backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	enc, err := NewEncrypter(tmp, s.key)
+	if err != nil {
+		return nil, err
+	}
+	zw := gzip.NewWriter(enc)
+	now := time.Now().UTC()
+	rows, err := dump(ctx, s.db, zw, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	key := s.dir(userID) + now.Format(timeLayout) + suffix
+	if err := s.store.Put(ctx, key, tmp, size, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("upload %s: %w", key, err)
+	}
+	return &Backup{Key: key, UserID: userID, Size: size, CreatedAt: now, Rows: rows}, nil
+}
+
+// List returns stored backups, newest first: one user's when userID is
+// set, otherwise all of them.
+func (s *Service) List(ctx context.Context, userID *string) ([]*Backup, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	prefix := s.root()
+	if userID != nil {
+		prefix = s.dir(userID)
+	}
+
+	objects, err := s.store.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var backups []*Backup
+	for _, obj := range objects {
+		if b := s.parseKey(obj.Key); b != nil {
+			b.Size = obj.Size
+			backups = append(backups, b)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// parseKey recognizes backup object keys, returning nil for anything else
+// stored under the prefix.
+func (s *Service) parseKey(key string) *Backup {
+	rel := strings.TrimPrefix(key, s.root())
+	parts := strings.Split(rel, "/")
+	name := parts[len(parts)-1]
+	if !strings.HasSuffix(name, suffix) {
+		return nil
+	}
+	createdAt, err := time.Parse(timeLayout, strings.TrimSuffix(name, suffix))
+	if err != nil {
+		return nil
+	}
+
+	b := &Backup{Key: key, CreatedAt: createdAt}
+	switch {
+	case len(parts) == 2 && parts[0] == "instance":
+	case len(parts) == 3 && parts[0] == "users":
+		b.UserID = &parts[1]
+	default:
+		return nil
+	}
+	return b
+}
+
+// Restore downloads and decrypts a backup and inserts the rows missing from
+// the database. Rows that still exist are left as they are, so a restore
+// brings back deleted data without rolling back later changes.
+func (s *Service) Restore(ctx context.Context, key string) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	body, err := s.store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	dec, err := NewDecrypter(body, s.key)
+	if err != nil {
+		return 0, err
+	}
+	zr, err := gzip.NewReader(dec)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	defer zr.Close()
+	return restore(ctx, s.db, zr)
+}
+
+// Run is the scheduled backup: it backs up the instance, or every user
+// separately, then prunes old backups.
+func (s *Service) Run(ctx context.Context) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	switch s.cfg.BackupScope {
+	case "instance":
+		b, err := s.Create(ctx, nil)
+		if err != nil {
+			return err
+		}
+		log.Printf("Backup %s: %d rows, %d bytes", b.Key, b.Rows, b.Size)
+	case "users":
+		userIDs, err := s.userIDs(ctx)
+		if err != nil {
+			return err
+		}
+		failed := 0
+		for _, id := range userIDs {
+			id := id
+			if _, err := s.Create(ctx, &id); err != nil {
+				log.Printf("Backup of user %s failed: %v", id, err)
+				failed++
+			}
+		}
+		log.Printf("Backed up %d users", len(userIDs)-failed)
+		if failed > 0 {
+			return fmt.Errorf("%d of %d user backups failed", failed, len(userIDs))
+		}
+	default:
+		return ErrInvalidScope
+	}
+
+	return s.Prune(ctx)
+}
+
+func (s *Service) userIDs(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Prune deletes all but the newest BACKUP_KEEP backups of the instance and
+// of each user. A BACKUP_KEEP of zero keeps everything.
+func (s *Service) Prune(ctx context.Context) error {
+	if s.cfg.BackupKeep <= 0 {
+		return nil
+	}
+	backups, err := s.List(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	kept := map[string]int{}
+	for _, b := range backups {
+		group := ""
+		if b.UserID != nil {
+			group = *b.UserID
+		}
+		if kept[group] < s.cfg.BackupKeep {
+			kept[group]++
+			continue
+		}
+		if err := s.store.Delete(ctx, b.Key); err != nil {
+			return fmt.Errorf("delete %s: %w", b.Key, err)
+		}
+	}
+	return nil
+}
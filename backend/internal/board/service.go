@@ -0,0 +1,263 @@
+// Package board keeps a daily snapshot of each user's application board and
+// compares snapshots, answering "what happened this week?" for the board
+// view and the weekly digest.
+package board
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/i18n"
+	"github.com/jobtracker/backend/internal/profile"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+const dateLayout = "2006-01-02"
+
+// snapshotRetention is how long snapshots are kept.
+const snapshotRetention = 400 * 24 * time.Hour
+
+// maxDiffDays caps the range of a diff.
+const maxDiffDays = 366
+
+// ErrInvalidRange is returned when from is after to or the range is longer
+// than a year.
+var ErrInvalidRange = apperr.New(apperr.Validation, "from must be on or before to, at most a year apart")
+
+// Card is an application as it stood on the board.
+type Card struct {
+	ID       string `json:"id"`
+	Company  string `json:"company"`
+	Position string `json:"position"`
+	Status   string `json:"status"`
+}
+
+// Move is an application that changed column.
+type Move struct {
+	Card       *Card  `json:"card"`
+	FromStatus string `json:"fromStatus"`
+}
+
+// StatusCount is the size of a board column at both ends of a diff.
+type StatusCount struct {
+	Status string `json:"status"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+}
+
+// Diff is what changed on the board between two dates.
+type Diff struct {
+	// From and To are the dates compared. From is the date of the snapshot
+	// used, which is the earliest one when none is that old; To is today
+	// when the live board was used.
+	From    string         `json:"from"`
+	To      string         `json:"to"`
+	Added   []*Card        `json:"added"`
+	Removed []*Card        `json:"removed"` // deleted applications
+	Moved   []*Move        `json:"moved"`
+	Counts  []*StatusCount `json:"counts"`
+}
+
+// Service takes and compares board snapshots.
+type Service struct {
+	db       *sql.DB
+	profiles *profile.Service
+}
+
+// NewService creates a board snapshot service.
+func NewService(db *sql.DB, profiles *profile.Service) *Service {
+	return &Service{db: db, profiles: profiles}
+}
+
+// liveBoard selects the user's board ($1) as a JSON array of cards.
+const liveBoard = `
+	SELECT COALESCE(jsonb_agg(jsonb_build_object(
+		'id', id, 'company', company, 'position', position, 'status', status) ORDER BY created_at), '[]')
+	FROM applications WHERE user_id = $1`
+
+// Snapshot records today's board, in each user's timezone, for every user
+// who has not been snapshotted today, and prunes old snapshots. It is
+// intended to run hourly from the scheduler.
+func (s *Service) Snapshot(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, COALESCE(u.timezone, $1), (SELECT MAX(snapshot_date) FROM board_snapshots b WHERE b.user_id = u.id)
+		FROM users u
+		WHERE EXISTS (SELECT 1 FROM applications a WHERE a.user_id = u.id)`, profile.DefaultTimezone)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	due := make(map[string]string)
+	for rows.Next() {
+		var id, tz string
+		var last sql.NullTime
+		if err := rows.Scan(&id, &tz, &last); err != nil {
+			rows.Close()
+			return err
+		}
+		p := profile.Profile{Timezone: tz}
+		today := now.In(p.Location()).Format(dateLayout)
+		if !last.Valid || last.Time.Format(dateLayout) < today {
+			due[id] = today
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for userID, today := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.db.ExecContext(ctx, `
+			INSERT INTO board_snapshots (user_id, snapshot_date, cards)
+			SELECT $1, $2, (`+liveBoard+`)
+			ON CONFLICT (user_id, snapshot_date) DO NOTHING`, userID, today); err != nil {
+			log.Printf("Failed to snapshot board of user %s: %v", userID, err)
+		}
+	}
+	_, err = s.db.ExecContext(ctx, `DELETE FROM board_snapshots WHERE created_at < $1`, now.Add(-snapshotRetention))
+	return err
+}
+
+// Diff compares the user's board on two dates (YYYY-MM-DD). Each date uses
+// the latest snapshot taken on or before it; to uses the live board when it
+// is today or later.
+func (s *Service) Diff(ctx context.Context, userID, from, to string) (*Diff, error) {
+	start, err := time.Parse(dateLayout, from)
+	if err != nil {
+		return nil, validation.Field("from", "must be a date (YYYY-MM-DD)")
+	}
+	end, err := time.Parse(dateLayout, to)
+	if err != nil {
+		return nil, validation.Field("to", "must be a date (YYYY-MM-DD)")
+	}
+	if start.After(end) || end.Sub(start) > maxDiffDays*24*time.Hour {
+		return nil, ErrInvalidRange
+	}
+	loc, err := s.profiles.Location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().In(loc).Format(dateLayout)
+
+	d := &Diff{Added: []*Card{}, Removed: []*Card{}, Moved: []*Move{}, Counts: []*StatusCount{}}
+	before, fromDate, err := s.board(ctx, userID, from, today)
+	if err != nil {
+		return nil, err
+	}
+	after, toDate, err := s.board(ctx, userID, to, today)
+	if err != nil {
+		return nil, err
+	}
+	// With only snapshots newer than to, both ends are the same snapshot.
+	if fromDate > toDate {
+		before, fromDate = after, toDate
+	}
+	d.From, d.To = fromDate, toDate
+
+	old := make(map[string]*Card, len(before))
+	counts := make(map[string]*StatusCount)
+	count := func(status string) *StatusCount {
+		if counts[status] == nil {
+			counts[status] = &StatusCount{Status: status}
+		}
+		return counts[status]
+	}
+	for _, c := range before {
+		old[c.ID] = c
+		count(c.Status).From++
+	}
+	for _, c := range after {
+		count(c.Status).To++
+		prev, ok := old[c.ID]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, c)
+		case prev.Status != c.Status:
+			d.Moved = append(d.Moved, &Move{Card: c, FromStatus: prev.Status})
+		}
+		delete(old, c.ID)
+	}
+	for _, c := range before {
+		if _, ok := old[c.ID]; ok {
+			d.Removed = append(d.Removed, c)
+		}
+	}
+	for _, c := range counts {
+		d.Counts = append(d.Counts, c)
+	}
+	sort.Slice(d.Counts, func(i, j int) bool { return d.Counts[i].Status < d.Counts[j].Status })
+	return d, nil
+}
+
+// board returns the user's board on a date and the date it is from: the
+// live board when date is today or later, otherwise the latest snapshot on
+// or before date, or the earliest snapshot when there is none that old.
+// Users without snapshots get the live board.
+func (s *Service) board(ctx context.Context, userID, date, today string) ([]*Card, string, error) {
+	var raw []byte
+	if date < today {
+		var taken time.Time
+		err := s.db.QueryRowContext(ctx, `
+			SELECT cards, snapshot_date FROM board_snapshots
+			WHERE user_id = $1
+			ORDER BY snapshot_date <= $2::date DESC,
+				CASE WHEN snapshot_date <= $2::date THEN snapshot_date END DESC, snapshot_date
+			LIMIT 1`, userID, date).Scan(&raw, &taken)
+		if err == nil {
+			cards, err := decode(raw)
+			return cards, taken.Format(dateLayout), err
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, "", err
+		}
+	}
+	if err := s.db.QueryRowContext(ctx, liveBoard, userID).Scan(&raw); err != nil {
+		return nil, "", err
+	}
+	cards, err := decode(raw)
+	return cards, today, err
+}
+
+func decode(raw []byte) ([]*Card, error) {
+	var cards []*Card
+	if err := json.Unmarshal(raw, &cards); err != nil {
+		return nil, fmt.Errorf("decode board: %w", err)
+	}
+	return cards, nil
+}
+
+// Summary describes a diff in a few lines for the weekly digest, in the
+// localizer's language, or "" when nothing changed.
+func Summary(l *i18n.Localizer, d *Diff) string {
+	var lines []string
+	if n := len(d.Added); n > 0 {
+		lines = append(lines, l.N("board.added", n, n))
+	}
+	moves := make(map[string]int)
+	var statuses []string
+	for _, m := range d.Moved {
+		if moves[m.Card.Status] == 0 {
+			statuses = append(statuses, m.Card.Status)
+		}
+		moves[m.Card.Status]++
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		lines = append(lines, l.N("board.moved", moves[status], moves[status], l.Status(status)))
+	}
+	if n := len(d.Removed); n > 0 {
+		lines = append(lines, l.N("board.removed", n, n))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,310 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	gcal "google.golang.org/api/calendar/v3"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// ErrApplicationNotFound is returned when a reply draft names an application
+// that does not exist or belongs to another user.
+var ErrApplicationNotFound = apperr.New(apperr.NotFound, "application not found")
+
+// Slot is a span of time, such as one a recruiter proposed for an interview.
+type Slot struct {
+	StartsAt time.Time `json:"startsAt" validate:"required"`
+	EndsAt   time.Time `json:"endsAt" validate:"required,gtfield=StartsAt"`
+}
+
+func (s Slot) overlaps(o Slot) bool {
+	return s.StartsAt.Before(o.EndsAt) && o.StartsAt.Before(s.EndsAt)
+}
+
+// AvailabilityInput is the recruiter's proposed slots to check against the
+// user's calendar. When DurationMinutes is set, slots are windows in which an
+// interview of that length must fit; otherwise the whole slot must be free.
+type AvailabilityInput struct {
+	Slots           []Slot `json:"slots" validate:"required,min=1,max=20,dive"`
+	DurationMinutes *int   `json:"durationMinutes" validate:"omitempty,min=5,max=480"`
+	// BufferMinutes of free time are kept around existing events.
+	BufferMinutes *int `json:"bufferMinutes" validate:"omitempty,min=0,max=120"`
+}
+
+// SlotOption is the outcome of checking one proposed slot.
+type SlotOption struct {
+	Proposed Slot `json:"proposed"`
+	// Available is the conflict-free time offered within the proposed slot,
+	// or nil when none is free.
+	Available *Slot `json:"available"`
+	// Conflicts are the busy periods that overlap the proposed slot.
+	Conflicts []Busy `json:"conflicts"`
+}
+
+// Busy is a period the user is not free.
+type Busy struct {
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+	// Title is the event's summary, omitted for calendar events the user can
+	// only see as busy.
+	Title string `json:"title"`
+}
+
+// Availability is the result of checking proposed slots.
+type Availability struct {
+	Options []*SlotOption `json:"options"`
+	// CalendarChecked is false when only interviews tracked here were
+	// considered, because calendar sync is off or Google Calendar could not
+	// be read.
+	CalendarChecked bool `json:"calendarChecked"`
+}
+
+// Availability checks the proposed slots against the user's Google Calendar
+// and their scheduled interviews, and returns a conflict-free option for
+// every slot that has one. Slots that have already started are never
+// offered.
+func (s *Syncer) Availability(ctx context.Context, userID string, in AvailabilityInput) (*Availability, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	var duration, buffer time.Duration
+	if in.DurationMinutes != nil {
+		duration = time.Duration(*in.DurationMinutes) * time.Minute
+	}
+	if in.BufferMinutes != nil {
+		buffer = time.Duration(*in.BufferMinutes) * time.Minute
+	}
+
+	from, to := in.Slots[0].StartsAt, in.Slots[0].EndsAt
+	for _, slot := range in.Slots[1:] {
+		if slot.StartsAt.Before(from) {
+			from = slot.StartsAt
+		}
+		if slot.EndsAt.After(to) {
+			to = slot.EndsAt
+		}
+	}
+	from, to = from.Add(-buffer), to.Add(buffer)
+
+	out := &Availability{}
+	busy, err := s.calendarBusy(ctx, userID, from, to)
+	if err != nil {
+		log.Printf("Availability: reading calendar for user %s: %v", userID, err)
+	}
+	out.CalendarChecked = busy != nil
+	tracked, err := s.interviewsBusy(ctx, userID, from, to, out.CalendarChecked)
+	if err != nil {
+		return nil, err
+	}
+	busy = append(busy, tracked...)
+	sort.Slice(busy, func(i, j int) bool { return busy[i].StartsAt.Before(busy[j].StartsAt) })
+
+	now := time.Now()
+	for _, slot := range in.Slots {
+		opt := &SlotOption{Proposed: slot, Conflicts: []Busy{}}
+		for _, b := range busy {
+			if slot.overlaps(Slot{StartsAt: b.StartsAt.Add(-buffer), EndsAt: b.EndsAt.Add(buffer)}) {
+				opt.Conflicts = append(opt.Conflicts, b)
+			}
+		}
+		opt.Available = firstFree(slot, duration, buffer, opt.Conflicts, now)
+		out.Options = append(out.Options, opt)
+	}
+	return out, nil
+}
+
+// firstFree returns the earliest span within slot that avoids every busy
+// period (padded by buffer) and has not started: the whole slot when
+// duration is zero, otherwise the first gap long enough for duration.
+func firstFree(slot Slot, duration, buffer time.Duration, busy []Busy, now time.Time) *Slot {
+	if duration == 0 {
+		if len(busy) > 0 || slot.StartsAt.Before(now) {
+			return nil
+		}
+		return &slot
+	}
+
+	start := slot.StartsAt
+	if start.Before(now) {
+		// Offer the next quarter hour rather than an odd minute.
+		start = now.Truncate(15 * time.Minute).Add(15 * time.Minute)
+	}
+	for _, b := range busy {
+		if !start.Add(duration).After(b.StartsAt.Add(-buffer)) {
+			break
+		}
+		if end := b.EndsAt.Add(buffer); end.After(start) {
+			start = end
+		}
+	}
+	if start.Add(duration).After(slot.EndsAt) {
+		return nil
+	}
+	return &Slot{StartsAt: start, EndsAt: start.Add(duration)}
+}
+
+// interviewsBusy returns the user's scheduled interviews in the range, which
+// count as busy even when calendar sync is off. Interviews mirrored to a
+// calendar event are skipped when the calendar itself was read.
+func (s *Syncer) interviewsBusy(ctx context.Context, userID string, from, to time.Time, calendarRead bool) ([]Busy, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT starts_at, ends_at, title FROM interviews
+		WHERE user_id = $1 AND status = 'scheduled' AND starts_at < $3 AND ends_at > $2
+			AND NOT ($4 AND calendar_event_id IS NOT NULL)`,
+		userID, from, to, calendarRead)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var busy []Busy
+	for rows.Next() {
+		var b Busy
+		if err := rows.Scan(&b.StartsAt, &b.EndsAt, &b.Title); err != nil {
+			return nil, err
+		}
+		busy = append(busy, b)
+	}
+	return busy, rows.Err()
+}
+
+// calendarBusy returns the events in the range that block the user's time
+// in Google Calendar, or nil when calendar sync is off. Events marked free,
+// declined invitations and all-day events are ignored.
+func (s *Syncer) calendarBusy(ctx context.Context, userID string, from, to time.Time) ([]Busy, error) {
+	if ok, err := s.enabled(ctx, userID); err != nil || !ok {
+		return nil, err
+	}
+	svc, err := s.client(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	busy := []Busy{}
+	err = svc.Events.List(calendarID).
+		TimeMin(from.Format(time.RFC3339)).
+		TimeMax(to.Format(time.RFC3339)).
+		SingleEvents(true).
+		Fields("nextPageToken", "items(summary,status,transparency,start,end,attendees(self,responseStatus))").
+		Pages(ctx, func(page *gcal.Events) error {
+			for _, ev := range page.Items {
+				if ev.Status == "cancelled" || ev.Transparency == "transparent" || declined(ev) {
+					continue
+				}
+				if ev.Start == nil || ev.Start.DateTime == "" {
+					continue
+				}
+				start, err := eventTime(ev.Start)
+				if err != nil {
+					continue
+				}
+				end, err := eventTime(ev.End)
+				if err != nil {
+					continue
+				}
+				busy = append(busy, Busy{StartsAt: start, EndsAt: end, Title: ev.Summary})
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	return busy, nil
+}
+
+func declined(ev *gcal.Event) bool {
+	for _, a := range ev.Attendees {
+		if a.Self {
+			return a.ResponseStatus == "declined"
+		}
+	}
+	return false
+}
+
+// ReplyInput asks for a reply offering the chosen slots to a recruiter.
+type ReplyInput struct {
+	ApplicationID *string `json:"applicationId" validate:"omitempty,uuid"`
+	RecipientName *string `json:"recipientName" validate:"omitempty,max=255"`
+	Slots         []Slot  `json:"slots" validate:"required,min=1,max=10,dive"`
+}
+
+// Reply is a drafted email; it is not sent.
+type Reply struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// ReplyDraft writes a reply listing the chosen slots in the user's timezone,
+// mentioning the role when the application is given.
+func (s *Syncer) ReplyDraft(ctx context.Context, userID string, in ReplyInput) (*Reply, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	var name, timezone string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(name, ''), COALESCE(timezone, 'UTC') FROM users WHERE id = $1`, userID).Scan(&name, &timezone)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	subject := "Interview availability"
+	intro := "Thank you for reaching out."
+	if in.ApplicationID != nil {
+		var company, position string
+		err := s.db.QueryRowContext(ctx,
+			`SELECT company, position FROM applications WHERE id = $1 AND user_id = $2`, *in.ApplicationID, userID).Scan(&company, &position)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrApplicationNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		subject = fmt.Sprintf("Interview availability: %s at %s", position, company)
+		intro = fmt.Sprintf("Thank you for reaching out about the %s role at %s. I'd be glad to schedule an interview.", position, company)
+	}
+
+	slots := append([]Slot(nil), in.Slots...)
+	sort.Slice(slots, func(i, j int) bool { return slots[i].StartsAt.Before(slots[j].StartsAt) })
+
+	var b strings.Builder
+	greeting := "Hi,"
+	if in.RecipientName != nil && strings.TrimSpace(*in.RecipientName) != "" {
+		greeting = fmt.Sprintf("Hi %s,", strings.TrimSpace(*in.RecipientName))
+	}
+	fmt.Fprintf(&b, "%s\n\n%s\n\n", greeting, intro)
+	if len(slots) == 1 {
+		b.WriteString("I'm available at the following time:\n\n")
+	} else {
+		b.WriteString("I'm available at any of the following times:\n\n")
+	}
+	for _, slot := range slots {
+		fmt.Fprintf(&b, "- %s\n", formatSlot(slot, loc))
+	}
+	b.WriteString("\nPlease let me know which works best for you.\n\nBest regards,")
+	if name != "" {
+		b.WriteString("\n" + name)
+	}
+	return &Reply{Subject: subject, Body: b.String()}, nil
+}
+
+// formatSlot renders a slot like "Tuesday, March 4, 2:00 PM - 2:45 PM PST".
+func formatSlot(slot Slot, loc *time.Location) string {
+	start, end := slot.StartsAt.In(loc), slot.EndsAt.In(loc)
+	if start.YearDay() == end.YearDay() && start.Year() == end.Year() {
+		return fmt.Sprintf("%s - %s", start.Format("Monday, January 2, 3:04 PM"), end.Format("3:04 PM MST"))
+	}
+	return fmt.Sprintf("%s - %s", start.Format("Monday, January 2, 3:04 PM"), end.Format("Monday, January 2, 3:04 PM MST"))
+}
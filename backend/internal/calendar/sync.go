@@ -0,0 +1,206 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	gcal "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/googleauth"
+	"github.com/jobtracker/backend/internal/interviews"
+)
+
+// calendarID is the calendar interviews are written to.
+const calendarID = "primary"
+
+// ErrScopeMissing is returned when enabling calendar sync without having
+// granted the Calendar scope.
+var ErrScopeMissing = apperr.New(apperr.Validation,
+	"calendar access has not been granted; grant it with the requestGoogleScope mutation (scope calendar) first")
+
+// Syncer mirrors interviews into the user's Google Calendar and pulls back
+// changes the user makes to those events. It only acts for users who have
+// enabled calendar sync and granted the Calendar scope.
+type Syncer struct {
+	db         *sql.DB
+	tokens     *googleauth.TokenStore
+	interviews *interviews.Service
+}
+
+// NewSyncer creates a calendar syncer and registers it as an interview hook.
+func NewSyncer(db *sql.DB, tokens *googleauth.TokenStore, interviewService *interviews.Service) *Syncer {
+	s := &Syncer{db: db, tokens: tokens, interviews: interviewService}
+	interviewService.AddHook(s)
+	return s
+}
+
+// SetEnabled turns calendar sync on or off for the user. Turning it on
+// requires the Calendar scope to have been granted during OAuth.
+func (s *Syncer) SetEnabled(ctx context.Context, userID string, enabled bool) error {
+	if enabled {
+		ok, err := s.tokens.HasScope(ctx, userID, googleauth.ScopeCalendarEvents)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrScopeMissing
+		}
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET calendar_sync_enabled = $2 WHERE id = $1`, userID, enabled)
+	return err
+}
+
+func (s *Syncer) enabled(ctx context.Context, userID string) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRowContext(ctx, `SELECT calendar_sync_enabled FROM users WHERE id = $1`, userID).Scan(&enabled)
+	return enabled, err
+}
+
+func (s *Syncer) client(ctx context.Context, userID string) (*gcal.Service, error) {
+	ts, err := s.tokens.TokenSource(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return gcal.NewService(ctx, option.WithTokenSource(ts))
+}
+
+// InterviewSaved creates or updates the calendar event for an interview.
+func (s *Syncer) InterviewSaved(ctx context.Context, iv *interviews.Interview) error {
+	if ok, err := s.enabled(ctx, iv.UserID); err != nil || !ok {
+		return err
+	}
+	svc, err := s.client(ctx, iv.UserID)
+	if err != nil {
+		return err
+	}
+
+	if iv.Status == interviews.StatusCancelled {
+		return s.InterviewDeleted(ctx, iv)
+	}
+
+	ev := toEvent(iv)
+	if iv.CalendarEventID != nil {
+		_, err := svc.Events.Update(calendarID, *iv.CalendarEventID, ev).Context(ctx).Do()
+		if !isGone(err) {
+			return apperr.Wrap(apperr.UpstreamGmail, err)
+		}
+		// The user deleted the event in Calendar; recreate it below.
+	}
+
+	created, err := svc.Events.Insert(calendarID, ev).Context(ctx).Do()
+	if err != nil {
+		return apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	return s.interviews.SetCalendarEvent(ctx, iv.ID, &created.Id)
+}
+
+// InterviewDeleted removes the calendar event for an interview, if any.
+func (s *Syncer) InterviewDeleted(ctx context.Context, iv *interviews.Interview) error {
+	if iv.CalendarEventID == nil {
+		return nil
+	}
+	svc, err := s.client(ctx, iv.UserID)
+	if err != nil {
+		return err
+	}
+	if err := svc.Events.Delete(calendarID, *iv.CalendarEventID).Context(ctx).Do(); err != nil && !isGone(err) {
+		return apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	return s.interviews.SetCalendarEvent(ctx, iv.ID, nil)
+}
+
+// Reconcile pulls changes made in Google Calendar back into interviews:
+// moved events reschedule the interview and deleted or cancelled events
+// cancel it. It is intended to run periodically from the scheduler.
+func (s *Syncer) Reconcile(ctx context.Context) error {
+	linked, err := s.interviews.Linked(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	clients := make(map[string]*gcal.Service)
+	for _, iv := range linked {
+		svc, ok := clients[iv.UserID]
+		if !ok {
+			svc, err = s.client(ctx, iv.UserID)
+			if err != nil {
+				log.Printf("Calendar reconcile: skipping user %s: %v", iv.UserID, err)
+			}
+			clients[iv.UserID] = svc
+		}
+		if svc == nil {
+			continue
+		}
+		if err := s.reconcileOne(ctx, svc, iv); err != nil {
+			log.Printf("Calendar reconcile: interview %s: %v", iv.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) reconcileOne(ctx context.Context, svc *gcal.Service, iv *interviews.Interview) error {
+	ev, err := svc.Events.Get(calendarID, *iv.CalendarEventID).Context(ctx).Do()
+	if isGone(err) || (err == nil && ev.Status == "cancelled") {
+		if err := s.interviews.Reschedule(ctx, iv.ID, iv.StartsAt, iv.EndsAt, interviews.StatusCancelled); err != nil {
+			return err
+		}
+		return s.interviews.SetCalendarEvent(ctx, iv.ID, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	start, err := eventTime(ev.Start)
+	if err != nil {
+		return err
+	}
+	end, err := eventTime(ev.End)
+	if err != nil {
+		return err
+	}
+	if start.Equal(iv.StartsAt) && end.Equal(iv.EndsAt) {
+		return nil
+	}
+	return s.interviews.Reschedule(ctx, iv.ID, start, end, iv.Status)
+}
+
+func toEvent(iv *interviews.Interview) *gcal.Event {
+	ev := &gcal.Event{
+		Summary: iv.Title,
+		Start:   &gcal.EventDateTime{DateTime: iv.StartsAt.Format(time.RFC3339), TimeZone: iv.Timezone},
+		End:     &gcal.EventDateTime{DateTime: iv.EndsAt.Format(time.RFC3339), TimeZone: iv.Timezone},
+		ExtendedProperties: &gcal.EventExtendedProperties{
+			Private: map[string]string{"jobtrackerInterviewId": iv.ID},
+		},
+	}
+	if iv.Location != nil {
+		ev.Location = *iv.Location
+	}
+	if iv.MeetingLink != nil {
+		ev.Description = fmt.Sprintf("Meeting link: %s", *iv.MeetingLink)
+	}
+	return ev
+}
+
+func eventTime(t *gcal.EventDateTime) (time.Time, error) {
+	if t == nil {
+		return time.Time{}, errors.New("event has no time")
+	}
+	if t.DateTime != "" {
+		return time.Parse(time.RFC3339, t.DateTime)
+	}
+	return time.Parse("2006-01-02", t.Date)
+}
+
+func isGone(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && (gerr.Code == http.StatusNotFound || gerr.Code == http.StatusGone)
+}
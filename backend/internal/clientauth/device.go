@@ -0,0 +1,161 @@
+package clientauth
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	deviceTTL      = 10 * time.Minute
+	deviceInterval = 5 * time.Second
+)
+
+// userCodeAlphabet omits vowels and look-alike characters so codes are easy
+// to read aloud and cannot spell words.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// DeviceCode is the response to a device authorization request.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type deviceGrant struct {
+	ClientID   string    `json:"clientId"`
+	UserCode   string    `json:"userCode"`
+	Status     string    `json:"status"` // pending, approved, denied
+	UserID     string    `json:"userId,omitempty"`
+	LastPolled time.Time `json:"lastPolled"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+func newUserCode() (string, error) {
+	var b strings.Builder
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+func normalizeUserCode(code string) string {
+	code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// StartDevice begins a device authorization: the client shows the user code
+// and verification URI, then polls the token endpoint.
+func (s *Service) StartDevice(ctx context.Context, clientID string) (*DeviceCode, error) {
+	if !s.knownClient(clientID) {
+		return nil, ErrInvalidClient
+	}
+
+	deviceCode, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	userCode, err := newUserCode()
+	if err != nil {
+		return nil, err
+	}
+
+	grant := deviceGrant{ClientID: clientID, UserCode: userCode, Status: "pending", ExpiresAt: time.Now().Add(deviceTTL)}
+	if err := s.put(ctx, "device:"+deviceCode, grant, deviceTTL); err != nil {
+		return nil, err
+	}
+	if err := s.put(ctx, "usercode:"+userCode, deviceCode, deviceTTL); err != nil {
+		return nil, err
+	}
+
+	verification := s.cfg.PublicURL + "/device"
+	return &DeviceCode{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verification,
+		VerificationURIComplete: verification + "?user_code=" + userCode,
+		ExpiresIn:               int(deviceTTL.Seconds()),
+		Interval:                int(deviceInterval.Seconds()),
+	}, nil
+}
+
+// ApproveDevice is called by a signed-in web user who entered the user code.
+// It approves or denies the pending device authorization.
+func (s *Service) ApproveDevice(ctx context.Context, userID, userCode string, approve bool) error {
+	userCode = normalizeUserCode(userCode)
+	var deviceCode string
+	ok, err := s.get(ctx, "usercode:"+userCode, &deviceCode, true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrExpiredToken
+	}
+
+	var grant deviceGrant
+	ok, err = s.get(ctx, "device:"+deviceCode, &grant, false)
+	if err != nil {
+		return err
+	}
+	if !ok || grant.Status != "pending" {
+		return ErrExpiredToken
+	}
+
+	grant.Status = "denied"
+	if approve {
+		grant.Status, grant.UserID = "approved", userID
+	}
+	return s.put(ctx, "device:"+deviceCode, grant, time.Until(grant.ExpiresAt))
+}
+
+// PollDevice is called by the client at the advertised interval until the
+// user has approved or denied the request.
+func (s *Service) PollDevice(ctx context.Context, clientID, deviceCode string) (*TokenResponse, error) {
+	var grant deviceGrant
+	ok, err := s.get(ctx, "device:"+deviceCode, &grant, false)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrExpiredToken
+	}
+	if grant.ClientID != clientID {
+		return nil, ErrInvalidGrant
+	}
+
+	switch grant.Status {
+	case "approved":
+		if _, err := s.redis.Del(ctx, keyPrefix+"device:"+deviceCode).Result(); err != nil {
+			return nil, err
+		}
+		return s.issueToken(ctx, grant.UserID, clientID)
+	case "denied":
+		s.redis.Del(ctx, keyPrefix+"device:"+deviceCode)
+		return nil, ErrAccessDenied
+	}
+
+	tooFast := time.Since(grant.LastPolled) < deviceInterval
+	grant.LastPolled = time.Now()
+	if err := s.put(ctx, "device:"+deviceCode, grant, time.Until(grant.ExpiresAt)); err != nil {
+		return nil, err
+	}
+	if tooFast {
+		return nil, ErrSlowDown
+	}
+	return nil, ErrAuthorizationPending
+}
@@ -0,0 +1,108 @@
+package clientauth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// Register mounts the non-web client OAuth routes on the auth group.
+func (s *Service) Register(rg *gin.RouterGroup) {
+	rg.GET("/authorize", s.AuthorizeHandler())
+	rg.GET("/authorize/callback", s.CallbackHandler())
+	rg.POST("/device/code", s.DeviceCodeHandler())
+	rg.POST("/token", s.TokenHandler())
+}
+
+func writeError(c *gin.Context, err error) {
+	var oauthErr *Error
+	if errors.As(err, &oauthErr) {
+		status := http.StatusBadRequest
+		if oauthErr == ErrInvalidClient {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, oauthErr)
+		return
+	}
+	log.Printf("Client auth error: %v", err)
+	c.JSON(http.StatusInternalServerError, &Error{Code: "server_error"})
+}
+
+// AuthorizeHandler starts the PKCE flow and redirects to Google.
+func (s *Service) AuthorizeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("response_type") != "code" {
+			writeError(c, invalidRequest("response_type must be code"))
+			return
+		}
+		target, err := s.Authorize(c.Request.Context(), AuthorizeRequest{
+			ClientID:            c.Query("client_id"),
+			RedirectURI:         c.Query("redirect_uri"),
+			State:               c.Query("state"),
+			CodeChallenge:       c.Query("code_challenge"),
+			CodeChallengeMethod: c.Query("code_challenge_method"),
+		})
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.Redirect(http.StatusFound, target)
+	}
+}
+
+// CallbackHandler receives Google's redirect and sends the browser back to
+// the client with an authorization code.
+func (s *Service) CallbackHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if e := c.Query("error"); e != "" {
+			writeError(c, &Error{Code: ErrAccessDenied.Code, Description: e})
+			return
+		}
+		target, err := s.Callback(c.Request.Context(), c.Query("state"), c.Query("code"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.Redirect(http.StatusFound, target)
+	}
+}
+
+// DeviceCodeHandler starts a device authorization.
+func (s *Service) DeviceCodeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dc, err := s.StartDevice(c.Request.Context(), c.PostForm("client_id"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, dc)
+	}
+}
+
+// TokenHandler redeems authorization codes and polls device grants.
+func (s *Service) TokenHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+
+		var resp *TokenResponse
+		var err error
+		switch c.PostForm("grant_type") {
+		case "authorization_code":
+			resp, err = s.ExchangeCode(c.Request.Context(), c.PostForm("client_id"),
+				c.PostForm("code"), c.PostForm("code_verifier"), c.PostForm("redirect_uri"))
+		case deviceCodeGrantType:
+			resp, err = s.PollDevice(c.Request.Context(), c.PostForm("client_id"), c.PostForm("device_code"))
+		default:
+			err = ErrUnsupportedGrantType
+		}
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,146 @@
+package clientauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	pendingTTL = 10 * time.Minute
+	codeTTL    = 2 * time.Minute
+)
+
+// AuthorizeRequest is the client's authorization request.
+type AuthorizeRequest struct {
+	ClientID            string `json:"clientId"`
+	RedirectURI         string `json:"redirectUri"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"codeChallenge"`
+	CodeChallengeMethod string `json:"codeChallengeMethod"`
+}
+
+type issuedCode struct {
+	UserID        string `json:"userId"`
+	ClientID      string `json:"clientId"`
+	RedirectURI   string `json:"redirectUri"`
+	CodeChallenge string `json:"codeChallenge"`
+	Method        string `json:"method"`
+}
+
+func (s *Service) googleConfig() *oauth2.Config {
+	c := s.oauthConfig()
+	c.RedirectURL = s.cfg.PublicURL + "/api/v1/auth/authorize/callback"
+	return c
+}
+
+// Authorize validates a PKCE authorization request (S256 only), remembers
+// it, and returns the Google consent URL the user's browser should be sent
+// to.
+func (s *Service) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	if !s.knownClient(req.ClientID) {
+		return "", ErrInvalidClient
+	}
+	if !s.allowedRedirect(req.RedirectURI) {
+		return "", invalidRequest("redirect_uri is not allowed")
+	}
+	if req.CodeChallenge == "" {
+		return "", invalidRequest("code_challenge is required")
+	}
+	// The plain method would let anyone who sees the authorization request
+	// redeem the code, so only S256 is accepted and it must be named.
+	if req.CodeChallengeMethod != "S256" {
+		return "", invalidRequest("code_challenge_method must be S256")
+	}
+
+	state, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	if err := s.put(ctx, "pending:"+state, req, pendingTTL); err != nil {
+		return "", err
+	}
+	return s.googleConfig().AuthCodeURL(state, oauth2.AccessTypeOffline), nil
+}
+
+// Callback completes the Google leg of the flow: it exchanges Google's code
+// server-side, signs the user in, and returns the client redirect carrying a
+// one-time authorization code bound to the PKCE challenge.
+func (s *Service) Callback(ctx context.Context, state, googleCode string) (string, error) {
+	var req AuthorizeRequest
+	ok, err := s.get(ctx, "pending:"+state, &req, true)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", invalidRequest("authorization request expired")
+	}
+
+	tok, err := s.googleConfig().Exchange(ctx, googleCode)
+	if err != nil {
+		return "", err
+	}
+	userID, err := s.upsertGoogleUser(ctx, tok)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	err = s.put(ctx, "code:"+code, issuedCode{
+		UserID:        userID,
+		ClientID:      req.ClientID,
+		RedirectURI:   req.RedirectURI,
+		CodeChallenge: req.CodeChallenge,
+		Method:        req.CodeChallengeMethod,
+	}, codeTTL)
+	if err != nil {
+		return "", err
+	}
+
+	redirect, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+	q := redirect.Query()
+	q.Set("code", code)
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	redirect.RawQuery = q.Encode()
+	return redirect.String(), nil
+}
+
+// ExchangeCode redeems an authorization code. The verifier must match the
+// challenge sent with the authorization request.
+func (s *Service) ExchangeCode(ctx context.Context, clientID, code, verifier, redirectURI string) (*TokenResponse, error) {
+	var issued issuedCode
+	ok, err := s.get(ctx, "code:"+code, &issued, true)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || issued.ClientID != clientID || issued.RedirectURI != redirectURI {
+		return nil, ErrInvalidGrant
+	}
+	if issued.Method != "S256" || !verifyChallenge(issued.CodeChallenge, verifier) {
+		return nil, &Error{Code: ErrInvalidGrant.Code, Description: "code_verifier does not match"}
+	}
+	return s.issueToken(ctx, issued.UserID, clientID)
+}
+
+// verifyChallenge checks an S256 code verifier against its challenge.
+func verifyChallenge(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
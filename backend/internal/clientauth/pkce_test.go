@@ -0,0 +1,44 @@
+package clientauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jobtracker/backend/internal/config"
+)
+
+func TestAuthorizeRequiresS256(t *testing.T) {
+	s := NewService(&config.Config{OAuthPublicClients: []string{"cli"}}, nil, nil, nil, nil)
+	for _, method := range []string{"", "plain", "s256"} {
+		_, err := s.Authorize(context.Background(), AuthorizeRequest{
+			ClientID:            "cli",
+			RedirectURI:         "http://127.0.0.1:8123/callback",
+			CodeChallenge:       "DbOCTxEzcAAK_o2DGVhdiLbAsEBCX6ra_D2gaOTql5o",
+			CodeChallengeMethod: method,
+		})
+		var oauthErr *Error
+		if !errors.As(err, &oauthErr) || oauthErr.Code != ErrInvalidRequest.Code {
+			t.Errorf("method %q: err = %v, want invalid_request", method, err)
+		}
+	}
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	const challenge = "DbOCTxEzcAAK_o2DGVhdiLbAsEBCX6ra_D2gaOTql5o"
+	tests := []struct {
+		name     string
+		verifier string
+		want     bool
+	}{
+		{"matching verifier", "dBjftJeZ4CVP-mJ92K1QH2sK7EyPyapR1IxkVUheK1w", true},
+		{"other verifier", "dBjftJeZ4CVP-mJ92K1QH2sK7EyPyapR1IxkVUheK1x", false},
+		{"challenge sent as its own verifier", challenge, false},
+		{"too short", "abc", false},
+	}
+	for _, tt := range tests {
+		if got := verifyChallenge(challenge, tt.verifier); got != tt.want {
+			t.Errorf("%s: verifyChallenge = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
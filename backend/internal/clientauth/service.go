@@ -0,0 +1,146 @@
+// Package clientauth lets non-web clients such as jobtrackerctl and the
+// browser extension sign in without embedding the Google client secret. It
+// supports the authorization code flow with PKCE (RFC 7636) for clients that
+// can open a browser and receive a redirect, and the device authorization
+// grant (RFC 8628) for clients that cannot. Both issue an API key as the
+// access token.
+package clientauth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/jobtracker/backend/internal/apikeys"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/googleauth"
+)
+
+const keyPrefix = "clientauth:"
+
+// OAuth error codes returned to clients (RFC 6749 section 5.2, RFC 8628 section 3.5).
+var (
+	ErrInvalidClient        = &Error{Code: "invalid_client", Description: "unknown client_id"}
+	ErrInvalidRequest       = &Error{Code: "invalid_request"}
+	ErrInvalidGrant         = &Error{Code: "invalid_grant", Description: "code is invalid, expired or already used"}
+	ErrUnsupportedGrantType = &Error{Code: "unsupported_grant_type"}
+	ErrAuthorizationPending = &Error{Code: "authorization_pending"}
+	ErrSlowDown             = &Error{Code: "slow_down"}
+	ErrAccessDenied         = &Error{Code: "access_denied"}
+	ErrExpiredToken         = &Error{Code: "expired_token"}
+)
+
+// Error is an OAuth protocol error.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func invalidRequest(description string) *Error {
+	return &Error{Code: ErrInvalidRequest.Code, Description: description}
+}
+
+// TokenResponse is returned by the token endpoint.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// Service runs the PKCE and device authorization flows.
+type Service struct {
+	cfg    *config.Config
+	db     *sql.DB
+	redis  *redis.Client
+	keys   *apikeys.Service
+	tokens *googleauth.TokenStore
+}
+
+// NewService creates the client authentication service.
+func NewService(cfg *config.Config, db *sql.DB, rdb *redis.Client, keys *apikeys.Service, tokens *googleauth.TokenStore) *Service {
+	return &Service{cfg: cfg, db: db, redis: rdb, keys: keys, tokens: tokens}
+}
+
+func (s *Service) knownClient(clientID string) bool {
+	for _, c := range s.cfg.OAuthPublicClients {
+		if c == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedRedirect accepts loopback redirects on any port (RFC 8252 section
+// 7.3) and any URI explicitly configured in OAUTH_CLIENT_REDIRECT_URIS, such
+// as the browser extension's chromiumapp.org URL.
+func (s *Service) allowedRedirect(raw string) bool {
+	for _, allowed := range s.cfg.OAuthClientRedirectURIs {
+		if raw == allowed {
+			return true
+		}
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "http" {
+		return false
+	}
+	host := u.Hostname()
+	return host == "127.0.0.1" || host == "::1" || host == "localhost"
+}
+
+// issueToken creates an API key for the user named after the client.
+func (s *Service) issueToken(ctx context.Context, userID, clientID string) (*TokenResponse, error) {
+	created, err := s.keys.Create(ctx, userID, clientID+" (signed in "+time.Now().Format("2006-01-02")+")")
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{AccessToken: created.Key, TokenType: "Bearer"}, nil
+}
+
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *Service) put(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, keyPrefix+key, data, ttl).Err()
+}
+
+// get loads a stored value; when consume is set the value is deleted so it
+// can only be used once.
+func (s *Service) get(ctx context.Context, key string, v any, consume bool) (bool, error) {
+	var data []byte
+	var err error
+	if consume {
+		data, err = s.redis.GetDel(ctx, keyPrefix+key).Bytes()
+	} else {
+		data, err = s.redis.Get(ctx, keyPrefix+key).Bytes()
+	}
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, v)
+}
@@ -0,0 +1,58 @@
+package clientauth
+
+import (
+	"context"
+
+	"golang.org/x/oauth2"
+	googleoauth "google.golang.org/api/oauth2/v2"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/googleauth"
+)
+
+func (s *Service) oauthConfig() *oauth2.Config {
+	return googleauth.OAuthConfig(s.cfg)
+}
+
+// errUnverifiedEmail refuses sign-in with a Google account whose email
+// address is not verified, which could otherwise claim the account
+// pre-provisioned for that address.
+var errUnverifiedEmail = &Error{Code: ErrAccessDenied.Code, Description: "the Google account's email address is not verified"}
+
+// upsertGoogleUser loads the Google profile for the token, creates or
+// updates the matching user, and stores the Google token for syncing.
+// Accounts with an unverified email address are refused.
+func (s *Service) upsertGoogleUser(ctx context.Context, tok *oauth2.Token) (string, error) {
+	svc, err := googleoauth.NewService(ctx, option.WithTokenSource(s.oauthConfig().TokenSource(ctx, tok)))
+	if err != nil {
+		return "", err
+	}
+	info, err := svc.Userinfo.Get().Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	if info.VerifiedEmail == nil || !*info.VerifiedEmail {
+		return "", errUnverifiedEmail
+	}
+
+	// Adopt a user pre-provisioned by email (e.g. with jobtrackerctl users
+	// create-admin) on their first sign-in.
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE users SET google_id = $1 WHERE LOWER(email) = LOWER($2) AND google_id IS NULL`,
+		info.Id, info.Email); err != nil {
+		return "", err
+	}
+
+	var userID string
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO users (id, email, name, picture_url, google_id)
+		VALUES ($1, $2, $3, $4, $1)
+		ON CONFLICT (google_id) DO UPDATE
+		SET email = EXCLUDED.email, name = EXCLUDED.name, picture_url = EXCLUDED.picture_url
+		RETURNING id`,
+		info.Id, info.Email, info.Name, info.Picture).Scan(&userID)
+	if err != nil {
+		return "", err
+	}
+	return userID, s.tokens.Save(ctx, userID, tok)
+}
@@ -0,0 +1,228 @@
+// Package companies keeps a research dossier per company - interview
+// process intel, contacts and culture notes - shared by all of the user's
+// applications to that company. Companies are matched by name, ignoring
+// case, the same way applications are grouped by company elsewhere.
+package companies
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// ErrNotFound is returned when the user has neither notes on nor
+// applications to a company.
+var ErrNotFound = apperr.New(apperr.NotFound, "company not found")
+
+// Contact is someone the user knows at a company.
+type Contact struct {
+	Name     string  `json:"name" validate:"required,max=255"`
+	Role     *string `json:"role" validate:"omitempty,max=255"`
+	Email    *string `json:"email" validate:"omitempty,email,max=255"`
+	LinkedIn *string `json:"linkedin" validate:"omitempty,url,max=2048"`
+	Notes    *string `json:"notes" validate:"omitempty,max=2000"`
+}
+
+// Notes is the user's dossier on a company.
+type Notes struct {
+	Company          string     `json:"company"`
+	InterviewProcess *string    `json:"interviewProcess"`
+	Culture          *string    `json:"culture"`
+	Contacts         []*Contact `json:"contacts"`
+	Notes            *string    `json:"notes"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+// NotesInput replaces a company's dossier.
+type NotesInput struct {
+	InterviewProcess *string    `json:"interviewProcess" validate:"omitempty,max=20000"`
+	Culture          *string    `json:"culture" validate:"omitempty,max=20000"`
+	Contacts         []*Contact `json:"contacts" validate:"max=100,dive"`
+	Notes            *string    `json:"notes" validate:"omitempty,max=20000"`
+}
+
+// Company is everything the user has on a company: the dossier and the
+// applications it is shared by.
+type Company struct {
+	Name         string                `json:"name"`
+	Notes        *Notes                `json:"notes"`
+	Applications []*models.Application `json:"applications"`
+}
+
+// Service manages company dossiers.
+type Service struct {
+	db           *sql.DB
+	applications *applications.Service
+}
+
+// NewService creates a company service.
+func NewService(db *sql.DB, applicationService *applications.Service) *Service {
+	return &Service{db: db, applications: applicationService}
+}
+
+const notesColumns = `company, interview_process, culture, contacts, notes, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNotes(row scanner) (*Notes, error) {
+	n := &Notes{}
+	var contacts []byte
+	err := row.Scan(&n.Company, &n.InterviewProcess, &n.Culture, &contacts, &n.Notes, &n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(contacts, &n.Contacts); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Company returns the user's dossier on the company, if any, and their
+// applications to it.
+func (s *Service) Company(ctx context.Context, userID, name string) (*Company, error) {
+	notes, err := s.Notes(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	apps, err := s.applications.FindByCompany(ctx, userID, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if notes == nil && len(apps) == 0 {
+		return nil, ErrNotFound
+	}
+	c := &Company{Name: strings.TrimSpace(name), Notes: notes, Applications: apps}
+	switch {
+	case notes != nil:
+		c.Name = notes.Company
+	case len(apps) > 0:
+		c.Name = apps[0].Company
+	}
+	return c, nil
+}
+
+// Notes returns the user's dossier on the company, or nil.
+func (s *Service) Notes(ctx context.Context, userID, company string) (*Notes, error) {
+	n, err := scanNotes(s.db.QueryRowContext(ctx, `
+		SELECT `+notesColumns+` FROM company_notes
+		WHERE user_id = $1 AND LOWER(company) = LOWER($2)`,
+		userID, strings.TrimSpace(company)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return n, err
+}
+
+// List returns all of the user's dossiers, by company name.
+func (s *Service) List(ctx context.Context, userID string) ([]*Notes, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+notesColumns+` FROM company_notes WHERE user_id = $1 ORDER BY LOWER(company)`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*Notes{}
+	for rows.Next() {
+		n, err := scanNotes(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// SetNotes creates or replaces the user's dossier on a company. The first
+// spelling of the company name is kept.
+func (s *Service) SetNotes(ctx context.Context, userID, company string, in NotesInput) (*Notes, error) {
+	company = strings.TrimSpace(company)
+	if company == "" || len(company) > 255 {
+		return nil, validation.Field("company", "must be between 1 and 255 characters")
+	}
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	if in.Contacts == nil {
+		in.Contacts = []*Contact{}
+	}
+	contacts, err := json.Marshal(in.Contacts)
+	if err != nil {
+		return nil, err
+	}
+	return scanNotes(s.db.QueryRowContext(ctx, `
+		INSERT INTO company_notes (user_id, company, interview_process, culture, contacts, notes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, LOWER(company)) DO UPDATE SET
+			interview_process = EXCLUDED.interview_process, culture = EXCLUDED.culture,
+			contacts = EXCLUDED.contacts, notes = EXCLUDED.notes, updated_at = CURRENT_TIMESTAMP
+		RETURNING `+notesColumns,
+		userID, company, in.InterviewProcess, in.Culture, contacts, in.Notes))
+}
+
+// DeleteNotes removes the user's dossier on a company. It reports false if
+// there was none.
+func (s *Service) DeleteNotes(ctx context.Context, userID, company string) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM company_notes WHERE user_id = $1 AND LOWER(company) = LOWER($2)`, userID, strings.TrimSpace(company))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ExportRow is a dossier flattened for the exported spreadsheet's
+// Companies sheet.
+type ExportRow struct {
+	Company          string `json:"company"`
+	InterviewProcess string `json:"interviewProcess"`
+	Culture          string `json:"culture"`
+	Contacts         string `json:"contacts"` // one "Name (Role) <email>" per line
+	Notes            string `json:"notes"`
+}
+
+// ExportRows lists the user's dossiers for exports.
+func (s *Service) ExportRows(ctx context.Context, userID string) ([]ExportRow, error) {
+	list, err := s.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ExportRow, 0, len(list))
+	for _, n := range list {
+		row := ExportRow{Company: n.Company, InterviewProcess: deref(n.InterviewProcess), Culture: deref(n.Culture), Notes: deref(n.Notes)}
+		lines := make([]string, 0, len(n.Contacts))
+		for _, c := range n.Contacts {
+			line := c.Name
+			if c.Role != nil && *c.Role != "" {
+				line += fmt.Sprintf(" (%s)", *c.Role)
+			}
+			if c.Email != nil && *c.Email != "" {
+				line += fmt.Sprintf(" <%s>", *c.Email)
+			}
+			lines = append(lines, line)
+		}
+		row.Contacts = strings.Join(lines, "\n")
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profiles are named sets of defaults for a kind of deployment, compiled
+// into the binary from profiles/<name>.yaml and chosen with CONFIG_PROFILE.
+// A profile maps environment variable names to values; variables set in the
+// environment win over the profile, which wins over the built-in defaults.
+//
+//go:embed profiles/*.yaml
+var profiles embed.FS
+
+// DefaultProfile is used when CONFIG_PROFILE is not set.
+const DefaultProfile = "dev"
+
+// Where a setting's value came from.
+const (
+	SourceEnv     = "env"
+	SourceProfile = "profile"
+	SourceDefault = "default"
+)
+
+// Setting is one configuration variable as resolved at startup, for
+// debugging deployments.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Settings returns every configuration variable, in declaration order.
+func (c *Config) Settings() []Setting {
+	return c.settings
+}
+
+// Profiles returns the names of the compiled-in profiles.
+func Profiles() []string {
+	entries, _ := profiles.ReadDir("profiles")
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loader resolves variables against the environment and a profile,
+// recording each setting as it goes.
+type loader struct {
+	profile  string
+	values   map[string]string
+	settings []Setting
+}
+
+func newLoader(profile string) (*loader, error) {
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	data, err := profiles.ReadFile("profiles/" + profile + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown profile %q (have %s)", profile, strings.Join(Profiles(), ", "))
+	}
+	l := &loader{profile: profile}
+	if err := yaml.Unmarshal(data, &l.values); err != nil {
+		return nil, fmt.Errorf("parse profile %s: %w", profile, err)
+	}
+	return l, nil
+}
+
+// lookup returns the raw value of a variable and where it came from, or ""
+// if neither the environment nor the profile sets it.
+func (l *loader) lookup(key string) (string, string) {
+	if value := os.Getenv(key); value != "" {
+		return value, SourceEnv
+	}
+	if value, ok := l.values[key]; ok && value != "" {
+		return value, SourceProfile
+	}
+	return "", SourceDefault
+}
+
+func (l *loader) record(key, value, source string) {
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source})
+}
+
+// secretMarkers are parts of variable names whose values are credentials.
+var secretMarkers = []string{"SECRET", "PASSWORD", "TOKEN", "API_KEY", "ACCESS_KEY", "ENCRYPTION_KEY"}
+
+// Redacted returns the value with credentials masked: secrets entirely,
+// and the password of URLs such as DATABASE_URL.
+func (s Setting) Redacted() string {
+	if s.Value == "" {
+		return ""
+	}
+	for _, marker := range secretMarkers {
+		if strings.Contains(s.Key, marker) {
+			return "[redacted]"
+		}
+	}
+	if u, err := url.Parse(s.Value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "redacted")
+			return u.String()
+		}
+	}
+	return s.Value
+}
@@ -0,0 +1,169 @@
+// Package consent grants optional Google scopes to a signed-in user with
+// incremental authorization: the user is sent to Google to approve one more
+// scope, and the token Google returns, covering it and every scope granted
+// before, replaces the stored one. Features behind an optional scope point
+// users here when it is missing.
+package consent
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/oauth2"
+	googleoauth "google.golang.org/api/oauth2/v2"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/googleauth"
+)
+
+const keyPrefix = "consent:"
+
+// pendingTTL is how long the user has to approve a scope at Google.
+const pendingTTL = 10 * time.Minute
+
+// Optional scopes, by the name clients ask for them with.
+var scopes = map[string]string{
+	"calendar": googleauth.ScopeCalendarEvents,
+	"modify":   googleauth.ScopeGmailModify,
+	"send":     googleauth.ScopeGmailSend,
+}
+
+var (
+	// ErrUnknownScope is returned when asking for a scope that is not
+	// optional.
+	ErrUnknownScope = apperr.New(apperr.Validation, "unknown scope")
+	// ErrExpired is returned when Google redirects back after the request
+	// expired or was already completed.
+	ErrExpired = apperr.New(apperr.Validation, "scope request expired; start again")
+	// ErrWrongAccount is returned when the user approved the scope with a
+	// different Google account than the one they signed in with.
+	ErrWrongAccount = apperr.New(apperr.Forbidden, "approve access with the Google account you signed in with")
+)
+
+type pending struct {
+	UserID string `json:"userId"`
+	Name   string `json:"name"` // in scopes
+}
+
+// Service runs incremental authorization.
+type Service struct {
+	cfg    *config.Config
+	db     *sql.DB
+	redis  *redis.Client
+	tokens *googleauth.TokenStore
+}
+
+// NewService creates a consent service.
+func NewService(cfg *config.Config, db *sql.DB, rdb *redis.Client, tokens *googleauth.TokenStore) *Service {
+	return &Service{cfg: cfg, db: db, redis: rdb, tokens: tokens}
+}
+
+func (s *Service) oauthConfig(extraScopes ...string) *oauth2.Config {
+	c := googleauth.OAuthConfig(s.cfg, extraScopes...)
+	c.RedirectURL = s.cfg.PublicURL + "/api/v1/auth/google/consent/callback"
+	return c
+}
+
+// URL returns the Google consent URL that asks the user for the named
+// optional scope. The user's browser should be sent to it.
+func (s *Service) URL(ctx context.Context, userID, name string) (string, error) {
+	scope, ok := scopes[name]
+	if !ok {
+		return "", ErrUnknownScope
+	}
+	var email string
+	if err := s.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&email); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	state := base64.RawURLEncoding.EncodeToString(buf)
+	data, err := json.Marshal(pending{UserID: userID, Name: name})
+	if err != nil {
+		return "", err
+	}
+	if err := s.redis.Set(ctx, keyPrefix+state, data, pendingTTL).Err(); err != nil {
+		return "", err
+	}
+	// Forcing the consent screen makes Google issue a refresh token that
+	// covers the new scope too.
+	return s.oauthConfig(scope).AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce,
+		oauth2.SetAuthURLParam("include_granted_scopes", "true"),
+		oauth2.SetAuthURLParam("login_hint", email)), nil
+}
+
+// Callback completes a scope request: it exchanges Google's code and stores
+// the token if it was granted by the user's own Google account. It returns
+// the name of the scope granted.
+func (s *Service) Callback(ctx context.Context, state, code string) (string, error) {
+	data, err := s.redis.GetDel(ctx, keyPrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrExpired
+	}
+	if err != nil {
+		return "", err
+	}
+	var p pending
+	if err := json.Unmarshal(data, &p); err != nil {
+		return "", err
+	}
+
+	conf := s.oauthConfig(scopes[p.Name])
+	tok, err := conf.Exchange(ctx, code)
+	if err != nil {
+		return "", apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	svc, err := googleoauth.NewService(ctx, option.WithTokenSource(conf.TokenSource(ctx, tok)))
+	if err != nil {
+		return "", err
+	}
+	info, err := svc.Userinfo.Get().Context(ctx).Do()
+	if err != nil {
+		return "", apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	var googleID sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT google_id FROM users WHERE id = $1`, p.UserID).Scan(&googleID); err != nil {
+		return "", err
+	}
+	if googleID.String != info.Id {
+		return "", ErrWrongAccount
+	}
+	return p.Name, s.tokens.Save(ctx, p.UserID, tok)
+}
+
+// Register mounts the route Google redirects back to on the auth group.
+// Scope requests are started with URL, for a signed-in user.
+func (s *Service) Register(rg *gin.RouterGroup) {
+	rg.GET("/google/consent/callback", s.CallbackHandler())
+}
+
+// CallbackHandler receives Google's redirect and sends the browser back to
+// the app, telling it which scope was granted.
+func (s *Service) CallbackHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if e := c.Query("error"); e != "" {
+			apperr.Respond(c, "Scope consent", apperr.New(apperr.Forbidden, "access was not granted: "+e))
+			return
+		}
+		name, err := s.Callback(c.Request.Context(), c.Query("state"), c.Query("code"))
+		if err != nil {
+			apperr.Respond(c, "Scope consent", err)
+			return
+		}
+		c.Redirect(http.StatusFound, s.cfg.PublicURL+"/?granted="+url.QueryEscape(name))
+	}
+}
@@ -0,0 +1,74 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Rates are exchange rates from one base currency: one unit of Base buys
+// Rates[code] units of code.
+type Rates struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Provider fetches the latest exchange rates.
+type Provider interface {
+	// Name identifies the provider in logs.
+	Name() string
+	// Latest returns rates for the given base currency.
+	Latest(ctx context.Context, base string) (*Rates, error)
+}
+
+// HTTP fetches rates from a configurable endpoint answering with JSON of the
+// form used by Frankfurter and exchangerate.host:
+//
+//	{"base": "USD", "date": "2024-01-31", "rates": {"EUR": 0.92, "GBP": 0.79}}
+//
+// The endpoint URL may contain {base}, replaced with the base currency.
+type HTTP struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTP creates an HTTP rates provider.
+func NewHTTP(endpoint string, timeout time.Duration) *HTTP {
+	return &HTTP{endpoint: endpoint, client: &http.Client{Timeout: timeout}}
+}
+
+func (h *HTTP) Name() string { return "http" }
+
+func (h *HTTP) Latest(ctx context.Context, base string) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.ReplaceAll(h.endpoint, "{base}", base), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rates provider returned status %d", resp.StatusCode)
+	}
+
+	var r Rates
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("decode rates: %w", err)
+	}
+	if r.Base == "" {
+		r.Base = base
+	}
+	if !strings.EqualFold(r.Base, base) || len(r.Rates) == 0 {
+		return nil, fmt.Errorf("rates provider returned no rates for %s", base)
+	}
+	r.Base = strings.ToUpper(r.Base)
+	return &r, nil
+}
@@ -0,0 +1,117 @@
+// Package currency converts amounts between currencies using daily exchange
+// rates from a configurable provider, cached in Redis.
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	xcurrency "golang.org/x/text/currency"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+)
+
+var (
+	// ErrInvalidCurrency is returned for codes that are not ISO 4217.
+	ErrInvalidCurrency = apperr.New(apperr.Validation, "invalid currency code")
+	// ErrNoRate is returned when the provider has no rate for a currency.
+	ErrNoRate = apperr.New(apperr.Validation, "no exchange rate for currency")
+)
+
+// cachePrefix namespaces cached rates in Redis; keys are per base and day.
+const cachePrefix = "currency:rates:"
+
+// Service converts amounts between currencies.
+type Service struct {
+	rdb      *redis.Client
+	provider Provider
+	base     string
+}
+
+// NewService creates a converter that fetches rates relative to the base
+// currency from the provider, at most once a day.
+func NewService(rdb *redis.Client, provider Provider, base string) *Service {
+	return &Service{rdb: rdb, provider: provider, base: strings.ToUpper(base)}
+}
+
+// FromConfig creates a converter using CURRENCY_RATES_URL and
+// CURRENCY_BASE.
+func FromConfig(cfg *config.Config, rdb *redis.Client) *Service {
+	return NewService(rdb, NewHTTP(cfg.CurrencyRatesURL, 10*time.Second), cfg.CurrencyBase)
+}
+
+// Normalize validates an ISO 4217 code and returns it in upper case.
+func Normalize(code string) (string, error) {
+	unit, err := xcurrency.ParseISO(strings.TrimSpace(code))
+	if err != nil {
+		return "", ErrInvalidCurrency
+	}
+	return unit.String(), nil
+}
+
+// Rates returns today's rates relative to the base currency.
+func (s *Service) Rates(ctx context.Context) (*Rates, error) {
+	key := cachePrefix + s.base + ":" + time.Now().UTC().Format("2006-01-02")
+	if raw, err := s.rdb.Get(ctx, key).Bytes(); err == nil {
+		var r Rates
+		if err := json.Unmarshal(raw, &r); err == nil {
+			return &r, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	r, err := s.provider.Latest(ctx, s.base)
+	if err != nil {
+		return nil, fmt.Errorf("%s rates: %w", s.provider.Name(), err)
+	}
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rdb.Set(ctx, key, raw, 48*time.Hour).Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// rate returns units of code per unit of the base currency.
+func (r *Rates) rate(code string) (float64, error) {
+	if code == r.Base {
+		return 1, nil
+	}
+	v, ok := r.Rates[code]
+	if !ok || v <= 0 {
+		return 0, fmt.Errorf("%w %s", ErrNoRate, code)
+	}
+	return v, nil
+}
+
+// Convert converts a whole-unit amount between currencies, rounding to the
+// nearest unit.
+func (s *Service) Convert(ctx context.Context, amount int64, from, to string) (int64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return amount, nil
+	}
+	r, err := s.Rates(ctx)
+	if err != nil {
+		return 0, err
+	}
+	fromRate, err := r.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := r.rate(to)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Round(float64(amount) / fromRate * toRate)), nil
+}
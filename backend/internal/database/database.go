@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/config"
+)
+
+// Open connects to PostgreSQL using the configured DATABASE_URL and verifies
+// the connection before returning it. Calls made with a context carrying a
+// Timer are timed.
+func Open(cfg *config.Config) (*sql.DB, error) {
+	connector, err := pq.NewConnector(cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(timedConnector{connector})
+
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+)
+
+// Timer accumulates the time spent in database calls made with a context
+// carrying it, for attributing DB time to a request or one part of it.
+// Timers nest: a call is counted by the innermost timer and the outermost
+// one, so a request's total includes the time of all its parts.
+type Timer struct {
+	root    *Timer
+	nanos   atomic.Int64
+	queries atomic.Int64
+}
+
+type timerKey struct{}
+
+// WithTimer returns a context that times the database calls made with it,
+// nested inside any timer ctx already carries.
+func WithTimer(ctx context.Context) (context.Context, *Timer) {
+	t := &Timer{}
+	if parent, ok := ctx.Value(timerKey{}).(*Timer); ok {
+		t.root = parent
+		if parent.root != nil {
+			t.root = parent.root
+		}
+	}
+	return context.WithValue(ctx, timerKey{}, t), t
+}
+
+// Elapsed is the total time spent in the timer's database calls so far.
+func (t *Timer) Elapsed() time.Duration {
+	return time.Duration(t.nanos.Load())
+}
+
+// Queries is the number of the timer's database calls so far.
+func (t *Timer) Queries() int {
+	return int(t.queries.Load())
+}
+
+func (t *Timer) add(d time.Duration, calls int64) {
+	t.nanos.Add(int64(d))
+	t.queries.Add(calls)
+	if t.root != nil {
+		t.root.nanos.Add(int64(d))
+		t.root.queries.Add(calls)
+	}
+}
+
+// observe charges the time since start to ctx's timer, if it has one.
+func observe(ctx context.Context, start time.Time, calls int64) {
+	if t, ok := ctx.Value(timerKey{}).(*Timer); ok {
+		t.add(time.Since(start), calls)
+	}
+}
+
+// timedConnector wraps the driver's connections so that queries, statements
+// and reading their rows are charged to the caller's Timer.
+type timedConnector struct {
+	driver.Connector
+}
+
+func (c timedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{Conn: conn}, nil
+}
+
+// timedConn forwards to lib/pq's connection, which implements all of the
+// context-aware driver interfaces.
+type timedConn struct {
+	driver.Conn
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	observe(ctx, start, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &timedRows{Rows: rows, ctx: ctx}, nil
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	defer observe(ctx, start, 1)
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{Stmt: stmt}, nil
+}
+
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *timedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+type timedStmt struct {
+	driver.Stmt
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args))
+	}
+	observe(ctx, start, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &timedRows{Rows: rows, ctx: ctx}, nil
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	defer observe(ctx, start, 1)
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	// pq's COPY statements only implement Exec.
+	return s.Stmt.Exec(values(args))
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	out := make([]driver.Value, len(args))
+	for i, a := range args {
+		out[i] = a.Value
+	}
+	return out
+}
+
+// timedRows charges fetching rows, which pq streams from the server as
+// they are read, to the query's timer.
+type timedRows struct {
+	driver.Rows
+	ctx context.Context
+}
+
+func (r *timedRows) Next(dest []driver.Value) error {
+	start := time.Now()
+	defer observe(r.ctx, start, 0)
+	return r.Rows.Next(dest)
+}
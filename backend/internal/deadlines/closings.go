@@ -0,0 +1,93 @@
+package deadlines
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/profile"
+)
+
+// closingHours are the points before a saved posting closes at which the
+// user is reminded to apply, furthest first.
+var closingHours = []int{72, 24}
+
+// RemindClosings reminds users to apply to saved postings that close within
+// three days, and again within a day. Like offer reminders, a posting that
+// crosses both points between runs only gets the later one, and snoozed
+// applications get none. It is intended to run from the scheduler at least
+// hourly.
+func (s *Service) RemindClosings(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.company, a.position, a.posting_deadline, a.status_link, a.user_id,
+			COALESCE(u.timezone, 'UTC'), COALESCE(u.locale, ''), a.posting_deadline_reminded_hours
+		FROM applications a
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.status = $1 AND a.snoozed_until IS NULL AND a.posting_deadline > CURRENT_TIMESTAMP
+			AND a.posting_deadline <= CURRENT_TIMESTAMP + make_interval(hours => $2)`,
+		models.StatusSaved, closingHours[0])
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var pending []*due
+	for rows.Next() {
+		d := &due{Deadline: &Deadline{Kind: KindPosting}}
+		var reminded sql.NullInt64
+		if err := rows.Scan(&d.ApplicationID, &d.Company, &d.Position, &d.Deadline.Deadline, &d.URL, &d.userID,
+			&d.timezone, &d.locale, &reminded); err != nil {
+			rows.Close()
+			return err
+		}
+		left := d.Deadline.Deadline.Sub(now)
+		d.HoursLeft = int(left.Hours())
+		for _, h := range closingHours {
+			if left <= time.Duration(h)*time.Hour {
+				d.hours = h
+			}
+		}
+		if !reminded.Valid || int64(d.hours) < reminded.Int64 {
+			pending = append(pending, d)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.remindClosing(ctx, d); err != nil {
+			log.Printf("Failed to send posting closing reminder for application %s: %v", d.ApplicationID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) remindClosing(ctx context.Context, d *due) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE applications SET posting_deadline_reminded_hours = $2
+		WHERE id = $1 AND (posting_deadline_reminded_hours IS NULL OR posting_deadline_reminded_hours > $2)`,
+		d.ApplicationID, d.hours)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	p := profile.Profile{Timezone: d.timezone, Locale: d.locale}
+	l := p.Localizer()
+	when := l.DateTime(d.Deadline.Deadline.In(p.Location()))
+	title := l.T("closing.title", d.Position, d.Company, remaining(l, d.HoursLeft))
+	body := l.T("closing.body", d.Position, d.Company, when)
+	if d.URL != nil && *d.URL != "" {
+		body += "\n\n" + l.T("closing.link", *d.URL)
+	}
+	return s.notifications.Notify(ctx, d.userID, notifications.KindPostingClosing, title, body)
+}
@@ -0,0 +1,63 @@
+package deadlines
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/ics"
+)
+
+// feedWindowDays is how far ahead the calendar feed lists deadlines.
+const feedWindowDays = 365
+
+// Register mounts the calendar feed route on the group, which must already
+// authenticate requests.
+func (s *Service) Register(rg *gin.RouterGroup) {
+	rg.GET("/calendar.ics", s.FeedHandler())
+}
+
+// FeedHandler serves the user's upcoming offer and posting deadlines as an
+// iCalendar feed for calendar apps to subscribe to. Each deadline is an
+// event ending at the deadline, so it shows up as the last moment to act.
+func (s *Service) FeedHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		userID := auth.UserID(c)
+		deadlines, err := s.Soon(ctx, userID, feedWindowDays)
+		if err != nil {
+			apperr.Respond(c, "Deadline feed", err)
+			return
+		}
+
+		l := s.notifications.Localizer(ctx, userID)
+		events := make([]*ics.Event, 0, len(deadlines))
+		for _, d := range deadlines {
+			e := &ics.Event{
+				UID:   d.Kind + "-" + d.ApplicationID + "@jobtracker",
+				Start: d.Deadline.Add(-30 * time.Minute),
+				End:   d.Deadline,
+			}
+			switch d.Kind {
+			case KindOffer:
+				e.Summary = l.T("feed.offer", d.Position, d.Company)
+			case KindPosting:
+				e.Summary = l.T("feed.closing", d.Position, d.Company)
+				if d.URL != nil {
+					e.Description = *d.URL
+				}
+			}
+			events = append(events, e)
+		}
+
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := ics.Write(c.Writer, l.T("feed.name"), events); err != nil {
+			log.Printf("Deadline feed for %s interrupted: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package deadlines tracks when offers must be answered and when saved job
+// postings stop taking applications. Offer reminders escalate as a deadline
+// approaches, and each one lists the user's other open applications, which
+// are worth asking to speed up before the offer lapses. Both kinds of
+// deadline are also published as a calendar feed.
+package deadlines
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/i18n"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/profile"
+)
+
+// reminderHours are the points before a deadline at which the user is
+// reminded, furthest first.
+var reminderHours = []int{7 * 24, 48, 24}
+
+// DefaultWindowDays is how far ahead Soon looks by default.
+const DefaultWindowDays = 7
+
+// maxAccelerate caps the other applications listed in a reminder.
+const maxAccelerate = 5
+
+// openStatuses are the statuses of applications still in progress, which a
+// pending offer could give leverage to accelerate.
+var openStatuses = []string{
+	models.StatusApplied, models.StatusUnderReview, models.StatusInterviewScheduled,
+	models.StatusInterviewComplete, models.StatusOffer,
+}
+
+// Deadline kinds.
+const (
+	KindOffer   = "offer"   // an offer awaiting the user's answer
+	KindPosting = "posting" // a saved posting that closes to applications
+)
+
+// Deadline is an offer awaiting the user's answer, or the closing date of
+// a posting the user saved but has not applied to.
+type Deadline struct {
+	Kind          string    `json:"kind"`
+	ApplicationID string    `json:"applicationId"`
+	Company       string    `json:"company"`
+	Position      string    `json:"position"`
+	Deadline      time.Time `json:"deadline"`
+	// HoursLeft is the whole hours until the deadline.
+	HoursLeft int `json:"hoursLeft"`
+	// URL is the posting to apply through, for posting deadlines.
+	URL *string `json:"url"`
+}
+
+// Service lists offer and posting deadlines and sends their reminders.
+type Service struct {
+	db            *sql.DB
+	notifications *notifications.Service
+}
+
+// NewService creates a deadline service.
+func NewService(db *sql.DB, notificationService *notifications.Service) *Service {
+	return &Service{db: db, notifications: notificationService}
+}
+
+// Soon returns the user's unanswered offers and saved postings due within
+// the given number of days, soonest first. Snoozed applications are left
+// out.
+func (s *Service) Soon(ctx context.Context, userID string, days int) ([]*Deadline, error) {
+	if days <= 0 {
+		days = DefaultWindowDays
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT $4::text, a.id, a.company, a.position, o.deadline, NULL::text
+		FROM application_offers o JOIN applications a ON a.id = o.application_id
+		WHERE a.user_id = $1 AND a.status = $2 AND a.snoozed_until IS NULL AND o.confirmed_at IS NOT NULL
+			AND o.deadline > CURRENT_TIMESTAMP AND o.deadline <= CURRENT_TIMESTAMP + make_interval(days => $3)
+		UNION ALL
+		SELECT $5::text, a.id, a.company, a.position, a.posting_deadline, a.status_link
+		FROM applications a
+		WHERE a.user_id = $1 AND a.status = $6 AND a.snoozed_until IS NULL
+			AND a.posting_deadline > CURRENT_TIMESTAMP AND a.posting_deadline <= CURRENT_TIMESTAMP + make_interval(days => $3)
+		ORDER BY 5`,
+		userID, models.StatusOffer, days, KindOffer, KindPosting, models.StatusSaved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	out := []*Deadline{}
+	for rows.Next() {
+		d := &Deadline{}
+		if err := rows.Scan(&d.Kind, &d.ApplicationID, &d.Company, &d.Position, &d.Deadline, &d.URL); err != nil {
+			return nil, err
+		}
+		d.HoursLeft = int(d.Deadline.Sub(now).Hours())
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// due is an offer that has crossed a reminder point it was not yet
+// reminded at.
+type due struct {
+	*Deadline
+	userID   string
+	timezone string
+	locale   string
+	hours    int
+}
+
+// Escalate sends the reminder for every offer that has crossed one of the
+// 7 day, 48 hour and 24 hour points since its last reminder. An offer
+// that crosses several points between runs only gets the latest one, and
+// snoozed applications get none until they resurface. It is
+// intended to run from the scheduler at least hourly.
+func (s *Service) Escalate(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.company, a.position, o.deadline, a.user_id, COALESCE(u.timezone, 'UTC'), COALESCE(u.locale, ''), o.deadline_reminded_hours
+		FROM application_offers o
+		JOIN applications a ON a.id = o.application_id
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.status = $1 AND a.snoozed_until IS NULL AND o.confirmed_at IS NOT NULL AND o.deadline > CURRENT_TIMESTAMP
+			AND o.deadline <= CURRENT_TIMESTAMP + make_interval(hours => $2)`,
+		models.StatusOffer, reminderHours[0])
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var pending []*due
+	for rows.Next() {
+		d := &due{Deadline: &Deadline{Kind: KindOffer}}
+		var reminded sql.NullInt64
+		if err := rows.Scan(&d.ApplicationID, &d.Company, &d.Position, &d.Deadline.Deadline, &d.userID, &d.timezone, &d.locale, &reminded); err != nil {
+			rows.Close()
+			return err
+		}
+		left := d.Deadline.Deadline.Sub(now)
+		d.HoursLeft = int(left.Hours())
+		for _, h := range reminderHours {
+			if left <= time.Duration(h)*time.Hour {
+				d.hours = h
+			}
+		}
+		if !reminded.Valid || int64(d.hours) < reminded.Int64 {
+			pending = append(pending, d)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range pending {
+		if err := s.remind(ctx, d); err != nil {
+			log.Printf("Failed to send offer deadline reminder for application %s: %v", d.ApplicationID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) remind(ctx context.Context, d *due) error {
+	// Claim the reminder first so concurrent or retried runs cannot send it
+	// twice.
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE application_offers SET deadline_reminded_hours = $2
+		WHERE application_id = $1 AND (deadline_reminded_hours IS NULL OR deadline_reminded_hours > $2)`,
+		d.ApplicationID, d.hours)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	others, err := s.accelerate(ctx, d.userID, d.ApplicationID)
+	if err != nil {
+		return err
+	}
+
+	p := profile.Profile{Timezone: d.timezone, Locale: d.locale}
+	l := p.Localizer()
+	when := l.DateTime(d.Deadline.Deadline.In(p.Location()))
+	title := l.T("deadline.title", d.Company, remaining(l, d.HoursLeft))
+	var b strings.Builder
+	b.WriteString(l.T("deadline.body", d.Position, d.Company, when))
+	if len(others) > 0 {
+		b.WriteString("\n\n" + l.T("deadline.others") + "\n")
+		for _, o := range others {
+			b.WriteString("\n" + l.T("deadline.other", o.position, o.company, l.Status(o.status)))
+		}
+	}
+	return s.notifications.Notify(ctx, d.userID, notifications.KindOfferDeadline, title, b.String())
+}
+
+type openApplication struct {
+	company, position, status string
+}
+
+// accelerate returns the user's other open applications, furthest along
+// and most recently active first.
+func (s *Service) accelerate(ctx context.Context, userID, exceptID string) ([]openApplication, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT company, position, status FROM applications
+		WHERE user_id = $1 AND id::text <> $2 AND status = ANY($3)
+		ORDER BY array_position($3, status) DESC, updated_at DESC
+		LIMIT $4`,
+		userID, exceptID, pq.Array(openStatuses), maxAccelerate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []openApplication
+	for rows.Next() {
+		var a openApplication
+		if err := rows.Scan(&a.company, &a.position, &a.status); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// remaining renders a countdown like "3 days" or "20 hours".
+func remaining(l *i18n.Localizer, hours int) string {
+	switch {
+	case hours >= 48:
+		return l.N("deadline.days", hours/24, hours/24)
+	case hours < 1:
+		return l.T("deadline.soon")
+	}
+	return l.N("deadline.hours", hours, hours)
+}
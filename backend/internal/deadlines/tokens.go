@@ -0,0 +1,157 @@
+package deadlines
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// feedTokenPrefix marks feed tokens, telling them apart from API keys and
+// share tokens.
+const feedTokenPrefix = "jtf_"
+
+// maxFeedTokens caps the active feed tokens per user.
+const maxFeedTokens = 10
+
+var (
+	// ErrInvalidFeedToken is returned for unknown or revoked feed tokens.
+	ErrInvalidFeedToken = apperr.New(apperr.Unauthenticated, "invalid feed token")
+	// ErrTooManyFeedTokens is returned when the user already has
+	// maxFeedTokens active tokens.
+	ErrTooManyFeedTokens = apperr.New(apperr.Conflict, "too many active feed tokens; revoke one first")
+)
+
+// FeedToken subscribes a calendar app to the deadline feed. Calendar apps
+// can only subscribe to a URL, so the token travels in it; it only reads
+// the feed and can be revoked on its own. Only a hash of it is stored.
+type FeedToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedFeedToken is returned once when a feed token is created; Token is
+// never shown again.
+type CreatedFeedToken struct {
+	FeedToken *FeedToken `json:"feedToken"`
+	Token     string     `json:"token"`
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateFeedToken issues a new feed token, named after the calendar app it
+// is for.
+func (s *Service) CreateFeedToken(ctx context.Context, userID, name string) (*CreatedFeedToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return nil, validation.Field("name", "must be 1 to 255 characters")
+	}
+	var active int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM feed_tokens WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID).Scan(&active); err != nil {
+		return nil, err
+	}
+	if active >= maxFeedTokens {
+		return nil, ErrTooManyFeedTokens
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := feedTokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	t := &FeedToken{Name: name, Prefix: token[:len(feedTokenPrefix)+6]}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO feed_tokens (user_id, name, prefix, token_hash) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		userID, t.Name, t.Prefix, hashToken(token)).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &CreatedFeedToken{FeedToken: t, Token: token}, nil
+}
+
+// FeedTokens returns the user's active feed tokens, newest first.
+func (s *Service) FeedTokens(ctx context.Context, userID string) ([]*FeedToken, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, prefix, last_used_at, created_at FROM feed_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []*FeedToken{}
+	for rows.Next() {
+		t := &FeedToken{}
+		if err := rows.Scan(&t.ID, &t.Name, &t.Prefix, &t.LastUsedAt, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// RevokeFeedToken disables a feed token. It reports false if no active
+// token matched.
+func (s *Service) RevokeFeedToken(ctx context.Context, userID, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE feed_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id::text = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// authenticateFeed returns the user of a plaintext feed token and records
+// its use.
+func (s *Service) authenticateFeed(ctx context.Context, token string) (string, error) {
+	if !strings.HasPrefix(token, feedTokenPrefix) {
+		return "", ErrInvalidFeedToken
+	}
+	var userID string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE feed_tokens SET last_used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND revoked_at IS NULL
+		RETURNING user_id`, hashToken(token)).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrInvalidFeedToken
+	}
+	return userID, err
+}
+
+// FeedMiddleware authenticates requests carrying a feed token as the
+// "token" query parameter, and rejects everything else. Feed tokens are
+// accepted nowhere else, so one leaked from a calendar's settings only
+// exposes the feed.
+func (s *Service) FeedMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := s.authenticateFeed(c.Request.Context(), c.Query("token"))
+		if err != nil {
+			apperr.Respond(c, "Feed token authentication", err)
+			return
+		}
+		auth.SetUserID(c, userID)
+		c.Next()
+	}
+}
@@ -0,0 +1,102 @@
+package demo
+
+import "github.com/jobtracker/backend/internal/models"
+
+// step is a status the application entered, in days after applying.
+type step struct {
+	status string
+	day    int
+}
+
+// fixture is one demo application and its timeline.
+type fixture struct {
+	company     string
+	domain      string
+	position    string
+	source      string
+	location    string
+	appliedAgo  int // days before the seed date
+	steps       []step
+	interviewIn int // days from the seed date of the next interview; 0 for none
+	offer       *offer
+	scheduling  string // booking link left as a pending action
+	referrer    string // who referred the user; empty for none
+	skills      string // asked for in the job description
+}
+
+type offer struct {
+	base, bonus, equity int64
+}
+
+// fixtures covers every stage of the funnel, several sources and a spread of
+// response times so analytics, the board and the timelines all have data.
+var fixtures = []fixture{
+	{company: "Stripe", domain: "stripe.com", position: "Software Engineer, Payments", source: "LinkedIn", location: "San Francisco, CA", skills: "Ruby, Java, SQL, AWS and Kafka", appliedAgo: 62,
+		steps: []step{{models.StatusUnderReview, 4}, {models.StatusInterviewScheduled, 11}, {models.StatusInterviewComplete, 25}, {models.StatusOffer, 40}},
+		offer: &offer{base: 185000, bonus: 20000, equity: 60000}},
+	{company: "Figma", domain: "figma.com", position: "Frontend Engineer", source: "Referral", location: "New York, NY", skills: "TypeScript, React, WebGL and C++", appliedAgo: 45,
+		steps: []step{{models.StatusUnderReview, 2}, {models.StatusInterviewScheduled, 6}, {models.StatusInterviewComplete, 20}}, referrer: "Priya Raman"},
+	{company: "Datadog", domain: "datadoghq.com", position: "Backend Engineer, Metrics", source: "Greenhouse", location: "Boston, MA", skills: "Go, Python, Kafka, Kubernetes and PostgreSQL", appliedAgo: 21,
+		steps: []step{{models.StatusUnderReview, 5}, {models.StatusInterviewScheduled, 12}}, interviewIn: 3},
+	{company: "Notion", domain: "makenotion.com", position: "Software Engineer Intern", source: "Lever", location: "Remote", skills: "TypeScript, React and Node.js", appliedAgo: 14,
+		steps: []step{{models.StatusUnderReview, 6}}, scheduling: "https://calendly.com/notion-recruiting/phone-screen"},
+	{company: "Airbnb", domain: "airbnb.com", position: "Senior Software Engineer", source: "Direct Application", location: "Seattle, WA", skills: "Java, Kotlin, GraphQL, React and AWS", appliedAgo: 38,
+		steps: []step{{models.StatusUnderReview, 9}, {models.StatusRejected, 17}}},
+	{company: "Shopify", domain: "shopify.com", position: "Developer, Checkout", source: "Indeed", location: "Remote", skills: "Ruby, Rails, React and MySQL", appliedAgo: 30,
+		steps: []step{{models.StatusRejected, 3}}},
+	{company: "Plaid", domain: "plaid.com", position: "Platform Engineer", source: "Recruiter", location: "San Francisco, CA", skills: "Go, Python, AWS, Terraform and Kubernetes", appliedAgo: 27,
+		steps: []step{{models.StatusUnderReview, 1}, {models.StatusInterviewScheduled, 4}}, interviewIn: 1},
+	{company: "Ramp", domain: "ramp.com", position: "Software Engineer, Infrastructure", source: "Y Combinator", location: "New York, NY", skills: "Python, PostgreSQL, AWS and Terraform", appliedAgo: 9},
+	{company: "Linear", domain: "linear.app", position: "Product Engineer", source: "Ashby", location: "Remote", skills: "TypeScript, React, Node.js and GraphQL", appliedAgo: 6},
+	{company: "Cloudflare", domain: "cloudflare.com", position: "Systems Engineer", source: "Workday", location: "Austin, TX", skills: "Rust, Go, Linux and C++", appliedAgo: 52,
+		steps: []step{{models.StatusUnderReview, 10}, {models.StatusInterviewScheduled, 19}, {models.StatusInterviewComplete, 30}, {models.StatusRejected, 36}}},
+	{company: "Vercel", domain: "vercel.com", position: "Software Engineer, Edge", source: "LinkedIn", location: "Remote", skills: "TypeScript, Node.js, Rust and Docker", appliedAgo: 18,
+		steps: []step{{models.StatusWithdrawn, 8}}},
+	{company: "Anduril", domain: "anduril.com", position: "Software Engineer", source: "Greenhouse", location: "Los Angeles, CA", skills: "C++, Rust, Linux and Python", appliedAgo: 3},
+	{company: "Duolingo", domain: "duolingo.com", position: "Software Engineer, Learning", source: "Referral", location: "Chicago, IL", skills: "Python, Kotlin, Swift and AWS", appliedAgo: 70,
+		steps: []step{{models.StatusUnderReview, 3}, {models.StatusInterviewScheduled, 8}, {models.StatusInterviewComplete, 22}, {models.StatusOffer, 31}, {models.StatusAccepted, 35}},
+		offer: &offer{base: 172000, bonus: 15000, equity: 45000}, referrer: "Marcus Lee"},
+	{company: "Retool", domain: "retool.com", position: "Full Stack Engineer", source: "AngelList", location: "San Francisco, CA", skills: "TypeScript, React, Node.js and PostgreSQL", appliedAgo: 11,
+		steps: []step{{models.StatusUnderReview, 7}}},
+}
+
+// jobDescription is the posting text stored with each application, from the
+// company, position and skills.
+const jobDescription = "%[1]s is hiring a %[2]s.\n\nWhat you'll bring:\n- Experience with %[3]s\n- A track record of shipping reliable software\n- Clear written communication"
+
+// emailTemplates are the subject and body of the email sent when an
+// application enters a status; %[1]s is the company and %[2]s the position.
+var emailTemplates = map[string][2]string{
+	models.StatusApplied: {
+		"Thank you for applying to %[1]s",
+		"Hi there,\n\nThank you for applying for the %[2]s position at %[1]s. We have received your application and our team will review it shortly.\n\nBest,\n%[1]s Recruiting",
+	},
+	models.StatusUnderReview: {
+		"Your %[1]s application is under review",
+		"Hi,\n\nQuick update: your application for %[2]s is now under review by the hiring team. We'll be in touch about next steps.\n\n%[1]s Talent Team",
+	},
+	models.StatusInterviewScheduled: {
+		"Interview invitation: %[2]s at %[1]s",
+		"Hi,\n\nWe'd love to move forward with an interview for the %[2]s role. Your interview has been scheduled; you'll find the details and video link in the attached calendar invite.\n\nLooking forward to speaking,\n%[1]s Recruiting",
+	},
+	models.StatusInterviewComplete: {
+		"Thanks for interviewing with %[1]s",
+		"Hi,\n\nThank you for taking the time to meet the team for the %[2]s position. We're gathering feedback and will follow up within a week.\n\n%[1]s Recruiting",
+	},
+	models.StatusOffer: {
+		"Offer letter: %[2]s at %[1]s",
+		"Hi,\n\nCongratulations! We are pleased to inform you that we'd like to offer you the %[2]s position at %[1]s. Please find your offer letter attached.\n\nWelcome aboard,\n%[1]s People Team",
+	},
+	models.StatusRejected: {
+		"Update on your %[1]s application",
+		"Hi,\n\nThank you for your interest in the %[2]s role. Unfortunately, we have decided to move forward with other candidates at this time. We encourage you to apply for future openings.\n\nBest wishes,\n%[1]s Recruiting",
+	},
+	models.StatusWithdrawn: {
+		"Re: %[2]s application",
+		"Hi,\n\nThanks for letting us know you'd like to withdraw your application for %[2]s. We wish you the best in your search.\n\n%[1]s Recruiting",
+	},
+	models.StatusAccepted: {
+		"Welcome to %[1]s!",
+		"Hi,\n\nWe're thrilled you've accepted our offer for the %[2]s position. Your onboarding details will follow shortly.\n\n%[1]s People Team",
+	},
+}
@@ -0,0 +1,290 @@
+// Package demo populates a realistic demo account so self-hosters and
+// frontend developers can exercise every screen without connecting a real
+// mailbox.
+package demo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/notifications"
+)
+
+// DefaultEmail is the address of the demo account.
+const DefaultEmail = "demo@jobtracker.local"
+
+// ErrRealAccount is returned when the target email belongs to a user who has
+// signed in with Google; seeding would wipe their data.
+var ErrRealAccount = apperr.New(apperr.Conflict, "refusing to seed an account that has signed in with Google")
+
+// Result summarizes what Seed created.
+type Result struct {
+	UserID       string
+	Applications int
+	Emails       int
+	Interviews   int
+}
+
+// Seed replaces the demo account's data with a fresh set of applications,
+// status histories, email timelines, interviews and their loops, offers, a
+// pending action, a goal and a notification, all dated relative to now.
+// Running it again resets the account.
+func Seed(ctx context.Context, db *sql.DB, email string) (*Result, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	userID, err := demoUser(ctx, tx, email)
+	if err != nil {
+		return nil, err
+	}
+	if err := reset(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	res := &Result{UserID: userID}
+	now := time.Now()
+	for i, f := range fixtures {
+		if err := seedApplication(ctx, tx, userID, email, i, f, now, res); err != nil {
+			return nil, fmt.Errorf("seed %s: %w", f.company, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO goals (user_id, metric, period, target, weekly_summary)
+		VALUES ($1, 'applications', 'week', 5, TRUE)`, userID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, kind, title, body, created_at)
+		VALUES ($1, $2, 'Weekly summary', 'You sent 3 of 5 applications this week. Keep going!', $3)`,
+		userID, notifications.KindGoalSummary, now.Add(-20*time.Hour)); err != nil {
+		return nil, err
+	}
+
+	return res, tx.Commit()
+}
+
+// demoUser creates the demo user or returns the existing one.
+func demoUser(ctx context.Context, tx *sql.Tx, email string) (string, error) {
+	var (
+		id       string
+		googleID sql.NullString
+	)
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO users (id, email, name)
+		VALUES (uuid_generate_v4()::text, LOWER($1), 'Demo User')
+		ON CONFLICT (email) DO UPDATE SET name = users.name
+		RETURNING id, google_id`, email).Scan(&id, &googleID)
+	if err != nil {
+		return "", err
+	}
+	if googleID.Valid {
+		return "", ErrRealAccount
+	}
+	return id, nil
+}
+
+// reset removes everything previously seeded for the user. Interviews,
+// actions, events, history and offers go with their applications.
+func reset(ctx context.Context, tx *sql.Tx, userID string) error {
+	for _, table := range []string{"applications", "email_cache", "goals", "notifications", "company_watches", "company_notes"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func seedApplication(ctx context.Context, tx *sql.Tx, userID, email string, n int, f fixture, now time.Time, res *Result) error {
+	applied := now.AddDate(0, 0, -f.appliedAgo).Add(-time.Duration(9+n%8) * time.Hour)
+	timeline := append([]step{{models.StatusApplied, 0}}, f.steps...)
+	last := timeline[len(timeline)-1]
+	updated := applied.AddDate(0, 0, last.day)
+	firstEmail := fmt.Sprintf("demo-%d-0", n)
+
+	var appID string
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO applications (user_id, company, position, applied_date, status, source, location,
+			status_link, email_id, job_description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id`,
+		userID, f.company, f.position, applied.Format("2006-01-02"), last.status, f.source, f.location,
+		"https://careers."+f.domain+"/applications/demo", firstEmail,
+		fmt.Sprintf(jobDescription, f.company, f.position, f.skills), applied, updated).Scan(&appID)
+	if err != nil {
+		return err
+	}
+	res.Applications++
+
+	// Replace the history row the trigger just wrote with the backdated timeline.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM application_status_history WHERE application_id = $1`, appID); err != nil {
+		return err
+	}
+
+	var previous string
+	for i, st := range timeline {
+		at := applied.AddDate(0, 0, st.day).Add(time.Duration(i) * 37 * time.Minute)
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO application_status_history (application_id, user_id, status, changed_at)
+			VALUES ($1, $2, $3, $4)`, appID, userID, st.status, at); err != nil {
+			return err
+		}
+
+		eventType, payload := models.EventApplicationCreated, map[string]string{"status": st.status}
+		if i > 0 {
+			eventType, payload = models.EventStatusChanged, map[string]string{"from": previous, "to": st.status}
+		}
+		body, _ := json.Marshal(payload)
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO application_events (application_id, user_id, event_type, payload, occurred_at)
+			VALUES ($1, $2, $3, $4, $5)`, appID, userID, eventType, body, at); err != nil {
+			return err
+		}
+		previous = st.status
+
+		tmpl := emailTemplates[st.status]
+		subject := fmt.Sprintf(tmpl[0], f.company, f.position)
+		text := fmt.Sprintf(tmpl[1], f.company, f.position)
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO email_cache (id, user_id, subject, sender, recipient, date, snippet, body_text,
+				labels, is_job_related, relevance_score, language, processed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, '{INBOX}', TRUE, 0.95, 'en', $6)`,
+			fmt.Sprintf("demo-%d-%d", n, i), userID, subject,
+			fmt.Sprintf("%s Recruiting <careers@%s>", f.company, f.domain), email,
+			at, snippet(text), text); err != nil {
+			return err
+		}
+		res.Emails++
+	}
+
+	if err := seedInterviews(ctx, tx, userID, appID, f, applied, timeline, now, res); err != nil {
+		return err
+	}
+	if err := seedLoop(ctx, tx, userID, appID, f, timeline, applied); err != nil {
+		return err
+	}
+
+	if f.offer != nil {
+		// Offers still awaiting an answer get a deadline inside the reminder window.
+		var deadline *time.Time
+		if last.status == models.StatusOffer {
+			d := now.AddDate(0, 0, 5)
+			deadline = &d
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO application_offers (application_id, base_salary, bonus, equity, deadline, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`, appID, f.offer.base, f.offer.bonus, f.offer.equity, deadline, updated); err != nil {
+			return err
+		}
+	}
+
+	if f.referrer != "" {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO application_referrals (application_id, referrer_name, state, asked_date, confirmed_date, updated_at)
+			VALUES ($1, $2, 'submitted', $3, $4, $3)`,
+			appID, f.referrer, applied.AddDate(0, 0, -7), applied.AddDate(0, 0, -5)); err != nil {
+			return err
+		}
+	}
+
+	if f.scheduling != "" {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO application_actions (application_id, user_id, kind, url, provider, created_at)
+			VALUES ($1, $2, 'schedule_interview', $3, 'Calendly', $4)`, appID, userID, f.scheduling, updated); err != nil {
+			return err
+		}
+	}
+
+	if last.status == models.StatusRejected {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO company_watches (user_id, company, careers_url, keywords, application_id, created_at)
+			VALUES ($1, $2, $3, '{engineer}', $4, $5)`, userID, f.company, "https://careers."+f.domain, appID, updated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedInterviews adds a completed interview for every interview round in the
+// timeline and an upcoming one when the fixture asks for it.
+func seedInterviews(ctx context.Context, tx *sql.Tx, userID, appID string, f fixture, applied time.Time, timeline []step, now time.Time, res *Result) error {
+	insert := func(title, status string, start time.Time) error {
+		start = time.Date(start.Year(), start.Month(), start.Day(), 17, 0, 0, 0, time.UTC)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO interviews (application_id, user_id, title, starts_at, ends_at, timezone, meeting_link, status)
+			VALUES ($1, $2, $3, $4, $5, 'America/New_York', $6, $7)`,
+			appID, userID, title, start, start.Add(45*time.Minute), "https://meet.google.com/demo-"+strings.ToLower(f.company), status)
+		if err == nil {
+			res.Interviews++
+		}
+		return err
+	}
+
+	for _, st := range timeline {
+		if st.status == models.StatusInterviewComplete {
+			if err := insert(f.company+" onsite", "completed", applied.AddDate(0, 0, st.day-1)); err != nil {
+				return err
+			}
+		}
+	}
+	if f.interviewIn > 0 {
+		return insert(f.company+" technical interview", "scheduled", now.AddDate(0, 0, f.interviewIn))
+	}
+	return nil
+}
+
+// seedLoop adds a three-round interview loop to applications that reached
+// the interview stage, with each round's status following the timeline.
+func seedLoop(ctx context.Context, tx *sql.Tx, userID, appID string, f fixture, timeline []step, applied time.Time) error {
+	reached := map[string]bool{}
+	for _, st := range timeline {
+		reached[st.status] = true
+	}
+	if !reached[models.StatusInterviewScheduled] {
+		return nil
+	}
+	last := timeline[len(timeline)-1].status
+	technical, onsite := "scheduled", "pending"
+	if reached[models.StatusInterviewComplete] {
+		technical = "passed"
+		switch {
+		case models.ReachedOffer(last):
+			onsite = "passed"
+		case last == models.StatusRejected:
+			onsite = "failed"
+		}
+	}
+
+	var loopID string
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO interview_loops (application_id, user_id, name, created_at)
+		VALUES ($1, $2, $3, $4) RETURNING id`,
+		appID, userID, f.company+" interview loop", applied).Scan(&loopID); err != nil {
+		return err
+	}
+	for i, round := range [][2]string{{"Recruiter screen", "passed"}, {"Technical screen", technical}, {"Onsite", onsite}} {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO interview_rounds (loop_id, position, name, status) VALUES ($1, $2, $3, $4)`,
+			loopID, i+1, round[0], round[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func snippet(body string) string {
+	text := strings.Join(strings.Fields(body), " ")
+	if len(text) > 120 {
+		text = text[:120]
+	}
+	return text
+}
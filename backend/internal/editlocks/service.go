@@ -0,0 +1,248 @@
+// Package editlocks provides advisory edit locks on applications, so two
+// people (or two of a user's tabs) do not overwrite each other's edits. A
+// client takes the lock when it starts editing and renews it while the
+// editor is open; locks expire on their own when the client goes away.
+// Everyone who can see the application is told over the realtime
+// WebSocket channel when it is locked or unlocked, e.g. to show "Coach Kim
+// is editing this application". Locks are advisory: writes are not refused
+// while one is held.
+package editlocks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/realtime"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// TTL is how long a lock lasts unless renewed. Clients renew about every
+// third of it.
+const TTL = time.Minute
+
+// Redis keys: the lock on an application, and the applications each
+// session holds locks on.
+const (
+	lockPrefix    = "editlocks:application:"
+	sessionPrefix = "editlocks:session:"
+)
+
+// ErrNotFound is returned when the application does not exist or the user
+// cannot see it.
+var ErrNotFound = apperr.New(apperr.NotFound, "application not found")
+
+// Lock is an edit lock on an application.
+type Lock struct {
+	ApplicationID string    `json:"applicationId"`
+	HolderID      string    `json:"holderId"`
+	HolderName    string    `json:"holderName"`
+	SessionID     string    `json:"-"`
+	AcquiredAt    time.Time `json:"acquiredAt"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	// Mine reports whether the requesting session holds the lock.
+	Mine bool `json:"mine"`
+}
+
+// held is the lock as stored in Redis.
+type held struct {
+	HolderID   string    `json:"holderId"`
+	HolderName string    `json:"holderName"`
+	SessionID  string    `json:"sessionId"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+}
+
+// acquireScript takes a free lock or renews the session's own, returning
+// the lock value, its remaining TTL in milliseconds and 1 if it was newly
+// taken.
+var acquireScript = redis.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if cur then
+	if cjson.decode(cur).sessionId ~= ARGV[2] then
+		return {cur, redis.call('PTTL', KEYS[1]), 0}
+	end
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	return {cur, tonumber(ARGV[3]), 0}
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+return {ARGV[1], tonumber(ARGV[3]), 1}`)
+
+var releaseScript = redis.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if cur and cjson.decode(cur).sessionId == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// Service hands out edit locks.
+type Service struct {
+	db  *sql.DB
+	rdb *redis.Client
+	bus *realtime.Service
+}
+
+// NewService creates an edit lock service.
+func NewService(db *sql.DB, rdb *redis.Client, bus *realtime.Service) *Service {
+	return &Service{db: db, rdb: rdb, bus: bus}
+}
+
+// audience returns who can see the application, failing with ErrNotFound
+// if userID cannot. Applications are only visible to their owner today;
+// this is where shared access is to be granted.
+func (s *Service) audience(ctx context.Context, userID, applicationID string) ([]string, error) {
+	var owner string
+	err := s.db.QueryRowContext(ctx, `SELECT user_id FROM applications WHERE id::text = $1`, applicationID).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && owner != userID {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []string{owner}, nil
+}
+
+// Acquire takes or renews the lock on an application for an editing
+// session (one per open editor, chosen by the client). When another session
+// holds the lock it is returned with Mine unset, and nothing changes.
+func (s *Service) Acquire(ctx context.Context, userID, applicationID, sessionID string) (*Lock, error) {
+	if sessionID == "" || len(sessionID) > 100 {
+		return nil, validation.Field("sessionId", "must be 1 to 100 characters")
+	}
+	audience, err := s.audience(ctx, userID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	var name string
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(NULLIF(name, ''), email) FROM users WHERE id = $1`, userID).Scan(&name); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(held{HolderID: userID, HolderName: name, SessionID: sessionID, AcquiredAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := acquireScript.Run(ctx, s.rdb, []string{lockPrefix + applicationID},
+		raw, sessionID, TTL.Milliseconds()).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("acquire edit lock: %w", err)
+	}
+	lock, err := decode(applicationID, res[0], res[1])
+	if err != nil {
+		return nil, err
+	}
+	lock.Mine = lock.SessionID == sessionID
+	if taken, _ := res[2].(int64); taken == 1 {
+		key := sessionPrefix + sessionID
+		pipe := s.rdb.TxPipeline()
+		pipe.SAdd(ctx, key, applicationID)
+		pipe.Expire(ctx, key, 24*time.Hour)
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Printf("Failed to record edit lock of session %s: %v", sessionID, err)
+		}
+		s.publish(ctx, audience, realtime.EventEditLockAcquired, lock)
+	}
+	return lock, nil
+}
+
+// Lock returns the current lock on an application, or nil if it is free.
+func (s *Service) Lock(ctx context.Context, userID, applicationID, sessionID string) (*Lock, error) {
+	if _, err := s.audience(ctx, userID, applicationID); err != nil {
+		return nil, err
+	}
+	key := lockPrefix + applicationID
+	pipe := s.rdb.Pipeline()
+	value := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	lock, err := decode(applicationID, value.Val(), ttl.Val().Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	lock.Mine = sessionID != "" && lock.SessionID == sessionID
+	return lock, nil
+}
+
+// Release gives up the session's lock on an application. It reports false
+// if the session did not hold it, e.g. because it expired.
+func (s *Service) Release(ctx context.Context, userID, applicationID, sessionID string) (bool, error) {
+	audience, err := s.audience(ctx, userID, applicationID)
+	if err != nil {
+		return false, err
+	}
+	return s.release(ctx, audience, applicationID, sessionID)
+}
+
+// ReleaseSession gives up every lock a session holds, for when its
+// WebSocket connection closes.
+func (s *Service) ReleaseSession(ctx context.Context, userID, sessionID string) error {
+	key := sessionPrefix + sessionID
+	ids, err := s.rdb.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		audience, err := s.audience(ctx, userID, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.release(ctx, audience, id, sessionID); err != nil {
+			return err
+		}
+	}
+	return s.rdb.Del(ctx, key).Err()
+}
+
+func (s *Service) release(ctx context.Context, audience []string, applicationID, sessionID string) (bool, error) {
+	n, err := releaseScript.Run(ctx, s.rdb, []string{lockPrefix + applicationID}, sessionID).Int()
+	if err != nil {
+		return false, fmt.Errorf("release edit lock: %w", err)
+	}
+	s.rdb.SRem(ctx, sessionPrefix+sessionID, applicationID)
+	if n == 0 {
+		return false, nil
+	}
+	s.publish(ctx, audience, realtime.EventEditLockReleased, map[string]string{"applicationId": applicationID})
+	return true, nil
+}
+
+func (s *Service) publish(ctx context.Context, audience []string, eventType string, payload interface{}) {
+	for _, userID := range audience {
+		if err := s.bus.Publish(ctx, userID, realtime.Event{Type: eventType, Payload: payload}); err != nil {
+			log.Printf("Failed to publish %s to user %s: %v", eventType, userID, err)
+		}
+	}
+}
+
+// decode turns a stored lock and its remaining TTL in milliseconds into a
+// Lock.
+func decode(applicationID string, value, ttl interface{}) (*Lock, error) {
+	raw, _ := value.(string)
+	var h held
+	if err := json.Unmarshal([]byte(raw), &h); err != nil {
+		return nil, fmt.Errorf("decode edit lock: %w", err)
+	}
+	ms, _ := ttl.(int64)
+	return &Lock{
+		ApplicationID: applicationID,
+		HolderID:      h.HolderID,
+		HolderName:    h.HolderName,
+		SessionID:     h.SessionID,
+		AcquiredAt:    h.AcquiredAt,
+		ExpiresAt:     time.Now().Add(time.Duration(ms) * time.Millisecond),
+	}, nil
+}
@@ -0,0 +1,307 @@
+// Package eventlog reads and extends the append-only event stream kept for
+// every application. A database trigger appends the full row when an
+// application is created and the changed columns on every update, so the
+// stream also covers writes made outside Go; callers describe why a change
+// happened by tagging their transaction with a Source. Replaying the stream
+// reconstructs the application, which answers "why is this application in
+// stage X?".
+package eventlog
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// Event types. Inserts are always EventCreated; updates take the type of
+// their Source and default to EventEdited.
+const (
+	EventCreated               = "created"
+	EventEdited                = "edited"
+	EventEmailIngested         = "email_ingested"
+	EventClassificationApplied = "classification_applied"
+	EventEmailSent             = "email_sent"
+	EventRebuilt               = "rebuilt"
+)
+
+// Actors that cause events.
+const (
+	ActorUser   = "user"
+	ActorSync   = "sync"
+	ActorSystem = "system"
+)
+
+var (
+	// ErrNotFound is returned when the application does not exist or
+	// belongs to another user.
+	ErrNotFound = apperr.New(apperr.NotFound, "application not found")
+	// ErrNoEvents is returned when an application has no recorded events.
+	ErrNoEvents = apperr.New(apperr.NotFound, "no events recorded for application")
+)
+
+// rebuildColumns are the application columns a rebuild may write back.
+var rebuildColumns = []string{
+	"company", "position", "applied_date", "status", "source", "location", "job_id",
+	"status_link", "notes", "email_id", "ats", "portal_url", "resume_id", "snoozed_until",
+	"job_description", "withdrawal_reason", "tags", "custom_fields", "alias",
+}
+
+// Source describes why the changes in a transaction were made.
+type Source struct {
+	Type  string
+	Actor string
+	// Ref identifies what caused the change, such as an email or job ID.
+	Ref string
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a context whose event log writes record the client
+// IP address of the request that caused them.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the client IP address stored by WithClientIP, or "".
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// Tag labels the application changes that tx makes before it commits.
+func Tag(ctx context.Context, tx *sql.Tx, src Source) error {
+	_, err := tx.ExecContext(ctx, `
+		SELECT set_config('jobtracker.event_type', $1, true),
+			set_config('jobtracker.event_actor', $2, true),
+			set_config('jobtracker.event_ref', $3, true),
+			set_config('jobtracker.event_client_ip', $4, true)`,
+		src.Type, src.Actor, src.Ref, ClientIP(ctx))
+	return err
+}
+
+// Within runs fn in a transaction tagged with src.
+func Within(ctx context.Context, db *sql.DB, src Source, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := Tag(ctx, tx, src); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Event is one entry of an application's stream.
+type Event struct {
+	Seq        int                        `json:"seq"`
+	Type       string                     `json:"type"`
+	Actor      string                     `json:"actor"`
+	Ref        *string                    `json:"ref"`
+	ClientIP   *string                    `json:"clientIp"` // set for changes made through the API
+	Changes    map[string]json.RawMessage `json:"changes"`
+	Details    map[string]json.RawMessage `json:"details"`
+	OccurredAt time.Time                  `json:"occurredAt"`
+}
+
+// Service reads, appends to and replays event streams.
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates an event log service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Append records an event that does not change the application row, such
+// as an email about it being ingested, with free-form details.
+func (s *Service) Append(ctx context.Context, userID, applicationID string, src Source, details map[string]interface{}) error {
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the application so concurrent appends get distinct sequence numbers.
+	var id string
+	err = tx.QueryRowContext(ctx,
+		`SELECT id FROM applications WHERE id = $1 AND user_id = $2 FOR UPDATE`, applicationID, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO application_event_stream (application_id, user_id, seq, event_type, actor, ref, details, client_ip)
+		SELECT $1, $2, COALESCE(MAX(seq), 0) + 1, $3, $4, NULLIF($5, ''), $6, NULLIF($7, '')::inet
+		FROM application_event_stream WHERE application_id = $1`,
+		applicationID, userID, src.Type, src.Actor, src.Ref, raw, ClientIP(ctx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Owner returns the ID of the user the application belongs to, for
+// administrative tools that only know the application.
+func (s *Service) Owner(ctx context.Context, applicationID string) (string, error) {
+	var userID string
+	err := s.db.QueryRowContext(ctx, `SELECT user_id FROM applications WHERE id::text = $1`, applicationID).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return userID, err
+}
+
+// Events returns the application's stream in order.
+func (s *Service) Events(ctx context.Context, userID, applicationID string) ([]*Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT seq, event_type, actor, ref, host(client_ip), changes, details, occurred_at
+		FROM application_event_stream
+		WHERE application_id = $1 AND user_id = $2
+		ORDER BY seq`,
+		applicationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		e := &Event{}
+		var changes, details []byte
+		if err := rows.Scan(&e.Seq, &e.Type, &e.Actor, &e.Ref, &e.ClientIP, &changes, &details, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, fmt.Errorf("event %d: %w", e.Seq, err)
+		}
+		if details != nil {
+			if err := json.Unmarshal(details, &e.Details); err != nil {
+				return nil, fmt.Errorf("event %d: %w", e.Seq, err)
+			}
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	return events, nil
+}
+
+// Replay folds events into the column values they produce.
+func Replay(events []*Event) map[string]json.RawMessage {
+	state := map[string]json.RawMessage{}
+	for _, e := range events {
+		for column, value := range e.Changes {
+			state[column] = value
+		}
+	}
+	return state
+}
+
+// Drift is a column whose stored value differs from the replayed one.
+type Drift struct {
+	Column   string
+	Stored   json.RawMessage
+	Replayed json.RawMessage
+}
+
+// Rebuild is the result of reconstructing an application from its stream.
+type Rebuild struct {
+	Events []*Event
+	State  map[string]json.RawMessage
+	Drift  []Drift
+}
+
+// Rebuild replays the application's stream and compares the result with the
+// stored row. With apply set, drifted columns are overwritten with the
+// replayed values, which appends an EventRebuilt event.
+func (s *Service) Rebuild(ctx context.Context, userID, applicationID string, apply bool) (*Rebuild, error) {
+	events, err := s.Events(ctx, userID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	r := &Rebuild{Events: events, State: Replay(events)}
+
+	var raw []byte
+	err = s.db.QueryRowContext(ctx,
+		`SELECT to_jsonb(a) FROM applications a WHERE id = $1 AND user_id = $2`, applicationID, userID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, err
+	}
+
+	for _, column := range rebuildColumns {
+		replayed, ok := r.State[column]
+		if !ok {
+			continue
+		}
+		if !sameJSON(stored[column], replayed) {
+			r.Drift = append(r.Drift, Drift{Column: column, Stored: stored[column], Replayed: replayed})
+		}
+	}
+	sort.Slice(r.Drift, func(i, j int) bool { return r.Drift[i].Column < r.Drift[j].Column })
+	if !apply || len(r.Drift) == 0 {
+		return r, nil
+	}
+
+	patch := map[string]json.RawMessage{}
+	assignments := make([]string, len(r.Drift))
+	for i, d := range r.Drift {
+		patch[d.Column] = d.Replayed
+		assignments[i] = d.Column + " = r." + d.Column
+	}
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	err = Within(ctx, s.db, Source{Type: EventRebuilt, Actor: ActorSystem}, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE applications a SET `+strings.Join(assignments, ", ")+`
+			FROM jsonb_populate_record(NULL::applications, $3) r
+			WHERE a.id = $1 AND a.user_id = $2`,
+			applicationID, userID, body)
+		return err
+	})
+	return r, err
+}
+
+func sameJSON(a, b json.RawMessage) bool {
+	var x, y interface{}
+	if len(a) == 0 {
+		a = json.RawMessage("null")
+	}
+	if len(b) == 0 {
+		b = json.RawMessage("null")
+	}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(x, y)
+}
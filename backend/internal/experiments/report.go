@@ -0,0 +1,183 @@
+package experiments
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/jobtracker/backend/internal/admin"
+)
+
+const (
+	// defaultCorrectionDays is how long after a trial a user's status
+	// change counts as correcting the classification.
+	defaultCorrectionDays = 14
+	// minReviewed is how many trials must have a user status change before
+	// a leading variant is named.
+	minReviewed = 20
+	// reportDisagreements caps the disagreement pairs listed.
+	reportDisagreements = 10
+)
+
+// Report compares the two variants of an experiment.
+type Report struct {
+	Experiment *Experiment `json:"experiment"`
+	// Trials counts sampled emails; ChallengerFailures those the
+	// challenger could not classify, which are left out of the rates.
+	Trials             int `json:"trials"`
+	ChallengerFailures int `json:"challengerFailures"`
+	// AgreementRate is the share of trials where both variants gave the
+	// same job-related verdict and status.
+	AgreementRate float64         `json:"agreementRate"`
+	Control       *VariantStats   `json:"control"`
+	Challenger    *VariantStats   `json:"challenger"`
+	Disagreements []*Disagreement `json:"disagreements"` // most frequent first
+	// Reviewed counts trials whose application the user later set a
+	// status on, within CorrectionDays.
+	Reviewed       int `json:"reviewed"`
+	CorrectionDays int `json:"correctionDays"`
+	// Leader is the variant users corrected less often, once Reviewed is
+	// large enough to tell; nil before then or on a tie.
+	Leader *string `json:"leader"`
+}
+
+// VariantStats are one variant's results over an experiment's trials.
+type VariantStats struct {
+	Variant        string  `json:"variant"`
+	JobRelatedRate float64 `json:"jobRelatedRate"`
+	AvgConfidence  float64 `json:"avgConfidence"`
+	// Corrections counts the reviewed trials where the status the user set
+	// differs from the variant's.
+	Corrections    int     `json:"corrections"`
+	CorrectionRate float64 `json:"correctionRate"` // of reviewed trials
+}
+
+// Disagreement counts trials where the variants gave these two statuses;
+// an empty status means not job related.
+type Disagreement struct {
+	ControlStatus    string `json:"controlStatus"`
+	ChallengerStatus string `json:"challengerStatus"`
+	Count            int    `json:"count"`
+}
+
+type variantTotals struct {
+	jobRelated  int
+	confidence  float64
+	corrections int
+}
+
+func (t *variantTotals) add(jobRelated bool, status sql.NullString, confidence sql.NullFloat64, userStatus sql.NullString) {
+	if jobRelated {
+		t.jobRelated++
+	}
+	t.confidence += confidence.Float64
+	if userStatus.Valid && userStatus.String != status.String {
+		t.corrections++
+	}
+}
+
+func (t *variantTotals) stats(variant string, trials, reviewed int) *VariantStats {
+	v := &VariantStats{Variant: variant, Corrections: t.corrections}
+	if trials > 0 {
+		v.JobRelatedRate = float64(t.jobRelated) / float64(trials)
+		v.AvgConfidence = t.confidence / float64(trials)
+	}
+	if reviewed > 0 {
+		v.CorrectionRate = float64(t.corrections) / float64(reviewed)
+	}
+	return v
+}
+
+// Report compares an experiment's variants, for administrators only. A
+// trial counts as corrected for a variant when the user set the status of
+// the email's application to something else within correctionDays
+// (default 14).
+func (s *Service) Report(ctx context.Context, adminID, id string, correctionDays int) (*Report, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	e, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if correctionDays <= 0 || correctionDays > 90 {
+		correctionDays = defaultCorrectionDays
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.control_job_related, t.control_status, t.control_confidence,
+			t.challenger_job_related, t.challenger_status, t.challenger_confidence,
+			(SELECT es.changes->>'status'
+			 FROM email_cache c
+			 JOIN application_event_stream es ON es.application_id = c.application_id
+			 WHERE c.id = t.email_id AND es.actor = 'user' AND es.changes ? 'status'
+			   AND es.occurred_at >= t.created_at
+			   AND es.occurred_at < t.created_at + make_interval(days => $2)
+			 ORDER BY es.occurred_at DESC LIMIT 1)
+		FROM classification_trials t
+		WHERE t.experiment_id = $1`, e.ID, correctionDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	r := &Report{Experiment: e, CorrectionDays: correctionDays, Disagreements: []*Disagreement{}}
+	var control, challenger variantTotals
+	var agreed, compared int
+	pairs := make(map[[2]string]int)
+	for rows.Next() {
+		var controlJob bool
+		var challengerJob sql.NullBool
+		var controlStatus, challengerStatus, userStatus sql.NullString
+		var controlConf, challengerConf sql.NullFloat64
+		if err := rows.Scan(&controlJob, &controlStatus, &controlConf,
+			&challengerJob, &challengerStatus, &challengerConf, &userStatus); err != nil {
+			return nil, err
+		}
+		r.Trials++
+		if !challengerJob.Valid {
+			r.ChallengerFailures++
+			continue
+		}
+		compared++
+		if userStatus.Valid {
+			r.Reviewed++
+		}
+		control.add(controlJob, controlStatus, controlConf, userStatus)
+		challenger.add(challengerJob.Bool, challengerStatus, challengerConf, userStatus)
+		if controlJob == challengerJob.Bool && controlStatus.String == challengerStatus.String {
+			agreed++
+		} else {
+			pairs[[2]string{controlStatus.String, challengerStatus.String}]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if compared > 0 {
+		r.AgreementRate = float64(agreed) / float64(compared)
+	}
+	r.Control = control.stats(e.ControlVariant, compared, r.Reviewed)
+	r.Challenger = challenger.stats(e.ChallengerVariant, compared, r.Reviewed)
+	if r.Reviewed >= minReviewed && control.corrections != challenger.corrections {
+		leader := e.ControlVariant
+		if challenger.corrections < control.corrections {
+			leader = e.ChallengerVariant
+		}
+		r.Leader = &leader
+	}
+
+	for pair, n := range pairs {
+		r.Disagreements = append(r.Disagreements, &Disagreement{ControlStatus: pair[0], ChallengerStatus: pair[1], Count: n})
+	}
+	sort.Slice(r.Disagreements, func(i, j int) bool {
+		a, b := r.Disagreements[i], r.Disagreements[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.ControlStatus+"\x00"+a.ChallengerStatus < b.ControlStatus+"\x00"+b.ChallengerStatus
+	})
+	r.Disagreements = r.Disagreements[:min(len(r.Disagreements), reportDisagreements)]
+	return r, nil
+}
@@ -0,0 +1,307 @@
+// Package experiments runs A/B tests of email classification variants, a
+// prompt and model the agents service classifies with. While an experiment
+// runs, production keeps classifying with the control variant and a sample
+// of emails is also classified with the challenger; comparing the two
+// results, and how often users corrected the status afterwards, tells an
+// administrator which variant to promote.
+package experiments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// DefaultVariant is the production variant until one is promoted.
+const DefaultVariant = "keywords"
+
+// cacheTTL is how long the production variant and running experiment are
+// cached between emails.
+const cacheTTL = time.Minute
+
+var (
+	// ErrNotFound is returned when an experiment does not exist.
+	ErrNotFound = apperr.New(apperr.NotFound, "classification experiment not found")
+	// ErrRunning is returned when starting an experiment while another runs.
+	ErrRunning = apperr.New(apperr.Conflict, "another classification experiment is running; stop it first")
+	// ErrInvalidVariant is returned for variants the agents service does
+	// not know.
+	ErrInvalidVariant = apperr.New(apperr.Validation, `variant must be "keywords", "llm" or "llm@<model>"`)
+	// ErrNotVariant is returned when promoting a variant the experiment did
+	// not test.
+	ErrNotVariant = apperr.New(apperr.Validation, "variant is not one the experiment tested")
+)
+
+// variantPattern matches the variants of ClassifyEmailRequest.variant.
+var variantPattern = regexp.MustCompile(`^(keywords|llm(@[A-Za-z0-9._:-]+)?)$`)
+
+// Experiment compares a challenger classification variant to the control.
+type Experiment struct {
+	ID                string     `json:"id"`
+	Name              string     `json:"name"`
+	ControlVariant    string     `json:"controlVariant"`
+	ChallengerVariant string     `json:"challengerVariant"`
+	SamplePercent     int        `json:"samplePercent"`
+	StartedAt         time.Time  `json:"startedAt"`
+	StoppedAt         *time.Time `json:"stoppedAt"`
+	PromotedVariant   *string    `json:"promotedVariant"`
+	PromotedAt        *time.Time `json:"promotedAt"`
+	Trials            int        `json:"trials"`
+}
+
+// StartInput starts an experiment.
+type StartInput struct {
+	Name              string `json:"name" validate:"required,max=255"`
+	ChallengerVariant string `json:"challengerVariant" validate:"required"`
+	// SamplePercent of emails are classified with both variants.
+	SamplePercent int `json:"samplePercent" validate:"min=1,max=100"`
+}
+
+// Service manages experiments and samples emails into the running one.
+type Service struct {
+	db *sql.DB
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	current  string      // production variant
+	running  *Experiment // nil when none runs
+}
+
+// NewService creates an experiment service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+const columns = `e.id, e.name, e.control_variant, e.challenger_variant, e.sample_percent, e.started_at,
+	e.stopped_at, e.promoted_variant, e.promoted_at,
+	(SELECT COUNT(*) FROM classification_trials t WHERE t.experiment_id = e.id)`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*Experiment, error) {
+	e := &Experiment{}
+	err := row.Scan(&e.ID, &e.Name, &e.ControlVariant, &e.ChallengerVariant, &e.SamplePercent, &e.StartedAt,
+		&e.StoppedAt, &e.PromotedVariant, &e.PromotedAt, &e.Trials)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return e, err
+}
+
+// List returns every experiment, newest first, for administrators only.
+func (s *Service) List(ctx context.Context, adminID string) ([]*Experiment, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT `+columns+` FROM classification_experiments e ORDER BY e.started_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []*Experiment{}
+	for rows.Next() {
+		e, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Start runs an experiment of a challenger against the production variant.
+// Only one experiment runs at a time.
+func (s *Service) Start(ctx context.Context, adminID string, in StartInput) (*Experiment, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	in.ChallengerVariant = strings.TrimSpace(in.ChallengerVariant)
+	if !variantPattern.MatchString(in.ChallengerVariant) {
+		return nil, ErrInvalidVariant
+	}
+	control, err := s.production(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if in.ChallengerVariant == control {
+		return nil, validation.Field("challengerVariant", "must differ from the production variant "+control)
+	}
+
+	var id string
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO classification_experiments (name, control_variant, challenger_variant, sample_percent, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		strings.TrimSpace(in.Name), control, in.ChallengerVariant, in.SamplePercent, adminID).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return s.get(ctx, id)
+}
+
+// Stop ends an experiment without promoting either variant.
+func (s *Service) Stop(ctx context.Context, adminID, id string) (*Experiment, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE classification_experiments SET stopped_at = CURRENT_TIMESTAMP
+		WHERE id::text = $1 AND stopped_at IS NULL`, id); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return s.get(ctx, id)
+}
+
+// Promote makes one of the experiment's variants the production variant,
+// stopping the experiment if it still runs.
+func (s *Service) Promote(ctx context.Context, adminID, id, variant string) (*Experiment, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	e, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if variant != e.ControlVariant && variant != e.ChallengerVariant {
+		return nil, ErrNotVariant
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE classification_experiments SET promoted_variant = $2, promoted_at = CURRENT_TIMESTAMP,
+			stopped_at = COALESCE(stopped_at, CURRENT_TIMESTAMP)
+		WHERE id = $1`, e.ID, variant); err != nil {
+		return nil, err
+	}
+	log.Printf("Classification variant %s promoted from experiment %s by %s", variant, e.ID, adminID)
+	s.invalidate()
+	return s.get(ctx, id)
+}
+
+func (s *Service) get(ctx context.Context, id string) (*Experiment, error) {
+	return scan(s.db.QueryRowContext(ctx, `SELECT `+columns+` FROM classification_experiments e WHERE e.id::text = $1`, id))
+}
+
+// production is the most recently promoted variant.
+func (s *Service) production(ctx context.Context) (string, error) {
+	var variant string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT promoted_variant FROM classification_experiments
+		WHERE promoted_at IS NOT NULL ORDER BY promoted_at DESC LIMIT 1`).Scan(&variant)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultVariant, nil
+	}
+	return variant, err
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// state returns the production variant and running experiment, reloading
+// them once the cache is stale. When the database is unavailable the last
+// known state is kept.
+func (s *Service) state(ctx context.Context) (string, *Experiment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) < cacheTTL {
+		return s.current, s.running
+	}
+	current, err := s.production(ctx)
+	if err != nil {
+		log.Printf("Failed to load the production classification variant: %v", err)
+		return s.current, s.running
+	}
+	running, err := scan(s.db.QueryRowContext(ctx, `SELECT `+columns+` FROM classification_experiments e WHERE e.stopped_at IS NULL`))
+	if errors.Is(err, ErrNotFound) {
+		running, err = nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed to load the running classification experiment: %v", err)
+		return s.current, s.running
+	}
+	s.current, s.running, s.loadedAt = current, running, time.Now()
+	return current, running
+}
+
+// Variants implements agents.Experiment: emails are classified with the
+// production variant, and a stable sample of them, by message ID, with the
+// running experiment's challenger as well.
+func (s *Service) Variants(ctx context.Context, email *agentspb.Email) (string, string) {
+	current, running := s.state(ctx)
+	if current == "" {
+		current = DefaultVariant
+	}
+	if running == nil || email.GetId() == "" {
+		return current, ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(running.ID + ":" + email.GetId()))
+	if int(h.Sum32()%100) >= running.SamplePercent {
+		return current, ""
+	}
+	return current, running.ChallengerVariant
+}
+
+// Record implements agents.Experiment, storing a sampled email's results
+// as a trial of the running experiment. challengerResult is nil when the
+// challenger failed.
+func (s *Service) Record(ctx context.Context, email *agentspb.Email, result, challengerResult *agentspb.ClassifyEmailResponse) {
+	_, running := s.state(ctx)
+	if running == nil {
+		return
+	}
+	var userID *string
+	if id, ok := auth.UserIDFromContext(ctx); ok {
+		userID = &id
+	}
+	var jobRelated *bool
+	var status *string
+	var confidence *float32
+	if challengerResult != nil {
+		jobRelated, confidence = &challengerResult.JobRelated, &challengerResult.Confidence
+		status = nullable(challengerResult.Status)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO classification_trials (experiment_id, email_id, user_id,
+			control_job_related, control_status, control_confidence,
+			challenger_job_related, challenger_status, challenger_confidence)
+		VALUES ($1, $2, COALESCE($3, (SELECT user_id FROM email_cache WHERE id = $2)), $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (experiment_id, email_id) DO NOTHING`,
+		running.ID, email.GetId(), userID, result.JobRelated, nullable(result.Status), result.Confidence,
+		jobRelated, status, confidence); err != nil {
+		log.Printf("Failed to record classification trial of email %s: %v", email.GetId(), err)
+	}
+}
+
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
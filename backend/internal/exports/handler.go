@@ -0,0 +1,39 @@
+package exports
+
+import (
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+)
+
+// Register mounts the export download route on the group, which must
+// already authenticate requests.
+func (s *Service) Register(rg *gin.RouterGroup) {
+	rg.GET("/:id/file", s.DownloadHandler())
+}
+
+// DownloadHandler serves the spreadsheet of a completed export.
+func (s *Service) DownloadHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		e, f, err := s.Open(c.Request.Context(), auth.UserID(c), c.Param("id"))
+		if err != nil {
+			apperr.Respond(c, "Export download", err)
+			return
+		}
+		defer f.Close()
+
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(e.path)}))
+		c.Status(http.StatusOK)
+		if _, err := io.Copy(c.Writer, f); err != nil {
+			log.Printf("Export download of %s interrupted: %v", e.ID, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package exports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// processStage is reported while the agents service builds the spreadsheet.
+const processStage = "processing"
+
+// Result is the outcome of processApplications.
+type Result struct {
+	ExportID              string   `json:"exportId"`
+	Success               bool     `json:"success"`
+	Message               string   `json:"message"`
+	FilePath              *string  `json:"filePath"`
+	ApplicationsFound     int      `json:"applicationsFound"`
+	ApplicationsProcessed int      `json:"applicationsProcessed"`
+	Errors                []string `json:"errors"`
+}
+
+// processResponse is the body of the agents service's /process answer.
+type processResponse struct {
+	Success               bool     `json:"success"`
+	TotalEmailsFound      int      `json:"total_emails_found"`
+	ApplicationsProcessed int      `json:"applications_processed"`
+	Errors                []string `json:"errors"`
+	OutputFile            string   `json:"output_file"`
+	Summary               string   `json:"summary"`
+}
+
+// Process runs an export for processApplications: it records the export,
+// has the agents service build the spreadsheet from the Request, and
+// finishes the export with the outcome. It waits for the spreadsheet;
+// progress and the outcome are also streamed like any export's.
+func (s *Service) Process(ctx context.Context, userID string, in StartInput) (*Result, error) {
+	req, err := s.Request(ctx, userID, in)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var id string
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO processing_jobs (user_id, start_date, end_date, output_path, kind, status, current_stage)
+		VALUES ($1, $2, $3, $4, 'export', $5, $6)
+		RETURNING id`,
+		userID, req.StartDate, req.EndDate, req.OutputFilePath, StatusProcessing, processStage).Scan(&id); err != nil {
+		return nil, err
+	}
+	if e, err := s.Get(ctx, userID, id); err == nil {
+		s.publish(ctx, e)
+	}
+
+	resp, err := s.process(ctx, body)
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		if ferr := s.Finish(ctx, id, []string{"the agents service could not build the spreadsheet"}); ferr != nil {
+			return nil, ferr
+		}
+		return nil, err
+	}
+	if err := s.Progress(ctx, id, 100, processStage, resp.TotalEmailsFound, resp.ApplicationsProcessed); err != nil {
+		return nil, err
+	}
+	// Errors of a successful run are about single emails; the spreadsheet
+	// is still complete.
+	var failures []string
+	if !resp.Success {
+		failures = resp.Errors
+		if len(failures) == 0 {
+			failures = []string{"the agents service could not build the spreadsheet"}
+		}
+	}
+	if err := s.Finish(ctx, id, failures); err != nil {
+		return nil, err
+	}
+
+	e, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{
+		ExportID:              id,
+		Success:               e.Status == StatusCompleted,
+		Message:               resp.Summary,
+		ApplicationsFound:     e.ApplicationsFound,
+		ApplicationsProcessed: e.ApplicationsProcessed,
+		Errors:                resp.Errors,
+	}
+	if res.Success && resp.OutputFile != "" {
+		res.FilePath = &resp.OutputFile
+	}
+	return res, nil
+}
+
+// process sends a request to the agents service's /process endpoint.
+func (s *Service) process(ctx context.Context, body []byte) (*processResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(s.cfg.AgentsServiceURL, "/")+"/process", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.UpstreamLLM, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperr.Wrap(apperr.UpstreamLLM, fmt.Errorf("agents service returned status %d", resp.StatusCode))
+	}
+	var out processResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, apperr.Wrap(apperr.UpstreamLLM, fmt.Errorf("decode agents response: %w", err))
+	}
+	return &out, nil
+}
@@ -0,0 +1,78 @@
+package exports
+
+import (
+	"context"
+
+	"github.com/jobtracker/backend/internal/analytics"
+	"github.com/jobtracker/backend/internal/companies"
+	"github.com/jobtracker/backend/internal/quotas"
+	"github.com/jobtracker/backend/internal/resumes"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// StartInput is the processApplications input.
+type StartInput struct {
+	StartDate         string  `json:"startDate" validate:"required,datetime=2006-01-02"`
+	EndDate           *string `json:"endDate" validate:"omitempty,datetime=2006-01-02"`
+	OutputPath        string  `json:"outputPath" validate:"required"`
+	OverwriteExisting bool    `json:"overwriteExisting"`
+	// IncludeAnalytics adds the Analytics sheet; nil means true.
+	IncludeAnalytics *bool `json:"includeAnalytics"`
+}
+
+// Request is the body of the agents service's /process call, which builds
+// the spreadsheet. Everything the backend knows and the agents service does
+// not, the analytics, resumes and company dossiers, travels with it.
+type Request struct {
+	StartDate      string                `json:"start_date"`
+	EndDate        *string               `json:"end_date,omitempty"`
+	OutputFilePath string                `json:"output_file_path"`
+	AppendMode     bool                  `json:"append_mode"`
+	Analytics      *analytics.Snapshot   `json:"analytics,omitempty"`
+	Timezone       string                `json:"timezone,omitempty"`
+	Locale         string                `json:"locale,omitempty"`
+	Resumes        []resumes.ExportRow   `json:"resumes,omitempty"`
+	Companies      []companies.ExportRow `json:"companies,omitempty"`
+}
+
+// Request builds the agents service request for an export of the user's
+// applications, written in the timezone and locale of their profile. The
+// analytics snapshot covers the export's date range.
+// The run syncs the user's mailbox before writing the spreadsheet, so it is
+// refused once the user has used up their exports or stored emails quota.
+func (s *Service) Request(ctx context.Context, userID string, in StartInput) (*Request, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	if err := s.quotas.Check(ctx, userID, quotas.MetricExports, 1); err != nil {
+		return nil, err
+	}
+	if err := s.quotas.Check(ctx, userID, quotas.MetricStoredEmails, 0); err != nil {
+		return nil, err
+	}
+	req := &Request{
+		StartDate:      in.StartDate,
+		EndDate:        in.EndDate,
+		OutputFilePath: in.OutputPath,
+		AppendMode:     !in.OverwriteExisting,
+	}
+	p, err := s.profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	req.Timezone, req.Locale = p.Timezone, p.Locale
+	if in.IncludeAnalytics == nil || *in.IncludeAnalytics {
+		snapshot, err := s.analytics.Snapshot(ctx, userID, analytics.DateRange{StartDate: &in.StartDate, EndDate: in.EndDate})
+		if err != nil {
+			return nil, err
+		}
+		req.Analytics = snapshot
+	}
+	if req.Resumes, err = s.resumes.ExportRows(ctx, userID); err != nil {
+		return nil, err
+	}
+	if req.Companies, err = s.companies.ExportRows(ctx, userID); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
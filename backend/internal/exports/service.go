@@ -0,0 +1,313 @@
+// Package exports runs spreadsheet exports through the agents service and
+// tracks them as records users can follow: progress is streamed over the
+// realtime WebSocket channel while the processing pipeline runs, running
+// exports can be cancelled, and past exports are kept with their download
+// link until the exports retention rule purges the file.
+package exports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/analytics"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/companies"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/profile"
+	"github.com/jobtracker/backend/internal/quotas"
+	"github.com/jobtracker/backend/internal/realtime"
+	"github.com/jobtracker/backend/internal/resumes"
+	"github.com/jobtracker/backend/internal/retention"
+	"github.com/jobtracker/backend/internal/storage"
+)
+
+const contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Export statuses, stored on processing_jobs.
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusCancelled  = "cancelled"
+)
+
+var (
+	// ErrNotFound is returned when an export does not exist or belongs to
+	// another user.
+	ErrNotFound = apperr.New(apperr.NotFound, "export not found")
+	// ErrNotCancellable is returned when cancelling an export that already
+	// finished.
+	ErrNotCancellable = apperr.New(apperr.Conflict, "export has already finished")
+	// ErrNotReady is returned when downloading an export that has not
+	// completed.
+	ErrNotReady = apperr.New(apperr.Conflict, "export has not completed")
+	// ErrExpired is returned when downloading an export whose file was
+	// purged.
+	ErrExpired = apperr.New(apperr.NotFound, "export has expired")
+	// ErrCancelled is returned to the pipeline reporting progress on an
+	// export the user cancelled; it should stop and clean up.
+	ErrCancelled = apperr.New(apperr.Conflict, "export was cancelled")
+)
+
+// Export is one run of the processing pipeline and the spreadsheet it
+// produces.
+type Export struct {
+	ID                    string     `json:"id"`
+	UserID                string     `json:"-"`
+	Status                string     `json:"status"`
+	Progress              int        `json:"progress"` // percent
+	Stage                 *string    `json:"stage"`
+	StartDate             string     `json:"startDate"`
+	EndDate               *string    `json:"endDate"`
+	ApplicationsFound     int        `json:"applicationsFound"`
+	ApplicationsProcessed int        `json:"applicationsProcessed"`
+	Errors                []string   `json:"errors"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	CompletedAt           *time.Time `json:"completedAt"`
+	// ExpiresAt is when the retention rule purges the file; nil while
+	// running or when exports are kept forever.
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Expired   bool       `json:"expired"`
+	// DownloadURL is set once the export completed, until it expires.
+	DownloadURL *string `json:"downloadUrl"`
+
+	path string
+}
+
+// Service tracks exports.
+type Service struct {
+	cfg       *config.Config
+	db        *sql.DB
+	bus       *realtime.Service
+	files     storage.Store
+	analytics *analytics.Service
+	resumes   *resumes.Service
+	companies *companies.Service
+	quotas    *quotas.Service
+	profiles  *profile.Service
+	client    *http.Client
+}
+
+// NewService creates an export service serving spreadsheets from files.
+// The analytics, resume, company and profile services supply what Request
+// sends along to the agents service; Request checks the user's quotas
+// first.
+func NewService(cfg *config.Config, db *sql.DB, bus *realtime.Service, files storage.Store,
+	analyticsService *analytics.Service, resumeService *resumes.Service, companyService *companies.Service,
+	quotaService *quotas.Service, profileService *profile.Service) *Service {
+	return &Service{cfg: cfg, db: db, bus: bus, files: files, analytics: analyticsService, resumes: resumeService,
+		companies: companyService, quotas: quotaService, profiles: profileService, client: &http.Client{}}
+}
+
+// query selects exports with their expiry under the user's exports
+// retention rule. $1 is the rule name and $2 its default days.
+const query = `
+	SELECT j.id, j.user_id, j.status, COALESCE(j.progress, 0), j.current_stage,
+		to_char(j.start_date, 'YYYY-MM-DD'), to_char(j.end_date, 'YYYY-MM-DD'),
+		COALESCE(j.applications_found, 0), COALESCE(j.applications_processed, 0), COALESCE(j.errors, '{}'),
+		j.created_at, j.completed_at,
+		CASE WHEN j.completed_at IS NOT NULL AND COALESCE(o.days, $2) > 0
+			THEN j.completed_at + make_interval(days => COALESCE(o.days, $2)) END,
+		j.export_purged_at IS NOT NULL, j.output_path
+	FROM processing_jobs j
+	LEFT JOIN retention_overrides o ON o.user_id = j.user_id AND o.rule = $1`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (s *Service) scan(row scanner) (*Export, error) {
+	e := &Export{}
+	err := row.Scan(&e.ID, &e.UserID, &e.Status, &e.Progress, &e.Stage, &e.StartDate, &e.EndDate,
+		&e.ApplicationsFound, &e.ApplicationsProcessed, pq.Array(&e.Errors),
+		&e.CreatedAt, &e.CompletedAt, &e.ExpiresAt, &e.Expired, &e.path)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.ExpiresAt != nil && !e.ExpiresAt.After(time.Now()) {
+		e.Expired = true
+	}
+	if e.Status == StatusCompleted && !e.Expired {
+		url := s.cfg.PublicURL + "/api/v1/exports/" + e.ID + "/file"
+		e.DownloadURL = &url
+	}
+	return e, nil
+}
+
+// List returns the user's exports, newest first.
+func (s *Service) List(ctx context.Context, userID string, limit int) ([]*Export, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	rows, err := s.db.QueryContext(ctx, query+`
+		WHERE j.user_id = $3
+		ORDER BY j.created_at DESC
+		LIMIT $4`,
+		retention.RuleExports, s.cfg.ExportRetentionDays, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Export
+	for rows.Next() {
+		e, err := s.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Get returns one of the user's exports.
+func (s *Service) Get(ctx context.Context, userID, id string) (*Export, error) {
+	return s.scan(s.db.QueryRowContext(ctx, query+`
+		WHERE j.id::text = $3 AND j.user_id = $4`,
+		retention.RuleExports, s.cfg.ExportRetentionDays, id, userID))
+}
+
+// Cancel stops an export. A pending export is cancelled at once; a running
+// one when the pipeline next reports progress.
+func (s *Service) Cancel(ctx context.Context, userID, id string) (*Export, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE processing_jobs SET cancel_requested_at = CURRENT_TIMESTAMP,
+			status = CASE WHEN status = 'pending' THEN 'cancelled' ELSE status END,
+			completed_at = CASE WHEN status = 'pending' THEN CURRENT_TIMESTAMP END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id::text = $1 AND user_id = $2 AND status IN ('pending', 'processing')`, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := res.RowsAffected()
+	e, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNotCancellable
+	}
+	s.publish(ctx, e)
+	return e, nil
+}
+
+// Progress records how far the pipeline got with an export and streams it
+// to the user. It returns ErrCancelled once the user cancelled the export,
+// which is then marked cancelled.
+func (s *Service) Progress(ctx context.Context, id string, percent int, stage string, found, processed int) error {
+	var userID string
+	var cancelled bool
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE processing_jobs SET
+			status = CASE WHEN cancel_requested_at IS NULL THEN 'processing' ELSE 'cancelled' END,
+			progress = CASE WHEN cancel_requested_at IS NULL THEN $2 ELSE progress END,
+			current_stage = $3, applications_found = $4, applications_processed = $5,
+			completed_at = CASE WHEN cancel_requested_at IS NOT NULL THEN CURRENT_TIMESTAMP END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id::text = $1 AND status IN ('pending', 'processing')
+		RETURNING user_id, cancel_requested_at IS NOT NULL`,
+		id, min(max(percent, 0), 100), stage, found, processed).Scan(&userID, &cancelled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if e, err := s.Get(ctx, userID, id); err == nil {
+		s.publish(ctx, e)
+	}
+	if cancelled {
+		return ErrCancelled
+	}
+	return nil
+}
+
+// Finish marks an export completed, or failed with the given errors, and
+// streams the outcome to the user. The pipeline writes the spreadsheet to
+// the local disk; a completed one is moved to the export store first, so it
+// outlives the replica, and the export fails if that is not possible.
+func (s *Service) Finish(ctx context.Context, id string, failures []string) error {
+	var path string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT output_path FROM processing_jobs
+		WHERE id::text = $1 AND status IN ('pending', 'processing')`, id).Scan(&path)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if len(failures) == 0 && path != "" {
+		key := filepath.Base(path)
+		if err := storage.Import(ctx, s.files, key, path, contentType); err != nil {
+			log.Printf("Failed to store spreadsheet of export %s: %v", id, err)
+			failures = append(failures, "the spreadsheet could not be stored")
+		} else {
+			path = key
+		}
+	}
+
+	status := StatusCompleted
+	if len(failures) > 0 {
+		status = StatusFailed
+	}
+	var userID string
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE processing_jobs SET status = $2, errors = $3, output_path = $4,
+			progress = CASE WHEN $2 = 'completed' THEN 100 ELSE progress END,
+			completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id::text = $1 AND status IN ('pending', 'processing')
+		RETURNING user_id`,
+		id, status, pq.Array(failures), path).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if e, err := s.Get(ctx, userID, id); err == nil {
+		s.publish(ctx, e)
+	}
+	return nil
+}
+
+func (s *Service) publish(ctx context.Context, e *Export) {
+	ev := realtime.Event{Type: realtime.EventExportProgress, Payload: e}
+	if err := s.bus.Publish(ctx, e.UserID, ev); err != nil {
+		log.Printf("Failed to publish progress of export %s: %v", e.ID, err)
+	}
+}
+
+// Open returns a completed export and its file. The caller closes the file.
+func (s *Service) Open(ctx context.Context, userID, id string) (*Export, io.ReadCloser, error) {
+	e, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.Status != StatusCompleted {
+		return nil, nil, ErrNotReady
+	}
+	if e.Expired {
+		return nil, nil, ErrExpired
+	}
+	f, err := s.files.Open(ctx, e.path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrExpired
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, f, nil
+}
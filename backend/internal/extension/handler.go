@@ -0,0 +1,204 @@
+// Package extension serves the compact REST endpoints used by the browser
+// extension. All routes are authenticated with an API key.
+package extension
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/postings"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Stages lists the statuses the extension may offer, in pipeline order.
+// Saved puts the posting on the wishlist without applying.
+var Stages = []string{
+	models.StatusSaved,
+	models.StatusApplied,
+	models.StatusUnderReview,
+	models.StatusInterviewScheduled,
+	models.StatusInterviewComplete,
+	models.StatusOffer,
+	models.StatusRejected,
+	models.StatusWithdrawn,
+	models.StatusAccepted,
+}
+
+// Handler serves the extension API.
+type Handler struct {
+	applications *applications.Service
+	postings     *postings.Service
+}
+
+// New creates an extension handler.
+func New(applicationService *applications.Service, postingService *postings.Service) *Handler {
+	return &Handler{applications: applicationService, postings: postingService}
+}
+
+// Register mounts the extension routes on the group.
+func (h *Handler) Register(rg *gin.RouterGroup) {
+	rg.POST("/applications", h.QuickAdd())
+	rg.GET("/applications/lookup", h.Lookup())
+	rg.POST("/applications/:id/applied", h.MarkApplied())
+	rg.GET("/stages", h.Stages())
+}
+
+type quickAddRequest struct {
+	URL      string  `json:"url" validate:"omitempty,http_url,max=2048"`
+	Company  string  `json:"company" validate:"max=255"`
+	Position string  `json:"position" validate:"max=1000"`
+	Location *string `json:"location" validate:"omitempty,max=255"`
+	Status   string  `json:"status"`
+	Source   string  `json:"source" validate:"max=255"`
+	Notes    *string `json:"notes" validate:"omitempty,max=10000"`
+}
+
+type lookupQuery struct {
+	Company  string `form:"company" validate:"required,max=255"`
+	Position string `form:"position" validate:"max=1000"`
+}
+
+type applicationSummary struct {
+	ID          string `json:"id"`
+	Company     string `json:"company"`
+	Position    string `json:"position"`
+	Status      string `json:"status"`
+	AppliedDate string `json:"appliedDate"`
+}
+
+func summarize(a *models.Application) applicationSummary {
+	return applicationSummary{ID: a.ID, Company: a.Company, Position: a.Position, Status: a.Status, AppliedDate: a.AppliedDate}
+}
+
+// QuickAdd creates an application from the page the user is viewing. Any
+// fields the extension could not scrape are filled by capturing the page URL,
+// which also keeps the posting's description for skill analytics.
+func (h *Handler) QuickAdd() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req quickAddRequest
+		if err := validation.BindJSON(c, &req); err != nil {
+			apperr.Respond(c, "Extension quick-add", err)
+			return
+		}
+		if req.Status != "" && !validStage(req.Status) {
+			apperr.Respond(c, "Extension quick-add", validation.Field("status", "must be one of the stages from /stages"))
+			return
+		}
+
+		var description *string
+		var deadline *time.Time
+		if req.URL != "" {
+			p, err := h.postings.Capture(c.Request.Context(), req.URL)
+			if err == nil {
+				req.Company = firstNonEmpty(req.Company, p.Company)
+				req.Position = firstNonEmpty(req.Position, p.Title)
+				req.Source = firstNonEmpty(req.Source, p.Source)
+				if req.Location == nil {
+					req.Location = p.Location
+				}
+				if p.Description != "" {
+					description = &p.Description
+				}
+				deadline = p.Deadline
+			} else {
+				log.Printf("Extension quick-add: capture %s failed: %v", req.URL, err)
+			}
+		}
+		in := applications.Input{
+			Company:         strings.TrimSpace(req.Company),
+			Position:        strings.TrimSpace(req.Position),
+			Status:          req.Status,
+			Source:          firstNonEmpty(req.Source, "Browser Extension"),
+			Location:        req.Location,
+			Notes:           req.Notes,
+			JobDescription:  description,
+			PostingDeadline: deadline,
+		}
+		if req.URL != "" {
+			in.StatusLink = &req.URL
+		}
+
+		app, err := h.applications.Create(c.Request.Context(), auth.UserID(c), in)
+		if err != nil {
+			apperr.Respond(c, "Extension quick-add", err)
+			return
+		}
+		c.JSON(http.StatusCreated, summarize(app))
+	}
+}
+
+// Lookup reports whether the user already tracks an application to a
+// company, optionally for a specific role.
+func (h *Handler) Lookup() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var q lookupQuery
+		if err := validation.BindQuery(c, &q); err != nil {
+			apperr.Respond(c, "Extension lookup", err)
+			return
+		}
+		var position *string
+		if p := strings.TrimSpace(q.Position); p != "" {
+			position = &p
+		}
+
+		apps, err := h.applications.FindByCompany(c.Request.Context(), auth.UserID(c), strings.TrimSpace(q.Company), position)
+		if err != nil && !errors.Is(err, applications.ErrNotFound) {
+			apperr.Respond(c, "Extension lookup", err)
+			return
+		}
+
+		matches := make([]applicationSummary, 0, len(apps))
+		for _, a := range apps {
+			matches = append(matches, summarize(a))
+		}
+		c.JSON(http.StatusOK, gin.H{"exists": len(matches) > 0, "matches": matches})
+	}
+}
+
+// MarkApplied moves a saved application to Applied once the user has
+// submitted it on the page.
+func (h *Handler) MarkApplied() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		app, err := h.applications.MarkApplied(c.Request.Context(), auth.UserID(c), c.Param("id"))
+		if err != nil {
+			apperr.Respond(c, "Extension mark applied", err)
+			return
+		}
+		c.JSON(http.StatusOK, summarize(app))
+	}
+}
+
+// Stages lists the statuses an application can be created with.
+func (h *Handler) Stages() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "private, max-age=3600")
+		c.JSON(http.StatusOK, gin.H{"stages": Stages, "default": models.StatusApplied})
+	}
+}
+
+func validStage(status string) bool {
+	for _, s := range Stages {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
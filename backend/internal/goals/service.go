@@ -0,0 +1,302 @@
+package goals
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/board"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/profile"
+)
+
+// Goal metrics.
+const (
+	MetricApplications = "applications"
+	MetricFollowUps    = "follow_ups"
+	MetricInterviews   = "interviews"
+)
+
+// Goal periods.
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+// streakLookback is how many past periods are scanned when computing streaks.
+const streakLookback = 52
+
+// ErrInvalidGoal is returned when a goal input has an unknown metric or
+// period or a non-positive target.
+var ErrInvalidGoal = apperr.New(apperr.Validation, "invalid goal")
+
+// Goal is a user-defined activity target such as "10 applications/week".
+type Goal struct {
+	ID            string    `json:"id"`
+	Metric        string    `json:"metric"`
+	Period        string    `json:"period"`
+	Target        int       `json:"target"`
+	WeeklySummary bool      `json:"weeklySummary"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// GoalInput creates or replaces the goal for a metric and period.
+type GoalInput struct {
+	Metric        string `json:"metric"`
+	Period        string `json:"period"`
+	Target        int    `json:"target"`
+	WeeklySummary bool   `json:"weeklySummary"`
+}
+
+// GoalProgress reports how a goal is tracking in the current period.
+type GoalProgress struct {
+	Goal          *Goal     `json:"goal"`
+	PeriodStart   time.Time `json:"periodStart"`
+	PeriodEnd     time.Time `json:"periodEnd"`
+	Current       int       `json:"current"`
+	Completion    float64   `json:"completion"`
+	CurrentStreak int       `json:"currentStreak"`
+	LongestStreak int       `json:"longestStreak"`
+}
+
+// Service manages goals and computes their progress from application data.
+// Periods follow the user's timezone, so a "day" ends at their midnight.
+type Service struct {
+	db            *sql.DB
+	notifications *notifications.Service
+	profiles      *profile.Service
+	board         *board.Service
+}
+
+// NewService creates a goal service. Weekly summaries include what changed
+// on the board.
+func NewService(db *sql.DB, notifications *notifications.Service, profiles *profile.Service, boardService *board.Service) *Service {
+	return &Service{db: db, notifications: notifications, profiles: profiles, board: boardService}
+}
+
+func validate(in GoalInput) error {
+	switch in.Metric {
+	case MetricApplications, MetricFollowUps, MetricInterviews:
+	default:
+		return fmt.Errorf("%w: unknown metric %q", ErrInvalidGoal, in.Metric)
+	}
+	switch in.Period {
+	case PeriodDay, PeriodWeek:
+	default:
+		return fmt.Errorf("%w: unknown period %q", ErrInvalidGoal, in.Period)
+	}
+	if in.Target <= 0 {
+		return fmt.Errorf("%w: target must be positive", ErrInvalidGoal)
+	}
+	return nil
+}
+
+// SetGoal creates a goal, replacing any existing goal for the same metric and period.
+func (s *Service) SetGoal(ctx context.Context, userID string, in GoalInput) (*Goal, error) {
+	if err := validate(in); err != nil {
+		return nil, err
+	}
+
+	g := &Goal{Metric: in.Metric, Period: in.Period, Target: in.Target, WeeklySummary: in.WeeklySummary}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO goals (user_id, metric, period, target, weekly_summary)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, metric, period)
+		DO UPDATE SET target = EXCLUDED.target, weekly_summary = EXCLUDED.weekly_summary
+		RETURNING id, created_at`,
+		userID, in.Metric, in.Period, in.Target, in.WeeklySummary,
+	).Scan(&g.ID, &g.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// DeleteGoal removes a goal. It reports false if the goal was not found.
+func (s *Service) DeleteGoal(ctx context.Context, userID, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM goals WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *Service) listGoals(ctx context.Context, userID string) ([]*Goal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, metric, period, target, weekly_summary, created_at
+		FROM goals WHERE user_id = $1
+		ORDER BY period, metric`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Goal
+	for rows.Next() {
+		g := &Goal{}
+		if err := rows.Scan(&g.ID, &g.Metric, &g.Period, &g.Target, &g.WeeklySummary, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// Goals returns every goal of the user with its current progress and streaks.
+func (s *Service) Goals(ctx context.Context, userID string) ([]*GoalProgress, error) {
+	goals, err := s.listGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := s.profiles.Location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	out := make([]*GoalProgress, 0, len(goals))
+	for _, g := range goals {
+		p, err := s.progress(ctx, userID, g, now)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (s *Service) progress(ctx context.Context, userID string, g *Goal, now time.Time) (*GoalProgress, error) {
+	start := periodStart(g.Period, now)
+	since := start
+	for i := 0; i < streakLookback; i++ {
+		since = previousPeriod(g.Period, since)
+	}
+
+	daily, err := s.dailyCounts(ctx, userID, g.Metric, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// counts[0] is the current period, counts[i] is i periods ago.
+	counts := make([]int, streakLookback+1)
+	for day, n := range daily {
+		for i, ps := 0, start; i <= streakLookback; i, ps = i+1, previousPeriod(g.Period, ps) {
+			if !day.Before(ps) {
+				counts[i] += n
+				break
+			}
+		}
+	}
+
+	p := &GoalProgress{
+		Goal:        g,
+		PeriodStart: start,
+		PeriodEnd:   nextPeriod(g.Period, start),
+		Current:     counts[0],
+		Completion:  float64(counts[0]) / float64(g.Target),
+	}
+	if p.Completion > 1 {
+		p.Completion = 1
+	}
+
+	// The current period only extends a streak once it has been met; an
+	// unfinished period does not break it.
+	first := 1
+	if counts[0] >= g.Target {
+		first = 0
+	}
+	for i := first; i < len(counts) && counts[i] >= g.Target; i++ {
+		p.CurrentStreak++
+	}
+	run := 0
+	for _, n := range counts {
+		if n >= g.Target {
+			run++
+			if run > p.LongestStreak {
+				p.LongestStreak = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return p, nil
+}
+
+// dailyCounts returns the number of metric occurrences per calendar day
+// since the given time: applications by applied date, follow-ups by when
+// they were sent from the user's Gmail and interviews by when they were
+// scheduled. Days are taken in since's location.
+func (s *Service) dailyCounts(ctx context.Context, userID, metric string, since time.Time) (map[time.Time]int, error) {
+	loc := since.Location()
+	var rows *sql.Rows
+	var err error
+	switch metric {
+	case MetricApplications:
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT applied_date, COUNT(*)
+			FROM applications
+			WHERE user_id = $1 AND applied_date >= $2::date AND status <> $3
+			GROUP BY applied_date`,
+			userID, since.Format("2006-01-02"), models.StatusSaved)
+	case MetricFollowUps:
+		// Every email sent through the user's Gmail keeps its send slot.
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT (reserved_at AT TIME ZONE $3)::date, COUNT(*)
+			FROM email_sends
+			WHERE user_id = $1 AND reserved_at >= $2
+			GROUP BY 1`,
+			userID, since, loc.String())
+	case MetricInterviews:
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT (created_at AT TIME ZONE $3)::date, COUNT(*)
+			FROM interviews
+			WHERE user_id = $1 AND created_at >= $2 AND status <> 'cancelled'
+			GROUP BY 1`,
+			userID, since, loc.String())
+	default:
+		return nil, fmt.Errorf("%w: unknown metric %q", ErrInvalidGoal, metric)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[time.Time]int)
+	for rows.Next() {
+		var day time.Time
+		var n int
+		if err := rows.Scan(&day, &n); err != nil {
+			return nil, err
+		}
+		out[time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)] += n
+	}
+	return out, rows.Err()
+}
+
+// periodStart returns the start of the day or ISO week (Monday) containing t.
+func periodStart(period string, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if period == PeriodDay {
+		return day
+	}
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func nextPeriod(period string, start time.Time) time.Time {
+	if period == PeriodDay {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+func previousPeriod(period string, start time.Time) time.Time {
+	if period == PeriodDay {
+		return start.AddDate(0, 0, -1)
+	}
+	return start.AddDate(0, 0, -7)
+}
@@ -0,0 +1,98 @@
+package goals
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/board"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/profile"
+)
+
+// Weekly summaries go out on Sunday evening in each user's timezone.
+const (
+	summaryDay  = time.Sunday
+	summaryHour = 18
+)
+
+// SendWeeklySummaries notifies every user who opted in with a recap of
+// their goal progress. It is intended to run hourly from the scheduler and
+// reaches each user when it is Sunday evening where they are.
+func (s *Service) SendWeeklySummaries(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT g.user_id, COALESCE(u.timezone, 'UTC')
+		FROM goals g LEFT JOIN users u ON u.id = g.user_id
+		WHERE g.weekly_summary
+			AND NOT EXISTS (
+				SELECT 1 FROM notifications n
+				WHERE n.user_id = g.user_id AND n.kind = $1
+					AND n.created_at > CURRENT_TIMESTAMP - INTERVAL '1 day'
+			)`,
+		notifications.KindGoalSummary)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var userIDs []string
+	for rows.Next() {
+		var id, tz string
+		if err := rows.Scan(&id, &tz); err != nil {
+			rows.Close()
+			return err
+		}
+		p := profile.Profile{Timezone: tz}
+		if local := now.In(p.Location()); local.Weekday() == summaryDay && local.Hour() == summaryHour {
+			userIDs = append(userIDs, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err := s.sendWeeklySummary(ctx, userID); err != nil {
+			log.Printf("Failed to send goal summary to user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) sendWeeklySummary(ctx context.Context, userID string) error {
+	progress, err := s.Goals(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	prof, err := s.profiles.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	l := prof.Localizer()
+
+	var lines []string
+	for _, p := range progress {
+		if !p.Goal.WeeklySummary {
+			continue
+		}
+		lines = append(lines, l.T("goals.line",
+			l.T("goals.metric."+p.Goal.Metric), p.Current, p.Goal.Target, l.T("goals.period."+p.Goal.Period),
+			p.Completion*100, p.CurrentStreak))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	now := time.Now().In(prof.Location())
+	week, err := s.board.Diff(ctx, userID, now.AddDate(0, 0, -7).Format("2006-01-02"), now.Format("2006-01-02"))
+	if err != nil {
+		log.Printf("Failed to diff board for goal summary of user %s: %v", userID, err)
+	} else if changes := board.Summary(l, week); changes != "" {
+		lines = append(lines, "", l.T("goals.board"), changes)
+	}
+
+	return s.notifications.Notify(ctx, userID, notifications.KindGoalSummary,
+		l.T("goals.title"), strings.Join(lines, "\n"))
+}
@@ -0,0 +1,210 @@
+package googleauth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+)
+
+// OAuth scopes.
+const (
+	ScopeGmailReadonly  = "https://www.googleapis.com/auth/gmail.readonly"
+	ScopeUserEmail      = "https://www.googleapis.com/auth/userinfo.email"
+	ScopeUserProfile    = "https://www.googleapis.com/auth/userinfo.profile"
+	ScopeCalendarEvents = "https://www.googleapis.com/auth/calendar.events"
+	ScopeGmailModify    = "https://www.googleapis.com/auth/gmail.modify"
+	ScopeGmailSend      = "https://www.googleapis.com/auth/gmail.send"
+)
+
+// ErrNotLinked is returned when the user has no stored Google token.
+var ErrNotLinked = apperr.New(apperr.Conflict, "google account not linked")
+
+// OAuthConfig builds the Google OAuth client configuration. Optional scopes
+// (such as Calendar, Gmail modify for rejection rules or Gmail send for
+// follow-ups) are appended to the base Gmail scopes and are only
+// requested when the user opts in.
+func OAuthConfig(cfg *config.Config, extraScopes ...string) *oauth2.Config {
+	scopes := append([]string{ScopeGmailReadonly, ScopeUserEmail, ScopeUserProfile}, extraScopes...)
+	return &oauth2.Config{
+		ClientID:     cfg.GmailClientID,
+		ClientSecret: cfg.GmailClientSecret,
+		RedirectURL:  cfg.GmailRedirectURI,
+		Scopes:       scopes,
+		Endpoint:     google.Endpoint,
+	}
+}
+
+// TokenStore loads and persists users' Google OAuth tokens.
+type TokenStore struct {
+	cfg *config.Config
+	db  *sql.DB
+}
+
+// NewTokenStore creates a token store backed by the users table.
+func NewTokenStore(cfg *config.Config, db *sql.DB) *TokenStore {
+	return &TokenStore{cfg: cfg, db: db}
+}
+
+// Token returns the stored token for the user.
+func (s *TokenStore) Token(ctx context.Context, userID string) (*oauth2.Token, error) {
+	var access, refresh sql.NullString
+	var expiry sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT access_token, refresh_token, token_expires_at FROM users WHERE id = $1`,
+		userID).Scan(&access, &refresh, &expiry)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !access.Valid && !refresh.Valid {
+		return nil, ErrNotLinked
+	}
+	return &oauth2.Token{
+		AccessToken:  access.String,
+		RefreshToken: refresh.String,
+		Expiry:       expiry.Time,
+		TokenType:    "Bearer",
+	}, nil
+}
+
+// Save persists a token for the user. A new token means the mailbox is
+// linked again, so any earlier disconnection is cleared.
+func (s *TokenStore) Save(ctx context.Context, userID string, tok *oauth2.Token) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE users SET access_token = $2, refresh_token = COALESCE(NULLIF($3, ''), refresh_token), token_expires_at = $4,
+			mailbox_disconnected_at = NULL, mailbox_disconnect_reason = NULL
+		WHERE id = $1`,
+		userID, tok.AccessToken, tok.RefreshToken, tok.Expiry)
+	return err
+}
+
+// Refresh exchanges the stored refresh token for a new access token now
+// instead of waiting for the current one to expire.
+func (s *TokenStore) Refresh(ctx context.Context, userID string) error {
+	tok, err := s.Token(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if tok.RefreshToken == "" {
+		return ErrNotLinked
+	}
+	fresh, err := OAuthConfig(s.cfg).TokenSource(ctx, &oauth2.Token{RefreshToken: tok.RefreshToken}).Token()
+	if err != nil {
+		return apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	return s.Save(ctx, userID, fresh)
+}
+
+// tokenInfoURL describes an access token, including the scopes it grants.
+const tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// Scopes returns the scopes the user has granted, as Google reports them
+// for their current access token.
+func (s *TokenStore) Scopes(ctx context.Context, userID string) ([]string, error) {
+	ts, err := s.TokenSource(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := ts.Token()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		tokenInfoURL+"?access_token="+url.QueryEscape(tok.AccessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperr.Wrap(apperr.UpstreamGmail, fmt.Errorf("tokeninfo answered %d", resp.StatusCode))
+	}
+	var info struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	return strings.Fields(info.Scope), nil
+}
+
+// HasScope reports whether the user has granted scope.
+func (s *TokenStore) HasScope(ctx context.Context, userID, scope string) (bool, error) {
+	scopes, err := s.Scopes(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, sc := range scopes {
+		if sc == scope {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsRevoked reports whether err is Google rejecting the refresh token, which
+// happens when the user revokes access or the grant expires. Only signing in
+// again fixes it.
+func IsRevoked(err error) bool {
+	var re *oauth2.RetrieveError
+	return errors.As(err, &re) && re.ErrorCode == "invalid_grant"
+}
+
+// TokenSource returns a token source for the user that writes refreshed
+// tokens back to the database.
+func (s *TokenStore) TokenSource(ctx context.Context, userID string) (oauth2.TokenSource, error) {
+	tok, err := s.Token(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	base := OAuthConfig(s.cfg).TokenSource(ctx, tok)
+	return &persistingSource{store: s, userID: userID, base: oauth2.ReuseTokenSource(tok, base), last: tok.AccessToken}, nil
+}
+
+type persistingSource struct {
+	store  *TokenStore
+	userID string
+	base   oauth2.TokenSource
+
+	mu   sync.Mutex
+	last string
+}
+
+func (p *persistingSource) Token() (*oauth2.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tok, err := p.base.Token()
+	if err != nil {
+		return nil, apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	if tok.AccessToken != p.last {
+		p.last = tok.AccessToken
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := p.store.Save(ctx, p.userID, tok); err != nil {
+			return nil, err
+		}
+	}
+	return tok, nil
+}
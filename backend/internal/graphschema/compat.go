@@ -0,0 +1,185 @@
+package graphschema
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Change is a difference between two versions of the schema.
+type Change struct {
+	Coordinate string `json:"coordinate"`
+	Message    string `json:"message"`
+	// Breaking changes can fail queries that clients already send.
+	Breaking bool `json:"breaking"`
+}
+
+func (c *Change) String() string {
+	if c.Breaking {
+		return "BREAKING " + c.Coordinate + ": " + c.Message
+	}
+	return c.Coordinate + ": " + c.Message
+}
+
+// Compare lists the changes from prev to next, breaking ones first.
+// Removing something is breaking unless prev deprecated it with a removal
+// date before today (YYYY-MM-DD); that is how the deprecation workflow
+// ends. Output types may only get stricter and input types looser; new
+// required arguments and input fields are breaking.
+func Compare(prev, next *ast.Schema, today string) []*Change {
+	c := &comparer{today: today}
+	for name, o := range prev.Types {
+		if o.BuiltIn {
+			continue
+		}
+		n := next.Types[name]
+		switch {
+		case n == nil:
+			c.add(name, true, "type removed")
+		case n.Kind != o.Kind:
+			c.add(name, true, fmt.Sprintf("changed from %s to %s", o.Kind, n.Kind))
+		default:
+			c.compareType(o, n)
+		}
+	}
+	for name, n := range next.Types {
+		if !n.BuiltIn && prev.Types[name] == nil {
+			c.add(name, false, "type added")
+		}
+	}
+	sort.SliceStable(c.changes, func(i, j int) bool {
+		if c.changes[i].Breaking != c.changes[j].Breaking {
+			return c.changes[i].Breaking
+		}
+		return c.changes[i].Coordinate < c.changes[j].Coordinate
+	})
+	return c.changes
+}
+
+// Breaking reports whether any of the changes is breaking.
+func Breaking(changes []*Change) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+type comparer struct {
+	today   string
+	changes []*Change
+}
+
+func (c *comparer) add(coordinate string, breaking bool, message string) {
+	c.changes = append(c.changes, &Change{Coordinate: coordinate, Breaking: breaking, Message: message})
+}
+
+// removed records the removal of something the previous schema had, which
+// is only allowed once its deprecation's removal date has passed.
+func (c *comparer) removed(coordinate, what, kind string, directives ast.DirectiveList) {
+	d := deprecation(coordinate, kind, directives)
+	if d != nil && d.RemoveAfter != nil && *d.RemoveAfter < c.today {
+		c.add(coordinate, false, what+" removed after its deprecation period")
+		return
+	}
+	c.add(coordinate, true, what+" removed")
+}
+
+func (c *comparer) compareType(o, n *ast.Definition) {
+	input := o.Kind == ast.InputObject
+	for _, of := range o.Fields {
+		coordinate := o.Name + "." + of.Name
+		nf := n.Fields.ForName(of.Name)
+		kind := KindField
+		if input {
+			kind = KindInputField
+		}
+		if nf == nil {
+			c.removed(coordinate, "field", kind, of.Directives)
+			continue
+		}
+		if input && !compatible(of.Type, nf.Type) || !input && !compatible(nf.Type, of.Type) {
+			c.add(coordinate, true, fmt.Sprintf("type changed from %s to %s", of.Type, nf.Type))
+		}
+		if of.Directives.ForName("deprecated") == nil && nf.Directives.ForName("deprecated") != nil {
+			c.add(coordinate, false, "deprecated")
+		}
+		for _, oa := range of.Arguments {
+			argCoordinate := coordinate + "(" + oa.Name + ":)"
+			na := nf.Arguments.ForName(oa.Name)
+			if na == nil {
+				c.removed(argCoordinate, "argument", KindArgument, oa.Directives)
+			} else if !compatible(oa.Type, na.Type) {
+				c.add(argCoordinate, true, fmt.Sprintf("type changed from %s to %s", oa.Type, na.Type))
+			}
+		}
+		for _, na := range nf.Arguments {
+			if of.Arguments.ForName(na.Name) == nil {
+				c.added(coordinate+"("+na.Name+":)", "argument", na.Type, na.DefaultValue)
+			}
+		}
+	}
+	for _, nf := range n.Fields {
+		if o.Fields.ForName(nf.Name) != nil {
+			continue
+		}
+		if input {
+			c.added(o.Name+"."+nf.Name, "input field", nf.Type, nf.DefaultValue)
+		} else {
+			c.add(o.Name+"."+nf.Name, false, "field added")
+		}
+	}
+
+	for _, ov := range o.EnumValues {
+		if n.EnumValues.ForName(ov.Name) == nil {
+			c.removed(o.Name+"."+ov.Name, "enum value", KindEnumValue, ov.Directives)
+		}
+	}
+	for _, nv := range n.EnumValues {
+		if o.EnumValues.ForName(nv.Name) == nil {
+			c.add(o.Name+"."+nv.Name, false, "enum value added")
+		}
+	}
+
+	for _, member := range o.Types {
+		if !contains(n.Types, member) {
+			c.add(o.Name, true, "union member "+member+" removed")
+		}
+	}
+}
+
+// added records a new argument or input field, which breaks clients that
+// do not send it if it is required.
+func (c *comparer) added(coordinate, what string, t *ast.Type, def *ast.Value) {
+	if t.NonNull && def == nil {
+		c.add(coordinate, true, "required "+what+" added")
+		return
+	}
+	c.add(coordinate, false, what+" added")
+}
+
+// compatible reports whether a value of type got can be used where want is
+// expected: the same type, possibly non-null where want allows null.
+func compatible(got, want *ast.Type) bool {
+	if want.NonNull && !got.NonNull {
+		return false
+	}
+	if (got.Elem == nil) != (want.Elem == nil) {
+		return false
+	}
+	if got.Elem != nil {
+		return compatible(got.Elem, want.Elem)
+	}
+	return got.NamedType == want.NamedType
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
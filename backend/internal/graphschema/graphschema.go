@@ -0,0 +1,149 @@
+// Package graphschema manages changes to the public GraphQL schema so that
+// the frontend, extension and other clients do not break silently. It lists
+// the schema's @deprecated fields for the schemaChangelog query, compares the
+// schema against an earlier one to catch breaking changes, and serves the
+// persisted queries clients register in versioned manifests.
+package graphschema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"regexp"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Deprecation kinds, by where the @deprecated directive sits.
+const (
+	KindField      = "field"
+	KindArgument   = "argument"
+	KindInputField = "input_field"
+	KindEnumValue  = "enum_value"
+)
+
+// removeAfter finds the removal date deprecation reasons are expected to
+// carry, as in "Use salary instead. Removal after 2027-01-31."
+var removeAfter = regexp.MustCompile(`(?i)remov\w* after (\d{4}-\d{2}-\d{2})`)
+
+// Deprecation is a deprecated part of the schema.
+type Deprecation struct {
+	// Coordinate is Type.field, Type.field(arg:), Input.field or Enum.VALUE.
+	Coordinate string `json:"coordinate"`
+	Kind       string `json:"kind"`
+	Reason     string `json:"reason"`
+	// RemoveAfter is the date (YYYY-MM-DD) after which it may be removed.
+	RemoveAfter *string `json:"removeAfter"`
+}
+
+// Changelog is the result of the schemaChangelog query.
+type Changelog struct {
+	// Version identifies the served schema; it changes with every edit.
+	Version      string          `json:"version"`
+	Deprecations []*Deprecation  `json:"deprecations"`
+	Manifests    []*ManifestInfo `json:"manifests"`
+}
+
+// Registry is the served schema and the persisted-query manifests
+// registered against it.
+type Registry struct {
+	version   string
+	schema    *ast.Schema
+	manifests []*Manifest
+	queries   map[string]string
+}
+
+// New parses the schema and loads every manifest in the manifests file
+// system. Manifests whose operations do not validate against the schema are
+// an error, so a schema change that breaks a registered client fails at
+// startup rather than in the client.
+func New(schema string, manifests fs.FS) (*Registry, error) {
+	s, err := Load("schema.graphqls", schema)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := LoadManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+	if problems := CheckManifests(s, ms); len(problems) > 0 {
+		return nil, problems[0]
+	}
+	r := &Registry{version: Version(schema), schema: s, manifests: ms, queries: make(map[string]string)}
+	for _, m := range ms {
+		for hash, query := range m.Operations {
+			r.queries[hash] = query
+		}
+	}
+	return r, nil
+}
+
+// Load parses a schema document.
+func Load(name, src string) (*ast.Schema, error) {
+	return gqlparser.LoadSchema(&ast.Source{Name: name, Input: src})
+}
+
+// Version returns a short hash identifying a schema document.
+func Version(src string) string {
+	sum := sha256.Sum256([]byte(src))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Changelog returns the schema's version, deprecations and registered
+// manifests.
+func (r *Registry) Changelog() *Changelog {
+	out := &Changelog{Version: r.version, Deprecations: Deprecations(r.schema), Manifests: []*ManifestInfo{}}
+	for _, m := range r.manifests {
+		out.Manifests = append(out.Manifests, m.Info())
+	}
+	return out
+}
+
+// Deprecations lists every deprecated field, argument, input field and enum
+// value in the schema, by coordinate.
+func Deprecations(s *ast.Schema) []*Deprecation {
+	out := []*Deprecation{}
+	for _, def := range s.Types {
+		if def.BuiltIn {
+			continue
+		}
+		for _, f := range def.Fields {
+			kind := KindField
+			if def.Kind == ast.InputObject {
+				kind = KindInputField
+			}
+			if d := deprecation(def.Name+"."+f.Name, kind, f.Directives); d != nil {
+				out = append(out, d)
+			}
+			for _, arg := range f.Arguments {
+				if d := deprecation(def.Name+"."+f.Name+"("+arg.Name+":)", KindArgument, arg.Directives); d != nil {
+					out = append(out, d)
+				}
+			}
+		}
+		for _, v := range def.EnumValues {
+			if d := deprecation(def.Name+"."+v.Name, KindEnumValue, v.Directives); d != nil {
+				out = append(out, d)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Coordinate < out[j].Coordinate })
+	return out
+}
+
+func deprecation(coordinate, kind string, directives ast.DirectiveList) *Deprecation {
+	dir := directives.ForName("deprecated")
+	if dir == nil {
+		return nil
+	}
+	d := &Deprecation{Coordinate: coordinate, Kind: kind, Reason: "No longer supported"}
+	if arg := dir.Arguments.ForName("reason"); arg != nil && arg.Value != nil {
+		d.Reason = arg.Value.Raw
+	}
+	if m := removeAfter.FindStringSubmatch(d.Reason); m != nil {
+		d.RemoveAfter = &m[1]
+	}
+	return d
+}
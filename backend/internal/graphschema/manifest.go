@@ -0,0 +1,105 @@
+package graphschema
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Manifest is the set of operations one release of a client sends, keyed by
+// the SHA-256 of the query text as in Apollo's persisted-query protocol.
+// Manifests live in graph/manifests as <client>-<version>.json and are
+// generated by the client's build.
+type Manifest struct {
+	Client     string            `json:"client"`  // e.g. frontend, extension
+	Version    string            `json:"version"` // the client release
+	Operations map[string]string `json:"operations"`
+}
+
+// ManifestInfo summarizes a manifest for the schemaChangelog query.
+type ManifestInfo struct {
+	Client     string `json:"client"`
+	Version    string `json:"version"`
+	Operations int    `json:"operations"`
+}
+
+// Info summarizes the manifest.
+func (m *Manifest) Info() *ManifestInfo {
+	return &ManifestInfo{Client: m.Client, Version: m.Version, Operations: len(m.Operations)}
+}
+
+// LoadManifests reads every .json manifest in fsys, checking that each
+// operation is keyed by its hash.
+func LoadManifests(fsys fs.FS) ([]*Manifest, error) {
+	var out []*Manifest
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".json" {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		m := &Manifest{}
+		if err := json.Unmarshal(b, m); err != nil {
+			return fmt.Errorf("manifest %s: %w", p, err)
+		}
+		if m.Client == "" || m.Version == "" {
+			return fmt.Errorf("manifest %s: client and version are required", p)
+		}
+		for hash, query := range m.Operations {
+			if sum := sha256.Sum256([]byte(query)); hex.EncodeToString(sum[:]) != hash {
+				return fmt.Errorf("manifest %s: operation %s does not match its hash", p, hash)
+			}
+		}
+		out = append(out, m)
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Client != out[j].Client {
+			return out[i].Client < out[j].Client
+		}
+		return out[i].Version < out[j].Version
+	})
+	return out, err
+}
+
+// CheckManifests validates every manifest operation against the schema and
+// returns one error per operation that no longer validates.
+func CheckManifests(s *ast.Schema, manifests []*Manifest) []error {
+	var out []error
+	for _, m := range manifests {
+		hashes := make([]string, 0, len(m.Operations))
+		for hash := range m.Operations {
+			hashes = append(hashes, hash)
+		}
+		sort.Strings(hashes)
+		for _, hash := range hashes {
+			if _, errs := gqlparser.LoadQuery(s, m.Operations[hash]); len(errs) > 0 {
+				out = append(out, fmt.Errorf("%s %s operation %s: %s", m.Client, m.Version, hash[:12], errs[0].Message))
+			}
+		}
+	}
+	return out
+}
+
+// Get returns the registered query with the given hash. Together with Add
+// it makes the registry a graphql.Cache, to be installed as the cache of
+// gqlgen's AutomaticPersistedQuery extension.
+func (r *Registry) Get(_ context.Context, hash string) (any, bool) {
+	q, ok := r.queries[hash]
+	return q, ok
+}
+
+// Add is a no-op: only operations registered in a manifest are served by
+// hash. Clients sending an unregistered hash are told to send the query
+// itself, which still works.
+func (r *Registry) Add(context.Context, string, any) {}
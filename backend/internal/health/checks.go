@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Dependencies probed by the health service.
+const (
+	DependencyDatabase   = "database"
+	DependencyRedis      = "redis"
+	DependencyAgents     = "agents"
+	DependencyLLM        = "llm"
+	DependencyGoogleAPIs = "google_apis"
+	DependencyExportDir  = "export_dir"
+)
+
+// googleProbeURL is fetched to tell whether Google's APIs (Gmail, Calendar)
+// are reachable. Any HTTP response counts; only network failures do not.
+const googleProbeURL = "https://gmail.googleapis.com/$discovery/rest?version=v1"
+
+func checkDatabase(ctx context.Context, db *sql.DB) error {
+	return db.PingContext(ctx)
+}
+
+func checkRedis(ctx context.Context, rdb *redis.Client) error {
+	return rdb.Ping(ctx).Err()
+}
+
+// agentsHealth is the body of the agents service's /health endpoint.
+type agentsHealth struct {
+	Status   string          `json:"status"`
+	Services map[string]bool `json:"services"`
+}
+
+// checkAgents asks the agents service for its health. The LLM is
+// reported separately since the agents service can run without it.
+func checkAgents(ctx context.Context, client *http.Client, baseURL string) (agents, llm error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/health", nil)
+	if err != nil {
+		return err, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("agents service returned status %d", resp.StatusCode)
+		return err, err
+	}
+
+	var h agentsHealth
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return fmt.Errorf("decode agents health: %w", err), err
+	}
+	if h.Status != "healthy" {
+		err := fmt.Errorf("agents service is %s", h.Status)
+		return err, err
+	}
+	if !h.Services["grpc"] {
+		err := fmt.Errorf("agents gRPC server is not running")
+		return err, err
+	}
+	if !h.Services["claude"] {
+		return nil, fmt.Errorf("agents service has no LLM configured")
+	}
+	return nil, nil
+}
+
+func checkGoogle(ctx context.Context, client *http.Client) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, googleProbeURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// checkExportDir verifies that spreadsheets can be written to dir.
+func checkExportDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".health-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,194 @@
+// Package health probes the backend's dependencies in the background and
+// derives which features are usable, so clients can disable features during
+// partial outages instead of surfacing generic errors.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/jobtracker/backend/internal/config"
+)
+
+// staleAfter is how old a snapshot may get before Capabilities probes
+// again instead of returning it, e.g. when the probe job is not running.
+const staleAfter = 2 * time.Minute
+
+// probeTimeout bounds each dependency check.
+const probeTimeout = 5 * time.Second
+
+// Dependency is the health of one dependency.
+type Dependency struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Feature is a user-facing feature and whether it can be used right now.
+type Feature struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"` // why it is unavailable, for display
+}
+
+// Capabilities is a point-in-time view of dependency health and features.
+type Capabilities struct {
+	CheckedAt    time.Time     `json:"checkedAt"`
+	Dependencies []*Dependency `json:"dependencies"`
+	Features     []*Feature    `json:"features"`
+}
+
+// feature derives a feature's availability from the dependencies it needs.
+type feature struct {
+	name     string
+	requires []string
+	// disabled returns why the feature is turned off by configuration, or "".
+	disabled func(cfg *config.Config) string
+}
+
+var features = []feature{
+	{name: "gmail_sync", requires: []string{DependencyDatabase, DependencyGoogleAPIs, DependencyAgents}},
+	{name: "ai_assist", requires: []string{DependencyAgents, DependencyLLM}},
+	{name: "calendar_sync", requires: []string{DependencyDatabase, DependencyGoogleAPIs}},
+	{
+		name:     "exports",
+		requires: []string{DependencyDatabase, DependencyAgents, DependencyExportDir},
+		disabled: func(cfg *config.Config) string {
+			if !cfg.ExportsEnabled {
+				return "Exports are disabled on this instance"
+			}
+			return ""
+		},
+	},
+	{name: "realtime_updates", requires: []string{DependencyRedis}},
+}
+
+// reasons explains a missing dependency to users.
+var reasons = map[string]string{
+	DependencyDatabase:   "The database is unavailable",
+	DependencyRedis:      "Live updates are temporarily unavailable",
+	DependencyAgents:     "The email processing service is down",
+	DependencyLLM:        "AI features are not configured or unavailable",
+	DependencyGoogleAPIs: "Google services cannot be reached",
+	DependencyExportDir:  "Export storage is not writable",
+}
+
+// Service probes dependencies and reports capabilities.
+type Service struct {
+	cfg    *config.Config
+	db     *sql.DB
+	rdb    *redis.Client
+	client *http.Client
+
+	mu       sync.RWMutex
+	snapshot *Capabilities
+}
+
+// NewService creates a health service.
+func NewService(cfg *config.Config, db *sql.DB, rdb *redis.Client) *Service {
+	return &Service{cfg: cfg, db: db, rdb: rdb, client: &http.Client{Timeout: probeTimeout}}
+}
+
+// Capabilities returns the latest snapshot, probing first if there is
+// none or it is stale.
+func (s *Service) Capabilities(ctx context.Context) *Capabilities {
+	s.mu.RLock()
+	snapshot := s.snapshot
+	s.mu.RUnlock()
+	if snapshot != nil && time.Since(snapshot.CheckedAt) < staleAfter {
+		return snapshot
+	}
+	return s.probe(ctx)
+}
+
+// Probe refreshes the snapshot; it is the scheduled job.
+func (s *Service) Probe(ctx context.Context) error {
+	s.probe(ctx)
+	return nil
+}
+
+func (s *Service) probe(ctx context.Context) *Capabilities {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	results := map[string]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	run := func(names []string, check func() []error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs := check()
+			mu.Lock()
+			for i, name := range names {
+				results[name] = errs[i]
+			}
+			mu.Unlock()
+		}()
+	}
+	run([]string{DependencyDatabase}, func() []error { return []error{checkDatabase(ctx, s.db)} })
+	run([]string{DependencyRedis}, func() []error { return []error{checkRedis(ctx, s.rdb)} })
+	run([]string{DependencyAgents, DependencyLLM}, func() []error {
+		agents, llm := checkAgents(ctx, s.client, s.cfg.AgentsServiceURL)
+		return []error{agents, llm}
+	})
+	run([]string{DependencyGoogleAPIs}, func() []error { return []error{checkGoogle(ctx, s.client)} })
+	run([]string{DependencyExportDir}, func() []error { return []error{checkExportDir(s.cfg.ExcelOutputDir)} })
+	wg.Wait()
+
+	c := &Capabilities{CheckedAt: time.Now().UTC()}
+	for _, name := range []string{DependencyDatabase, DependencyRedis, DependencyAgents, DependencyLLM, DependencyGoogleAPIs, DependencyExportDir} {
+		d := &Dependency{Name: name, Healthy: results[name] == nil}
+		if err := results[name]; err != nil {
+			d.Error = err.Error()
+		}
+		c.Dependencies = append(c.Dependencies, d)
+	}
+	for _, f := range features {
+		feat := &Feature{Name: f.name, Available: true}
+		if f.disabled != nil {
+			if reason := f.disabled(s.cfg); reason != "" {
+				feat.Available, feat.Reason = false, reason
+			}
+		}
+		for _, dep := range f.requires {
+			if feat.Available && results[dep] != nil {
+				feat.Available, feat.Reason = false, reasons[dep]
+			}
+		}
+		c.Features = append(c.Features, feat)
+	}
+
+	s.mu.Lock()
+	previous := s.snapshot
+	s.snapshot = c
+	s.mu.Unlock()
+	logChanges(previous, c)
+	return c
+}
+
+// logChanges logs dependencies that went down or recovered since the
+// previous probe.
+func logChanges(previous, current *Capabilities) {
+	was := map[string]bool{}
+	if previous != nil {
+		for _, d := range previous.Dependencies {
+			was[d.Name] = d.Healthy
+		}
+	}
+	for _, d := range current.Dependencies {
+		healthy, known := was[d.Name]
+		switch {
+		case !d.Healthy && (!known || healthy):
+			log.Printf("Dependency %s is unhealthy: %s", d.Name, d.Error)
+		case d.Healthy && known && !healthy:
+			log.Printf("Dependency %s recovered", d.Name)
+		}
+	}
+}
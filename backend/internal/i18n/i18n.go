@@ -0,0 +1,225 @@
+// Package i18n localizes user-facing text: notification templates, digests
+// and export labels. Messages live in embedded catalogs, one YAML file per
+// language keyed by message ID; dates, times and numbers are formatted for
+// the user's locale. Messages missing from a catalog fall back to English.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLocale is used for empty or unparseable locales.
+const DefaultLocale = "en-US"
+
+//go:embed catalogs/*.yaml
+var catalogFiles embed.FS
+
+var (
+	// catalogs maps a base language ("de") to its messages.
+	catalogs = make(map[string]map[string]string)
+	// langs are the catalog languages in matcher order, English first.
+	langs   = []string{"en"}
+	matcher language.Matcher
+)
+
+func init() {
+	entries, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+	tags := []language.Tag{language.English}
+	for _, e := range entries {
+		raw, err := catalogFiles.ReadFile(path.Join("catalogs", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := make(map[string]string)
+		if err := yaml.Unmarshal(raw, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: catalog %s: %v", e.Name(), err))
+		}
+		lang := strings.TrimSuffix(e.Name(), ".yaml")
+		catalogs[lang] = messages
+		if lang != "en" {
+			langs = append(langs, lang)
+			tags = append(tags, language.Make(lang))
+		}
+	}
+	matcher = language.NewMatcher(tags)
+}
+
+// Languages lists the languages with a catalog, English first.
+func Languages() []string {
+	return append([]string(nil), langs...)
+}
+
+// Localizer formats messages, dates and numbers for one locale.
+type Localizer struct {
+	tag      language.Tag
+	lang     string
+	messages map[string]string
+	printer  *message.Printer
+	// pluralTag selects plural forms; it is English when the locale has
+	// no catalog.
+	pluralTag language.Tag
+}
+
+// For returns a localizer for a BCP 47 locale such as "en-GB" or "de",
+// falling back to DefaultLocale.
+func For(locale string) *Localizer {
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		tag = language.MustParse(DefaultLocale)
+	}
+	lang := "en"
+	if _, i, conf := matcher.Match(tag); conf != language.No {
+		lang = langs[i]
+	}
+	// Plural forms follow the language the messages are written in.
+	pluralTag := tag
+	if base, _ := tag.Base(); base.String() != lang {
+		pluralTag = language.Make(lang)
+	}
+	return &Localizer{tag: tag, lang: lang, messages: catalogs[lang], printer: message.NewPrinter(tag), pluralTag: pluralTag}
+}
+
+// Lang returns the ISO 639-1 code of the catalog in use, e.g. "de".
+func (l *Localizer) Lang() string {
+	return l.lang
+}
+
+// Locale returns the full locale, e.g. "de-AT".
+func (l *Localizer) Locale() string {
+	return l.tag.String()
+}
+
+// T formats the message with the given ID. Messages are fmt formats and may
+// reorder their arguments with explicit indexes (%[2]s); integers are
+// printed with the locale's digit grouping.
+func (l *Localizer) T(id string, args ...any) string {
+	format, ok := l.messages[id]
+	if !ok {
+		if format, ok = catalogs["en"][id]; !ok {
+			log.Printf("i18n: missing message %q", id)
+			return id
+		}
+	}
+	return l.printer.Sprintf(format, args...)
+}
+
+// N formats the plural form of a message for count n: the ID suffixed with
+// the locale's plural category (".one", ".few", ".other", ...), falling
+// back to ".other". Count is not passed to the message implicitly.
+func (l *Localizer) N(id string, n int, args ...any) string {
+	form := pluralForms[plural.Cardinal.MatchPlural(l.pluralTag, n, 0, 0, 0, 0)]
+	if _, ok := l.messages[id+"."+form]; !ok {
+		form = "other"
+	}
+	return l.T(id+"."+form, args...)
+}
+
+var pluralForms = map[plural.Form]string{
+	plural.Other: "other",
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+}
+
+// Number formats an integer or float with the locale's separators.
+func (l *Localizer) Number(v any) string {
+	switch v.(type) {
+	case float32, float64:
+		return l.printer.Sprintf("%.2f", v)
+	}
+	return l.printer.Sprintf("%d", v)
+}
+
+// Status returns the display name of an application status.
+func (l *Localizer) Status(status string) string {
+	if _, ok := catalogs["en"]["status."+status]; !ok {
+		return status
+	}
+	return l.T("status." + status)
+}
+
+// dateLayouts are short date layouts by locale; a full locale (en-GB) wins
+// over its language (en). They match DATE_FORMATS in the agents' Excel
+// writer.
+var dateLayouts = map[string]string{
+	"en-US": "01/02/2006",
+	"en-GB": "02/01/2006",
+	"en-AU": "02/01/2006",
+	"en-IN": "02/01/2006",
+	"en-CA": "2006-01-02",
+	"en":    "01/02/2006",
+	"de":    "02.01.2006",
+	"fr":    "02/01/2006",
+	"es":    "02/01/2006",
+	"it":    "02/01/2006",
+	"pt":    "02/01/2006",
+	"nl":    "02-01-2006",
+	"pl":    "02.01.2006",
+	"ru":    "02.01.2006",
+	"sv":    "2006-01-02",
+	"ja":    "2006/01/02",
+	"zh":    "2006/01/02",
+	"ko":    "2006.01.02",
+}
+
+// clockLayouts are time-of-day layouts by locale, 24-hour unless listed.
+var clockLayouts = map[string]string{
+	"en":    "3:04 PM MST",
+	"en-GB": "15:04 MST",
+	"en-IE": "15:04 MST",
+}
+
+// lookup finds the locale's entry in a table keyed by locale or language.
+func (l *Localizer) lookup(table map[string]string) (string, bool) {
+	if v, ok := table[l.tag.String()]; ok {
+		return v, true
+	}
+	base, _ := l.tag.Base()
+	v, ok := table[base.String()]
+	return v, ok
+}
+
+// Date formats t as a short numeric date, e.g. 03/14/2025 or 14.03.2025.
+func (l *Localizer) Date(t time.Time) string {
+	layout, ok := l.lookup(dateLayouts)
+	if !ok {
+		layout = "2006-01-02"
+	}
+	return t.Format(layout)
+}
+
+// Time formats the time of day with the zone abbreviation, e.g. 3:04 PM
+// PST or 15:04 CET.
+func (l *Localizer) Time(t time.Time) string {
+	layout, ok := l.lookup(clockLayouts)
+	if !ok {
+		layout = "15:04 MST"
+	}
+	return t.Format(layout)
+}
+
+// Weekday returns the localized name of t's weekday.
+func (l *Localizer) Weekday(t time.Time) string {
+	return l.T("weekday." + strings.ToLower(t.Weekday().String()))
+}
+
+// DateTime formats t with its weekday, date and time, e.g. "Friday,
+// 03/14/2025 at 5:00 PM PDT".
+func (l *Localizer) DateTime(t time.Time) string {
+	return l.T("format.datetime", l.Weekday(t), l.Date(t), l.Time(t))
+}
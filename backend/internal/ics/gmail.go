@@ -0,0 +1,62 @@
+package ics
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// FromMessage extracts every calendar invitation carried by a Gmail message,
+// either as a text/calendar MIME part or as an .ics attachment. Attachment
+// bodies that are not inlined are fetched through the Gmail API.
+func FromMessage(ctx context.Context, svc *gmail.Service, userID string, msg *gmail.Message) ([]*Calendar, error) {
+	var parts []*gmail.MessagePart
+	collectCalendarParts(msg.Payload, &parts)
+
+	var out []*Calendar
+	for _, part := range parts {
+		data := part.Body.Data
+		if data == "" && part.Body.AttachmentId != "" {
+			att, err := svc.Users.Messages.Attachments.Get(userID, msg.Id, part.Body.AttachmentId).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			data = att.Data
+		}
+		raw, err := base64.URLEncoding.DecodeString(data)
+		if err != nil {
+			// Gmail sometimes omits padding.
+			raw, err = base64.RawURLEncoding.DecodeString(data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cal, err := Parse(raw)
+		if err == ErrNoEvents {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cal)
+	}
+	return out, nil
+}
+
+func collectCalendarParts(part *gmail.MessagePart, out *[]*gmail.MessagePart) {
+	if part == nil {
+		return
+	}
+	mime := strings.ToLower(part.MimeType)
+	if strings.HasPrefix(mime, "text/calendar") || mime == "application/ics" ||
+		strings.HasSuffix(strings.ToLower(part.Filename), ".ics") {
+		if part.Body != nil {
+			*out = append(*out, part)
+		}
+	}
+	for _, child := range part.Parts {
+		collectCalendarParts(child, out)
+	}
+}
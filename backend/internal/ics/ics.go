@@ -0,0 +1,245 @@
+// Package ics parses the subset of iCalendar (RFC 5545) found in interview
+// invitations: VEVENT components with their times, location and links. It
+// also writes the same subset for calendar feeds.
+package ics
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// Calendar methods that matter for invitations.
+const (
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+// ErrNoEvents is returned when the calendar contains no VEVENT.
+var ErrNoEvents = apperr.New(apperr.Validation, "ics: no events")
+
+// Calendar is a parsed VCALENDAR.
+type Calendar struct {
+	Method string
+	Events []*Event
+}
+
+// Event is a parsed VEVENT.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Organizer   string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Timezone    string
+	MeetingLink string
+	Status      string
+	Sequence    string
+}
+
+// Cancelled reports whether the event has been cancelled by the organizer.
+func (e *Event) Cancelled(method string) bool {
+	return method == MethodCancel || strings.EqualFold(e.Status, "CANCELLED")
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse parses an iCalendar document.
+func Parse(data []byte) (*Calendar, error) {
+	cal := &Calendar{}
+	var ev *Event
+	for _, p := range unfold(string(data)) {
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			ev = &Event{}
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			if ev != nil {
+				finish(ev)
+				cal.Events = append(cal.Events, ev)
+			}
+			ev = nil
+		case p.name == "METHOD" && ev == nil:
+			cal.Method = strings.ToUpper(p.value)
+		case ev != nil:
+			if err := apply(ev, p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(cal.Events) == 0 {
+		return nil, ErrNoEvents
+	}
+	return cal, nil
+}
+
+// unfold joins continuation lines and splits each content line into a property.
+func unfold(doc string) []property {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(doc))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	props := make([]property, 0, len(lines))
+	for _, line := range lines {
+		colon := valueSeparator(line)
+		if colon < 0 {
+			continue
+		}
+		head, value := line[:colon], line[colon+1:]
+		parts := strings.Split(head, ";")
+		p := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: value}
+		for _, param := range parts[1:] {
+			if k, v, ok := strings.Cut(param, "="); ok {
+				p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+			}
+		}
+		props = append(props, p)
+	}
+	return props
+}
+
+// valueSeparator finds the colon that separates the property name and
+// parameters from the value, skipping colons inside quoted parameters.
+func valueSeparator(line string) int {
+	quoted := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func apply(ev *Event, p property) error {
+	switch p.name {
+	case "UID":
+		ev.UID = p.value
+	case "SUMMARY":
+		ev.Summary = unescape(p.value)
+	case "DESCRIPTION":
+		ev.Description = unescape(p.value)
+	case "LOCATION":
+		ev.Location = unescape(p.value)
+	case "STATUS":
+		ev.Status = strings.ToUpper(p.value)
+	case "SEQUENCE":
+		ev.Sequence = p.value
+	case "ORGANIZER":
+		ev.Organizer = strings.TrimPrefix(strings.TrimPrefix(p.value, "mailto:"), "MAILTO:")
+	case "URL", "X-GOOGLE-CONFERENCE", "X-MICROSOFT-SKYPETEAMSMEETINGURL":
+		if ev.MeetingLink == "" {
+			ev.MeetingLink = p.value
+		}
+	case "DTSTART", "DTEND":
+		t, allDay, tz, err := parseTime(p)
+		if err != nil {
+			return err
+		}
+		if p.name == "DTSTART" {
+			ev.Start, ev.AllDay = t, allDay
+			if tz != "" {
+				ev.Timezone = tz
+			}
+		} else {
+			ev.End = t
+		}
+	}
+	return nil
+}
+
+var meetingLinkPattern = regexp.MustCompile(`https://[^\s<>"]*(zoom\.us|meet\.google\.com|teams\.microsoft\.com|teams\.live\.com|webex\.com|chime\.aws|whereby\.com)[^\s<>"]*`)
+
+func finish(ev *Event) {
+	if ev.MeetingLink == "" {
+		if m := meetingLinkPattern.FindString(ev.Location + " " + ev.Description); m != "" {
+			ev.MeetingLink = m
+		}
+	}
+	if ev.End.IsZero() && !ev.Start.IsZero() {
+		if ev.AllDay {
+			ev.End = ev.Start.AddDate(0, 0, 1)
+		} else {
+			ev.End = ev.Start.Add(time.Hour)
+		}
+	}
+	if ev.Timezone == "" {
+		ev.Timezone = "UTC"
+	}
+}
+
+func parseTime(p property) (t time.Time, allDay bool, tz string, err error) {
+	value := p.value
+	if p.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err = time.Parse("20060102", value)
+		return t, true, "", err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, "", err
+	}
+
+	loc := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, ok := loadLocation(tzid); ok {
+			loc, tz = l, l.String()
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, tz, err
+}
+
+// windowsZones maps the Windows zone names Outlook/Exchange put in TZID to
+// IANA names.
+var windowsZones = map[string]string{
+	"Pacific Standard Time":        "America/Los_Angeles",
+	"Mountain Standard Time":       "America/Denver",
+	"Central Standard Time":        "America/Chicago",
+	"Eastern Standard Time":        "America/New_York",
+	"GMT Standard Time":            "Europe/London",
+	"W. Europe Standard Time":      "Europe/Berlin",
+	"Romance Standard Time":        "Europe/Paris",
+	"Central Europe Standard Time": "Europe/Budapest",
+	"India Standard Time":          "Asia/Kolkata",
+	"China Standard Time":          "Asia/Shanghai",
+	"Tokyo Standard Time":          "Asia/Tokyo",
+	"Korea Standard Time":          "Asia/Seoul",
+	"Singapore Standard Time":      "Asia/Singapore",
+	"AUS Eastern Standard Time":    "Australia/Sydney",
+	"UTC":                          "UTC",
+}
+
+func loadLocation(tzid string) (*time.Location, bool) {
+	if name, ok := windowsZones[tzid]; ok {
+		tzid = name
+	}
+	loc, err := time.LoadLocation(tzid)
+	return loc, err == nil
+}
+
+var unescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
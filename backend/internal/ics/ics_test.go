@@ -0,0 +1,102 @@
+package ics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func calendar(lines ...string) []byte {
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		method string
+		want   Event
+	}{
+		{
+			name: "utc times",
+			data: calendar("BEGIN:VCALENDAR", "METHOD:REQUEST", "BEGIN:VEVENT", "UID:abc@example.com",
+				"SUMMARY:Onsite\\, round 2", "DTSTART:20240305T170000Z", "DTEND:20240305T180000Z",
+				"ORGANIZER;CN=Recruiter:mailto:recruiter@example.com", "END:VEVENT", "END:VCALENDAR"),
+			method: MethodRequest,
+			want: Event{UID: "abc@example.com", Summary: "Onsite, round 2", Organizer: "recruiter@example.com",
+				Start: time.Date(2024, 3, 5, 17, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC),
+				Timezone: "UTC"},
+		},
+		{
+			name: "windows zone and default duration",
+			data: calendar("BEGIN:VCALENDAR", "BEGIN:VEVENT", "UID:win",
+				`DTSTART;TZID="Pacific Standard Time":20240305T090000`, "END:VEVENT", "END:VCALENDAR"),
+			want: Event{UID: "win", Timezone: "America/Los_Angeles",
+				Start: time.Date(2024, 3, 5, 17, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "all day",
+			data: calendar("BEGIN:VCALENDAR", "BEGIN:VEVENT", "UID:day", "DTSTART;VALUE=DATE:20240305",
+				"END:VEVENT", "END:VCALENDAR"),
+			want: Event{UID: "day", AllDay: true, Timezone: "UTC",
+				Start: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "folded description with meeting link",
+			data: calendar("BEGIN:VCALENDAR", "METHOD:CANCEL", "BEGIN:VEVENT", "UID:fold",
+				"DESCRIPTION:Join at https://zoom.us/j/12", " 345 please", "DTSTART:20240305T170000Z",
+				"STATUS:cancelled", "END:VEVENT", "END:VCALENDAR"),
+			method: MethodCancel,
+			want: Event{UID: "fold", Description: "Join at https://zoom.us/j/12345 please",
+				MeetingLink: "https://zoom.us/j/12345", Status: "CANCELLED", Timezone: "UTC",
+				Start: time.Date(2024, 3, 5, 17, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := Parse(tt.data)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if cal.Method != tt.method {
+				t.Errorf("Method = %q, want %q", cal.Method, tt.method)
+			}
+			if len(cal.Events) != 1 {
+				t.Fatalf("got %d events, want 1", len(cal.Events))
+			}
+			got := cal.Events[0]
+			if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+				t.Errorf("times = %v to %v, want %v to %v", got.Start, got.End, tt.want.Start, tt.want.End)
+			}
+			got.Start, got.End = tt.want.Start, tt.want.End
+			if *got != tt.want {
+				t.Errorf("event = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNoEvents(t *testing.T) {
+	_, err := Parse(calendar("BEGIN:VCALENDAR", "METHOD:REQUEST", "END:VCALENDAR"))
+	if !errors.Is(err, ErrNoEvents) {
+		t.Fatalf("err = %v, want ErrNoEvents", err)
+	}
+}
+
+func TestCancelled(t *testing.T) {
+	tests := []struct {
+		method, status string
+		want           bool
+	}{
+		{MethodRequest, "", false},
+		{MethodRequest, "CONFIRMED", false},
+		{MethodRequest, "cancelled", true},
+		{MethodCancel, "", true},
+	}
+	for _, tt := range tests {
+		if got := (&Event{Status: tt.status}).Cancelled(tt.method); got != tt.want {
+			t.Errorf("Cancelled(%q) with status %q = %v, want %v", tt.method, tt.status, got, tt.want)
+		}
+	}
+}
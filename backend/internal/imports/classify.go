@@ -0,0 +1,416 @@
+package imports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/quotas"
+	"github.com/jobtracker/backend/internal/syncguard"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+const (
+	// defaultBatch and maxBatch bound the emails in one classification
+	// batch.
+	defaultBatch = 100
+	maxBatch     = 1000
+	// classifyPerRun caps the emails classified per run of Classify.
+	classifyPerRun = 100
+	// maxAttempts is how many times classifying an email may fail before
+	// it is parked and left out of later batches.
+	maxAttempts = 3
+	// reasoningTokens is roughly what the classifier's one-line reasoning,
+	// the output metered for a classification, comes to.
+	reasoningTokens = 40
+	// extractionTokens is roughly what an extracted application, the
+	// output metered for an extraction, comes to.
+	extractionTokens = 80
+)
+
+var (
+	// ErrNothingPending is returned when releasing a batch while no
+	// imported email waits for classification.
+	ErrNothingPending = apperr.New(apperr.Conflict, "no imported email is waiting for classification")
+	// ErrOverBudget is returned when a batch is estimated to cost more than
+	// the limit the user set for it.
+	ErrOverBudget = apperr.New(apperr.Validation, "the batch is estimated to cost more than the limit given")
+	// ErrHeld is returned when releasing a batch while the results of an
+	// earlier one are held for an administrator's review.
+	ErrHeld = apperr.New(apperr.Conflict, "classification of imported email is held for review")
+)
+
+// Estimate is what classifying the next batch of imported email would cost,
+// including extracting the application from the emails expected to be job
+// related. Costs are priced like LLM usage is metered, from the subject and
+// body sent and the reasoning or application returned.
+type Estimate struct {
+	// Pending counts imported emails waiting to be released for
+	// classification; Queued those released and not classified yet;
+	// Failed those parked after failing to classify maxAttempts times.
+	Pending int `json:"pending"`
+	Queued  int `json:"queued"`
+	Failed  int `json:"failed"`
+	// BatchSize is the emails in the batch, at most the size asked for.
+	BatchSize int `json:"batchSize"`
+	// ExpectedJobRelated is how many of them are expected to be job
+	// related, going by the share of the user's classified email that is;
+	// all of them when none is classified yet.
+	ExpectedJobRelated int     `json:"expectedJobRelated"`
+	InputTokens        int     `json:"inputTokens"`
+	OutputTokens       int     `json:"outputTokens"`
+	CostCents          float64 `json:"costCents"`
+	// RemainingCents is what is left of the user's monthly LLM spend
+	// quota, nil when it is unlimited.
+	RemainingCents *float64 `json:"remainingCents"`
+}
+
+// addExtraction adds extracting the application from the share of the
+// batch expected to be job related. The emails sent for extraction are
+// those sent for classification, so their input is the same share of it.
+func (est *Estimate) addExtraction(share float64) {
+	est.ExpectedJobRelated = int(math.Ceil(share * float64(est.BatchSize)))
+	est.InputTokens += int(math.Ceil(share * float64(est.InputTokens)))
+	est.OutputTokens += est.ExpectedJobRelated * extractionTokens
+}
+
+// Estimate prices classifying the next batch of the user's imported email,
+// oldest first, without releasing it. The size defaults to 100.
+func (s *Service) Estimate(ctx context.Context, userID string, size *int) (*Estimate, error) {
+	est, _, err := s.estimate(ctx, userID, size)
+	return est, err
+}
+
+func (s *Service) estimate(ctx context.Context, userID string, size *int) (*Estimate, []string, error) {
+	limit := defaultBatch
+	if size != nil {
+		if *size < 1 || *size > maxBatch {
+			return nil, nil, validation.Field("batchSize", "must be between 1 and 1000")
+		}
+		limit = *size
+	}
+	est := &Estimate{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE classify_queued_at IS NULL AND classify_failed_at IS NULL),
+			COUNT(*) FILTER (WHERE classify_queued_at IS NOT NULL), COUNT(*) FILTER (WHERE classify_failed_at IS NOT NULL)
+		FROM email_cache WHERE user_id = $1 AND classify_pending_at IS NOT NULL`,
+		userID).Scan(&est.Pending, &est.Queued, &est.Failed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Sizes are measured in the database so a large batch's bodies are not
+	// loaded just to be counted.
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, COALESCE(octet_length(subject), 0) + COALESCE(octet_length(body_text), 0)
+		FROM email_cache
+		WHERE user_id = $1 AND classify_pending_at IS NOT NULL AND classify_queued_at IS NULL AND classify_failed_at IS NULL
+		ORDER BY date NULLS LAST, id
+		LIMIT $2`,
+		userID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		var length int
+		if err := rows.Scan(&id, &length); err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+		est.InputTokens += (length + 3) / 4 // as quotas.EstimateTokens
+		est.OutputTokens += reasoningTokens
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	est.BatchSize = len(ids)
+
+	var share float64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(AVG(CASE WHEN is_job_related THEN 1 ELSE 0 END), 1)
+		FROM email_cache WHERE user_id = $1 AND classify_pending_at IS NULL`,
+		userID).Scan(&share)
+	if err != nil {
+		return nil, nil, err
+	}
+	est.addExtraction(share)
+	est.CostCents = math.Round(s.quotas.Cost(est.InputTokens, est.OutputTokens)*100) / 100
+
+	usage, err := s.quotas.Usage(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, q := range usage.Quotas {
+		if q.Metric == quotas.MetricLLMSpend && q.Limit != nil {
+			remaining := math.Max(float64(*q.Limit)-q.Used, 0)
+			est.RemainingCents = &remaining
+		}
+	}
+	return est, ids, nil
+}
+
+// ClassifyBatch releases the next batch of the user's imported email,
+// oldest first, for classification in the background and returns its
+// estimate. It is refused if the estimate exceeds maxCostCents, when given,
+// or what is left of the user's LLM spend quota, and while the sync guard
+// holds the user's import for review.
+func (s *Service) ClassifyBatch(ctx context.Context, userID string, size *int, maxCostCents *float64) (*Estimate, error) {
+	var held bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM processing_jobs WHERE user_id = $1 AND kind = $2 AND review_status = $3)`,
+		userID, kind, syncguard.ReviewPending).Scan(&held); err != nil {
+		return nil, err
+	}
+	if held {
+		return nil, ErrHeld
+	}
+	est, ids, err := s.estimate(ctx, userID, size)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrNothingPending
+	}
+	if maxCostCents != nil && est.CostCents > *maxCostCents {
+		return nil, ErrOverBudget
+	}
+	if err := s.quotas.Check(ctx, userID, quotas.MetricLLMSpend, int64(math.Ceil(est.CostCents))); err != nil {
+		return nil, err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE email_cache SET classify_queued_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($2) AND user_id = $1 AND classify_pending_at IS NOT NULL AND classify_queued_at IS NULL
+			AND classify_failed_at IS NULL`,
+		userID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	n, _ := res.RowsAffected()
+	est.Pending -= int(n)
+	est.Queued += int(n)
+	return est, nil
+}
+
+// CancelClassification takes the user's released emails that have not been
+// classified yet back out of the queue, so they wait for a later batch, and
+// returns how many there were.
+func (s *Service) CancelClassification(ctx context.Context, userID string) (int, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE email_cache SET classify_queued_at = NULL
+		WHERE user_id = $1 AND classify_pending_at IS NOT NULL AND classify_queued_at IS NOT NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+type queuedEmail struct {
+	userID string
+	email  *agentspb.Email
+	date   *time.Time
+}
+
+type classifiedEmail struct {
+	queuedEmail
+	resp *agentspb.ClassifyEmailResponse
+}
+
+// Classify classifies released imported emails, in the order their batches
+// were released, as batch work metered against each user's LLM quota, then
+// files job-related ones under applications and suggests a triage action
+// as the sync pipeline does. Before anything is applied, each user's
+// results from the run are checked by the sync guard against their import;
+// held results are discarded, the rest of the user's queue is put back and
+// the import waits for review. A user who runs out of quota has the rest of
+// their queue put back to wait for a later batch. Emails that fail are
+// retried on later runs, after the rest of the queue, and parked after
+// maxAttempts failures. It is intended to run every minute from the
+// scheduler.
+func (s *Service) Classify(ctx context.Context) error {
+	if s.agents == nil {
+		return nil
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, COALESCE(subject, ''), COALESCE(sender, ''), COALESCE(recipient, ''), date,
+			COALESCE(snippet, ''), COALESCE(body_text, ''), labels
+		FROM email_cache
+		WHERE classify_queued_at IS NOT NULL AND classify_pending_at IS NOT NULL
+		ORDER BY classify_attempts, classify_queued_at, date NULLS LAST, id
+		LIMIT $1`,
+		classifyPerRun)
+	if err != nil {
+		return err
+	}
+	var queued []queuedEmail
+	for rows.Next() {
+		q := queuedEmail{email: &agentspb.Email{}}
+		var date sql.NullTime
+		if err := rows.Scan(&q.email.Id, &q.userID, &q.email.Subject, &q.email.From, &q.email.To, &date,
+			&q.email.Snippet, &q.email.Body, pq.Array(&q.email.Labels)); err != nil {
+			rows.Close()
+			return err
+		}
+		if date.Valid {
+			q.email.Date = date.Time.Format(time.RFC3339)
+			q.date = &date.Time
+		}
+		queued = append(queued, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ctx = agents.WithPriority(ctx, agents.PriorityBatch)
+	exhausted := make(map[string]bool)
+	var users []string
+	classified := make(map[string][]classifiedEmail)
+	for _, q := range queued {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if exhausted[q.userID] {
+			continue
+		}
+		resp, err := s.agents.ClassifyEmail(auth.WithUserID(ctx, q.userID), q.email)
+		if errors.Is(err, quotas.ErrQuotaExceeded) {
+			log.Printf("Stopped classifying imported email for user %s: %v", q.userID, err)
+			exhausted[q.userID] = true
+			if _, err := s.CancelClassification(ctx, q.userID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to classify imported email %s: %v", q.email.Id, err)
+			if err := s.failed(ctx, q.email.Id); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := classified[q.userID]; !ok {
+			users = append(users, q.userID)
+		}
+		classified[q.userID] = append(classified[q.userID], classifiedEmail{q, resp})
+	}
+
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		held, err := s.hold(ctx, userID, classified[userID])
+		if err != nil {
+			return err
+		}
+		if held {
+			if _, err := s.CancelClassification(ctx, userID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.apply(ctx, classified[userID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hold checks a user's classified emails with the sync guard, against their
+// latest import, and reports whether they must be left unapplied.
+func (s *Service) hold(ctx context.Context, userID string, results []classifiedEmail) (bool, error) {
+	if s.guard == nil {
+		return false, nil
+	}
+	b := syncguard.Batch{UserID: userID, Scanned: len(results), Statuses: make(map[string]int)}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id FROM processing_jobs WHERE user_id = $1 AND kind = $2
+		ORDER BY created_at DESC LIMIT 1`,
+		userID, kind).Scan(&b.JobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, c := range results {
+		if c.resp.JobRelated {
+			b.Matched++
+			if c.resp.Status != "" {
+				b.Statuses[c.resp.Status]++
+			}
+		}
+	}
+	verdict, err := s.guard.Check(ctx, b)
+	if err != nil {
+		return false, err
+	}
+	return verdict.Hold, nil
+}
+
+// apply stores classification results, files job-related emails under
+// applications and suggests a triage action for each.
+func (s *Service) apply(ctx context.Context, results []classifiedEmail) error {
+	for _, c := range results {
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE email_cache SET is_job_related = $2, relevance_score = $3, classify_pending_at = NULL,
+				classify_queued_at = NULL
+			WHERE id = $1`,
+			c.email.Id, c.resp.JobRelated, c.resp.Confidence); err != nil {
+			return err
+		}
+		var applicationID *string
+		if c.resp.JobRelated {
+			applicationID = s.link(auth.WithUserID(ctx, c.userID), c.queuedEmail, c.resp.Status)
+		}
+		if err := s.triage.Classified(ctx, c.userID, c.email.Id, applicationID, c.resp.Status, c.resp.Language); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failed counts a failed attempt at classifying an email, parking it once
+// it has failed maxAttempts times.
+func (s *Service) failed(ctx context.Context, emailID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE email_cache SET classify_attempts = classify_attempts + 1,
+			classify_queued_at = CASE WHEN classify_attempts + 1 >= $2 THEN NULL ELSE classify_queued_at END,
+			classify_failed_at = CASE WHEN classify_attempts + 1 >= $2 THEN CURRENT_TIMESTAMP END
+		WHERE id = $1`,
+		emailID, maxAttempts)
+	return err
+}
+
+// link extracts the application a job-related email is about and files the
+// email under it with its classified status. It returns the application's
+// ID, or nil when the email could not be filed. Failures are logged and
+// leave the email unfiled rather than classifying it again.
+func (s *Service) link(ctx context.Context, q queuedEmail, status string) *string {
+	extracted, err := s.agents.ExtractApplication(ctx, q.email)
+	if err != nil {
+		log.Printf("Failed to extract the application from imported email %s: %v", q.email.Id, err)
+		return nil
+	}
+	app, err := s.applications.LinkEmail(ctx, q.userID, q.email.Id, q.date, extracted.Application, status)
+	if err != nil {
+		log.Printf("Failed to file imported email %s under an application: %v", q.email.Id, err)
+		return nil
+	}
+	if app == nil {
+		return nil
+	}
+	return &app.ID
+}
@@ -0,0 +1,27 @@
+package imports
+
+import "testing"
+
+func TestAddExtraction(t *testing.T) {
+	tests := []struct {
+		name         string
+		share        float64
+		batch, input int
+		want         Estimate
+	}{
+		{"none expected", 0, 10, 1000, Estimate{BatchSize: 10, InputTokens: 1000, OutputTokens: 400}},
+		{"all expected", 1, 10, 1000,
+			Estimate{BatchSize: 10, ExpectedJobRelated: 10, InputTokens: 2000, OutputTokens: 400 + 10*extractionTokens}},
+		{"a share, rounded up", 0.25, 10, 1000,
+			Estimate{BatchSize: 10, ExpectedJobRelated: 3, InputTokens: 1250, OutputTokens: 400 + 3*extractionTokens}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := Estimate{BatchSize: tt.batch, InputTokens: tt.input, OutputTokens: tt.batch * reasoningTokens}
+			est.addExtraction(tt.share)
+			if est != tt.want {
+				t.Errorf("addExtraction(%v) = %+v, want %+v", tt.share, est, tt.want)
+			}
+		})
+	}
+}
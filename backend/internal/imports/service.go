@@ -0,0 +1,345 @@
+// Package imports brings a user's historical Gmail into the cache without
+// classifying it as it arrives. An import stores messages quickly and at no
+// LLM cost; the user then releases them for classification in batches they
+// size themselves, after seeing what each batch is estimated to cost.
+package imports
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/exports"
+	"github.com/jobtracker/backend/internal/googleauth"
+	"github.com/jobtracker/backend/internal/quotas"
+	"github.com/jobtracker/backend/internal/syncguard"
+	"github.com/jobtracker/backend/internal/triage"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// kind is the processing_jobs kind of an import, tasks.TypeImport.
+const kind = "import"
+
+const (
+	// importsPerRun caps the imports advanced per run of Import.
+	importsPerRun = 5
+	// pagesPerRun caps the Gmail result pages one import reads per run, so
+	// a large mailbox is imported over several runs and a restart loses at
+	// most one page.
+	pagesPerRun = 5
+	// pageSize is the messages listed per Gmail result page.
+	pageSize = 100
+	// maxBody caps the stored body of an imported message in bytes.
+	maxBody = 100000
+	// stage is reported as the import's current stage.
+	stage = "importing"
+)
+
+var (
+	// ErrImportRunning is returned when starting an import while another
+	// one is pending or running for the user.
+	ErrImportRunning = apperr.New(apperr.Conflict, "an import is already running; wait for it or cancel it first")
+)
+
+// StartInput is the range of mail to import.
+type StartInput struct {
+	StartDate string `json:"startDate" validate:"required,datetime=2006-01-02"`
+	// EndDate is inclusive and defaults to today.
+	EndDate *string `json:"endDate" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// Service imports historical mail and classifies it in batches.
+type Service struct {
+	db           *sql.DB
+	tokens       *googleauth.TokenStore
+	exports      *exports.Service
+	quotas       *quotas.Service
+	agents       *agents.Client
+	applications *applications.Service
+	triage       *triage.Service
+	guard        *syncguard.Service
+}
+
+// NewService creates an import service. Imports are processing jobs like
+// exports, so their progress and cancellation go through the export
+// service. Classified imported emails are filed under applications and
+// given a triage suggestion like synced ones, once the sync guard has
+// checked them.
+func NewService(db *sql.DB, tokens *googleauth.TokenStore, exportService *exports.Service, quotaService *quotas.Service,
+	agentsClient *agents.Client, applicationService *applications.Service, triageService *triage.Service,
+	guard *syncguard.Service) *Service {
+	return &Service{db: db, tokens: tokens, exports: exportService, quotas: quotaService, agents: agentsClient,
+		applications: applicationService, triage: triageService, guard: guard}
+}
+
+// Start queues an import of the user's Gmail received in the range and
+// returns its ID; it is followed and cancelled as a task. Messages already
+// cached are skipped, and imported ones wait for ClassifyBatch.
+func (s *Service) Start(ctx context.Context, userID string, in StartInput) (string, error) {
+	if err := validation.Struct(in); err != nil {
+		return "", err
+	}
+	start, _ := time.Parse("2006-01-02", in.StartDate)
+	if in.EndDate != nil {
+		if end, _ := time.Parse("2006-01-02", *in.EndDate); end.Before(start) {
+			return "", validation.Field("endDate", "must not be before startDate")
+		}
+	}
+	if start.After(time.Now()) {
+		return "", validation.Field("startDate", "must not be in the future")
+	}
+	if err := s.quotas.Check(ctx, userID, quotas.MetricExports, 1); err != nil {
+		return "", err
+	}
+	if err := s.quotas.Check(ctx, userID, quotas.MetricStoredEmails, 0); err != nil {
+		return "", err
+	}
+
+	var id string
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO processing_jobs (user_id, start_date, end_date, output_path, kind)
+		SELECT $1, $2, $3, '', $4
+		WHERE NOT EXISTS (
+			SELECT 1 FROM processing_jobs
+			WHERE user_id = $1 AND kind = $4 AND status IN ('pending', 'processing'))
+		RETURNING id`,
+		userID, in.StartDate, in.EndDate, kind).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrImportRunning
+	}
+	return id, err
+}
+
+type pendingImport struct {
+	id, userID     string
+	start          time.Time
+	end            *time.Time
+	pageToken      string
+	seen, stored   int
+	estimatedTotal int
+}
+
+// Import advances pending and running imports by a few pages each,
+// resuming where the last run stopped. Imports of paused or disconnected
+// mailboxes wait. Failures for one import are recorded on it and do not
+// stop the run. It is intended to run every minute from the scheduler.
+func (s *Service) Import(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT j.id, j.user_id, j.start_date, j.end_date, COALESCE(j.import_page_token, ''),
+			COALESCE(j.applications_found, 0), COALESCE(j.applications_processed, 0), COALESCE(j.import_estimated_total, 0)
+		FROM processing_jobs j JOIN users u ON u.id = j.user_id
+		WHERE j.kind = $1 AND j.status IN ('pending', 'processing')
+			AND u.mailbox_disconnected_at IS NULL AND u.mailbox_paused_at IS NULL
+		ORDER BY j.updated_at
+		LIMIT $2`,
+		kind, importsPerRun)
+	if err != nil {
+		return err
+	}
+	var due []*pendingImport
+	for rows.Next() {
+		p := &pendingImport{}
+		if err := rows.Scan(&p.id, &p.userID, &p.start, &p.end, &p.pageToken, &p.seen, &p.stored,
+			&p.estimatedTotal); err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		done, err := s.advance(ctx, p)
+		switch {
+		case errors.Is(err, exports.ErrCancelled), errors.Is(err, exports.ErrNotFound):
+			continue
+		case errors.Is(err, quotas.ErrQuotaExceeded):
+			err = s.exports.Finish(ctx, p.id, []string{"stopped at the stored email limit of your plan"})
+		case err != nil:
+			log.Printf("Import %s for user %s failed: %v", p.id, p.userID, err)
+			err = s.exports.Finish(ctx, p.id, []string{"the mail could not be imported"})
+		case done:
+			err = s.exports.Finish(ctx, p.id, nil)
+		}
+		if err != nil && !errors.Is(err, exports.ErrNotFound) {
+			log.Printf("Failed to finish import %s: %v", p.id, err)
+		}
+	}
+	return nil
+}
+
+// advance imports up to pagesPerRun pages of one import and reports
+// whether it reached the end of the range.
+func (s *Service) advance(ctx context.Context, p *pendingImport) (bool, error) {
+	ts, err := s.tokens.TokenSource(ctx, p.userID)
+	if err != nil {
+		return false, err
+	}
+	svc, err := gmail.NewService(ctx, option.WithTokenSource(ts))
+	if err != nil {
+		return false, err
+	}
+	end := time.Now()
+	if p.end != nil {
+		end = p.end.AddDate(0, 0, 1)
+	}
+	query := fmt.Sprintf("after:%d before:%d -in:chats", p.start.Unix(), end.Unix())
+
+	for page := 0; page < pagesPerRun; page++ {
+		call := svc.Users.Messages.List("me").Q(query).MaxResults(pageSize).Context(ctx)
+		if p.pageToken != "" {
+			call = call.PageToken(p.pageToken)
+		}
+		list, err := call.Do()
+		if err != nil {
+			return false, err
+		}
+		if p.estimatedTotal == 0 {
+			p.estimatedTotal = int(list.ResultSizeEstimate)
+		}
+		if err := s.store(ctx, svc, p, list.Messages); err != nil {
+			return false, err
+		}
+		p.pageToken = list.NextPageToken
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE processing_jobs SET import_page_token = NULLIF($2, ''), import_estimated_total = $3
+			WHERE id = $1`, p.id, p.pageToken, p.estimatedTotal); err != nil {
+			return false, err
+		}
+		percent := 99
+		if p.estimatedTotal > p.seen {
+			percent = p.seen * 100 / p.estimatedTotal
+		}
+		if err := s.exports.Progress(ctx, p.id, percent, stage, p.seen, p.stored); err != nil {
+			return false, err
+		}
+		if p.pageToken == "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// store caches the listed messages that are not cached yet, marked as
+// waiting for classification.
+func (s *Service) store(ctx context.Context, svc *gmail.Service, p *pendingImport, listed []*gmail.Message) error {
+	ids := make([]string, 0, len(listed))
+	for _, m := range listed {
+		ids = append(ids, m.Id)
+	}
+	p.seen += len(ids)
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM email_cache WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	cached := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		cached[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if cached[id] {
+			continue
+		}
+		if err := s.quotas.Check(ctx, p.userID, quotas.MetricStoredEmails, 1); err != nil {
+			return err
+		}
+		msg, err := svc.Users.Messages.Get("me", id).Format("full").Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+		h := headers(msg)
+		res, err := s.db.ExecContext(ctx, `
+			INSERT INTO email_cache (id, user_id, subject, sender, recipient, date, snippet, body_text, labels,
+				classify_pending_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP)
+			ON CONFLICT (id) DO NOTHING`,
+			msg.Id, p.userID, clean(h["subject"], maxBody), clean(h["from"], 255), clean(h["to"], 255),
+			time.UnixMilli(msg.InternalDate), clean(msg.Snippet, maxBody), clean(plainText(msg.Payload), maxBody),
+			pq.Array(msg.LabelIds))
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			p.stored++
+		}
+	}
+	return nil
+}
+
+// headers returns a message's headers by lower-case name.
+func headers(m *gmail.Message) map[string]string {
+	out := make(map[string]string)
+	if m.Payload == nil {
+		return out
+	}
+	for _, h := range m.Payload.Headers {
+		out[strings.ToLower(h.Name)] = h.Value
+	}
+	return out
+}
+
+// plainText returns the text/plain parts of a message.
+func plainText(part *gmail.MessagePart) string {
+	if part == nil {
+		return ""
+	}
+	var out strings.Builder
+	if strings.HasPrefix(strings.ToLower(part.MimeType), "text/plain") && part.Body != nil && part.Body.Data != "" {
+		raw, err := base64.URLEncoding.DecodeString(part.Body.Data)
+		if err != nil {
+			// Gmail sometimes omits padding.
+			raw, err = base64.RawURLEncoding.DecodeString(part.Body.Data)
+		}
+		if err == nil {
+			out.Write(raw)
+			out.WriteByte('\n')
+		}
+	}
+	for _, child := range part.Parts {
+		out.WriteString(plainText(child))
+	}
+	return out.String()
+}
+
+// clean makes text storable: Postgres rejects NUL bytes and invalid UTF-8,
+// which bodies in legacy charsets decode to. It is then cut to at most n
+// bytes without splitting a character.
+func clean(s string, n int) string {
+	s = strings.ToValidUTF8(strings.ReplaceAll(s, "\x00", ""), "")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,360 @@
+// Package integrity checks application data for inconsistencies that slip
+// past constraints: job-related emails linked to no application,
+// applications without a company, past interviews still marked scheduled
+// and status histories that do not end at the application's status. Each
+// run is recorded with the issues found for administrators, and issues that
+// can be fixed without the user are repaired when asked to.
+package integrity
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/config"
+)
+
+// Checks, in the order they run.
+const (
+	CheckOrphanedEmail    = "orphaned_email"
+	CheckMissingCompany   = "missing_company"
+	CheckStaleInterview   = "stale_interview"
+	CheckStatusHistoryGap = "status_history_gap"
+)
+
+const (
+	// issueLimit caps the issues recorded, and repaired, per check and run;
+	// later runs pick up the rest.
+	issueLimit = 500
+	// keepRuns is how long runs and their issues are kept.
+	keepRuns = 30 * 24 * time.Hour
+	// reportRuns caps the runs listed in the report.
+	reportRuns = 30
+)
+
+// CheckSummary is what one check found in a run.
+type CheckSummary struct {
+	Check string `json:"check"`
+	// Found counts every issue, including those past the recorded ones.
+	Found      int  `json:"found"`
+	Repairable bool `json:"repairable"`
+	Repaired   int  `json:"repaired"`
+}
+
+// Run is one integrity check over the whole instance.
+type Run struct {
+	ID         string     `json:"id"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+	// Repair is set when repairable issues were fixed.
+	Repair bool `json:"repair"`
+	// TriggeredBy is the administrator who ran it, nil for scheduled runs.
+	TriggeredBy *string         `json:"triggeredBy"`
+	Found       int             `json:"found"`
+	Repaired    int             `json:"repaired"`
+	Checks      []*CheckSummary `json:"checks"`
+}
+
+// Issue is one inconsistency found by a check.
+type Issue struct {
+	Check  string `json:"check"`
+	UserID string `json:"userId"`
+	// SubjectID is the email, application or interview concerned.
+	SubjectID  string `json:"subjectId"`
+	Detail     string `json:"detail"`
+	Repairable bool   `json:"repairable"`
+	Repaired   bool   `json:"repaired"`
+}
+
+// Report is the recent history of integrity checks for administrators.
+type Report struct {
+	Runs []*Run `json:"runs"` // newest first
+	// Issues are those recorded by the latest finished run.
+	Issues []*Issue `json:"issues"`
+}
+
+// check finds one kind of issue. find returns user_id, subject_id, detail,
+// repairable and the total count of issues, limited to $1 rows; repair
+// fixes the given subjects where still needed, returning those it fixed.
+type check struct {
+	name       string
+	repairable bool
+	find       string
+	repair     string
+}
+
+var checks = []check{
+	{
+		// Usually a link the pipeline wrote to the application but not the
+		// email; those are relinked. Emails linked to another user's
+		// application count as orphaned too.
+		name:       CheckOrphanedEmail,
+		repairable: true,
+		find: `
+			SELECT e.user_id, e.id, COALESCE(NULLIF(e.subject, ''), '(no subject)'),
+				EXISTS (SELECT 1 FROM applications a WHERE a.email_id = e.id AND a.user_id = e.user_id),
+				COUNT(*) OVER ()
+			FROM email_cache e
+			LEFT JOIN applications l ON l.id = e.application_id
+			WHERE e.is_job_related AND (e.application_id IS NULL OR l.user_id <> e.user_id)
+			ORDER BY e.processed_at DESC
+			LIMIT $1`,
+		repair: `
+			UPDATE email_cache e SET application_id = a.id
+			FROM applications a
+			WHERE e.id = ANY($1) AND a.email_id = e.id AND a.user_id = e.user_id
+			RETURNING e.id`,
+	},
+	{
+		// Only the user knows the company, so these are reported only.
+		name: CheckMissingCompany,
+		find: `
+			SELECT user_id, id::text, position, FALSE, COUNT(*) OVER ()
+			FROM applications
+			WHERE TRIM(company) = ''
+			ORDER BY created_at DESC
+			LIMIT $1`,
+	},
+	{
+		// Interviews a day past their end are taken to have happened.
+		name:       CheckStaleInterview,
+		repairable: true,
+		find: `
+			SELECT user_id, id::text, title || ' ended ' || to_char(ends_at AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI') || ' UTC',
+				TRUE, COUNT(*) OVER ()
+			FROM interviews
+			WHERE status = 'scheduled' AND ends_at < CURRENT_TIMESTAMP - INTERVAL '1 day'
+			ORDER BY ends_at
+			LIMIT $1`,
+		repair: `
+			UPDATE interviews SET status = 'completed'
+			WHERE id::text = ANY($1) AND status = 'scheduled' AND ends_at < CURRENT_TIMESTAMP - INTERVAL '1 day'
+			RETURNING id::text`,
+	},
+	{
+		// The missing entry is dated when the application was last
+		// updated, and never before the history's last entry.
+		name:       CheckStatusHistoryGap,
+		repairable: true,
+		find: `
+			SELECT a.user_id, a.id::text,
+				CASE WHEN h.status IS NULL THEN 'no status history for ' || a.status
+					ELSE 'history ends at ' || h.status || ' but the status is ' || a.status END,
+				TRUE, COUNT(*) OVER ()
+			FROM applications a
+			LEFT JOIN LATERAL (
+				SELECT status FROM application_status_history
+				WHERE application_id = a.id ORDER BY changed_at DESC LIMIT 1
+			) h ON TRUE
+			WHERE h.status IS DISTINCT FROM a.status
+			ORDER BY a.updated_at DESC
+			LIMIT $1`,
+		repair: `
+			INSERT INTO application_status_history (application_id, user_id, status, changed_at)
+			SELECT a.id, a.user_id, a.status,
+				GREATEST(COALESCE(a.updated_at, a.created_at, CURRENT_TIMESTAMP), COALESCE(h.changed_at, '-infinity'))
+			FROM applications a
+			LEFT JOIN LATERAL (
+				SELECT status, changed_at FROM application_status_history
+				WHERE application_id = a.id ORDER BY changed_at DESC LIMIT 1
+			) h ON TRUE
+			WHERE a.id::text = ANY($1) AND h.status IS DISTINCT FROM a.status
+			RETURNING application_id::text`,
+	},
+}
+
+// Service runs integrity checks.
+type Service struct {
+	cfg *config.Config
+	db  *sql.DB
+}
+
+// NewService creates an integrity checker.
+func NewService(cfg *config.Config, db *sql.DB) *Service {
+	return &Service{cfg: cfg, db: db}
+}
+
+// Check runs every check, repairing issues if INTEGRITY_AUTO_REPAIR is set,
+// and drops runs older than 30 days. It is intended to run daily from the
+// scheduler.
+func (s *Service) Check(ctx context.Context) error {
+	if _, err := s.run(ctx, s.cfg.IntegrityAutoRepair, nil); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM integrity_runs WHERE started_at < $1`, time.Now().Add(-keepRuns))
+	return err
+}
+
+// RunNow runs every check on demand, for administrators only.
+func (s *Service) RunNow(ctx context.Context, adminID string, repair bool) (*Run, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	return s.run(ctx, repair, &adminID)
+}
+
+func (s *Service) run(ctx context.Context, repair bool, triggeredBy *string) (*Run, error) {
+	run := &Run{StartedAt: time.Now(), Repair: repair, TriggeredBy: triggeredBy, Checks: []*CheckSummary{}}
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO integrity_runs (started_at, repair, triggered_by) VALUES ($1, $2, $3) RETURNING id`,
+		run.StartedAt, repair, triggeredBy).Scan(&run.ID); err != nil {
+		return nil, err
+	}
+
+	for _, c := range checks {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		summary, err := s.runCheck(ctx, run, c)
+		if err != nil {
+			return nil, err
+		}
+		run.Checks = append(run.Checks, summary)
+		run.Found += summary.Found
+		run.Repaired += summary.Repaired
+	}
+
+	checksJSON, err := json.Marshal(run.Checks)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE integrity_runs SET finished_at = CURRENT_TIMESTAMP, found = $2, repaired = $3, checks = $4
+		WHERE id = $1
+		RETURNING finished_at`,
+		run.ID, run.Found, run.Repaired, checksJSON).Scan(&run.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if run.Found > 0 {
+		log.Printf("Integrity check %s: %d issues found, %d repaired", run.ID, run.Found, run.Repaired)
+	}
+	return run, nil
+}
+
+func (s *Service) runCheck(ctx context.Context, run *Run, c check) (*CheckSummary, error) {
+	summary := &CheckSummary{Check: c.name, Repairable: c.repairable}
+	rows, err := s.db.QueryContext(ctx, c.find, issueLimit)
+	if err != nil {
+		return nil, err
+	}
+	var issues []*Issue
+	var fixable []string
+	for rows.Next() {
+		i := &Issue{Check: c.name}
+		if err := rows.Scan(&i.UserID, &i.SubjectID, &i.Detail, &i.Repairable, &summary.Found); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		issues = append(issues, i)
+		if i.Repairable {
+			fixable = append(fixable, i.SubjectID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if run.Repair && c.repair != "" && len(fixable) > 0 {
+		repaired, err := s.repair(ctx, c, fixable)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range issues {
+			if repaired[i.SubjectID] {
+				i.Repaired = true
+				summary.Repaired++
+			}
+		}
+	}
+
+	for _, i := range issues {
+		if _, err := s.db.ExecContext(ctx, `
+			INSERT INTO integrity_issues (run_id, check_name, user_id, subject_id, detail, repairable, repaired)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT DO NOTHING`,
+			run.ID, i.Check, i.UserID, i.SubjectID, i.Detail, i.Repairable, i.Repaired); err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
+
+func (s *Service) repair(ctx context.Context, c check, ids []string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, c.repair, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	repaired := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		repaired[id] = true
+	}
+	return repaired, rows.Err()
+}
+
+// Report returns recent runs, newest first, and the issues the latest
+// finished run recorded, for administrators only.
+func (s *Service) Report(ctx context.Context, adminID string) (*Report, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+
+	r := &Report{Runs: []*Run{}, Issues: []*Issue{}}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, started_at, finished_at, repair, triggered_by, found, repaired, checks
+		FROM integrity_runs ORDER BY started_at DESC LIMIT $1`, reportRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var latest string
+	for rows.Next() {
+		run := &Run{}
+		var checksJSON []byte
+		if err := rows.Scan(&run.ID, &run.StartedAt, &run.FinishedAt, &run.Repair, &run.TriggeredBy,
+			&run.Found, &run.Repaired, &checksJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(checksJSON, &run.Checks); err != nil {
+			return nil, err
+		}
+		if latest == "" && run.FinishedAt != nil {
+			latest = run.ID
+		}
+		r.Runs = append(r.Runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if latest == "" {
+		return r, nil
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT check_name, user_id, subject_id, detail, repairable, repaired
+		FROM integrity_issues WHERE run_id = $1
+		ORDER BY check_name, repaired, subject_id`, latest)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		i := &Issue{}
+		if err := rows.Scan(&i.Check, &i.UserID, &i.SubjectID, &i.Detail, &i.Repairable, &i.Repaired); err != nil {
+			return nil, err
+		}
+		r.Issues = append(r.Issues, i)
+	}
+	return r, rows.Err()
+}
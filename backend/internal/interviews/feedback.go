@@ -0,0 +1,205 @@
+package interviews
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// feedbackWindow is how long after an interview ends the user is still
+// prompted to assess it; older interviews are left alone, e.g. after a
+// backfill.
+const feedbackWindow = 48 * time.Hour
+
+// SelfAssessment is the user's quick take on how an interview went.
+type SelfAssessment struct {
+	// Rating runs from 1 (went poorly) to 5 (went well).
+	Rating          int       `json:"rating"`
+	StruggledTopics []string  `json:"struggledTopics"` // e.g. "dynamic programming", "salary expectations"
+	Notes           *string   `json:"notes"`
+	AssessedAt      time.Time `json:"assessedAt"`
+}
+
+// SelfAssessmentInput records a self-assessment.
+type SelfAssessmentInput struct {
+	Rating          int      `json:"rating" validate:"min=1,max=5"`
+	StruggledTopics []string `json:"struggledTopics" validate:"max=10,dive,required,max=100"`
+	Notes           *string  `json:"notes" validate:"omitempty,max=10000"`
+}
+
+// Assess stores the user's self-assessment of an interview, replacing any
+// earlier one. Topics are trimmed and deduplicated case-insensitively.
+func (s *Service) Assess(ctx context.Context, userID, id string, in SelfAssessmentInput) (*Interview, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	topics := []string{}
+	for _, t := range in.StruggledTopics {
+		t = strings.Join(strings.Fields(t), " ")
+		if key := strings.ToLower(t); t != "" && !seen[key] {
+			seen[key] = true
+			topics = append(topics, t)
+		}
+	}
+	return scanInterview(s.db.QueryRowContext(ctx, `
+		UPDATE interviews SET self_rating = $3, struggled_topics = $4, self_assessment_notes = $5,
+			self_assessed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+interviewColumns,
+		id, userID, in.Rating, pq.Array(topics), in.Notes))
+}
+
+type feedbackPrompt struct {
+	id, userID, title, company string
+}
+
+// PromptSelfAssessments notifies users to assess each interview that ended
+// within the last feedbackWindow. Every interview is prompted about once,
+// and not at all if it was already assessed. It is intended to run from the
+// scheduler.
+func (s *Service) PromptSelfAssessments(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE interviews i SET self_assessment_prompted_at = CURRENT_TIMESTAMP
+		FROM applications a
+		WHERE a.id = i.application_id AND i.status <> 'cancelled'
+			AND i.ends_at <= CURRENT_TIMESTAMP AND i.ends_at > $1
+			AND i.self_assessment_prompted_at IS NULL AND i.self_assessed_at IS NULL
+		RETURNING i.id, i.user_id, i.title, a.company`,
+		time.Now().Add(-feedbackWindow))
+	if err != nil {
+		return err
+	}
+	var prompts []feedbackPrompt
+	for rows.Next() {
+		var p feedbackPrompt
+		if err := rows.Scan(&p.id, &p.userID, &p.title, &p.company); err != nil {
+			rows.Close()
+			return err
+		}
+		prompts = append(prompts, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range prompts {
+		l := s.notifier.Localizer(ctx, p.userID)
+		title := l.T("interview.feedback.title", p.title, p.company)
+		if err := s.notifier.Notify(ctx, p.userID, notifications.KindInterviewFeedback, title, l.T("interview.feedback.body")); err != nil {
+			log.Printf("Failed to prompt self-assessment of interview %s: %v", p.id, err)
+		}
+	}
+	return nil
+}
+
+// FocusTopic is a topic the user struggled with in interviews.
+type FocusTopic struct {
+	Topic      string    `json:"topic"`
+	Interviews int       `json:"interviews"`
+	LastSeenAt time.Time `json:"lastSeenAt"` // when the latest such interview started
+	// AverageRating is the mean self-rating of those interviews.
+	AverageRating float64 `json:"averageRating"`
+}
+
+// RoundTypeRating is how the user rates themselves in one type of round.
+type RoundTypeRating struct {
+	RoundType     string  `json:"roundType"`
+	Interviews    int     `json:"interviews"`
+	AverageRating float64 `json:"averageRating"`
+}
+
+// PreparationFocus aggregates the user's self-assessments into what to
+// prepare next.
+type PreparationFocus struct {
+	Assessed      int     `json:"assessed"`
+	AverageRating float64 `json:"averageRating"`
+	// Topics are struggled-with topics, most frequent first.
+	Topics []*FocusTopic `json:"topics"`
+	// RoundTypes are round types by self-rating, weakest first.
+	RoundTypes []*RoundTypeRating `json:"roundTypes"`
+}
+
+// PreparationFocus aggregates self-assessments of interviews that started
+// since the given time (all time when nil).
+func (s *Service) PreparationFocus(ctx context.Context, userID string, since *time.Time) (*PreparationFocus, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT title, starts_at, self_rating, COALESCE(struggled_topics, '{}')
+		FROM interviews
+		WHERE user_id = $1 AND self_assessed_at IS NOT NULL AND ($2::timestamptz IS NULL OR starts_at >= $2)
+		ORDER BY starts_at`, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	f := &PreparationFocus{Topics: []*FocusTopic{}, RoundTypes: []*RoundTypeRating{}}
+	topics := make(map[string]*FocusTopic)
+	topicRatings := make(map[string]int)
+	rounds := make(map[string]*RoundTypeRating)
+	roundRatings := make(map[string]int)
+	total := 0
+	for rows.Next() {
+		var title string
+		var startsAt time.Time
+		var rating int
+		var struggled []string
+		if err := rows.Scan(&title, &startsAt, &rating, pq.Array(&struggled)); err != nil {
+			return nil, err
+		}
+		f.Assessed++
+		total += rating
+		for _, t := range struggled {
+			key := strings.ToLower(t)
+			ft := topics[key]
+			if ft == nil {
+				ft = &FocusTopic{Topic: t}
+				topics[key] = ft
+				f.Topics = append(f.Topics, ft)
+			}
+			ft.Interviews++
+			ft.LastSeenAt = startsAt
+			topicRatings[key] += rating
+		}
+		rt := RoundType(title)
+		r := rounds[rt]
+		if r == nil {
+			r = &RoundTypeRating{RoundType: rt}
+			rounds[rt] = r
+			f.RoundTypes = append(f.RoundTypes, r)
+		}
+		r.Interviews++
+		roundRatings[rt] += rating
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if f.Assessed > 0 {
+		f.AverageRating = float64(total) / float64(f.Assessed)
+	}
+	for key, ft := range topics {
+		ft.AverageRating = float64(topicRatings[key]) / float64(ft.Interviews)
+	}
+	for rt, r := range rounds {
+		r.AverageRating = float64(roundRatings[rt]) / float64(r.Interviews)
+	}
+	sort.SliceStable(f.Topics, func(i, j int) bool {
+		if f.Topics[i].Interviews != f.Topics[j].Interviews {
+			return f.Topics[i].Interviews > f.Topics[j].Interviews
+		}
+		return f.Topics[i].LastSeenAt.After(f.Topics[j].LastSeenAt)
+	})
+	sort.SliceStable(f.RoundTypes, func(i, j int) bool {
+		return f.RoundTypes[i].AverageRating < f.RoundTypes[j].AverageRating
+	})
+	return f, nil
+}
@@ -0,0 +1,177 @@
+package interviews
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/ics"
+)
+
+// inviteBatch bounds the emails one run of ImportInvites reads.
+const inviteBatch = 100
+
+type inviteEmail struct {
+	emailID, userID, applicationID string
+}
+
+// ImportInvites reads the emails linked to an application that have not been
+// checked for calendar invitations yet, newest first, and imports the
+// invitations they carry as interviews. Gmail is asked for each message, as
+// calendar parts are not cached. Each email is checked once, including when
+// reading it fails, so a message that cannot be read does not hold up the
+// rest. Mailboxes that are paused or disconnected wait. It is intended to run
+// from the scheduler.
+func (s *Service) ImportInvites(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.user_id, a.id
+		FROM email_cache e
+		JOIN applications a ON a.user_id = e.user_id AND (a.id = e.application_id OR a.email_id = e.id)
+		JOIN users u ON u.id = e.user_id
+		WHERE e.invites_checked_at IS NULL AND e.gmail_deleted_at IS NULL
+			AND u.mailbox_disconnected_at IS NULL AND u.mailbox_paused_at IS NULL
+		ORDER BY e.user_id, e.date DESC NULLS LAST
+		LIMIT $1`, inviteBatch)
+	if err != nil {
+		return err
+	}
+	var pending []inviteEmail
+	for rows.Next() {
+		var e inviteEmail
+		if err := rows.Scan(&e.emailID, &e.userID, &e.applicationID); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var svc *gmail.Service
+	var svcUser string
+	for _, e := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if e.userID != svcUser {
+			svc, svcUser = nil, e.userID
+			ts, err := s.tokens.TokenSource(ctx, e.userID)
+			if err == nil {
+				svc, err = gmail.NewService(ctx, option.WithTokenSource(ts))
+			}
+			if err != nil {
+				log.Printf("Failed to read calendar invites of user %s: %v", e.userID, err)
+			}
+		}
+		if svc == nil {
+			continue
+		}
+		msg, err := svc.Users.Messages.Get("me", e.emailID).Format("full").Context(ctx).Do()
+		if err == nil {
+			_, err = s.ImportMessageInvites(ctx, svc, e.userID, e.applicationID, msg)
+		}
+		if err != nil {
+			log.Printf("Failed to import calendar invites from email %s: %v", e.emailID, err)
+		}
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE email_cache SET invites_checked_at = CURRENT_TIMESTAMP WHERE id = $1`, e.emailID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportMessageInvites imports every calendar invitation attached to a synced
+// Gmail message as interviews on the given application. ImportInvites calls
+// it for emails linked to an application.
+func (s *Service) ImportMessageInvites(ctx context.Context, svc *gmail.Service, userID, applicationID string, msg *gmail.Message) ([]*Interview, error) {
+	cals, err := ics.FromMessage(ctx, svc, "me", msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*Interview
+	for _, cal := range cals {
+		for _, ev := range cal.Events {
+			iv, err := s.ImportInvite(ctx, userID, applicationID, cal.Method, ev)
+			if err != nil {
+				return out, err
+			}
+			if iv != nil {
+				out = append(out, iv)
+			}
+		}
+	}
+	return out, nil
+}
+
+// ImportInvite creates or updates the interview described by a calendar
+// invitation found in an email. Invites are matched on their iCalendar UID,
+// so rescheduled invites update the existing interview and cancellations
+// cancel it. Invites with no start time are ignored.
+func (s *Service) ImportInvite(ctx context.Context, userID, applicationID, method string, ev *ics.Event) (*Interview, error) {
+	if ev.Start.IsZero() || ev.UID == "" {
+		return nil, nil
+	}
+
+	status := StatusScheduled
+	if ev.Cancelled(method) {
+		status = StatusCancelled
+	}
+
+	in := InterviewInput{
+		ApplicationID: applicationID,
+		Title:         ev.Summary,
+		StartsAt:      ev.Start,
+		EndsAt:        ev.End,
+		Timezone:      &ev.Timezone,
+		Location:      optional(ev.Location),
+		MeetingLink:   optional(ev.MeetingLink),
+		Status:        &status,
+	}
+	if in.Title == "" {
+		in.Title = "Interview"
+	}
+	// The organizer is the best guess at the interviewer until the user
+	// says otherwise.
+	if addr, err := mail.ParseAddress(ev.Organizer); err == nil {
+		in.InterviewerEmail = &addr.Address
+	}
+
+	existing, err := scanInterview(s.db.QueryRowContext(ctx,
+		`SELECT `+interviewColumns+` FROM interviews WHERE user_id = $1 AND ical_uid = $2`, userID, ev.UID))
+	switch {
+	case err == nil:
+		if existing.InterviewerEmail != nil || existing.InterviewerName != nil {
+			in.InterviewerName, in.InterviewerEmail = existing.InterviewerName, existing.InterviewerEmail
+		}
+		return s.Update(ctx, userID, existing.ID, in)
+	case !errors.Is(err, ErrNotFound):
+		return nil, err
+	case status == StatusCancelled:
+		// Cancellation for an invite we never saw.
+		return nil, nil
+	}
+
+	iv, err := s.Create(ctx, userID, in)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE interviews SET ical_uid = $2 WHERE id = $1`, iv.ID, ev.UID); err != nil {
+		return nil, err
+	}
+	return iv, nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
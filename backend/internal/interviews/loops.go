@@ -0,0 +1,299 @@
+package interviews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Round statuses.
+const (
+	RoundPending   = "pending"
+	RoundScheduled = "scheduled"
+	RoundPassed    = "passed"
+	RoundFailed    = "failed"
+	RoundSkipped   = "skipped"
+)
+
+// Loop outcomes, derived from the rounds.
+const (
+	LoopInProgress = "in_progress"
+	LoopPassed     = "passed"
+	LoopFailed     = "failed"
+)
+
+var (
+	// ErrLoopNotFound is returned when a loop does not exist or belongs to
+	// another user.
+	ErrLoopNotFound = apperr.New(apperr.NotFound, "interview loop not found")
+	// ErrRoundNotFound is returned when a round does not exist or belongs to
+	// another user.
+	ErrRoundNotFound = apperr.New(apperr.NotFound, "interview round not found")
+	// ErrApplicationNotFound is returned when creating a loop for an
+	// application the user does not own.
+	ErrApplicationNotFound = apperr.New(apperr.NotFound, "application not found")
+)
+
+// Round is one step of an interview loop.
+type Round struct {
+	ID          string    `json:"id"`
+	Position    int       `json:"position"` // 1-based order within the loop
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Feedback    *string   `json:"feedback"`
+	InterviewID *string   `json:"interviewId"` // the scheduled interview for the round
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Loop is a named sequence of interview rounds for an application, such as
+// recruiter screen, technical phone screen and onsite.
+type Loop struct {
+	ID            string    `json:"id"`
+	ApplicationID string    `json:"applicationId"`
+	Company       string    `json:"company"`
+	Position      string    `json:"position"`
+	Name          string    `json:"name"`
+	Rounds        []*Round  `json:"rounds"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Outcome is in_progress until a round is failed or every round is passed
+// or skipped.
+func (l *Loop) Outcome() string {
+	done := 0
+	for _, r := range l.Rounds {
+		switch r.Status {
+		case RoundFailed:
+			return LoopFailed
+		case RoundPassed, RoundSkipped:
+			done++
+		}
+	}
+	if len(l.Rounds) > 0 && done == len(l.Rounds) {
+		return LoopPassed
+	}
+	return LoopInProgress
+}
+
+// Current returns the first round not yet passed or skipped, which is where
+// the user is in the loop, or nil once the loop is over.
+func (l *Loop) Current() *Round {
+	if l.Outcome() != LoopInProgress {
+		return nil
+	}
+	for _, r := range l.Rounds {
+		if r.Status != RoundPassed && r.Status != RoundSkipped {
+			return r
+		}
+	}
+	return nil
+}
+
+// CompletedRounds counts the rounds passed or skipped.
+func (l *Loop) CompletedRounds() int {
+	n := 0
+	for _, r := range l.Rounds {
+		if r.Status == RoundPassed || r.Status == RoundSkipped {
+			n++
+		}
+	}
+	return n
+}
+
+// LoopInput creates a loop with its rounds in order.
+type LoopInput struct {
+	ApplicationID string   `json:"applicationId" validate:"required,uuid"`
+	Name          string   `json:"name" validate:"required,max=255"`
+	Rounds        []string `json:"rounds" validate:"min=1,max=20,dive,required,max=255"`
+}
+
+// RoundInput updates a round; nil fields are left unchanged.
+type RoundInput struct {
+	Name        *string `json:"name" validate:"omitempty,max=255"`
+	Status      *string `json:"status" validate:"omitempty,oneof=pending scheduled passed failed skipped"`
+	Feedback    *string `json:"feedback" validate:"omitempty,max=10000"`
+	InterviewID *string `json:"interviewId" validate:"omitempty,uuid"`
+	// ExtractQuestions adds the questions asked, found in Feedback, to the
+	// user's question bank.
+	ExtractQuestions bool `json:"extractQuestions"`
+}
+
+const loopColumns = `l.id, l.application_id, a.company, a.position, l.name, l.created_at, l.updated_at`
+
+// CreateLoop adds an interview loop to one of the user's applications.
+func (s *Service) CreateLoop(ctx context.Context, userID string, in LoopInput) (*Loop, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO interview_loops (application_id, user_id, name)
+		SELECT a.id, a.user_id, $3 FROM applications a WHERE a.id = $1 AND a.user_id = $2
+		RETURNING id`,
+		in.ApplicationID, userID, strings.TrimSpace(in.Name)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrApplicationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range in.Rounds {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO interview_rounds (loop_id, position, name) VALUES ($1, $2, $3)`,
+			id, i+1, strings.TrimSpace(name)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Loop(ctx, userID, id)
+}
+
+// Loop returns one of the user's loops with its rounds.
+func (s *Service) Loop(ctx context.Context, userID, id string) (*Loop, error) {
+	loops, err := s.loops(ctx, `l.id = $2`, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(loops) == 0 {
+		return nil, ErrLoopNotFound
+	}
+	return loops[0], nil
+}
+
+// Loops returns the user's loops, newest first, optionally for one
+// application or for every application to a company (matched ignoring
+// case), so "where am I in the Google loop?" is a single lookup.
+func (s *Service) Loops(ctx context.Context, userID string, applicationID, company *string) ([]*Loop, error) {
+	var c *string
+	if company != nil {
+		trimmed := strings.TrimSpace(*company)
+		c = &trimmed
+	}
+	return s.loops(ctx, `($2::uuid IS NULL OR l.application_id = $2::uuid) AND ($3::text IS NULL OR LOWER(a.company) = LOWER($3))`,
+		userID, applicationID, c)
+}
+
+func (s *Service) loops(ctx context.Context, cond string, userID string, args ...any) ([]*Loop, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+loopColumns+`
+		FROM interview_loops l JOIN applications a ON a.id = l.application_id
+		WHERE l.user_id = $1 AND `+cond+`
+		ORDER BY l.created_at DESC`,
+		append([]any{userID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	var out []*Loop
+	byID := make(map[string]*Loop)
+	var ids []string
+	for rows.Next() {
+		l := &Loop{Rounds: []*Round{}}
+		if err := rows.Scan(&l.ID, &l.ApplicationID, &l.Company, &l.Position, &l.Name, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, l)
+		byID[l.ID] = l
+		ids = append(ids, l.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return out, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT loop_id, id, position, name, status, feedback, interview_id, updated_at
+		FROM interview_rounds WHERE loop_id = ANY($1::uuid[])
+		ORDER BY loop_id, position`,
+		pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r := &Round{}
+		var loopID string
+		if err := rows.Scan(&loopID, &r.ID, &r.Position, &r.Name, &r.Status, &r.Feedback, &r.InterviewID, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		byID[loopID].Rounds = append(byID[loopID].Rounds, r)
+	}
+	return out, rows.Err()
+}
+
+// UpdateRound records a round's status or feedback, or links it to the
+// interview it was held in, and returns the loop it belongs to. The
+// interview must belong to the loop's application. A failure to extract
+// questions from the feedback is logged and does not fail the update.
+func (s *Service) UpdateRound(ctx context.Context, userID, roundID string, in RoundInput) (*Loop, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	var loopID string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE interview_rounds r SET name = COALESCE($3, r.name), status = COALESCE($4, r.status),
+			feedback = COALESCE($5, r.feedback), interview_id = COALESCE($6::uuid, r.interview_id),
+			updated_at = CURRENT_TIMESTAMP
+		FROM interview_loops l
+		WHERE l.id = r.loop_id AND r.id = $1 AND l.user_id = $2
+			AND ($6::uuid IS NULL OR EXISTS (
+				SELECT 1 FROM interviews i WHERE i.id = $6::uuid AND i.application_id = l.application_id))
+		RETURNING l.id`,
+		roundID, userID, in.Name, in.Status, in.Feedback, in.InterviewID).Scan(&loopID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Tell a missing round apart from an interview for another application.
+		var exists bool
+		if in.InterviewID != nil {
+			if err := s.db.QueryRowContext(ctx, `
+				SELECT EXISTS (SELECT 1 FROM interview_rounds r JOIN interview_loops l ON l.id = r.loop_id
+				WHERE r.id = $1 AND l.user_id = $2)`, roundID, userID).Scan(&exists); err != nil {
+				return nil, err
+			}
+		}
+		if exists {
+			return nil, validation.Field("interviewId", "must be an interview for the loop's application")
+		}
+		return nil, ErrRoundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE interview_loops SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, loopID); err != nil {
+		return nil, err
+	}
+	if in.ExtractQuestions && in.Feedback != nil {
+		if err := s.extractQuestions(ctx, userID, roundID, *in.Feedback); err != nil {
+			log.Printf("Failed to extract interview questions from round %s: %v", roundID, err)
+		}
+	}
+	return s.Loop(ctx, userID, loopID)
+}
+
+// DeleteLoop removes a loop and its rounds. Linked interviews are kept.
+func (s *Service) DeleteLoop(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM interview_loops WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrLoopNotFound
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package interviews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Round types questions are tagged with, inferred from round names.
+const (
+	RoundTypeRecruiter     = "recruiter_screen"
+	RoundTypeTechnical     = "technical"
+	RoundTypeSystemDesign  = "system_design"
+	RoundTypeBehavioral    = "behavioral"
+	RoundTypeHiringManager = "hiring_manager"
+	RoundTypeTakeHome      = "take_home"
+	RoundTypeOnsite        = "onsite"
+	RoundTypeOther         = "other"
+)
+
+// roundTypeKeywords maps words in round names to round types, checked in
+// order so "technical phone screen" is technical rather than a recruiter
+// screen.
+var roundTypeKeywords = []struct {
+	keyword, roundType string
+}{
+	{"system design", RoundTypeSystemDesign},
+	{"architecture", RoundTypeSystemDesign},
+	{"take-home", RoundTypeTakeHome},
+	{"take home", RoundTypeTakeHome},
+	{"assignment", RoundTypeTakeHome},
+	{"behavio", RoundTypeBehavioral},
+	{"culture", RoundTypeBehavioral},
+	{"values", RoundTypeBehavioral},
+	{"hiring manager", RoundTypeHiringManager},
+	{"manager", RoundTypeHiringManager},
+	{"technical", RoundTypeTechnical},
+	{"coding", RoundTypeTechnical},
+	{"algorithm", RoundTypeTechnical},
+	{"pair", RoundTypeTechnical},
+	{"onsite", RoundTypeOnsite},
+	{"on-site", RoundTypeOnsite},
+	{"final", RoundTypeOnsite},
+	{"recruiter", RoundTypeRecruiter},
+	{"phone screen", RoundTypeRecruiter},
+	{"screen", RoundTypeRecruiter},
+}
+
+// RoundType infers the type of a round from its name.
+func RoundType(name string) string {
+	name = strings.ToLower(name)
+	for _, k := range roundTypeKeywords {
+		if strings.Contains(name, k.keyword) {
+			return k.roundType
+		}
+	}
+	return RoundTypeOther
+}
+
+// ErrQuestionNotFound is returned when a question does not exist or belongs
+// to another user.
+var ErrQuestionNotFound = apperr.New(apperr.NotFound, "interview question not found")
+
+// Question is an interview question the user was asked, kept for prep.
+type Question struct {
+	ID            string    `json:"id"`
+	Question      string    `json:"question"`
+	Company       string    `json:"company"`
+	Role          string    `json:"role"`
+	RoundType     string    `json:"roundType"`
+	RoundID       *string   `json:"roundId"` // the round it was extracted from
+	ApplicationID *string   `json:"applicationId"`
+	Notes         *string   `json:"notes"` // how the user answered, or what to prepare
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// QuestionInput adds a question to the bank by hand.
+type QuestionInput struct {
+	Question  string  `json:"question" validate:"required,max=1000"`
+	Company   string  `json:"company" validate:"required,max=255"`
+	Role      string  `json:"role" validate:"required,max=1000"`
+	RoundType string  `json:"roundType" validate:"omitempty,oneof=recruiter_screen technical system_design behavioral hiring_manager take_home onsite other"`
+	Notes     *string `json:"notes" validate:"omitempty,max=10000"`
+}
+
+// QuestionFilter narrows the bank. Company, role and search match literal,
+// case-insensitive substrings, so "engineer" finds questions from every
+// engineering role.
+type QuestionFilter struct {
+	Company   *string `json:"company"`
+	Role      *string `json:"role"`
+	RoundType *string `json:"roundType"`
+	Search    *string `json:"search"`
+}
+
+const questionColumns = `id, question, company, role, round_type, round_id, application_id, notes, created_at`
+
+func scanQuestion(row scanner) (*Question, error) {
+	q := &Question{}
+	err := row.Scan(&q.ID, &q.Question, &q.Company, &q.Role, &q.RoundType, &q.RoundID, &q.ApplicationID, &q.Notes, &q.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestionNotFound
+	}
+	return q, err
+}
+
+// Questions returns the user's question bank, newest first.
+func (s *Service) Questions(ctx context.Context, userID string, f QuestionFilter) ([]*Question, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+questionColumns+` FROM interview_questions
+		WHERE user_id = $1
+			AND ($2::text IS NULL OR strpos(LOWER(company), LOWER($2)) > 0)
+			AND ($3::text IS NULL OR strpos(LOWER(role), LOWER($3)) > 0)
+			AND ($4::text IS NULL OR round_type = $4)
+			AND ($5::text IS NULL OR strpos(LOWER(question), LOWER($5)) > 0 OR strpos(LOWER(notes), LOWER($5)) > 0)
+		ORDER BY created_at DESC`,
+		userID, f.Company, f.Role, f.RoundType, f.Search)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Question
+	for rows.Next() {
+		q, err := scanQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, q)
+	}
+	return out, rows.Err()
+}
+
+// AddQuestion adds a question to the user's bank. RoundType defaults to
+// other.
+func (s *Service) AddQuestion(ctx context.Context, userID string, in QuestionInput) (*Question, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	if in.RoundType == "" {
+		in.RoundType = RoundTypeOther
+	}
+	return scanQuestion(s.db.QueryRowContext(ctx, `
+		INSERT INTO interview_questions (user_id, question, company, role, round_type, notes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+questionColumns,
+		userID, strings.TrimSpace(in.Question), in.Company, in.Role, in.RoundType, in.Notes))
+}
+
+// DeleteQuestion removes a question from the user's bank.
+func (s *Service) DeleteQuestion(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM interview_questions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrQuestionNotFound
+	}
+	return nil
+}
+
+// extractQuestions adds the questions found in a round's feedback to the
+// user's bank, tagged with the application's company and role and the
+// round's type. Questions already extracted from the round are skipped.
+func (s *Service) extractQuestions(ctx context.Context, userID, roundID, feedback string) error {
+	questions := ExtractQuestions(feedback)
+	if len(questions) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	roundType := roundTypeOf(ctx, tx, roundID)
+	for _, q := range questions {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO interview_questions (user_id, question, company, role, round_type, round_id, application_id)
+			SELECT l.user_id, $3, a.company, a.position, $4, r.id, a.id
+			FROM interview_rounds r
+			JOIN interview_loops l ON l.id = r.loop_id
+			JOIN applications a ON a.id = l.application_id
+			WHERE r.id = $1 AND l.user_id = $2
+			ON CONFLICT (round_id, LOWER(question)) DO NOTHING`,
+			roundID, userID, q, roundType); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// roundTypeOf infers a round's type from its name, or from the name of the
+// loop when the round's name says nothing.
+func roundTypeOf(ctx context.Context, tx *sql.Tx, roundID string) string {
+	var round, loop string
+	if err := tx.QueryRowContext(ctx, `
+		SELECT r.name, l.name FROM interview_rounds r JOIN interview_loops l ON l.id = r.loop_id
+		WHERE r.id = $1`, roundID).Scan(&round, &loop); err != nil {
+		return RoundTypeOther
+	}
+	if t := RoundType(round); t != RoundTypeOther {
+		return t
+	}
+	return RoundType(loop)
+}
+
+var (
+	// bullet matches list markers: "-", "*", "•", "1.", "2)".
+	bullet = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+	// askedPrefix matches how notes introduce a question.
+	askedPrefix = regexp.MustCompile(`(?i)^(?:q\d*\s*[:.-]|question\s*\d*\s*[:.-]|asked\s*(?:me\s*)?[:-]?|they asked\s*(?:me\s*)?[:-]?|(?:the\s+)?interviewer asked\s*(?:me\s*)?[:-]?)\s*`)
+	// sentence matches a sentence ending in a question mark.
+	sentence = regexp.MustCompile(`[^.!?]*\?`)
+)
+
+// ExtractQuestions finds the questions in post-interview notes: sentences
+// ending in a question mark and list items or lines introduced with "Q:",
+// "Asked:", "They asked about" and the like.
+func ExtractQuestions(notes string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(q string) {
+		q = strings.TrimSpace(strings.Trim(strings.TrimSpace(q), `"'“”`))
+		key := strings.ToLower(q)
+		if len([]rune(q)) < 10 || len(q) > 1000 || seen[key] {
+			return
+		}
+		seen[key] = true
+		out = append(out, q)
+	}
+	for _, line := range strings.Split(notes, "\n") {
+		line = bullet.ReplaceAllString(line, "")
+		prefixed := askedPrefix.MatchString(line)
+		line = askedPrefix.ReplaceAllString(line, "")
+		if strings.Contains(line, "?") {
+			for _, q := range sentence.FindAllString(line, -1) {
+				add(q)
+			}
+			continue
+		}
+		if prefixed {
+			add(line)
+		}
+	}
+	return out
+}
@@ -0,0 +1,266 @@
+package interviews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/googleauth"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Interview statuses.
+const (
+	StatusScheduled = "scheduled"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// ErrNotFound is returned when an interview does not exist or belongs to
+// another user.
+var ErrNotFound = apperr.New(apperr.NotFound, "interview not found")
+
+// Interview is a scheduled conversation for an application.
+type Interview struct {
+	ID               string    `json:"id"`
+	ApplicationID    string    `json:"applicationId"`
+	UserID           string    `json:"-"`
+	Title            string    `json:"title"`
+	StartsAt         time.Time `json:"startsAt"`
+	EndsAt           time.Time `json:"endsAt"`
+	Timezone         string    `json:"timezone"`
+	Location         *string   `json:"location"`
+	MeetingLink      *string   `json:"meetingLink"`
+	InterviewerName  *string   `json:"interviewerName"` // who to thank afterwards
+	InterviewerEmail *string   `json:"interviewerEmail"`
+	Status           string    `json:"status"`
+	CalendarEventID  *string   `json:"calendarEventId"`
+	// SelfAssessment is how the user felt it went, once they said.
+	SelfAssessment *SelfAssessment `json:"selfAssessment"`
+	// Transcript is the user's notes or a transcript of the conversation.
+	Transcript *string `json:"transcript"`
+	// TranscriptSummary is set once the transcript has been summarized.
+	TranscriptSummary *TranscriptSummary `json:"transcriptSummary"`
+	CreatedAt         time.Time          `json:"createdAt"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+}
+
+// InterviewInput creates or updates an interview.
+type InterviewInput struct {
+	ApplicationID    string    `json:"applicationId" validate:"required,uuid"`
+	Title            string    `json:"title" validate:"required,max=255"`
+	StartsAt         time.Time `json:"startsAt" validate:"required"`
+	EndsAt           time.Time `json:"endsAt" validate:"required,gtfield=StartsAt"`
+	Timezone         *string   `json:"timezone" validate:"omitempty,timezone"`
+	Location         *string   `json:"location" validate:"omitempty,max=255"`
+	MeetingLink      *string   `json:"meetingLink" validate:"omitempty,url,max=2048"`
+	InterviewerName  *string   `json:"interviewerName" validate:"omitempty,max=255"`
+	InterviewerEmail *string   `json:"interviewerEmail" validate:"omitempty,email,max=255"`
+	Status           *string   `json:"status" validate:"omitempty,oneof=scheduled completed cancelled"`
+}
+
+// Hook is notified after interviews change, e.g. to mirror them into an
+// external calendar. Hook errors are logged and do not fail the change.
+type Hook interface {
+	InterviewSaved(ctx context.Context, iv *Interview) error
+	InterviewDeleted(ctx context.Context, iv *Interview) error
+}
+
+// Service manages interviews.
+type Service struct {
+	db       *sql.DB
+	notifier *notifications.Service
+	agents   *agents.Client
+	tokens   *googleauth.TokenStore
+	hooks    []Hook
+}
+
+// NewService creates an interview service. Prompts to assess finished
+// interviews go through the notifier, transcripts are summarized by the
+// agents client, and calendar invitations are read from Gmail with the
+// user's token.
+func NewService(db *sql.DB, notifier *notifications.Service, agentsClient *agents.Client, tokens *googleauth.TokenStore) *Service {
+	return &Service{db: db, notifier: notifier, agents: agentsClient, tokens: tokens}
+}
+
+// AddHook registers a hook that observes interview changes.
+func (s *Service) AddHook(h Hook) {
+	s.hooks = append(s.hooks, h)
+}
+
+const interviewColumns = `id, application_id, user_id, title, starts_at, ends_at, timezone,
+	location, meeting_link, interviewer_name, interviewer_email, status, calendar_event_id,
+	self_rating, struggled_topics, self_assessment_notes, self_assessed_at,
+	transcript, transcript_takeaways, transcript_action_items, transcript_summarized_at, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInterview(row scanner) (*Interview, error) {
+	iv := &Interview{}
+	var rating sql.NullInt64
+	var topics []string
+	var notes *string
+	var assessedAt, summarizedAt *time.Time
+	var takeaways, actionItems []string
+	err := row.Scan(&iv.ID, &iv.ApplicationID, &iv.UserID, &iv.Title, &iv.StartsAt, &iv.EndsAt,
+		&iv.Timezone, &iv.Location, &iv.MeetingLink, &iv.InterviewerName, &iv.InterviewerEmail, &iv.Status, &iv.CalendarEventID,
+		&rating, pq.Array(&topics), &notes, &assessedAt,
+		&iv.Transcript, pq.Array(&takeaways), pq.Array(&actionItems), &summarizedAt, &iv.CreatedAt, &iv.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err == nil && assessedAt != nil {
+		iv.SelfAssessment = &SelfAssessment{
+			Rating: int(rating.Int64), StruggledTopics: topics, Notes: notes, AssessedAt: *assessedAt,
+		}
+		if iv.SelfAssessment.StruggledTopics == nil {
+			iv.SelfAssessment.StruggledTopics = []string{}
+		}
+	}
+	if err == nil && summarizedAt != nil {
+		iv.TranscriptSummary = &TranscriptSummary{
+			Takeaways: nonNil(takeaways), ActionItems: nonNil(actionItems), SummarizedAt: *summarizedAt,
+		}
+	}
+	return iv, err
+}
+
+// Get returns a single interview.
+func (s *Service) Get(ctx context.Context, userID, id string) (*Interview, error) {
+	return scanInterview(s.db.QueryRowContext(ctx,
+		`SELECT `+interviewColumns+` FROM interviews WHERE id = $1 AND user_id = $2`, id, userID))
+}
+
+// List returns the user's interviews, optionally limited to one application.
+func (s *Service) List(ctx context.Context, userID string, applicationID *string) ([]*Interview, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+interviewColumns+` FROM interviews
+		WHERE user_id = $1 AND ($2::uuid IS NULL OR application_id = $2::uuid)
+		ORDER BY starts_at`,
+		userID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Interview
+	for rows.Next() {
+		iv, err := scanInterview(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, iv)
+	}
+	return out, rows.Err()
+}
+
+// Create adds an interview to one of the user's applications.
+func (s *Service) Create(ctx context.Context, userID string, in InterviewInput) (*Interview, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	iv, err := scanInterview(s.db.QueryRowContext(ctx, `
+		INSERT INTO interviews (application_id, user_id, title, starts_at, ends_at, timezone, location, meeting_link,
+			interviewer_name, interviewer_email, status)
+		SELECT a.id, a.user_id, $3, $4, $5, COALESCE($6, u.timezone, 'UTC'), $7, $8, $10, $11, COALESCE($9, 'scheduled')
+		FROM applications a LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.id = $1 AND a.user_id = $2
+		RETURNING `+interviewColumns,
+		in.ApplicationID, userID, in.Title, in.StartsAt, in.EndsAt, in.Timezone, in.Location, in.MeetingLink, in.Status,
+		in.InterviewerName, in.InterviewerEmail))
+	if err != nil {
+		return nil, err
+	}
+	s.saved(ctx, iv)
+	return iv, nil
+}
+
+// Update replaces the editable fields of an interview.
+func (s *Service) Update(ctx context.Context, userID, id string, in InterviewInput) (*Interview, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	iv, err := scanInterview(s.db.QueryRowContext(ctx, `
+		UPDATE interviews SET title = $3, starts_at = $4, ends_at = $5, timezone = COALESCE($6, timezone),
+			location = $7, meeting_link = $8, status = COALESCE($9, status),
+			interviewer_name = $10, interviewer_email = $11
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+interviewColumns,
+		id, userID, in.Title, in.StartsAt, in.EndsAt, in.Timezone, in.Location, in.MeetingLink, in.Status,
+		in.InterviewerName, in.InterviewerEmail))
+	if err != nil {
+		return nil, err
+	}
+	s.saved(ctx, iv)
+	return iv, nil
+}
+
+// Delete removes an interview.
+func (s *Service) Delete(ctx context.Context, userID, id string) error {
+	iv, err := scanInterview(s.db.QueryRowContext(ctx,
+		`DELETE FROM interviews WHERE id = $1 AND user_id = $2 RETURNING `+interviewColumns, id, userID))
+	if err != nil {
+		return err
+	}
+	for _, h := range s.hooks {
+		if err := h.InterviewDeleted(ctx, iv); err != nil {
+			log.Printf("Interview hook failed on delete of %s: %v", iv.ID, err)
+		}
+	}
+	return nil
+}
+
+// Reschedule moves an interview without notifying hooks. It is used when the
+// change originated in the external system the hooks sync to.
+func (s *Service) Reschedule(ctx context.Context, id string, startsAt, endsAt time.Time, status string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE interviews SET starts_at = $2, ends_at = $3, status = $4 WHERE id = $1`,
+		id, startsAt, endsAt, status)
+	return err
+}
+
+// SetCalendarEvent stores the external calendar event linked to an interview.
+func (s *Service) SetCalendarEvent(ctx context.Context, id string, eventID *string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE interviews SET calendar_event_id = $2 WHERE id = $1`, id, eventID)
+	return err
+}
+
+// Linked returns upcoming interviews that have a calendar event attached,
+// across all users, for reconciliation.
+func (s *Service) Linked(ctx context.Context, since time.Time) ([]*Interview, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+interviewColumns+` FROM interviews
+		WHERE calendar_event_id IS NOT NULL AND ends_at >= $1 AND status <> 'cancelled'
+		ORDER BY user_id, starts_at`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Interview
+	for rows.Next() {
+		iv, err := scanInterview(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, iv)
+	}
+	return out, rows.Err()
+}
+
+func (s *Service) saved(ctx context.Context, iv *Interview) {
+	for _, h := range s.hooks {
+		if err := h.InterviewSaved(ctx, iv); err != nil {
+			log.Printf("Interview hook failed on save of %s: %v", iv.ID, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package interviews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// maxTranscript caps an interview transcript in characters, roughly a two
+// hour conversation.
+const maxTranscript = 100000
+
+// ErrNoTranscript is returned when summarizing an interview that has no
+// notes or transcript attached.
+var ErrNoTranscript = apperr.New(apperr.Conflict, "interview has no notes or transcript to summarize")
+
+// ErrSummarizerUnavailable is returned when summarizing without an agents
+// service to do it.
+var ErrSummarizerUnavailable = apperr.New(apperr.Unavailable, "interview summaries are not available")
+
+// TranscriptSummary is what the LLM pulled out of an interview transcript.
+type TranscriptSummary struct {
+	Takeaways    []string  `json:"takeaways"`
+	ActionItems  []string  `json:"actionItems"` // follow-ups for the user, e.g. "Send the article on rate limiting"
+	SummarizedAt time.Time `json:"summarizedAt"`
+}
+
+// AttachTranscript stores the user's notes or a transcript of an interview,
+// replacing any earlier one; an empty transcript removes it. Changing the
+// transcript discards its summary, which no longer matches it.
+func (s *Service) AttachTranscript(ctx context.Context, userID, id, transcript string) (*Interview, error) {
+	transcript = strings.TrimSpace(transcript)
+	if len([]rune(transcript)) > maxTranscript {
+		return nil, validation.Field("transcript", "must be at most 100000 characters")
+	}
+	var value *string
+	if transcript != "" {
+		value = &transcript
+	}
+	return scanInterview(s.db.QueryRowContext(ctx, `
+		UPDATE interviews SET transcript = $3,
+			transcript_takeaways = CASE WHEN transcript IS NOT DISTINCT FROM $3 THEN transcript_takeaways END,
+			transcript_action_items = CASE WHEN transcript IS NOT DISTINCT FROM $3 THEN transcript_action_items END,
+			transcript_summarized_at = CASE WHEN transcript IS NOT DISTINCT FROM $3 THEN transcript_summarized_at END
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+interviewColumns,
+		id, userID, value))
+}
+
+// SummarizeTranscript has the LLM pull the key takeaways and follow-up
+// action items out of an interview's transcript and stores them with the
+// interview, replacing any earlier summary. The application's summary picks
+// them up the next time it is generated.
+func (s *Service) SummarizeTranscript(ctx context.Context, userID, id string) (*Interview, error) {
+	req := &agentspb.SummarizeInterviewRequest{}
+	var transcript, language sql.NullString
+	var startsAt time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT a.company, a.position, i.title, i.starts_at, i.transcript,
+			(SELECT language FROM email_cache
+			 WHERE application_id = a.id AND language IS NOT NULL ORDER BY date DESC LIMIT 1)
+		FROM interviews i JOIN applications a ON a.id = i.application_id
+		WHERE i.id = $1 AND i.user_id = $2`,
+		id, userID).Scan(&req.Company, &req.Position, &req.Title, &startsAt, &transcript, &language)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !transcript.Valid {
+		return nil, ErrNoTranscript
+	}
+	if s.agents == nil {
+		return nil, ErrSummarizerUnavailable
+	}
+	req.OccurredAt = startsAt.Format(time.RFC3339)
+	req.Transcript = transcript.String
+	req.Language = language.String
+
+	resp, err := s.agents.SummarizeInterview(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// The transcript is matched so a summary of text replaced meanwhile is
+	// not stored against the new one.
+	iv, err := scanInterview(s.db.QueryRowContext(ctx, `
+		UPDATE interviews SET transcript_takeaways = $4, transcript_action_items = $5,
+			transcript_summarized_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND transcript = $3
+		RETURNING `+interviewColumns,
+		id, userID, transcript.String, pq.Array(nonNil(resp.Takeaways)), pq.Array(nonNil(resp.ActionItems))))
+	if errors.Is(err, ErrNotFound) {
+		return s.Get(ctx, userID, id)
+	}
+	return iv, err
+}
+
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
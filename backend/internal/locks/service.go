@@ -0,0 +1,335 @@
+// Package locks provides Redis-backed leases so singleton background work
+// runs on one replica at a time. A lease expires unless its holder keeps
+// renewing it, so when a replica crashes another takes over after at most
+// one TTL.
+package locks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+)
+
+var (
+	// ErrHeld is returned by Acquire when another replica holds the lock.
+	ErrHeld = apperr.New(apperr.Conflict, "lock is held by another instance")
+	// ErrLost is returned when a lease could not be renewed before it
+	// expired, so another instance may have taken over.
+	ErrLost = apperr.New(apperr.Conflict, "lock lease lost")
+)
+
+// Redis keys: the lease itself (with a TTL), the last holder that has not
+// released cleanly (to detect takeovers), the last slot run by RunOnce,
+// per-lock counters and the set of known lock names.
+const (
+	leasePrefix = "locks:lease:"
+	lastPrefix  = "locks:last:"
+	donePrefix  = "locks:done:"
+	statsPrefix = "locks:stats:"
+	namesKey    = "locks:names"
+)
+
+// acquireScript takes the lease if it is free. It returns 2 instead of 1
+// when the previous holder never released, i.e. it crashed or was cut off.
+var acquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	local prev = redis.call('GETSET', KEYS[2], ARGV[1])
+	if prev then
+		return 2
+	end
+	return 1
+end
+return 0`)
+
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('DEL', KEYS[2])
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// Service hands out leases identified by this process.
+type Service struct {
+	rdb   *redis.Client
+	ttl   time.Duration
+	owner string
+}
+
+// NewService creates a lock service with leases of LOCK_TTL_SECONDS.
+func NewService(cfg *config.Config, rdb *redis.Client) *Service {
+	ttl := time.Duration(cfg.LockTTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &Service{rdb: rdb, ttl: ttl, owner: ownerID()}
+}
+
+// ownerID identifies this process in lock values and stats.
+func ownerID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Lease is a held lock. It must be renewed within the TTL and released when
+// the work is done.
+type Lease struct {
+	s    *Service
+	name string
+}
+
+// Acquire takes the named lock, returning ErrHeld if another instance has it.
+func (s *Service) Acquire(ctx context.Context, name string) (*Lease, error) {
+	res, err := acquireScript.Run(ctx, s.rdb,
+		[]string{leasePrefix + name, lastPrefix + name},
+		s.owner, s.ttl.Milliseconds()).Int()
+	if err != nil {
+		s.count(name, "errors")
+		return nil, fmt.Errorf("acquire lock %s: %w", name, err)
+	}
+	switch res {
+	case 0:
+		s.count(name, "skipped")
+		return nil, ErrHeld
+	case 2:
+		log.Printf("Lock %s: taking over from an instance that did not release it", name)
+		s.count(name, "takeovers")
+	}
+	s.count(name, "acquired")
+	s.rdb.HSet(ctx, statsPrefix+name, "holder", s.owner, "acquired_at", time.Now().Unix())
+	return &Lease{s: s, name: name}, nil
+}
+
+// Renew extends the lease by another TTL, returning ErrLost if it expired
+// or was taken by another instance.
+func (l *Lease) Renew(ctx context.Context) error {
+	ok, err := renewScript.Run(ctx, l.s.rdb, []string{leasePrefix + l.name}, l.s.owner, l.s.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		l.s.count(l.name, "lost")
+		return ErrLost
+	}
+	return nil
+}
+
+// Release gives the lock up if it is still held.
+func (l *Lease) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.s.rdb,
+		[]string{leasePrefix + l.name, lastPrefix + l.name}, l.s.owner).Err()
+}
+
+// Run calls fn while holding the named lock and reports whether it ran; it
+// returns false without error when another instance holds the lock. The
+// lease is renewed in the background, and fn's context is cancelled if it
+// is lost.
+func (s *Service) Run(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	lease, err := s.Acquire(ctx, name)
+	if errors.Is(err, ErrHeld) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, s.hold(ctx, lease, fn)
+}
+
+// RunOnce is Run for scheduled work: fn runs at most once per slot across
+// all instances, even when their clocks or timers fire slightly apart. If
+// the holder crashes mid-run, the slot is not retried and the next one runs
+// wherever the lock can be taken.
+func (s *Service) RunOnce(ctx context.Context, name string, slot time.Time, fn func(ctx context.Context) error) (bool, error) {
+	lease, err := s.Acquire(ctx, name)
+	if errors.Is(err, ErrHeld) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	last, err := s.rdb.Get(ctx, donePrefix+name).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		lease.Release(ctx)
+		return false, err
+	}
+	if last >= slot.Unix() {
+		s.count(name, "skipped")
+		return false, lease.Release(ctx)
+	}
+
+	return true, s.hold(ctx, lease, func(ctx context.Context) error {
+		err := fn(ctx)
+		markCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if merr := s.rdb.Set(markCtx, donePrefix+name, slot.Unix(), 0).Err(); merr != nil {
+			log.Printf("Lock %s: record completed slot: %v", name, merr)
+		}
+		return err
+	})
+}
+
+// Lead campaigns for the named lock until ctx is cancelled, running fn for
+// as long as this instance is the leader. If leadership is lost fn's
+// context is cancelled and the campaign resumes; an error from fn only ends
+// the current term.
+func (s *Service) Lead(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	for {
+		lease, err := s.Acquire(ctx, name)
+		switch {
+		case err == nil:
+			log.Printf("Lock %s: elected leader", name)
+			if err := s.hold(ctx, lease, fn); err != nil && ctx.Err() == nil {
+				log.Printf("Lock %s: leader term ended: %v", name, err)
+			}
+		case !errors.Is(err, ErrHeld):
+			log.Printf("Lock %s: campaign failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.ttl / 3):
+		}
+	}
+}
+
+// hold runs fn with a renewal loop and releases the lease afterwards.
+func (s *Service) hold(ctx context.Context, lease *Lease, fn func(ctx context.Context) error) error {
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			renewCtx, stop := context.WithTimeout(context.Background(), s.ttl/3)
+			err := lease.Renew(renewCtx)
+			stop()
+			if errors.Is(err, ErrLost) {
+				log.Printf("Lock %s: lease lost, stopping work", lease.name)
+				close(lost)
+				cancel()
+				return
+			}
+			if err != nil {
+				// Keep trying until the lease would have expired anyway.
+				log.Printf("Lock %s: renew failed: %v", lease.name, err)
+			}
+		}
+	}()
+
+	err := fn(fnCtx)
+	close(done)
+
+	releaseCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+	if rerr := lease.Release(releaseCtx); rerr != nil {
+		log.Printf("Lock %s: release failed: %v", lease.name, rerr)
+	}
+
+	select {
+	case <-lost:
+		return ErrLost
+	default:
+		return err
+	}
+}
+
+// count increments a lock counter. Stats are best effort and never fail
+// the caller.
+func (s *Service) count(name, field string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	pipe := s.rdb.Pipeline()
+	pipe.SAdd(ctx, namesKey, name)
+	pipe.HIncrBy(ctx, statsPrefix+name, field, 1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Lock %s: record %s: %v", name, field, err)
+	}
+}
+
+// Stats are the counters for one lock, summed over every instance.
+type Stats struct {
+	Name string
+	// Holder is the instance holding the lock now, or "" if it is free.
+	Holder     string
+	ExpiresIn  time.Duration
+	Acquired   int64
+	Skipped    int64 // attempts while another instance held the lock or had run the slot
+	Takeovers  int64 // acquisitions after a holder failed to release
+	Lost       int64 // leases that expired while work was running
+	Errors     int64
+	LastHolder string
+	LastAt     time.Time
+}
+
+// Stats reports every lock that has been used, by name.
+func (s *Service) Stats(ctx context.Context) ([]Stats, error) {
+	names, err := s.rdb.SMembers(ctx, namesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	out := make([]Stats, 0, len(names))
+	for _, name := range names {
+		fields, err := s.rdb.HGetAll(ctx, statsPrefix+name).Result()
+		if err != nil {
+			return nil, err
+		}
+		num := func(k string) int64 {
+			n, _ := strconv.ParseInt(fields[k], 10, 64)
+			return n
+		}
+		st := Stats{
+			Name:       name,
+			Acquired:   num("acquired"),
+			Skipped:    num("skipped"),
+			Takeovers:  num("takeovers"),
+			Lost:       num("lost"),
+			Errors:     num("errors"),
+			LastHolder: fields["holder"],
+		}
+		if at := num("acquired_at"); at > 0 {
+			st.LastAt = time.Unix(at, 0)
+		}
+
+		holder, err := s.rdb.Get(ctx, leasePrefix+name).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		if holder != "" {
+			st.Holder = holder
+			st.ExpiresIn, _ = s.rdb.PTTL(ctx, leasePrefix+name).Result()
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
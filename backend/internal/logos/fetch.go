@@ -0,0 +1,132 @@
+package logos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxLogoBytes caps a fetched logo.
+	maxLogoBytes = 512 << 10
+	// maxPageBytes bounds how much of a homepage is read for its icons.
+	maxPageBytes = 1 << 20
+)
+
+// fetch downloads the domain's logo, trying the logo service, then the
+// icons the homepage links to, then /favicon.ico. It returns the image,
+// its sniffed content type and where it came from.
+func (s *Service) fetch(ctx context.Context, domain string) ([]byte, string, string, error) {
+	var candidates []string
+	if u := s.source(domain); u != "" {
+		candidates = append(candidates, u)
+	}
+	home := "https://" + domain + "/"
+	icons, err := s.icons(ctx, home)
+	if err != nil {
+		// Some companies only answer on www.
+		home = "https://www." + domain + "/"
+		icons, _ = s.icons(ctx, home)
+	}
+	candidates = append(candidates, icons...)
+	candidates = append(candidates, "https://"+domain+"/favicon.ico")
+
+	var last error
+	for _, u := range candidates {
+		data, contentType, err := s.download(ctx, u)
+		if err == nil {
+			return data, contentType, u, nil
+		}
+		last = err
+	}
+	return nil, "", "", last
+}
+
+// download fetches an image. Its type is sniffed rather than taken from
+// the response, so only raster images are accepted: an SVG, which can carry
+// script, is never served as one.
+func (s *Service) download(ctx context.Context, u string) ([]byte, string, error) {
+	resp, err := s.get(ctx, u, "image/*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLogoBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxLogoBytes {
+		return nil, "", fmt.Errorf("%s is larger than %d bytes", u, maxLogoBytes)
+	}
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("%s is %s, not an image", u, contentType)
+	}
+	return data, contentType, nil
+}
+
+// icons returns the icons a homepage links to, touch icons first since
+// they are larger.
+func (s *Service) icons(ctx context.Context, home string) ([]string, error) {
+	resp, err := s.get(ctx, home, "text/html")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return nil, err
+	}
+	base := resp.Request.URL
+
+	var touch, plain []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "link" {
+			var rel, href string
+			for _, a := range n.Attr {
+				switch strings.ToLower(a.Key) {
+				case "rel":
+					rel = strings.ToLower(a.Val)
+				case "href":
+					href = strings.TrimSpace(a.Val)
+				}
+			}
+			if u, err := base.Parse(href); href != "" && err == nil && (u.Scheme == "https" || u.Scheme == "http") {
+				switch {
+				case strings.Contains(rel, "apple-touch-icon"):
+					touch = append(touch, u.String())
+				case strings.Contains(rel, "icon"):
+					plain = append(plain, u.String())
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return append(touch, plain...), nil
+}
+
+func (s *Service) get(ctx context.Context, u, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; JobTracker/1.0; +https://github.com/jobtracker)")
+	req.Header.Set("Accept", accept)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s answered %d", u, resp.StatusCode)
+	}
+	return resp, nil
+}
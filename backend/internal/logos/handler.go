@@ -0,0 +1,47 @@
+package logos
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// Register mounts the logo route on the group. Logos are public images and
+// the browser loads them without credentials, so the route is not
+// authenticated; URLs are signed instead.
+func (s *Service) Register(rg *gin.RouterGroup) {
+	rg.GET("/:domain", s.Handler())
+}
+
+// Handler serves a domain's logo. Browsers may cache it for a day, and
+// the absence of one too, so a page of applications does not ask again.
+func (s *Service) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		domain := c.Param("domain")
+		if err := s.Verify(domain, c.Query("sig")); err != nil {
+			apperr.Respond(c, "Logo", err)
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=86400")
+		l, err := s.Logo(c.Request.Context(), domain)
+		if err != nil {
+			apperr.Respond(c, "Logo", err)
+			return
+		}
+		defer l.Body.Close()
+
+		c.Header("Content-Type", l.ContentType)
+		c.Header("Content-Length", strconv.FormatInt(l.Size, 10))
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Last-Modified", l.FetchedAt.UTC().Format(http.TimeFormat))
+		c.Status(http.StatusOK)
+		if _, err := io.Copy(c.Writer, l.Body); err != nil {
+			log.Printf("Logo of %s interrupted: %v", domain, err)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+// Package logos serves company logos through the backend. Logos are fetched
+// once per domain, from the configured logo service or the company's own
+// site icon, and kept in object storage, so the frontend never contacts
+// third parties on the user's behalf and logos load from one place. A
+// company's domain is worked out from the emails and careers pages the user
+// has for it.
+package logos
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/ats"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/safehttp"
+	"github.com/jobtracker/backend/internal/storage"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+const (
+	// missingRetry is how long a domain without a logo, or whose fetch
+	// failed, waits before it is tried again.
+	missingRetry = 24 * time.Hour
+	// maxCompanies caps the companies looked up at once.
+	maxCompanies = 200
+)
+
+var (
+	// ErrNoLogo is returned when a domain has no logo that could be fetched.
+	ErrNoLogo = apperr.New(apperr.NotFound, "no logo for this domain")
+	// ErrBadSignature is returned for logo URLs the server did not hand out.
+	ErrBadSignature = apperr.New(apperr.Forbidden, "invalid logo URL")
+)
+
+var domainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+$`)
+
+// notEmployers are mail and job board domains that send email about many
+// companies and so say nothing about which one it is.
+var notEmployers = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "outlook.com": true, "hotmail.com": true, "live.com": true,
+	"yahoo.com": true, "icloud.com": true, "me.com": true, "aol.com": true, "proton.me": true,
+	"protonmail.com": true, "gmx.com": true,
+	"linkedin.com": true, "indeed.com": true, "glassdoor.com": true, "ziprecruiter.com": true,
+	"wellfound.com": true, "angel.co": true, "hired.com": true, "dice.com": true, "monster.com": true,
+	"otta.com": true, "welcometothejungle.com": true,
+}
+
+// CompanyLogo is where a company's logo is served, when its domain is known.
+type CompanyLogo struct {
+	Company string  `json:"company"`
+	Domain  *string `json:"domain"`
+	URL     *string `json:"url"`
+}
+
+// Logo is a cached logo. The caller closes Body.
+type Logo struct {
+	ContentType string
+	Size        int64
+	FetchedAt   time.Time
+	Body        io.ReadCloser
+}
+
+// Service finds company domains and fetches and caches their logos.
+type Service struct {
+	cfg    *config.Config
+	db     *sql.DB
+	store  storage.Store
+	client *http.Client
+}
+
+// NewService creates a logo service caching logos in the store.
+func NewService(cfg *config.Config, db *sql.DB, store storage.Store) *Service {
+	return &Service{cfg: cfg, db: db, store: store, client: safehttp.NewClient(10 * time.Second)}
+}
+
+// Logos returns where the logos of the user's companies are served, in the
+// order asked for. A company whose domain could not be worked out has no
+// URL.
+func (s *Service) Logos(ctx context.Context, userID string, companies []string) ([]*CompanyLogo, error) {
+	if len(companies) > maxCompanies {
+		return nil, validation.Field("companies", "must list at most 200 companies")
+	}
+	names := make([]string, 0, len(companies))
+	for _, c := range companies {
+		names = append(names, strings.ToLower(strings.TrimSpace(c)))
+	}
+	domains, err := s.domains(ctx, userID, names)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*CompanyLogo, 0, len(companies))
+	for i, c := range companies {
+		l := &CompanyLogo{Company: c}
+		if d, ok := domains[names[i]]; ok {
+			u := s.URL(d)
+			l.Domain, l.URL = &d, &u
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// domains works out each company's domain, by lower-case name: the host of
+// a careers page the user watches, or else the domain most of the email
+// about its applications came from, skipping applicant tracking systems,
+// job boards and webmail.
+func (s *Service) domains(ctx context.Context, userID string, names []string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT company, host, n FROM (
+			SELECT LOWER(company) AS company, SUBSTRING(careers_url FROM '^[a-zA-Z]+://([^/:?#]+)') AS host,
+				0 AS rank, COUNT(*) AS n
+			FROM company_watches
+			WHERE user_id = $1 AND LOWER(company) = ANY($2)
+			GROUP BY 1, 2
+			UNION ALL
+			SELECT LOWER(a.company), SUBSTRING(e.sender FROM '@([A-Za-z0-9.-]+)'), 1, COUNT(*)
+			FROM applications a
+			JOIN email_cache e ON e.user_id = a.user_id AND (e.application_id = a.id OR e.id = a.email_id)
+			WHERE a.user_id = $1 AND LOWER(a.company) = ANY($2)
+			GROUP BY 1, 2
+		) candidates
+		WHERE host IS NOT NULL
+		ORDER BY company, rank, n DESC`,
+		userID, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var company, host string
+		var n int
+		if err := rows.Scan(&company, &host, &n); err != nil {
+			return nil, err
+		}
+		if _, ok := out[company]; ok {
+			continue
+		}
+		if d := employerDomain(host); d != "" {
+			out[company] = d
+		}
+	}
+	return out, rows.Err()
+}
+
+// employerDomain reduces a mail or web host to the registrable domain it
+// belongs to, e.g. careers.acme.co.uk to acme.co.uk, or returns "" if it
+// does not identify an employer.
+func employerDomain(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	d, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil || !domainPattern.MatchString(d) || notEmployers[d] || ats.OwnsDomain(host) || ats.OwnsDomain(d) {
+		return ""
+	}
+	return d
+}
+
+// URL is where the domain's logo is served. It is signed so the proxy only
+// fetches logos for domains the server handed out.
+func (s *Service) URL(domain string) string {
+	return s.cfg.PublicURL + "/api/v1/logos/" + domain + "?sig=" + s.sign(domain)
+}
+
+func (s *Service) sign(domain string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.SessionSecret))
+	mac.Write([]byte("logo:" + domain))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Verify checks a logo URL's signature.
+func (s *Service) Verify(domain, sig string) error {
+	if !domainPattern.MatchString(domain) || !hmac.Equal([]byte(sig), []byte(s.sign(domain))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Logo returns the domain's logo, fetching and storing it when it is not
+// cached or is older than LOGO_REFRESH_DAYS. A domain found to have no
+// logo is not tried again for a day. A stale logo is served if refreshing
+// it fails.
+func (s *Service) Logo(ctx context.Context, domain string) (*Logo, error) {
+	var contentType sql.NullString
+	var size sql.NullInt64
+	var fetchedAt time.Time
+	err := s.db.QueryRowContext(ctx,
+		`SELECT content_type, size_bytes, fetched_at FROM company_logos WHERE domain = $1`,
+		domain).Scan(&contentType, &size, &fetchedAt)
+	cached := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if cached {
+		age := time.Since(fetchedAt)
+		switch {
+		case !contentType.Valid && age < missingRetry:
+			return nil, ErrNoLogo
+		case contentType.Valid && age < time.Duration(s.cfg.LogoRefreshDays)*24*time.Hour:
+			if l, err := s.open(ctx, domain, contentType.String, size.Int64, fetchedAt); err == nil {
+				return l, nil
+			}
+		}
+	}
+
+	data, fetchedType, source, err := s.fetch(ctx, domain)
+	if err != nil {
+		log.Printf("No logo fetched for %s: %v", domain, err)
+		if cached && contentType.Valid {
+			if l, err := s.open(ctx, domain, contentType.String, size.Int64, fetchedAt); err == nil {
+				return l, nil
+			}
+		}
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO company_logos (domain, content_type, size_bytes, source, fetched_at)
+			VALUES ($1, NULL, NULL, NULL, CURRENT_TIMESTAMP)
+			ON CONFLICT (domain) DO UPDATE SET content_type = NULL, size_bytes = NULL, source = NULL,
+				fetched_at = CURRENT_TIMESTAMP`, domain)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrNoLogo
+	}
+
+	if err := s.store.Put(ctx, domain, bytes.NewReader(data), int64(len(data)), fetchedType); err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO company_logos (domain, content_type, size_bytes, source, fetched_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (domain) DO UPDATE SET content_type = EXCLUDED.content_type, size_bytes = EXCLUDED.size_bytes,
+			source = EXCLUDED.source, fetched_at = EXCLUDED.fetched_at`,
+		domain, fetchedType, len(data), source); err != nil {
+		return nil, err
+	}
+	return &Logo{ContentType: fetchedType, Size: int64(len(data)), FetchedAt: time.Now(),
+		Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (s *Service) open(ctx context.Context, domain, contentType string, size int64, fetchedAt time.Time) (*Logo, error) {
+	f, err := s.store.Open(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	return &Logo{ContentType: contentType, Size: size, FetchedAt: fetchedAt, Body: f}, nil
+}
+
+// source expands LOGO_SOURCE_URL for a domain, or returns "" when no logo
+// service is configured.
+func (s *Service) source(domain string) string {
+	if s.cfg.LogoSourceURL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(s.cfg.LogoSourceURL, "{domain}", url.PathEscape(domain))
+}
@@ -0,0 +1,261 @@
+package mailbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/googleauth"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// revokeURL is Google's OAuth token revocation endpoint.
+const revokeURL = "https://oauth2.googleapis.com/revoke"
+
+// maxPause is the longest a mailbox can be paused until a set time.
+const maxPause = 365 * 24 * time.Hour
+
+// reasonUnlinked is the disconnect reason shown after the user disconnects.
+const reasonUnlinked = "Disconnected by you"
+
+var (
+	// ErrNotConnected is returned when pausing or syncing a mailbox that is
+	// not linked.
+	ErrNotConnected = apperr.New(apperr.Conflict, "mailbox is not connected")
+	// ErrPaused is returned by CheckSync while the user has paused syncing.
+	ErrPaused = apperr.New(apperr.Conflict, "mailbox sync is paused")
+)
+
+// CheckSync returns ErrNotConnected or ErrPaused when the user's mailbox
+// must not be read. The sync pipeline and Gmail push handler call it before
+// fetching messages.
+func (s *Service) CheckSync(ctx context.Context, userID string) error {
+	st, err := s.Status(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !st.Connected {
+		return ErrNotConnected
+	}
+	if st.Paused {
+		return ErrPaused
+	}
+	return nil
+}
+
+// Pause stops syncing the user's mailbox until they resume it or, when
+// until is set, until then. The Gmail push watch is stopped and running
+// syncs are cancelled, and neither reconciliation nor the rejection rule
+// touch Gmail while paused. Tokens are still refreshed so resuming needs no
+// new sign-in.
+func (s *Service) Pause(ctx context.Context, userID string, until *time.Time) (*Status, error) {
+	if until != nil && (!until.After(time.Now()) || until.After(time.Now().Add(maxPause))) {
+		return nil, validation.Field("until", "must be in the next year")
+	}
+	st, err := s.Status(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !st.Connected {
+		return nil, ErrNotConnected
+	}
+	if err := s.stopWatch(ctx, userID); err != nil {
+		log.Printf("Failed to stop the Gmail watch of user %s: %v", userID, err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE users SET mailbox_paused_at = COALESCE(mailbox_paused_at, CURRENT_TIMESTAMP), mailbox_paused_until = $2,
+			gmail_watch_expires_at = NULL
+		WHERE id = $1`,
+		userID, until); err != nil {
+		return nil, err
+	}
+	if err := s.cancelSyncs(ctx, userID); err != nil {
+		return nil, err
+	}
+	log.Printf("Mailbox sync for user %s paused", userID)
+	return s.Status(ctx, userID)
+}
+
+// Resume restarts syncing a paused mailbox. With skipMissed, mail received
+// while paused is never synced; otherwise the next sync catches up on it.
+// Resuming a mailbox that is not paused is a no-op.
+func (s *Service) Resume(ctx context.Context, userID string, skipMissed bool) (*Status, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE users SET mailbox_paused_at = NULL, mailbox_paused_until = NULL,
+			gmail_history_id = CASE WHEN $2 THEN NULL ELSE gmail_history_id END
+		WHERE id = $1 AND mailbox_paused_at IS NOT NULL`,
+		userID, skipMissed)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("Mailbox sync for user %s resumed", userID)
+		st, err := s.Status(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if st.Connected && s.cfg.GmailPubSubTopic != "" {
+			if err := s.renewWatch(ctx, userID); err != nil {
+				// Maintain renews the watch on its next run.
+				s.fail(ctx, userID, "renew Gmail watch", err)
+			}
+		}
+	}
+	return s.Status(ctx, userID)
+}
+
+// resumeDue resumes mailboxes paused until a time that has passed,
+// catching up on the mail received in the meantime.
+func (s *Service) resumeDue(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM users WHERE mailbox_paused_until <= CURRENT_TIMESTAMP
+		LIMIT `+fmt.Sprint(batchSize))
+	if err != nil {
+		return err
+	}
+	var due []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, userID := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.Resume(ctx, userID, false); err != nil {
+			log.Printf("Mailbox maintenance: resume user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// Unlink disconnects the user's mailbox at their request: the Gmail watch
+// is stopped, running syncs are cancelled, the Google grant is revoked and
+// the stored tokens are discarded, so signing in again is needed to re-link
+// it. With purge, synced emails
+// are deleted too; the applications recorded from them are kept.
+func (s *Service) Unlink(ctx context.Context, userID string, purge bool) (*Status, error) {
+	if err := s.stopWatch(ctx, userID); err != nil && !errors.Is(err, googleauth.ErrNotLinked) {
+		log.Printf("Failed to stop the Gmail watch of user %s: %v", userID, err)
+	}
+	if err := s.revoke(ctx, userID); err != nil {
+		// The stored tokens are discarded regardless; the grant can still
+		// be removed from the Google account.
+		log.Printf("Failed to revoke the Google grant of user %s: %v", userID, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET access_token = NULL, refresh_token = NULL, token_expires_at = NULL,
+			gmail_watch_expires_at = NULL, gmail_history_id = NULL,
+			mailbox_paused_at = NULL, mailbox_paused_until = NULL,
+			mailbox_disconnected_at = COALESCE(mailbox_disconnected_at, CURRENT_TIMESTAMP), mailbox_disconnect_reason = $2
+		WHERE id = $1`,
+		userID, reasonUnlinked); err != nil {
+		return nil, err
+	}
+	var purged int64
+	if purge {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM classification_trials WHERE user_id = $1`, userID); err != nil {
+			return nil, err
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM email_cache WHERE user_id = $1`, userID)
+		if err != nil {
+			return nil, err
+		}
+		purged, _ = res.RowsAffected()
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if err := s.cancelSyncs(ctx, userID); err != nil {
+		return nil, err
+	}
+	log.Printf("Mailbox for user %s disconnected by the user (%d emails purged)", userID, purged)
+	return s.Status(ctx, userID)
+}
+
+// stopWatch stops Gmail push notifications for the user.
+func (s *Service) stopWatch(ctx context.Context, userID string) error {
+	if s.cfg.GmailPubSubTopic == "" {
+		return nil
+	}
+	ts, err := s.tokens.TokenSource(ctx, userID)
+	if err != nil {
+		return err
+	}
+	svc, err := gmail.NewService(ctx, option.WithTokenSource(ts))
+	if err != nil {
+		return err
+	}
+	if err := svc.Users.Stop("me").Context(ctx).Do(); err != nil {
+		return apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	return nil
+}
+
+// revoke revokes the user's Google grant. Tokens Google no longer
+// recognizes, because the grant was already revoked or expired, need no
+// revoking.
+func (s *Service) revoke(ctx context.Context, userID string) error {
+	tok, err := s.tokens.Token(ctx, userID)
+	if errors.Is(err, googleauth.ErrNotLinked) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token := tok.RefreshToken
+	if token == "" {
+		token = tok.AccessToken
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, revokeURL,
+		strings.NewReader(url.Values{"token": {token}}.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return apperr.Wrap(apperr.UpstreamGmail, fmt.Errorf("revoking token: %s", resp.Status))
+	}
+	return nil
+}
+
+// cancelSyncs cancels the user's pending syncs and asks running ones to
+// stop, as exports.Service.Cancel does.
+func (s *Service) cancelSyncs(ctx context.Context, userID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE processing_jobs SET cancel_requested_at = CURRENT_TIMESTAMP,
+			status = CASE WHEN status = 'pending' THEN 'cancelled' ELSE status END,
+			completed_at = CASE WHEN status = 'pending' THEN CURRENT_TIMESTAMP END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND status IN ('pending', 'processing')`, userID)
+	return err
+}
@@ -0,0 +1,235 @@
+package mailbox
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// reverifyAfter is how long a verified email is left alone before it may
+// be sampled again.
+const reverifyAfter = 7 * 24 * time.Hour
+
+// ReconcileRun is the outcome of one reconciliation run.
+type ReconcileRun struct {
+	ID         string     `json:"id"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+	Checked    int        `json:"checked"`
+	// Deleted counts emails no longer in Gmail, or in its trash; they are
+	// flagged, not removed, since applications may still point at them.
+	Deleted int `json:"deleted"`
+	// Relabeled counts emails whose labels changed in Gmail; the cached
+	// labels are repaired.
+	Relabeled int `json:"relabeled"`
+	// Failed counts emails that could not be checked, e.g. because the
+	// user's mailbox was unreachable.
+	Failed int `json:"failed"`
+}
+
+// DriftedEmail is a cached email found deleted from Gmail.
+type DriftedEmail struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"userId"`
+	Subject       *string   `json:"subject"`
+	ApplicationID *string   `json:"applicationId"`
+	DeletedAt     time.Time `json:"deletedAt"` // when the deletion was detected
+}
+
+// ReconcileReport is the recent history of reconciliation for
+// administrators.
+type ReconcileReport struct {
+	Runs    []*ReconcileRun `json:"runs"`    // newest first
+	Deleted []*DriftedEmail `json:"deleted"` // most recently detected first
+	// DeletedTotal counts every cached email flagged deleted.
+	DeletedTotal int `json:"deletedTotal"`
+}
+
+type sampledEmail struct {
+	id     string
+	labels []string
+}
+
+// Reconcile re-verifies a sample of cached emails against Gmail, those
+// never or least recently verified first. Emails gone from Gmail are
+// flagged and changed labels are repaired. Each run is recorded for the
+// reconciliation report; failures for one user are logged and do not stop
+// the run. It is intended to run hourly from the scheduler.
+func (s *Service) Reconcile(ctx context.Context) error {
+	if s.cfg.GmailReconcileSample <= 0 {
+		return nil
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.user_id, e.labels
+		FROM email_cache e JOIN users u ON u.id = e.user_id
+		WHERE COALESCE(u.refresh_token, '') <> '' AND u.mailbox_disconnected_at IS NULL AND u.mailbox_paused_at IS NULL
+			AND e.gmail_deleted_at IS NULL AND (e.verified_at IS NULL OR e.verified_at < $1)
+		ORDER BY e.verified_at NULLS FIRST, random()
+		LIMIT $2`,
+		time.Now().Add(-reverifyAfter), s.cfg.GmailReconcileSample)
+	if err != nil {
+		return err
+	}
+	byUser := make(map[string][]sampledEmail)
+	var users []string
+	for rows.Next() {
+		var e sampledEmail
+		var userID string
+		if err := rows.Scan(&e.id, &userID, pq.Array(&e.labels)); err != nil {
+			rows.Close()
+			return err
+		}
+		if byUser[userID] == nil {
+			users = append(users, userID)
+		}
+		byUser[userID] = append(byUser[userID], e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	run := &ReconcileRun{StartedAt: time.Now()}
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			break
+		}
+		checked := run.Checked
+		if err := s.reconcileUser(ctx, userID, byUser[userID], run); err != nil {
+			run.Failed += len(byUser[userID]) - (run.Checked - checked)
+			s.fail(ctx, userID, "reconcile emails", err)
+		}
+	}
+	_, err = s.db.ExecContext(context.WithoutCancel(ctx), `
+		INSERT INTO gmail_reconcile_runs (started_at, finished_at, checked, deleted, relabeled, failed)
+		VALUES ($1, CURRENT_TIMESTAMP, $2, $3, $4, $5)`,
+		run.StartedAt, run.Checked, run.Deleted, run.Relabeled, run.Failed)
+	if run.Deleted > 0 || run.Relabeled > 0 {
+		log.Printf("Mailbox reconciliation: checked %d emails, %d deleted from Gmail, %d relabeled",
+			run.Checked, run.Deleted, run.Relabeled)
+	}
+	return err
+}
+
+// reconcileUser checks one user's sampled emails. An error means the
+// mailbox could not be read; emails already checked keep their result.
+func (s *Service) reconcileUser(ctx context.Context, userID string, emails []sampledEmail, run *ReconcileRun) error {
+	ts, err := s.tokens.TokenSource(ctx, userID)
+	if err != nil {
+		return err
+	}
+	svc, err := gmail.NewService(ctx, option.WithTokenSource(ts))
+	if err != nil {
+		return err
+	}
+
+	for _, e := range emails {
+		msg, err := svc.Users.Messages.Get("me", e.id).Format("minimal").Context(ctx).Do()
+		switch {
+		case isNotFound(err) || err == nil && hasLabel(msg.LabelIds, "TRASH"):
+			_, err = s.db.ExecContext(ctx, `
+				UPDATE email_cache SET gmail_deleted_at = CURRENT_TIMESTAMP, verified_at = CURRENT_TIMESTAMP
+				WHERE id = $1`, e.id)
+			run.Deleted++
+		case err != nil:
+			return apperr.Wrap(apperr.UpstreamGmail, err)
+		case !sameLabels(e.labels, msg.LabelIds):
+			_, err = s.db.ExecContext(ctx,
+				`UPDATE email_cache SET labels = $2, verified_at = CURRENT_TIMESTAMP WHERE id = $1`,
+				e.id, pq.Array(msg.LabelIds))
+			run.Relabeled++
+		default:
+			_, err = s.db.ExecContext(ctx, `UPDATE email_cache SET verified_at = CURRENT_TIMESTAMP WHERE id = $1`, e.id)
+		}
+		if err != nil {
+			return err
+		}
+		run.Checked++
+	}
+	return nil
+}
+
+func hasLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reportRuns and reportDeleted cap the report.
+const (
+	reportRuns    = 48
+	reportDeleted = 100
+)
+
+// ReconcileReport returns recent reconciliation runs and the emails found
+// deleted from Gmail, for administrators only.
+func (s *Service) ReconcileReport(ctx context.Context, adminID string) (*ReconcileReport, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+
+	r := &ReconcileReport{Runs: []*ReconcileRun{}, Deleted: []*DriftedEmail{}}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, started_at, finished_at, checked, deleted, relabeled, failed
+		FROM gmail_reconcile_runs ORDER BY started_at DESC LIMIT $1`, reportRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		run := &ReconcileRun{}
+		if err := rows.Scan(&run.ID, &run.StartedAt, &run.FinishedAt, &run.Checked, &run.Deleted, &run.Relabeled, &run.Failed); err != nil {
+			return nil, err
+		}
+		r.Runs = append(r.Runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT id, user_id, subject, application_id, gmail_deleted_at, COUNT(*) OVER ()
+		FROM email_cache WHERE gmail_deleted_at IS NOT NULL
+		ORDER BY gmail_deleted_at DESC LIMIT $1`, reportDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e := &DriftedEmail{}
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Subject, &e.ApplicationID, &e.DeletedAt, &r.DeletedTotal); err != nil {
+			return nil, err
+		}
+		r.Deleted = append(r.Deleted, e)
+	}
+	return r, rows.Err()
+}
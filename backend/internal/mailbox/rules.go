@@ -0,0 +1,246 @@
+package mailbox
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// What the rejection rule does to a rejection's email thread.
+const (
+	RuleOff     = "off"
+	RuleArchive = "archive" // remove from the inbox, adding the label if one is set
+	RuleLabel   = "label"   // add the label and leave it in the inbox
+)
+
+// DefaultRejectionLabel is the Gmail label applied when none is configured
+// for the label action.
+const DefaultRejectionLabel = "Job Tracker/Rejected"
+
+// ErrInvalidRule is returned for rule actions other than off, archive and
+// label.
+var ErrInvalidRule = apperr.New(apperr.Validation, "action must be off, archive or label")
+
+// RejectionRule is what happens in Gmail once a rejection email has been
+// classified and recorded on its application.
+type RejectionRule struct {
+	Action string  `json:"action"`
+	Label  *string `json:"label"` // Gmail label name; nested labels use "/"
+}
+
+// RejectionRuleInput sets the rejection rule.
+type RejectionRuleInput struct {
+	Action string  `json:"action"`
+	Label  *string `json:"label" validate:"omitempty,max=225"`
+}
+
+// RejectionRule returns the user's rejection rule.
+func (s *Service) RejectionRule(ctx context.Context, userID string) (*RejectionRule, error) {
+	r := &RejectionRule{}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT rejection_email_action, rejection_email_label FROM users WHERE id = $1`, userID).Scan(&r.Action, &r.Label)
+	return r, err
+}
+
+// SetRejectionRule saves the user's rejection rule. Acting on Gmail needs
+// the gmail.modify scope, granted through incremental consent (scope
+// modify); the rule only applies to rejections synced after it is turned
+// on, so existing mail is left alone.
+func (s *Service) SetRejectionRule(ctx context.Context, userID string, in RejectionRuleInput) (*RejectionRule, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	in.Action = strings.ToLower(strings.TrimSpace(in.Action))
+	if in.Action != RuleOff && in.Action != RuleArchive && in.Action != RuleLabel {
+		return nil, ErrInvalidRule
+	}
+	if in.Label != nil {
+		label := strings.Trim(strings.TrimSpace(*in.Label), "/")
+		in.Label = &label
+		if label == "" {
+			in.Label = nil
+		}
+	}
+	r := &RejectionRule{}
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE users SET rejection_email_action = $2, rejection_email_label = $3,
+			rejection_rule_enabled_at = CASE
+				WHEN $2 = 'off' THEN NULL
+				ELSE COALESCE(rejection_rule_enabled_at, CURRENT_TIMESTAMP) END
+		WHERE id = $1
+		RETURNING rejection_email_action, rejection_email_label`,
+		userID, in.Action, in.Label).Scan(&r.Action, &r.Label)
+	return r, err
+}
+
+// ruleEmail is a rejection email waiting for the user's rule.
+type ruleEmail struct {
+	id, userID string
+	rule       RejectionRule
+}
+
+// ApplyRejectionRules archives or labels the Gmail threads of rejections
+// that have been classified and recorded on their application since each
+// user turned the rule on. Emails are only acted on once; failures for one
+// user are logged and retried on the next run. It is intended to run from
+// the scheduler.
+func (s *Service) ApplyRejectionRules(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.user_id, u.rejection_email_action, u.rejection_email_label
+		FROM email_cache e JOIN users u ON u.id = e.user_id
+		WHERE u.rejection_email_action <> $1 AND COALESCE(u.refresh_token, '') <> '' AND u.mailbox_disconnected_at IS NULL
+			AND u.mailbox_paused_at IS NULL
+			AND e.classified_status = $2 AND e.mailbox_rule_applied_at IS NULL
+			AND e.processed_at >= u.rejection_rule_enabled_at
+			AND EXISTS (
+				SELECT 1 FROM applications a
+				WHERE a.user_id = e.user_id AND a.status = $2 AND (a.id = e.application_id OR a.email_id = e.id))
+		ORDER BY e.user_id, e.processed_at
+		LIMIT $3`,
+		RuleOff, models.StatusRejected, batchSize)
+	if err != nil {
+		return err
+	}
+	byUser := make(map[string][]ruleEmail)
+	var users []string
+	for rows.Next() {
+		var e ruleEmail
+		if err := rows.Scan(&e.id, &e.userID, &e.rule.Action, &e.rule.Label); err != nil {
+			rows.Close()
+			return err
+		}
+		if byUser[e.userID] == nil {
+			users = append(users, e.userID)
+		}
+		byUser[e.userID] = append(byUser[e.userID], e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.applyRule(ctx, userID, byUser[userID]); err != nil {
+			if isPermissionDenied(err) {
+				s.ruleDenied(ctx, userID)
+				continue
+			}
+			s.fail(ctx, userID, "apply rejection rule", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) applyRule(ctx context.Context, userID string, emails []ruleEmail) error {
+	ts, err := s.tokens.TokenSource(ctx, userID)
+	if err != nil {
+		return err
+	}
+	svc, err := gmail.NewService(ctx, option.WithTokenSource(ts))
+	if err != nil {
+		return err
+	}
+
+	rule := emails[0].rule
+	req := &gmail.ModifyThreadRequest{}
+	if rule.Action == RuleArchive {
+		req.RemoveLabelIds = []string{"INBOX"}
+	}
+	name := ""
+	if rule.Label != nil {
+		name = *rule.Label
+	} else if rule.Action == RuleLabel {
+		name = DefaultRejectionLabel
+	}
+	if name != "" {
+		labelID, err := ensureLabel(ctx, svc, name)
+		if err != nil {
+			return err
+		}
+		req.AddLabelIds = []string{labelID}
+	}
+
+	for _, e := range emails {
+		msg, err := svc.Users.Messages.Get("me", e.id).Format("minimal").Context(ctx).Do()
+		if err == nil {
+			_, err = svc.Users.Threads.Modify("me", msg.ThreadId, req).Context(ctx).Do()
+		}
+		// A message deleted from Gmail has nothing left to tidy.
+		if err != nil && !isNotFound(err) {
+			return apperr.Wrap(apperr.UpstreamGmail, err)
+		}
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE email_cache SET mailbox_rule_applied_at = CURRENT_TIMESTAMP WHERE id = $1`, e.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureLabel returns the ID of the user's Gmail label with the given name,
+// creating it if needed.
+func ensureLabel(ctx context.Context, svc *gmail.Service, name string) (string, error) {
+	labels, err := svc.Users.Labels.List("me").Context(ctx).Do()
+	if err != nil {
+		return "", apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	for _, l := range labels.Labels {
+		if strings.EqualFold(l.Name, name) {
+			return l.Id, nil
+		}
+	}
+	l, err := svc.Users.Labels.Create("me", &gmail.Label{
+		Name:                  name,
+		LabelListVisibility:   "labelShow",
+		MessageListVisibility: "show",
+	}).Context(ctx).Do()
+	if err != nil {
+		return "", apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	return l.Id, nil
+}
+
+// ruleDenied turns the rejection rule off for a user whose grant lacks the
+// modify scope and tells them how to turn it back on.
+func (s *Service) ruleDenied(ctx context.Context, userID string) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE users SET rejection_email_action = $2, rejection_rule_enabled_at = NULL
+		WHERE id = $1 AND rejection_email_action <> $2`,
+		userID, RuleOff)
+	if err != nil {
+		log.Printf("Mailbox rules: turn off rejection rule for user %s: %v", userID, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return
+	}
+	l := s.notifications.Localizer(ctx, userID)
+	body := l.T("mailbox.rule_denied.body", s.cfg.PublicURL)
+	if err := s.notifications.Notify(ctx, userID, notifications.KindMailboxRule, l.T("mailbox.rule_denied.title"), body); err != nil {
+		log.Printf("Mailbox rules: notify user %s: %v", userID, err)
+	}
+}
+
+func isPermissionDenied(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && gerr.Code == http.StatusForbidden
+}
+
+func isNotFound(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && gerr.Code == http.StatusNotFound
+}
@@ -0,0 +1,268 @@
+package mailbox
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+var (
+	// ErrApplicationNotFound is returned when sending about an application
+	// that does not exist or belongs to another user.
+	ErrApplicationNotFound = apperr.New(apperr.NotFound, "application not found")
+	// ErrSendLimit is returned once the user has sent GMAIL_SEND_DAILY_LIMIT
+	// emails in the last 24 hours.
+	ErrSendLimit = apperr.New(apperr.RateLimited, "daily email send limit reached")
+	// ErrSendNotGranted is returned when the user's Google grant lacks the
+	// gmail.send scope.
+	ErrSendNotGranted = apperr.New(apperr.Forbidden, "sending email needs permission to send from your Gmail; grant it with the requestGoogleScope mutation (scope send)")
+)
+
+// SendInput is an email to send about an application, usually an edited
+// draft. It is sent as a reply in the thread of the latest email about the
+// application, or of ReplyToEmailID, and addressed to its sender unless To
+// is given.
+type SendInput struct {
+	To             *string `json:"to" validate:"omitempty,email,max=255"`
+	Subject        string  `json:"subject" validate:"required,max=998"`
+	Body           string  `json:"body" validate:"required,max=100000"`
+	ReplyToEmailID *string `json:"replyToEmailId" validate:"omitempty,max=255"`
+}
+
+// SentEmail is an email sent through the user's Gmail.
+type SentEmail struct {
+	ID        string    `json:"id"` // Gmail message ID
+	ThreadID  string    `json:"threadId"`
+	To        string    `json:"to"`
+	Subject   string    `json:"subject"`
+	InReplyTo *string   `json:"inReplyTo"` // Message-ID of the email replied to
+	SentAt    time.Time `json:"sentAt"`
+}
+
+// sentTodayQuery counts the send slots the user took in the last 24 hours.
+const sentTodayQuery = `
+	SELECT COUNT(*) FROM email_sends
+	WHERE user_id = $1 AND reserved_at > CURRENT_TIMESTAMP - INTERVAL '24 hours'`
+
+// SentToday returns how many emails the user sent in the last 24 hours and
+// how many they may send.
+func (s *Service) SentToday(ctx context.Context, userID string) (sent, limit int, err error) {
+	err = s.db.QueryRowContext(ctx, sentTodayQuery, userID).Scan(&sent)
+	return sent, s.cfg.GmailSendDailyLimit, err
+}
+
+// reserveSend takes one of the user's daily send slots, or fails with
+// ErrSendLimit. The user's row is locked while counting, so concurrent
+// sends cannot both take the last slot.
+func (s *Service) reserveSend(ctx context.Context, userID, applicationID string) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return "", err
+	}
+	var sent int
+	if err := tx.QueryRowContext(ctx, sentTodayQuery, userID).Scan(&sent); err != nil {
+		return "", err
+	}
+	if limit := s.cfg.GmailSendDailyLimit; sent >= limit {
+		return "", fmt.Errorf("%w (%d per day)", ErrSendLimit, limit)
+	}
+	var id string
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO email_sends (user_id, application_id) VALUES ($1, $2) RETURNING id`,
+		userID, applicationID).Scan(&id); err != nil {
+		return "", err
+	}
+	return id, tx.Commit()
+}
+
+// releaseSend gives back a send slot whose email was not sent.
+func (s *Service) releaseSend(ctx context.Context, id string) {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM email_sends WHERE id = $1`, id); err != nil {
+		log.Printf("Failed to release send slot %s: %v", id, err)
+	}
+}
+
+// Send sends an email about one of the user's applications from their
+// Gmail, threaded with In-Reply-To and References under the email it
+// answers, and records it on the application's event stream. It needs the
+// gmail.send scope, granted through incremental consent (scope send), and
+// is capped at GMAIL_SEND_DAILY_LIMIT emails per user per 24 hours: a slot
+// is reserved before sending and given back if the send fails.
+func (s *Service) Send(ctx context.Context, userID, applicationID string, in SendInput) (*SentEmail, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	var emailID sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		`SELECT email_id FROM applications WHERE id = $1 AND user_id = $2`, applicationID, userID).Scan(&emailID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrApplicationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The email to reply to: the one asked for, or the latest about the
+	// application.
+	var replyTo sql.NullString
+	if in.ReplyToEmailID != nil {
+		err = s.db.QueryRowContext(ctx, `SELECT id FROM email_cache WHERE id = $1 AND user_id = $2`,
+			*in.ReplyToEmailID, userID).Scan(&replyTo)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, validation.Field("replyToEmailId", "not one of your emails")
+		}
+	} else {
+		err = s.db.QueryRowContext(ctx, `
+			SELECT id FROM email_cache
+			WHERE user_id = $1 AND (application_id = $2 OR id = $3)
+			ORDER BY date DESC NULLS LAST LIMIT 1`,
+			userID, applicationID, emailID).Scan(&replyTo)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := s.tokens.TokenSource(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := gmail.NewService(ctx, append([]option.ClientOption{option.WithTokenSource(ts)}, s.gmailOptions...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &gmail.Message{}
+	out := &SentEmail{Subject: in.Subject}
+	var to, references string
+	if in.To != nil {
+		to = *in.To
+	}
+	if replyTo.Valid {
+		orig, err := svc.Users.Messages.Get("me", replyTo.String).Format("metadata").
+			MetadataHeaders("Message-ID", "References", "From", "Reply-To").Context(ctx).Do()
+		if err != nil && !isNotFound(err) {
+			return nil, apperr.Wrap(apperr.UpstreamGmail, err)
+		}
+		if err == nil {
+			msg.ThreadId = orig.ThreadId
+			h := headers(orig)
+			if id := h["message-id"]; id != "" {
+				out.InReplyTo = &id
+				references = strings.TrimSpace(h["references"] + " " + id)
+			}
+			if to == "" {
+				from := h["reply-to"]
+				if from == "" {
+					from = h["from"]
+				}
+				if addr, err := mail.ParseAddress(from); err == nil {
+					to = addr.Address
+				}
+			}
+		}
+	}
+	if to == "" {
+		return nil, validation.Field("to", "required when there is no email to reply to")
+	}
+	out.To = to
+
+	raw, err := compose(to, in.Subject, in.Body, out.InReplyTo, references)
+	if err != nil {
+		return nil, err
+	}
+	msg.Raw = base64.URLEncoding.EncodeToString(raw)
+
+	slot, err := s.reserveSend(ctx, userID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := svc.Users.Messages.Send("me", msg).Context(ctx).Do()
+	if err != nil {
+		// Gmail may have sent it anyway if the request timed out, but a
+		// slot is only kept for emails known to be sent.
+		s.releaseSend(context.WithoutCancel(ctx), slot)
+		if isPermissionDenied(err) {
+			return nil, ErrSendNotGranted
+		}
+		return nil, apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	out.ID, out.ThreadID, out.SentAt = res.Id, res.ThreadId, time.Now().UTC()
+
+	// The email is already sent and its slot taken, so failures to record
+	// it are only logged.
+	if _, err := s.db.ExecContext(ctx, `UPDATE email_sends SET message_id = $2 WHERE id = $1`, slot, out.ID); err != nil {
+		log.Printf("Failed to record sent email %s on its send slot: %v", out.ID, err)
+	}
+	details := map[string]interface{}{"to": out.To, "subject": out.Subject, "threadId": out.ThreadID}
+	if out.InReplyTo != nil {
+		details["inReplyTo"] = *out.InReplyTo
+	}
+	src := eventlog.Source{Type: eventlog.EventEmailSent, Actor: eventlog.ActorUser, Ref: out.ID}
+	if err := s.events.Append(ctx, userID, applicationID, src, details); err != nil {
+		log.Printf("Failed to record sent email %s on application %s: %v", out.ID, applicationID, err)
+	}
+	return out, nil
+}
+
+// headers returns a message's headers by lower-case name.
+func headers(m *gmail.Message) map[string]string {
+	out := make(map[string]string)
+	if m.Payload == nil {
+		return out
+	}
+	for _, h := range m.Payload.Headers {
+		out[strings.ToLower(h.Name)] = h.Value
+	}
+	return out
+}
+
+// compose builds a plain-text RFC 5322 message. Gmail fills in From, Date
+// and Message-ID.
+func compose(to, subject, body string, inReplyTo *string, references string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "To: %s\r\n", (&mail.Address{Address: to}).String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(subject), " ")))
+	if inReplyTo != nil {
+		fmt.Fprintf(&b, "In-Reply-To: %s\r\n", oneLine(*inReplyTo))
+		fmt.Fprintf(&b, "References: %s\r\n", oneLine(references))
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// oneLine keeps header values from other emails from injecting headers.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,75 @@
+package mailbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/board"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/dbtest"
+	"github.com/jobtracker/backend/internal/goals"
+	"github.com/jobtracker/backend/internal/googleauth"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/profile"
+)
+
+// TestSendCountsTowardsFollowUpGoal sends a follow-up through a fake Gmail
+// and checks it shows up in the user's daily follow-up goal.
+func TestSendCountsTowardsFollowUpGoal(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	userID := dbtest.User(t, db)
+	if _, err := db.ExecContext(ctx, `
+		UPDATE users SET access_token = 'test', token_expires_at = CURRENT_TIMESTAMP + INTERVAL '1 hour'
+		WHERE id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+	var appID string
+	if err := db.QueryRowContext(ctx, `
+		INSERT INTO applications (user_id, company, position, applied_date, status)
+		VALUES ($1, 'Acme', 'Engineer', CURRENT_DATE, 'applied')
+		RETURNING id`, userID).Scan(&appID); err != nil {
+		t.Fatal(err)
+	}
+
+	gmail := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/gmail/v1/users/me/messages/send" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": "sent-1", "threadId": "thread-1"})
+	}))
+	defer gmail.Close()
+
+	cfg := &config.Config{GmailSendDailyLimit: 10}
+	notificationService := notifications.NewService(db)
+	s := NewService(cfg, db, googleauth.NewTokenStore(cfg, db), notificationService)
+	s.gmailOptions = []option.ClientOption{option.WithEndpoint(gmail.URL + "/"), option.WithHTTPClient(gmail.Client())}
+
+	profiles := profile.NewService(db)
+	goalService := goals.NewService(db, notificationService, profiles, board.NewService(db, profiles))
+	if _, err := goalService.SetGoal(ctx, userID, goals.GoalInput{Metric: goals.MetricFollowUps, Period: goals.PeriodDay, Target: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	to := "recruiter@acme.test"
+	if _, err := s.Send(ctx, userID, appID, SendInput{To: &to, Subject: "Following up", Body: "Any news?"}); err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := goalService.Goals(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 1 {
+		t.Fatalf("got %d goals, want 1", len(progress))
+	}
+	if p := progress[0]; p.Current != 1 || p.Completion != 1 {
+		t.Errorf("follow-up goal: current = %d, completion = %v; want 1 and 1", p.Current, p.Completion)
+	}
+}
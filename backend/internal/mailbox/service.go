@@ -0,0 +1,202 @@
+// Package mailbox keeps users' Gmail connections alive: it renews Pub/Sub
+// watches before Gmail lets them lapse after 7 days, refreshes OAuth tokens
+// ahead of expiry, and marks a mailbox disconnected when Google revokes the
+// grant so the user can be asked to re-link it. Users can pause syncing or
+// disconnect their mailbox themselves. It also applies the user's
+// rejection rule, archiving or labeling rejection emails in Gmail, sends
+// follow-ups from the user's Gmail, and reconciles cached emails with Gmail
+// to catch messages deleted or relabeled there.
+package mailbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/eventlog"
+	"github.com/jobtracker/backend/internal/googleauth"
+	"github.com/jobtracker/backend/internal/notifications"
+)
+
+const (
+	// refreshAhead is how long before expiry access tokens are refreshed;
+	// it must exceed the maintenance interval so tokens never lapse.
+	refreshAhead = 20 * time.Minute
+	// watchRenewAhead renews watches once they have less than this left.
+	// Google recommends renewing daily even though watches last a week.
+	watchRenewAhead = 6 * 24 * time.Hour
+	// batchSize caps the users handled per step of one maintenance run.
+	batchSize = 200
+)
+
+// Status is the state of a user's Gmail connection.
+type Status struct {
+	Connected        bool       `json:"connected"`
+	DisconnectedAt   *time.Time `json:"disconnectedAt"`
+	DisconnectReason *string    `json:"disconnectReason"`
+	WatchExpiresAt   *time.Time `json:"watchExpiresAt"`
+	// Paused is set while the user has paused syncing, until they resume
+	// or PausedUntil if set.
+	Paused      bool       `json:"paused"`
+	PausedAt    *time.Time `json:"pausedAt"`
+	PausedUntil *time.Time `json:"pausedUntil"`
+}
+
+// Service manages Gmail watches and tokens.
+type Service struct {
+	cfg           *config.Config
+	db            *sql.DB
+	tokens        *googleauth.TokenStore
+	notifications *notifications.Service
+	events        *eventlog.Service
+	// gmailOptions are added to the Gmail client Send creates; tests point
+	// it at a fake Gmail.
+	gmailOptions []option.ClientOption
+}
+
+// NewService creates a mailbox lifecycle manager.
+func NewService(cfg *config.Config, db *sql.DB, tokens *googleauth.TokenStore, notificationService *notifications.Service) *Service {
+	return &Service{cfg: cfg, db: db, tokens: tokens, notifications: notificationService, events: eventlog.NewService(db)}
+}
+
+// Status returns the user's Gmail connection state.
+func (s *Service) Status(ctx context.Context, userID string) (*Status, error) {
+	var st Status
+	var linked bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(refresh_token, '') <> '', mailbox_disconnected_at, mailbox_disconnect_reason, gmail_watch_expires_at,
+			mailbox_paused_at, mailbox_paused_until
+		FROM users WHERE id = $1`,
+		userID).Scan(&linked, &st.DisconnectedAt, &st.DisconnectReason, &st.WatchExpiresAt, &st.PausedAt, &st.PausedUntil)
+	if err != nil {
+		return nil, err
+	}
+	st.Connected = linked && st.DisconnectedAt == nil
+	st.Paused = st.PausedAt != nil
+	return &st, nil
+}
+
+// Maintain resumes mailboxes whose pause ended, refreshes tokens that are
+// about to expire and renews the watches of unpaused mailboxes that are
+// due, disconnecting mailboxes whose grant was revoked. Failures for one
+// user are logged and do not stop the run.
+func (s *Service) Maintain(ctx context.Context) error {
+	if err := s.resumeDue(ctx); err != nil {
+		return err
+	}
+	due, err := s.users(ctx, `token_expires_at IS NULL OR token_expires_at < $1`, time.Now().Add(refreshAhead))
+	if err != nil {
+		return err
+	}
+	for _, userID := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.tokens.Refresh(ctx, userID); err != nil {
+			s.fail(ctx, userID, "refresh token", err)
+		}
+	}
+
+	if s.cfg.GmailPubSubTopic == "" {
+		return nil
+	}
+	due, err = s.users(ctx, `mailbox_paused_at IS NULL AND (gmail_watch_expires_at IS NULL OR gmail_watch_expires_at < $1)`, time.Now().Add(watchRenewAhead))
+	if err != nil {
+		return err
+	}
+	for _, userID := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.renewWatch(ctx, userID); err != nil {
+			s.fail(ctx, userID, "renew Gmail watch", err)
+		}
+	}
+	return nil
+}
+
+// users lists connected users matching the condition.
+func (s *Service) users(ctx context.Context, cond string, arg interface{}) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM users
+		WHERE COALESCE(refresh_token, '') <> '' AND mailbox_disconnected_at IS NULL AND (`+cond+`)
+		LIMIT `+fmt.Sprint(batchSize),
+		arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *Service) renewWatch(ctx context.Context, userID string) error {
+	ts, err := s.tokens.TokenSource(ctx, userID)
+	if err != nil {
+		return err
+	}
+	svc, err := gmail.NewService(ctx, option.WithTokenSource(ts))
+	if err != nil {
+		return err
+	}
+	resp, err := svc.Users.Watch("me", &gmail.WatchRequest{
+		TopicName: s.cfg.GmailPubSubTopic,
+		LabelIds:  []string{"INBOX"},
+	}).Context(ctx).Do()
+	if err != nil {
+		return apperr.Wrap(apperr.UpstreamGmail, err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE users SET gmail_watch_expires_at = $2, gmail_history_id = COALESCE(gmail_history_id, $3)
+		WHERE id = $1`,
+		userID, time.UnixMilli(resp.Expiration), int64(resp.HistoryId))
+	return err
+}
+
+// fail logs a maintenance failure, disconnecting the mailbox if the cause
+// is a revoked grant.
+func (s *Service) fail(ctx context.Context, userID, what string, err error) {
+	if !googleauth.IsRevoked(err) {
+		log.Printf("Mailbox maintenance: %s for user %s: %v", what, userID, err)
+		return
+	}
+	if err := s.Disconnect(ctx, userID, "Google access was revoked or expired"); err != nil {
+		log.Printf("Mailbox maintenance: disconnect user %s: %v", userID, err)
+	}
+}
+
+// Disconnect discards the user's unusable tokens, marks the mailbox
+// disconnected and asks the user to re-link it. It is a no-op for mailboxes
+// that are already disconnected.
+func (s *Service) Disconnect(ctx context.Context, userID, reason string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE users SET access_token = NULL, refresh_token = NULL, token_expires_at = NULL,
+			gmail_watch_expires_at = NULL, mailbox_disconnected_at = CURRENT_TIMESTAMP, mailbox_disconnect_reason = $2
+		WHERE id = $1 AND mailbox_disconnected_at IS NULL`,
+		userID, reason)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	log.Printf("Mailbox for user %s disconnected: %s", userID, reason)
+
+	l := s.notifications.Localizer(ctx, userID)
+	body := l.T("mailbox.disconnected.body", reason, s.cfg.PublicURL+"/api/v1/auth/gmail")
+	return s.notifications.Notify(ctx, userID, notifications.KindMailboxDisconnected, l.T("mailbox.disconnected.title"), body)
+}
@@ -0,0 +1,200 @@
+// Package masking hides sensitive GraphQL fields from viewers other than
+// the account owner, such as someone holding a read-only share link or a
+// coach the user shared their search with. What each viewer level may see
+// is declared in one table, policy.go, and enforced by a gqlgen field
+// interceptor, so resolvers never need to check who is asking.
+package masking
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// Level is how much of the account a viewer may see.
+type Level int
+
+// Viewer levels, from least to most trusted.
+const (
+	// LevelShare is anyone holding a read-only share link.
+	LevelShare Level = iota + 1
+	// LevelCoach is a coach the user shared their search with: it also
+	// sees compensation, notes that are not private and contact details.
+	LevelCoach
+	// LevelOwner is the account owner, who sees everything.
+	LevelOwner
+)
+
+// Names of the shared viewer levels, as stored on share links.
+const (
+	NameShare = "share"
+	NameCoach = "coach"
+)
+
+// ParseLevel returns the shared viewer level with the given name.
+func ParseLevel(name string) (Level, bool) {
+	switch name {
+	case NameShare:
+		return LevelShare, true
+	case NameCoach:
+		return LevelCoach, true
+	}
+	return 0, false
+}
+
+// Redacted replaces the text of redacted string fields.
+const Redacted = "[redacted]"
+
+var (
+	// ErrReadOnly is returned for mutations and subscriptions from shared
+	// viewers.
+	ErrReadOnly = apperr.New(apperr.Forbidden, "shared views are read-only")
+	// ErrNotShared is returned for queries the viewer's level may not run.
+	ErrNotShared = apperr.New(apperr.Forbidden, "not available in shared views")
+)
+
+type contextKey struct{}
+
+// WithLevel returns a context for a viewer of the given level.
+func WithLevel(ctx context.Context, level Level) context.Context {
+	return context.WithValue(ctx, contextKey{}, level)
+}
+
+// LevelFromContext returns the viewer's level, LevelOwner unless a share
+// link set another.
+func LevelFromContext(ctx context.Context) Level {
+	if level, ok := ctx.Value(contextKey{}).(Level); ok {
+		return level
+	}
+	return LevelOwner
+}
+
+// Private is implemented by objects whose owner can mark some of their
+// fields private, hiding them from every other viewer.
+type Private interface {
+	PrivateField(field string) bool
+}
+
+// Masker is a gqlgen handler extension; the GraphQL server adds it with
+// Use.
+type Masker struct{}
+
+// New creates a field masker.
+func New() *Masker {
+	return &Masker{}
+}
+
+var (
+	_ graphql.HandlerExtension = (*Masker)(nil)
+	_ graphql.FieldInterceptor = (*Masker)(nil)
+)
+
+// ExtensionName implements graphql.HandlerExtension.
+func (m *Masker) ExtensionName() string {
+	return "FieldMasking"
+}
+
+// Validate implements graphql.HandlerExtension. It fails the server's start
+// when the policy names a field the schema lacks, omits a non-null field or
+// redacts one that is not a string, so the table cannot drift from the
+// schema unnoticed.
+func (m *Masker) Validate(es graphql.ExecutableSchema) error {
+	schema := es.Schema()
+	for root := range queries {
+		if schema.Query.Fields.ForName(root) == nil {
+			return fmt.Errorf("masking policy: Query.%s is not in the schema", root)
+		}
+	}
+	for coordinate, r := range fields {
+		typeName, fieldName, _ := strings.Cut(coordinate, ".")
+		def := schema.Types[typeName]
+		if def == nil || def.Fields.ForName(fieldName) == nil {
+			return fmt.Errorf("masking policy: %s is not in the schema", coordinate)
+		}
+		t := def.Fields.ForName(fieldName).Type
+		switch {
+		case r.action == omit && t.NonNull:
+			return fmt.Errorf("masking policy: %s is non-null and cannot be omitted", coordinate)
+		case r.action == redact && (t.Elem != nil || t.NamedType != "String"):
+			return fmt.Errorf("masking policy: %s is not a string and cannot be redacted", coordinate)
+		}
+	}
+	return nil
+}
+
+// InterceptField implements graphql.FieldInterceptor. Shared viewers may
+// only run the queries the policy lists for their level, and fields above
+// their level are omitted or redacted; owners are never affected.
+func (m *Masker) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	level := LevelFromContext(ctx)
+	fc := graphql.GetFieldContext(ctx)
+	if level >= LevelOwner || fc == nil || strings.HasPrefix(fc.Object, "__") || strings.HasPrefix(fc.Field.Name, "__") {
+		return next(ctx)
+	}
+
+	switch fc.Object {
+	case "Mutation", "Subscription":
+		return nil, ErrReadOnly
+	case "Query":
+		if need, ok := queries[fc.Field.Name]; !ok || level < need {
+			return nil, ErrNotShared
+		}
+		return next(ctx)
+	}
+
+	r, ok := fields[fc.Object+"."+fc.Field.Name]
+	if !ok {
+		return next(ctx)
+	}
+	need := r.level
+	if r.private && private(fc, fc.Field.Name) {
+		need = LevelOwner
+	}
+	if level >= need {
+		return next(ctx)
+	}
+	if r.action == omit {
+		return nil, nil
+	}
+	res, err := next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch v := res.(type) {
+	case string:
+		return Redacted, nil
+	case *string:
+		if v != nil {
+			s := Redacted
+			return &s, nil
+		}
+	}
+	return res, nil
+}
+
+// private reports whether the object the field belongs to marks it
+// private, or cannot be asked. The object is the parent field's result, held by pointer when
+// it is a list element.
+func private(fc *graphql.FieldContext, field string) bool {
+	if fc.Parent == nil {
+		return true
+	}
+	obj := fc.Parent.Result
+	for obj != nil {
+		if p, ok := obj.(Private); ok {
+			return p.PrivateField(field)
+		}
+		v := reflect.ValueOf(obj)
+		if v.Kind() != reflect.Pointer || v.IsNil() {
+			break
+		}
+		obj = v.Elem().Interface()
+	}
+	// Fail closed when the object cannot say.
+	return true
+}
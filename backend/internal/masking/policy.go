@@ -0,0 +1,83 @@
+package masking
+
+type action int
+
+const (
+	// omit resolves the field to null without running its resolver.
+	omit action = iota
+	// redact replaces the field's text with Redacted.
+	redact
+)
+
+type rule struct {
+	level  Level
+	action action
+	// private fields need LevelOwner when their object marks them private.
+	private bool
+}
+
+// queries are the root queries shared viewers may run, with the level each
+// needs. Any other query, and every mutation and subscription, is for the
+// owner only.
+var queries = map[string]Level{
+	"applications":       LevelShare,
+	"application":        LevelShare,
+	"company":            LevelShare,
+	"boardDiff":          LevelShare,
+	"deadlinesSoon":      LevelShare,
+	"interviews":         LevelShare,
+	"interviewLoops":     LevelShare,
+	"interviewLoop":      LevelShare,
+	"timeInStage":        LevelShare,
+	"sourceAnalytics":    LevelShare,
+	"analyticsSnapshot":  LevelShare,
+	"loopOutcomes":       LevelShare,
+	"wishlistConversion": LevelShare,
+	"activityHeatmap":    LevelShare,
+	"keywordFrequency":   LevelShare,
+	"capabilities":       LevelShare,
+	"schemaChangelog":    LevelShare,
+	"health":             LevelShare,
+
+	"offerComparison":  LevelCoach,
+	"companyNotes":     LevelCoach,
+	"pendingActions":   LevelCoach,
+	"preparationFocus": LevelCoach,
+	"goals":            LevelCoach,
+	"resumes":          LevelCoach,
+	"outreach":         LevelCoach,
+}
+
+// fields are the sensitive fields of objects reachable from shared
+// queries, keyed by Type.field.
+var fields = map[string]rule{
+	// Compensation
+	"Compensation.expected":   {level: LevelCoach},
+	"Compensation.offer":      {level: LevelCoach},
+	"Compensation.normalized": {level: LevelCoach},
+	"Compensation.vsMedian":   {level: LevelCoach},
+
+	// Notes; the owner can mark an application's notes private
+	"Application.notes":           {level: LevelCoach, private: true},
+	"Application.companyNotes":    {level: LevelCoach},
+	"Company.notes":               {level: LevelCoach},
+	"InterviewRound.feedback":     {level: LevelCoach},
+	"Interview.selfAssessment":    {level: LevelCoach},
+	"Interview.transcript":        {level: LevelCoach},
+	"Interview.transcriptSummary": {level: LevelCoach},
+	"Application.referral":        {level: LevelCoach},
+	"Application.resume":          {level: LevelCoach},
+
+	// Contact details and links that work without signing in
+	"Application.alias":                {level: LevelCoach},
+	"Application.portalUrl":            {level: LevelCoach},
+	"Interview.meetingLink":            {level: LevelCoach},
+	"Interview.interviewerEmail":       {level: LevelCoach},
+	"Interview.calendarEventId":        {level: LevelCoach},
+	"ApplicationAction.recipientEmail": {level: LevelCoach},
+
+	// Email content
+	"Application.summary":     {level: LevelCoach},
+	"ApplicationAction.draft": {level: LevelOwner},
+	"EmailDraft.body":         {level: LevelOwner, action: redact},
+}
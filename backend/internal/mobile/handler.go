@@ -0,0 +1,161 @@
+// Package mobile serves compact, pre-joined REST payloads for the mobile
+// client so a cold start needs one request instead of several GraphQL
+// round trips. All routes are authenticated with an API key.
+package mobile
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+const (
+	defaultLimit = 5
+	maxLimit     = 50
+)
+
+// Handler serves the mobile API.
+type Handler struct {
+	db *sql.DB
+}
+
+// New creates a mobile handler.
+func New(db *sql.DB) *Handler {
+	return &Handler{db: db}
+}
+
+// Register mounts the mobile routes on the group.
+func (h *Handler) Register(rg *gin.RouterGroup) {
+	rg.GET("/home", h.Home())
+	rg.GET("/summary", h.Summary())
+	rg.GET("/interviews/upcoming", h.UpcomingInterviews())
+	rg.GET("/changes", h.RecentChanges())
+}
+
+type homeResponse struct {
+	Summary        *Summary            `json:"summary"`
+	NextInterviews []UpcomingInterview `json:"nextInterviews"`
+	RecentChanges  []Change            `json:"recentChanges"`
+}
+
+// Home returns everything the app's first screen needs in one payload.
+func (h *Handler) Home() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, userID := c.Request.Context(), auth.UserID(c)
+		limit := limitParam(c)
+
+		s, err := summary(ctx, h.db, userID)
+		if err != nil {
+			fail(c, "home summary", err)
+			return
+		}
+		interviews, err := upcomingInterviews(ctx, h.db, userID, limit)
+		if err != nil {
+			fail(c, "home interviews", err)
+			return
+		}
+		changes, err := recentChanges(ctx, h.db, userID, nil, limit)
+		if err != nil {
+			fail(c, "home changes", err)
+			return
+		}
+
+		respond(c, homeResponse{
+			Summary:        s,
+			NextInterviews: interviews,
+			RecentChanges:  changes,
+		})
+	}
+}
+
+// Summary returns the dashboard counts.
+func (h *Handler) Summary() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s, err := summary(c.Request.Context(), h.db, auth.UserID(c))
+		if err != nil {
+			fail(c, "summary", err)
+			return
+		}
+		respond(c, s)
+	}
+}
+
+// UpcomingInterviews returns the next scheduled interviews.
+func (h *Handler) UpcomingInterviews() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		interviews, err := upcomingInterviews(c.Request.Context(), h.db, auth.UserID(c), limitParam(c))
+		if err != nil {
+			fail(c, "upcoming interviews", err)
+			return
+		}
+		respond(c, gin.H{"interviews": interviews})
+	}
+}
+
+// RecentChanges returns the latest status changes, optionally only those
+// after ?since= (RFC 3339) so the app can fetch just what is new.
+func (h *Handler) RecentChanges() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var since *time.Time
+		if raw := c.Query("since"); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				apperr.Respond(c, "Mobile recent changes", validation.Field("since", "must be an RFC 3339 timestamp"))
+				return
+			}
+			since = &t
+		}
+		changes, err := recentChanges(c.Request.Context(), h.db, auth.UserID(c), since, limitParam(c))
+		if err != nil {
+			fail(c, "recent changes", err)
+			return
+		}
+		respond(c, gin.H{"changes": changes})
+	}
+}
+
+func limitParam(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return defaultLimit
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
+
+// respond writes the payload with an ETag so the app can revalidate its
+// cached copy with If-None-Match and receive an empty 304 when nothing
+// changed.
+func respond(c *gin.Context, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		fail(c, "encode", err)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+func fail(c *gin.Context, what string, err error) {
+	apperr.Respond(c, "Mobile "+what, err)
+}
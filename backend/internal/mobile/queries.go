@@ -0,0 +1,113 @@
+package mobile
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Summary is the dashboard header: pipeline counts plus badge counts.
+type Summary struct {
+	Total               int `json:"total"`
+	Active              int `json:"active"`
+	Interviewing        int `json:"interviewing"`
+	Offers              int `json:"offers"`
+	AppliedThisWeek     int `json:"appliedThisWeek"`
+	Saved               int `json:"saved"` // roles not applied to yet
+	PendingActions      int `json:"pendingActions"`
+	UnreadNotifications int `json:"unreadNotifications"`
+}
+
+// UpcomingInterview is an interview with its application's company and
+// position joined in.
+type UpcomingInterview struct {
+	ID            string    `json:"id"`
+	ApplicationID string    `json:"applicationId"`
+	Company       string    `json:"company"`
+	Position      string    `json:"position"`
+	Title         string    `json:"title"`
+	StartsAt      time.Time `json:"startsAt"`
+	EndsAt        time.Time `json:"endsAt"`
+	Timezone      string    `json:"timezone"`
+	MeetingLink   *string   `json:"meetingLink,omitempty"`
+	Location      *string   `json:"location,omitempty"`
+}
+
+// Change is a status change with its application's company and position
+// joined in.
+type Change struct {
+	ApplicationID string    `json:"applicationId"`
+	Company       string    `json:"company"`
+	Position      string    `json:"position"`
+	Status        string    `json:"status"`
+	ChangedAt     time.Time `json:"changedAt"`
+}
+
+func summary(ctx context.Context, db *sql.DB, userID string) (*Summary, error) {
+	s := &Summary{}
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE status <> 'Saved'),
+			COUNT(*) FILTER (WHERE status NOT IN ('Rejected', 'Withdrawn', 'Accepted', 'Saved')),
+			COUNT(*) FILTER (WHERE status IN ('Interview Scheduled', 'Interview Complete')),
+			COUNT(*) FILTER (WHERE status = 'Offer'),
+			COUNT(*) FILTER (WHERE applied_date >= date_trunc('week', CURRENT_DATE) AND status <> 'Saved'),
+			COUNT(*) FILTER (WHERE status = 'Saved'),
+			(SELECT COUNT(*) FROM application_actions x JOIN applications a ON a.id = x.application_id
+				WHERE x.user_id = $1 AND x.status = 'pending' AND a.snoozed_until IS NULL),
+			(SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL)
+		FROM applications WHERE user_id = $1`,
+		userID).Scan(&s.Total, &s.Active, &s.Interviewing, &s.Offers, &s.AppliedThisWeek,
+		&s.Saved, &s.PendingActions, &s.UnreadNotifications)
+	return s, err
+}
+
+func upcomingInterviews(ctx context.Context, db *sql.DB, userID string, limit int) ([]UpcomingInterview, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT i.id, i.application_id, a.company, a.position, i.title, i.starts_at, i.ends_at,
+			i.timezone, i.meeting_link, i.location
+		FROM interviews i JOIN applications a ON a.id = i.application_id
+		WHERE i.user_id = $1 AND i.status = 'scheduled' AND i.ends_at >= CURRENT_TIMESTAMP
+		ORDER BY i.starts_at
+		LIMIT $2`,
+		userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []UpcomingInterview{}
+	for rows.Next() {
+		var iv UpcomingInterview
+		if err := rows.Scan(&iv.ID, &iv.ApplicationID, &iv.Company, &iv.Position, &iv.Title,
+			&iv.StartsAt, &iv.EndsAt, &iv.Timezone, &iv.MeetingLink, &iv.Location); err != nil {
+			return nil, err
+		}
+		out = append(out, iv)
+	}
+	return out, rows.Err()
+}
+
+func recentChanges(ctx context.Context, db *sql.DB, userID string, since *time.Time, limit int) ([]Change, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT h.application_id, a.company, a.position, h.status, h.changed_at
+		FROM application_status_history h JOIN applications a ON a.id = h.application_id
+		WHERE h.user_id = $1 AND ($2::timestamptz IS NULL OR h.changed_at > $2)
+		ORDER BY h.changed_at DESC
+		LIMIT $3`,
+		userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Change{}
+	for rows.Next() {
+		var ch Change
+		if err := rows.Scan(&ch.ApplicationID, &ch.Company, &ch.Position, &ch.Status, &ch.ChangedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, ch)
+	}
+	return out, rows.Err()
+}
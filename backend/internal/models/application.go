@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Application statuses, mirroring ApplicationStatus in shared/types.ts.
+const (
+	StatusApplied            = "Applied"
+	StatusUnderReview        = "Under Review"
+	StatusInterviewScheduled = "Interview Scheduled"
+	StatusInterviewComplete  = "Interview Complete"
+	StatusOffer              = "Offer"
+	StatusRejected           = "Rejected"
+	StatusWithdrawn          = "Withdrawn"
+	StatusAccepted           = "Accepted"
+	// StatusSaved is a role the user means to apply to but has not yet.
+	StatusSaved = "Saved"
+)
+
+// Application is a row of the applications table.
+type Application struct {
+	ID               string         `json:"id"`
+	UserID           string         `json:"-"`
+	Company          string         `json:"company"`
+	Position         string         `json:"position"`
+	AppliedDate      string         `json:"appliedDate"`
+	Status           string         `json:"status"`
+	Source           string         `json:"source"`
+	Location         *string        `json:"location"`
+	JobID            *string        `json:"jobId"`
+	StatusLink       *string        `json:"statusLink"`
+	Notes            *string        `json:"notes"`
+	NotesPrivate     bool           `json:"notesPrivate"` // notes hidden from shared views
+	EmailID          *string        `json:"-"`
+	ATS              *string        `json:"ats"`
+	PortalURL        *string        `json:"portalUrl"`
+	SnoozedUntil     *time.Time     `json:"snoozedUntil"` // hidden and silenced until then
+	JobDescription   *string        `json:"jobDescription"`
+	PostingDeadline  *time.Time     `json:"postingDeadline"` // when the posting stops taking applications
+	Tags             []string       `json:"tags"`
+	CustomFields     []*CustomField `json:"customFields"`
+	WithdrawalReason *string        `json:"withdrawalReason"` // set once the user withdraws
+	Alias            *string        `json:"alias"`            // the user's address the company writes to
+	ArchivedAt       *time.Time     `json:"archivedAt"`       // hidden from default views for good
+	CreatedAt        time.Time      `json:"createdAt"`
+	UpdatedAt        time.Time      `json:"updatedAt"`
+}
+
+// PrivateField reports whether the user marked the field private, hiding
+// it from shared views; it implements masking.Private.
+func (a *Application) PrivateField(field string) bool {
+	return field == "notes" && a.NotesPrivate
+}
+
+// CustomField is a user-defined field on an application, such as
+// "Visa sponsorship: yes".
+type CustomField struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Value string `json:"value" validate:"max=1000"`
+}
+
+// ReachedInterview reports whether the status implies at least one interview.
+func ReachedInterview(status string) bool {
+	switch status {
+	case StatusInterviewScheduled, StatusInterviewComplete, StatusOffer, StatusAccepted:
+		return true
+	}
+	return false
+}
+
+// ReachedOffer reports whether the status implies an offer was extended.
+func ReachedOffer(status string) bool {
+	return status == StatusOffer || status == StatusAccepted
+}
+
+// HasResponse reports whether the company has replied beyond the initial
+// application acknowledgement.
+func HasResponse(status string) bool {
+	switch status {
+	case StatusSaved, StatusApplied, StatusWithdrawn:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,9 @@
+package models
+
+// Application event types recorded in application_events.
+const (
+	EventApplicationCreated = "application_created"
+	EventStatusChanged      = "status_changed"
+	EventFollowUpSent       = "follow_up_sent"
+	EventInterviewScheduled = "interview_scheduled"
+)
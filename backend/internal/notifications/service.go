@@ -0,0 +1,105 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jobtracker/backend/internal/i18n"
+)
+
+// Notification kinds.
+const (
+	KindGoalSummary         = "goal_summary"
+	KindCompanyWatch        = "company_watch"
+	KindMailboxDisconnected = "mailbox_disconnected"
+	KindSyncAnomaly         = "sync_anomaly"
+	KindOfferDeadline       = "offer_deadline"
+	KindReferralThanks      = "referral_thanks"
+	KindMailboxRule         = "mailbox_rule"
+	KindInterviewFeedback   = "interview_feedback"
+	KindPostingClosing      = "posting_closing"
+	KindQuotaWarning        = "quota_warning"
+)
+
+// Notification is a message shown in the user's notification feed.
+type Notification struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Service stores and lists user notifications.
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a notification service backed by the given database.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Notify records a new notification for the user.
+func (s *Service) Notify(ctx context.Context, userID, kind, title, body string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, kind, title, body)
+		VALUES ($1, $2, $3, $4)`,
+		userID, kind, title, body)
+	return err
+}
+
+// Localizer returns a localizer for the user's locale, which notification
+// titles and bodies are written in. Unknown users and lookup failures get
+// the default locale.
+func (s *Service) Localizer(ctx context.Context, userID string) *i18n.Localizer {
+	var locale sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT locale FROM users WHERE id = $1`, userID).Scan(&locale)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Failed to look up locale of user %s: %v", userID, err)
+	}
+	return i18n.For(locale.String)
+}
+
+// List returns the user's most recent notifications.
+func (s *Service) List(ctx context.Context, userID string, unreadOnly bool, limit int) ([]*Notification, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, kind, title, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3`,
+		userID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Notification
+	for rows.Next() {
+		n := &Notification{}
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Title, &n.Body, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// MarkRead marks a notification as read. It reports false if the
+// notification does not exist or belongs to another user.
+func (s *Service) MarkRead(ctx context.Context, userID, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND read_at IS NULL`,
+		id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
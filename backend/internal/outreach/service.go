@@ -0,0 +1,269 @@
+// Package outreach tracks cold emails the user sends to recruiters and
+// hiring managers before applying. Replies are picked up from the synced
+// mailbox, outreach is linked to the application it led to, and analytics
+// report how often outreach turns into replies and applications.
+package outreach
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Reply statuses.
+const (
+	StatusAwaiting = "awaiting"
+	StatusReplied  = "replied"
+	StatusNoReply  = "no_reply" // given up on
+)
+
+// interviewStatuses are the application statuses that mean outreach got
+// the user an interview.
+var interviewStatuses = []string{
+	models.StatusInterviewScheduled, models.StatusInterviewComplete, models.StatusOffer, models.StatusAccepted,
+}
+
+var (
+	// ErrNotFound is returned when outreach does not exist or belongs to
+	// another user.
+	ErrNotFound = apperr.New(apperr.NotFound, "outreach not found")
+	// ErrApplicationNotFound is returned when linking outreach to an
+	// application the user does not have.
+	ErrApplicationNotFound = apperr.New(apperr.NotFound, "application not found")
+)
+
+// Outreach is one cold email to a contact at a company.
+type Outreach struct {
+	ID           string     `json:"id"`
+	ContactName  *string    `json:"contactName"`
+	ContactEmail string     `json:"contactEmail"`
+	Company      string     `json:"company"`
+	Position     *string    `json:"position"` // role asked about, if any
+	SentDate     string     `json:"sentDate"`
+	ReplyStatus  string     `json:"replyStatus"`
+	RepliedAt    *time.Time `json:"repliedAt"`
+	ReplyEmailID *string    `json:"replyEmailId"` // the reply found in the mailbox
+	// ApplicationID is the application the outreach led to.
+	ApplicationID *string   `json:"applicationId"`
+	Notes         *string   `json:"notes"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Input records or updates outreach. Leaving ReplyStatus empty keeps the
+// current status (awaiting for new outreach).
+type Input struct {
+	ContactName  *string `json:"contactName" validate:"omitempty,max=255"`
+	ContactEmail string  `json:"contactEmail" validate:"required,email,max=255"`
+	Company      string  `json:"company" validate:"required,max=255"`
+	Position     *string `json:"position" validate:"omitempty,max=1000"`
+	SentDate     string  `json:"sentDate" validate:"omitempty,datetime=2006-01-02"`
+	ReplyStatus  string  `json:"replyStatus" validate:"omitempty,oneof=awaiting replied no_reply"`
+	Notes        *string `json:"notes" validate:"omitempty,max=10000"`
+}
+
+// Service manages outreach.
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates an outreach service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+const columns = `id, contact_name, contact_email, company, position, sent_date::text, reply_status, replied_at,
+	reply_email_id, application_id, notes, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*Outreach, error) {
+	o := &Outreach{}
+	err := row.Scan(&o.ID, &o.ContactName, &o.ContactEmail, &o.Company, &o.Position, &o.SentDate, &o.ReplyStatus,
+		&o.RepliedAt, &o.ReplyEmailID, &o.ApplicationID, &o.Notes, &o.CreatedAt, &o.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return o, err
+}
+
+// List returns the user's outreach, most recently sent first, optionally
+// with one reply status.
+func (s *Service) List(ctx context.Context, userID string, status *string) ([]*Outreach, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM outreach
+		WHERE user_id = $1 AND ($2::text IS NULL OR reply_status = $2)
+		ORDER BY sent_date DESC, created_at DESC`, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Outreach
+	for rows.Next() {
+		o, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// Create records outreach sent on SentDate, today by default.
+func (s *Service) Create(ctx context.Context, userID string, in Input) (*Outreach, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	return scan(s.db.QueryRowContext(ctx, `
+		INSERT INTO outreach (user_id, contact_name, contact_email, company, position, sent_date, reply_status, notes)
+		VALUES ($1, $2, LOWER($3), $4, $5, COALESCE(NULLIF($6, '')::date, CURRENT_DATE), COALESCE(NULLIF($7, ''), $8), $9)
+		RETURNING `+columns,
+		userID, in.ContactName, strings.TrimSpace(in.ContactEmail), strings.TrimSpace(in.Company), in.Position,
+		in.SentDate, in.ReplyStatus, StatusAwaiting, in.Notes))
+}
+
+// Update replaces the user's outreach details. Marking it replied by hand
+// records now as the reply time; marking it awaiting again clears it.
+func (s *Service) Update(ctx context.Context, userID, id string, in Input) (*Outreach, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	return scan(s.db.QueryRowContext(ctx, `
+		UPDATE outreach SET contact_name = $3, contact_email = LOWER($4), company = $5, position = $6,
+			sent_date = COALESCE(NULLIF($7, '')::date, sent_date),
+			reply_status = COALESCE(NULLIF($8, ''), reply_status),
+			replied_at = CASE
+				WHEN NULLIF($8, '') IS NULL OR $8 = reply_status THEN replied_at
+				WHEN $8 = $9 THEN CURRENT_TIMESTAMP
+				ELSE NULL END,
+			notes = $10, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+columns,
+		id, userID, in.ContactName, strings.TrimSpace(in.ContactEmail), strings.TrimSpace(in.Company), in.Position,
+		in.SentDate, in.ReplyStatus, StatusReplied, in.Notes))
+}
+
+// Delete removes the user's outreach.
+func (s *Service) Delete(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM outreach WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Link records the application outreach led to, or clears it when
+// applicationID is nil.
+func (s *Service) Link(ctx context.Context, userID, id string, applicationID *string) (*Outreach, error) {
+	if applicationID != nil {
+		var owned bool
+		if err := s.db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM applications WHERE id = $1 AND user_id = $2)`, *applicationID, userID).Scan(&owned); err != nil {
+			return nil, err
+		}
+		if !owned {
+			return nil, ErrApplicationNotFound
+		}
+	}
+	return scan(s.db.QueryRowContext(ctx, `
+		UPDATE outreach SET application_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+columns, id, userID, applicationID))
+}
+
+// Reconcile marks awaiting outreach replied when an email from the contact
+// dated after it was sent has been synced, and links outreach to the
+// earliest application to the same company made after it. It is intended
+// to run from the scheduler.
+func (s *Service) Reconcile(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE outreach o SET reply_status = $1, replied_at = r.date, reply_email_id = r.id, updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT DISTINCT ON (o.id) o.id AS outreach_id, e.id, e.date
+			FROM outreach o
+			JOIN email_cache e ON e.user_id = o.user_id
+				AND POSITION(o.contact_email IN LOWER(e.sender)) > 0
+				AND e.date >= o.sent_date
+			WHERE o.reply_status = $2
+			ORDER BY o.id, e.date
+		) r
+		WHERE o.id = r.outreach_id`,
+		StatusReplied, StatusAwaiting); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE outreach o SET application_id = m.id, updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT DISTINCT ON (o.id) o.id AS outreach_id, a.id
+			FROM outreach o
+			JOIN applications a ON a.user_id = o.user_id
+				AND LOWER(a.company) = LOWER(o.company)
+				AND a.applied_date >= o.sent_date
+			WHERE o.application_id IS NULL
+			ORDER BY o.id, a.applied_date, a.created_at
+		) m
+		WHERE o.id = m.outreach_id`)
+	return err
+}
+
+// Analytics is how the user's outreach converts.
+type Analytics struct {
+	Total     int `json:"total"`
+	Replied   int `json:"replied"`
+	Converted int `json:"converted"` // led to an application
+	// ReplyRate and ConversionRate are fractions of Total, 0 without
+	// outreach.
+	ReplyRate      float64 `json:"replyRate"`
+	ConversionRate float64 `json:"conversionRate"`
+	// MedianDaysToReply is nil until someone has replied.
+	MedianDaysToReply *float64 `json:"medianDaysToReply"`
+	// Interviewed is how many applications from outreach reached an
+	// interview or beyond.
+	Interviewed int `json:"interviewed"`
+}
+
+// Analytics reports reply and conversion rates of the user's outreach sent
+// in the date range (YYYY-MM-DD, inclusive); nil bounds are open.
+func (s *Service) Analytics(ctx context.Context, userID string, from, to *string) (*Analytics, error) {
+	a := &Analytics{}
+	var median sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE o.reply_status = $4),
+			COUNT(o.application_id),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (
+				ORDER BY EXTRACT(EPOCH FROM o.replied_at - o.sent_date::timestamptz) / 86400),
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM application_status_history h
+				WHERE h.application_id = o.application_id AND h.status = ANY($5)))
+		FROM outreach o
+		WHERE o.user_id = $1 AND ($2::date IS NULL OR o.sent_date >= $2::date) AND ($3::date IS NULL OR o.sent_date <= $3::date)`,
+		userID, from, to, StatusReplied, pq.Array(interviewStatuses)).Scan(&a.Total, &a.Replied, &a.Converted, &median, &a.Interviewed)
+	if err != nil {
+		return nil, err
+	}
+	if a.Total > 0 {
+		a.ReplyRate = float64(a.Replied) / float64(a.Total)
+		a.ConversionRate = float64(a.Converted) / float64(a.Total)
+	}
+	if median.Valid {
+		days := math.Round(median.Float64*10) / 10
+		a.MedianDaysToReply = &days
+	}
+	return a, nil
+}
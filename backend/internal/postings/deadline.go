@@ -0,0 +1,69 @@
+package postings
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// validThroughLayouts are the forms of schema.org validThrough seen on job
+// boards, most specific first.
+var validThroughLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// parseValidThrough parses a JSON-LD validThrough value. A bare date means
+// the posting closes at the end of that day, taken as UTC since the page
+// gives no zone.
+func parseValidThrough(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range validThroughLayouts {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return &t
+	}
+	return nil
+}
+
+// deadlinePattern finds phrases like "Apply by March 31, 2025" or
+// "Closing date: 31/03/2025" in posting text.
+var deadlinePattern = regexp.MustCompile(`(?i)\b(?:apply (?:by|before)|application deadline|deadline to apply|applications? (?:close|closes|are due)(?: on)?|closing date|posting closes(?: on)?)\s*(?:is|:|-)?\s*(?:on\s+)?([A-Za-z0-9][A-Za-z0-9 ,./-]{5,30})`)
+
+var textDateLayouts = []string{
+	"January 2, 2006", "January 2 2006", "Jan 2, 2006", "Jan 2 2006", "Jan. 2, 2006",
+	"2 January 2006", "2 Jan 2006", "2006-01-02", "02/01/2006", "2/1/2006", "02.01.2006",
+}
+
+// ordinalSuffix strips "st", "nd", "rd" and "th" from day numbers.
+var ordinalSuffix = regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)\b`)
+
+// deadlineFromText looks for a stated application deadline in posting text.
+// Numeric dates are read day first, except in ISO form, since postings
+// giving a "closing date" are mostly from outside the US.
+func deadlineFromText(s string) *time.Time {
+	m := deadlinePattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+	candidate := ordinalSuffix.ReplaceAllString(strings.TrimSpace(m[1]), "$1")
+	// Try the longest prefix that parses, as the match runs past the date.
+	words := strings.Fields(candidate)
+	for n := min(len(words), 4); n > 0; n-- {
+		phrase := strings.TrimRight(strings.Join(words[:n], " "), ".,;")
+		for _, layout := range textDateLayouts {
+			if t, err := time.Parse(layout, phrase); err == nil {
+				t = t.Add(24*time.Hour - time.Second)
+				return &t
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package postings
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+func hostMatches(u *url.URL, domain string) bool {
+	host := strings.ToLower(u.Hostname())
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func optionalString(s string) *string {
+	if s = clean(s); s == "" {
+		return nil
+	}
+	return &s
+}
+
+// linkedIn extracts public (logged-out) LinkedIn job view pages.
+type linkedIn struct{}
+
+func (linkedIn) Name() string          { return "linkedin" }
+func (linkedIn) Match(u *url.URL) bool { return hostMatches(u, "linkedin.com") }
+
+func (linkedIn) Extract(doc *nethtml.Node, _ *url.URL) (*Posting, error) {
+	return &Posting{
+		Title:       text(find(doc, byTagClass("h1", "top-card-layout__title"))),
+		Company:     text(find(doc, byTagClass("a", "topcard__org-name-link"))),
+		Location:    optionalString(text(find(doc, byTagClass("span", "topcard__flavor--bullet")))),
+		Description: text(find(doc, byTagClass("div", "show-more-less-html__markup"))),
+	}, nil
+}
+
+// indeed extracts Indeed "viewjob" pages.
+type indeed struct{}
+
+func (indeed) Name() string          { return "indeed" }
+func (indeed) Match(u *url.URL) bool { return hostMatches(u, "indeed.com") }
+
+func (indeed) Extract(doc *nethtml.Node, _ *url.URL) (*Posting, error) {
+	company := attr(orEmpty(find(doc, func(n *nethtml.Node) bool { return attr(n, "data-company-name") != "" })), "data-company-name")
+	if company == "" || company == "true" {
+		company = text(find(doc, byAttr("data-testid", "inlineHeader-companyName")))
+	}
+	return &Posting{
+		Title:       text(find(doc, byTagClass("h1", "jobsearch-JobInfoHeader-title"))),
+		Company:     company,
+		Location:    optionalString(text(find(doc, byAttr("data-testid", "inlineHeader-companyLocation")))),
+		Description: text(find(doc, byAttr("id", "jobDescriptionText"))),
+	}, nil
+}
+
+func orEmpty(n *nethtml.Node) *nethtml.Node {
+	if n == nil {
+		return &nethtml.Node{}
+	}
+	return n
+}
+
+// jsonLD reads schema.org JobPosting structured data, which most job boards
+// and ATS pages embed for search engines.
+type jsonLD struct{}
+
+func (jsonLD) Name() string        { return "json-ld" }
+func (jsonLD) Match(*url.URL) bool { return true }
+
+type jobPostingLD struct {
+	Type               any             `json:"@type"`
+	Title              string          `json:"title"`
+	Description        string          `json:"description"`
+	ValidThrough       string          `json:"validThrough"`
+	HiringOrganization json.RawMessage `json:"hiringOrganization"`
+	JobLocation        json.RawMessage `json:"jobLocation"`
+	Graph              []jobPostingLD  `json:"@graph"`
+}
+
+func (jsonLD) Extract(doc *nethtml.Node, _ *url.URL) (*Posting, error) {
+	var found *jobPostingLD
+	walk(doc, func(n *nethtml.Node) bool {
+		if n.Data != "script" || attr(n, "type") != "application/ld+json" || n.FirstChild == nil {
+			return true
+		}
+		found = findJobPosting([]byte(n.FirstChild.Data))
+		return found == nil
+	})
+	if found == nil {
+		return nil, nil
+	}
+
+	p := &Posting{
+		Title:       found.Title,
+		Company:     orgName(found.HiringOrganization),
+		Description: htmlToText(found.Description),
+		Deadline:    parseValidThrough(found.ValidThrough),
+	}
+	if loc := locationName(found.JobLocation); loc != "" {
+		p.Location = &loc
+	}
+	return p, nil
+}
+
+func findJobPosting(data []byte) *jobPostingLD {
+	var items []jobPostingLD
+	if err := json.Unmarshal(data, &items); err != nil {
+		var single jobPostingLD
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil
+		}
+		items = []jobPostingLD{single}
+	}
+	for i := range items {
+		if isJobPosting(items[i].Type) {
+			return &items[i]
+		}
+		for j := range items[i].Graph {
+			if isJobPosting(items[i].Graph[j].Type) {
+				return &items[i].Graph[j]
+			}
+		}
+	}
+	return nil
+}
+
+func isJobPosting(t any) bool {
+	switch v := t.(type) {
+	case string:
+		return v == "JobPosting"
+	case []any:
+		for _, x := range v {
+			if s, ok := x.(string); ok && s == "JobPosting" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func orgName(raw json.RawMessage) string {
+	var org struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(raw, &org) == nil && org.Name != "" {
+		return org.Name
+	}
+	var name string
+	json.Unmarshal(raw, &name)
+	return name
+}
+
+type placeLD struct {
+	Address struct {
+		Locality string `json:"addressLocality"`
+		Region   string `json:"addressRegion"`
+		Country  any    `json:"addressCountry"`
+	} `json:"address"`
+}
+
+func locationName(raw json.RawMessage) string {
+	var places []placeLD
+	if err := json.Unmarshal(raw, &places); err != nil {
+		var single placeLD
+		if json.Unmarshal(raw, &single) != nil {
+			return ""
+		}
+		places = []placeLD{single}
+	}
+	var names []string
+	for _, pl := range places {
+		var parts []string
+		for _, s := range []string{pl.Address.Locality, pl.Address.Region} {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		}
+		if len(parts) > 0 {
+			names = append(names, strings.Join(parts, ", "))
+		}
+	}
+	return strings.Join(names, " / ")
+}
+
+// htmlToText converts an HTML fragment (as JSON-LD descriptions usually are)
+// to plain text.
+func htmlToText(s string) string {
+	s = html.UnescapeString(s)
+	frag, err := nethtml.Parse(strings.NewReader(s))
+	if err != nil {
+		return s
+	}
+	return text(frag)
+}
+
+// readability is the last-resort extractor: it takes the page title from
+// Open Graph or <title>, the company from og:site_name, and the description
+// from the element with the most paragraph text.
+type readability struct{}
+
+func (readability) Name() string        { return "readability" }
+func (readability) Match(*url.URL) bool { return true }
+
+func (readability) Extract(doc *nethtml.Node, u *url.URL) (*Posting, error) {
+	title := meta(doc, "og:title")
+	if title == "" {
+		title = text(find(doc, func(n *nethtml.Node) bool { return n.Data == "h1" }))
+	}
+	if title == "" {
+		title = text(find(doc, func(n *nethtml.Node) bool { return n.Data == "title" }))
+	}
+	company := meta(doc, "og:site_name")
+
+	var best *nethtml.Node
+	bestScore := 0
+	walk(doc, func(n *nethtml.Node) bool {
+		switch n.Data {
+		case "article", "main", "section", "div":
+		default:
+			return true
+		}
+		score := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == nethtml.ElementNode && (c.Data == "p" || c.Data == "ul" || c.Data == "li") {
+				score += len(text(c))
+			}
+		}
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+		return true
+	})
+
+	description := text(best)
+	if description == "" {
+		description = meta(doc, "og:description")
+	}
+
+	return &Posting{Title: title, Company: company, Description: description}, nil
+}
@@ -0,0 +1,55 @@
+package postings
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/jobtracker/backend/internal/safehttp"
+)
+
+// maxPageBytes bounds how much of a posting page is read.
+const maxPageBytes = 5 << 20
+
+// Fetcher downloads posting pages using a client that refuses to connect
+// to non-public addresses.
+type Fetcher struct {
+	client *http.Client
+}
+
+// NewFetcher creates a fetcher with the given request timeout.
+func NewFetcher(timeout time.Duration) *Fetcher {
+	return &Fetcher{client: safehttp.NewClient(timeout)}
+}
+
+// Fetch downloads and parses the page, returning the URL after redirects.
+func (f *Fetcher) Fetch(ctx context.Context, u *url.URL) (*html.Node, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; JobTracker/1.0; +https://github.com/jobtracker)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.8")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, resp.Request.URL, nil
+}
@@ -0,0 +1,113 @@
+package postings
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// walk calls fn for every element node under n, stopping early if fn returns false.
+func walk(n *html.Node, fn func(*html.Node) bool) bool {
+	if n.Type == html.ElementNode && !fn(n) {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !walk(c, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns the first element matching pred.
+func find(n *html.Node, pred func(*html.Node) bool) *html.Node {
+	var found *html.Node
+	walk(n, func(el *html.Node) bool {
+		if pred(el) {
+			found = el
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func byTagClass(tag, class string) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		return n.Data == tag && hasClass(n, class)
+	}
+}
+
+func byAttr(key, value string) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		return attr(n, key) == value
+	}
+}
+
+// text returns the visible text under n, with block elements separated by newlines.
+func text(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	var rec func(*html.Node)
+	rec = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode:
+			switch n.Data {
+			case "script", "style", "noscript", "svg":
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			rec(c)
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "p", "div", "li", "br", "h1", "h2", "h3", "h4", "ul", "ol", "section":
+				b.WriteString("\n")
+			}
+		}
+	}
+	rec(n)
+
+	lines := strings.Split(b.String(), "\n")
+	out := lines[:0]
+	for _, l := range lines {
+		if l = clean(l); l != "" {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// meta returns the content of a <meta property=...> or <meta name=...> tag.
+func meta(doc *html.Node, key string) string {
+	n := find(doc, func(n *html.Node) bool {
+		return n.Data == "meta" && (attr(n, "property") == key || attr(n, "name") == key)
+	})
+	if n == nil {
+		return ""
+	}
+	return attr(n, "content")
+}
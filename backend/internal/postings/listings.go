@@ -0,0 +1,63 @@
+package postings
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Listing is a single role linked from a careers page.
+type Listing struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// jobPathPattern matches link paths that usually point at an individual
+// role on company careers sites and hosted ATS boards.
+var jobPathPattern = regexp.MustCompile(`(?i)/(jobs?|careers?|positions?|openings?|roles?|vacanc(y|ies)|requisitions?)/[^/]+`)
+
+// Listings fetches a careers page and returns the roles it links to. Links
+// are kept when their path looks like a job detail page and their text reads
+// like a title; duplicates and links back to the page itself are dropped.
+func (s *Service) Listings(ctx context.Context, rawURL string) ([]Listing, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrUnsupportedURL
+	}
+
+	doc, finalURL, err := s.fetcher.Fetch(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("fetch careers page: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var out []Listing
+	walk(doc, func(n *html.Node) bool {
+		if n.Data != "a" {
+			return true
+		}
+		href, err := finalURL.Parse(attr(n, "href"))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https") {
+			return true
+		}
+		href.Fragment = ""
+		if href.Path == finalURL.Path || !jobPathPattern.MatchString(href.Path) {
+			return true
+		}
+		title := clean(text(n))
+		if len(title) < 3 || len(title) > 150 {
+			return true
+		}
+		key := href.String()
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, Listing{Title: title, URL: key})
+		}
+		return true
+	})
+	return out, nil
+}
@@ -0,0 +1,154 @@
+// Package postings fetches public job posting pages and extracts the fields
+// needed to pre-fill a new application, including the application deadline
+// when the posting states one.
+package postings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// ErrUnsupportedURL is returned for URLs that are not http(s).
+var ErrUnsupportedURL = apperr.New(apperr.Validation, "unsupported posting URL")
+
+// Posting is the data extracted from a job posting page.
+type Posting struct {
+	URL         string  `json:"url"`
+	Title       string  `json:"title"`
+	Company     string  `json:"company"`
+	Location    *string `json:"location"`
+	Description string  `json:"description"`
+	Source      string  `json:"source"`
+	Extractor   string  `json:"extractor"`
+	// Deadline is when the posting stops taking applications, if it says.
+	Deadline *time.Time `json:"deadline"`
+}
+
+// Extractor pulls posting fields out of a page for the sites it matches.
+type Extractor interface {
+	Name() string
+	Match(u *url.URL) bool
+	Extract(doc *html.Node, u *url.URL) (*Posting, error)
+}
+
+// Service captures job postings.
+type Service struct {
+	fetcher    *Fetcher
+	extractors []Extractor
+	fallbacks  []Extractor
+}
+
+// NewService creates a posting capture service with the built-in site
+// extractors and the generic fallbacks.
+func NewService(fetcher *Fetcher) *Service {
+	return &Service{
+		fetcher:    fetcher,
+		extractors: []Extractor{linkedIn{}, indeed{}},
+		fallbacks:  []Extractor{jsonLD{}, readability{}},
+	}
+}
+
+// Capture fetches the posting at rawURL and extracts its fields. Site
+// extractors are tried first; when they do not recognise the page or return
+// incomplete data, the structured-data and readability fallbacks fill the gaps.
+func (s *Service) Capture(ctx context.Context, rawURL string) (*Posting, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrUnsupportedURL
+	}
+
+	doc, finalURL, err := s.fetcher.Fetch(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("fetch posting: %w", err)
+	}
+
+	var candidates []Extractor
+	for _, e := range s.extractors {
+		if e.Match(finalURL) {
+			candidates = append(candidates, e)
+		}
+	}
+	candidates = append(candidates, s.fallbacks...)
+
+	result := &Posting{URL: finalURL.String(), Source: sourceName(finalURL)}
+	for _, e := range candidates {
+		p, err := e.Extract(doc, finalURL)
+		if err != nil || p == nil {
+			continue
+		}
+		if result.Extractor == "" && p.Title != "" {
+			result.Extractor = e.Name()
+		}
+		merge(result, p)
+		if complete(result) {
+			break
+		}
+	}
+	if result.Title == "" {
+		return nil, errors.New("could not find a job title on the page")
+	}
+	if result.Deadline == nil {
+		result.Deadline = deadlineFromText(result.Description)
+	}
+	return result, nil
+}
+
+func merge(dst, src *Posting) {
+	if dst.Title == "" {
+		dst.Title = clean(src.Title)
+	}
+	if dst.Company == "" {
+		dst.Company = clean(src.Company)
+	}
+	if dst.Location == nil && src.Location != nil && clean(*src.Location) != "" {
+		loc := clean(*src.Location)
+		dst.Location = &loc
+	}
+	if dst.Description == "" {
+		dst.Description = strings.TrimSpace(src.Description)
+	}
+	if dst.Deadline == nil {
+		dst.Deadline = src.Deadline
+	}
+}
+
+func complete(p *Posting) bool {
+	return p.Title != "" && p.Company != "" && p.Location != nil && p.Description != ""
+}
+
+var knownSources = map[string]string{
+	"linkedin.com":        "LinkedIn",
+	"indeed.com":          "Indeed",
+	"glassdoor.com":       "Glassdoor",
+	"greenhouse.io":       "Greenhouse",
+	"lever.co":            "Lever",
+	"myworkdayjobs.com":   "Workday",
+	"ashbyhq.com":         "Ashby",
+	"workatastartup.com":  "Y Combinator",
+	"wellfound.com":       "Wellfound",
+	"smartrecruiters.com": "SmartRecruiters",
+}
+
+// sourceName maps a posting host to the application source label the agents
+// service uses, falling back to the bare host.
+func sourceName(u *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for domain, name := range knownSources {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return name
+		}
+	}
+	return host
+}
+
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
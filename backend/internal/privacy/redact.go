@@ -0,0 +1,62 @@
+package privacy
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetLimit is the longest redacted snippet kept, in characters.
+const snippetLimit = 200
+
+var (
+	// Keep the host of links so "applied via greenhouse.io" stays readable,
+	// but drop paths and query strings, which often carry tokens.
+	linkPattern  = regexp.MustCompile(`(?i)\bhttps?://([a-z0-9.-]+)[^\s<>"]*`)
+	emailPattern = regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`)
+	// Phone candidates are only masked with 10 to 15 digits, so dates and
+	// times survive.
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().-]{7,}\d`)
+	// Long digit runs: reference, account and card numbers.
+	numberPattern = regexp.MustCompile(`\b\d{6,}\b`)
+)
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// Redact masks contact details, links and long numbers in an email snippet
+// and truncates it, leaving enough text to recognise the message.
+func Redact(snippet string) string {
+	text := whitespace.ReplaceAllString(strings.TrimSpace(snippet), " ")
+	// Links and addresses go first so their digits are not mistaken for
+	// phone or account numbers.
+	text = linkPattern.ReplaceAllString(text, "[link:$1]")
+	text = emailPattern.ReplaceAllString(text, "[email]")
+	text = phonePattern.ReplaceAllStringFunc(text, func(m string) string {
+		digits := 0
+		for _, r := range m {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits < 10 || digits > 15 {
+			return m
+		}
+		return "[phone]"
+	})
+	text = numberPattern.ReplaceAllString(text, "[number]")
+	if utf8.RuneCountInString(text) > snippetLimit {
+		runes := []rune(text)
+		text = strings.TrimSpace(string(runes[:snippetLimit])) + "…"
+	}
+	return text
+}
+
+// ForStorage returns the snippet and body to persist for an email under
+// the storage mode: unchanged when storing full emails, or a redacted
+// snippet and no body in minimal mode.
+func ForStorage(mode, snippet, body string) (string, *string) {
+	if mode == StorageMinimal {
+		return Redact(snippet), nil
+	}
+	return snippet, &body
+}
@@ -0,0 +1,95 @@
+// Package privacy implements the minimal email storage mode, in which only
+// the fields extracted from an email and a redacted snippet are kept, never
+// the body.
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// Email storage modes, chosen per user in their profile.
+const (
+	StorageFull    = "full"
+	StorageMinimal = "minimal"
+)
+
+// minimizeBatch is how many stored emails are stripped per statement.
+const minimizeBatch = 500
+
+// Service strips stored emails of users who chose minimal storage.
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a privacy service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Mode returns the user's email storage mode.
+func (s *Service) Mode(ctx context.Context, userID string) (string, error) {
+	var mode string
+	err := s.db.QueryRowContext(ctx, `SELECT email_storage FROM users WHERE id = $1`, userID).Scan(&mode)
+	return mode, err
+}
+
+// Minimize deletes the bodies of emails stored before their users switched
+// to minimal storage and redacts their snippets. It is the migration job
+// for existing data; ForStorage minimizes new emails as they are stored.
+func (s *Service) Minimize(ctx context.Context) error {
+	var total int
+	for {
+		n, err := s.minimizeBatch(ctx)
+		if err != nil {
+			return err
+		}
+		total += n
+		if n < minimizeBatch {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("Minimized %d stored emails", total)
+	}
+	return nil
+}
+
+func (s *Service) minimizeBatch(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, COALESCE(e.snippet, '') FROM email_cache e
+		JOIN users u ON u.id = e.user_id
+		WHERE u.email_storage = $1 AND e.redacted_at IS NULL
+		LIMIT $2`,
+		StorageMinimal, minimizeBatch)
+	if err != nil {
+		return 0, err
+	}
+	var ids, snippets []string
+	for rows.Next() {
+		var id, snippet string
+		if err := rows.Scan(&id, &snippet); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		snippets = append(snippets, Redact(snippet))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE email_cache e SET body_text = NULL, snippet = NULLIF(r.snippet, ''), redacted_at = CURRENT_TIMESTAMP
+		FROM unnest($1::text[], $2::text[]) AS r(id, snippet)
+		WHERE e.id = r.id`,
+		pq.Array(ids), pq.Array(snippets))
+	return len(ids), err
+}
@@ -0,0 +1,142 @@
+// Package profile stores per-user preferences: the timezone used for
+// interview times, reminders and digests, the locale exports, digests and
+// notifications are written in, the currency compensation is compared in,
+// and whether full email bodies are stored.
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/currency"
+	"github.com/jobtracker/backend/internal/i18n"
+	"github.com/jobtracker/backend/internal/privacy"
+)
+
+// Defaults used when a user has not set a preference.
+const (
+	DefaultTimezone = "UTC"
+	DefaultLocale   = "en-US"
+	DefaultCurrency = "USD"
+	// DefaultEmailStorage keeps full email bodies.
+	DefaultEmailStorage = privacy.StorageFull
+)
+
+var (
+	// ErrInvalidTimezone is returned for names that are not IANA timezones.
+	ErrInvalidTimezone = apperr.New(apperr.Validation, "invalid timezone")
+	// ErrInvalidLocale is returned for locales that are not BCP 47 tags.
+	ErrInvalidLocale = apperr.New(apperr.Validation, "invalid locale")
+	// ErrInvalidEmailStorage is returned for unknown email storage modes.
+	ErrInvalidEmailStorage = apperr.New(apperr.Validation, "email storage must be full or minimal")
+)
+
+// Profile holds a user's timezone, locale, preferred currency and email
+// storage mode.
+type Profile struct {
+	Timezone     string `json:"timezone"`
+	Locale       string `json:"locale"`
+	Currency     string `json:"currency"`
+	EmailStorage string `json:"emailStorage"`
+}
+
+// Location returns the profile's timezone, falling back to UTC if it can no
+// longer be loaded.
+func (p *Profile) Location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Localizer returns a localizer for the profile's locale.
+func (p *Profile) Localizer() *i18n.Localizer {
+	return i18n.For(p.Locale)
+}
+
+// ProfileInput updates a profile; nil fields are left unchanged.
+type ProfileInput struct {
+	Timezone     *string `json:"timezone"`
+	Locale       *string `json:"locale"`
+	Currency     *string `json:"currency"`
+	EmailStorage *string `json:"emailStorage"`
+}
+
+// Service reads and updates profiles.
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a profile service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Get returns the user's profile, or the defaults for unknown users.
+func (s *Service) Get(ctx context.Context, userID string) (*Profile, error) {
+	p := &Profile{}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT timezone, locale, currency, email_storage FROM users WHERE id = $1`, userID).Scan(&p.Timezone, &p.Locale, &p.Currency, &p.EmailStorage)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Profile{Timezone: DefaultTimezone, Locale: DefaultLocale, Currency: DefaultCurrency, EmailStorage: DefaultEmailStorage}, nil
+	}
+	return p, err
+}
+
+// Location returns the user's timezone.
+func (s *Service) Location(ctx context.Context, userID string) (*time.Location, error) {
+	p, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return p.Location(), nil
+}
+
+// Update validates and saves the user's preferences.
+func (s *Service) Update(ctx context.Context, userID string, in ProfileInput) (*Profile, error) {
+	if in.Timezone != nil {
+		tz := strings.TrimSpace(*in.Timezone)
+		if _, err := time.LoadLocation(tz); err != nil || tz == "" || tz == "Local" {
+			return nil, ErrInvalidTimezone
+		}
+		in.Timezone = &tz
+	}
+	if in.Locale != nil {
+		tag, err := language.Parse(strings.TrimSpace(*in.Locale))
+		if err != nil {
+			return nil, ErrInvalidLocale
+		}
+		locale := tag.String()
+		in.Locale = &locale
+	}
+	if in.Currency != nil {
+		code, err := currency.Normalize(*in.Currency)
+		if err != nil {
+			return nil, err
+		}
+		in.Currency = &code
+	}
+	if in.EmailStorage != nil {
+		mode := strings.ToLower(strings.TrimSpace(*in.EmailStorage))
+		if mode != privacy.StorageFull && mode != privacy.StorageMinimal {
+			return nil, ErrInvalidEmailStorage
+		}
+		in.EmailStorage = &mode
+	}
+
+	p := &Profile{}
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE users SET timezone = COALESCE($2, timezone), locale = COALESCE($3, locale),
+			currency = COALESCE($4, currency), email_storage = COALESCE($5, email_storage)
+		WHERE id = $1
+		RETURNING timezone, locale, currency, email_storage`,
+		userID, in.Timezone, in.Locale, in.Currency, in.EmailStorage).Scan(&p.Timezone, &p.Locale, &p.Currency, &p.EmailStorage)
+	return p, err
+}
@@ -0,0 +1,246 @@
+// Package querylog logs every GraphQL operation with its timings, and a
+// detailed trace of the ones slower than a threshold, to find the queries
+// that make the dashboard slow.
+package querylog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/database"
+)
+
+const (
+	// topResolvers is how many of the slowest resolvers an entry lists.
+	topResolvers = 5
+	// maxTraceCalls caps the resolver calls kept in a slow operation's trace.
+	maxTraceCalls = 200
+)
+
+// Logger is a gqlgen handler extension; the GraphQL server adds it with
+// Use. Variables are never logged, only hashed, since they carry user data.
+type Logger struct {
+	enabled bool
+	slow    time.Duration
+}
+
+// New creates an operation logger from GRAPHQL_LOG_OPERATIONS and
+// GRAPHQL_SLOW_OPERATION_MS.
+func New(cfg *config.Config) *Logger {
+	return &Logger{
+		enabled: cfg.GraphQLLogOperations,
+		slow:    time.Duration(cfg.GraphQLSlowOperationMs) * time.Millisecond,
+	}
+}
+
+var (
+	_ graphql.HandlerExtension    = (*Logger)(nil)
+	_ graphql.ResponseInterceptor = (*Logger)(nil)
+	_ graphql.FieldInterceptor    = (*Logger)(nil)
+)
+
+// ExtensionName implements graphql.HandlerExtension.
+func (l *Logger) ExtensionName() string {
+	return "OperationLog"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (l *Logger) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Entry is the record logged for an operation, as JSON.
+type Entry struct {
+	Operation string `json:"operation"` // name, or "" for anonymous operations
+	Type      string `json:"type"`      // query, mutation or subscription
+	UserID    string `json:"userId,omitempty"`
+	// QueryHash is the SHA-256 of the query text, as keyed in persisted
+	// query manifests; VariablesHash tells calls with the same variables
+	// apart without logging them.
+	QueryHash     string           `json:"queryHash"`
+	VariablesHash string           `json:"variablesHash,omitempty"`
+	DurationMs    float64          `json:"durationMs"`
+	ParseMs       float64          `json:"parseMs"`
+	ValidateMs    float64          `json:"validateMs"`
+	DBMs          float64          `json:"dbMs"`
+	DBQueries     int              `json:"dbQueries"`
+	Resolvers     int              `json:"resolvers"`
+	Errors        int              `json:"errors"`
+	Slow          bool             `json:"slow,omitempty"`
+	Slowest       []*ResolverStats `json:"slowest,omitempty"`
+	// Trace lists each resolver call of a slow operation in start order.
+	Trace          []*Call `json:"trace,omitempty"`
+	TraceTruncated int     `json:"traceTruncated,omitempty"`
+}
+
+// ResolverStats totals the calls of one resolver in an operation.
+type ResolverStats struct {
+	Field   string  `json:"field"` // Type.field
+	Calls   int     `json:"calls"`
+	TotalMs float64 `json:"totalMs"`
+	DBMs    float64 `json:"dbMs"`
+}
+
+// Call is one resolver call. Its DB time excludes that of the resolvers
+// of its fields, which have calls of their own.
+type Call struct {
+	Path       string  `json:"path"`
+	Field      string  `json:"field"`
+	StartMs    float64 `json:"startMs"` // since the operation started
+	DurationMs float64 `json:"durationMs"`
+	DBMs       float64 `json:"dbMs"`
+	DBQueries  int     `json:"dbQueries"`
+	Error      bool    `json:"error,omitempty"`
+}
+
+type traceKey struct{}
+
+// trace collects an operation's resolver calls, which run concurrently.
+type trace struct {
+	start time.Time
+	mu    sync.Mutex
+	calls []*Call
+}
+
+// InterceptResponse times the operation and the database calls made for
+// it, then logs the entry.
+func (l *Logger) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !l.enabled || !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	tr := &trace{start: time.Now()}
+	ctx, timer := database.WithTimer(ctx)
+	resp := next(context.WithValue(ctx, traceKey{}, tr))
+	l.log(ctx, tr, timer, resp)
+	return resp
+}
+
+// InterceptField times resolver calls; fields read straight off a struct
+// are not worth the bookkeeping.
+func (l *Logger) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	tr, ok := ctx.Value(traceKey{}).(*trace)
+	fc := graphql.GetFieldContext(ctx)
+	if !ok || fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	ctx, timer := database.WithTimer(ctx)
+	start := time.Now()
+	res, err := next(ctx)
+	call := &Call{
+		Path:       fc.Path().String(),
+		Field:      fc.Object + "." + fc.Field.Name,
+		StartMs:    ms(start.Sub(tr.start)),
+		DurationMs: ms(time.Since(start)),
+		DBMs:       ms(timer.Elapsed()),
+		DBQueries:  timer.Queries(),
+		Error:      err != nil,
+	}
+	tr.mu.Lock()
+	tr.calls = append(tr.calls, call)
+	tr.mu.Unlock()
+	return res, err
+}
+
+func (l *Logger) log(ctx context.Context, tr *trace, timer *database.Timer, resp *graphql.Response) {
+	rc := graphql.GetOperationContext(ctx)
+	elapsed := time.Since(tr.start)
+	e := &Entry{
+		Operation:  rc.OperationName,
+		QueryHash:  hash(rc.RawQuery),
+		DurationMs: ms(elapsed),
+		ParseMs:    ms(rc.Stats.Parsing.End.Sub(rc.Stats.Parsing.Start)),
+		ValidateMs: ms(rc.Stats.Validation.End.Sub(rc.Stats.Validation.Start)),
+		DBMs:       ms(timer.Elapsed()),
+		DBQueries:  timer.Queries(),
+	}
+	if rc.Operation != nil {
+		e.Type = string(rc.Operation.Operation)
+		if e.Operation == "" {
+			e.Operation = rc.Operation.Name
+		}
+	}
+	if userID, ok := auth.UserIDFromContext(ctx); ok {
+		e.UserID = userID
+	}
+	if len(rc.Variables) > 0 {
+		if raw, err := json.Marshal(rc.Variables); err == nil {
+			e.VariablesHash = hash(string(raw))[:16]
+		}
+	}
+	if resp != nil {
+		e.Errors = len(resp.Errors)
+	}
+
+	tr.mu.Lock()
+	calls := tr.calls
+	tr.mu.Unlock()
+	e.Resolvers = len(calls)
+	e.Slowest = slowest(calls, topResolvers)
+
+	if l.slow > 0 && elapsed >= l.slow {
+		e.Slow = true
+		sort.Slice(calls, func(i, j int) bool { return calls[i].StartMs < calls[j].StartMs })
+		if len(calls) > maxTraceCalls {
+			e.TraceTruncated = len(calls) - maxTraceCalls
+			calls = calls[:maxTraceCalls]
+		}
+		e.Trace = calls
+	}
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("Failed to encode GraphQL operation log entry: %v", err)
+		return
+	}
+	if e.Slow {
+		log.Printf("GraphQL slow operation: %s", raw)
+	} else {
+		log.Printf("GraphQL operation: %s", raw)
+	}
+}
+
+// slowest totals the calls by resolver and returns the n with the most
+// time spent.
+func slowest(calls []*Call, n int) []*ResolverStats {
+	byField := make(map[string]*ResolverStats)
+	for _, c := range calls {
+		s := byField[c.Field]
+		if s == nil {
+			s = &ResolverStats{Field: c.Field}
+			byField[c.Field] = s
+		}
+		s.Calls++
+		s.TotalMs += c.DurationMs
+		s.DBMs += c.DBMs
+	}
+	out := make([]*ResolverStats, 0, len(byField))
+	for _, s := range byField {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TotalMs != out[j].TotalMs {
+			return out[i].TotalMs > out[j].TotalMs
+		}
+		return out[i].Field < out[j].Field
+	})
+	return out[:min(n, len(out))]
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,66 @@
+package quotas
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jobtracker/backend/internal/config"
+)
+
+// DefaultPlan is the plan of users who have not been assigned one.
+const DefaultPlan = "default"
+
+// Limits caps a plan's usage of each metric. Zero means unlimited.
+type Limits struct {
+	StoredEmails    int64 `json:"storedEmails"`
+	AttachmentMB    int64 `json:"attachmentMB"`
+	LLMSpendCents   int64 `json:"llmSpendCents"` // per calendar month
+	ExportsPerMonth int64 `json:"exportsPerMonth"`
+}
+
+// limit returns the cap for a metric in the metric's own unit.
+func (l Limits) limit(metric string) int64 {
+	switch metric {
+	case MetricStoredEmails:
+		return l.StoredEmails
+	case MetricAttachmentBytes:
+		return l.AttachmentMB << 20
+	case MetricLLMSpend:
+		return l.LLMSpendCents
+	case MetricExports:
+		return l.ExportsPerMonth
+	}
+	return 0
+}
+
+// PlansFromConfig builds the plan table: the default plan from the
+// QUOTA_* settings, plus any named plans in the JSON file at
+// QUOTA_PLANS_PATH, which maps plan names to Limits and may also redefine
+// the default plan.
+func PlansFromConfig(cfg *config.Config) (map[string]Limits, error) {
+	plans := map[string]Limits{
+		DefaultPlan: {
+			StoredEmails:    int64(cfg.QuotaStoredEmails),
+			AttachmentMB:    int64(cfg.QuotaAttachmentMB),
+			LLMSpendCents:   int64(cfg.QuotaLLMSpendCents),
+			ExportsPerMonth: int64(cfg.QuotaExportsPerMonth),
+		},
+	}
+	if cfg.QuotaPlansPath == "" {
+		return plans, nil
+	}
+
+	data, err := os.ReadFile(cfg.QuotaPlansPath)
+	if err != nil {
+		return nil, err
+	}
+	var named map[string]Limits
+	if err := json.Unmarshal(data, &named); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", cfg.QuotaPlansPath, err)
+	}
+	for name, limits := range named {
+		plans[name] = limits
+	}
+	return plans, nil
+}
@@ -0,0 +1,233 @@
+// Package quotas meters per-user usage of storage, LLM spend and exports
+// against configurable plan limits, so multi-user instances can cap
+// individual accounts.
+package quotas
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/realtime"
+)
+
+// Metrics, in the unit their limits and usage are expressed in.
+const (
+	MetricStoredEmails    = "stored_emails"
+	MetricAttachmentBytes = "attachment_bytes"
+	MetricLLMSpend        = "llm_spend_cents"
+	MetricExports         = "exports"
+)
+
+var (
+	// ErrQuotaExceeded is returned when an operation would take a user over
+	// their plan's limit.
+	ErrQuotaExceeded = apperr.New(apperr.RateLimited, "quota exceeded")
+	// ErrUnknownPlan is returned when assigning a plan that is not configured.
+	ErrUnknownPlan = apperr.New(apperr.Validation, "unknown plan")
+)
+
+// metric describes how usage of a metric is measured. $1 is the user ID.
+type metric struct {
+	name    string
+	period  string // "total" or "month"
+	usedSQL string
+}
+
+var metrics = []metric{
+	{MetricStoredEmails, "total", `SELECT COUNT(*) FROM email_cache WHERE user_id = $1`},
+	{MetricAttachmentBytes, "total", `SELECT COALESCE(SUM(size_bytes), 0) FROM resumes WHERE user_id = $1`},
+	{MetricLLMSpend, "month", `
+		SELECT COALESCE(SUM(cost_cents), 0) FROM llm_usage
+		WHERE user_id = $1 AND created_at >= date_trunc('month', CURRENT_TIMESTAMP)`},
+	{MetricExports, "month", `
+		SELECT COUNT(*) FROM processing_jobs
+		WHERE user_id = $1 AND created_at >= date_trunc('month', CURRENT_TIMESTAMP)`},
+}
+
+func lookup(name string) (*metric, bool) {
+	for i := range metrics {
+		if metrics[i].name == name {
+			return &metrics[i], true
+		}
+	}
+	return nil, false
+}
+
+// Service measures usage and enforces plan limits.
+type Service struct {
+	cfg           *config.Config
+	db            *sql.DB
+	plans         map[string]Limits
+	notifications *notifications.Service
+	bus           *realtime.Service
+
+	warned sync.Map // user:metric:period to the warning level last seen
+}
+
+// NewService creates a quota service with the given plan table, usually
+// from PlansFromConfig. Users nearing a limit are warned through the
+// notification service and their WebSocket connections; with neither, as
+// in the CLI, no warnings are sent.
+func NewService(cfg *config.Config, db *sql.DB, plans map[string]Limits, notificationService *notifications.Service, bus *realtime.Service) *Service {
+	return &Service{cfg: cfg, db: db, plans: plans, notifications: notificationService, bus: bus}
+}
+
+// Quota is a user's usage of one metric.
+type Quota struct {
+	Metric   string  `json:"metric"`
+	Period   string  `json:"period"`
+	Used     float64 `json:"used"`
+	Limit    *int64  `json:"limit"` // nil when unlimited
+	Exceeded bool    `json:"exceeded"`
+	// Percent of the limit used, and the highest of WarningThresholds it
+	// has reached; nil when unlimited or below every threshold.
+	Percent           *float64 `json:"percent"`
+	Warning           *int     `json:"warning"`
+	WarningThresholds []int    `json:"warningThresholds"`
+}
+
+// Usage is a user's plan and their usage of every metric.
+type Usage struct {
+	Plan   string   `json:"plan"`
+	Quotas []*Quota `json:"quotas"`
+}
+
+// plan returns the user's plan name and limits. Users on a plan that is
+// no longer configured fall back to the default plan.
+func (s *Service) plan(ctx context.Context, userID string) (string, Limits, error) {
+	var name string
+	err := s.db.QueryRowContext(ctx, `SELECT plan FROM users WHERE id = $1`, userID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		name = DefaultPlan
+	} else if err != nil {
+		return "", Limits{}, err
+	}
+	limits, ok := s.plans[name]
+	if !ok {
+		log.Printf("User %s is on unconfigured plan %q, applying the default plan", userID, name)
+		limits = s.plans[DefaultPlan]
+	}
+	return name, limits, nil
+}
+
+func (s *Service) used(ctx context.Context, userID string, m *metric) (float64, error) {
+	var used float64
+	err := s.db.QueryRowContext(ctx, m.usedSQL, userID).Scan(&used)
+	return used, err
+}
+
+// Usage reports the user's plan and usage.
+func (s *Service) Usage(ctx context.Context, userID string) (*Usage, error) {
+	name, limits, err := s.plan(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{Plan: name}
+	for i := range metrics {
+		m := &metrics[i]
+		used, err := s.used(ctx, userID, m)
+		if err != nil {
+			return nil, fmt.Errorf("measure %s: %w", m.name, err)
+		}
+		q := &Quota{Metric: m.name, Period: m.period, Used: used, WarningThresholds: WarningThresholds}
+		if l := limits.limit(m.name); l > 0 {
+			q.Limit = &l
+			q.Exceeded = used >= float64(l)
+			pct := math.Round(percent(used, l)*10) / 10
+			q.Percent = &pct
+			if level := reached(percent(used, l)); level > 0 {
+				q.Warning = &level
+			}
+		}
+		usage.Quotas = append(usage.Quotas, q)
+	}
+	return usage, nil
+}
+
+// Check returns ErrQuotaExceeded if adding amount to the user's usage of
+// the metric would go over their plan's limit, or with an amount of zero,
+// if the limit has already been reached. Call it before storing emails or
+// files, calling the LLM or starting an export. Usage past a warning
+// threshold is allowed, and warned about.
+func (s *Service) Check(ctx context.Context, userID, metricName string, amount int64) error {
+	m, ok := lookup(metricName)
+	if !ok {
+		return fmt.Errorf("unknown quota metric %q", metricName)
+	}
+	_, limits, err := s.plan(ctx, userID)
+	if err != nil {
+		return err
+	}
+	limit := limits.limit(m.name)
+	if limit <= 0 {
+		return nil
+	}
+
+	used, err := s.used(ctx, userID, m)
+	if err != nil {
+		return err
+	}
+	s.warn(ctx, userID, m, used, limit)
+	if used+float64(amount) > float64(limit) || (amount == 0 && used >= float64(limit)) {
+		return fmt.Errorf("%w: %s limit of %d per %s reached", ErrQuotaExceeded, m.name, limit, m.period)
+	}
+	return nil
+}
+
+// RecordLLM records one LLM request, priced from its estimated token
+// counts with LLM_INPUT_CENTS_PER_MTOK and LLM_OUTPUT_CENTS_PER_MTOK, and
+// warns the user if it took their spend past a warning threshold.
+func (s *Service) RecordLLM(ctx context.Context, userID, operation string, inputTokens, outputTokens int) error {
+	cost := s.Cost(inputTokens, outputTokens)
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO llm_usage (user_id, operation, input_tokens, output_tokens, cost_cents)
+		VALUES ($1, $2, $3, $4, $5)`,
+		userID, operation, inputTokens, outputTokens, math.Round(cost*1e4)/1e4)
+	if err != nil {
+		return err
+	}
+	s.warnLLM(ctx, userID)
+	return nil
+}
+
+// Cost prices an LLM request in cents as RecordLLM does, so work can be
+// estimated before it is done.
+func (s *Service) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*float64(s.cfg.LLMInputCentsPerMTok) +
+		float64(outputTokens)*float64(s.cfg.LLMOutputCentsPerMTok)) / 1e6
+}
+
+// EstimateTokens approximates the token count of text, at roughly four
+// characters per token.
+func EstimateTokens(text ...string) int {
+	n := 0
+	for _, t := range text {
+		n += len(t)
+	}
+	return (n + 3) / 4
+}
+
+// SetPlan assigns a configured plan to a user.
+func (s *Service) SetPlan(ctx context.Context, userID, plan string) error {
+	plan = strings.TrimSpace(plan)
+	if _, ok := s.plans[plan]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownPlan, plan)
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET plan = $2 WHERE id = $1`, userID, plan)
+	return err
+}
+
+// Plans returns the configured plans.
+func (s *Service) Plans() map[string]Limits {
+	return s.plans
+}
@@ -0,0 +1,140 @@
+package quotas
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/realtime"
+)
+
+// WarningThresholds are the percentages of a limit at which users are
+// warned, once each per period, before Check starts refusing at 100%.
+var WarningThresholds = []int{80, 95}
+
+// Warning is the payload of a quota_warning event.
+type Warning struct {
+	Metric    string  `json:"metric"`
+	Period    string  `json:"period"`
+	Threshold int     `json:"threshold"`
+	Used      float64 `json:"used"`
+	Limit     int64   `json:"limit"`
+}
+
+// percent is used as a percentage of limit.
+func percent(used float64, limit int64) float64 {
+	return used * 100 / float64(limit)
+}
+
+// reached returns the highest warning threshold at or below pct, or 0.
+func reached(pct float64) int {
+	level := 0
+	for _, t := range WarningThresholds {
+		if pct >= float64(t) {
+			level = t
+		}
+	}
+	return level
+}
+
+// periodKey names the period usage is counted over: the calendar month,
+// or "total".
+func periodKey(m *metric) string {
+	if m.period == "month" {
+		return time.Now().UTC().Format("2006-01")
+	}
+	return "total"
+}
+
+// warn notifies the user, and their open connections, the first time in a
+// period their usage of a metric reaches a warning threshold. Thresholds
+// usage has fallen back below are re-armed, so freeing up storage and
+// filling it again warns again. Each replica remembers the level it last
+// saw, so usage that has not crossed a threshold costs no queries.
+func (s *Service) warn(ctx context.Context, userID string, m *metric, used float64, limit int64) {
+	if s.notifications == nil && s.bus == nil {
+		return
+	}
+	period := periodKey(m)
+	level := reached(percent(used, limit))
+	key := userID + ":" + m.name + ":" + period
+	if last, ok := s.warned.Load(key); ok && last.(int) == level {
+		return
+	}
+	if err := s.raiseWarning(ctx, userID, m, period, level, used, limit); err != nil {
+		log.Printf("Failed to warn user %s about their %s quota: %v", userID, m.name, err)
+		return
+	}
+	s.warned.Store(key, level)
+}
+
+func (s *Service) raiseWarning(ctx context.Context, userID string, m *metric, period string, level int, used float64, limit int64) error {
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM quota_warnings WHERE user_id = $1 AND metric = $2 AND period = $3 AND threshold > $4`,
+		userID, m.name, period, level); err != nil {
+		return err
+	}
+	if level == 0 {
+		return nil
+	}
+	// Every threshold reached is recorded, but only the highest newly
+	// reached one is announced.
+	announce := 0
+	for _, t := range WarningThresholds {
+		if t > level {
+			break
+		}
+		res, err := s.db.ExecContext(ctx, `
+			INSERT INTO quota_warnings (user_id, metric, threshold, period) VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`,
+			userID, m.name, t, period)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			announce = t
+		}
+	}
+	if announce == 0 {
+		return nil
+	}
+
+	if s.notifications != nil {
+		l := s.notifications.Localizer(ctx, userID)
+		title := l.T("quota.warning.title", announce, l.T("quota.metric."+m.name))
+		if err := s.notifications.Notify(ctx, userID, notifications.KindQuotaWarning, title,
+			l.T("quota.warning.body."+m.name)); err != nil {
+			return fmt.Errorf("notify: %w", err)
+		}
+	}
+	if s.bus != nil {
+		w := &Warning{Metric: m.name, Period: m.period, Threshold: announce, Used: used, Limit: limit}
+		if err := s.bus.Publish(ctx, userID, realtime.Event{Type: realtime.EventQuotaWarning, Payload: w}); err != nil {
+			log.Printf("Failed to publish quota warning for user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// warnLLM checks the user's LLM spend against the warning thresholds after
+// a request was recorded.
+func (s *Service) warnLLM(ctx context.Context, userID string) {
+	m, _ := lookup(MetricLLMSpend)
+	_, limits, err := s.plan(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to check LLM spend warnings for user %s: %v", userID, err)
+		return
+	}
+	limit := limits.limit(m.name)
+	if limit <= 0 {
+		return
+	}
+	used, err := s.used(ctx, userID, m)
+	if err != nil {
+		log.Printf("Failed to check LLM spend warnings for user %s: %v", userID, err)
+		return
+	}
+	s.warn(ctx, userID, m, used, limit)
+}
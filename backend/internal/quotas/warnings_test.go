@@ -0,0 +1,37 @@
+package quotas
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReached(t *testing.T) {
+	tests := []struct {
+		used  float64
+		limit int64
+		want  int
+	}{
+		{0, 100, 0},
+		{79.9, 100, 0},
+		{80, 100, 80},
+		{94, 100, 80},
+		{19, 20, 95},
+		{100, 100, 95},
+		{250, 100, 95},
+	}
+	for _, tt := range tests {
+		if got := reached(percent(tt.used, tt.limit)); got != tt.want {
+			t.Errorf("reached(%v of %d) = %d, want %d", tt.used, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestPeriodKey(t *testing.T) {
+	if got := periodKey(&metric{period: "total"}); got != "total" {
+		t.Errorf("periodKey(total) = %q", got)
+	}
+	m, _ := lookup(MetricExports)
+	if got, want := periodKey(m), time.Now().UTC().Format("2006-01"); got != want {
+		t.Errorf("periodKey(%s) = %q, want %q", m.name, got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+)
+
+// refreshInterval is how long custom limits are cached by each replica.
+// Changes made on this replica apply immediately.
+const refreshInterval = 30 * time.Second
+
+// counterPrefix keys the per-minute request counters in Redis.
+const counterPrefix = "ratelimit:"
+
+// subject is what a custom limit applies to. keyID and route are empty for
+// limits on every API key and route.
+type subject struct {
+	userID, keyID, route string
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// customLimits returns the unexpired custom limits, reloading them when the
+// cache is stale. If they cannot be loaded the previous ones stay in use.
+func (s *Service) customLimits(ctx context.Context) map[subject]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) < refreshInterval {
+		return s.limits
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, COALESCE(api_key_id::text, ''), route, requests_per_minute FROM rate_limits
+		WHERE expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP`)
+	if err != nil {
+		log.Printf("Failed to load rate limits: %v", err)
+		return s.limits
+	}
+	defer rows.Close()
+	limits := make(map[subject]int)
+	for rows.Next() {
+		var sub subject
+		var perMinute int
+		if err := rows.Scan(&sub.userID, &sub.keyID, &sub.route, &perMinute); err != nil {
+			log.Printf("Failed to load rate limits: %v", err)
+			return s.limits
+		}
+		limits[sub] = perMinute
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to load rate limits: %v", err)
+		return s.limits
+	}
+	s.limits, s.loadedAt = limits, time.Now()
+	return limits
+}
+
+// limitFor returns the requests per minute allowed to a user, or one of
+// their API keys, on a route: the most specific custom limit, or the
+// default. Zero is unlimited.
+func (s *Service) limitFor(ctx context.Context, userID, keyID, route string) int {
+	limits := s.customLimits(ctx)
+	candidates := []subject{{userID, keyID, route}, {userID, keyID, ""}, {userID, "", route}, {userID, "", ""}}
+	for _, c := range candidates {
+		if l, ok := limits[c]; ok {
+			return l
+		}
+	}
+	return s.defaultLimit
+}
+
+// Allow counts a request and reports whether it is within the limit, with
+// the limit and the requests left this minute. Requests are allowed when
+// Redis is unavailable.
+func (s *Service) Allow(ctx context.Context, userID, keyID, route string) (allowed bool, limit, remaining int) {
+	limit = s.limitFor(ctx, userID, keyID, route)
+	if limit <= 0 {
+		return true, 0, 0
+	}
+
+	// Requests with an API key are counted apart from the user's others,
+	// so a key with a looser limit does not use up the user's budget.
+	minute := time.Now().Unix() / 60
+	key := counterPrefix + userID + ":" + keyID + ":" + route + ":" + strconv.FormatInt(minute, 10)
+	pipe := s.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 2*time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Rate limiter unavailable, allowing request: %v", err)
+		return true, limit, limit
+	}
+	count := int(incr.Val())
+	if count > limit {
+		return false, limit, 0
+	}
+	return true, limit, limit - count
+}
+
+// Middleware limits the requests of the authenticated user or API key on a
+// route, e.g. "extension" or "graphql", and sets X-RateLimit-* headers. It
+// must run after authentication; anonymous requests are limited per client
+// IP as resolved through server.TrustProxies.
+func (s *Service) Middleware(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, keyID := auth.UserID(c), auth.APIKeyID(c)
+		if userID == "" {
+			userID = "ip:" + c.ClientIP()
+		}
+
+		allowed, limit, remaining := s.Allow(c.Request.Context(), userID, keyID, route)
+		if limit > 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		}
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(60-time.Now().Second()))
+			apperr.Respond(c, "rate limit", ErrRateLimited)
+			return
+		}
+		c.Next()
+	}
+}
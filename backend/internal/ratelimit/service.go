@@ -0,0 +1,199 @@
+// Package ratelimit limits how many requests a user or API key may make per
+// minute. Everyone gets RATE_LIMIT_REQUESTS_PER_MINUTE unless an
+// administrator has set a custom limit, e.g. to loosen the limit of the
+// trusted browser extension or throttle an abusive script. Limits are stored
+// in Postgres and requests are counted in Redis, so every replica enforces
+// the same budget.
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+var (
+	// ErrRateLimited is returned when a request goes over its limit.
+	ErrRateLimited = apperr.New(apperr.RateLimited, "too many requests, try again in a minute")
+	// ErrLimitNotFound is returned when a custom limit does not exist.
+	ErrLimitNotFound = apperr.New(apperr.NotFound, "rate limit not found")
+	// ErrSubjectNotFound is returned when a limit names an unknown user or
+	// a revoked or unknown API key.
+	ErrSubjectNotFound = apperr.New(apperr.NotFound, "user or API key not found")
+)
+
+// Subject types of a custom limit.
+const (
+	SubjectUser   = "user"
+	SubjectAPIKey = "api_key"
+)
+
+// Limit is a custom request limit for a user, or for one of their API keys.
+// Limits on an API key take precedence over limits on its user, and limits
+// on a route over limits on every route.
+type Limit struct {
+	ID                string     `json:"id"`
+	SubjectType       string     `json:"subjectType"`
+	UserID            string     `json:"userId"`
+	APIKeyID          *string    `json:"apiKeyId"`
+	Route             *string    `json:"route"`             // nil for every route
+	RequestsPerMinute int        `json:"requestsPerMinute"` // 0 is unlimited
+	Note              *string    `json:"note"`
+	ExpiresAt         *time.Time `json:"expiresAt"`
+	CreatedBy         *string    `json:"createdBy"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// LimitInput sets the custom limit of a user (by ID or email) or an API key
+// (by ID) on one route, or on every route when Route is empty.
+type LimitInput struct {
+	SubjectType       string     `json:"subjectType" validate:"required,oneof=user api_key"`
+	Subject           string     `json:"subject" validate:"required,max=255"`
+	Route             *string    `json:"route" validate:"omitempty,max=50"`
+	RequestsPerMinute int        `json:"requestsPerMinute" validate:"min=0,max=100000"`
+	Note              *string    `json:"note" validate:"omitempty,max=1000"`
+	ExpiresAt         *time.Time `json:"expiresAt"`
+}
+
+// Service manages custom limits and enforces them.
+type Service struct {
+	db           *sql.DB
+	rdb          *redis.Client
+	defaultLimit int
+
+	mu       sync.Mutex
+	limits   map[subject]int
+	loadedAt time.Time
+}
+
+// NewService creates a rate limiter allowing RATE_LIMIT_REQUESTS_PER_MINUTE
+// to subjects without a custom limit.
+func NewService(cfg *config.Config, db *sql.DB, rdb *redis.Client) *Service {
+	return &Service{db: db, rdb: rdb, defaultLimit: cfg.RateLimitRequestsPerMinute}
+}
+
+const limitColumns = `id, user_id, api_key_id, NULLIF(route, ''), requests_per_minute, note, expires_at,
+	created_by, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLimit(row scanner) (*Limit, error) {
+	l := &Limit{}
+	err := row.Scan(&l.ID, &l.UserID, &l.APIKeyID, &l.Route, &l.RequestsPerMinute, &l.Note, &l.ExpiresAt,
+		&l.CreatedBy, &l.CreatedAt, &l.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLimitNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.SubjectType = SubjectUser
+	if l.APIKeyID != nil {
+		l.SubjectType = SubjectAPIKey
+	}
+	return l, nil
+}
+
+// Limits lists the custom limits for an administrator, expired ones last.
+func (s *Service) Limits(ctx context.Context, adminID string) ([]*Limit, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+limitColumns+` FROM rate_limits
+		ORDER BY expires_at IS NOT NULL AND expires_at <= CURRENT_TIMESTAMP, created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Limit
+	for rows.Next() {
+		l, err := scanLimit(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// SetLimit creates or replaces the custom limit of a user or API key on a
+// route. It applies on every replica within a minute.
+func (s *Service) SetLimit(ctx context.Context, adminID string, in LimitInput) (*Limit, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		return nil, validation.Field("expiresAt", "must be in the future")
+	}
+	route := ""
+	if in.Route != nil {
+		route = strings.ToLower(strings.TrimSpace(*in.Route))
+	}
+
+	var userID string
+	var keyID *string
+	subject := strings.TrimSpace(in.Subject)
+	var err error
+	if in.SubjectType == SubjectUser {
+		err = s.db.QueryRowContext(ctx,
+			`SELECT id FROM users WHERE id = $1 OR LOWER(email) = LOWER($1)`, subject).Scan(&userID)
+	} else {
+		keyID = &subject
+		err = s.db.QueryRowContext(ctx,
+			`SELECT user_id FROM api_keys WHERE id::text = $1 AND revoked_at IS NULL`, subject).Scan(&userID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSubjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := scanLimit(s.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limits (user_id, api_key_id, route, requests_per_minute, note, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		ON CONFLICT (user_id, (COALESCE(api_key_id::text, '')), route) DO UPDATE
+		SET requests_per_minute = EXCLUDED.requests_per_minute, note = EXCLUDED.note,
+			expires_at = EXCLUDED.expires_at, created_by = EXCLUDED.created_by, updated_at = CURRENT_TIMESTAMP
+		RETURNING `+limitColumns,
+		userID, keyID, route, in.RequestsPerMinute, in.Note, in.ExpiresAt, adminID))
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return l, nil
+}
+
+// DeleteLimit removes a custom limit, returning its subject to the default.
+func (s *Service) DeleteLimit(ctx context.Context, adminID, id string) error {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrLimitNotFound
+	}
+	s.invalidate()
+	return nil
+}
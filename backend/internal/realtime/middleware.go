@@ -0,0 +1,30 @@
+package realtime
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// ErrDraining is returned for new connections while the replica shuts down.
+var ErrDraining = apperr.New(apperr.Unavailable, "server is restarting")
+
+// DrainGuard refuses WebSocket upgrades once Drain has been called, with a
+// Retry-After hint, so that clients reconnect through the load balancer to
+// a replica that is staying up.
+func (s *Service) DrainGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.Draining() {
+			s.mu.Lock()
+			secs := math.Ceil(s.reconnectAfter.Seconds())
+			s.mu.Unlock()
+			c.Header("Retry-After", strconv.Itoa(max(int(secs), 1)))
+			apperr.Respond(c, "WebSocket", ErrDraining)
+			return
+		}
+		c.Next()
+	}
+}
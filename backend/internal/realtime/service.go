@@ -0,0 +1,311 @@
+// Package realtime fans events out to a user's open WebSocket connections.
+// Events go through Redis pub/sub, so one replica can publish an event
+// (from a scheduled job, say) that a connection held by another replica
+// delivers. Each event is also kept briefly in a per-user Redis stream, so a
+// client that reconnects (to another replica, during a deploy) can resume
+// from the last event it saw instead of missing what happened in between.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Event types.
+const (
+	EventApplicationResurfaced = "application_resurfaced"
+	EventEditLockAcquired      = "edit_lock_acquired"
+	EventEditLockReleased      = "edit_lock_released"
+	EventExportProgress        = "export_progress"
+	EventQuotaWarning          = "quota_warning"
+	// EventServerRestarting is sent, with a reconnectAfterMs payload, to
+	// connections on a replica that is shutting down just before they close.
+	EventServerRestarting = "server_restarting"
+)
+
+const (
+	// replayTTL is how long after a user's last event it can be replayed.
+	replayTTL = 10 * time.Minute
+	// replayMaxLen caps the events kept per user for replay.
+	replayMaxLen = 500
+)
+
+// Event is a message pushed to the user's WebSocket connections.
+type Event struct {
+	// ID orders the user's events; clients pass the last one they saw to
+	// Resume when reconnecting. Events that are not replayable have none.
+	ID      string      `json:"id,omitempty"`
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload"`
+}
+
+// Service publishes and subscribes to per-user event channels.
+type Service struct {
+	rdb *redis.Client
+
+	mu             sync.Mutex
+	subs           map[*subscription]struct{}
+	draining       bool
+	reconnectAfter time.Duration
+}
+
+// subscription is a connection's subscription held by this replica.
+type subscription struct {
+	restart chan []byte // the server_restarting event, sent once on Drain
+	done    chan struct{}
+}
+
+// NewService creates a realtime event bus on Redis.
+func NewService(rdb *redis.Client) *Service {
+	return &Service{rdb: rdb, subs: make(map[*subscription]struct{})}
+}
+
+func channel(userID string) string {
+	return "realtime:user:" + userID
+}
+
+func stream(userID string) string {
+	return "realtime:replay:" + userID
+}
+
+// Publish sends an event to the user's connections. Users with no open
+// connection miss it unless they resume within the replay window.
+func (s *Service) Publish(ctx context.Context, userID string, ev Event) error {
+	ev.ID = ""
+	raw, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.rdb.TxPipeline()
+	add := pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream:       stream(userID),
+		MaxLenApprox: replayMaxLen,
+		Values:       map[string]interface{}{"event": raw},
+	})
+	pipe.Expire(ctx, stream(userID), replayTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	ev.ID = add.Val()
+	if raw, err = json.Marshal(ev); err != nil {
+		return err
+	}
+	return s.rdb.Publish(ctx, channel(userID), raw).Err()
+}
+
+// Subscribe returns the user's events, encoded as JSON, until ctx is done.
+// The WebSocket handler writes them to the connection as they arrive, and
+// closes the connection when the channel closes.
+func (s *Service) Subscribe(ctx context.Context, userID string) <-chan []byte {
+	return s.Resume(ctx, userID, "")
+}
+
+// Resume is Subscribe for a reconnecting client: it first replays the
+// user's events after lastEventID, then continues with live ones, without
+// gaps or duplicates in between. Events older than the replay window are
+// gone; an empty lastEventID replays nothing.
+func (s *Service) Resume(ctx context.Context, userID, lastEventID string) <-chan []byte {
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &subscription{restart: make(chan []byte, 1), done: make(chan struct{})}
+	if !s.track(sub) {
+		// Already draining: close at once, telling the client when to retry.
+		sub.restart <- s.restartEvent()
+	}
+
+	ps := s.rdb.Subscribe(ctx, channel(userID))
+	out := make(chan []byte)
+	go func() {
+		defer close(sub.done)
+		defer s.untrack(sub)
+		defer close(out)
+		defer cancel()
+		defer ps.Close()
+
+		send := func(raw []byte) bool {
+			select {
+			case out <- raw:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// Subscribe before reading the stream so nothing published in
+		// between is lost; anything seen both ways is dropped by ID below.
+		last := lastEventID
+		if last != "" {
+			if _, err := ps.Receive(ctx); err != nil {
+				log.Printf("Realtime subscription for user %s failed: %v", userID, err)
+				return
+			}
+			missed, err := s.replay(ctx, userID, last)
+			if err != nil {
+				log.Printf("Failed to replay realtime events for user %s: %v", userID, err)
+			}
+			for _, m := range missed {
+				if !send(m.raw) {
+					return
+				}
+				last = m.id
+			}
+		}
+
+		msgs := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case raw := <-sub.restart:
+				send(raw)
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					log.Printf("Realtime subscription for user %s closed", userID)
+					return
+				}
+				if last != "" {
+					var ev struct {
+						ID string `json:"id"`
+					}
+					if json.Unmarshal([]byte(msg.Payload), &ev) == nil && ev.ID != "" && !after(ev.ID, last) {
+						continue
+					}
+				}
+				if !send([]byte(msg.Payload)) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+type storedEvent struct {
+	id  string
+	raw []byte
+}
+
+// replay reads the user's stored events after lastID, re-encoded with
+// their IDs.
+func (s *Service) replay(ctx context.Context, userID, lastID string) ([]storedEvent, error) {
+	msgs, err := s.rdb.XRangeN(ctx, stream(userID), lastID, "+", replayMaxLen+1).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]storedEvent, 0, len(msgs))
+	for _, m := range msgs {
+		if m.ID == lastID {
+			continue
+		}
+		stored, _ := m.Values["event"].(string)
+		var ev struct {
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := json.Unmarshal([]byte(stored), &ev); err != nil {
+			continue
+		}
+		raw, err := json.Marshal(Event{ID: m.ID, Type: ev.Type, Payload: ev.Payload})
+		if err != nil {
+			return out, err
+		}
+		out = append(out, storedEvent{id: m.ID, raw: raw})
+	}
+	return out, nil
+}
+
+// after reports whether stream ID a ("<ms>-<seq>") comes after b.
+func after(a, b string) bool {
+	am, as := splitID(a)
+	bm, bs := splitID(b)
+	return am > bm || (am == bm && as > bs)
+}
+
+func splitID(id string) (uint64, uint64) {
+	ms, seq, _ := strings.Cut(id, "-")
+	m, _ := strconv.ParseUint(ms, 10, 64)
+	n, _ := strconv.ParseUint(seq, 10, 64)
+	return m, n
+}
+
+func (s *Service) track(sub *subscription) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.subs[sub] = struct{}{}
+	return true
+}
+
+func (s *Service) untrack(sub *subscription) {
+	s.mu.Lock()
+	delete(s.subs, sub)
+	s.mu.Unlock()
+}
+
+// Draining reports whether Drain has been called.
+func (s *Service) Draining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.draining
+}
+
+// restartEvent is the server_restarting event for one connection. The
+// reconnect delay is spread over [reconnectAfter, 2*reconnectAfter) so the
+// replica's clients don't all land on the remaining ones at once.
+func (s *Service) restartEvent() []byte {
+	s.mu.Lock()
+	d := s.reconnectAfter
+	s.mu.Unlock()
+	if d > 0 {
+		d += time.Duration(rand.Int63n(int64(d)))
+	}
+	raw, _ := json.Marshal(Event{
+		Type:    EventServerRestarting,
+		Payload: map[string]int64{"reconnectAfterMs": d.Milliseconds()},
+	})
+	return raw
+}
+
+// Drain prepares the replica to shut down: it stops new subscriptions, sends
+// every open one a server_restarting event telling the client to reconnect
+// after about reconnectAfter, and closes them, waiting until they are all
+// closed or ctx is done. Clients resume from their last event ID, so events
+// published while they are away are replayed once they are back.
+func (s *Service) Drain(ctx context.Context, reconnectAfter time.Duration) {
+	s.mu.Lock()
+	s.draining = true
+	s.reconnectAfter = reconnectAfter
+	subs := make([]*subscription, 0, len(s.subs))
+	for sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.restart <- s.restartEvent():
+		default:
+		}
+	}
+	for _, sub := range subs {
+		select {
+		case <-sub.done:
+		case <-ctx.Done():
+			log.Printf("Realtime drain timed out closing %d connections", len(subs))
+			return
+		}
+	}
+	log.Printf("Realtime drain closed %d connections", len(subs))
+}
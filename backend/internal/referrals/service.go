@@ -0,0 +1,260 @@
+// Package referrals tracks who referred the user for an application and
+// how far the referral has got: requested, confirmed by the referrer, then
+// submitted to the company. Once the application has an outcome the user is
+// reminded to thank the referrer.
+package referrals
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Referral states, in the order a referral moves through them.
+const (
+	StateRequested = "requested"
+	StateConfirmed = "confirmed"
+	StateSubmitted = "submitted"
+)
+
+// States lists every referral state in order.
+var States = []string{StateRequested, StateConfirmed, StateSubmitted}
+
+// outcomeStatuses are the application statuses worth thanking a referrer
+// for.
+var outcomeStatuses = []string{
+	models.StatusInterviewScheduled, models.StatusOffer, models.StatusAccepted, models.StatusRejected,
+}
+
+var (
+	// ErrNotFound is returned when the application has no referral.
+	ErrNotFound = apperr.New(apperr.NotFound, "referral not found")
+	// ErrInvalidState is returned for states other than those in States.
+	ErrInvalidState = apperr.New(apperr.Validation, "state must be requested, confirmed or submitted")
+	// ErrTransition is returned when a referral is moved anywhere but its
+	// next state.
+	ErrTransition = apperr.New(apperr.Conflict, "referral can only move to its next state")
+)
+
+// Referral is the referral behind an application.
+type Referral struct {
+	ApplicationID   string     `json:"applicationId"`
+	ReferrerName    string     `json:"referrerName"`
+	ReferrerContact *string    `json:"referrerContact"` // email, phone or profile link
+	State           string     `json:"state"`
+	AskedDate       *string    `json:"askedDate"`
+	ConfirmedDate   *string    `json:"confirmedDate"`
+	Notes           *string    `json:"notes"`
+	ThankedAt       *time.Time `json:"thankedAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// Input records or updates who referred the user. It does not change the
+// referral's state.
+type Input struct {
+	ReferrerName    string  `json:"referrerName" validate:"required,max=255"`
+	ReferrerContact *string `json:"referrerContact" validate:"omitempty,max=255"`
+	AskedDate       *string `json:"askedDate" validate:"omitempty,datetime=2006-01-02"`
+	ConfirmedDate   *string `json:"confirmedDate" validate:"omitempty,datetime=2006-01-02"`
+	Notes           *string `json:"notes" validate:"omitempty,max=10000"`
+}
+
+// Service manages referrals and their thank-you reminders.
+type Service struct {
+	db            *sql.DB
+	notifications *notifications.Service
+}
+
+// NewService creates a referral service.
+func NewService(db *sql.DB, notificationService *notifications.Service) *Service {
+	return &Service{db: db, notifications: notificationService}
+}
+
+const columns = `r.application_id, r.referrer_name, r.referrer_contact, r.state, r.asked_date::text,
+	r.confirmed_date::text, r.notes, r.thanked_at, r.updated_at`
+
+func scan(row *sql.Row) (*Referral, error) {
+	r := &Referral{}
+	err := row.Scan(&r.ApplicationID, &r.ReferrerName, &r.ReferrerContact, &r.State, &r.AskedDate,
+		&r.ConfirmedDate, &r.Notes, &r.ThankedAt, &r.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// Get returns the referral for one of the user's applications, or nil.
+func (s *Service) Get(ctx context.Context, userID, applicationID string) (*Referral, error) {
+	r, err := scan(s.db.QueryRowContext(ctx, `
+		SELECT `+columns+`
+		FROM application_referrals r JOIN applications a ON a.id = r.application_id
+		WHERE r.application_id = $1 AND a.user_id = $2`,
+		applicationID, userID))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// Set records who referred the user for an application. A new referral
+// starts out requested, asked today unless another date is given.
+func (s *Service) Set(ctx context.Context, userID, applicationID string, in Input) (*Referral, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	r, err := scan(s.db.QueryRowContext(ctx, `
+		INSERT INTO application_referrals AS r (application_id, referrer_name, referrer_contact, asked_date, confirmed_date, notes)
+		SELECT a.id, $3, $4, COALESCE($5::date, CURRENT_DATE), $6::date, $7 FROM applications a WHERE a.id = $1 AND a.user_id = $2
+		ON CONFLICT (application_id) DO UPDATE SET
+			referrer_name = EXCLUDED.referrer_name, referrer_contact = EXCLUDED.referrer_contact,
+			asked_date = COALESCE($5::date, r.asked_date), confirmed_date = COALESCE($6::date, r.confirmed_date),
+			notes = EXCLUDED.notes, updated_at = CURRENT_TIMESTAMP
+		RETURNING `+columns,
+		applicationID, userID, in.ReferrerName, in.ReferrerContact, in.AskedDate, in.ConfirmedDate, in.Notes))
+	if errors.Is(err, ErrNotFound) {
+		return nil, applications.ErrNotFound
+	}
+	return r, err
+}
+
+// Advance moves a referral to its next state. Confirming it records today
+// as the confirmed date unless one was already given.
+func (s *Service) Advance(ctx context.Context, userID, applicationID, state string) (*Referral, error) {
+	prev := ""
+	for i, st := range States {
+		if st == state && i > 0 {
+			prev = States[i-1]
+		}
+	}
+	if prev == "" {
+		if state == StateRequested {
+			return nil, ErrTransition
+		}
+		return nil, ErrInvalidState
+	}
+	r, err := scan(s.db.QueryRowContext(ctx, `
+		UPDATE application_referrals r SET state = $3,
+			confirmed_date = CASE WHEN $3 = $5 THEN COALESCE(r.confirmed_date, CURRENT_DATE) ELSE r.confirmed_date END,
+			updated_at = CURRENT_TIMESTAMP
+		FROM applications a
+		WHERE a.id = r.application_id AND r.application_id = $1 AND a.user_id = $2 AND r.state = $4
+		RETURNING `+columns,
+		applicationID, userID, state, prev, StateConfirmed))
+	if !errors.Is(err, ErrNotFound) {
+		return r, err
+	}
+	// Tell a referral in another state apart from a missing one.
+	current, err := s.Get(ctx, userID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrNotFound
+	}
+	return nil, ErrTransition
+}
+
+// Thanked records that the user has thanked the referrer, which silences
+// the reminder for the application's current outcome.
+func (s *Service) Thanked(ctx context.Context, userID, applicationID string) (*Referral, error) {
+	return scan(s.db.QueryRowContext(ctx, `
+		UPDATE application_referrals r SET thanked_at = CURRENT_TIMESTAMP, thank_reminded_status = a.status,
+			updated_at = CURRENT_TIMESTAMP
+		FROM applications a
+		WHERE a.id = r.application_id AND r.application_id = $1 AND a.user_id = $2
+		RETURNING `+columns,
+		applicationID, userID))
+}
+
+// Delete removes the referral from an application. It reports false if
+// there was none.
+func (s *Service) Delete(ctx context.Context, userID, applicationID string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM application_referrals r USING applications a
+		WHERE a.id = r.application_id AND r.application_id = $1 AND a.user_id = $2`,
+		applicationID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+type thanks struct {
+	applicationID, userID, company, position, status, referrer string
+}
+
+// RemindThanks reminds users to thank their referrer whenever a referred
+// application reaches a new outcome: an interview, an offer, or a
+// rejection. Each outcome is reminded about once, and snoozed applications
+// wait until they resurface. It is intended to run from the scheduler.
+func (s *Service) RemindThanks(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.user_id, a.company, a.position, a.status, r.referrer_name
+		FROM application_referrals r JOIN applications a ON a.id = r.application_id
+		WHERE a.status = ANY($1) AND a.snoozed_until IS NULL
+			AND r.thank_reminded_status IS DISTINCT FROM a.status`,
+		pq.Array(outcomeStatuses))
+	if err != nil {
+		return err
+	}
+	var pending []thanks
+	for rows.Next() {
+		var t thanks
+		if err := rows.Scan(&t.applicationID, &t.userID, &t.company, &t.position, &t.status, &t.referrer); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range pending {
+		if err := s.remind(ctx, t); err != nil {
+			log.Printf("Failed to send referral thanks reminder for application %s: %v", t.applicationID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) remind(ctx context.Context, t thanks) error {
+	// Claim the reminder first so concurrent or retried runs cannot send it
+	// twice.
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE application_referrals SET thank_reminded_status = $2
+		WHERE application_id = $1 AND thank_reminded_status IS DISTINCT FROM $2`,
+		t.applicationID, t.status)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	news := "referral.news.decided"
+	switch t.status {
+	case models.StatusInterviewScheduled:
+		news = "referral.news.interview"
+	case models.StatusOffer:
+		news = "referral.news.offer"
+	case models.StatusAccepted:
+		news = "referral.news.accepted"
+	}
+	l := s.notifications.Localizer(ctx, t.userID)
+	title := l.T("referral.title", t.referrer, t.company)
+	body := l.T("referral.body", t.referrer, t.position, t.company, l.T(news))
+	return s.notifications.Notify(ctx, t.userID, notifications.KindReferralThanks, title, body)
+}
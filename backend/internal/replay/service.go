@@ -0,0 +1,384 @@
+// Package replay runs a stored email through the classification pipeline
+// again for administrators: parsing, the deterministic rules, the agents
+// service and the writes that would follow, in dry-run. Every stage reports
+// what it saw and produced, so a misclassification report can be reproduced
+// exactly and compared with what was stored, without changing the user's
+// data. LLM calls are metered against the administrator's quota.
+package replay
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/actions"
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/ats"
+	"github.com/jobtracker/backend/internal/automation"
+	"github.com/jobtracker/backend/internal/mailbox"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/triage"
+)
+
+// Stages, in the order they run.
+const (
+	StageParse    = "parse"
+	StageRules    = "rules"
+	StageClassify = "classify"
+	StageExtract  = "extract"
+	StagePersist  = "persist"
+)
+
+var (
+	// ErrEmailNotFound is returned when no cached email has the ID.
+	ErrEmailNotFound = apperr.New(apperr.NotFound, "email not found")
+	// ErrRedacted is returned for emails whose content was redacted, which
+	// cannot be replayed faithfully.
+	ErrRedacted = apperr.New(apperr.Conflict, "the email's content was redacted")
+)
+
+// Detail is one intermediate output of a stage.
+type Detail struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Step is one stage of a replay. A stage that failed has Error set, and
+// the stages after it did not run.
+type Step struct {
+	Stage      string    `json:"stage"`
+	DurationMs int       `json:"durationMs"`
+	Error      *string   `json:"error"`
+	Details    []*Detail `json:"details"`
+}
+
+func (st *Step) add(key string, value any) {
+	st.Details = append(st.Details, &Detail{Key: key, Value: fmt.Sprint(value)})
+}
+
+// Change is a field the replay would store differently from what is
+// stored. Stored and Replayed are nil when the field is empty.
+type Change struct {
+	Field    string  `json:"field"`
+	Stored   *string `json:"stored"`
+	Replayed *string `json:"replayed"`
+}
+
+// Replay is the outcome of replaying an email.
+type Replay struct {
+	EmailID string `json:"emailId"`
+	UserID  string `json:"userId"` // the email's owner
+	// Variant is the classification variant used, "" for the agents
+	// service's default.
+	Variant  string    `json:"variant"`
+	Steps    []*Step   `json:"steps"`
+	Changes  []*Change `json:"changes"`
+	Complete bool      `json:"complete"` // every stage ran
+}
+
+// stored is a cached email and the results the pipeline stored for it.
+type stored struct {
+	userID        string
+	email         *agentspb.Email
+	date          sql.NullTime
+	jobRelated    bool
+	relevance     sql.NullFloat64
+	status        sql.NullString
+	language      sql.NullString
+	applicationID sql.NullString
+	triageAction  sql.NullString
+	redacted      bool
+}
+
+// Service replays stored emails.
+type Service struct {
+	db           *sql.DB
+	agents       *agents.Client
+	applications *applications.Service
+	automation   *automation.Service
+	mailbox      *mailbox.Service
+}
+
+// NewService creates a replay service.
+func NewService(db *sql.DB, agentsClient *agents.Client, applicationService *applications.Service,
+	automationService *automation.Service, mailboxService *mailbox.Service) *Service {
+	return &Service{db: db, agents: agentsClient, applications: applicationService, automation: automationService,
+		mailbox: mailboxService}
+}
+
+// Replay runs a cached email through the pipeline again, classifying it
+// with the given variant or, when variant is empty, the one production
+// would use now, and reports every stage and what would be stored
+// differently. Nothing is written, and no experiment trial is recorded.
+// For administrators only.
+func (s *Service) Replay(ctx context.Context, adminID, emailID string, variant *string) (*Replay, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	e, err := s.load(ctx, emailID)
+	if err != nil {
+		return nil, err
+	}
+	if e.redacted {
+		return nil, ErrRedacted
+	}
+	r := &Replay{EmailID: emailID, UserID: e.userID, Steps: []*Step{}, Changes: []*Change{}}
+
+	s.run(r, StageParse, func(st *Step) error { return s.parse(st, e) })
+	if !s.run(r, StageRules, func(st *Step) error { return s.rules(ctx, st, e) }) {
+		return r, nil
+	}
+	var resp *agentspb.ClassifyEmailResponse
+	ok := s.run(r, StageClassify, func(st *Step) error {
+		want := ""
+		if variant != nil {
+			want = strings.TrimSpace(*variant)
+		}
+		var err error
+		resp, r.Variant, err = s.agents.ReplayClassifyEmail(ctx, e.email, want)
+		if err != nil {
+			return err
+		}
+		st.add("variant", orNone(r.Variant))
+		st.add("jobRelated", resp.JobRelated)
+		st.add("status", orNone(resp.Status))
+		st.add("confidence", strconv.FormatFloat(float64(resp.Confidence), 'f', 2, 32))
+		st.add("language", orNone(resp.Language))
+		st.add("reasoning", resp.Reasoning)
+		return nil
+	})
+	if !ok {
+		return r, nil
+	}
+	var extracted *agentspb.ExtractApplicationResponse
+	if resp.JobRelated {
+		ok = s.run(r, StageExtract, func(st *Step) error {
+			var err error
+			if extracted, err = s.agents.ExtractApplication(ctx, e.email); err != nil {
+				return err
+			}
+			a := extracted.Application
+			if a == nil {
+				a = &agentspb.ExtractedApplication{}
+			}
+			st.add("company", orNone(a.Company))
+			st.add("position", orNone(a.Position))
+			st.add("appliedDate", orNone(a.AppliedDate))
+			st.add("status", orNone(a.Status))
+			st.add("source", orNone(a.Source))
+			st.add("confidence", strconv.FormatFloat(float64(extracted.Confidence), 'f', 2, 32))
+			st.add("extractedFields", strings.Join(extracted.ExtractedFields, ", "))
+			return nil
+		})
+		if !ok {
+			return r, nil
+		}
+	}
+	r.Complete = s.run(r, StagePersist, func(st *Step) error { return s.persist(ctx, st, r, e, resp, extracted) })
+	return r, nil
+}
+
+// run times a stage and adds it to the replay, reporting whether it
+// succeeded.
+func (s *Service) run(r *Replay, stage string, fn func(st *Step) error) bool {
+	st := &Step{Stage: stage, Details: []*Detail{}}
+	start := time.Now()
+	err := fn(st)
+	st.DurationMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		msg := err.Error()
+		st.Error = &msg
+	}
+	r.Steps = append(r.Steps, st)
+	return err == nil
+}
+
+func (s *Service) load(ctx context.Context, emailID string) (*stored, error) {
+	e := &stored{email: &agentspb.Email{}}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, COALESCE(subject, ''), COALESCE(sender, ''), COALESCE(recipient, ''), date,
+			COALESCE(snippet, ''), COALESCE(body_text, ''), labels, COALESCE(is_job_related, FALSE), relevance_score,
+			classified_status, language, application_id, triage_action, redacted_at IS NOT NULL
+		FROM email_cache WHERE id = $1`,
+		emailID).Scan(&e.email.Id, &e.userID, &e.email.Subject, &e.email.From, &e.email.To, &e.date,
+		&e.email.Snippet, &e.email.Body, pq.Array(&e.email.Labels), &e.jobRelated, &e.relevance,
+		&e.status, &e.language, &e.applicationID, &e.triageAction, &e.redacted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmailNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.date.Valid {
+		e.email.Date = e.date.Time.Format(time.RFC3339)
+	}
+	return e, nil
+}
+
+// parse reports the email as the agents service receives it.
+func (s *Service) parse(st *Step, e *stored) error {
+	st.add("subject", e.email.Subject)
+	st.add("from", e.email.From)
+	st.add("to", e.email.To)
+	st.add("date", orNone(e.email.Date))
+	st.add("labels", strings.Join(e.email.Labels, ", "))
+	st.add("snippet", e.email.Snippet)
+	st.add("bodyBytes", len(e.email.Body))
+	return nil
+}
+
+// rules reports the owner's rules that act on classified email.
+func (s *Service) rules(ctx context.Context, st *Step, e *stored) error {
+	rule, err := s.mailbox.RejectionRule(ctx, e.userID)
+	if err != nil {
+		return err
+	}
+	if rule.Label != nil {
+		st.add("rejectionRule", rule.Action+" "+*rule.Label)
+	} else {
+		st.add("rejectionRule", rule.Action)
+	}
+	list, err := s.automation.List(ctx, e.userID)
+	if err != nil {
+		return err
+	}
+	for _, r := range list {
+		if r.Trigger != automation.TriggerEmailClassified || !r.Enabled {
+			continue
+		}
+		status := "any status"
+		if r.Status != nil {
+			status = *r.Status
+		}
+		name := r.Name
+		if r.DryRun {
+			name += " (dry run)"
+		}
+		st.add("automationRule", name+" on "+status)
+	}
+	return nil
+}
+
+// persist works out what the pipeline would store for the replayed
+// results, without storing it, and compares it with what is stored. Once
+// an email is linked to an application, the ats-detection and
+// scheduling-links jobs record what they find in it on the application;
+// those are reported too. Only the sender is cached of the headers, so ATS
+// detection may find less than the job, which reads them all from Gmail.
+func (s *Service) persist(ctx context.Context, st *Step, r *Replay, e *stored,
+	resp *agentspb.ClassifyEmailResponse, extracted *agentspb.ExtractApplicationResponse) error {
+	relevance := float64(resp.Confidence)
+	action := triage.Suggest(triage.Email{Subject: e.email.Subject, Snippet: e.email.Snippet, Body: e.email.Body,
+		Status: resp.Status, Relevance: &relevance})
+
+	var applicationID string
+	if extracted != nil && extracted.Application != nil && extracted.Application.Company != "" {
+		a := extracted.Application
+		var position *string
+		if a.Position != "" {
+			position = &a.Position
+		}
+		matches, err := s.applications.FindByCompany(ctx, e.userID, a.Company, position)
+		if err != nil {
+			return err
+		}
+		if len(matches) > 0 {
+			m := matches[0]
+			applicationID = m.ID
+			st.add("application", fmt.Sprintf("would update %s (%s, %s)", m.ID, m.Company, m.Position))
+			if resp.Status != "" && resp.Status != m.Status {
+				st.add("applicationStatus", m.Status+" -> "+resp.Status)
+			}
+		} else {
+			st.add("application", fmt.Sprintf("would create %s, %s", a.Company, orNone(a.Position)))
+		}
+		d := ats.Detect(map[string]string{"From": e.email.From}, e.email.Body)
+		if d.ATS != "" || d.PortalURL != "" {
+			st.add("ats", fmt.Sprintf("would record %s, portal %s", orNone(d.ATS), orNone(d.PortalURL)))
+		}
+		for _, l := range actions.ExtractSchedulingLinks(e.email.Subject + "\n" + e.email.Body) {
+			st.add("schedulingLink", "would add "+l.Provider+" "+l.URL)
+		}
+	}
+	st.add("triageAction", action)
+
+	if resp.Status == models.StatusRejected {
+		rule, err := s.mailbox.RejectionRule(ctx, e.userID)
+		if err != nil {
+			return err
+		}
+		if rule.Action != mailbox.RuleOff {
+			st.add("gmail", "rejection rule would "+rule.Action+" the thread")
+		}
+	}
+	if resp.Status != "" {
+		at := time.Now()
+		if e.date.Valid {
+			at = e.date.Time
+		}
+		firings, err := s.automation.Preview(ctx, e.userID, resp.Status, at)
+		if err != nil {
+			return err
+		}
+		for _, f := range firings {
+			for _, a := range f.Actions {
+				st.add("automation", fmt.Sprintf("%s: %s, due %s", f.RuleName, a.Description, a.DueAt.Format(time.RFC3339)))
+			}
+		}
+	}
+
+	compare := func(field string, was, now *string) {
+		if (was == nil) != (now == nil) || was != nil && *was != *now {
+			r.Changes = append(r.Changes, &Change{Field: field, Stored: was, Replayed: now})
+		}
+	}
+	compare("isJobRelated", text(strconv.FormatBool(e.jobRelated)), text(strconv.FormatBool(resp.JobRelated)))
+	var storedRelevance *string
+	if e.relevance.Valid {
+		storedRelevance = text(strconv.FormatFloat(e.relevance.Float64, 'f', 2, 64))
+	}
+	compare("relevanceScore", storedRelevance, text(strconv.FormatFloat(relevance, 'f', 2, 64)))
+	compare("classifiedStatus", nullable(e.status), text(resp.Status))
+	if resp.Language != "" {
+		// An empty language leaves the stored one in place.
+		compare("language", nullable(e.language), text(resp.Language))
+	}
+	if extracted != nil {
+		compare("applicationId", nullable(e.applicationID), text(applicationID))
+	}
+	compare("triageAction", nullable(e.triageAction), text(action))
+	st.add("changes", len(r.Changes))
+	return nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// text returns nil for "".
+func text(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func nullable(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return text(s.String)
+}
@@ -0,0 +1,132 @@
+package resthooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxBatch bounds how many history rows one dispatch run delivers.
+const maxBatch = 500
+
+// Dispatch delivers events for status history rows recorded since the last
+// run. It is intended to run frequently from the scheduler; the cursor is
+// persisted so restarts neither drop nor repeat deliveries.
+func (s *Service) Dispatch(ctx context.Context) error {
+	var cursor time.Time
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rest_hook_cursor (id, last_changed_at) VALUES (1, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET id = 1
+		RETURNING last_changed_at`).Scan(&cursor)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.QueryContext(ctx, historyQuery+`
+		WHERE h.changed_at > $1
+		  AND EXISTS (SELECT 1 FROM rest_hook_subscriptions s WHERE s.user_id = h.user_id)
+		ORDER BY h.changed_at
+		LIMIT $2`, cursor, maxBatch)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		userID  string
+		payload *Payload
+	}
+	var batch []pending
+	for rows.Next() {
+		var userID string
+		p, err := scanPayloadWithUser(rows, &userID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		batch = append(batch, pending{userID: userID, payload: p})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range batch {
+		for _, event := range eventsFor(item.payload.Status, item.payload.PreviousStatus) {
+			p := *item.payload
+			p.Event = event
+			if err := s.deliver(ctx, item.userID, &p); err != nil {
+				log.Printf("REST hook delivery for %s failed: %v", event, err)
+			}
+		}
+		cursor = item.payload.OccurredAt
+	}
+
+	if len(batch) == 0 {
+		// Nothing subscribed happened; move the cursor to now so the next
+		// scan does not revisit unsubscribed history.
+		_, err = s.db.ExecContext(ctx, `UPDATE rest_hook_cursor SET last_changed_at = GREATEST(last_changed_at, CURRENT_TIMESTAMP - INTERVAL '1 second') WHERE id = 1`)
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `UPDATE rest_hook_cursor SET last_changed_at = $1 WHERE id = 1`, cursor)
+	return err
+}
+
+func (s *Service) deliver(ctx context.Context, userID string, p *Payload) error {
+	subs, err := s.subscriptions(ctx, userID, p.Event)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if err := s.post(ctx, sub, body); err != nil {
+			log.Printf("REST hook %s: %v", sub.ID, err)
+		}
+	}
+	return nil
+}
+
+// post sends one delivery, retrying transient failures. A 410 Gone response
+// means the subscriber has gone away and the subscription is removed.
+func (s *Service) post(ctx context.Context, sub *Subscription, body []byte) error {
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.TargetURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			switch {
+			case resp.StatusCode == http.StatusGone:
+				_, err := s.db.ExecContext(ctx, `DELETE FROM rest_hook_subscriptions WHERE id = $1`, sub.ID)
+				return err
+			case resp.StatusCode < 300:
+				return nil
+			case resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+				return fmt.Errorf("target rejected delivery with status %d", resp.StatusCode)
+			}
+			err = fmt.Errorf("target returned status %d", resp.StatusCode)
+		}
+		if attempt == 3 {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
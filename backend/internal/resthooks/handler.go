@@ -0,0 +1,78 @@
+package resthooks
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Register mounts the Zapier-compatible REST hook routes on the group, which
+// must already authenticate requests.
+func (s *Service) Register(rg *gin.RouterGroup) {
+	rg.GET("/me", s.Me())
+	rg.POST("/subscribe", s.SubscribeHandler())
+	rg.DELETE("/subscribe/:id", s.UnsubscribeHandler())
+	rg.GET("/samples/:event", s.SamplesHandler())
+}
+
+// Me is Zapier's authentication test endpoint.
+func (s *Service) Me() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": auth.UserID(c), "events": Events})
+	}
+}
+
+type subscribeRequest struct {
+	Event     string `json:"event" validate:"required"`
+	TargetURL string `json:"target_url" validate:"required,url,max=2048"`
+}
+
+// SubscribeHandler registers a target URL for an event.
+func (s *Service) SubscribeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req subscribeRequest
+		if err := validation.BindJSON(c, &req); err != nil {
+			apperr.Respond(c, "REST hook subscribe", err)
+			return
+		}
+
+		sub, err := s.Subscribe(c.Request.Context(), auth.UserID(c), req.Event, req.TargetURL)
+		if err != nil {
+			apperr.Respond(c, "REST hook subscribe", err)
+			return
+		}
+		c.JSON(http.StatusCreated, sub)
+	}
+}
+
+// UnsubscribeHandler removes a subscription.
+func (s *Service) UnsubscribeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := s.Unsubscribe(c.Request.Context(), auth.UserID(c), c.Param("id")); err != nil {
+			apperr.Respond(c, "REST hook unsubscribe", err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// SamplesHandler returns sample payloads for an event.
+func (s *Service) SamplesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		samples, err := s.Samples(c.Request.Context(), auth.UserID(c), c.Param("event"))
+		if errors.Is(err, ErrUnknownEvent) {
+			// The event is part of the path, so an unknown one is a missing resource.
+			err = apperr.Wrap(apperr.NotFound, err)
+		}
+		if err != nil {
+			apperr.Respond(c, "REST hook samples", err)
+			return
+		}
+		c.JSON(http.StatusOK, samples)
+	}
+}
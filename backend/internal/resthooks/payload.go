@@ -0,0 +1,129 @@
+package resthooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// Payload is the body delivered for every event. Its shape is stable so
+// Zapier field mappings keep working.
+type Payload struct {
+	ID             string    `json:"id"`
+	Event          string    `json:"event"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	ApplicationID  string    `json:"application_id"`
+	Company        string    `json:"company"`
+	Position       string    `json:"position"`
+	Status         string    `json:"status"`
+	PreviousStatus *string   `json:"previous_status"`
+	Source         string    `json:"source"`
+	AppliedDate    string    `json:"applied_date"`
+}
+
+// eventsFor returns the events a status history row produces.
+func eventsFor(status string, previous *string) []string {
+	if previous == nil {
+		events := []string{EventApplicationCreated}
+		if status == models.StatusOffer {
+			events = append(events, EventOfferReceived)
+		}
+		return events
+	}
+	events := []string{EventStatusChanged}
+	if status == models.StatusOffer {
+		events = append(events, EventOfferReceived)
+	}
+	return events
+}
+
+// sample is returned when the user has no real data for an event yet.
+func sample(event string) *Payload {
+	prev := models.StatusInterviewComplete
+	p := &Payload{
+		ID:            "00000000-0000-0000-0000-000000000000",
+		Event:         event,
+		OccurredAt:    time.Date(2025, 3, 12, 16, 0, 0, 0, time.UTC),
+		ApplicationID: "00000000-0000-0000-0000-000000000001",
+		Company:       "Acme Corp",
+		Position:      "Software Engineer",
+		Status:        models.StatusOffer,
+		Source:        "LinkedIn",
+		AppliedDate:   "2025-02-20",
+	}
+	switch event {
+	case EventApplicationCreated:
+		p.Status = models.StatusApplied
+	default:
+		p.PreviousStatus = &prev
+	}
+	return p
+}
+
+// Samples returns up to three recent payloads for the event, as Zapier's
+// "perform list" expects, falling back to a static example.
+func (s *Service) Samples(ctx context.Context, userID, event string) ([]*Payload, error) {
+	if !knownEvent(event) {
+		return nil, ErrUnknownEvent
+	}
+
+	filter := `prev.status IS NOT NULL`
+	switch event {
+	case EventApplicationCreated:
+		filter = `prev.status IS NULL`
+	case EventOfferReceived:
+		filter = `h.status = 'Offer'`
+	}
+	rows, err := s.db.QueryContext(ctx, historyQuery+` WHERE h.user_id = $1 AND `+filter+`
+		ORDER BY h.changed_at DESC LIMIT 3`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Payload
+	for rows.Next() {
+		p, err := scanPayload(rows)
+		if err != nil {
+			return nil, err
+		}
+		p.Event = event
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		out = append(out, sample(event))
+	}
+	return out, nil
+}
+
+// historyQuery selects status history rows with the status that preceded them.
+const historyQuery = `
+	SELECT h.id, h.user_id, h.changed_at, a.id, a.company, a.position, h.status, prev.status,
+	       COALESCE(a.source, ''), a.applied_date::text
+	FROM application_status_history h
+	JOIN applications a ON a.id = h.application_id
+	LEFT JOIN LATERAL (
+		SELECT p.status FROM application_status_history p
+		WHERE p.application_id = h.application_id AND p.changed_at < h.changed_at
+		ORDER BY p.changed_at DESC LIMIT 1
+	) prev ON TRUE`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayload(row rowScanner) (*Payload, error) {
+	var userID string
+	return scanPayloadWithUser(row, &userID)
+}
+
+func scanPayloadWithUser(row rowScanner, userID *string) (*Payload, error) {
+	p := &Payload{}
+	err := row.Scan(&p.ID, userID, &p.OccurredAt, &p.ApplicationID, &p.Company, &p.Position,
+		&p.Status, &p.PreviousStatus, &p.Source, &p.AppliedDate)
+	return p, err
+}
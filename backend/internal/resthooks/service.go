@@ -0,0 +1,114 @@
+// Package resthooks implements the REST hook subscription pattern used by
+// Zapier: clients subscribe a target URL to an event, and matching events are
+// POSTed to it as JSON.
+package resthooks
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/safehttp"
+)
+
+// Events that can be subscribed to.
+const (
+	EventApplicationCreated = "application.created"
+	EventStatusChanged      = "application.status_changed"
+	EventOfferReceived      = "application.offer_received"
+)
+
+// Events lists every supported event.
+var Events = []string{EventApplicationCreated, EventStatusChanged, EventOfferReceived}
+
+var (
+	// ErrUnknownEvent is returned when subscribing to an unsupported event.
+	ErrUnknownEvent = apperr.New(apperr.Validation, "unknown event")
+	// ErrInvalidTarget is returned when the target URL is not an https URL.
+	ErrInvalidTarget = apperr.New(apperr.Validation, "target_url must be an https URL")
+	// ErrNotFound is returned when a subscription does not exist.
+	ErrNotFound = apperr.New(apperr.NotFound, "subscription not found")
+)
+
+// Subscription is a target URL registered for an event.
+type Subscription struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	TargetURL string    `json:"target_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Service manages subscriptions and delivers events to them.
+type Service struct {
+	db     *sql.DB
+	client *http.Client
+}
+
+// NewService creates a REST hook service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db, client: safehttp.NewClient(10 * time.Second)}
+}
+
+func knownEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe registers a target URL for an event.
+func (s *Service) Subscribe(ctx context.Context, userID, event, targetURL string) (*Subscription, error) {
+	if !knownEvent(event) {
+		return nil, ErrUnknownEvent
+	}
+	if u, err := url.Parse(targetURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, ErrInvalidTarget
+	}
+
+	sub := &Subscription{Event: event, TargetURL: targetURL}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rest_hook_subscriptions (user_id, event, target_url) VALUES ($1, $2, $3)
+		RETURNING id, created_at`,
+		userID, event, targetURL).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Unsubscribe removes a subscription.
+func (s *Service) Unsubscribe(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM rest_hook_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Service) subscriptions(ctx context.Context, userID, event string) ([]*Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, event, target_url, created_at FROM rest_hook_subscriptions
+		WHERE user_id = $1 AND event = $2`, userID, event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Subscription
+	for rows.Next() {
+		sub := &Subscription{}
+		if err := rows.Scan(&sub.ID, &sub.Event, &sub.TargetURL, &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,82 @@
+package resumes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// ErrApplicationNotFound is returned when linking a resume to an application
+// the user does not own.
+var ErrApplicationNotFound = apperr.New(apperr.NotFound, "application not found")
+
+// SetForApplication records which resume version was sent with an
+// application; a nil resumeID clears it.
+func (s *Service) SetForApplication(ctx context.Context, userID, applicationID string, resumeID *string) error {
+	if resumeID != nil {
+		if _, err := s.Get(ctx, userID, *resumeID); err != nil {
+			return err
+		}
+	}
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE applications SET resume_id = $3 WHERE id = $1 AND user_id = $2`,
+		applicationID, userID, resumeID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrApplicationNotFound
+	}
+	return nil
+}
+
+// ForApplication returns the resume sent with an application, or nil.
+func (s *Service) ForApplication(ctx context.Context, userID, applicationID string) (*Resume, error) {
+	r, err := scan(s.db.QueryRowContext(ctx, `
+		SELECT `+prefixed+` FROM resumes r
+		JOIN applications a ON a.resume_id = r.id
+		WHERE a.id = $1 AND a.user_id = $2`, applicationID, userID))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// prefixed is columns qualified for joins against applications.
+const prefixed = `r.id, r.user_id, r.label, r.version, r.filename, r.content_type, r.size_bytes, r.sha256,
+	r.skills, r.experience, r.created_at, r.scan_status, r.scan_signature, r.scanned_at, r.storage_path`
+
+// ExportRow names the resume used for an application in exports.
+type ExportRow struct {
+	Company  string `json:"company"`
+	Position string `json:"position"`
+	Resume   string `json:"resume"` // "Backend v3 (resume.pdf)"
+}
+
+// ExportRows lists the resume used for each of the user's applications that
+// has one, for the exported spreadsheet's Resume column.
+func (s *Service) ExportRows(ctx context.Context, userID string) ([]ExportRow, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.company, a.position, r.label || ' v' || r.version || ' (' || r.filename || ')'
+		FROM applications a
+		JOIN resumes r ON r.id = a.resume_id
+		WHERE a.user_id = $1
+		ORDER BY a.applied_date DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ExportRow
+	for rows.Next() {
+		var row ExportRow
+		if err := rows.Scan(&row.Company, &row.Position, &row.Resume); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
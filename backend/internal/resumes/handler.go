@@ -0,0 +1,41 @@
+package resumes
+
+import (
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+)
+
+// Register mounts the resume download route on the group, which must
+// already authenticate requests. Uploads go through the uploadResume
+// mutation.
+func (s *Service) Register(rg *gin.RouterGroup) {
+	rg.GET("/:id/file", s.DownloadHandler())
+}
+
+// DownloadHandler serves the stored file of a resume version.
+func (s *Service) DownloadHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r, f, err := s.Open(c.Request.Context(), auth.UserID(c), c.Param("id"))
+		if err != nil {
+			apperr.Respond(c, "Resume download", err)
+			return
+		}
+		defer f.Close()
+
+		c.Header("Content-Type", r.ContentType)
+		c.Header("Content-Length", strconv.FormatInt(r.Size, 10))
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": r.Filename}))
+		c.Status(http.StatusOK)
+		if _, err := io.Copy(c.Writer, f); err != nil {
+			log.Printf("Resume download of %s interrupted: %v", r.ID, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package resumes
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Experience is one position parsed from a resume's experience section.
+type Experience struct {
+	Title     string `json:"title"`
+	Company   string `json:"company"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"` // "Present" for a current role
+}
+
+// knownSkills are recognized anywhere in the resume. Matching is
+// case-insensitive except for entries that are ambiguous as plain words.
+var knownSkills = []string{
+	"Go", "Golang", "Python", "Java", "JavaScript", "TypeScript", "C++", "C#", "Rust", "Ruby", "Kotlin",
+	"Swift", "Scala", "PHP", "SQL", "R",
+	"React", "Vue", "Angular", "Next.js", "Node.js", "Django", "Flask", "FastAPI", "Spring", "Rails", "GraphQL", "gRPC",
+	"PostgreSQL", "MySQL", "MongoDB", "Redis", "Kafka", "Elasticsearch", "Spark",
+	"AWS", "GCP", "Azure", "Docker", "Kubernetes", "Terraform", "Linux", "Git", "CI/CD",
+	"Machine Learning", "PyTorch", "TensorFlow", "pandas",
+}
+
+// caseSensitiveSkills only match with exact casing.
+var caseSensitiveSkills = map[string]bool{"Go": true, "R": true, "Spring": true}
+
+type section int
+
+const (
+	sectionOther section = iota
+	sectionSkills
+	sectionExperience
+)
+
+var headings = map[string]section{
+	"skills":                  sectionSkills,
+	"technical skills":        sectionSkills,
+	"skills & technologies":   sectionSkills,
+	"technologies":            sectionSkills,
+	"core competencies":       sectionSkills,
+	"experience":              sectionExperience,
+	"work experience":         sectionExperience,
+	"professional experience": sectionExperience,
+	"relevant experience":     sectionExperience,
+	"employment history":      sectionExperience,
+	"internships":             sectionExperience,
+	"education":               sectionOther,
+	"projects":                sectionOther,
+	"summary":                 sectionOther,
+	"certifications":          sectionOther,
+	"awards":                  sectionOther,
+	"publications":            sectionOther,
+	"interests":               sectionOther,
+	"languages":               sectionOther,
+}
+
+var (
+	month = `(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Sept|Oct|Nov|Dec)[a-z]*\.?`
+	date  = `(?:` + month + `\s+\d{4}|\d{1,2}/\d{4}|\d{4})`
+	// dateRange matches "Jan 2020 - Present", "06/2019 – 08/2021", "2018-2020".
+	dateRange = regexp.MustCompile(`(?i)(` + date + `)\s*(?:-|–|—|to)\s*(` + date + `|present|current|now)`)
+	// titleCompany splits "Title at Company", "Title, Company", "Title | Company"
+	// and "Company — Title".
+	titleAt     = regexp.MustCompile(`^(.+?)\s+(?:at|@)\s+(.+)$`)
+	titleSep    = regexp.MustCompile(`^(.+?)\s*(?:,|\||·|•)\s*(.+)$`)
+	companyDash = regexp.MustCompile(`^(.+?)\s+(?:-|–|—)\s+(.+)$`)
+	listSep     = regexp.MustCompile(`\s*(?:,|;|\||•|·)\s*`)
+)
+
+// Parse extracts skills and work experience from resume text.
+func Parse(text string) (skills []string, experience []Experience) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r", ""), "\n")
+
+	seen := map[string]bool{}
+	addSkill := func(skill string) {
+		skill = strings.TrimSpace(strings.Trim(skill, ".-*"))
+		key := strings.ToLower(skill)
+		if skill == "" || len(skill) > 40 || seen[key] {
+			return
+		}
+		seen[key] = true
+		skills = append(skills, skill)
+	}
+
+	current := sectionOther
+	var previous string
+	for _, raw := range lines {
+		line := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(raw), "•·-*▪◦"))
+		if line == "" {
+			continue
+		}
+		if s, ok := heading(line); ok {
+			current = s
+			previous = ""
+			continue
+		}
+
+		switch current {
+		case sectionSkills:
+			// "Languages: Go, Python" lists skills after the category label.
+			if i := strings.Index(line, ":"); i >= 0 && i < 30 {
+				line = line[i+1:]
+			}
+			for _, item := range listSep.Split(line, -1) {
+				addSkill(item)
+			}
+		case sectionExperience:
+			if m := dateRange.FindStringSubmatchIndex(line); m != nil {
+				rest := strings.TrimSpace(strings.Trim(line[:m[0]]+line[m[1]:], " ,|()–—-"))
+				if rest == "" {
+					rest = previous
+				}
+				exp := splitTitleCompany(rest)
+				exp.StartDate = line[m[2]:m[3]]
+				exp.EndDate = line[m[4]:m[5]]
+				if strings.EqualFold(exp.EndDate, "current") || strings.EqualFold(exp.EndDate, "now") || strings.EqualFold(exp.EndDate, "present") {
+					exp.EndDate = "Present"
+				}
+				if exp.Title != "" {
+					experience = append(experience, exp)
+				}
+			}
+		}
+		previous = line
+	}
+
+	for _, skill := range MatchSkills(text) {
+		addSkill(skill)
+	}
+	return skills, experience
+}
+
+// MatchSkills returns the known skills mentioned anywhere in the text, such
+// as a job description, in the order they are listed in knownSkills.
+func MatchSkills(text string) []string {
+	var found []string
+	for _, skill := range knownSkills {
+		if skillPatterns[skill].MatchString(text) {
+			found = append(found, skill)
+		}
+	}
+	return found
+}
+
+// heading reports whether a line is a section heading and which section.
+func heading(line string) (section, bool) {
+	if len(line) > 40 {
+		return sectionOther, false
+	}
+	s, ok := headings[strings.ToLower(strings.TrimRight(line, ":"))]
+	return s, ok
+}
+
+func splitTitleCompany(s string) Experience {
+	for _, re := range []*regexp.Regexp{titleAt, titleSep} {
+		if m := re.FindStringSubmatch(s); m != nil {
+			return Experience{Title: strings.TrimSpace(m[1]), Company: strings.TrimSpace(m[2])}
+		}
+	}
+	if m := companyDash.FindStringSubmatch(s); m != nil {
+		return Experience{Company: strings.TrimSpace(m[1]), Title: strings.TrimSpace(m[2])}
+	}
+	return Experience{Title: s}
+}
+
+// skillPatterns match each known skill as a whole word.
+var skillPatterns = func() map[string]*regexp.Regexp {
+	patterns := make(map[string]*regexp.Regexp, len(knownSkills))
+	for _, skill := range knownSkills {
+		pattern := `(?:^|[^\w+#.])` + regexp.QuoteMeta(skill) + `(?:$|[^\w+#])`
+		if !caseSensitiveSkills[skill] {
+			pattern = `(?i)` + pattern
+		}
+		patterns[skill] = regexp.MustCompile(pattern)
+	}
+	return patterns
+}()
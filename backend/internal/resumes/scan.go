@@ -0,0 +1,78 @@
+package resumes
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"path"
+
+	"github.com/jobtracker/backend/internal/avscan"
+	"github.com/jobtracker/backend/internal/storage"
+)
+
+// verdict is the scan status to store for a file.
+type verdict struct {
+	status    string
+	signature sql.NullString
+}
+
+// check scans a file. Without a scanner, or when the scanner fails and
+// ATTACHMENT_SCAN_FAIL_OPEN is set, the file is unscanned.
+func (s *Service) check(ctx context.Context, filename string, data []byte) (verdict, error) {
+	if s.scanner == nil {
+		return verdict{status: avscan.StatusUnscanned}, nil
+	}
+	res, err := s.scanner.Scan(ctx, filename, data)
+	if err != nil {
+		if s.cfg.AttachmentScanFailOpen {
+			log.Printf("Storing %s unscanned, %s scanner failed: %v", filename, s.scanner.Name(), err)
+			return verdict{status: avscan.StatusUnscanned}, nil
+		}
+		log.Printf("Rejecting %s, %s scanner failed: %v", filename, s.scanner.Name(), err)
+		return verdict{}, ErrScanUnavailable
+	}
+	if res.Infected {
+		return verdict{status: avscan.StatusInfected, signature: sql.NullString{String: res.Signature, Valid: true}}, nil
+	}
+	return verdict{status: avscan.StatusClean}, nil
+}
+
+// quarantineKey is where a flagged file stored at key is kept: outside the
+// user's directory, so nothing serves it by accident.
+func quarantineKey(userID, key string) string {
+	return path.Join("quarantine", userID, path.Base(key))
+}
+
+// rescan scans a stored file that has not been scanned, recording the
+// verdict on every version sharing the file and quarantining it if
+// flagged.
+func (s *Service) rescan(ctx context.Context, r *Resume) error {
+	data, err := storage.ReadFile(ctx, s.files, r.storagePath)
+	if err != nil {
+		return err
+	}
+	v, err := s.check(ctx, r.Filename, data)
+	if err != nil || v.status == avscan.StatusUnscanned {
+		return err
+	}
+
+	key := r.storagePath
+	if v.status == avscan.StatusInfected {
+		log.Printf("Quarantined stored resume %s of user %s: %s", r.ID, r.UserID, v.signature.String)
+		key = quarantineKey(r.UserID, r.storagePath)
+		if err := storage.Move(ctx, s.files, r.storagePath, key, r.ContentType); err != nil {
+			return err
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE resumes SET scan_status = $2, scan_signature = $3, scanned_at = CURRENT_TIMESTAMP, storage_path = $4
+		WHERE storage_path = $1`,
+		r.storagePath, v.status, v.signature, key); err != nil {
+		return err
+	}
+	r.ScanStatus, r.storagePath = v.status, key
+	if v.signature.Valid {
+		r.ScanSignature = &v.signature.String
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+// Package resumes stores uploaded resume versions, parses them into skills
+// and experience, and records which version was sent with each application.
+// Uploads are scanned for malware first and infected files are quarantined.
+package resumes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/avscan"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/quotas"
+	"github.com/jobtracker/backend/internal/storage"
+)
+
+var (
+	// ErrNotFound is returned when a resume does not exist or belongs to
+	// another user.
+	ErrNotFound = apperr.New(apperr.NotFound, "resume not found")
+	// ErrTooLarge is returned when an upload exceeds MAX_FILE_SIZE_MB.
+	ErrTooLarge = apperr.New(apperr.Validation, "resume file is too large")
+	// ErrLabelRequired is returned when an upload has no label.
+	ErrLabelRequired = apperr.New(apperr.Validation, "resume label is required")
+	// ErrQuarantined is returned when downloading a file the malware
+	// scanner flagged.
+	ErrQuarantined = apperr.New(apperr.Forbidden, "resume file is quarantined because malware was found in it")
+	// ErrScanUnavailable is returned when the malware scanner fails and
+	// ATTACHMENT_SCAN_FAIL_OPEN is off.
+	ErrScanUnavailable = apperr.New(apperr.Unavailable, "resume files cannot be scanned for malware right now")
+)
+
+// Resume is one uploaded version of a resume.
+type Resume struct {
+	ID          string       `json:"id"`
+	UserID      string       `json:"-"`
+	Label       string       `json:"label"`
+	Version     int          `json:"version"`
+	Filename    string       `json:"filename"`
+	ContentType string       `json:"contentType"`
+	Size        int64        `json:"size"`
+	SHA256      string       `json:"sha256"`
+	Skills      []string     `json:"skills"`
+	Experience  []Experience `json:"experience"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	// ScanStatus is clean, infected (quarantined) or unscanned.
+	ScanStatus    string     `json:"scanStatus"`
+	ScanSignature *string    `json:"scanSignature"` // what the scanner found
+	ScannedAt     *time.Time `json:"scannedAt"`
+
+	storagePath string
+}
+
+// Service stores and parses resumes.
+type Service struct {
+	cfg     *config.Config
+	db      *sql.DB
+	quotas  *quotas.Service
+	scanner avscan.Scanner
+	files   storage.Store
+}
+
+// NewService creates a resume service storing files in files. Uploads count
+// towards the user's attachment storage quota and are checked by the
+// scanner, which may be nil to store files unscanned.
+func NewService(cfg *config.Config, db *sql.DB, quotaService *quotas.Service, scanner avscan.Scanner, files storage.Store) *Service {
+	return &Service{cfg: cfg, db: db, quotas: quotaService, scanner: scanner, files: files}
+}
+
+const columns = `id, user_id, label, version, filename, content_type, size_bytes, sha256, skills, experience,
+	created_at, scan_status, scan_signature, scanned_at, storage_path`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*Resume, error) {
+	r := &Resume{}
+	var experience []byte
+	err := row.Scan(&r.ID, &r.UserID, &r.Label, &r.Version, &r.Filename, &r.ContentType, &r.Size, &r.SHA256,
+		pq.Array(&r.Skills), &experience, &r.CreatedAt, &r.ScanStatus, &r.ScanSignature, &r.ScannedAt, &r.storagePath)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, json.Unmarshal(experience, &r.Experience)
+}
+
+// Upload stores a new version of the labelled resume and parses it. Versions
+// are numbered per label starting at 1. A file the scanner flags is stored
+// in quarantine, unparsed, and returned with its scan status so the user
+// sees why it cannot be downloaded.
+func (s *Service) Upload(ctx context.Context, userID, label, filename string, file io.Reader) (*Resume, error) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, ErrLabelRequired
+	}
+	filename = filepath.Base(filename)
+	ct := contentType(filename)
+	if ct == "" {
+		return nil, ErrUnsupportedType
+	}
+
+	limit := int64(s.cfg.MaxFileSizeMB) << 20
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	if err := s.quotas.Check(ctx, userID, quotas.MetricAttachmentBytes, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	verdict, err := s.check(ctx, filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	key := path.Join(userID, digest+filepath.Ext(filename))
+	var text string
+	var skills []string
+	experience := []Experience{}
+	if verdict.status == avscan.StatusInfected {
+		log.Printf("Quarantined resume upload %s of user %s: %s", filename, userID, verdict.signature.String)
+		key = quarantineKey(userID, key)
+	} else {
+		text, err = extractText(ct, data)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", filename, err)
+		}
+		var parsed []Experience
+		skills, parsed = Parse(text)
+		if parsed != nil {
+			experience = parsed
+		}
+	}
+	experienceJSON, err := json.Marshal(experience)
+	if err != nil {
+		return nil, err
+	}
+	// Keys are content-addressed, so storing an upload identical to an
+	// earlier one rewrites the same file.
+	if err := s.files.Put(ctx, key, bytes.NewReader(data), int64(len(data)), ct); err != nil {
+		return nil, err
+	}
+
+	return scan(s.db.QueryRowContext(ctx, `
+		INSERT INTO resumes (user_id, label, version, filename, content_type, size_bytes, sha256,
+			storage_path, text_content, skills, experience, scan_status, scan_signature, scanned_at)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+			CASE WHEN $11 = 'unscanned' THEN NULL ELSE CURRENT_TIMESTAMP END
+		FROM resumes WHERE user_id = $1 AND label = $2
+		RETURNING `+columns,
+		userID, label, filename, ct, len(data), digest, key, strings.ToValidUTF8(text, ""),
+		pq.Array(skills), experienceJSON, verdict.status, verdict.signature))
+}
+
+// Get returns one of the user's resumes.
+func (s *Service) Get(ctx context.Context, userID, id string) (*Resume, error) {
+	return scan(s.db.QueryRowContext(ctx,
+		`SELECT `+columns+` FROM resumes WHERE id = $1 AND user_id = $2`, id, userID))
+}
+
+// List returns the user's resumes, newest version first, optionally for a
+// single label.
+func (s *Service) List(ctx context.Context, userID string, label *string) ([]*Resume, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+columns+` FROM resumes
+		WHERE user_id = $1 AND ($2::text IS NULL OR label = $2)
+		ORDER BY label, version DESC`, userID, label)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resumes []*Resume
+	for rows.Next() {
+		r, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		resumes = append(resumes, r)
+	}
+	return resumes, rows.Err()
+}
+
+// Open returns the stored file of a resume. Files uploaded before a scanner
+// was configured are scanned first; quarantined files are not served.
+func (s *Service) Open(ctx context.Context, userID, id string) (*Resume, io.ReadCloser, error) {
+	r, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.ScanStatus == avscan.StatusUnscanned && s.scanner != nil {
+		if err := s.rescan(ctx, r); err != nil {
+			return nil, nil, err
+		}
+	}
+	if r.ScanStatus == avscan.StatusInfected {
+		return nil, nil, ErrQuarantined
+	}
+	f, err := s.files.Open(ctx, r.storagePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, f, nil
+}
+
+// Delete removes a resume version. Applications that used it keep no
+// resume; the file is removed once no other version shares it.
+func (s *Service) Delete(ctx context.Context, userID, id string) error {
+	var key, digest string
+	err := s.db.QueryRowContext(ctx,
+		`DELETE FROM resumes WHERE id = $1 AND user_id = $2 RETURNING storage_path, sha256`, id, userID).Scan(&key, &digest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Versions saved before files moved to a store may name the same file
+	// by its path, so any version with the same contents counts as sharing.
+	var shared bool
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM resumes WHERE storage_path = $1 OR (user_id = $2 AND sha256 = $3))`,
+		key, userID, digest).Scan(&shared); err != nil {
+		return err
+	}
+	if !shared {
+		if err := s.files.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete resume file %s: %v", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package resumes
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// ErrUnsupportedType is returned for files that are not PDF, DOCX or text.
+var ErrUnsupportedType = apperr.New(apperr.Validation, "resume must be a PDF, DOCX or plain text file")
+
+// Content types accepted for upload, by extension.
+var contentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".txt":  "text/plain",
+	".md":   "text/markdown",
+}
+
+// contentType returns the content type for a filename, or "" if unsupported.
+func contentType(filename string) string {
+	return contentTypes[strings.ToLower(filepath.Ext(filename))]
+}
+
+// extractText returns the plain text of a resume file.
+func extractText(ct string, data []byte) (string, error) {
+	switch ct {
+	case contentTypes[".pdf"]:
+		return pdfText(data), nil
+	case contentTypes[".docx"]:
+		return docxText(data)
+	case contentTypes[".txt"], contentTypes[".md"]:
+		return string(data), nil
+	}
+	return "", ErrUnsupportedType
+}
+
+// docxText concatenates the text runs of word/document.xml, one line per
+// paragraph.
+func docxText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+
+		var b strings.Builder
+		dec := xml.NewDecoder(rc)
+		inText := false
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				return b.String(), nil
+			}
+			if err != nil {
+				return "", err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "t":
+					inText = true
+				case "tab":
+					b.WriteByte('\t')
+				case "br":
+					b.WriteByte('\n')
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "t":
+					inText = false
+				case "p":
+					b.WriteByte('\n')
+				}
+			case xml.CharData:
+				if inText {
+					b.Write(t)
+				}
+			}
+		}
+	}
+	return "", errors.New("docx has no word/document.xml")
+}
+
+var (
+	pdfStream = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n(.*?)\r?\n?endstream`)
+	// pdfTextOp matches string operands of the text-showing operators and
+	// the operators that move to a new line.
+	pdfTextOp = regexp.MustCompile(`(?s)\((?:\\.|[^\\)])*\)\s*(?:Tj|'|")|\[(?:\\.|[^\]])*\]\s*TJ|T\*|-?[\d.]+\s+(-?[\d.]+)\s+T[dD]`)
+	pdfString = regexp.MustCompile(`(?s)\((?:\\.|[^\\)])*\)`)
+)
+
+// pdfText extracts text from the content streams of a PDF. It handles the
+// uncompressed and Flate-encoded streams that resume builders and word
+// processors produce; text in fonts with custom encodings comes out empty
+// and the resume is stored without parsed details.
+func pdfText(data []byte) string {
+	var b strings.Builder
+	for _, m := range pdfStream.FindAllSubmatch(data, -1) {
+		dict, stream := m[1], m[2]
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			stream, err = io.ReadAll(zr)
+			if err != nil && len(stream) == 0 {
+				continue
+			}
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			continue
+		}
+
+		for _, op := range pdfTextOp.FindAllSubmatch(stream, -1) {
+			switch last := op[0][len(op[0])-1]; {
+			case bytes.Equal(op[0], []byte("T*")):
+				b.WriteByte('\n')
+			case op[1] != nil:
+				// Td/TD with a vertical offset starts a new line; a purely
+				// horizontal move separates words.
+				if y, _ := strconv.ParseFloat(string(op[1]), 64); y != 0 {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(' ')
+				}
+			default:
+				if last == '\'' || last == '"' {
+					b.WriteByte('\n')
+				}
+				for _, s := range pdfString.FindAll(op[0], -1) {
+					b.WriteString(unescapePDF(s[1 : len(s)-1]))
+				}
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// unescapePDF decodes the backslash escapes of a PDF literal string.
+func unescapePDF(s []byte) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r', 'b', 'f':
+		case 't':
+			b.WriteByte('\t')
+		case '\r', '\n':
+			// Line continuation.
+		default:
+			if c >= '0' && c <= '7' {
+				v, n := 0, 0
+				for ; n < 3 && i+n < len(s) && s[i+n] >= '0' && s[i+n] <= '7'; n++ {
+					v = v*8 + int(s[i+n]-'0')
+				}
+				i += n - 1
+				b.WriteRune(rune(v))
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
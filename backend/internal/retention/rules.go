@@ -0,0 +1,125 @@
+package retention
+
+import (
+	"context"
+	"log"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/config"
+)
+
+// Rule names.
+const (
+	RuleEmailBodies          = "email_bodies"
+	RuleRejectedApplications = "rejected_applications"
+	RuleExports              = "exports"
+)
+
+// rule describes data that expires a number of days after a timestamp.
+type rule struct {
+	name        string
+	description string
+	defaultDays func(cfg *config.Config) int
+	// key, table, timestamp and filter select candidate rows; the table is
+	// aliased t and must have a user_id column.
+	key       string
+	table     string
+	timestamp string
+	filter    string
+	// apply expires the rows with the given keys and returns how many it
+	// handled.
+	apply func(ctx context.Context, s *Service, keys []string) (int64, error)
+}
+
+var rules = []*rule{
+	{
+		name:        RuleEmailBodies,
+		description: "Raw email bodies and snippets cached from Gmail",
+		defaultDays: func(cfg *config.Config) int { return cfg.RetentionEmailBodyDays },
+		key:         "t.id",
+		table:       "email_cache",
+		timestamp:   "COALESCE(t.date, t.processed_at)",
+		filter:      "(t.body_text IS NOT NULL OR t.snippet IS NOT NULL)",
+		apply: func(ctx context.Context, s *Service, keys []string) (int64, error) {
+			return s.exec(ctx, `UPDATE email_cache SET body_text = NULL, snippet = NULL WHERE id = ANY($1)`, keys)
+		},
+	},
+	{
+		name:        RuleRejectedApplications,
+		description: "Rejected applications with their interviews, actions and history",
+		defaultDays: func(cfg *config.Config) int { return cfg.RetentionRejectedApplicationDays },
+		key:         "t.id::text",
+		table:       "applications",
+		timestamp:   "t.updated_at",
+		filter:      "t.status = 'Rejected'",
+		apply: func(ctx context.Context, s *Service, keys []string) (int64, error) {
+			return s.exec(ctx, `DELETE FROM applications WHERE id::text = ANY($1) AND status = 'Rejected'`, keys)
+		},
+	},
+	{
+		name:        RuleExports,
+		description: "Exported spreadsheets",
+		defaultDays: func(cfg *config.Config) int { return cfg.ExportRetentionDays },
+		key:         "t.id::text",
+		table:       "processing_jobs",
+		timestamp:   "t.completed_at",
+		filter:      "t.completed_at IS NOT NULL AND t.export_purged_at IS NULL",
+		apply:       purgeExports,
+	},
+}
+
+// ruleByName returns the rule with the given name, or nil.
+func ruleByName(name string) *rule {
+	for _, r := range rules {
+		if r.name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *Service) exec(ctx context.Context, query string, keys []string) (int64, error) {
+	res, err := s.db.ExecContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// purgeExports deletes export files and marks their jobs as purged. Files
+// that are already gone are still marked; files that cannot be deleted are
+// left for the next run.
+func purgeExports(ctx context.Context, s *Service, keys []string) (int64, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id::text, output_path FROM processing_jobs WHERE id::text = ANY($1)`, pq.Array(keys))
+	if err != nil {
+		return 0, err
+	}
+	paths := make(map[string]string)
+	for rows.Next() {
+		var id, path string
+		if err := rows.Scan(&id, &path); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		paths[id] = path
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var purged []string
+	for id, path := range paths {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if err := s.exports.Delete(ctx, path); err != nil {
+			log.Printf("Retention: failed to delete export %s: %v", path, err)
+			continue
+		}
+		purged = append(purged, id)
+	}
+	return s.exec(ctx, `UPDATE processing_jobs SET export_purged_at = CURRENT_TIMESTAMP WHERE id::text = ANY($1)`, purged)
+}
@@ -0,0 +1,222 @@
+// Package retention deletes data once it is older than configurable
+// retention rules allow. Each rule has an instance-wide default that users
+// can override, and every run can be previewed as a dry-run report.
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/storage"
+)
+
+var (
+	// ErrUnknownRule is returned for rule names that do not exist.
+	ErrUnknownRule = apperr.New(apperr.Validation, "unknown retention rule")
+	// ErrInvalidDays is returned for negative retention periods.
+	ErrInvalidDays = apperr.New(apperr.Validation, "retention days must be zero (keep forever) or more")
+)
+
+// batchSize bounds how many rows one statement expires.
+const batchSize = 500
+
+// Service evaluates and applies retention rules.
+type Service struct {
+	cfg     *config.Config
+	db      *sql.DB
+	exports storage.Store
+}
+
+// NewService creates a retention service purging export files from exports.
+func NewService(cfg *config.Config, db *sql.DB, exports storage.Store) *Service {
+	return &Service{cfg: cfg, db: db, exports: exports}
+}
+
+// Report is what a rule would delete, or deleted, in one run.
+type Report struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	DefaultDays int    `json:"defaultDays"`
+	Rows        int64  `json:"rows"`
+	Users       int64  `json:"users"`
+}
+
+// UserRule is a rule as it applies to one user.
+type UserRule struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	DefaultDays int    `json:"defaultDays"`
+	Days        int    `json:"days"` // effective period; 0 keeps data forever
+	Overridden  bool   `json:"overridden"`
+	Pending     int64  `json:"pending"` // rows the next run would delete
+}
+
+// expiredQuery selects the expired rows of a rule, honouring per-user
+// overrides. $1 is the rule name, $2 the default days and $3 an optional
+// user ID.
+func (s *Service) expiredQuery(r *rule, selectList string) string {
+	return fmt.Sprintf(`
+		SELECT %s
+		FROM %s t
+		LEFT JOIN retention_overrides o ON o.user_id = t.user_id AND o.rule = $1
+		WHERE %s
+		  AND COALESCE(o.days, $2) > 0
+		  AND %s < CURRENT_TIMESTAMP - make_interval(days => COALESCE(o.days, $2))
+		  AND ($3::text IS NULL OR t.user_id = $3)`,
+		selectList, r.table, r.filter, r.timestamp)
+}
+
+// DryRun reports what a run would delete across all users.
+func (s *Service) DryRun(ctx context.Context) ([]*Report, error) {
+	var reports []*Report
+	for _, r := range rules {
+		rep := &Report{Rule: r.name, Description: r.description, DefaultDays: r.defaultDays(s.cfg)}
+		err := s.db.QueryRowContext(ctx, s.expiredQuery(r, "COUNT(*), COUNT(DISTINCT t.user_id)"),
+			r.name, rep.DefaultDays, nil).Scan(&rep.Rows, &rep.Users)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		reports = append(reports, rep)
+	}
+	return reports, nil
+}
+
+// Run applies every rule and reports what was deleted. It is intended to
+// run daily from the scheduler.
+func (s *Service) Run(ctx context.Context) error {
+	reports, err := s.Apply(ctx, "")
+	for _, rep := range reports {
+		if rep.Rows > 0 {
+			log.Printf("Retention: %s expired %d rows for %d users", rep.Rule, rep.Rows, rep.Users)
+		}
+	}
+	return err
+}
+
+// Apply applies one rule, or every rule when name is empty.
+func (s *Service) Apply(ctx context.Context, name string) ([]*Report, error) {
+	selected := rules
+	if name != "" {
+		r := ruleByName(name)
+		if r == nil {
+			return nil, ErrUnknownRule
+		}
+		selected = []*rule{r}
+	}
+
+	var reports []*Report
+	for _, r := range selected {
+		rep, err := s.apply(ctx, r)
+		if rep != nil {
+			reports = append(reports, rep)
+		}
+		if err != nil {
+			return reports, fmt.Errorf("%s: %w", r.name, err)
+		}
+	}
+	return reports, nil
+}
+
+func (s *Service) apply(ctx context.Context, r *rule) (*Report, error) {
+	rep := &Report{Rule: r.name, Description: r.description, DefaultDays: r.defaultDays(s.cfg)}
+	users := map[string]bool{}
+	for {
+		rows, err := s.db.QueryContext(ctx, s.expiredQuery(r, r.key+", t.user_id")+fmt.Sprintf(" LIMIT %d", batchSize),
+			r.name, rep.DefaultDays, nil)
+		if err != nil {
+			return rep, err
+		}
+		var keys []string
+		for rows.Next() {
+			var key, userID string
+			if err := rows.Scan(&key, &userID); err != nil {
+				rows.Close()
+				return rep, err
+			}
+			keys = append(keys, key)
+			users[userID] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return rep, err
+		}
+		if len(keys) == 0 {
+			break
+		}
+
+		n, err := r.apply(ctx, s, keys)
+		rep.Rows += n
+		rep.Users = int64(len(users))
+		if err != nil {
+			return rep, err
+		}
+		// Stop when rows could not be expired rather than selecting them again.
+		if n < int64(len(keys)) || len(keys) < batchSize {
+			break
+		}
+	}
+	return rep, nil
+}
+
+// Policy returns every rule as it applies to the user, with the number of
+// rows the next run would delete for them.
+func (s *Service) Policy(ctx context.Context, userID string) ([]*UserRule, error) {
+	var out []*UserRule
+	for _, r := range rules {
+		ur, err := s.userRule(ctx, userID, r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ur)
+	}
+	return out, nil
+}
+
+func (s *Service) userRule(ctx context.Context, userID string, r *rule) (*UserRule, error) {
+	ur := &UserRule{Rule: r.name, Description: r.description, DefaultDays: r.defaultDays(s.cfg)}
+
+	var days sql.NullInt64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT days FROM retention_overrides WHERE user_id = $1 AND rule = $2`, userID, r.name).Scan(&days)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	ur.Days, ur.Overridden = ur.DefaultDays, days.Valid
+	if days.Valid {
+		ur.Days = int(days.Int64)
+	}
+
+	err = s.db.QueryRowContext(ctx, s.expiredQuery(r, "COUNT(*)"), r.name, ur.DefaultDays, userID).Scan(&ur.Pending)
+	return ur, err
+}
+
+// SetOverride sets the user's retention period for a rule; 0 keeps the data
+// forever and nil reverts to the instance default.
+func (s *Service) SetOverride(ctx context.Context, userID, name string, days *int) (*UserRule, error) {
+	r := ruleByName(name)
+	if r == nil {
+		return nil, ErrUnknownRule
+	}
+
+	var err error
+	if days == nil {
+		_, err = s.db.ExecContext(ctx,
+			`DELETE FROM retention_overrides WHERE user_id = $1 AND rule = $2`, userID, name)
+	} else if *days < 0 {
+		return nil, ErrInvalidDays
+	} else {
+		_, err = s.db.ExecContext(ctx, `
+			INSERT INTO retention_overrides (user_id, rule, days) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, rule) DO UPDATE SET days = EXCLUDED.days, updated_at = CURRENT_TIMESTAMP`,
+			userID, name, *days)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.userRule(ctx, userID, r)
+}
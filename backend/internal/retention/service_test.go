@@ -0,0 +1,91 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/dbtest"
+)
+
+func TestSetOverrideValidation(t *testing.T) {
+	s := NewService(&config.Config{}, nil, nil)
+	days := -1
+	if _, err := s.SetOverride(context.Background(), "user", RuleEmailBodies, &days); !errors.Is(err, ErrInvalidDays) {
+		t.Errorf("negative days: err = %v, want ErrInvalidDays", err)
+	}
+	if _, err := s.SetOverride(context.Background(), "user", "attachments", nil); !errors.Is(err, ErrUnknownRule) {
+		t.Errorf("unknown rule: err = %v, want ErrUnknownRule", err)
+	}
+	if _, err := s.Apply(context.Background(), "attachments"); !errors.Is(err, ErrUnknownRule) {
+		t.Errorf("Apply unknown rule: err = %v, want ErrUnknownRule", err)
+	}
+}
+
+func TestEmailBodyRetention(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	s := NewService(&config.Config{RetentionEmailBodyDays: 30}, db, nil)
+
+	defaults, forever, longer := dbtest.User(t, db), dbtest.User(t, db), dbtest.User(t, db)
+	zero, ninety := 0, 90
+	if _, err := s.SetOverride(ctx, forever, RuleEmailBodies, &zero); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetOverride(ctx, longer, RuleEmailBodies, &ninety); err != nil {
+		t.Fatal(err)
+	}
+
+	emails := map[string]string{} // email ID to owner
+	for _, userID := range []string{defaults, forever, longer} {
+		for _, age := range []int{5, 60, 120} {
+			var id string
+			err := db.QueryRowContext(ctx, `
+				INSERT INTO email_cache (id, user_id, date, body_text, snippet)
+				VALUES (uuid_generate_v4()::text, $1, $2, 'body', 'snippet')
+				RETURNING id`,
+				userID, time.Now().AddDate(0, 0, -age)).Scan(&id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			emails[id] = userID
+		}
+	}
+
+	// Default 30 days: the 60 and 120 day old bodies expire. Overridden to
+	// 0: nothing does. Overridden to 90: only the 120 day old one.
+	wantPending := map[string]int64{defaults: 2, forever: 0, longer: 1}
+	for userID, want := range wantPending {
+		policy, err := s.Policy(ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ur := range policy {
+			if ur.Rule == RuleEmailBodies && ur.Pending != want {
+				t.Errorf("user %s: pending = %d, want %d", userID, ur.Pending, want)
+			}
+		}
+	}
+
+	if _, err := s.Apply(ctx, RuleEmailBodies); err != nil {
+		t.Fatal(err)
+	}
+	cleared := map[string]int64{}
+	for id, userID := range emails {
+		var body sql.NullString
+		if err := db.QueryRowContext(ctx, `SELECT body_text FROM email_cache WHERE id = $1`, id).Scan(&body); err != nil {
+			t.Fatal(err)
+		}
+		if !body.Valid {
+			cleared[userID]++
+		}
+	}
+	for userID, want := range wantPending {
+		if cleared[userID] != want {
+			t.Errorf("user %s: %d bodies cleared, want %d", userID, cleared[userID], want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package salary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// API estimates compensation by calling a configurable HTTP endpoint. The
+// endpoint receives company, title and location query parameters and
+// answers with JSON of the form
+//
+//	{"currency": "USD", "basis": "total", "low": 150000, "median": 180000, "high": 210000, "sampleSize": 42}
+//
+// A 404 response means the provider has no data for the role.
+type API struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+// NewAPI creates an API provider for the given endpoint. The key, when set,
+// is sent as a bearer token.
+func NewAPI(endpoint, apiKey string, timeout time.Duration) *API {
+	return &API{endpoint: endpoint, apiKey: apiKey, client: &http.Client{Timeout: timeout}}
+}
+
+func (a *API) Name() string { return "api" }
+
+type apiResponse struct {
+	Currency   string `json:"currency"`
+	Basis      string `json:"basis"`
+	Low        int64  `json:"low"`
+	Median     int64  `json:"median"`
+	High       int64  `json:"high"`
+	SampleSize int    `json:"sampleSize"`
+}
+
+func (a *API) Estimate(ctx context.Context, q Query) (*Range, error) {
+	u, err := url.Parse(a.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	params := u.Query()
+	params.Set("company", q.Company)
+	params.Set("title", q.Position)
+	if q.Location != nil {
+		params.Set("location", *q.Location)
+	}
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if a.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("salary API returned status %d", resp.StatusCode)
+	}
+
+	var body apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode salary API response: %w", err)
+	}
+	if body.Median == 0 {
+		return nil, nil
+	}
+	if body.Basis == "" {
+		body.Basis = "total"
+	}
+	return &Range{
+		Provider:   a.Name(),
+		Currency:   body.Currency,
+		Basis:      body.Basis,
+		Low:        body.Low,
+		Median:     body.Median,
+		High:       body.High,
+		SampleSize: body.SampleSize,
+		FetchedAt:  time.Now(),
+	}, nil
+}
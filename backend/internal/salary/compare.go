@@ -0,0 +1,106 @@
+package salary
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jobtracker/backend/internal/currency"
+)
+
+// ComparedOffer is an offer converted to the comparison currency.
+type ComparedOffer struct {
+	ApplicationID    string   `json:"applicationId"`
+	Company          string   `json:"company"`
+	Position         string   `json:"position"`
+	OriginalCurrency string   `json:"originalCurrency"`
+	BaseSalary       int64    `json:"baseSalary"`
+	AnnualTotal      int64    `json:"annualTotal"`
+	SigningBonus     *int64   `json:"signingBonus"`
+	VsMedian         *float64 `json:"vsMedian"`
+}
+
+// OfferComparison lists the user's offers in one currency, highest annual
+// total first.
+type OfferComparison struct {
+	Currency string           `json:"currency"`
+	Offers   []*ComparedOffer `json:"offers"`
+}
+
+// CompareOffers converts all of the user's confirmed offers to the given
+// currency, or to their preferred currency when it is empty.
+func (s *Service) CompareOffers(ctx context.Context, userID, to string) (*OfferComparison, error) {
+	if to == "" {
+		p, err := s.profiles.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		to = p.Currency
+	}
+	to, err := currency.Normalize(to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.company, a.position, o.currency, o.base_salary, o.bonus, o.equity, o.signing_bonus,
+			e.currency, e.basis, e.median
+		FROM application_offers o
+		JOIN applications a ON a.id = o.application_id
+		LEFT JOIN application_salary_estimates e ON e.application_id = o.application_id AND e.median IS NOT NULL
+		WHERE a.user_id = $1 AND o.confirmed_at IS NOT NULL`, userID)
+	if err != nil {
+		return nil, err
+	}
+	type pending struct {
+		offer          Offer
+		company        string
+		position       string
+		expectedCode   *string
+		expectedBasis  *string
+		expectedMedian *int64
+	}
+	var batch []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.offer.ApplicationID, &p.company, &p.position, &p.offer.Currency, &p.offer.BaseSalary,
+			&p.offer.Bonus, &p.offer.Equity, &p.offer.SigningBonus, &p.expectedCode, &p.expectedBasis, &p.expectedMedian); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		batch = append(batch, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := &OfferComparison{Currency: to, Offers: []*ComparedOffer{}}
+	for _, p := range batch {
+		from := p.offer.Currency
+		c := &ComparedOffer{ApplicationID: p.offer.ApplicationID, Company: p.company, Position: p.position, OriginalCurrency: from}
+		if c.BaseSalary, err = s.rates.Convert(ctx, p.offer.BaseSalary, from, to); err != nil {
+			return nil, err
+		}
+		if c.AnnualTotal, err = s.rates.Convert(ctx, p.offer.AnnualTotal(), from, to); err != nil {
+			return nil, err
+		}
+		if p.offer.SigningBonus != nil {
+			v, err := s.rates.Convert(ctx, *p.offer.SigningBonus, from, to)
+			if err != nil {
+				return nil, err
+			}
+			c.SigningBonus = &v
+		}
+		if p.expectedMedian != nil {
+			median, err := s.rates.Convert(ctx, *p.expectedMedian, *p.expectedCode, to)
+			if err != nil {
+				return nil, err
+			}
+			c.VsMedian = vsMedian(c.BaseSalary, c.AnnualTotal, *p.expectedBasis, median)
+		}
+		out.Offers = append(out.Offers, c)
+	}
+
+	sort.SliceStable(out.Offers, func(i, j int) bool { return out.Offers[i].AnnualTotal > out.Offers[j].AnnualTotal })
+	return out, nil
+}
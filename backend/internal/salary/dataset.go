@@ -0,0 +1,154 @@
+package salary
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minSamples is the fewest data points a dataset estimate is built from.
+const minSamples = 3
+
+type dataPoint struct {
+	company  string
+	title    string
+	base     string
+	location string
+	total    int64
+	currency string
+}
+
+// Dataset estimates compensation from a local CSV export in the style of
+// levels.fyi. The file needs a header row with at least company, title and
+// total_compensation columns; location and currency are optional.
+type Dataset struct {
+	points []dataPoint
+}
+
+// LoadDataset reads a compensation dataset from path.
+func LoadDataset(path string) (*Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read dataset header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"company", "title", "total_compensation"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("dataset is missing the %s column", required)
+		}
+	}
+
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	d := &Dataset{}
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read dataset: %w", err)
+		}
+		total, err := strconv.ParseFloat(strings.ReplaceAll(field(rec, "total_compensation"), ",", ""), 64)
+		if err != nil || total <= 0 {
+			continue
+		}
+		currency := strings.ToUpper(field(rec, "currency"))
+		if currency == "" {
+			currency = "USD"
+		}
+		d.points = append(d.points, dataPoint{
+			company:  normalizeCompany(field(rec, "company")),
+			title:    normalizeTitle(field(rec, "title")),
+			base:     baseTitle(field(rec, "title")),
+			location: strings.ToLower(field(rec, "location")),
+			total:    int64(total),
+			currency: currency,
+		})
+	}
+	return d, nil
+}
+
+func (d *Dataset) Name() string { return "dataset" }
+
+// Estimate narrows from company+title+location down to title alone until
+// enough data points remain.
+func (d *Dataset) Estimate(_ context.Context, q Query) (*Range, error) {
+	company := normalizeCompany(q.Company)
+	title := normalizeTitle(q.Position)
+	base := baseTitle(q.Position)
+	location := ""
+	if q.Location != nil {
+		location = strings.ToLower(strings.TrimSpace(*q.Location))
+	}
+
+	filters := []func(p dataPoint) bool{
+		func(p dataPoint) bool {
+			return p.company == company && p.title == title && location != "" && strings.Contains(p.location, location)
+		},
+		func(p dataPoint) bool { return p.company == company && p.title == title },
+		func(p dataPoint) bool { return p.company == company && p.base == base },
+		func(p dataPoint) bool {
+			return p.title == title && location != "" && strings.Contains(p.location, location)
+		},
+		func(p dataPoint) bool { return p.title == title },
+	}
+	for _, keep := range filters {
+		var values []int64
+		currency := ""
+		for _, p := range d.points {
+			if !keep(p) || (currency != "" && p.currency != currency) {
+				continue
+			}
+			currency = p.currency
+			values = append(values, p.total)
+		}
+		if len(values) >= minSamples {
+			sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+			return &Range{
+				Provider:   d.Name(),
+				Currency:   currency,
+				Basis:      "total",
+				Low:        quantile(values, 0.25),
+				Median:     quantile(values, 0.5),
+				High:       quantile(values, 0.75),
+				SampleSize: len(values),
+				FetchedAt:  time.Now(),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// quantile interpolates the q-th quantile of sorted values.
+func quantile(sorted []int64, q float64) int64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	frac := pos - float64(lo)
+	return sorted[lo] + int64(frac*float64(sorted[lo+1]-sorted[lo]))
+}
@@ -0,0 +1,130 @@
+package salary
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/jobtracker/backend/internal/agents/agentspb"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/currency"
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// extractBatch caps the offer letters read per run of ExtractOffers.
+const extractBatch = 50
+
+type offerEmail struct {
+	applicationID, userID string
+	email                 *agentspb.Email
+}
+
+// ExtractOffers reads the compensation out of emails classified as offers
+// and records it on their application, flagged for the user to confirm.
+// Each email is read once; an offer the user already entered or confirmed
+// is never overwritten, and a later letter replaces an unconfirmed one. It
+// is intended to run from the scheduler.
+func (s *Service) ExtractOffers(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.user_id, e.id, COALESCE(e.subject, ''), COALESCE(e.sender, ''), e.date,
+			COALESCE(e.snippet, ''), COALESCE(e.body_text, ''), COALESCE(e.language, '')
+		FROM email_cache e
+		JOIN applications a ON a.user_id = e.user_id AND (a.id = e.application_id OR a.email_id = e.id)
+		WHERE e.classified_status = $1 AND e.offer_extracted_at IS NULL AND e.redacted_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM application_offers o WHERE o.application_id = a.id AND o.confirmed_at IS NOT NULL)
+		ORDER BY e.date NULLS LAST
+		LIMIT $2`,
+		models.StatusOffer, extractBatch)
+	if err != nil {
+		return err
+	}
+	var pending []offerEmail
+	for rows.Next() {
+		p := offerEmail{email: &agentspb.Email{}}
+		var date sql.NullTime
+		if err := rows.Scan(&p.applicationID, &p.userID, &p.email.Id, &p.email.Subject, &p.email.From, &date,
+			&p.email.Snippet, &p.email.Body, &p.email.Language); err != nil {
+			rows.Close()
+			return err
+		}
+		if date.Valid {
+			p.email.Date = date.Time.Format(time.RFC3339)
+		}
+		pending = append(pending, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.extractOffer(ctx, p); err != nil {
+			log.Printf("Failed to extract offer from email %s: %v", p.email.Id, err)
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE email_cache SET offer_extracted_at = CURRENT_TIMESTAMP WHERE id = $1`, p.email.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractOffer reads one offer letter, metered against the user's LLM
+// quota, and stores what it found.
+func (s *Service) extractOffer(ctx context.Context, p offerEmail) error {
+	prof, err := s.profiles.Get(ctx, p.userID)
+	if err != nil {
+		return err
+	}
+	resp, err := s.agents.ExtractOffer(auth.WithUserID(ctx, p.userID), p.email, prof.Currency)
+	if err != nil {
+		return err
+	}
+	x := resp.Offer
+	if !resp.Found || x == nil || x.BaseSalary == nil {
+		return nil
+	}
+
+	code, err := currency.Normalize(x.Currency)
+	if err != nil {
+		code = prof.Currency
+	}
+	var deadline *time.Time
+	if x.Deadline != nil {
+		if t, err := time.Parse(time.RFC3339, *x.Deadline); err == nil {
+			deadline = &t
+		}
+	}
+	var startDate *string
+	if x.StartDate != nil {
+		if _, err := time.Parse("2006-01-02", *x.StartDate); err == nil {
+			startDate = x.StartDate
+		}
+	}
+	for _, v := range []*int64{x.BaseSalary, x.Bonus, x.Equity, x.SigningBonus} {
+		if v != nil && *v < 0 {
+			return nil
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO application_offers (application_id, currency, base_salary, bonus, equity, signing_bonus, deadline,
+			equity_schedule, start_date, source, source_email_id, confirmed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::date, $10, $11, NULL)
+		ON CONFLICT (application_id) DO UPDATE SET
+			currency = EXCLUDED.currency, base_salary = EXCLUDED.base_salary, bonus = EXCLUDED.bonus,
+			equity = EXCLUDED.equity, signing_bonus = EXCLUDED.signing_bonus, deadline = EXCLUDED.deadline,
+			equity_schedule = EXCLUDED.equity_schedule, start_date = EXCLUDED.start_date,
+			source = EXCLUDED.source, source_email_id = EXCLUDED.source_email_id,
+			deadline_reminded_hours = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE application_offers.confirmed_at IS NULL`,
+		p.applicationID, code, *x.BaseSalary, x.Bonus, x.Equity, x.SigningBonus, deadline,
+		x.EquitySchedule, startDate, OfferExtracted, p.email.Id)
+	return err
+}
@@ -0,0 +1,263 @@
+package salary
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/currency"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// ErrNoOffer is returned when confirming an offer that was never recorded.
+var ErrNoOffer = apperr.New(apperr.NotFound, "no offer recorded for this application")
+
+// Offer is the compensation the user was actually offered.
+type Offer struct {
+	ApplicationID string     `json:"applicationId"`
+	Currency      string     `json:"currency"`
+	BaseSalary    int64      `json:"baseSalary"`
+	Bonus         *int64     `json:"bonus"`        // annual target bonus
+	Equity        *int64     `json:"equity"`       // annualized equity value
+	SigningBonus  *int64     `json:"signingBonus"` // one-off, excluded from the annual total
+	Notes         *string    `json:"notes"`
+	Deadline      *time.Time `json:"deadline"` // when the company needs an answer
+	// EquitySchedule describes how the equity vests, e.g. "4 years, 1 year
+	// cliff".
+	EquitySchedule *string `json:"equitySchedule"`
+	StartDate      *string `json:"startDate"` // YYYY-MM-DD
+	// Source is OfferManual or OfferExtracted; extracted offers remember the
+	// email they were read from.
+	Source        string     `json:"source"`
+	SourceEmailID *string    `json:"sourceEmailId"`
+	ConfirmedAt   *time.Time `json:"confirmedAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// Offer sources.
+const (
+	OfferManual    = "manual"
+	OfferExtracted = "extracted"
+)
+
+// NeedsConfirmation reports whether the offer was extracted from an email
+// and the user has not confirmed it yet. Such offers are shown but left
+// out of comparisons and deadline reminders.
+func (o *Offer) NeedsConfirmation() bool {
+	return o.ConfirmedAt == nil
+}
+
+// AnnualTotal is base salary plus target bonus and annualized equity.
+func (o *Offer) AnnualTotal() int64 {
+	total := o.BaseSalary
+	if o.Bonus != nil {
+		total += *o.Bonus
+	}
+	if o.Equity != nil {
+		total += *o.Equity
+	}
+	return total
+}
+
+// OfferInput records or replaces an offer.
+type OfferInput struct {
+	Currency     string     `json:"currency"`
+	BaseSalary   int64      `json:"baseSalary" validate:"gte=0"`
+	Bonus        *int64     `json:"bonus" validate:"omitempty,gte=0"`
+	Equity       *int64     `json:"equity" validate:"omitempty,gte=0"`
+	SigningBonus *int64     `json:"signingBonus" validate:"omitempty,gte=0"`
+	Notes        *string    `json:"notes" validate:"omitempty,max=10000"`
+	Deadline     *time.Time `json:"deadline"`
+	// EquitySchedule describes how the equity vests.
+	EquitySchedule *string `json:"equitySchedule" validate:"omitempty,max=500"`
+	StartDate      *string `json:"startDate" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// Compensation puts the expected range next to the user's offer.
+type Compensation struct {
+	Expected *Range `json:"expected"`
+	Offer    *Offer `json:"offer"`
+	// Normalized holds both sides converted to the user's preferred
+	// currency; nil when rates are unavailable.
+	Normalized *Normalized `json:"normalized"`
+	// VsMedian is the offer's annual total relative to the expected median
+	// (0.1 means 10% above), set when both are known.
+	VsMedian *float64 `json:"vsMedian"`
+}
+
+// Normalized is compensation converted to one currency.
+type Normalized struct {
+	Currency         string `json:"currency"`
+	ExpectedLow      *int64 `json:"expectedLow"`
+	ExpectedMedian   *int64 `json:"expectedMedian"`
+	ExpectedHigh     *int64 `json:"expectedHigh"`
+	OfferBase        *int64 `json:"offerBase"`
+	OfferAnnualTotal *int64 `json:"offerAnnualTotal"`
+}
+
+const offerColumns = `application_id, currency, base_salary, bonus, equity, signing_bonus, notes, deadline,
+	equity_schedule, to_char(start_date, 'YYYY-MM-DD'), source, source_email_id, confirmed_at, updated_at`
+
+func scanOffer(row *sql.Row) (*Offer, error) {
+	o := &Offer{}
+	err := row.Scan(&o.ApplicationID, &o.Currency, &o.BaseSalary, &o.Bonus, &o.Equity,
+		&o.SigningBonus, &o.Notes, &o.Deadline, &o.EquitySchedule, &o.StartDate, &o.Source,
+		&o.SourceEmailID, &o.ConfirmedAt, &o.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return o, err
+}
+
+// SetOffer records the offer for one of the user's applications. Moving the
+// deadline restarts its reminders. An offer entered by the user is
+// confirmed, replacing any extracted one.
+func (s *Service) SetOffer(ctx context.Context, userID, applicationID string, in OfferInput) (*Offer, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	if in.Currency == "" {
+		p, err := s.profiles.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		in.Currency = p.Currency
+	}
+	code, err := currency.Normalize(in.Currency)
+	if err != nil {
+		return nil, err
+	}
+	in.Currency = code
+	o, err := scanOffer(s.db.QueryRowContext(ctx, `
+		INSERT INTO application_offers (application_id, currency, base_salary, bonus, equity, signing_bonus, notes, deadline,
+			equity_schedule, start_date, source, confirmed_at)
+		SELECT a.id, $3, $4, $5, $6, $7, $8, $9, $10, $11::date, $12, CURRENT_TIMESTAMP
+		FROM applications a WHERE a.id = $1 AND a.user_id = $2
+		ON CONFLICT (application_id) DO UPDATE SET
+			currency = EXCLUDED.currency, base_salary = EXCLUDED.base_salary, bonus = EXCLUDED.bonus,
+			equity = EXCLUDED.equity, signing_bonus = EXCLUDED.signing_bonus, notes = EXCLUDED.notes,
+			deadline = EXCLUDED.deadline, equity_schedule = EXCLUDED.equity_schedule, start_date = EXCLUDED.start_date,
+			source = EXCLUDED.source, source_email_id = NULL, confirmed_at = EXCLUDED.confirmed_at,
+			deadline_reminded_hours = CASE WHEN application_offers.deadline IS DISTINCT FROM EXCLUDED.deadline
+				THEN NULL ELSE application_offers.deadline_reminded_hours END,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING `+offerColumns,
+		applicationID, userID, in.Currency, in.BaseSalary, in.Bonus, in.Equity, in.SigningBonus, in.Notes, in.Deadline,
+		in.EquitySchedule, in.StartDate, OfferManual))
+	if err == nil && o == nil {
+		return nil, applications.ErrNotFound
+	}
+	return o, err
+}
+
+// Offer returns the recorded offer for an application, or nil.
+func (s *Service) Offer(ctx context.Context, userID, applicationID string) (*Offer, error) {
+	return scanOffer(s.db.QueryRowContext(ctx, `
+		SELECT `+offerColumns+` FROM application_offers
+		WHERE application_id = $1 AND application_id IN (SELECT id FROM applications WHERE user_id = $2)`,
+		applicationID, userID))
+}
+
+// ConfirmOffer marks an extracted offer as checked by the user, after which
+// it counts in comparisons and its deadline is reminded of. Corrections are
+// made with SetOffer, which confirms as well.
+func (s *Service) ConfirmOffer(ctx context.Context, userID, applicationID string) (*Offer, error) {
+	o, err := scanOffer(s.db.QueryRowContext(ctx, `
+		UPDATE application_offers SET confirmed_at = COALESCE(confirmed_at, CURRENT_TIMESTAMP)
+		WHERE application_id = $1 AND application_id IN (SELECT id FROM applications WHERE user_id = $2)
+		RETURNING `+offerColumns,
+		applicationID, userID))
+	if err == nil && o == nil {
+		return nil, ErrNoOffer
+	}
+	return o, err
+}
+
+// Compensation returns the expected range and the user's offer for an
+// application.
+func (s *Service) Compensation(ctx context.Context, userID, applicationID string) (*Compensation, error) {
+	expected, err := s.Estimate(ctx, userID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	offer, err := s.Offer(ctx, userID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Compensation{Expected: expected, Offer: offer}
+	if expected == nil && offer == nil {
+		return c, nil
+	}
+
+	p, err := s.profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.normalize(ctx, p.Currency, expected, offer)
+	if err != nil {
+		log.Printf("Salary: cannot normalize compensation for %s to %s: %v", applicationID, p.Currency, err)
+	} else {
+		c.Normalized = n
+	}
+
+	switch {
+	case expected == nil || offer == nil || offer.NeedsConfirmation() || expected.Median <= 0:
+	case expected.Currency == offer.Currency:
+		c.VsMedian = vsMedian(offer.BaseSalary, offer.AnnualTotal(), expected.Basis, expected.Median)
+	case c.Normalized != nil:
+		c.VsMedian = vsMedian(*n.OfferBase, *n.OfferAnnualTotal, expected.Basis, *n.ExpectedMedian)
+	}
+	return c, nil
+}
+
+// vsMedian compares the offer on the same basis as the expected range.
+func vsMedian(base, annualTotal int64, basis string, median int64) *float64 {
+	if median <= 0 {
+		return nil
+	}
+	compare := annualTotal
+	if basis == "base" {
+		compare = base
+	}
+	v := float64(compare)/float64(median) - 1
+	return &v
+}
+
+// normalize converts the expected range and offer to the given currency.
+func (s *Service) normalize(ctx context.Context, to string, expected *Range, offer *Offer) (*Normalized, error) {
+	n := &Normalized{Currency: to}
+	convert := func(amount int64, from string) (*int64, error) {
+		v, err := s.rates.Convert(ctx, amount, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return &v, nil
+	}
+
+	var err error
+	if expected != nil {
+		if n.ExpectedLow, err = convert(expected.Low, expected.Currency); err != nil {
+			return nil, err
+		}
+		if n.ExpectedMedian, err = convert(expected.Median, expected.Currency); err != nil {
+			return nil, err
+		}
+		if n.ExpectedHigh, err = convert(expected.High, expected.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if offer != nil {
+		if n.OfferBase, err = convert(offer.BaseSalary, offer.Currency); err != nil {
+			return nil, err
+		}
+		if n.OfferAnnualTotal, err = convert(offer.AnnualTotal(), offer.Currency); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,87 @@
+// Package salary annotates applications with expected compensation ranges
+// from pluggable providers and stores the user's own offer details to
+// compare against.
+package salary
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Query identifies the role to estimate compensation for.
+type Query struct {
+	Company  string
+	Position string
+	Location *string
+}
+
+// Range is an expected annual compensation range.
+type Range struct {
+	Provider   string    `json:"provider"`
+	Currency   string    `json:"currency"`
+	Basis      string    `json:"basis"` // base, total
+	Low        int64     `json:"low"`   // 25th percentile
+	Median     int64     `json:"median"`
+	High       int64     `json:"high"` // 75th percentile
+	SampleSize int       `json:"sampleSize"`
+	FetchedAt  time.Time `json:"fetchedAt"`
+}
+
+// Provider estimates compensation for a role. Estimate returns nil, nil
+// when the provider has no data for the query.
+type Provider interface {
+	Name() string
+	Estimate(ctx context.Context, q Query) (*Range, error)
+}
+
+var levelWords = []string{"senior", "sr", "junior", "jr", "staff", "principal", "lead", "intern", "i", "ii", "iii", "iv"}
+
+// normalizeTitle lowercases a job title and strips punctuation so that
+// "Sr. Software Engineer, Backend" and "senior software engineer backend"
+// compare equal.
+func normalizeTitle(s string) string {
+	s = strings.ToLower(s)
+	s = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return ' '
+	}, s)
+	words := strings.Fields(s)
+	for i, w := range words {
+		if w == "sr" {
+			words[i] = "senior"
+		} else if w == "jr" {
+			words[i] = "junior"
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// baseTitle drops seniority words so a dataset without exact level matches
+// can still fall back to the role family.
+func baseTitle(s string) string {
+	var out []string
+	for _, w := range strings.Fields(normalizeTitle(s)) {
+		level := false
+		for _, l := range levelWords {
+			if w == l {
+				level = true
+				break
+			}
+		}
+		if !level {
+			out = append(out, w)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func normalizeCompany(s string) string {
+	s = normalizeTitle(s)
+	for _, suffix := range []string{" inc", " llc", " ltd", " corp", " corporation", " co"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	return s
+}
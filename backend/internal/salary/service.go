@@ -0,0 +1,175 @@
+package salary
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jobtracker/backend/internal/agents"
+	"github.com/jobtracker/backend/internal/applications"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/currency"
+	"github.com/jobtracker/backend/internal/profile"
+)
+
+// cacheTTL is how long a stored estimate (or a "no data" result) is reused
+// before the providers are asked again.
+const cacheTTL = 30 * 24 * time.Hour
+
+// Service enriches applications with expected compensation.
+type Service struct {
+	db           *sql.DB
+	applications *applications.Service
+	profiles     *profile.Service
+	rates        *currency.Service
+	agents       *agents.Client
+	providers    []Provider
+}
+
+// NewService creates a salary service. Providers are consulted in order and
+// the first one with data wins. Comparisons are converted to each user's
+// preferred currency with rates. Offer letters are read with agentsClient.
+func NewService(db *sql.DB, applicationService *applications.Service, profiles *profile.Service, rates *currency.Service, agentsClient *agents.Client, providers ...Provider) *Service {
+	return &Service{db: db, applications: applicationService, profiles: profiles, rates: rates, agents: agentsClient, providers: providers}
+}
+
+// ProvidersFromConfig builds the providers enabled in the configuration: a
+// local dataset when SALARY_DATASET_PATH is set and an HTTP API when
+// SALARY_API_URL is set.
+func ProvidersFromConfig(cfg *config.Config) ([]Provider, error) {
+	var providers []Provider
+	if cfg.SalaryDatasetPath != "" {
+		d, err := LoadDataset(cfg.SalaryDatasetPath)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, d)
+	}
+	if cfg.SalaryAPIURL != "" {
+		providers = append(providers, NewAPI(cfg.SalaryAPIURL, cfg.SalaryAPIKey, 10*time.Second))
+	}
+	return providers, nil
+}
+
+// Estimate returns the expected compensation for one of the user's
+// applications, or nil when no provider has data for it.
+func (s *Service) Estimate(ctx context.Context, userID, applicationID string) (*Range, error) {
+	r, fresh, err := s.cached(ctx, applicationID)
+	if err != nil || fresh {
+		return r, err
+	}
+
+	app, err := s.applications.Get(ctx, userID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.refresh(ctx, app.ID, Query{Company: app.Company, Position: app.Position, Location: app.Location})
+}
+
+func (s *Service) cached(ctx context.Context, applicationID string) (*Range, bool, error) {
+	var r Range
+	var provider, currency, basis sql.NullString
+	var low, median, high sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT provider, currency, basis, low, median, high, sample_size, fetched_at
+		FROM application_salary_estimates WHERE application_id = $1`,
+		applicationID).Scan(&provider, &currency, &basis, &low, &median, &high, &r.SampleSize, &r.FetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	fresh := time.Since(r.FetchedAt) < cacheTTL
+	if !median.Valid {
+		return nil, fresh, nil
+	}
+	r.Provider, r.Currency, r.Basis = provider.String, currency.String, basis.String
+	r.Low, r.Median, r.High = low.Int64, median.Int64, high.Int64
+	return &r, fresh, nil
+}
+
+// refresh asks the providers for an estimate and stores the result. A miss
+// is stored too so the providers are not asked again until the cache expires.
+func (s *Service) refresh(ctx context.Context, applicationID string, q Query) (*Range, error) {
+	var found *Range
+	for _, p := range s.providers {
+		r, err := p.Estimate(ctx, q)
+		if err != nil {
+			log.Printf("Salary provider %s failed for application %s: %v", p.Name(), applicationID, err)
+			continue
+		}
+		if r != nil {
+			found = r
+			break
+		}
+	}
+
+	var provider, currency, basis *string
+	var low, median, high *int64
+	sampleSize := 0
+	if found != nil {
+		provider, currency, basis = &found.Provider, &found.Currency, &found.Basis
+		low, median, high = &found.Low, &found.Median, &found.High
+		sampleSize = found.SampleSize
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO application_salary_estimates
+			(application_id, provider, currency, basis, low, median, high, sample_size, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP)
+		ON CONFLICT (application_id) DO UPDATE SET
+			provider = EXCLUDED.provider, currency = EXCLUDED.currency, basis = EXCLUDED.basis,
+			low = EXCLUDED.low, median = EXCLUDED.median, high = EXCLUDED.high,
+			sample_size = EXCLUDED.sample_size, fetched_at = EXCLUDED.fetched_at`,
+		applicationID, provider, currency, basis, low, median, high, sampleSize)
+	return found, err
+}
+
+// EnrichPending estimates compensation for applications that have no
+// estimate yet or whose estimate has expired. It is intended to run from
+// the scheduler.
+func (s *Service) EnrichPending(ctx context.Context) error {
+	if len(s.providers) == 0 {
+		return nil
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.company, a.position, a.location
+		FROM applications a
+		LEFT JOIN application_salary_estimates e ON e.application_id = a.id
+		WHERE e.application_id IS NULL OR e.fetched_at < $1
+		ORDER BY a.created_at DESC
+		LIMIT 50`,
+		time.Now().Add(-cacheTTL))
+	if err != nil {
+		return err
+	}
+	type pending struct {
+		id string
+		q  Query
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.q.Company, &p.q.Position, &p.q.Location); err != nil {
+			rows.Close()
+			return err
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range todo {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.refresh(ctx, p.id, p.q); err != nil {
+			log.Printf("Failed to store salary estimate for application %s: %v", p.id, err)
+		}
+	}
+	return nil
+}
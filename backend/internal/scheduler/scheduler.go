@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schedule decides when a job should next run.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Every runs a job at a fixed interval. Runs are aligned to multiples of
+// the interval on the clock so every replica picks the same times.
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (i interval) Next(after time.Time) time.Time {
+	return after.Truncate(time.Duration(i)).Add(time.Duration(i))
+}
+
+// Hourly runs a job at the top of every hour, for jobs that act on each
+// user at a fixed time in their own timezone.
+func Hourly() Schedule {
+	return hourly{}
+}
+
+type hourly struct{}
+
+func (hourly) Next(after time.Time) time.Time {
+	return after.Truncate(time.Hour).Add(time.Hour)
+}
+
+// Daily runs a job once a day at the given hour (server local time).
+func Daily(hour int) Schedule {
+	return daily{hour: hour}
+}
+
+type daily struct {
+	hour int
+}
+
+func (d daily) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.hour, 0, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Weekly runs a job once a week on the given weekday and hour (server local time).
+func Weekly(day time.Weekday, hour int) Schedule {
+	return weekly{day: day, hour: hour}
+}
+
+type weekly struct {
+	day  time.Weekday
+	hour int
+}
+
+func (w weekly) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), w.hour, 0, 0, 0, after.Location())
+	next = next.AddDate(0, 0, (int(w.day)-int(next.Weekday())+7)%7)
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
+}
+
+// Parse reads a schedule from configuration: "hourly", "daily@<hour>",
+// "weekly@<weekday>@<hour>" or a Go duration such as "6h".
+func Parse(spec string) (Schedule, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(spec)), "@")
+	hour := func(s string) (int, error) {
+		h, err := strconv.Atoi(s)
+		if err != nil || h < 0 || h > 23 {
+			return 0, fmt.Errorf("invalid hour %q in schedule %q", s, spec)
+		}
+		return h, nil
+	}
+
+	switch {
+	case parts[0] == "hourly" && len(parts) == 1:
+		return Hourly(), nil
+	case parts[0] == "daily" && len(parts) == 2:
+		h, err := hour(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		return Daily(h), nil
+	case parts[0] == "weekly" && len(parts) == 3:
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if name := strings.ToLower(day.String()); parts[1] == name || parts[1] == name[:3] {
+				h, err := hour(parts[2])
+				if err != nil {
+					return nil, err
+				}
+				return Weekly(day, h), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid weekday %q in schedule %q", parts[1], spec)
+	}
+
+	d, err := time.ParseDuration(spec)
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("invalid schedule %q", spec)
+	}
+	return Every(d), nil
+}
+
+// Job is a named unit of background work.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+	// Singleton jobs run on only one replica at a time.
+	Singleton bool
+}
+
+// Locker runs fn while this instance holds the named lock, at most once per
+// slot across replicas, reporting whether it ran.
+type Locker interface {
+	RunOnce(ctx context.Context, name string, slot time.Time, fn func(ctx context.Context) error) (bool, error)
+}
+
+// Scheduler runs registered jobs on their schedules until stopped.
+type Scheduler struct {
+	jobs   []Job
+	locker Locker
+	wg     sync.WaitGroup
+}
+
+// New creates an empty scheduler. Singleton jobs take the locker's lock
+// named after the job before running; with a nil locker every job runs
+// unguarded, which is only safe with a single replica.
+func New(locker Locker) *Scheduler {
+	return &Scheduler{locker: locker}
+}
+
+// Register adds a job that runs on every replica, for per-process work such
+// as refreshing local caches. Jobs must be registered before Start.
+func (s *Scheduler) Register(name string, schedule Schedule, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, Job{Name: name, Schedule: schedule, Run: run})
+}
+
+// RegisterSingleton adds a job that must not run concurrently on several
+// replicas. Jobs must be registered before Start.
+func (s *Scheduler) RegisterSingleton(name string, schedule Schedule, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, Job{Name: name, Schedule: schedule, Run: run, Singleton: true})
+}
+
+// Start launches every registered job in its own goroutine. Jobs stop when
+// ctx is cancelled; use Wait to block until they have returned.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait blocks until every job loop has exited.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	for {
+		next := job.Schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		ran, err := s.run(ctx, job, next)
+		if err != nil {
+			log.Printf("Scheduled job %s failed: %v", job.Name, err)
+			continue
+		}
+		if !ran {
+			// Another replica holds the job's lock and runs it this time.
+			continue
+		}
+		log.Printf("Scheduled job %s completed in %s", job.Name, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job, slot time.Time) (bool, error) {
+	if !job.Singleton || s.locker == nil {
+		return true, job.Run(ctx)
+	}
+	return s.locker.RunOnce(ctx, "job:"+job.Name, slot, job.Run)
+}
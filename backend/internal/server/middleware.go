@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/eventlog"
+)
+
+// HSTS sets Strict-Transport-Security on HTTPS responses when the server
+// terminates TLS. Behind a proxy the proxy is expected to send it instead.
+func HSTS(cfg *config.Config) gin.HandlerFunc {
+	if !cfg.TLSEnabled() || cfg.HSTSMaxAgeSeconds <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	value := "max-age=" + strconv.Itoa(cfg.HSTSMaxAgeSeconds)
+	if cfg.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return func(c *gin.Context) {
+		if c.Request.TLS != nil {
+			c.Header("Strict-Transport-Security", value)
+		}
+		c.Next()
+	}
+}
+
+// SetCookie writes an HttpOnly, SameSite=Lax cookie on the root path, marked
+// Secure according to COOKIE_SECURE (on by default with TLS). A negative
+// maxAge deletes the cookie.
+func SetCookie(c *gin.Context, cfg *config.Config, name, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   cfg.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// TrustProxies makes c.ClientIP the real client address behind load
+// balancers. Forwarding headers (CLIENT_IP_HEADERS) are only honoured on
+// connections from TRUSTED_PROXIES; X-Forwarded-For is read from the right,
+// skipping trusted hops, so a client cannot spoof its address by sending
+// the header itself. With no trusted proxies the connection's address is
+// used as is.
+func TrustProxies(router *gin.Engine, cfg *config.Config) error {
+	var proxies []string
+	for _, p := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			return fmt.Errorf("TRUSTED_PROXIES: %q is neither an IP address nor a CIDR", p)
+		}
+		proxies = append(proxies, p)
+	}
+	router.ForwardedByClientIP = len(proxies) > 0
+	router.RemoteIPHeaders = cfg.ClientIPHeaders
+	return router.SetTrustedProxies(proxies)
+}
+
+// ClientIP records the client IP address on the request context, so
+// application events written while serving it are attributed to it.
+func ClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ip := c.ClientIP(); ip != "" {
+			c.Request = c.Request.WithContext(eventlog.WithClientIP(c.Request.Context(), ip))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,125 @@
+// Package server builds the backend's HTTP server. Behind a reverse proxy it
+// serves plain HTTP (optionally h2c); self-hosters without one can have it
+// terminate TLS itself with certificate files or Let's Encrypt certificates,
+// which also enables HTTP/2.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/jobtracker/backend/internal/config"
+)
+
+// Server is the API server plus, when terminating TLS, a plain HTTP listener
+// that answers ACME challenges and redirects everything else to HTTPS.
+type Server struct {
+	cfg      *config.Config
+	srv      *http.Server
+	redirect *http.Server
+}
+
+// New configures a server for the handler from the TLS settings.
+func New(cfg *config.Config, handler http.Handler) (*Server, error) {
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSCertFile != "" && len(cfg.TLSAutocertDomains) > 0 {
+		return nil, errors.New("set either TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	}
+
+	s := &Server{
+		cfg: cfg,
+		srv: &http.Server{
+			Addr:              ":" + cfg.Port,
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+
+	if !cfg.TLSEnabled() {
+		if cfg.HTTP2Cleartext {
+			s.srv.Handler = h2c.NewHandler(handler, &http2.Server{})
+		}
+		return s, nil
+	}
+
+	redirect := http.Handler(http.HandlerFunc(s.redirectToHTTPS))
+	s.srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(cfg.TLSAutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+			Email:      cfg.TLSAutocertEmail,
+		}
+		s.srv.TLSConfig = m.TLSConfig()
+		s.srv.TLSConfig.MinVersion = tls.VersionTLS12
+		redirect = m.HTTPHandler(redirect)
+	}
+	if err := http2.ConfigureServer(s.srv, &http2.Server{}); err != nil {
+		return nil, fmt.Errorf("configure HTTP/2: %w", err)
+	}
+
+	if cfg.HTTPRedirectPort != "" {
+		s.redirect = &http.Server{
+			Addr:              ":" + cfg.HTTPRedirectPort,
+			Handler:           redirect,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	} else if len(cfg.TLSAutocertDomains) > 0 {
+		log.Println("HTTP_REDIRECT_PORT is empty: Let's Encrypt can only validate over TLS-ALPN on port 443")
+	}
+	return s, nil
+}
+
+// ListenAndServe serves until Shutdown, returning http.ErrServerClosed.
+func (s *Server) ListenAndServe() error {
+	if s.redirect != nil {
+		go func() {
+			log.Printf("Redirecting HTTP on port %s to HTTPS", s.cfg.HTTPRedirectPort)
+			if err := s.redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("HTTP redirect listener failed: %v", err)
+			}
+		}()
+	}
+
+	if !s.cfg.TLSEnabled() {
+		log.Printf("Server starting on port %s", s.cfg.Port)
+		return s.srv.ListenAndServe()
+	}
+	log.Printf("Server starting with TLS on port %s", s.cfg.Port)
+	// With autocert the certificate comes from TLSConfig.GetCertificate.
+	return s.srv.ListenAndServeTLS(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+}
+
+// Shutdown gracefully stops the server and the redirect listener.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			log.Printf("HTTP redirect listener shutdown: %v", err)
+		}
+	}
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *Server) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if s.cfg.Port != "443" {
+		host = net.JoinHostPort(host, s.cfg.Port)
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+}
@@ -0,0 +1,194 @@
+// Package sharing issues read-only share links to a user's job search, for
+// a friend or mentor to look at or a coach to help with. A link's level
+// decides what masking hides from whoever holds it.
+package sharing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/masking"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// tokenPrefix marks share tokens, telling them apart from API keys.
+const tokenPrefix = "jts_"
+
+// maxLinks caps the active links per user.
+const maxLinks = 20
+
+var (
+	// ErrInvalidLink is returned for unknown, revoked or expired links.
+	ErrInvalidLink = apperr.New(apperr.Unauthenticated, "invalid or expired share link")
+	// ErrInvalidLevel is returned when creating a link with an unknown level.
+	ErrInvalidLevel = apperr.New(apperr.Validation, "share link level must be share or coach")
+	// ErrTooManyLinks is returned when the user already has maxLinks active
+	// links.
+	ErrTooManyLinks = apperr.New(apperr.Conflict, "too many active share links; revoke one first")
+)
+
+// Link is a read-only share link. Only a hash of its token is stored.
+type Link struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Level      string     `json:"level"` // share or coach
+	Prefix     string     `json:"prefix"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedLink is returned once when a link is created; Token is never
+// shown again.
+type CreatedLink struct {
+	Link  *Link  `json:"link"`
+	Token string `json:"token"`
+}
+
+// CreateInput describes a new share link.
+type CreateInput struct {
+	Name  string `json:"name" validate:"required,max=255"`
+	Level string `json:"level"`
+	// ExpiresInDays is nil for a link that lasts until revoked.
+	ExpiresInDays *int `json:"expiresInDays" validate:"omitempty,min=1,max=365"`
+}
+
+// Service issues and verifies share links.
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates a share link service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create issues a new link to the user's job search.
+func (s *Service) Create(ctx context.Context, userID string, in CreateInput) (*CreatedLink, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	in.Level = strings.ToLower(strings.TrimSpace(in.Level))
+	if _, ok := masking.ParseLevel(in.Level); !ok {
+		return nil, ErrInvalidLevel
+	}
+	var active int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM share_links
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`,
+		userID).Scan(&active); err != nil {
+		return nil, err
+	}
+	if active >= maxLinks {
+		return nil, ErrTooManyLinks
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	l := &Link{Name: strings.TrimSpace(in.Name), Level: in.Level, Prefix: token[:len(tokenPrefix)+6]}
+	if in.ExpiresInDays != nil {
+		expires := time.Now().AddDate(0, 0, *in.ExpiresInDays)
+		l.ExpiresAt = &expires
+	}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO share_links (user_id, name, level, prefix, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		userID, l.Name, l.Level, l.Prefix, hash(token), l.ExpiresAt).Scan(&l.ID, &l.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &CreatedLink{Link: l, Token: token}, nil
+}
+
+// List returns the user's active links, newest first.
+func (s *Service) List(ctx context.Context, userID string) ([]*Link, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, level, prefix, expires_at, last_used_at, created_at FROM share_links
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []*Link{}
+	for rows.Next() {
+		l := &Link{}
+		if err := rows.Scan(&l.ID, &l.Name, &l.Level, &l.Prefix, &l.ExpiresAt, &l.LastUsedAt, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// Revoke disables a link. It reports false if no active link matched.
+func (s *Service) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE share_links SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id::text = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// authenticate returns the user and viewer level of a plaintext token and
+// records its use.
+func (s *Service) authenticate(ctx context.Context, token string) (string, masking.Level, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return "", 0, ErrInvalidLink
+	}
+	var userID, name string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE share_links SET last_used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		RETURNING user_id, level`, hash(token)).Scan(&userID, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, ErrInvalidLink
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	level, ok := masking.ParseLevel(name)
+	if !ok {
+		return "", 0, ErrInvalidLink
+	}
+	return userID, level, nil
+}
+
+// Middleware authenticates requests carrying a share token in the
+// X-Share-Token header, and rejects everything else. The request then acts
+// as the link's owner at the link's viewer level, which the GraphQL
+// server's masking extension enforces.
+func (s *Service) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, level, err := s.authenticate(c.Request.Context(), c.GetHeader("X-Share-Token"))
+		if err != nil {
+			apperr.Respond(c, "Share link authentication", err)
+			return
+		}
+		auth.SetUserID(c, userID)
+		c.Request = c.Request.WithContext(masking.WithLevel(c.Request.Context(), level))
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/jobtracker/backend/internal/s3"
+)
+
+// Bucket keeps files in an object storage bucket under a key prefix. Google
+// Cloud Storage is used through its S3-compatible XML API with HMAC keys.
+type Bucket struct {
+	driver string
+	client *s3.Client
+	prefix string
+}
+
+// NewBucket creates a store over the bucket the client accesses, keeping
+// files under prefix. driver names it in logs.
+func NewBucket(driver string, client *s3.Client, prefix string) *Bucket {
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		prefix += "/"
+	}
+	return &Bucket{driver: driver, client: client, prefix: prefix}
+}
+
+// Name implements Store.
+func (b *Bucket) Name() string {
+	return b.driver
+}
+
+// Put implements Store.
+func (b *Bucket) Put(ctx context.Context, key string, body io.ReadSeeker, size int64, contentType string) error {
+	return b.client.Put(ctx, b.prefix+key, body, size, contentType)
+}
+
+// Open implements Store.
+func (b *Bucket) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	body, err := b.client.Get(ctx, b.prefix+key)
+	if errors.Is(err, s3.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return body, err
+}
+
+// Delete implements Store.
+func (b *Bucket) Delete(ctx context.Context, key string) error {
+	if err := b.client.Delete(ctx, b.prefix+key); err != nil && !errors.Is(err, s3.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// List implements Store.
+func (b *Bucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	objects, err := b.client.List(ctx, b.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Object, 0, len(objects))
+	for _, o := range objects {
+		out = append(out, Object{Key: strings.TrimPrefix(o.Key, b.prefix), Size: o.Size, ModTime: o.LastModified})
+	}
+	return out, nil
+}
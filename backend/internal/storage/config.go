@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/s3"
+)
+
+// Stores are where each kind of file is kept.
+type Stores struct {
+	Exports Store // spreadsheets produced by the processing pipeline
+	Resumes Store // uploaded resume files, and those quarantined
+	Logos   Store // company logos cached by the logo proxy
+
+	rules []Rule
+}
+
+// store returns the store with the given name, as used in lifecycle rules.
+func (s *Stores) store(name string) Store {
+	switch name {
+	case "exports":
+		return s.Exports
+	case "resumes":
+		return s.Resumes
+	case "logos":
+		return s.Logos
+	}
+	return nil
+}
+
+// gcsEndpoint is Google Cloud Storage's S3-compatible XML API.
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// FromConfig returns the stores selected by STORAGE_DRIVER: "local" keeps
+// exports in EXCEL_OUTPUT_DIR, resumes in RESUME_STORAGE_DIR and logos in
+// LOGO_STORAGE_DIR, "s3" and "gcs" keep them in STORAGE_BUCKET under
+// exports/, resumes/ and logos/, copied to the STORAGE_REPLICAS buckets.
+func FromConfig(cfg *config.Config) (*Stores, error) {
+	rules, err := ParseRules(cfg.StorageLifecycle)
+	if err != nil {
+		return nil, err
+	}
+
+	driver := strings.ToLower(cfg.StorageDriver)
+	switch driver {
+	case "", "local":
+		exports, err := NewLocal(cfg.ExcelOutputDir)
+		if err != nil {
+			return nil, err
+		}
+		resumes, err := NewLocal(cfg.ResumeStorageDir)
+		if err != nil {
+			return nil, err
+		}
+		logos, err := NewLocal(cfg.LogoStorageDir)
+		if err != nil {
+			return nil, err
+		}
+		return &Stores{Exports: exports, Resumes: resumes, Logos: logos, rules: rules}, nil
+	case "s3", "gcs":
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q, want local, s3 or gcs", cfg.StorageDriver)
+	}
+
+	primary, err := bucketClient(cfg, driver, cfg.StorageRegion, cfg.StorageBucket)
+	if err != nil {
+		return nil, err
+	}
+	replicas := make([]*s3.Client, 0, len(cfg.StorageReplicas))
+	for _, spec := range cfg.StorageReplicas {
+		region, bucket, ok := strings.Cut(spec, ":")
+		if !ok {
+			region, bucket = cfg.StorageRegion, spec
+		}
+		client, err := bucketClient(cfg, driver, region, bucket)
+		if err != nil {
+			return nil, fmt.Errorf("STORAGE_REPLICAS %q: %w", spec, err)
+		}
+		replicas = append(replicas, client)
+	}
+
+	open := func(sub string) Store {
+		prefix := strings.Trim(cfg.StoragePrefix, "/") + "/" + sub
+		if len(replicas) == 0 {
+			return NewBucket(driver, primary, prefix)
+		}
+		copies := make([]Store, 0, len(replicas))
+		for _, r := range replicas {
+			copies = append(copies, NewBucket(driver, r, prefix))
+		}
+		return NewReplicated(NewBucket(driver, primary, prefix), copies...)
+	}
+	return &Stores{Exports: open("exports"), Resumes: open("resumes"), Logos: open("logos"), rules: rules}, nil
+}
+
+// bucketClient is a client for a bucket in a region. S3 endpoints may
+// contain {region}; without STORAGE_ENDPOINT, AWS's regional endpoint is
+// used.
+func bucketClient(cfg *config.Config, driver, region, bucket string) (*s3.Client, error) {
+	endpoint := cfg.StorageEndpoint
+	switch {
+	case driver == "gcs":
+		endpoint, region = gcsEndpoint, "auto"
+	case endpoint == "":
+		endpoint = "https://s3.{region}.amazonaws.com"
+	}
+	endpoint = strings.ReplaceAll(endpoint, "{region}", region)
+	return s3.NewClient(s3.Config{
+		Endpoint:        endpoint,
+		Region:          region,
+		Bucket:          bucket,
+		AccessKeyID:     cfg.StorageAccessKeyID,
+		SecretAccessKey: cfg.StorageSecretAccessKey,
+	}, 5*time.Minute)
+}
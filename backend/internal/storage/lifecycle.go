@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule expires the files of a store under a key prefix some time after they
+// were last written.
+type Rule struct {
+	Store  string // exports, resumes or logos
+	Prefix string
+	MaxAge time.Duration
+}
+
+// ParseRules reads STORAGE_LIFECYCLE entries of the form
+// <store>/<prefix>=<days>, e.g. resumes/quarantine/=90.
+func ParseRules(specs []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(specs))
+	for _, spec := range specs {
+		target, days, ok := strings.Cut(spec, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(days))
+		if !ok || err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid STORAGE_LIFECYCLE rule %q, want <store>/<prefix>=<days>", spec)
+		}
+		store, prefix, _ := strings.Cut(strings.TrimSpace(target), "/")
+		if store != "exports" && store != "resumes" && store != "logos" {
+			return nil, fmt.Errorf("STORAGE_LIFECYCLE rule %q: unknown store %q, want exports, resumes or logos", spec, store)
+		}
+		rules = append(rules, Rule{Store: store, Prefix: prefix, MaxAge: time.Duration(n) * 24 * time.Hour})
+	}
+	return rules, nil
+}
+
+// Expire deletes the files that the lifecycle rules expire. Unlike data
+// retention it knows nothing of users' settings, so rules should only cover
+// files nothing refers to for long, such as quarantined uploads. It is
+// intended to run from the scheduler daily.
+func (s *Stores) Expire(ctx context.Context) error {
+	for _, r := range s.rules {
+		st := s.store(r.Store)
+		objects, err := st.List(ctx, r.Prefix)
+		if err != nil {
+			return fmt.Errorf("list %s/%s: %w", r.Store, r.Prefix, err)
+		}
+		cutoff := time.Now().Add(-r.MaxAge)
+		var deleted int
+		for _, o := range objects {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !o.ModTime.Before(cutoff) {
+				continue
+			}
+			if err := st.Delete(ctx, o.Key); err != nil {
+				log.Printf("Storage lifecycle: failed to delete %s/%s: %v", r.Store, o.Key, err)
+				continue
+			}
+			deleted++
+		}
+		if deleted > 0 {
+			log.Printf("Storage lifecycle: deleted %d files under %s/%s", deleted, r.Store, r.Prefix)
+		}
+	}
+	return nil
+}
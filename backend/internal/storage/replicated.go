@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+)
+
+// Replicated keeps files in a primary store and copies them to replicas,
+// buckets in other regions, so they survive the loss of one. Reads fall
+// back to the replicas in order when the primary fails.
+type Replicated struct {
+	primary  Store
+	replicas []Store
+}
+
+// NewReplicated creates a store writing to primary and replicas.
+func NewReplicated(primary Store, replicas ...Store) *Replicated {
+	return &Replicated{primary: primary, replicas: replicas}
+}
+
+// Name implements Store.
+func (r *Replicated) Name() string {
+	return r.primary.Name()
+}
+
+// Put implements Store. It fails only if the primary write fails; a replica
+// that could not be written is logged and catches up on the file's next
+// write.
+func (r *Replicated) Put(ctx context.Context, key string, body io.ReadSeeker, size int64, contentType string) error {
+	if err := r.primary.Put(ctx, key, body, size, contentType); err != nil {
+		return err
+	}
+	for i, replica := range r.replicas {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := replica.Put(ctx, key, body, size, contentType); err != nil {
+			log.Printf("Failed to replicate %s to storage replica %d: %v", key, i+1, err)
+		}
+	}
+	return nil
+}
+
+// Open implements Store.
+func (r *Replicated) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	f, err := r.primary.Open(ctx, key)
+	if err == nil || ctx.Err() != nil {
+		return f, err
+	}
+	for _, replica := range r.replicas {
+		if f, rerr := replica.Open(ctx, key); rerr == nil {
+			if !errors.Is(err, ErrNotFound) {
+				log.Printf("Read %s from a storage replica, primary failed: %v", key, err)
+			}
+			return f, nil
+		}
+	}
+	return nil, err
+}
+
+// Delete implements Store, removing the file from the replicas too.
+func (r *Replicated) Delete(ctx context.Context, key string) error {
+	if err := r.primary.Delete(ctx, key); err != nil {
+		return err
+	}
+	for i, replica := range r.replicas {
+		if err := replica.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete %s from storage replica %d: %v", key, i+1, err)
+		}
+	}
+	return nil
+}
+
+// List implements Store, listing the primary.
+func (r *Replicated) List(ctx context.Context, prefix string) ([]Object, error) {
+	return r.primary.List(ctx, prefix)
+}
@@ -0,0 +1,111 @@
+// Package storage persists the files the server keeps, exports and resume
+// uploads, behind a Store: the local disk, or an S3 or Google Cloud Storage
+// bucket that every replica shares, optionally copied to buckets in other
+// regions. Lifecycle rules expire old files under a prefix.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// ErrNotFound is returned when a file does not exist.
+var ErrNotFound = apperr.New(apperr.NotFound, "file not found")
+
+// Object is a stored file, as returned by List.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store keeps files by slash-separated key.
+type Store interface {
+	// Name identifies the driver in logs, e.g. "local" or "s3".
+	Name() string
+	// Put stores a file of the given size, replacing any with its key.
+	Put(ctx context.Context, key string, body io.ReadSeeker, size int64, contentType string) error
+	// Open returns a file's contents, or ErrNotFound. The caller closes it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a file; deleting a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns the files whose keys start with prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// ReadFile returns the whole contents of a file.
+func ReadFile(ctx context.Context, st Store, key string) ([]byte, error) {
+	f, err := st.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// renamer is implemented by stores that can move a file without copying it.
+type renamer interface {
+	rename(from, to string) error
+}
+
+// Move gives a file a new key.
+func Move(ctx context.Context, st Store, from, to, contentType string) error {
+	if r, ok := st.(renamer); ok {
+		return r.rename(from, to)
+	}
+	data, err := ReadFile(ctx, st, from)
+	if err != nil {
+		return err
+	}
+	if err := st.Put(ctx, to, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return err
+	}
+	return st.Delete(ctx, from)
+}
+
+// Import moves a file written to the local disk at path, by a process that
+// only knows local files, into the store. It does nothing when the store
+// keeps the file there already.
+func Import(ctx context.Context, st Store, key, path, contentType string) error {
+	if l, ok := st.(*Local); ok {
+		if p, err := l.path(key); err == nil && sameFile(p, path) {
+			return nil
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := st.Put(ctx, key, f, info.Size(), contentType); err != nil {
+		return fmt.Errorf("store %s: %w", key, err)
+	}
+	f.Close()
+	return os.Remove(path)
+}
+
+func sameFile(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && a == b
+}
+
+// notFound maps the drivers' not found errors to ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
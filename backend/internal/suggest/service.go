@@ -0,0 +1,176 @@
+// Package suggest completes company names, job titles and tags as the user
+// types in manual entry forms. It matches the start of the value or of any
+// word in it against the user's own applications, using trigram indexes,
+// and caches each answer briefly in Redis since forms ask again on every
+// keystroke. It is not full-text search: only whole values come back,
+// ranked by how often and how recently the user used them.
+package suggest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// Kinds of suggestions.
+const (
+	KindCompany  = "company"
+	KindPosition = "position"
+	KindTag      = "tag"
+)
+
+// Kinds lists every kind of suggestion, in the order results are grouped.
+var Kinds = []string{KindCompany, KindPosition, KindTag}
+
+const (
+	// DefaultLimit and MaxLimit bound the suggestions returned per kind.
+	DefaultLimit = 8
+	MaxLimit     = 25
+	// maxPrefix caps the prefix length; longer input is not a prefix any
+	// more and should go to search instead.
+	maxPrefix = 100
+	// cacheTTL is short so values from just-saved applications show up
+	// soon without invalidating on every write.
+	cacheTTL    = 2 * time.Minute
+	cachePrefix = "suggest:"
+)
+
+// ErrUnknownKind is returned for kinds other than company, position and tag.
+var ErrUnknownKind = apperr.New(apperr.Validation, "kind must be company, position or tag")
+
+// Suggestion is a value the user has used before.
+type Suggestion struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	// Count is how many of the user's applications use the value.
+	Count int `json:"count"`
+}
+
+// Service answers typeahead queries.
+type Service struct {
+	db  *sql.DB
+	rdb *redis.Client
+}
+
+// NewService creates a typeahead service.
+func NewService(db *sql.DB, rdb *redis.Client) *Service {
+	return &Service{db: db, rdb: rdb}
+}
+
+// Suggest returns up to limit values of each requested kind (all kinds
+// when none are given) that start with prefix, or have a word that does,
+// ignoring case. Values starting with the prefix come first, then the most
+// used and most recently used. An empty prefix returns nothing.
+func (s *Service) Suggest(ctx context.Context, userID, prefix string, kinds []string, limit int) ([]*Suggestion, error) {
+	prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	if prefix == "" {
+		return []*Suggestion{}, nil
+	}
+	if r := []rune(prefix); len(r) > maxPrefix {
+		prefix = string(r[:maxPrefix])
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+	for _, kind := range kinds {
+		if !contains(Kinds, kind) {
+			return nil, ErrUnknownKind
+		}
+	}
+
+	out := []*Suggestion{}
+	for _, kind := range Kinds {
+		if !contains(kinds, kind) {
+			continue
+		}
+		values, err := s.cached(ctx, userID, kind, prefix, limit)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, values...)
+	}
+	return out, nil
+}
+
+func (s *Service) cached(ctx context.Context, userID, kind, prefix string, limit int) ([]*Suggestion, error) {
+	key := cachePrefix + userID + ":" + kind + ":" + strconv.Itoa(limit) + ":" + prefix
+	if raw, err := s.rdb.Get(ctx, key).Bytes(); err == nil {
+		var out []*Suggestion
+		if err := json.Unmarshal(raw, &out); err == nil {
+			return out, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		log.Printf("Failed to read cached suggestions for user %s: %v", userID, err)
+	}
+
+	out, err := s.query(ctx, userID, kind, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err := json.Marshal(out); err == nil {
+		if err := s.rdb.Set(ctx, key, raw, cacheTTL).Err(); err != nil {
+			log.Printf("Failed to cache suggestions for user %s: %v", userID, err)
+		}
+	}
+	return out, nil
+}
+
+// valueQueries select (value, applied date) pairs of each kind for a user.
+var valueQueries = map[string]string{
+	KindCompany:  `SELECT company AS value, applied_date FROM applications WHERE user_id = $1`,
+	KindPosition: `SELECT position AS value, applied_date FROM applications WHERE user_id = $1`,
+	KindTag:      `SELECT unnest(tags) AS value, applied_date FROM applications WHERE user_id = $1`,
+}
+
+func (s *Service) query(ctx context.Context, userID, kind, prefix string, limit int) ([]*Suggestion, error) {
+	// The most recently used spelling of a value stands for all its casings.
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT (array_agg(value ORDER BY applied_date DESC))[1], COUNT(*)
+		FROM (`+valueQueries[kind]+`) v
+		WHERE LOWER(value) LIKE $2 || '%' ESCAPE '\' OR LOWER(value) LIKE '% ' || $2 || '%' ESCAPE '\'
+		GROUP BY LOWER(value)
+		ORDER BY bool_or(LOWER(value) LIKE $2 || '%' ESCAPE '\') DESC, COUNT(*) DESC, MAX(applied_date) DESC, LOWER(value)
+		LIMIT $3`,
+		userID, escapeLike(prefix), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*Suggestion{}
+	for rows.Next() {
+		sg := &Suggestion{Kind: kind}
+		if err := rows.Scan(&sg.Value, &sg.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, sg)
+	}
+	return out, rows.Err()
+}
+
+// escapeLike escapes LIKE wildcards so the prefix matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func contains(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+// Package syncguard sanity-checks the results of a Gmail sync before they
+// are applied. A batch that looks wrong - hundreds of emails classified as
+// offers, or no matches where there are usually many - usually means a
+// prompt regression or a broken filter, so it is held for an
+// administrator instead of rewriting users' applications.
+package syncguard
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/admin"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/config"
+	"github.com/jobtracker/backend/internal/models"
+	"github.com/jobtracker/backend/internal/notifications"
+)
+
+// Review states of a processing job.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// StatusNeedsReview is the processing job status while a flagged batch
+// waits for review.
+const StatusNeedsReview = "needs_review"
+
+// KindImport is the processing_jobs kind of a mail import. Its batches are
+// checked as they are classified, and approving one does not rerun it.
+const KindImport = "import"
+
+// baselineJobs is how many of the user's earlier syncs form the baseline.
+const baselineJobs = 10
+
+// ErrNotFlagged is returned when reviewing a job that is not awaiting review.
+var ErrNotFlagged = apperr.New(apperr.Conflict, "processing job is not awaiting review")
+
+// Batch summarises one sync's results before they are applied.
+type Batch struct {
+	JobID  string
+	UserID string
+	// Scanned is how many emails were examined and Matched how many were
+	// classified as job related.
+	Scanned int
+	Matched int
+	// Statuses counts the application statuses the results would set.
+	Statuses map[string]int
+}
+
+// Verdict is the outcome of checking a batch.
+type Verdict struct {
+	// Hold is set when the results must not be applied automatically.
+	Hold    bool
+	Reasons []string
+}
+
+// Service checks batches and tracks their review.
+type Service struct {
+	cfg           *config.Config
+	db            *sql.DB
+	notifications *notifications.Service
+}
+
+// NewService creates a sync guard with the SYNC_ANOMALY_* thresholds.
+func NewService(cfg *config.Config, db *sql.DB, notificationService *notifications.Service) *Service {
+	return &Service{cfg: cfg, db: db, notifications: notificationService}
+}
+
+// Check compares the batch with the thresholds and the user's recent syncs.
+// An anomalous batch is marked for review, the job is paused in the
+// needs_review status and administrators are alerted; the caller must then
+// leave the results unapplied. Batches an administrator already approved
+// always pass.
+func (s *Service) Check(ctx context.Context, b Batch) (*Verdict, error) {
+	var review sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT review_status FROM processing_jobs WHERE id = $1`, b.JobID).Scan(&review)
+	if err != nil {
+		return nil, err
+	}
+	if review.String == ReviewApproved {
+		return &Verdict{}, nil
+	}
+
+	baseline, history, err := s.baseline(ctx, b.UserID, b.JobID)
+	if err != nil {
+		return nil, err
+	}
+	reasons := s.evaluate(b, baseline, history)
+	if len(reasons) == 0 {
+		return &Verdict{}, nil
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE processing_jobs
+		SET status = $2, review_status = $3, anomaly_reasons = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		b.JobID, StatusNeedsReview, ReviewPending, pq.Array(reasons)); err != nil {
+		return nil, err
+	}
+	log.Printf("Sync batch %s for user %s held for review: %s", b.JobID, b.UserID, strings.Join(reasons, "; "))
+	s.alertAdmins(ctx, b, reasons)
+	return &Verdict{Hold: true, Reasons: reasons}, nil
+}
+
+// baseline returns the user's average matches over recent completed syncs
+// and how many syncs it covers.
+func (s *Service) baseline(ctx context.Context, userID, jobID string) (float64, int, error) {
+	var avg sql.NullFloat64
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT AVG(applications_found), COUNT(*) FROM (
+			SELECT applications_found FROM processing_jobs
+			WHERE user_id = $1 AND id::text <> $2 AND status = 'completed'
+				AND COALESCE(review_status, '') <> $3
+			ORDER BY completed_at DESC
+			LIMIT $4
+		) recent`,
+		userID, jobID, ReviewRejected, baselineJobs).Scan(&avg, &n)
+	return avg.Float64, n, err
+}
+
+// evaluate returns why the batch looks anomalous, if it does. Thresholds set
+// to 0 are disabled.
+func (s *Service) evaluate(b Batch, baseline float64, history int) []string {
+	var reasons []string
+	if b.Matched >= s.cfg.SyncAnomalyMinBatch {
+		statuses := make([]string, 0, len(b.Statuses))
+		for status := range b.Statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		for _, status := range statuses {
+			n := b.Statuses[status]
+			if status == models.StatusApplied {
+				continue
+			}
+			if limit := s.cfg.SyncAnomalyMaxStatusCount; limit > 0 && n > limit {
+				reasons = append(reasons, fmt.Sprintf("%d results set %q (limit %d)", n, status, limit))
+			} else if pct := s.cfg.SyncAnomalyMaxStatusPercent; pct > 0 && n*100 > pct*b.Matched {
+				reasons = append(reasons, fmt.Sprintf("%d of %d results set %q (limit %d%%)", n, b.Matched, status, pct))
+			}
+		}
+	}
+
+	// Comparisons with the user's history need a few earlier syncs.
+	if history < 3 {
+		return reasons
+	}
+	if f := s.cfg.SyncAnomalySpikeFactor; f > 0 && b.Matched >= s.cfg.SyncAnomalyMinBatch && float64(b.Matched) > baseline*float64(f) {
+		reasons = append(reasons, fmt.Sprintf("%d matches is over %dx the recent average of %.1f", b.Matched, f, baseline))
+	}
+	if b.Matched == 0 && b.Scanned >= s.cfg.SyncAnomalyMinBatch && baseline >= 5 {
+		reasons = append(reasons, fmt.Sprintf("no matches in %d emails against a recent average of %.1f", b.Scanned, baseline))
+	}
+	return reasons
+}
+
+// alertAdmins notifies every administrator about a held batch.
+func (s *Service) alertAdmins(ctx context.Context, b Batch, reasons []string) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM users WHERE is_admin`)
+	if err != nil {
+		log.Printf("Failed to list admins for sync alert: %v", err)
+		return
+	}
+	var admins []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			admins = append(admins, id)
+		}
+	}
+	rows.Close()
+
+	title := "Sync results held for review"
+	body := fmt.Sprintf("Processing job %s for user %s was paused:\n%s\n\nReview with `jobtrackerctl jobs approve --admin <you> %s` or `jobtrackerctl jobs reject --admin <you> %s`.",
+		b.JobID, b.UserID, strings.Join(reasons, "\n"), b.JobID, b.JobID)
+	for _, id := range admins {
+		if err := s.notifications.Notify(ctx, id, notifications.KindSyncAnomaly, title, body); err != nil {
+			log.Printf("Failed to alert admin %s about job %s: %v", id, b.JobID, err)
+		}
+	}
+}
+
+// Flagged is a processing job awaiting review.
+type Flagged struct {
+	ID        string
+	UserID    string
+	StartDate time.Time
+	Found     int
+	Reasons   []string
+	UpdatedAt time.Time
+}
+
+// Flagged lists jobs awaiting review, oldest first. Only administrators
+// may list them.
+func (s *Service) Flagged(ctx context.Context, adminID string) ([]*Flagged, error) {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, start_date, COALESCE(applications_found, 0), COALESCE(anomaly_reasons, '{}'), updated_at
+		FROM processing_jobs
+		WHERE review_status = $1
+		ORDER BY updated_at`,
+		ReviewPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Flagged
+	for rows.Next() {
+		j := &Flagged{}
+		if err := rows.Scan(&j.ID, &j.UserID, &j.StartDate, &j.Found, pq.Array(&j.Reasons), &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// Approve releases a held job: a sync is requeued and its results are
+// applied without being checked again. A held import is completed again;
+// the batches its user releases next are classified and applied without
+// being checked. Only administrators may approve.
+func (s *Service) Approve(ctx context.Context, adminID, jobID string) error {
+	return s.review(ctx, adminID, jobID, `
+		UPDATE processing_jobs
+		SET review_status = $2, updated_at = CURRENT_TIMESTAMP,
+			status = CASE WHEN kind = $4 THEN 'completed' ELSE 'pending' END,
+			progress = CASE WHEN kind = $4 THEN progress ELSE 0 END,
+			current_stage = CASE WHEN kind = $4 THEN current_stage END,
+			completed_at = CASE WHEN kind = $4 THEN completed_at END
+		WHERE id::text = $1 AND review_status = $3`,
+		ReviewApproved, KindImport)
+}
+
+// Reject discards a held job's results and fails the job. Only
+// administrators may reject.
+func (s *Service) Reject(ctx context.Context, adminID, jobID string) error {
+	return s.review(ctx, adminID, jobID, `
+		UPDATE processing_jobs
+		SET review_status = $2, status = 'failed',
+			errors = array_append(COALESCE(errors, '{}'), 'Results rejected in anomaly review'),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id::text = $1 AND review_status = $3`,
+		ReviewRejected)
+}
+
+func (s *Service) review(ctx context.Context, adminID, jobID, query, outcome string, args ...interface{}) error {
+	if err := admin.Require(ctx, s.db, adminID); err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, query, append([]interface{}{jobID, outcome, ReviewPending}, args...)...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFlagged
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package tasks lists a user's background jobs in one place: syncs,
+// backfills, exports and imports are all runs of the processing pipeline,
+// told apart by their kind, and are followed and cancelled alike.
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/exports"
+	"github.com/jobtracker/backend/internal/syncguard"
+)
+
+// Task types, stored as processing_jobs.kind.
+const (
+	TypeSync     = "sync"
+	TypeBackfill = "backfill"
+	TypeExport   = "export"
+	TypeImport   = "import"
+)
+
+// Task states.
+const (
+	StatePending     = "pending"
+	StateRunning     = "running"
+	StateNeedsReview = "needs_review"
+	StateCompleted   = "completed"
+	StateFailed      = "failed"
+	StateCancelled   = "cancelled"
+)
+
+// ErrNotFound is returned when a task does not exist or belongs to another
+// user.
+var ErrNotFound = apperr.New(apperr.NotFound, "task not found")
+
+// Task is one background job.
+type Task struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+	// Progress is a percentage; Stage names the pipeline step running.
+	Progress int     `json:"progress"`
+	Stage    *string `json:"stage"`
+	// StartDate and EndDate bound the mail the job covers.
+	StartDate             string     `json:"startDate"`
+	EndDate               *string    `json:"endDate"`
+	ApplicationsFound     int        `json:"applicationsFound"`
+	ApplicationsProcessed int        `json:"applicationsProcessed"`
+	Errors                []string   `json:"errors"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+	CompletedAt           *time.Time `json:"completedAt"`
+	// CancelRequested is set once the user cancelled a running task; it
+	// stops at its next progress report.
+	CancelRequested bool `json:"cancelRequested"`
+	Cancellable     bool `json:"cancellable"`
+}
+
+// Filter narrows the tasks listed.
+type Filter struct {
+	// Active keeps only pending, running and held tasks.
+	Active bool
+	Type   string
+	Limit  int
+}
+
+// Service lists and cancels tasks.
+type Service struct {
+	db      *sql.DB
+	exports *exports.Service
+}
+
+// NewService creates a task service. Cancelling goes through the export
+// service, which streams the change to the user.
+func NewService(db *sql.DB, exportService *exports.Service) *Service {
+	return &Service{db: db, exports: exportService}
+}
+
+const query = `
+	SELECT id, kind, status, COALESCE(progress, 0), current_stage,
+		to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'),
+		COALESCE(applications_found, 0), COALESCE(applications_processed, 0),
+		COALESCE(errors, '{}') || COALESCE(anomaly_reasons, '{}'),
+		created_at, COALESCE(updated_at, created_at), completed_at, cancel_requested_at IS NOT NULL
+	FROM processing_jobs`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(row scanner) (*Task, error) {
+	t := &Task{}
+	var status string
+	err := row.Scan(&t.ID, &t.Type, &status, &t.Progress, &t.Stage, &t.StartDate, &t.EndDate,
+		&t.ApplicationsFound, &t.ApplicationsProcessed, pq.Array(&t.Errors),
+		&t.CreatedAt, &t.UpdatedAt, &t.CompletedAt, &t.CancelRequested)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case exports.StatusPending:
+		t.State = StatePending
+	case exports.StatusProcessing:
+		t.State = StateRunning
+	case syncguard.StatusNeedsReview:
+		t.State = StateNeedsReview
+	default:
+		t.State = status
+	}
+	t.Cancellable = (t.State == StatePending || t.State == StateRunning) && !t.CancelRequested
+	return t, nil
+}
+
+// List returns the user's tasks, newest first (default 50).
+func (s *Service) List(ctx context.Context, userID string, f Filter) ([]*Task, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, query+`
+		WHERE user_id = $1 AND ($2 = '' OR kind = $2)
+			AND (NOT $3 OR status IN ('pending', 'processing', 'needs_review'))
+		ORDER BY created_at DESC
+		LIMIT $4`,
+		userID, f.Type, f.Active, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*Task{}
+	for rows.Next() {
+		t, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Get returns one of the user's tasks.
+func (s *Service) Get(ctx context.Context, userID, id string) (*Task, error) {
+	return scan(s.db.QueryRowContext(ctx, query+` WHERE id::text = $1 AND user_id = $2`, id, userID))
+}
+
+// Cancel stops a pending or running task as exports.Service.Cancel does,
+// returning exports.ErrNotCancellable once it finished or while it is held
+// for review.
+func (s *Service) Cancel(ctx context.Context, userID, id string) (*Task, error) {
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	if _, err := s.exports.Cancel(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID, id)
+}
@@ -0,0 +1,234 @@
+// Package triage turns newly classified job-hunt email into a queue grouped
+// by what each message needs, so a day's mail can be processed in one pass:
+// reply to recruiters, book interviews, and archive or ignore the rest.
+package triage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/actions"
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+// Triage actions, in the order the queue lists them.
+const (
+	ActionRespond  = "respond"
+	ActionSchedule = "schedule"
+	ActionArchive  = "archive"
+	ActionIgnore   = "ignore"
+)
+
+// Actions lists every triage action in queue order.
+var Actions = []string{ActionRespond, ActionSchedule, ActionArchive, ActionIgnore}
+
+const (
+	// defaultWindow is how far back the queue looks by default.
+	defaultWindow = 7 * 24 * time.Hour
+	// queueLimit caps the emails in one queue.
+	queueLimit = 200
+)
+
+var (
+	// ErrInvalidAction is returned for actions other than those in Actions.
+	ErrInvalidAction = apperr.New(apperr.Validation, "action must be respond, schedule, archive or ignore")
+	// ErrNotFound is returned when no untriaged email matched.
+	ErrNotFound = apperr.New(apperr.NotFound, "email not found or already triaged")
+)
+
+// Item is an email waiting in the queue.
+type Item struct {
+	EmailID       string     `json:"emailId"`
+	Subject       *string    `json:"subject"`
+	Sender        *string    `json:"sender"`
+	Date          *time.Time `json:"date"`
+	Snippet       *string    `json:"snippet"`
+	Suggested     string     `json:"suggested"`
+	ApplicationID *string    `json:"applicationId"`
+	Company       *string    `json:"company"`
+	Position      *string    `json:"position"`
+	Status        *string    `json:"status"`
+}
+
+// Group is the queue's emails with one suggested action.
+type Group struct {
+	Action string  `json:"action"`
+	Items  []*Item `json:"items"`
+}
+
+// Queue is the user's untriaged email, grouped by suggested action.
+type Queue struct {
+	Groups []*Group `json:"groups"`
+	Total  int      `json:"total"`
+}
+
+// Service builds triage queues and applies triage decisions.
+type Service struct {
+	db      *sql.DB
+	actions *actions.Service
+}
+
+// NewService creates a triage service.
+func NewService(db *sql.DB, actionService *actions.Service) *Service {
+	return &Service{db: db, actions: actionService}
+}
+
+// Classified stores the status an email was classified as, the language
+// the agents service detected ("" keeps the stored one) and the application
+// it was matched to, along with the suggested triage action. It is called by
+// the sync pipeline and once imported email is classified; emails neither
+// saw get their suggestion worked out when the queue is built.
+func (s *Service) Classified(ctx context.Context, userID, emailID string, applicationID *string, status, language string) error {
+	var e Email
+	var relevance sql.NullFloat64
+	var subject, snippet, body sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		`SELECT subject, snippet, body_text, relevance_score FROM email_cache WHERE id = $1 AND user_id = $2`,
+		emailID, userID).Scan(&subject, &snippet, &body, &relevance)
+	if err != nil {
+		return err
+	}
+	e.Subject, e.Snippet, e.Body, e.Status = subject.String, snippet.String, body.String, status
+	if relevance.Valid {
+		e.Relevance = &relevance.Float64
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE email_cache SET application_id = $3, classified_status = NULLIF($4, ''), triage_action = $5,
+			language = COALESCE(NULLIF($6, ''), language)
+		WHERE id = $1 AND user_id = $2`,
+		emailID, userID, applicationID, status, Suggest(e), language)
+	return err
+}
+
+// Queue returns the user's job-related emails received since the given time
+// (the last 7 days by default) that have not been triaged, newest first
+// within each group. Every action has a group, even when it is empty.
+func (s *Service) Queue(ctx context.Context, userID string, since *time.Time) (*Queue, error) {
+	from := time.Now().Add(-defaultWindow)
+	if since != nil {
+		from = *since
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.subject, e.sender, e.date, e.snippet, e.body_text, e.relevance_score, e.triage_action,
+			COALESCE(e.classified_status, a.status), a.id, a.company, a.position
+		FROM email_cache e
+		LEFT JOIN LATERAL (
+			SELECT id, company, position, status FROM applications
+			WHERE user_id = e.user_id AND (id = e.application_id OR (e.application_id IS NULL AND email_id = e.id))
+			LIMIT 1
+		) a ON TRUE
+		WHERE e.user_id = $1 AND e.is_job_related AND e.triaged_at IS NULL
+			AND COALESCE(e.date, e.processed_at) >= $2
+		ORDER BY COALESCE(e.date, e.processed_at) DESC
+		LIMIT $3`,
+		userID, from, queueLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make(map[string]*Group, len(Actions))
+	q := &Queue{}
+	for _, action := range Actions {
+		g := &Group{Action: action, Items: []*Item{}}
+		groups[action] = g
+		q.Groups = append(q.Groups, g)
+	}
+	for rows.Next() {
+		it := &Item{}
+		var body, suggested sql.NullString
+		var relevance sql.NullFloat64
+		if err := rows.Scan(&it.EmailID, &it.Subject, &it.Sender, &it.Date, &it.Snippet, &body, &relevance, &suggested,
+			&it.Status, &it.ApplicationID, &it.Company, &it.Position); err != nil {
+			return nil, err
+		}
+		it.Suggested = suggested.String
+		if groups[it.Suggested] == nil {
+			e := Email{Body: body.String}
+			if it.Subject != nil {
+				e.Subject = *it.Subject
+			}
+			if it.Snippet != nil {
+				e.Snippet = *it.Snippet
+			}
+			if it.Status != nil {
+				e.Status = *it.Status
+			}
+			if relevance.Valid {
+				e.Relevance = &relevance.Float64
+			}
+			it.Suggested = Suggest(e)
+		}
+		groups[it.Suggested].Items = append(groups[it.Suggested].Items, it)
+		q.Total++
+	}
+	return q, rows.Err()
+}
+
+// Apply triages the user's emails with one action and returns how many were
+// triaged. Responding or scheduling adds a pending action to the matched
+// application so it shows up in its to-dos; ignoring also marks the email as
+// not job related. Emails already triaged are skipped.
+func (s *Service) Apply(ctx context.Context, userID string, emailIDs []string, action string) (int, error) {
+	if !valid(action) {
+		return 0, ErrInvalidAction
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE email_cache e SET triaged_action = $3, triaged_at = CURRENT_TIMESTAMP,
+			is_job_related = e.is_job_related AND $3 <> $4
+		WHERE e.id = ANY($2) AND e.user_id = $1 AND e.triaged_at IS NULL
+		RETURNING COALESCE(e.application_id, (
+			SELECT a.id FROM applications a WHERE a.user_id = e.user_id AND a.email_id = e.id LIMIT 1))`,
+		userID, pq.Array(emailIDs), action, ActionIgnore)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	var applicationIDs []string
+	for rows.Next() {
+		var id sql.NullString
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		n++
+		if id.Valid {
+			applicationIDs = append(applicationIDs, id.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, ErrNotFound
+	}
+
+	kind := ""
+	switch action {
+	case ActionRespond:
+		kind = actions.KindReplyEmail
+	case ActionSchedule:
+		kind = actions.KindScheduleInterview
+	}
+	if kind != "" {
+		for _, id := range applicationIDs {
+			if err := s.actions.Add(ctx, userID, id, kind); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func valid(action string) bool {
+	for _, a := range Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
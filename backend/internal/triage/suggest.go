@@ -0,0 +1,52 @@
+package triage
+
+import (
+	"regexp"
+
+	"github.com/jobtracker/backend/internal/actions"
+	"github.com/jobtracker/backend/internal/models"
+)
+
+// minRelevance is the relevance score below which a job-related email is
+// suggested to be ignored, such as job alerts and newsletters.
+const minRelevance = 0.5
+
+var (
+	schedulePattern = regexp.MustCompile(`(?i)\b(availability|available times?|schedule (a|an|your)|time slots?|book a time|find a time|when are you free)\b`)
+	respondPattern  = regexp.MustCompile(`(?i)(\?|\b(please (reply|respond|confirm|let us know|send)|let (me|us) know|get back to (me|us)|could you|would you|can you|next steps)\b)`)
+)
+
+// Email is what Suggest looks at to decide what an email needs.
+type Email struct {
+	Subject string
+	Snippet string
+	Body    string
+	// Status is the application status the email was classified as, or the
+	// linked application's status when the classification was not kept.
+	Status    string
+	Relevance *float64
+}
+
+// Suggest picks the action an email most likely needs: schedule for
+// interview requests and booking links, respond for offers and direct
+// questions, ignore for low-relevance mail and archive for everything else,
+// such as application confirmations and rejections.
+func Suggest(e Email) string {
+	if e.Relevance != nil && *e.Relevance < minRelevance {
+		return ActionIgnore
+	}
+	text := e.Subject + "\n" + e.Snippet + "\n" + e.Body
+	if len(actions.ExtractSchedulingLinks(text)) > 0 || schedulePattern.MatchString(text) {
+		return ActionSchedule
+	}
+	switch e.Status {
+	case models.StatusRejected, models.StatusApplied, models.StatusWithdrawn:
+		return ActionArchive
+	case models.StatusOffer:
+		return ActionRespond
+	}
+	if respondPattern.MatchString(e.Subject + "\n" + e.Snippet) {
+		return ActionRespond
+	}
+	return ActionArchive
+}
@@ -0,0 +1,167 @@
+// Package validation checks API input against `validate` struct tags
+// (github.com/go-playground/validator) and reports every rejected field as
+// an apperr.Validation error, for REST bodies and query strings as well as
+// the inputs services receive from GraphQL resolvers.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/jobtracker/backend/internal/apperr"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by the names clients send.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			if name := strings.Split(f.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+				return name
+			}
+		}
+		return f.Name
+	})
+	return v
+}
+
+// Struct validates v, which must be a struct or a pointer to one.
+func Struct(v any) error {
+	err := validate.Struct(v)
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	fields := make([]apperr.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, apperr.FieldError{Field: fieldPath(fe), Message: message(fe)})
+	}
+	return invalid(fields)
+}
+
+// BindJSON decodes the request body into v and validates it.
+func BindJSON(c *gin.Context, v any) error {
+	if err := json.NewDecoder(c.Request.Body).Decode(v); err != nil {
+		return decodeError(err)
+	}
+	return Struct(v)
+}
+
+// BindQuery decodes the query string into v, using `form` tags, and
+// validates it.
+func BindQuery(c *gin.Context, v any) error {
+	if err := c.ShouldBindQuery(v); err != nil {
+		return apperr.New(apperr.Validation, "invalid query string: "+err.Error())
+	}
+	return Struct(v)
+}
+
+// Field reports a single rejected field, for checks that tags cannot
+// express.
+func Field(field, msg string) error {
+	return invalid([]apperr.FieldError{{Field: field, Message: msg}})
+}
+
+func invalid(fields []apperr.FieldError) error {
+	msgs := make([]string, len(fields))
+	for i, f := range fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return &apperr.Error{
+		Code:    apperr.Validation,
+		Message: "invalid input: " + strings.Join(msgs, "; "),
+		Fields:  fields,
+	}
+}
+
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, io.EOF):
+		return apperr.New(apperr.Validation, "request body is required")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return Field(typeErr.Field, "must be a "+jsonType(typeErr.Type))
+	case errors.As(err, &syntaxErr):
+		return apperr.New(apperr.Validation, fmt.Sprintf("request body is not valid JSON (offset %d)", syntaxErr.Offset))
+	}
+	return apperr.New(apperr.Validation, "invalid request body")
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	}
+	return "number"
+}
+
+// fieldPath drops the top-level struct name from the namespace, leaving
+// e.g. "keywords[2]".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "timezone":
+		return "must be an IANA timezone such as Europe/Berlin"
+	case "datetime":
+		switch fe.Param() {
+		case "2006-01-02":
+			return "must be a date in YYYY-MM-DD format"
+		case time.RFC3339:
+			return "must be an RFC 3339 timestamp"
+		}
+		return "must be a timestamp in " + fe.Param() + " format"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		if fe.Kind() == reflect.Slice {
+			return "must have at most " + fe.Param() + " items"
+		}
+		return "must be at most " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "gtfield":
+		return "must be after " + strings.ToLower(fe.Param()[:1]) + fe.Param()[1:]
+	}
+	return "is invalid"
+}
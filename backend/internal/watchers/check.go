@@ -0,0 +1,84 @@
+package watchers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/postings"
+)
+
+// check fetches the watch's careers page, records the roles it lists, and
+// notifies the user about new roles matching the keywords when notify is set.
+// Failures are stored on the watch rather than returned so one broken page
+// does not stop the rest of the run.
+func (s *Service) check(ctx context.Context, w *Watch, notify bool) {
+	listings, err := s.postings.Listings(ctx, w.CareersURL)
+	if err != nil {
+		log.Printf("Failed to check careers page for watch %s: %v", w.ID, err)
+		s.recordCheck(ctx, w.ID, err)
+		return
+	}
+
+	var fresh []postings.Listing
+	for _, l := range listings {
+		if !matches(l.Title, w.Keywords) {
+			continue
+		}
+		res, err := s.db.ExecContext(ctx, `
+			INSERT INTO company_watch_roles (watch_id, url, title)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (watch_id, url) DO NOTHING`,
+			w.ID, l.URL, l.Title)
+		if err != nil {
+			log.Printf("Failed to record role for watch %s: %v", w.ID, err)
+			continue
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			fresh = append(fresh, l)
+		}
+	}
+	s.recordCheck(ctx, w.ID, nil)
+
+	if !notify || len(fresh) == 0 {
+		return
+	}
+	lines := make([]string, len(fresh))
+	for i, l := range fresh {
+		lines[i] = l.Title + " - " + l.URL
+	}
+	title := s.notifications.Localizer(ctx, w.UserID).N("watch.title", len(fresh), len(fresh), w.Company)
+	if err := s.notifications.Notify(ctx, w.UserID, notifications.KindCompanyWatch, title, strings.Join(lines, "\n")); err != nil {
+		log.Printf("Failed to notify user %s about watch %s: %v", w.UserID, w.ID, err)
+	}
+}
+
+func (s *Service) recordCheck(ctx context.Context, id string, checkErr error) {
+	var lastError *string
+	if checkErr != nil {
+		msg := checkErr.Error()
+		lastError = &msg
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE company_watches SET last_checked_at = CURRENT_TIMESTAMP, last_error = $2
+		WHERE id = $1`,
+		id, lastError); err != nil {
+		log.Printf("Failed to update watch %s: %v", id, err)
+	}
+}
+
+// matches reports whether the title contains any of the keywords. A watch
+// without keywords matches every role.
+func matches(title string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	title = strings.ToLower(title)
+	for _, k := range keywords {
+		if strings.Contains(title, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+// Package watchers lets users watch a company's careers page for new roles
+// that match their keywords, so a rejection can turn into a future
+// application.
+package watchers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/notifications"
+	"github.com/jobtracker/backend/internal/postings"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+var (
+	// ErrNotFound is returned when a watch does not exist or belongs to
+	// another user.
+	ErrNotFound = apperr.New(apperr.NotFound, "watch not found")
+	// ErrInvalidURL is returned when the careers URL is not http(s).
+	ErrInvalidURL = apperr.New(apperr.Validation, "careers URL must be an http(s) URL")
+)
+
+// checkInterval is how long a watch rests between checks.
+const checkInterval = 12 * time.Hour
+
+// Watch is a company careers page the user is watching.
+type Watch struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"-"`
+	Company       string     `json:"company"`
+	CareersURL    string     `json:"careersUrl"`
+	Keywords      []string   `json:"keywords"`
+	ApplicationID *string    `json:"applicationId"`
+	LastCheckedAt *time.Time `json:"lastCheckedAt"`
+	LastError     *string    `json:"lastError"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// WatchInput creates or updates a watch.
+type WatchInput struct {
+	Company       string   `json:"company" validate:"required,max=255"`
+	CareersURL    string   `json:"careersUrl" validate:"required,max=2048"`
+	Keywords      []string `json:"keywords" validate:"max=50,dive,max=100"`
+	ApplicationID *string  `json:"applicationId" validate:"omitempty,uuid"`
+}
+
+// Service manages company watches and checks them for new roles.
+type Service struct {
+	db            *sql.DB
+	postings      *postings.Service
+	notifications *notifications.Service
+}
+
+// NewService creates a watcher service.
+func NewService(db *sql.DB, postingService *postings.Service, notificationService *notifications.Service) *Service {
+	return &Service{db: db, postings: postingService, notifications: notificationService}
+}
+
+const watchColumns = `id, user_id, company, careers_url, keywords, application_id,
+	last_checked_at, last_error, created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWatch(row scanner) (*Watch, error) {
+	w := &Watch{}
+	err := row.Scan(&w.ID, &w.UserID, &w.Company, &w.CareersURL, pq.Array(&w.Keywords),
+		&w.ApplicationID, &w.LastCheckedAt, &w.LastError, &w.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return w, err
+}
+
+func normalize(in WatchInput) (WatchInput, error) {
+	if err := validation.Struct(in); err != nil {
+		return in, err
+	}
+	u, err := url.Parse(strings.TrimSpace(in.CareersURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return in, ErrInvalidURL
+	}
+	in.CareersURL = u.String()
+	in.Company = strings.TrimSpace(in.Company)
+
+	keywords := make([]string, 0, len(in.Keywords))
+	for _, k := range in.Keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	in.Keywords = keywords
+	return in, nil
+}
+
+// List returns the user's watches.
+func (s *Service) List(ctx context.Context, userID string) ([]*Watch, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+watchColumns+` FROM company_watches WHERE user_id = $1 ORDER BY company`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Watch
+	for rows.Next() {
+		w, err := scanWatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// Create starts watching a careers page. The page is checked straight away
+// so the roles already listed are recorded and only later ones notify.
+func (s *Service) Create(ctx context.Context, userID string, in WatchInput) (*Watch, error) {
+	in, err := normalize(in)
+	if err != nil {
+		return nil, err
+	}
+	w, err := scanWatch(s.db.QueryRowContext(ctx, `
+		INSERT INTO company_watches (user_id, company, careers_url, keywords, application_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+watchColumns,
+		userID, in.Company, in.CareersURL, pq.Array(in.Keywords), in.ApplicationID))
+	if err != nil {
+		return nil, err
+	}
+	s.check(ctx, w, false)
+	return w, nil
+}
+
+// Update changes a watch's company, URL or keywords.
+func (s *Service) Update(ctx context.Context, userID, id string, in WatchInput) (*Watch, error) {
+	in, err := normalize(in)
+	if err != nil {
+		return nil, err
+	}
+	return scanWatch(s.db.QueryRowContext(ctx, `
+		UPDATE company_watches SET company = $3, careers_url = $4, keywords = $5, application_id = $6
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+watchColumns,
+		id, userID, in.Company, in.CareersURL, pq.Array(in.Keywords), in.ApplicationID))
+}
+
+// Delete stops watching a company.
+func (s *Service) Delete(ctx context.Context, userID, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM company_watches WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CheckDue checks every watch that has not been checked recently. It is
+// intended to run from the scheduler.
+func (s *Service) CheckDue(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+watchColumns+` FROM company_watches
+		WHERE last_checked_at IS NULL OR last_checked_at < $1
+		ORDER BY last_checked_at NULLS FIRST
+		LIMIT 100`,
+		time.Now().Add(-checkInterval))
+	if err != nil {
+		return err
+	}
+	var due []*Watch
+	for rows.Next() {
+		w, err := scanWatch(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, w := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.check(ctx, w, true)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package workspaces
+
+import (
+	"encoding/csv"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jobtracker/backend/internal/analytics"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/auth"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Register mounts the report download route on the group, which must
+// already authenticate requests.
+func (s *Service) Register(rg *gin.RouterGroup) {
+	rg.GET("/:id/report.csv", s.ReportCSVHandler())
+}
+
+type reportQuery struct {
+	StartDate        *string `form:"startDate" validate:"omitempty,datetime=2006-01-02"`
+	EndDate          *string `form:"endDate" validate:"omitempty,datetime=2006-01-02"`
+	IncludeWithdrawn bool    `form:"includeWithdrawn"`
+}
+
+// ReportCSVHandler serves a workspace report as CSV for spreadsheets: the
+// aggregate funnel followed by one row per member.
+func (s *Service) ReportCSVHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var q reportQuery
+		if err := validation.BindQuery(c, &q); err != nil {
+			apperr.Respond(c, "Workspace report", err)
+			return
+		}
+		r := analytics.DateRange{StartDate: q.StartDate, EndDate: q.EndDate, IncludeWithdrawn: q.IncludeWithdrawn}
+		report, err := s.Report(c.Request.Context(), auth.UserID(c), c.Param("id"), r)
+		if err != nil {
+			apperr.Respond(c, "Workspace report", err)
+			return
+		}
+
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": "workspace-report-" + report.GeneratedAt.Format("2006-01-02") + ".csv",
+		}))
+		c.Status(http.StatusOK)
+
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"Stage", "Applications", "Rate"})
+		for _, f := range report.Funnel {
+			w.Write([]string{f.Stage, strconv.Itoa(f.Count), percent(f.Rate)})
+		}
+		w.Write(nil)
+		w.Write([]string{"Member", "Applications", "Responses", "Interviews", "Offers",
+			"Response Rate", "Interview Rate", "Offer Rate"})
+		for _, m := range report.PerMember {
+			w.Write([]string{cell(m.Label), strconv.Itoa(m.Applications), strconv.Itoa(m.Responses),
+				strconv.Itoa(m.Interviews), strconv.Itoa(m.Offers),
+				percent(m.ResponseRate), percent(m.InterviewRate), percent(m.OfferRate)})
+		}
+		w.Flush()
+	}
+}
+
+// cell keeps member names from being read as spreadsheet formulas.
+func cell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func percent(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', 1, 64) + "%"
+}
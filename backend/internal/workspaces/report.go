@@ -0,0 +1,129 @@
+package workspaces
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jobtracker/backend/internal/analytics"
+)
+
+// MemberReport is one member's line in a workspace report. Name is only
+// set for members who share their identity; the others are labelled
+// "Member 1", "Member 2", ... in an order that does not follow when they
+// joined, so the labels can't be matched to the roster.
+type MemberReport struct {
+	Label string  `json:"label"`
+	Name  *string `json:"name"`
+	*analytics.MemberTotals
+}
+
+// Report aggregates the statistics of a workspace's members for its
+// coaches.
+type Report struct {
+	WorkspaceID string                           `json:"workspaceId"`
+	Name        string                           `json:"name"`
+	GeneratedAt time.Time                        `json:"generatedAt"`
+	StartDate   *string                          `json:"startDate"`
+	EndDate     *string                          `json:"endDate"`
+	Members     int                              `json:"members"`
+	Funnel      []*analytics.FunnelStage         `json:"funnel"`
+	WeeklyTrend []*analytics.WeeklyTrend         `json:"weeklyTrend"`
+	Sources     []*analytics.SourceEffectiveness `json:"sources"`
+	// PerMember lists named members by name, then the anonymized ones by
+	// number of applications.
+	PerMember []*MemberReport `json:"perMember"`
+}
+
+type member struct {
+	userID string
+	name   *string
+}
+
+// Report builds the aggregate report of the workspace's members, which only
+// coaches may see. Coaches' own applications are not included.
+func (s *Service) Report(ctx context.Context, coachID, workspaceID string, r analytics.DateRange) (*Report, error) {
+	w, err := s.Get(ctx, coachID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if w.Role != RoleCoach {
+		return nil, ErrNotCoach
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.user_id, CASE WHEN m.share_identity THEN COALESCE(NULLIF(TRIM(u.name), ''), u.email) END
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = $1 AND m.role = 'member'
+		ORDER BY md5(m.workspace_id::text || m.user_id)`, w.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []member
+	for rows.Next() {
+		var m member
+		if err := rows.Scan(&m.userID, &m.name); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(members))
+	for i, m := range members {
+		ids[i] = m.userID
+	}
+	cohort, err := s.analytics.Cohort(ctx, ids, r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Report{
+		WorkspaceID: w.ID,
+		Name:        w.Name,
+		GeneratedAt: cohort.GeneratedAt,
+		StartDate:   r.StartDate,
+		EndDate:     r.EndDate,
+		Members:     len(members),
+		Funnel:      cohort.Funnel,
+		WeeklyTrend: cohort.WeeklyTrend,
+		Sources:     cohort.Sources,
+		PerMember:   make([]*MemberReport, len(members)),
+	}
+	for i, m := range members {
+		totals := cohort.Members[i]
+		if m.name == nil {
+			totals.UserID = ""
+		}
+		out.PerMember[i] = &MemberReport{Name: m.name, MemberTotals: totals}
+	}
+	sort.SliceStable(out.PerMember, func(i, j int) bool {
+		a, b := out.PerMember[i], out.PerMember[j]
+		if (a.Name == nil) != (b.Name == nil) {
+			return a.Name != nil
+		}
+		if a.Name != nil && *a.Name != *b.Name {
+			return *a.Name < *b.Name
+		}
+		if a.Applications != b.Applications {
+			return a.Applications > b.Applications
+		}
+		return a.ResponseRate > b.ResponseRate
+	})
+	n := 0
+	for _, m := range out.PerMember {
+		if m.Name != nil {
+			m.Label = *m.Name
+			continue
+		}
+		n++
+		m.Label = "Member " + strconv.Itoa(n)
+	}
+	return out, nil
+}
@@ -0,0 +1,240 @@
+// Package workspaces lets a career coach follow a group of job seekers.
+// The coach creates a workspace and hands out its join code; members who
+// join share their application statistics, and only their name if they
+// choose to. Coaches never see members' applications themselves, only the
+// aggregate reports built here from the analytics queries.
+package workspaces
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jobtracker/backend/internal/analytics"
+	"github.com/jobtracker/backend/internal/apperr"
+	"github.com/jobtracker/backend/internal/validation"
+)
+
+// Member roles.
+const (
+	RoleCoach  = "coach"
+	RoleMember = "member"
+)
+
+var (
+	// ErrNotFound is returned when a workspace does not exist or the user
+	// is not in it.
+	ErrNotFound = apperr.New(apperr.NotFound, "workspace not found")
+	// ErrInvalidCode is returned when joining with an unknown join code.
+	ErrInvalidCode = apperr.New(apperr.NotFound, "no workspace has that join code")
+	// ErrNotCoach is returned when a member asks for a coach-only view.
+	ErrNotCoach = apperr.New(apperr.Forbidden, "only the workspace's coaches can view its reports")
+	// ErrLastCoach is returned when the only coach of a workspace with
+	// members tries to leave it.
+	ErrLastCoach = apperr.New(apperr.Conflict, "the last coach cannot leave a workspace that still has members")
+)
+
+// Workspace is a workspace as seen by one of its members.
+type Workspace struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// JoinCode is only shown to coaches.
+	JoinCode      *string   `json:"joinCode"`
+	Role          string    `json:"role"`
+	ShareIdentity bool      `json:"shareIdentity"`
+	Members       int       `json:"members"` // excluding coaches
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// CreateInput creates a workspace.
+type CreateInput struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// Service manages workspaces and their reports.
+type Service struct {
+	db        *sql.DB
+	analytics *analytics.Service
+}
+
+// NewService creates a workspace service. Reports are computed through the
+// analytics service.
+func NewService(db *sql.DB, analyticsService *analytics.Service) *Service {
+	return &Service{db: db, analytics: analyticsService}
+}
+
+const query = `
+	SELECT w.id, w.name, w.join_code, m.role, m.share_identity,
+		(SELECT COUNT(*) FROM workspace_members o WHERE o.workspace_id = w.id AND o.role = 'member'), w.created_at
+	FROM workspaces w
+	JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = $1`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*Workspace, error) {
+	w := &Workspace{}
+	var code string
+	err := row.Scan(&w.ID, &w.Name, &code, &w.Role, &w.ShareIdentity, &w.Members, &w.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if w.Role == RoleCoach {
+		w.JoinCode = &code
+	}
+	return w, nil
+}
+
+// List returns the workspaces the user coaches or belongs to, oldest first.
+func (s *Service) List(ctx context.Context, userID string) ([]*Workspace, error) {
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY m.joined_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*Workspace{}
+	for rows.Next() {
+		w, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// Get returns one of the user's workspaces.
+func (s *Service) Get(ctx context.Context, userID, id string) (*Workspace, error) {
+	return scan(s.db.QueryRowContext(ctx, query+` WHERE w.id::text = $2`, userID, id))
+}
+
+// Create creates a workspace coached by the user.
+func (s *Service) Create(ctx context.Context, userID string, in CreateInput) (*Workspace, error) {
+	if err := validation.Struct(in); err != nil {
+		return nil, err
+	}
+	code, err := newJoinCode()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id string
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO workspaces (name, join_code, created_by) VALUES ($1, $2, $3) RETURNING id`,
+		strings.TrimSpace(in.Name), code, userID).Scan(&id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role, share_identity) VALUES ($1, $2, $3, TRUE)`,
+		id, userID, RoleCoach); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID, id)
+}
+
+// Join adds the user to the workspace with the join code. shareIdentity
+// lets the coaches see the user's name in reports instead of a numbered
+// pseudonym. Joining a workspace again only updates that choice.
+func (s *Service) Join(ctx context.Context, userID, code string, shareIdentity bool) (*Workspace, error) {
+	var id string
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM workspaces WHERE join_code = $1`,
+		strings.ToUpper(strings.TrimSpace(code))).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role, share_identity) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET share_identity = EXCLUDED.share_identity
+		WHERE workspace_members.role = 'member'`,
+		id, userID, RoleMember, shareIdentity); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID, id)
+}
+
+// SetShareIdentity changes whether the coaches see the user's name.
+func (s *Service) SetShareIdentity(ctx context.Context, userID, id string, share bool) (*Workspace, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE workspace_members SET share_identity = $3
+		WHERE workspace_id::text = $1 AND user_id = $2 AND role = 'member'`, id, userID, share)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNotFound
+	}
+	return s.Get(ctx, userID, id)
+}
+
+// Leave removes the user from a workspace. A workspace left by its last
+// member is deleted.
+func (s *Service) Leave(ctx context.Context, userID, id string) error {
+	w, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the workspace so two coaches leaving at once can't both pass the
+	// check.
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM workspaces WHERE id = $1 FOR UPDATE`, w.ID); err != nil {
+		return err
+	}
+	if w.Role == RoleCoach {
+		var coaches, members int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FILTER (WHERE role = 'coach'), COUNT(*) FILTER (WHERE role = 'member')
+			FROM workspace_members WHERE workspace_id = $1`,
+			w.ID).Scan(&coaches, &members); err != nil {
+			return err
+		}
+		if coaches <= 1 && members > 0 {
+			return ErrLastCoach
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, w.ID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM workspaces w WHERE w.id = $1
+		AND NOT EXISTS (SELECT 1 FROM workspace_members m WHERE m.workspace_id = w.id)`, w.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// newJoinCode returns a random code that is easy to read out and type.
+func newJoinCode() (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(buf), nil
+}
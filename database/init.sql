@@ -2,6 +2,8 @@
 
 -- Enable UUID extension
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+-- Trigram indexes for typeahead suggestions
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
 -- Applications table
 CREATE TABLE IF NOT EXISTS applications (
@@ -21,6 +23,38 @@ CREATE TABLE IF NOT EXISTS applications (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Applicant tracking system and candidate portal detected from emails
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS ats VARCHAR(50);
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS portal_url TEXT;
+
+-- Private notes are hidden from shared views
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS notes_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Snoozed applications are hidden from default views and reminders until then
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMP WITH TIME ZONE;
+
+-- Archived applications are hidden from default views for good
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+
+-- Text of the job posting, mined for the skills employers ask for
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS job_description TEXT;
+
+-- When the job posting stops taking applications, and the last closing
+-- reminder sent for it (hours before the deadline)
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS posting_deadline TIMESTAMP WITH TIME ZONE;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS posting_deadline_reminded_hours INTEGER;
+
+-- Why the user withdrew the application
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS withdrawal_reason TEXT;
+
+-- Free-form tags and user-defined fields ([{name, value}])
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '[]';
+
+-- The user's address the company writes to, e.g. a plus address such as
+-- me+google@gmail.com, detected from the recipients of its emails
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS alias VARCHAR(255);
+
 -- Processing jobs table for tracking agent processing
 CREATE TABLE IF NOT EXISTS processing_jobs (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -39,6 +73,25 @@ CREATE TABLE IF NOT EXISTS processing_jobs (
     completed_at TIMESTAMP WITH TIME ZONE
 );
 
+-- Set once the exported spreadsheet has been deleted by the retention purge
+ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS export_purged_at TIMESTAMP WITH TIME ZONE;
+
+-- Anomalous sync results held for an administrator: pending, approved or rejected
+ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS review_status VARCHAR(20);
+ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS anomaly_reasons TEXT[];
+
+-- Set when the user cancels the export; the pipeline stops at its next
+-- progress report
+ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS cancel_requested_at TIMESTAMP WITH TIME ZONE;
+
+-- What started the run: export (the default), sync, backfill or import
+ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'export';
+
+-- Where a Gmail import resumes, and Gmail's estimate of the messages in its
+-- range for progress
+ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS import_page_token TEXT;
+ALTER TABLE processing_jobs ADD COLUMN IF NOT EXISTS import_estimated_total INTEGER;
+
 -- Users table for OAuth
 CREATE TABLE IF NOT EXISTS users (
     id VARCHAR(255) PRIMARY KEY,
@@ -53,6 +106,90 @@ CREATE TABLE IF NOT EXISTS users (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Opt-in to anonymized instance benchmarks
+ALTER TABLE users ADD COLUMN IF NOT EXISTS benchmark_opt_in BOOLEAN DEFAULT FALSE;
+
+-- Opt-in Google Calendar sync for interviews (requires calendar.events scope)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS calendar_sync_enabled BOOLEAN DEFAULT FALSE;
+
+-- Display preferences: IANA timezone and BCP 47 locale
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(35) NOT NULL DEFAULT 'en-US';
+
+-- ISO 4217 currency that offers and salary estimates are compared in
+ALTER TABLE users ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Instance administrators, managed with jobtrackerctl
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Usage quota plan (see QUOTA_PLANS_PATH)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS plan VARCHAR(50) NOT NULL DEFAULT 'default';
+
+-- Email storage mode: full bodies, or minimal (extracted fields and a
+-- redacted snippet only)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_storage VARCHAR(16) NOT NULL DEFAULT 'full';
+
+-- Gmail push watch: when it lapses and the history ID it started from
+ALTER TABLE users ADD COLUMN IF NOT EXISTS gmail_watch_expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS gmail_history_id BIGINT;
+
+-- Set when Google rejects the refresh token or the user disconnects;
+-- cleared when the user re-links
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mailbox_disconnected_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mailbox_disconnect_reason TEXT;
+
+-- Set while the user has paused syncing, with when the pause ends (NULL
+-- until they resume)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mailbox_paused_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mailbox_paused_until TIMESTAMP WITH TIME ZONE;
+
+-- Rejection rule: what to do with a rejection's Gmail thread once recorded
+-- (off, archive, label), the label to apply, and when it was turned on
+ALTER TABLE users ADD COLUMN IF NOT EXISTS rejection_email_action VARCHAR(10) NOT NULL DEFAULT 'off';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS rejection_email_label VARCHAR(225);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS rejection_rule_enabled_at TIMESTAMP WITH TIME ZONE;
+
+-- Pre-draft thank-you notes with the LLM once interviews end
+ALTER TABLE users ADD COLUMN IF NOT EXISTS thank_you_drafts BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- API keys for the browser extension and other non-browser clients
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL, -- first characters of the key, for display
+    key_hash CHAR(64) UNIQUE NOT NULL, -- SHA-256 of the key
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Read-only share links; level decides what viewers see (share, coach)
+CREATE TABLE IF NOT EXISTS share_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    level VARCHAR(10) NOT NULL,
+    prefix VARCHAR(16) NOT NULL, -- first characters of the token, for display
+    token_hash CHAR(64) UNIQUE NOT NULL, -- SHA-256 of the token
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Read-only tokens subscribing calendar apps to the deadline feed
+CREATE TABLE IF NOT EXISTS feed_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL, -- first characters of the token, for display
+    token_hash CHAR(64) UNIQUE NOT NULL, -- SHA-256 of the token
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Email cache table to avoid re-processing
 CREATE TABLE IF NOT EXISTS email_cache (
     id VARCHAR(255) PRIMARY KEY, -- Gmail message ID
@@ -70,6 +207,595 @@ CREATE TABLE IF NOT EXISTS email_cache (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+-- Language detected by the agents service (ISO 639-1)
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS language VARCHAR(8);
+
+-- Set once the body was dropped and the snippet redacted for minimal storage
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS redacted_at TIMESTAMP WITH TIME ZONE;
+
+-- Inbox triage: the application status the email was classified as, the
+-- application it was matched to, the suggested action (respond, schedule,
+-- archive, ignore) and the action the user took
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS classified_status VARCHAR(50);
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS application_id UUID REFERENCES applications(id) ON DELETE SET NULL;
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS triage_action VARCHAR(20);
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS triaged_action VARCHAR(20);
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS triaged_at TIMESTAMP WITH TIME ZONE;
+
+-- Set once the rejection rule archived or labeled the email's thread
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS mailbox_rule_applied_at TIMESTAMP WITH TIME ZONE;
+
+-- Reconciliation against Gmail: when the email was last confirmed to still
+-- exist with the cached labels, and when it was found deleted or trashed
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS gmail_deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Set once the recipients were checked for the alias the user applied with
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS alias_checked_at TIMESTAMP WITH TIME ZONE;
+
+-- Set once the email was read for interview booking links
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS scheduling_checked_at TIMESTAMP WITH TIME ZONE;
+
+-- Set once the email was read for the ATS and candidate portal behind it
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS ats_checked_at TIMESTAMP WITH TIME ZONE;
+
+-- Set once the email was read for calendar invitations
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS invites_checked_at TIMESTAMP WITH TIME ZONE;
+
+-- Set once an email classified as an offer was read for its compensation
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS offer_extracted_at TIMESTAMP WITH TIME ZONE;
+
+-- Set once automation rules have looked at the classified email
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS automation_checked_at TIMESTAMP WITH TIME ZONE;
+
+-- Historical imports store email without classifying it: pending is set
+-- until it is classified, queued once the user released it in a batch
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS classify_pending_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS classify_queued_at TIMESTAMP WITH TIME ZONE;
+-- Failed classification attempts of an imported email; after three it is
+-- parked (failed set) and left out of later batches
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS classify_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE email_cache ADD COLUMN IF NOT EXISTS classify_failed_at TIMESTAMP WITH TIME ZONE;
+
+-- Interviews scheduled for applications
+CREATE TABLE IF NOT EXISTS interviews (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    title TEXT NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    location TEXT,
+    meeting_link TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled', -- scheduled, completed, cancelled
+    calendar_event_id VARCHAR(255), -- Google Calendar event ID
+    ical_uid TEXT, -- UID of the emailed invitation this interview was imported from
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Who the user met, for the thank-you note afterwards
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS interviewer_name VARCHAR(255);
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS interviewer_email VARCHAR(255);
+
+-- The user's self-assessment afterwards: a 1 (went poorly) to 5 (went well)
+-- rating and the topics they struggled with; prompted once per interview
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS self_rating SMALLINT CHECK (self_rating BETWEEN 1 AND 5);
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS struggled_topics TEXT[];
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS self_assessment_notes TEXT;
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS self_assessed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS self_assessment_prompted_at TIMESTAMP WITH TIME ZONE;
+
+-- The user's notes or a transcript of the interview, and the takeaways and
+-- follow-up action items the LLM pulled out of it
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS transcript TEXT;
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS transcript_takeaways TEXT[];
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS transcript_action_items TEXT[];
+ALTER TABLE interviews ADD COLUMN IF NOT EXISTS transcript_summarized_at TIMESTAMP WITH TIME ZONE;
+
+-- Interview loops: a named sequence of rounds for an application
+CREATE TABLE IF NOT EXISTS interview_loops (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS interview_rounds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    loop_id UUID NOT NULL REFERENCES interview_loops(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL, -- 1-based order within the loop
+    name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, scheduled, passed, failed, skipped
+    feedback TEXT,
+    interview_id UUID REFERENCES interviews(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (loop_id, position)
+);
+
+-- Questions asked in interviews, extracted from round feedback or added by
+-- hand, for prep before similar interviews
+CREATE TABLE IF NOT EXISTS interview_questions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    company VARCHAR(255) NOT NULL,
+    role TEXT NOT NULL,
+    round_type VARCHAR(30) NOT NULL DEFAULT 'other', -- recruiter_screen, technical, system_design, behavioral, hiring_manager, take_home, onsite, other
+    round_id UUID REFERENCES interview_rounds(id) ON DELETE SET NULL, -- round it was extracted from
+    application_id UUID REFERENCES applications(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Pending actions on applications (e.g. schedule an interview via a booking link)
+CREATE TABLE IF NOT EXISTS application_actions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    url TEXT,
+    provider VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, done, dismissed
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Thank-you note actions: the interview followed up on, who to thank and the draft
+ALTER TABLE application_actions ADD COLUMN IF NOT EXISTS interview_id UUID REFERENCES interviews(id) ON DELETE CASCADE;
+ALTER TABLE application_actions ADD COLUMN IF NOT EXISTS recipient_name VARCHAR(255);
+ALTER TABLE application_actions ADD COLUMN IF NOT EXISTS recipient_email VARCHAR(255);
+
+-- What to do, for free-form tasks (kind task)
+ALTER TABLE application_actions ADD COLUMN IF NOT EXISTS title VARCHAR(255);
+ALTER TABLE application_actions ADD COLUMN IF NOT EXISTS draft_subject TEXT;
+ALTER TABLE application_actions ADD COLUMN IF NOT EXISTS draft_body TEXT;
+
+-- Application events (status changes, follow-ups, interviews)
+CREATE TABLE IF NOT EXISTS application_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB,
+    occurred_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Append-only stream of every change to an application, maintained by
+-- trigger; writers tag changes through the jobtracker.event_* settings
+CREATE TABLE IF NOT EXISTS application_event_stream (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    seq INTEGER NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    actor VARCHAR(50) NOT NULL,
+    ref TEXT,
+    changes JSONB NOT NULL DEFAULT '{}',
+    details JSONB,
+    occurred_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (application_id, seq)
+);
+
+-- Client IP address of the request behind the change, as resolved through
+-- TRUSTED_PROXIES
+ALTER TABLE application_event_stream ADD COLUMN IF NOT EXISTS client_ip INET;
+
+-- Emails sent through the user's Gmail; a row reserves one of the user's
+-- GMAIL_SEND_DAILY_LIMIT daily sends before the email goes out
+CREATE TABLE IF NOT EXISTS email_sends (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    application_id UUID REFERENCES applications(id) ON DELETE SET NULL,
+    message_id VARCHAR(255), -- Gmail message ID, once sent
+    reserved_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Status history for time-in-stage metrics, maintained by trigger
+CREATE TABLE IF NOT EXISTS application_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Zapier-style REST hook subscriptions
+CREATE TABLE IF NOT EXISTS rest_hook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event VARCHAR(64) NOT NULL,
+    target_url TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Position of the REST hook dispatcher in application_status_history
+CREATE TABLE IF NOT EXISTS rest_hook_cursor (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    last_changed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Activity goals (e.g. 10 applications per week)
+CREATE TABLE IF NOT EXISTS goals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL,
+    metric VARCHAR(50) NOT NULL, -- applications, follow_ups, interviews
+    period VARCHAR(10) NOT NULL, -- day, week
+    target INTEGER NOT NULL CHECK (target > 0),
+    weekly_summary BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, metric, period)
+);
+
+-- In-app notifications
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Company careers pages watched for new roles
+CREATE TABLE IF NOT EXISTS company_watches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL,
+    company VARCHAR(255) NOT NULL,
+    careers_url TEXT NOT NULL,
+    keywords TEXT[] NOT NULL DEFAULT '{}',
+    application_id UUID REFERENCES applications(id) ON DELETE SET NULL,
+    last_checked_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Roles already seen on a watched careers page
+CREATE TABLE IF NOT EXISTS company_watch_roles (
+    watch_id UUID NOT NULL REFERENCES company_watches(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (watch_id, url)
+);
+
+-- Research dossier per company, shared by the user's applications to it
+CREATE TABLE IF NOT EXISTS company_notes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    company VARCHAR(255) NOT NULL, -- matched case-insensitively
+    interview_process TEXT,
+    culture TEXT,
+    contacts JSONB NOT NULL DEFAULT '[]', -- [{name, role, email, linkedin, notes}]
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Expected compensation from salary providers (median NULL = no data)
+CREATE TABLE IF NOT EXISTS application_salary_estimates (
+    application_id UUID PRIMARY KEY REFERENCES applications(id) ON DELETE CASCADE,
+    provider VARCHAR(50),
+    currency CHAR(3),
+    basis VARCHAR(10), -- base, total
+    low BIGINT,
+    median BIGINT,
+    high BIGINT,
+    sample_size INTEGER NOT NULL DEFAULT 0,
+    fetched_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Offers the user received
+CREATE TABLE IF NOT EXISTS application_offers (
+    application_id UUID PRIMARY KEY REFERENCES applications(id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    base_salary BIGINT NOT NULL,
+    bonus BIGINT,
+    equity BIGINT,
+    signing_bonus BIGINT,
+    notes TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- When the company needs an answer to the offer
+ALTER TABLE application_offers ADD COLUMN IF NOT EXISTS deadline TIMESTAMP WITH TIME ZONE;
+-- Latest deadline reminder sent, in hours before the deadline (168, 48, 24);
+-- cleared when the deadline moves
+ALTER TABLE application_offers ADD COLUMN IF NOT EXISTS deadline_reminded_hours INTEGER;
+-- Vesting schedule and proposed start date
+ALTER TABLE application_offers ADD COLUMN IF NOT EXISTS equity_schedule TEXT;
+ALTER TABLE application_offers ADD COLUMN IF NOT EXISTS start_date DATE;
+-- Offers read from an offer letter (source 'extracted') stay unconfirmed
+-- until the user checks them; entered offers are confirmed on save
+ALTER TABLE application_offers ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'manual';
+ALTER TABLE application_offers ADD COLUMN IF NOT EXISTS source_email_id VARCHAR(255);
+ALTER TABLE application_offers ADD COLUMN IF NOT EXISTS confirmed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Who referred the user for an application and how far the referral got
+CREATE TABLE IF NOT EXISTS application_referrals (
+    application_id UUID PRIMARY KEY REFERENCES applications(id) ON DELETE CASCADE,
+    referrer_name VARCHAR(255) NOT NULL,
+    referrer_contact VARCHAR(255),
+    state VARCHAR(20) NOT NULL DEFAULT 'requested', -- requested, confirmed, submitted
+    asked_date DATE,
+    confirmed_date DATE,
+    notes TEXT,
+    thanked_at TIMESTAMP WITH TIME ZONE,
+    thank_reminded_status VARCHAR(50), -- application status last reminded or thanked for
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Uploaded resume versions with text and structure parsed from the file
+CREATE TABLE IF NOT EXISTS resumes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label VARCHAR(255) NOT NULL, -- groups versions, e.g. "Backend"
+    version INTEGER NOT NULL,
+    filename TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    storage_path TEXT NOT NULL,
+    text_content TEXT,
+    skills TEXT[] NOT NULL DEFAULT '{}',
+    experience JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, label, version)
+);
+
+-- Malware scan of the stored file: clean, infected (quarantined) or unscanned
+ALTER TABLE resumes ADD COLUMN IF NOT EXISTS scan_status VARCHAR(20) NOT NULL DEFAULT 'unscanned';
+ALTER TABLE resumes ADD COLUMN IF NOT EXISTS scan_signature TEXT;
+ALTER TABLE resumes ADD COLUMN IF NOT EXISTS scanned_at TIMESTAMP WITH TIME ZONE;
+
+-- Resume version sent with each application
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS resume_id UUID REFERENCES resumes(id) ON DELETE SET NULL;
+
+-- Reusable defaults for applications to roles the user applies for repeatedly
+CREATE TABLE IF NOT EXISTS application_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    position TEXT NOT NULL,
+    source VARCHAR(255),
+    location VARCHAR(255),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    custom_fields JSONB NOT NULL DEFAULT '[]',
+    resume_id UUID REFERENCES resumes(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-user overrides of retention rules (days = 0 keeps data forever)
+CREATE TABLE IF NOT EXISTS retention_overrides (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rule VARCHAR(50) NOT NULL, -- email_bodies, rejected_applications, exports
+    days INTEGER NOT NULL CHECK (days >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, rule)
+);
+
+-- Estimated LLM spend per request, metered against usage quotas
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    operation VARCHAR(50) NOT NULL, -- classify_email, extract_application, draft_email, summarize_application
+    input_tokens INTEGER NOT NULL,
+    output_tokens INTEGER NOT NULL,
+    cost_cents NUMERIC(12,4) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- LLM-written summary of each application's history, regenerated once the
+-- event stream or linked emails move past what it was written from
+CREATE TABLE IF NOT EXISTS application_summaries (
+    application_id UUID PRIMARY KEY REFERENCES applications(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    event_seq INTEGER NOT NULL, -- latest application_event_stream seq summarized
+    last_email_at TIMESTAMP WITH TIME ZONE, -- date of the latest email summarized
+    generated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- When the latest interview transcript summary included was made
+ALTER TABLE application_summaries ADD COLUMN IF NOT EXISTS last_interview_summary_at TIMESTAMP WITH TIME ZONE;
+
+-- Each user's board as of each day, in their timezone, for board diffs
+CREATE TABLE IF NOT EXISTS board_snapshots (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    snapshot_date DATE NOT NULL,
+    cards JSONB NOT NULL, -- [{id, company, position, status}]
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, snapshot_date)
+);
+
+-- Request limits set by an administrator for a user or one of their API
+-- keys, overriding RATE_LIMIT_REQUESTS_PER_MINUTE (0 is unlimited)
+CREATE TABLE IF NOT EXISTS rate_limits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE CASCADE, -- NULL for all of the user's requests
+    route VARCHAR(50) NOT NULL DEFAULT '', -- graphql, extension, mobile, ...; '' for every route
+    requests_per_minute INTEGER NOT NULL CHECK (requests_per_minute >= 0),
+    note TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_by VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Outcome of each run of the Gmail reconciliation job, for the admin report
+CREATE TABLE IF NOT EXISTS gmail_reconcile_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    checked INTEGER NOT NULL DEFAULT 0,
+    deleted INTEGER NOT NULL DEFAULT 0, -- flagged gmail_deleted_at
+    relabeled INTEGER NOT NULL DEFAULT 0, -- cached labels repaired
+    failed INTEGER NOT NULL DEFAULT 0
+);
+
+-- Cold outreach to contacts at companies, marked replied when a reply is
+-- synced and linked to the application it led to
+CREATE TABLE IF NOT EXISTS outreach (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contact_name VARCHAR(255),
+    contact_email VARCHAR(255) NOT NULL, -- lowercased
+    company VARCHAR(255) NOT NULL,
+    position TEXT,
+    sent_date DATE NOT NULL,
+    reply_status VARCHAR(20) NOT NULL DEFAULT 'awaiting', -- awaiting, replied, no_reply
+    replied_at TIMESTAMP WITH TIME ZONE,
+    reply_email_id VARCHAR(255), -- Gmail message ID of the reply
+    application_id UUID REFERENCES applications(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Shared workspaces linking job seekers with a coach. Members join with the
+-- workspace's code and choose whether the coach sees their name in reports
+CREATE TABLE IF NOT EXISTS workspaces (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    join_code VARCHAR(32) NOT NULL UNIQUE,
+    created_by VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member', -- coach, member
+    share_identity BOOLEAN NOT NULL DEFAULT FALSE, -- named in coach reports
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+-- Email classification A/B experiments. Production classifies with the
+-- control variant; a sample of emails is also classified with the
+-- challenger, recording both results as a trial
+CREATE TABLE IF NOT EXISTS classification_experiments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    control_variant VARCHAR(255) NOT NULL,
+    challenger_variant VARCHAR(255) NOT NULL,
+    sample_percent INTEGER NOT NULL CHECK (sample_percent BETWEEN 1 AND 100),
+    created_by VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    stopped_at TIMESTAMP WITH TIME ZONE,
+    -- Set when one of the variants became the production variant
+    promoted_variant VARCHAR(255),
+    promoted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS classification_trials (
+    experiment_id UUID NOT NULL REFERENCES classification_experiments(id) ON DELETE CASCADE,
+    email_id VARCHAR(255) NOT NULL, -- Gmail message ID
+    user_id VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+    control_job_related BOOLEAN NOT NULL,
+    control_status VARCHAR(50),
+    control_confidence REAL,
+    challenger_job_related BOOLEAN, -- NULL when the challenger failed
+    challenger_status VARCHAR(50),
+    challenger_confidence REAL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (experiment_id, email_id)
+);
+
+-- Runs of the data integrity check, with per-check counts as
+-- [{check, found, repairable, repaired}]
+CREATE TABLE IF NOT EXISTS integrity_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    repair BOOLEAN NOT NULL DEFAULT FALSE,
+    triggered_by VARCHAR(255), -- administrator; NULL for scheduled runs
+    found INTEGER NOT NULL DEFAULT 0,
+    repaired INTEGER NOT NULL DEFAULT 0,
+    checks JSONB NOT NULL DEFAULT '[]'
+);
+
+-- Issues found by an integrity check run (up to 500 per check)
+CREATE TABLE IF NOT EXISTS integrity_issues (
+    run_id UUID NOT NULL REFERENCES integrity_runs(id) ON DELETE CASCADE,
+    check_name VARCHAR(50) NOT NULL, -- orphaned_email, missing_company, stale_interview, status_history_gap
+    user_id VARCHAR(255) NOT NULL,
+    subject_id VARCHAR(255) NOT NULL, -- email, application or interview ID
+    detail TEXT NOT NULL,
+    repairable BOOLEAN NOT NULL DEFAULT FALSE,
+    repaired BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (run_id, check_name, subject_id)
+);
+
+-- User-defined automation rules: when an email is classified with, or an
+-- application enters, a status, run actions now or after a delay
+CREATE TABLE IF NOT EXISTS automation_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(30) NOT NULL, -- email_classified, status_changed
+    status VARCHAR(50), -- status the rule fires on; NULL for any
+    actions JSONB NOT NULL, -- [{type, status, tag, title, delayDays}]
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE, -- record what would be done without doing it
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Actions automation rules scheduled, one per rule, trigger and action
+CREATE TABLE IF NOT EXISTS automation_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rule_id UUID NOT NULL REFERENCES automation_rules(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    action_index SMALLINT NOT NULL,
+    action JSONB NOT NULL,
+    trigger_ref TEXT NOT NULL, -- email:<id> or event:<application_event_stream id>
+    trigger TEXT NOT NULL, -- e.g. Status changed to Rejected
+    expect_status VARCHAR(50) NOT NULL, -- application status when scheduled
+    status VARCHAR(20) NOT NULL, -- scheduled, done, skipped, failed, dry_run
+    detail TEXT,
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ran_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (rule_id, trigger_ref, action_index)
+);
+
+-- Position of the automation rule evaluator in application_event_stream
+CREATE TABLE IF NOT EXISTS automation_cursor (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    last_occurred_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Company logos cached by the logo proxy, by domain; a row without a
+-- content type records a domain that had none when last tried
+CREATE TABLE IF NOT EXISTS company_logos (
+    domain VARCHAR(255) PRIMARY KEY,
+    content_type VARCHAR(100),
+    size_bytes INTEGER,
+    source TEXT, -- the URL the logo was fetched from
+    fetched_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Quota warnings sent, once per metric, threshold and period (the month,
+-- e.g. 2026-10, or 'total'); removed when usage falls back below the
+-- threshold so it warns again
+CREATE TABLE IF NOT EXISTS quota_warnings (
+    user_id VARCHAR(255) NOT NULL,
+    metric VARCHAR(50) NOT NULL,
+    threshold INTEGER NOT NULL,
+    period VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, metric, threshold, period),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
 -- Indexes for performance
 CREATE INDEX IF NOT EXISTS idx_applications_user_id ON applications(user_id);
 CREATE INDEX IF NOT EXISTS idx_applications_company ON applications(company);
@@ -78,8 +804,69 @@ CREATE INDEX IF NOT EXISTS idx_applications_status ON applications(status);
 CREATE INDEX IF NOT EXISTS idx_processing_jobs_user_id ON processing_jobs(user_id);
 CREATE INDEX IF NOT EXISTS idx_processing_jobs_status ON processing_jobs(status);
 CREATE INDEX IF NOT EXISTS idx_email_cache_user_id ON email_cache(user_id);
+CREATE INDEX IF NOT EXISTS idx_resumes_user_id ON resumes(user_id);
 CREATE INDEX IF NOT EXISTS idx_email_cache_date ON email_cache(date);
 CREATE INDEX IF NOT EXISTS idx_email_cache_is_job_related ON email_cache(is_job_related);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_interviews_user_id ON interviews(user_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_interviews_application_id ON interviews(application_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_interviews_ical_uid ON interviews(user_id, ical_uid) WHERE ical_uid IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_application_actions_application ON application_actions(application_id, status);
+CREATE INDEX IF NOT EXISTS idx_application_actions_user ON application_actions(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_application_events_application_id ON application_events(application_id);
+CREATE INDEX IF NOT EXISTS idx_application_events_user_type ON application_events(user_id, event_type, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_status_history_application ON application_status_history(application_id, changed_at);
+CREATE INDEX IF NOT EXISTS idx_status_history_user_id ON application_status_history(user_id);
+CREATE INDEX IF NOT EXISTS idx_status_history_changed_at ON application_status_history(changed_at);
+CREATE INDEX IF NOT EXISTS idx_rest_hook_subscriptions_user_event ON rest_hook_subscriptions(user_id, event);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_company_watches_user_id ON company_watches(user_id);
+CREATE INDEX IF NOT EXISTS idx_company_watches_last_checked ON company_watches(last_checked_at);
+CREATE INDEX IF NOT EXISTS idx_salary_estimates_fetched_at ON application_salary_estimates(fetched_at);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_user_created ON llm_usage(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_event_stream_user ON application_event_stream(user_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_application_offers_deadline ON application_offers(deadline) WHERE deadline IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_applications_snoozed_until ON applications(snoozed_until) WHERE snoozed_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_untriaged ON email_cache(user_id, date) WHERE is_job_related AND triaged_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_company_notes_user_company ON company_notes(user_id, LOWER(company));
+CREATE INDEX IF NOT EXISTS idx_interview_loops_user_id ON interview_loops(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_interview_loops_application_id ON interview_loops(application_id);
+CREATE INDEX IF NOT EXISTS idx_application_actions_interview_id ON application_actions(interview_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_application_templates_user_name ON application_templates(user_id, LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_applications_tags ON applications USING GIN(tags);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rate_limits_subject ON rate_limits(user_id, COALESCE(api_key_id::text, ''), route);
+CREATE INDEX IF NOT EXISTS idx_outreach_user_sent_date ON outreach(user_id, sent_date);
+CREATE INDEX IF NOT EXISTS idx_outreach_awaiting ON outreach(user_id) WHERE reply_status = 'awaiting';
+CREATE INDEX IF NOT EXISTS idx_interview_questions_user_id ON interview_questions(user_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_interview_questions_round ON interview_questions(round_id, LOWER(question));
+CREATE INDEX IF NOT EXISTS idx_email_cache_verified_at ON email_cache(verified_at NULLS FIRST) WHERE gmail_deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_gmail_deleted_at ON email_cache(gmail_deleted_at) WHERE gmail_deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_gmail_reconcile_runs_started_at ON gmail_reconcile_runs(started_at);
+CREATE INDEX IF NOT EXISTS idx_applications_user_alias ON applications(user_id, alias) WHERE alias IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_alias_unchecked ON email_cache(date) WHERE alias_checked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_processing_jobs_user_created_at ON processing_jobs(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_email_cache_user_job_date ON email_cache(user_id, date) WHERE is_job_related;
+CREATE INDEX IF NOT EXISTS idx_interviews_self_assessment_unprompted ON interviews(ends_at) WHERE self_assessment_prompted_at IS NULL AND self_assessed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_offer_unextracted ON email_cache(date) WHERE classified_status = 'Offer' AND offer_extracted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_applications_company_trgm ON applications USING GIN(LOWER(company) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_applications_position_trgm ON applications USING GIN(LOWER(position) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user ON workspace_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_applications_posting_deadline ON applications(posting_deadline) WHERE posting_deadline IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_classification_experiments_running ON classification_experiments((TRUE)) WHERE stopped_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_integrity_runs_started_at ON integrity_runs(started_at);
+CREATE INDEX IF NOT EXISTS idx_share_links_user_id ON share_links(user_id);
+CREATE INDEX IF NOT EXISTS idx_feed_tokens_user_id ON feed_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_email_sends_user_reserved ON email_sends(user_id, reserved_at);
+CREATE INDEX IF NOT EXISTS idx_automation_rules_user_id ON automation_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_automation_runs_due ON automation_runs(due_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_automation_runs_user ON automation_runs(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_event_stream_occurred_at ON application_event_stream(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_email_cache_automation_unchecked ON email_cache(date) WHERE automation_checked_at IS NULL AND classified_status IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_classify_pending ON email_cache(user_id, date) WHERE classify_pending_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_classify_queued ON email_cache(classify_queued_at) WHERE classify_queued_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_scheduling_unchecked ON email_cache(date) WHERE scheduling_checked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_ats_unchecked ON email_cache(user_id, date) WHERE ats_checked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_email_cache_invites_unchecked ON email_cache(user_id, date) WHERE invites_checked_at IS NULL;
 
 -- Trigger to update updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -101,3 +888,87 @@ CREATE OR REPLACE TRIGGER update_processing_jobs_updated_at
 CREATE OR REPLACE TRIGGER update_users_updated_at 
     BEFORE UPDATE ON users 
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_interviews_updated_at 
+    BEFORE UPDATE ON interviews 
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_automation_rules_updated_at 
+    BEFORE UPDATE ON automation_rules 
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Record every status an application enters
+CREATE OR REPLACE FUNCTION record_application_status()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.status IS DISTINCT FROM OLD.status THEN
+        INSERT INTO application_status_history (application_id, user_id, status)
+        VALUES (NEW.id, NEW.user_id, NEW.status);
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE TRIGGER record_applications_status
+    AFTER INSERT OR UPDATE OF status ON applications
+    FOR EACH ROW EXECUTE FUNCTION record_application_status();
+
+-- Append every application insert and change to its event stream. Inserts
+-- carry the full row and updates the changed columns.
+CREATE OR REPLACE FUNCTION record_application_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    changes JSONB;
+    kind TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changes := to_jsonb(NEW) - 'id' - 'user_id' - 'created_at' - 'updated_at';
+        kind := 'created';
+    ELSE
+        SELECT COALESCE(jsonb_object_agg(n.key, n.value), '{}') INTO changes
+        FROM jsonb_each(to_jsonb(NEW)) n
+        WHERE n.key NOT IN ('updated_at') AND n.value IS DISTINCT FROM to_jsonb(OLD) -> n.key;
+        IF changes = '{}' THEN
+            RETURN NEW;
+        END IF;
+        kind := COALESCE(NULLIF(current_setting('jobtracker.event_type', true), ''), 'edited');
+    END IF;
+
+    INSERT INTO application_event_stream (application_id, user_id, seq, event_type, actor, ref, changes, client_ip)
+    SELECT NEW.id, NEW.user_id, COALESCE(MAX(seq), 0) + 1, kind,
+        COALESCE(NULLIF(current_setting('jobtracker.event_actor', true), ''), 'unknown'),
+        NULLIF(current_setting('jobtracker.event_ref', true), ''), changes,
+        NULLIF(current_setting('jobtracker.event_client_ip', true), '')::inet
+    FROM application_event_stream WHERE application_id = NEW.id;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE TRIGGER record_applications_event
+    AFTER INSERT OR UPDATE ON applications
+    FOR EACH ROW EXECUTE FUNCTION record_application_event();
+
+-- Event stream rows are never rewritten
+CREATE OR REPLACE FUNCTION reject_event_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'application_event_stream is append-only';
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE TRIGGER application_event_stream_append_only
+    BEFORE UPDATE ON application_event_stream
+    FOR EACH ROW EXECUTE FUNCTION reject_event_update();
+
+-- Start the stream of applications created before it existed with a snapshot
+INSERT INTO application_event_stream (application_id, user_id, seq, event_type, actor, changes, occurred_at)
+SELECT a.id, a.user_id, 1, 'created', 'backfill',
+    to_jsonb(a) - 'id' - 'user_id' - 'created_at' - 'updated_at', a.created_at
+FROM applications a
+WHERE NOT EXISTS (SELECT 1 FROM application_event_stream e WHERE e.application_id = a.id);
+
+-- Seed history for applications created before the trigger existed
+INSERT INTO application_status_history (application_id, user_id, status, changed_at)
+SELECT a.id, a.user_id, a.status, a.created_at
+FROM applications a
+WHERE NOT EXISTS (SELECT 1 FROM application_status_history h WHERE h.application_id = a.id);
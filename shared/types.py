@@ -78,6 +78,7 @@ class EmailData(BaseModel):
     body: str
     snippet: str
     labels: List[str]
+    language: Optional[str] = None  # ISO 639-1 code detected by the agents service
 
 class AgentResponse(BaseModel):
     success: bool
@@ -10,10 +10,163 @@ export interface Application {
   jobId?: string;
   statusLink?: string;
   notes?: string;
+  ats?: string;
+  portalUrl?: string;
+  quickLinks: QuickLink[];
+  compensation: Compensation;
+  resume?: Resume;
+  snoozedUntil?: string; // hidden from default views and reminders until then
+  companyNotes?: CompanyNotes; // shared by all applications to the company
+  referral?: Referral;
+  jobDescription?: string;
+  postingDeadline?: string; // when the posting stops taking applications
+  withdrawalReason?: string;
+  tags: string[];
+  customFields: CustomField[];
   createdAt: string;
   updatedAt: string;
 }
 
+// CustomField is a user-defined field on an application
+export interface CustomField {
+  name: string;
+  value: string;
+}
+
+// ApplicationTemplate holds defaults for applications to a repeat role
+export interface ApplicationTemplate {
+  id: string;
+  name: string;
+  position: string;
+  source?: string;
+  location?: string;
+  tags: string[];
+  customFields: CustomField[];
+  resume?: Resume;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export type ReferralState = 'requested' | 'confirmed' | 'submitted';
+
+// Referral records who referred the user and how far the referral got
+export interface Referral {
+  referrerName: string;
+  referrerContact?: string;
+  state: ReferralState;
+  askedDate?: string;
+  confirmedDate?: string;
+  notes?: string;
+  thankedAt?: string;
+  updatedAt: string;
+}
+
+// CompanyNotes is the user's research dossier on a company
+export interface CompanyNotes {
+  company: string;
+  interviewProcess?: string;
+  culture?: string;
+  contacts: CompanyContact[];
+  notes?: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface CompanyContact {
+  name: string;
+  role?: string;
+  email?: string;
+  linkedin?: string;
+  notes?: string;
+}
+
+export interface QuickLink {
+  label: string;
+  url: string;
+}
+
+// Expected annual compensation from a salary data provider
+export interface SalaryRange {
+  provider: string;
+  currency: string;
+  basis: "base" | "total";
+  low: number; // 25th percentile
+  median: number;
+  high: number; // 75th percentile
+  sampleSize: number;
+  fetchedAt: string;
+}
+
+export interface Offer {
+  currency: string;
+  baseSalary: number;
+  bonus?: number;
+  equity?: number; // annualized
+  signingBonus?: number;
+  annualTotal: number;
+  notes?: string;
+  deadline?: string;
+  equitySchedule?: string; // e.g. "4 years, 1 year cliff"
+  startDate?: string; // YYYY-MM-DD
+  source: 'manual' | 'extracted';
+  // Read from an offer letter and not yet confirmed by the user
+  needsConfirmation: boolean;
+  updatedAt: string;
+}
+
+export interface Compensation {
+  expected?: SalaryRange;
+  offer?: Offer;
+  normalized?: NormalizedCompensation; // in the user's preferred currency
+  vsMedian?: number; // offer relative to expected median, 0.1 = 10% above
+}
+
+export interface NormalizedCompensation {
+  currency: string;
+  expectedLow?: number;
+  expectedMedian?: number;
+  expectedHigh?: number;
+  offerBase?: number;
+  offerAnnualTotal?: number;
+}
+
+// Offers side by side in one currency
+export interface ComparedOffer {
+  applicationId: string;
+  company: string;
+  position: string;
+  originalCurrency: string;
+  baseSalary: number;
+  annualTotal: number;
+  signingBonus?: number;
+  vsMedian?: number;
+}
+
+export interface OfferComparison {
+  currency: string;
+  offers: ComparedOffer[];
+}
+
+// Uploaded resume version with parsed details
+export interface Resume {
+  id: string;
+  label: string; // groups versions, e.g. "Backend"
+  version: number;
+  filename: string;
+  contentType: string;
+  size: number;
+  skills: string[];
+  experience: ResumeExperience[];
+  createdAt: string;
+}
+
+export interface ResumeExperience {
+  title: string;
+  company?: string;
+  startDate?: string;
+  endDate?: string; // "Present" for a current role
+}
+
 // ApplicationInput for creating/updating applications
 export interface ApplicationInput {
   company: string;
@@ -25,6 +178,10 @@ export interface ApplicationInput {
   jobId?: string;
   statusLink?: string;
   notes?: string;
+  jobDescription?: string;
+  postingDeadline?: string;
+  tags?: string[];
+  customFields?: CustomField[];
 }
 
 // Application status enum
@@ -36,7 +193,8 @@ export enum ApplicationStatus {
   OFFER = "Offer",
   REJECTED = "Rejected",
   WITHDRAWN = "Withdrawn",
-  ACCEPTED = "Accepted"
+  ACCEPTED = "Accepted",
+  SAVED = "Saved" // not applied yet
 }
 
 // Processing request
@@ -77,6 +235,46 @@ export enum ProcessingStage {
   ERROR = "error"
 }
 
+// Source effectiveness analytics
+export type ApplicationChannel = "referral" | "cold_apply" | "recruiter_outreach";
+
+export interface SourceEffectiveness {
+  channel: ApplicationChannel;
+  source?: string;
+  applications: number;
+  responses: number;
+  interviews: number;
+  offers: number;
+  rejections: number;
+  responseRate: number; // 0-1
+  interviewRate: number; // 0-1
+  offerRate: number; // 0-1
+}
+
+export interface SourceAnalytics {
+  byChannel: SourceEffectiveness[];
+  bySource: SourceEffectiveness[];
+  byReferralState: SourceEffectiveness[]; // source holds the referral state
+}
+
+// Skill frequency across job descriptions
+export interface KeywordCount {
+  keyword: string;
+  applications: number;
+  share: number; // 0-1, of applications with a job description
+  interviews: number;
+  rejections: number;
+  interviewRate: number; // 0-1
+  rejectionRate: number; // 0-1
+  onResume: boolean;
+}
+
+export interface KeywordFrequency {
+  analyzed: number;
+  keywords: KeywordCount[];
+  filteredOn: KeywordCount[];
+}
+
 // Email data for agents
 export interface EmailData {
   id: string;
@@ -87,6 +285,33 @@ export interface EmailData {
   body: string;
   snippet: string;
   labels: string[];
+  language?: string; // ISO 639-1 code detected by the agents service
+}
+
+// Codes in GraphQL errors[].extensions.code and REST error bodies
+export enum ErrorCode {
+  NOT_FOUND = "NOT_FOUND",
+  CONFLICT = "CONFLICT",
+  RATE_LIMITED = "RATE_LIMITED",
+  UPSTREAM_GMAIL = "UPSTREAM_GMAIL",
+  UPSTREAM_LLM = "UPSTREAM_LLM",
+  VALIDATION = "VALIDATION",
+  UNAUTHENTICATED = "UNAUTHENTICATED",
+  FORBIDDEN = "FORBIDDEN",
+  UNAVAILABLE = "UNAVAILABLE",
+  INTERNAL = "INTERNAL"
+}
+
+// REST error body; GraphQL errors carry code and fields in extensions
+export interface ApiError {
+  error: string;
+  code: ErrorCode;
+  fields?: FieldError[]; // set for VALIDATION errors
+}
+
+export interface FieldError {
+  field: string; // JSON name, e.g. "appliedDate" or "keywords[2]"
+  message: string;
 }
 
 // Agent response types